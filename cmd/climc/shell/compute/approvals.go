@@ -0,0 +1,205 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type ApprovalPolicyListOptions struct {
+		options.BaseListOptions
+		ResourceType []string `help:"filter by resource type" choices:"server|disk"`
+	}
+	R(&ApprovalPolicyListOptions{}, "approval-policy-list", "List approval policies", func(s *mcclient.ClientSession, args *ApprovalPolicyListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.ApprovalPolicies.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.ApprovalPolicies.GetColumns(s))
+		return nil
+	})
+
+	type ApprovalPolicyShowOptions struct {
+		ID string `help:"ID or Name of approval policy"`
+	}
+	R(&ApprovalPolicyShowOptions{}, "approval-policy-show", "Show details of an approval policy", func(s *mcclient.ClientSession, args *ApprovalPolicyShowOptions) error {
+		result, err := modules.ApprovalPolicies.GetById(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type ApprovalPolicyCreateOptions struct {
+		NAME           string   `help:"Name of approval policy"`
+		Desc           string   `help:"Description" json:"description"`
+		ResourceType   string   `help:"resource type the policy applies to" choices:"server|disk" required:"true"`
+		Action         []string `help:"actions that need approval, all actions if not specified" choices:"create|change-config|delete" json:"actions"`
+		Project        []string `help:"projects the policy applies to, all projects if not specified" json:"projects"`
+		MinVcpuCount   int      `help:"approval is required when requested vcpu count reaches this value"`
+		MinVmemSizeMb  int      `help:"approval is required when requested memory in MB reaches this value"`
+		MinDiskSizeMb  int      `help:"approval is required when requested disk size in MB reaches this value"`
+		MinCount       int      `help:"approval is required when requested resource count reaches this value"`
+		MinMonthlyCost float64  `help:"approval is required when estimated monthly cost reaches this value"`
+		Approver       []string `help:"ID or name of approvers" required:"true" json:"approvers"`
+		ExpireHours    int      `help:"hours before a pending request expires, default 72"`
+	}
+	R(&ApprovalPolicyCreateOptions{}, "approval-policy-create", "Create an approval policy", func(s *mcclient.ClientSession, args *ApprovalPolicyCreateOptions) error {
+		params := jsonutils.Marshal(args)
+		result, err := modules.ApprovalPolicies.Create(s, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type ApprovalPolicyUpdateOptions struct {
+		ID             string   `help:"ID or Name of approval policy" json:"-"`
+		Name           string   `help:"New name of approval policy"`
+		Desc           string   `help:"Description" json:"description"`
+		Action         []string `help:"actions that need approval" choices:"create|change-config|delete" json:"actions"`
+		Project        []string `help:"projects the policy applies to" json:"projects"`
+		MinVcpuCount   *int     `help:"vcpu count threshold"`
+		MinVmemSizeMb  *int     `help:"memory size threshold in MB"`
+		MinDiskSizeMb  *int     `help:"disk size threshold in MB"`
+		MinCount       *int     `help:"resource count threshold"`
+		MinMonthlyCost *float64 `help:"monthly cost threshold"`
+		Approver       []string `help:"ID or name of approvers" json:"approvers"`
+		ExpireHours    *int     `help:"hours before a pending request expires"`
+	}
+	R(&ApprovalPolicyUpdateOptions{}, "approval-policy-update", "Update an approval policy", func(s *mcclient.ClientSession, args *ApprovalPolicyUpdateOptions) error {
+		params := jsonutils.Marshal(args)
+		result, err := modules.ApprovalPolicies.Update(s, args.ID, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&ApprovalPolicyShowOptions{}, "approval-policy-enable", "Enable an approval policy", func(s *mcclient.ClientSession, args *ApprovalPolicyShowOptions) error {
+		result, err := modules.ApprovalPolicies.PerformAction(s, args.ID, "enable", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&ApprovalPolicyShowOptions{}, "approval-policy-disable", "Disable an approval policy", func(s *mcclient.ClientSession, args *ApprovalPolicyShowOptions) error {
+		result, err := modules.ApprovalPolicies.PerformAction(s, args.ID, "disable", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&ApprovalPolicyShowOptions{}, "approval-policy-delete", "Delete an approval policy", func(s *mcclient.ClientSession, args *ApprovalPolicyShowOptions) error {
+		result, err := modules.ApprovalPolicies.Delete(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type ApprovalRequestListOptions struct {
+		options.BaseListOptions
+		ResourceType []string `help:"filter by resource type" choices:"server|disk"`
+		Action       []string `help:"filter by action"`
+		PolicyId     string   `help:"filter by approval policy"`
+		RequesterId  string   `help:"filter by requester"`
+	}
+	R(&ApprovalRequestListOptions{}, "approval-request-list", "List approval requests", func(s *mcclient.ClientSession, args *ApprovalRequestListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.ApprovalRequests.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.ApprovalRequests.GetColumns(s))
+		return nil
+	})
+
+	type ApprovalRequestShowOptions struct {
+		ID string `help:"ID or Name of approval request"`
+	}
+	R(&ApprovalRequestShowOptions{}, "approval-request-show", "Show details of an approval request", func(s *mcclient.ClientSession, args *ApprovalRequestShowOptions) error {
+		result, err := modules.ApprovalRequests.GetById(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type ApprovalRequestApproveOptions struct {
+		ID      string `help:"ID or Name of approval request" json:"-"`
+		Comment string `help:"comment of approver"`
+	}
+	R(&ApprovalRequestApproveOptions{}, "approval-request-approve", "Approve a request and execute it as the requester", func(s *mcclient.ClientSession, args *ApprovalRequestApproveOptions) error {
+		result, err := modules.ApprovalRequests.PerformAction(s, args.ID, "approve", jsonutils.Marshal(args))
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type ApprovalRequestRejectOptions struct {
+		ID     string `help:"ID or Name of approval request" json:"-"`
+		REASON string `help:"reason of rejection" json:"reason"`
+	}
+	R(&ApprovalRequestRejectOptions{}, "approval-request-reject", "Reject a request", func(s *mcclient.ClientSession, args *ApprovalRequestRejectOptions) error {
+		result, err := modules.ApprovalRequests.PerformAction(s, args.ID, "reject", jsonutils.Marshal(args))
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&ApprovalRequestShowOptions{}, "approval-request-cancel", "Cancel a pending request", func(s *mcclient.ClientSession, args *ApprovalRequestShowOptions) error {
+		result, err := modules.ApprovalRequests.PerformAction(s, args.ID, "cancel", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&ApprovalRequestShowOptions{}, "approval-request-delete", "Delete a closed request", func(s *mcclient.ClientSession, args *ApprovalRequestShowOptions) error {
+		result, err := modules.ApprovalRequests.Delete(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+}
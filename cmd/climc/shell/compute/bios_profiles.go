@@ -0,0 +1,128 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func parseBiosAttributes(attrs []string) (*jsonutils.JSONDict, error) {
+	ret := jsonutils.NewDict()
+	for _, attr := range attrs {
+		pos := strings.Index(attr, "=")
+		if pos <= 0 {
+			return nil, fmt.Errorf("invalid bios attribute %s, should be Key=Value", attr)
+		}
+		key, val := attr[:pos], attr[pos+1:]
+		if i, err := strconv.ParseInt(val, 10, 64); err == nil {
+			ret.Add(jsonutils.NewInt(i), key)
+		} else {
+			ret.Add(jsonutils.NewString(val), key)
+		}
+	}
+	return ret, nil
+}
+
+func init() {
+	type BiosProfileListOptions struct {
+		options.BaseListOptions
+		Manufacture []string `help:"filter by manufacture"`
+	}
+	R(&BiosProfileListOptions{}, "bios-profile-list", "List bios profiles", func(s *mcclient.ClientSession, args *BiosProfileListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.BiosProfiles.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.BiosProfiles.GetColumns(s))
+		return nil
+	})
+
+	type BiosProfileShowOptions struct {
+		ID string `help:"ID or Name of bios profile"`
+	}
+	R(&BiosProfileShowOptions{}, "bios-profile-show", "Show details of a bios profile", func(s *mcclient.ClientSession, args *BiosProfileShowOptions) error {
+		result, err := modules.BiosProfiles.GetById(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type BiosProfileCreateOptions struct {
+		NAME        string   `help:"Name of bios profile"`
+		Desc        string   `help:"Description" json:"description"`
+		Manufacture string   `help:"manufacture of baremetal this profile applies to, e.g. Dell Inc."`
+		Attribute   []string `help:"desired bios attribute, e.g. ProcVirtualization=Enabled" json:"-"`
+	}
+	R(&BiosProfileCreateOptions{}, "bios-profile-create", "Create a bios profile", func(s *mcclient.ClientSession, args *BiosProfileCreateOptions) error {
+		attrs, err := parseBiosAttributes(args.Attribute)
+		if err != nil {
+			return err
+		}
+		params := jsonutils.Marshal(args).(*jsonutils.JSONDict)
+		params.Add(attrs, "attributes")
+		result, err := modules.BiosProfiles.Create(s, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type BiosProfileUpdateOptions struct {
+		ID        string   `help:"ID or Name of bios profile" json:"-"`
+		Name      string   `help:"New name of bios profile"`
+		Desc      string   `help:"Description" json:"description"`
+		Attribute []string `help:"replace desired bios attributes, e.g. ProcVirtualization=Enabled" json:"-"`
+	}
+	R(&BiosProfileUpdateOptions{}, "bios-profile-update", "Update a bios profile", func(s *mcclient.ClientSession, args *BiosProfileUpdateOptions) error {
+		params := jsonutils.Marshal(args).(*jsonutils.JSONDict)
+		if len(args.Attribute) > 0 {
+			attrs, err := parseBiosAttributes(args.Attribute)
+			if err != nil {
+				return err
+			}
+			params.Add(attrs, "attributes")
+		}
+		result, err := modules.BiosProfiles.Update(s, args.ID, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&BiosProfileShowOptions{}, "bios-profile-delete", "Delete a bios profile", func(s *mcclient.ClientSession, args *BiosProfileShowOptions) error {
+		result, err := modules.BiosProfiles.Delete(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+}
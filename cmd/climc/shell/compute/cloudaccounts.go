@@ -39,6 +39,7 @@ func init() {
 	cmd.CreateWithKeyword("create-huawei", &options.SHuaweiCloudAccountCreateOptions{})
 	cmd.CreateWithKeyword("create-ucloud", &options.SUcloudCloudAccountCreateOptions{})
 	cmd.CreateWithKeyword("create-zstack", &options.SZStackCloudAccountCreateOptions{})
+	cmd.CreateWithKeyword("create-proxmox", &options.SProxmoxCloudAccountCreateOptions{})
 	cmd.CreateWithKeyword("create-s3", &options.SS3CloudAccountCreateOptions{})
 	cmd.CreateWithKeyword("create-ceph", &options.SCephCloudAccountCreateOptions{})
 	cmd.CreateWithKeyword("create-xsky", &options.SXskyCloudAccountCreateOptions{})
@@ -55,6 +56,7 @@ func init() {
 	cmd.UpdateWithKeyword("update-huawei", &options.SHuaweiCloudAccountUpdateOptions{})
 	cmd.UpdateWithKeyword("update-ucloud", &options.SUcloudCloudAccountUpdateOptions{})
 	cmd.UpdateWithKeyword("update-zstack", &options.SZStackCloudAccountUpdateOptions{})
+	cmd.UpdateWithKeyword("update-proxmox", &options.SProxmoxCloudAccountUpdateOptions{})
 	cmd.UpdateWithKeyword("update-s3", &options.SS3CloudAccountUpdateOptions{})
 	cmd.UpdateWithKeyword("update-ctyun", &options.SCtyunCloudAccountUpdateOptions{})
 
@@ -70,6 +72,7 @@ func init() {
 	cmd.PerformWithKeyword("update-credential-huawei", "update-credential", &options.SHuaweiCloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("update-credential-ucloud", "update-credential", &options.SUcloudCloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("update-credential-zstack", "update-credential", &options.SZStackCloudAccountUpdateCredentialOptions{})
+	cmd.PerformWithKeyword("update-credential-proxmox", "update-credential", &options.SProxmoxCloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("update-credential-s3", "update-credential", &options.SS3CloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("update-credential-ctyun", "update-credential", &options.SCtyunCloudAccountUpdateCredentialOptions{})
 
@@ -83,6 +86,7 @@ func init() {
 	cmd.PerformWithKeyword("test-connectivity-huawei", "test-connectivity", &options.SHuaweiCloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("test-connectivity-ucloud", "test-connectivity", &options.SUcloudCloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("test-connectivity-zstack", "test-connectivity", &options.SZStackCloudAccountUpdateCredentialOptions{})
+	cmd.PerformWithKeyword("test-connectivity-proxmox", "test-connectivity", &options.SProxmoxCloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("test-connectivity-s3", "test-connectivity", &options.SS3CloudAccountUpdateCredentialOptions{})
 	cmd.PerformWithKeyword("test-connectivity-ctyun", "test-connectivity", &options.SCtyunCloudAccountUpdateCredentialOptions{})
 
@@ -103,4 +107,5 @@ func init() {
 	cmd.Get("enrollment-accounts", &options.SCloudAccountIdOptions{})
 	cmd.Get("balance", &options.SCloudAccountIdOptions{})
 	cmd.Get("saml", &options.SCloudAccountIdOptions{})
+	cmd.Get("sync-plan", &options.SCloudAccountIdOptions{})
 }
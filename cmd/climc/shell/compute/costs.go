@@ -0,0 +1,150 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type CostRateListOptions struct {
+		options.BaseListOptions
+		Metric []string `help:"filter by metric" choices:"cpu|mem|disk|eip|bandwidth|gpu"`
+	}
+	R(&CostRateListOptions{}, "cost-rate-list", "List cost rates of on-premise resources", func(s *mcclient.ClientSession, args *CostRateListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.CostRates.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.CostRates.GetColumns(s))
+		return nil
+	})
+
+	type CostRateShowOptions struct {
+		ID string `help:"ID or Name of cost rate"`
+	}
+	R(&CostRateShowOptions{}, "cost-rate-show", "Show details of a cost rate", func(s *mcclient.ClientSession, args *CostRateShowOptions) error {
+		result, err := modules.CostRates.GetById(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type CostRateCreateOptions struct {
+		NAME     string  `help:"Name of cost rate"`
+		Desc     string  `help:"Description" json:"description"`
+		METRIC   string  `help:"metric of the rate" choices:"cpu|mem|disk|eip|bandwidth|gpu" json:"metric"`
+		Spec     string  `help:"storage medium type of disk or model of gpu, default rate of the metric if not specified"`
+		PRICE    float64 `help:"price per unit per hour, e.g. per vCPU-hour, per GB-hour or per Mbps-hour" json:"price"`
+		Currency string  `help:"currency, default CNY"`
+	}
+	R(&CostRateCreateOptions{}, "cost-rate-create", "Create a cost rate", func(s *mcclient.ClientSession, args *CostRateCreateOptions) error {
+		result, err := modules.CostRates.Create(s, jsonutils.Marshal(args))
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type CostRateUpdateOptions struct {
+		ID       string   `help:"ID or Name of cost rate" json:"-"`
+		Name     string   `help:"New name of cost rate"`
+		Desc     string   `help:"Description" json:"description"`
+		Price    *float64 `help:"price per unit per hour"`
+		Currency string   `help:"currency"`
+	}
+	R(&CostRateUpdateOptions{}, "cost-rate-update", "Update a cost rate", func(s *mcclient.ClientSession, args *CostRateUpdateOptions) error {
+		result, err := modules.CostRates.Update(s, args.ID, jsonutils.Marshal(args))
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&CostRateShowOptions{}, "cost-rate-delete", "Delete a cost rate", func(s *mcclient.ClientSession, args *CostRateShowOptions) error {
+		result, err := modules.CostRates.Delete(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type UsageRecordListOptions struct {
+		options.BaseListOptions
+		Metric       []string `help:"filter by metric" choices:"cpu|mem|disk|eip|bandwidth|gpu"`
+		ResourceType []string `help:"filter by resource type"`
+		StartTime    string   `help:"usage hour since, e.g. 2020-06-01T00:00:00Z"`
+		EndTime      string   `help:"usage hour before, e.g. 2020-07-01T00:00:00Z"`
+	}
+	R(&UsageRecordListOptions{}, "usage-record-list", "List hourly usage records of on-premise resources", func(s *mcclient.ClientSession, args *UsageRecordListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.UsageRecords.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.UsageRecords.GetColumns(s))
+		return nil
+	})
+
+	type ShowbackReportOptions struct {
+		StartTime string `help:"report since, default the beginning of current month, e.g. 2020-06-01T00:00:00Z"`
+		EndTime   string `help:"report before, default now"`
+		GroupBy   string `help:"group costs by" choices:"project|domain|tag" default:"project"`
+		TagKey    string `help:"tag key to group costs when group by tag"`
+		Output    string `help:"write report as csv to this file" json:"-"`
+	}
+	R(&ShowbackReportOptions{}, "showback-report", "Show cost report of on-premise resources", func(s *mcclient.ClientSession, args *ShowbackReportOptions) error {
+		params := jsonutils.Marshal(args).(*jsonutils.JSONDict)
+		if len(args.Output) > 0 {
+			params.Set("format", jsonutils.NewString("csv"))
+		}
+		result, err := modules.UsageRecords.Get(s, "showback-report", params)
+		if err != nil {
+			return err
+		}
+		if len(args.Output) > 0 {
+			content, _ := result.GetString("csv")
+			err := ioutil.WriteFile(args.Output, []byte(content), 0644)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("report is written to %s\n", args.Output)
+			return nil
+		}
+		rows, _ := result.GetArray("rows")
+		printList(&modulebase.ListResult{Data: rows}, []string{"key", "name", "currency", "cpu", "mem", "disk", "eip", "bandwidth", "gpu", "total"})
+		return nil
+	})
+}
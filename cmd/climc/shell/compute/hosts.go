@@ -170,6 +170,15 @@ func init() {
 		return nil
 	})
 
+	R(&HostDetailOptions{}, "host-rebalance-plan", "Get migrations from or to a host planned by rebalance controller", func(s *mcclient.ClientSession, args *HostDetailOptions) error {
+		result, err := modules.Hosts.GetSpecific(s, args.ID, "rebalance-plan", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
 	R(&HostDetailOptions{}, "host-logininfo", "Get SSH login information of a host", func(s *mcclient.ClientSession, args *HostDetailOptions) error {
 		srvid, e := modules.Hosts.GetId(s, args.ID, nil)
 		if e != nil {
@@ -250,6 +259,8 @@ func init() {
 		IpmiUsername string `help:"IPMI user"`
 		IpmiPassword string `help:"IPMI password"`
 		IpmiIpAddr   string `help:"IPMI ip_addr"`
+
+		IpmiRedfishRaid string `help:"configure RAID out-of-band through Redfish" choices:"true|false"`
 	}
 	R(&HostUpdateOptions{}, "host-update", "Update information of a host", func(s *mcclient.ClientSession, args *HostUpdateOptions) error {
 		params := jsonutils.NewDict()
@@ -289,6 +300,9 @@ func init() {
 		if len(args.IpmiIpAddr) > 0 {
 			params.Add(jsonutils.NewString(args.IpmiIpAddr), "ipmi_ip_addr")
 		}
+		if len(args.IpmiRedfishRaid) > 0 {
+			params.Add(jsonutils.NewBool(args.IpmiRedfishRaid == "true"), "ipmi_redfish_raid")
+		}
 		if params.Size() == 0 {
 			return fmt.Errorf("Not data to update")
 		}
@@ -804,9 +818,10 @@ func init() {
 	})
 
 	type HostAutoMigrateOnHostDownOptions struct {
-		ID      string `help:"ID or name of host"`
-		Enable  bool   `help:"enable auto migrate"`
-		Disable bool   `help:"disable auto migrate"`
+		ID          string `help:"ID or name of host"`
+		Enable      bool   `help:"enable auto migrate"`
+		Disable     bool   `help:"disable auto migrate"`
+		FenceMethod string `help:"fence host before migrate servers" choices:"none|ipmi|watchdog"`
 	}
 	R(&HostAutoMigrateOnHostDownOptions{}, "host-auto-migrate-on-host-down", "Get change owner candidate domain list", func(s *mcclient.ClientSession, args *HostAutoMigrateOnHostDownOptions) error {
 		params := jsonutils.NewDict()
@@ -814,6 +829,9 @@ func init() {
 			params.Set("auto_migrate_on_host_down", jsonutils.NewString("disable"))
 		} else if args.Enable {
 			params.Set("auto_migrate_on_host_down", jsonutils.NewString("enable"))
+			if len(args.FenceMethod) > 0 {
+				params.Set("fence_method", jsonutils.NewString(args.FenceMethod))
+			}
 		} else {
 			return fmt.Errorf("missing input enable or disable")
 		}
@@ -825,6 +843,70 @@ func init() {
 		return nil
 	})
 
+	type HostEvacuateOptions struct {
+		ID                 string `help:"ID or name of host"`
+		FenceMethod        string `help:"fence host before evacuate servers" choices:"none|ipmi|watchdog"`
+		PreferHost         string `help:"prefer host to migrate servers with shared storage" json:"prefer_host_id"`
+		RebuildLocalGuests bool   `help:"rebuild servers with local storage from instance snapshot, requires ipmi fence method" json:"-"`
+	}
+	R(&HostEvacuateOptions{}, "host-evacuate", "Fence down host and recover servers on it", func(s *mcclient.ClientSession, args *HostEvacuateOptions) error {
+		params := jsonutils.Marshal(args).(*jsonutils.JSONDict)
+		params.Remove("id")
+		if args.RebuildLocalGuests {
+			params.Set("rebuild_local_guests", jsonutils.JSONTrue)
+		}
+		result, err := modules.Hosts.PerformAction(s, args.ID, "evacuate", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type HostFirmwareUpdateOptions struct {
+		ID                     string   `help:"ID or name of baremetal host" json:"-"`
+		IMAGEURI               string   `help:"URI of firmware image" json:"image_uri"`
+		Method                 string   `help:"BMC fetch image by itself or push image to BMC" choices:"simple|push"`
+		Target                 []string `help:"firmware inventory path to update" json:"targets"`
+		AutoReboot             bool     `help:"reboot baremetal to apply firmware"`
+		MaintenanceWindowStart string   `help:"start time of maintenance window to reboot, e.g. 2020-01-01T02:00:00Z"`
+		MaintenanceWindowEnd   string   `help:"end time of maintenance window to reboot"`
+	}
+	R(&HostFirmwareUpdateOptions{}, "host-firmware-update", "Update firmware of baremetal through Redfish", func(s *mcclient.ClientSession, args *HostFirmwareUpdateOptions) error {
+		params := jsonutils.Marshal(args)
+		result, err := modules.Hosts.PerformAction(s, args.ID, "firmware-update", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type HostApplyBiosProfileOptions struct {
+		ID          string `help:"ID or name of host" json:"-"`
+		BiosProfile string `help:"ID or name of bios profile, default is the attached one" json:"bios_profile_id"`
+		AutoReboot  bool   `help:"reboot baremetal to apply bios settings"`
+		CheckOnly   bool   `help:"only check drift of bios settings"`
+	}
+	R(&HostApplyBiosProfileOptions{}, "host-apply-bios-profile", "Apply bios profile to baremetal through Redfish", func(s *mcclient.ClientSession, args *HostApplyBiosProfileOptions) error {
+		params := jsonutils.Marshal(args)
+		result, err := modules.Hosts.PerformAction(s, args.ID, "apply-bios-profile", params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&HostDetailOptions{}, "host-detach-bios-profile", "Detach bios profile from baremetal", func(s *mcclient.ClientSession, args *HostDetailOptions) error {
+		result, err := modules.Hosts.PerformAction(s, args.ID, "detach-bios-profile", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
 	type HostSetReservedResourceForIsolatedDevice struct {
 		ID              []string `help:"ID or name of host" json:"-"`
 		ReservedCpu     *int     `help:"reserved cpu count"`
@@ -30,6 +30,9 @@ func init() {
 		Host   string `help:"Host ID or Name"`
 		Region string `help:"Cloudregion ID or Name"`
 		Zone   string `help:"Zone ID or Name"`
+
+		DevType []string `help:"Device type, e.g. GPU-HPC, SRIOV-VF"`
+		Wire    []string `help:"Wire of SR-IOV VF"`
 	}
 	R(&DeviceListOptions{}, "isolated-device-list", "List isolated devices like GPU", func(s *mcclient.ClientSession, args *DeviceListOptions) error {
 		var params *jsonutils.JSONDict
@@ -56,6 +59,12 @@ func init() {
 		if args.Zone != "" {
 			params.Add(jsonutils.NewString(args.Zone), "zone")
 		}
+		if len(args.DevType) > 0 {
+			params.Add(jsonutils.NewStringArray(args.DevType), "dev_type")
+		}
+		if len(args.Wire) > 0 {
+			params.Add(jsonutils.NewStringArray(args.Wire), "wire_id")
+		}
 		result, err := modules.IsolatedDevices.List(s, params)
 		if err != nil {
 			return err
@@ -61,8 +61,14 @@ func init() {
 	cmd.BatchPerform("purge", new(options.ServerIdsOptions))
 	cmd.Perform("migrate", new(options.ServerMigrateOptions))
 	cmd.Perform("live-migrate", new(options.ServerLiveMigrateOptions))
+	cmd.Perform("change-disk-storage", new(options.ServerChangeDiskStorageOptions))
+	cmd.Perform("cancel-change-disk-storage", new(options.ServerIdOptions))
+	cmd.Perform("set-ha-priority", new(options.ServerSetHaPriorityOptions))
+	cmd.Perform("bind-instance-role", new(options.ServerBindInstanceRoleOptions))
+	cmd.Perform("unbind-instance-role", new(options.ServerIdOptions))
 	cmd.Perform("modify-src-check", new(options.ServerModifySrcCheckOptions))
 	cmd.Perform("set-secgroup", new(options.ServerSecGroupsOptions))
+	cmd.PerformClass("verify-identity-document", new(options.ServerVerifyIdentityDocumentOptions))
 	cmd.Perform("add-secgroup", new(options.ServerSecGroupsOptions))
 	cmd.Perform("assign-secgroup", new(options.ServerSecGroupOptions))
 	cmd.Perform("assign-admin-secgroup", new(options.ServerSecGroupOptions))
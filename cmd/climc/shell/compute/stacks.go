@@ -0,0 +1,175 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func parseStackParameters(params []string) (*jsonutils.JSONDict, error) {
+	ret := jsonutils.NewDict()
+	for _, param := range params {
+		pos := strings.Index(param, "=")
+		if pos <= 0 {
+			return nil, fmt.Errorf("invalid stack parameter %s, should be Key=Value", param)
+		}
+		key, val := param[:pos], param[pos+1:]
+		if i, err := strconv.ParseInt(val, 10, 64); err == nil {
+			ret.Add(jsonutils.NewInt(i), key)
+		} else if b, err := strconv.ParseBool(val); err == nil {
+			ret.Add(jsonutils.NewBool(b), key)
+		} else {
+			ret.Add(jsonutils.NewString(val), key)
+		}
+	}
+	return ret, nil
+}
+
+type StackTemplateOptions struct {
+	TemplateFile string   `help:"path of template file in YAML or JSON format" json:"-"`
+	Param        []string `help:"value of template parameter, e.g. image=centos-7" json:"-"`
+}
+
+func (opts *StackTemplateOptions) update(params *jsonutils.JSONDict) error {
+	if len(opts.TemplateFile) > 0 {
+		content, err := ioutil.ReadFile(opts.TemplateFile)
+		if err != nil {
+			return err
+		}
+		params.Add(jsonutils.NewString(string(content)), "template")
+	}
+	if len(opts.Param) > 0 {
+		values, err := parseStackParameters(opts.Param)
+		if err != nil {
+			return err
+		}
+		params.Add(values, "parameters")
+	}
+	return nil
+}
+
+func init() {
+	type StackListOptions struct {
+		options.BaseListOptions
+	}
+	R(&StackListOptions{}, "stack-list", "List resource stacks", func(s *mcclient.ClientSession, args *StackListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.Stacks.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.Stacks.GetColumns(s))
+		return nil
+	})
+
+	type StackShowOptions struct {
+		ID string `help:"ID or Name of stack"`
+	}
+	R(&StackShowOptions{}, "stack-show", "Show details of a resource stack", func(s *mcclient.ClientSession, args *StackShowOptions) error {
+		result, err := modules.Stacks.GetById(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&StackShowOptions{}, "stack-resources", "Show resources managed by a stack", func(s *mcclient.ClientSession, args *StackShowOptions) error {
+		result, err := modules.Stacks.GetSpecific(s, args.ID, "resources", nil)
+		if err != nil {
+			return err
+		}
+		resources, _ := result.GetArray("resources")
+		printList(&modulebase.ListResult{Data: resources}, []string{"name", "type", "status", "resource_id", "drift_status", "drift_changes"})
+		return nil
+	})
+
+	R(&StackShowOptions{}, "stack-detect-drift", "Check whether resources of a stack are modified or deleted outside of it", func(s *mcclient.ClientSession, args *StackShowOptions) error {
+		result, err := modules.Stacks.PerformAction(s, args.ID, "detect-drift", nil)
+		if err != nil {
+			return err
+		}
+		resources, _ := result.GetArray("resources")
+		printList(&modulebase.ListResult{Data: resources}, []string{"name", "type", "resource_id", "drift_status", "drift_changes"})
+		return nil
+	})
+
+	type StackCreateOptions struct {
+		NAME string `help:"Name of stack"`
+		Desc string `help:"Description" json:"description"`
+		StackTemplateOptions
+	}
+	R(&StackCreateOptions{}, "stack-create", "Create a resource stack from template", func(s *mcclient.ClientSession, args *StackCreateOptions) error {
+		if len(args.TemplateFile) == 0 {
+			return fmt.Errorf("--template-file is required")
+		}
+		params := jsonutils.Marshal(args).(*jsonutils.JSONDict)
+		if err := args.update(params); err != nil {
+			return err
+		}
+		result, err := modules.Stacks.Create(s, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type StackPlanOptions struct {
+		ID string `help:"ID or Name of stack" json:"-"`
+		StackTemplateOptions
+	}
+	for action, desc := range map[string]string{
+		"plan":  "Preview changes of applying new template or parameters to a stack",
+		"apply": "Apply new template or parameters to a stack",
+	} {
+		action := action
+		R(&StackPlanOptions{}, "stack-"+action, desc, func(s *mcclient.ClientSession, args *StackPlanOptions) error {
+			params := jsonutils.NewDict()
+			if err := args.update(params); err != nil {
+				return err
+			}
+			result, err := modules.Stacks.PerformAction(s, args.ID, action, params)
+			if err != nil {
+				return err
+			}
+			steps, _ := result.GetArray("steps")
+			printList(&modulebase.ListResult{Data: steps}, []string{"name", "type", "action", "resource_id", "changes"})
+			return nil
+		})
+	}
+
+	R(&StackShowOptions{}, "stack-delete", "Delete a stack and all resources of it", func(s *mcclient.ClientSession, args *StackShowOptions) error {
+		result, err := modules.Stacks.Delete(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+}
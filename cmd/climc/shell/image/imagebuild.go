@@ -0,0 +1,139 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/image"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type ImageBuildListOptions struct {
+		options.BaseListOptions
+		BaseImage string `help:"filter by base image"`
+		Image     string `help:"filter by built image"`
+	}
+	R(&ImageBuildListOptions{}, "image-build-list", "List image builds", func(s *mcclient.ClientSession, args *ImageBuildListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.ImageBuilds.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.ImageBuilds.GetColumns(s))
+		return nil
+	})
+
+	type ImageBuildShowOptions struct {
+		ID string `help:"ID or Name of image build"`
+	}
+	R(&ImageBuildShowOptions{}, "image-build-show", "Show details of an image build", func(s *mcclient.ClientSession, args *ImageBuildShowOptions) error {
+		result, err := modules.ImageBuilds.Get(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		result.(*jsonutils.JSONDict).Remove("build_log")
+		printObject(result)
+		return nil
+	})
+
+	R(&ImageBuildShowOptions{}, "image-build-log", "Show log of an image build", func(s *mcclient.ClientSession, args *ImageBuildShowOptions) error {
+		result, err := modules.ImageBuilds.Get(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		buildLog, _ := result.GetString("build_log")
+		fmt.Println(buildLog)
+		return nil
+	})
+
+	R(&ImageBuildShowOptions{}, "image-build-delete", "Delete an image build record, the built image is kept", func(s *mcclient.ClientSession, args *ImageBuildShowOptions) error {
+		result, err := modules.ImageBuilds.Delete(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type ImageBuildCreateOptions struct {
+		BASEIMAGE         string   `help:"ID or Name of base image"`
+		NAME              string   `help:"Name of image build and the built image"`
+		Desc              string   `help:"Description"`
+		Package           []string `help:"package to install inside the isolated libguestfs appliance"`
+		File              []string `help:"file to inject, in format of <path in image>=<local file>[:<octal mode>], e.g. /etc/motd=./motd:0644"`
+		Script            []string `help:"local shell script to run inside the isolated libguestfs appliance, run in given order"`
+		EnableService     []string `help:"service to enable on boot"`
+		ResetMachineId    bool     `help:"reset /etc/machine-id"`
+		RemoveSshHostKeys bool     `help:"remove ssh host keys"`
+		CleanLogs         bool     `help:"clean /var/log and temporary directories"`
+	}
+	R(&ImageBuildCreateOptions{}, "image-build-create", "Build a new image by customizing a base image offline", func(s *mcclient.ClientSession, args *ImageBuildCreateOptions) error {
+		recipe := api.ImageBuildRecipe{
+			Packages:          args.Package,
+			EnableServices:    args.EnableService,
+			ResetMachineId:    args.ResetMachineId,
+			RemoveSshHostKeys: args.RemoveSshHostKeys,
+			CleanLogs:         args.CleanLogs,
+		}
+		for _, f := range args.File {
+			pos := strings.Index(f, "=")
+			if pos <= 0 {
+				return fmt.Errorf("invalid file %s, should be <path in image>=<local file>[:<octal mode>]", f)
+			}
+			file := api.ImageBuildFile{Path: f[:pos]}
+			local := f[pos+1:]
+			if colon := strings.LastIndex(local, ":"); colon > 0 {
+				file.Mode = local[colon+1:]
+				local = local[:colon]
+			}
+			content, err := ioutil.ReadFile(local)
+			if err != nil {
+				return err
+			}
+			file.Content = string(content)
+			recipe.Files = append(recipe.Files, file)
+		}
+		for _, script := range args.Script {
+			content, err := ioutil.ReadFile(script)
+			if err != nil {
+				return err
+			}
+			recipe.Scripts = append(recipe.Scripts, string(content))
+		}
+		input := api.ImageBuildCreateInput{
+			BaseImage: args.BASEIMAGE,
+			Recipe:    &recipe,
+		}
+		input.Name = args.NAME
+		input.Description = args.Desc
+		result, err := modules.ImageBuilds.Create(s, jsonutils.Marshal(input))
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+}
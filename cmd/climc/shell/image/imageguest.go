@@ -15,6 +15,9 @@
 package image
 
 import (
+	"io"
+	"os"
+
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/pkg/errors"
 
@@ -230,4 +233,77 @@ func init() {
 			return nil
 		},
 	)
+
+	type GuestImageImportOptions struct {
+		NAME      string `help:"Name of guest image"`
+		Format    string `help:"Format of the source" choices:"ova|ovf" default:"ova"`
+		File      string `help:"Path of a local ova file to upload"`
+		CopyFrom  string `help:"Url of the ova or ovf file, disks of an ovf are fetched relatively to it"`
+		OsArch    string `help:"Os arch of guest image" choices:"x86_64|aarch64"`
+		Protected bool   `help:"if guest image is protected"`
+	}
+
+	R(&GuestImageImportOptions{}, "guest-image-import", "Import a guest image from an ova, or an ovf with vmdk disks",
+		func(s *mcclient.ClientSession, args *GuestImageImportOptions) error {
+
+			if len(args.File) == 0 && len(args.CopyFrom) == 0 {
+				return errors.Error("either --file or --copy-from must be specified")
+			}
+			params := jsonutils.NewDict()
+			params.Add(jsonutils.NewString(args.NAME), "name")
+			params.Add(jsonutils.NewString(args.Format), "disk_format")
+			if len(args.OsArch) > 0 {
+				params.Add(jsonutils.NewString(args.OsArch), "os_arch")
+			}
+			if args.Protected {
+				params.Add(jsonutils.JSONTrue, "protected")
+			}
+			var body io.Reader
+			var size int64
+			if len(args.File) > 0 {
+				f, err := os.Open(args.File)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				finfo, err := f.Stat()
+				if err != nil {
+					return err
+				}
+				body = f
+				size = finfo.Size()
+			} else {
+				params.Add(jsonutils.NewString(args.CopyFrom), "copy_from")
+			}
+			ret, err := modules.GuestImages.Import(s, params, body, size)
+			if err != nil {
+				return err
+			}
+			printObject(ret)
+			return nil
+		},
+	)
+
+	type GuestImageExportOptions struct {
+		ID     string `help:"ID or name of guest image"`
+		OUTPUT string `help:"Path of the ova file to save"`
+	}
+
+	R(&GuestImageExportOptions{}, "guest-image-export", "Export a guest image to an ova file",
+		func(s *mcclient.ClientSession, args *GuestImageExportOptions) error {
+
+			src, err := modules.GuestImages.ExportOva(s, args.ID)
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+			f, err := os.Create(args.OUTPUT)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(f, src)
+			return err
+		},
+	)
 }
@@ -26,6 +26,7 @@ import (
 	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
 	"yunion.io/x/onecloud/pkg/mcclient/modules"
 	"yunion.io/x/onecloud/pkg/mcclient/options"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
 )
 
 type ImageOptionalOptions struct {
@@ -314,6 +315,83 @@ func init() {
 		return nil
 	})
 
+	type ImageChunkedUploadOptions struct {
+		NAME        string `help:"Image Name, ignored when resuming with --image"`
+		FILE        string `help:"The local image filename to Upload"`
+		Image       string `help:"ID or Name of a queued image to resume the upload"`
+		ChunkSizeMb int64  `help:"Size of each chunk in MB" default:"64"`
+		Retry       int    `help:"Times to retry a failed chunk" default:"5"`
+		ImageOptionalOptions
+	}
+	R(&ImageChunkedUploadOptions{}, "image-chunked-upload", "Upload a local image in resumable chunks", func(s *mcclient.ClientSession, args *ImageChunkedUploadOptions) error {
+		f, err := os.Open(args.FILE)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		finfo, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		size := finfo.Size()
+		checksum, err := fileutils2.MD5(args.FILE)
+		if err != nil {
+			return err
+		}
+
+		imageId := args.Image
+		if len(imageId) == 0 {
+			params := jsonutils.NewDict()
+			params.Add(jsonutils.NewString(args.NAME), "name")
+			err := addImageOptionalOptions(s, params, args.ImageOptionalOptions)
+			if err != nil {
+				return err
+			}
+			img, err := modules.Images.Create(s, params)
+			if err != nil {
+				return err
+			}
+			imageId, _ = img.GetString("id")
+			fmt.Printf("Created image %s, resume with --image %s if interrupted\n", imageId, imageId)
+		}
+
+		offset, err := modules.Images.StartUpload(s, imageId)
+		if err != nil {
+			return err
+		}
+		chunkSize := args.ChunkSizeMb * 1024 * 1024
+		retry := 0
+		for offset < size {
+			length := chunkSize
+			if offset+length > size {
+				length = size - offset
+			}
+			next, err := modules.Images.UploadChunk(s, imageId, offset, io.NewSectionReader(f, offset, length), length)
+			if err != nil {
+				if retry >= args.Retry {
+					return err
+				}
+				retry++
+				fmt.Fprintf(os.Stderr, "upload chunk at %d failed: %s, retry %d\n", offset, err, retry)
+				// the server may have saved part of the chunk
+				next, err = modules.Images.StartUpload(s, imageId)
+				if err != nil {
+					return err
+				}
+			} else {
+				retry = 0
+			}
+			offset = next
+			fmt.Fprintf(os.Stderr, "uploaded %d/%d bytes\n", offset, size)
+		}
+		img, err := modules.Images.FinishUpload(s, imageId, size, checksum)
+		if err != nil {
+			return err
+		}
+		printObject(img)
+		return nil
+	})
+
 	type ImageImportOptions struct {
 		ImageOptionalOptions
 		NAME     string `help:"Image Name"`
@@ -0,0 +1,128 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func init() {
+	type ImageSigningKeyListOptions struct {
+		options.BaseListOptions
+		Fingerprint []string `help:"filter by fingerprint of public key"`
+	}
+	R(&ImageSigningKeyListOptions{}, "image-signing-key-list", "List public keys trusted to sign images", func(s *mcclient.ClientSession, args *ImageSigningKeyListOptions) error {
+		params, err := options.ListStructToParams(args)
+		if err != nil {
+			return err
+		}
+		result, err := modules.ImageSigningKeys.List(s, params)
+		if err != nil {
+			return err
+		}
+		printList(result, modules.ImageSigningKeys.GetColumns(s))
+		return nil
+	})
+
+	type ImageSigningKeyShowOptions struct {
+		ID string `help:"ID or Name of image signing key"`
+	}
+	R(&ImageSigningKeyShowOptions{}, "image-signing-key-show", "Show details of an image signing key", func(s *mcclient.ClientSession, args *ImageSigningKeyShowOptions) error {
+		result, err := modules.ImageSigningKeys.Get(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type ImageSigningKeyCreateOptions struct {
+		NAME          string `help:"Name of image signing key"`
+		Desc          string `help:"Description" json:"description"`
+		PUBLICKEYFILE string `help:"path to RSA public key in PEM format, e.g. output of openssl rsa -pubout" json:"-"`
+	}
+	R(&ImageSigningKeyCreateOptions{}, "image-signing-key-create", "Add a public key trusted to sign images", func(s *mcclient.ClientSession, args *ImageSigningKeyCreateOptions) error {
+		content, err := ioutil.ReadFile(args.PUBLICKEYFILE)
+		if err != nil {
+			return err
+		}
+		params := jsonutils.Marshal(args).(*jsonutils.JSONDict)
+		params.Set("public_key", jsonutils.NewString(string(content)))
+		result, err := modules.ImageSigningKeys.Create(s, params)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&ImageSigningKeyShowOptions{}, "image-signing-key-enable", "Enable an image signing key", func(s *mcclient.ClientSession, args *ImageSigningKeyShowOptions) error {
+		result, err := modules.ImageSigningKeys.PerformAction(s, args.ID, "enable", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&ImageSigningKeyShowOptions{}, "image-signing-key-disable", "Disable an image signing key, images signed by it are no longer trusted", func(s *mcclient.ClientSession, args *ImageSigningKeyShowOptions) error {
+		result, err := modules.ImageSigningKeys.PerformAction(s, args.ID, "disable", nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	R(&ImageSigningKeyShowOptions{}, "image-signing-key-delete", "Delete an image signing key", func(s *mcclient.ClientSession, args *ImageSigningKeyShowOptions) error {
+		result, err := modules.ImageSigningKeys.Delete(s, args.ID, nil)
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+
+	type ImageSignOptions struct {
+		ID            string `help:"ID or Name of image" json:"-"`
+		KEY           string `help:"ID or Name of the signing key" json:"signing_key"`
+		Signature     string `help:"base64 encoded signature"`
+		SignatureFile string `help:"path to the binary signature, e.g. output of openssl dgst -sha256 -sign key.pem image.qcow2" json:"-"`
+	}
+	R(&ImageSignOptions{}, "image-sign", "Upload detached signature of an image", func(s *mcclient.ClientSession, args *ImageSignOptions) error {
+		if len(args.SignatureFile) > 0 {
+			content, err := ioutil.ReadFile(args.SignatureFile)
+			if err != nil {
+				return err
+			}
+			args.Signature = base64.StdEncoding.EncodeToString(content)
+		}
+		args.Signature = strings.TrimSpace(args.Signature)
+		result, err := modules.Images.PerformAction(s, args.ID, "sign", jsonutils.Marshal(args))
+		if err != nil {
+			return err
+		}
+		printObject(result)
+		return nil
+	})
+}
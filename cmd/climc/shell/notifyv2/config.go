@@ -19,6 +19,7 @@ import (
 
 	"yunion.io/x/jsonutils"
 
+	api "yunion.io/x/onecloud/pkg/apis/notify"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/mcclient/modules"
 	"yunion.io/x/onecloud/pkg/mcclient/options"
@@ -43,6 +44,25 @@ func init() {
 	type ConfigCreateOptions struct {
 		TYPE    string   `help:"Type contact config"`
 		Configs []string `help:"Config content, format: 'key:value'"`
+
+		MaxAttempts       int     `help:"Max attempts to deliver a notification before it is moved to dead letter queue"`
+		BackoffSeconds    int     `help:"Seconds to wait before the first retry, doubled for every later retry"`
+		MaxBackoffSeconds int     `help:"Upper bound of seconds to wait between retries"`
+		RateLimit         float64 `help:"Messages sent by the send agent per second"`
+		RateBurst         int     `help:"Max messages sent by the send agent in a burst"`
+	}
+	deliveryPolicy := func(args *ConfigCreateOptions) *api.SDeliveryPolicy {
+		policy := api.SDeliveryPolicy{
+			MaxAttempts:       args.MaxAttempts,
+			BackoffSeconds:    args.BackoffSeconds,
+			MaxBackoffSeconds: args.MaxBackoffSeconds,
+			RateLimit:         args.RateLimit,
+			RateBurst:         args.RateBurst,
+		}
+		if policy.IsZero() {
+			return nil
+		}
+		return &policy
 	}
 	R(&ConfigCreateOptions{}, "notify-config-create", "Create notify config", func(s *mcclient.ClientSession, args *ConfigCreateOptions) error {
 		configs := jsonutils.NewDict()
@@ -53,6 +73,9 @@ func init() {
 		params := jsonutils.NewDict()
 		params.Set("type", jsonutils.NewString(args.TYPE))
 		params.Set("content", configs)
+		if policy := deliveryPolicy(args); policy != nil {
+			params.Set("delivery_policy", jsonutils.Marshal(policy))
+		}
 		ret, err := modules.NotifyConfig.Create(s, params)
 		if err != nil {
 			return err
@@ -67,7 +90,12 @@ func init() {
 			configs.Set(kv[:index], jsonutils.NewString(kv[index+1:]))
 		}
 		params := jsonutils.NewDict()
-		params.Set("content", configs)
+		if len(args.Configs) > 0 {
+			params.Set("content", configs)
+		}
+		if policy := deliveryPolicy(args); policy != nil {
+			params.Set("delivery_policy", jsonutils.Marshal(policy))
+		}
 
 		id, err := configIdFromType(s, args.TYPE)
 		if err != nil {
@@ -0,0 +1,43 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifyv2
+
+import (
+	"yunion.io/x/onecloud/cmd/climc/shell"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/options/notify"
+)
+
+func init() {
+	cmd := shell.NewResourceCmd(&modules.OncallSchedule).WithKeyword("notify-oncall-schedule")
+	cmd.List(new(notify.OncallScheduleListOptions))
+	cmd.Create(new(notify.OncallScheduleCreateOptions))
+	cmd.Update(new(notify.OncallScheduleUpdateOptions))
+	cmd.Show(new(notify.OncallScheduleOptions))
+	cmd.Delete(new(notify.OncallScheduleOptions))
+	cmd.Perform("enable", new(notify.OncallScheduleOptions))
+	cmd.Perform("disable", new(notify.OncallScheduleOptions))
+	cmd.Perform("add-override", new(notify.OncallScheduleAddOverrideOptions))
+	cmd.Perform("remove-override", new(notify.OncallScheduleRemoveOverrideOptions))
+
+	cmd = shell.NewResourceCmd(&modules.EscalationPolicy).WithKeyword("notify-escalation-policy")
+	cmd.List(new(notify.EscalationPolicyListOptions))
+	cmd.Create(new(notify.EscalationPolicyCreateOptions))
+	cmd.Update(new(notify.EscalationPolicyUpdateOptions))
+	cmd.Show(new(notify.EscalationPolicyOptions))
+	cmd.Delete(new(notify.EscalationPolicyOptions))
+	cmd.Perform("enable", new(notify.EscalationPolicyOptions))
+	cmd.Perform("disable", new(notify.EscalationPolicyOptions))
+}
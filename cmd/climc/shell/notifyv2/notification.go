@@ -32,6 +32,8 @@ func init() {
 		Priority    string   `help:"Priority"`
 		MESSAGE     string   `help:"Message"`
 		Oldsdk      bool     `help:"Old sdk"`
+
+		EscalationPolicy string `help:"Id or Name of escalation policy"`
 	}
 	R(&NotificationCreateInput{}, "notify-send", "Send a notify message", func(s *mcclient.ClientSession, args *NotificationCreateInput) error {
 		var (
@@ -59,6 +61,8 @@ func init() {
 				Topic:       args.TOPIC,
 				Priority:    args.Priority,
 				Message:     args.MESSAGE,
+
+				EscalationPolicy: args.EscalationPolicy,
 			}
 			ret, err = modules.Notification.Create(s, jsonutils.Marshal(input))
 			if err != nil {
@@ -79,6 +83,14 @@ func init() {
 		printObject(ret)
 		return nil
 	})
+	R(&NotificationInput{}, "notify-acknowledge", "Acknowledge an escalated notify message", func(s *mcclient.ClientSession, args *NotificationInput) error {
+		ret, err := modules.Notification.PerformAction(s, args.ID, "acknowledge", nil)
+		if err != nil {
+			return err
+		}
+		printObject(ret)
+		return nil
+	})
 	type NotificationListInput struct {
 		options.BaseListOptions
 
@@ -27,4 +27,5 @@ func init() {
 	cmd.Perform("set-receiver", new(notify.SubscriptionSetReceiverOptions))
 	cmd.Perform("set-robot", new(notify.SubscriptionSetRobotOptions))
 	cmd.Perform("set-webhook", new(notify.SubscriptionSetWebhookOptions))
+	cmd.Perform("set-escalation-policy", new(notify.SubscriptionSetEscalationPolicyOptions))
 }
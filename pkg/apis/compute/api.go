@@ -60,7 +60,7 @@ type NetworkConfig struct {
 	// swagger:ignore
 	Address6 string `json:"address6"`
 
-	// 驱动方式
+	// 驱动方式, vfio-pci 表示直通宿主机网卡的 SR-IOV VF, vhost-user 表示接入宿主机 OVS-DPDK 数据面
 	// 若指定镜像的网络驱动方式，此参数会被覆盖
 	Driver   string `json:"driver"`
 	BwLimit  int    `json:"bw_limit"`
@@ -362,6 +362,11 @@ type ServerCreateInput struct {
 	// required: false
 	UserData string `json:"user_data"`
 
+	// 挂载到虚拟机的 config drive 卷标, 仅KVM支持
+	// required: false
+	// enum: config-2, cidata
+	ConfigDrive string `json:"config_drive"`
+
 	// swagger:ignore
 	// Deprecated
 	Keypair string `json:"keypair" yunion-deprecated-by:"keypair_id"`
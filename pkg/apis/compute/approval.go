@@ -0,0 +1,153 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	APPROVAL_POLICY_STATUS_AVAILABLE = "available"
+
+	// 等待审批
+	APPROVAL_REQUEST_STATUS_PENDING = "pending"
+	// 已批准, 正在以申请人身份执行
+	APPROVAL_REQUEST_STATUS_APPROVED = "approved"
+	// 已执行
+	APPROVAL_REQUEST_STATUS_EXECUTED = "executed"
+	// 执行失败
+	APPROVAL_REQUEST_STATUS_EXECUTE_FAILED = "execute_failed"
+	// 已驳回
+	APPROVAL_REQUEST_STATUS_REJECTED = "rejected"
+	// 超时未审批
+	APPROVAL_REQUEST_STATUS_EXPIRED = "expired"
+	// 申请人已撤销
+	APPROVAL_REQUEST_STATUS_CANCELLED = "cancelled"
+
+	APPROVAL_RESOURCE_SERVER = "server"
+	APPROVAL_RESOURCE_DISK   = "disk"
+
+	APPROVAL_ACTION_CREATE        = "create"
+	APPROVAL_ACTION_CHANGE_CONFIG = "change-config"
+	APPROVAL_ACTION_DELETE        = "delete"
+
+	APPROVAL_DEFAULT_EXPIRE_HOURS = 72
+)
+
+type ApprovalPolicyCreateInput struct {
+	apis.EnabledStatusInfrasResourceBaseCreateInput
+
+	// 适用的资源类型
+	// enum: server, disk
+	ResourceType string `json:"resource_type"`
+
+	// 需要审批的操作, 为空表示全部操作
+	// example: ["create","change-config"]
+	Actions []string `json:"actions"`
+
+	// 适用的项目ID或名称, 为空表示域内全部项目
+	Projects []string `json:"projects"`
+
+	// 申请的CPU核数达到该值时需要审批, 0表示不限制
+	MinVcpuCount int `json:"min_vcpu_count"`
+	// 申请的内存(MB)达到该值时需要审批
+	MinVmemSizeMb int `json:"min_vmem_size_mb"`
+	// 申请的磁盘总容量(MB)达到该值时需要审批
+	MinDiskSizeMb int `json:"min_disk_size_mb"`
+	// 一次申请的资源数量达到该值时需要审批
+	MinCount int `json:"min_count"`
+	// 预估月费用达到该值时需要审批, 需要配置费用模型
+	MinMonthlyCost float64 `json:"min_monthly_cost"`
+
+	// 审批人用户ID或名称
+	Approvers []string `json:"approvers"`
+
+	// 申请超时时间(小时), 超时后自动失效, 默认72
+	ExpireHours int `json:"expire_hours"`
+}
+
+type ApprovalPolicyUpdateInput struct {
+	apis.EnabledStatusInfrasResourceBaseUpdateInput
+
+	Actions  []string `json:"actions"`
+	Projects []string `json:"projects"`
+
+	MinVcpuCount   *int     `json:"min_vcpu_count"`
+	MinVmemSizeMb  *int     `json:"min_vmem_size_mb"`
+	MinDiskSizeMb  *int     `json:"min_disk_size_mb"`
+	MinCount       *int     `json:"min_count"`
+	MinMonthlyCost *float64 `json:"min_monthly_cost"`
+
+	Approvers   []string `json:"approvers"`
+	ExpireHours *int     `json:"expire_hours"`
+}
+
+type ApprovalPolicyListInput struct {
+	apis.EnabledStatusInfrasResourceBaseListInput
+
+	// 以资源类型过滤
+	ResourceType []string `json:"resource_type"`
+}
+
+type ApprovalPolicyDetails struct {
+	apis.EnabledStatusInfrasResourceBaseDetails
+
+	SApprovalPolicy
+
+	// 等待审批的申请数量
+	PendingCount int `json:"pending_count"`
+}
+
+type ApprovalRequestListInput struct {
+	apis.VirtualResourceListInput
+
+	// 以资源类型过滤
+	ResourceType []string `json:"resource_type"`
+	// 以操作过滤
+	Action []string `json:"action"`
+	// 以审批策略过滤
+	PolicyId string `json:"policy_id"`
+	// 以申请人过滤
+	RequesterId string `json:"requester_id"`
+}
+
+type ApprovalRequestDetails struct {
+	apis.VirtualResourceDetails
+
+	SApprovalRequest
+
+	// 审批策略名称
+	Policy string `json:"policy"`
+}
+
+type ApprovalRequestApproveInput struct {
+	// 审批意见
+	Comment string `json:"comment"`
+}
+
+type ApprovalRequestRejectInput struct {
+	// 驳回原因
+	// required: true
+	Reason string `json:"reason"`
+}
+
+// ApprovalSize is the amount of resources a request asks for, matched against policy thresholds
+type ApprovalSize struct {
+	Count      int    `json:"count"`
+	VcpuCount  int    `json:"vcpu_count"`
+	VmemSizeMb int    `json:"vmem_size_mb"`
+	DiskSizeMb int    `json:"disk_size_mb"`
+	Hypervisor string `json:"hypervisor"`
+}
@@ -35,6 +35,8 @@ const (
 	DISK_DRIVER_MPT2SAS    = "Mpt2SAS"
 	DISK_DRIVER_MARVELRAID = "MarvelRaid"
 	DISK_DRIVER_PCIE       = "PCIE"
+	// RAID configured out-of-band through Redfish Storage of BMC
+	DISK_DRIVER_REDFISH = "Redfish"
 
 	HDD_DISK_SPEC_TYPE = "HDD"
 	SSD_DISK_SPEC_TYPE = "SSD"
@@ -60,6 +62,7 @@ var (
 		DISK_DRIVER_HPSARAID,
 		DISK_DRIVER_MPT2SAS,
 		DISK_DRIVER_MARVELRAID,
+		DISK_DRIVER_REDFISH,
 	)
 
 	DISK_DRIVERS = sets.NewString(
@@ -0,0 +1,82 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	BIOS_PROFILE_STATUS_AVAILABLE = "available"
+
+	// 主机BIOS与模板一致
+	HOST_BIOS_PROFILE_CONVERGED = "converged"
+	// BIOS设置已下发, 等待重启生效
+	HOST_BIOS_PROFILE_PENDING = "pending"
+	// BIOS设置与模板不一致
+	HOST_BIOS_PROFILE_DRIFT = "drift"
+)
+
+type BiosProfileCreateInput struct {
+	apis.EnabledStatusInfrasResourceBaseCreateInput
+
+	// 适用的服务器厂商, 为空表示不限制, 不同厂商的BIOS属性名称不同
+	// example: Dell Inc.
+	Manufacture string `json:"manufacture"`
+
+	// 期望的BIOS属性, Redfish Bios/Attributes中的属性名及取值
+	// example: {"ProcVirtualization":"Enabled","SriovGlobalEnable":"Enabled","BootMode":"Uefi"}
+	Attributes jsonutils.JSONObject `json:"attributes"`
+}
+
+type BiosProfileUpdateInput struct {
+	apis.EnabledStatusInfrasResourceBaseUpdateInput
+
+	// 期望的BIOS属性
+	Attributes jsonutils.JSONObject `json:"attributes"`
+}
+
+type BiosProfileListInput struct {
+	apis.EnabledStatusInfrasResourceBaseListInput
+
+	// 以服务器厂商过滤
+	Manufacture []string `json:"manufacture"`
+}
+
+type BiosProfileDetails struct {
+	apis.EnabledStatusInfrasResourceBaseDetails
+
+	SBiosProfile
+
+	// 关联的宿主机数量
+	HostCount int `json:"host_count"`
+}
+
+type HostApplyBiosProfileInput struct {
+	// BIOS模板ID或名称
+	BiosProfileId string `json:"bios_profile_id"`
+
+	// swagger:ignore
+	// Deprecated
+	BiosProfile string `json:"bios_profile" yunion-deprecated-by:"bios_profile_id"`
+
+	// 属性下发后是否自动重启使其生效并检查是否一致
+	AutoReboot bool `json:"auto_reboot"`
+
+	// 只检查BIOS与模板是否一致, 不修改
+	CheckOnly bool `json:"check_only"`
+}
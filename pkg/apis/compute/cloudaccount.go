@@ -15,6 +15,8 @@
 package compute
 
 import (
+	"time"
+
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/pkg/utils"
 
@@ -461,3 +463,37 @@ type GetCloudaccountSamlOutput struct {
 	// initial SAML SSO login URL for this cloudaccount
 	InitLoginUrl string `json:"init_login_url,allowempty"`
 }
+
+// SyncPlanSample is a resource which the sync would add, update or remove
+type SyncPlanSample struct {
+	// local id, empty for resource to add
+	Id         string `json:"id,omitempty"`
+	Name       string `json:"name"`
+	ExternalId string `json:"external_id"`
+	// changed fields of resource to update, e.g. "status: running -> ready"
+	Diff []string `json:"diff,omitempty"`
+}
+
+// SyncPlanItem is the planned changes of a resource type
+type SyncPlanItem struct {
+	// count of local records compared
+	LocalCnt  int `json:"local_cnt"`
+	AddCnt    int `json:"add_cnt"`
+	UpdateCnt int `json:"update_cnt"`
+	DelCnt    int `json:"del_cnt"`
+
+	Added   []SyncPlanSample `json:"added,omitempty"`
+	Updated []SyncPlanSample `json:"updated,omitempty"`
+	Removed []SyncPlanSample `json:"removed,omitempty"`
+}
+
+type CloudaccountSyncPlanOutput struct {
+	// planned changes keyed by resource type, e.g. servers
+	Resources map[string]*SyncPlanItem `json:"resources"`
+	// resource types whose removals exceed cloud_sync_removal_threshold_percent
+	RemovalExceeded []string `json:"removal_exceeded,omitempty"`
+	// errors fetching remote resources, resources under them are not compared
+	Errors []string `json:"errors,omitempty"`
+	// time the plan was made
+	PlannedAt time.Time `json:"planned_at"`
+}
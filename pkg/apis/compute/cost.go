@@ -0,0 +1,161 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"time"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	// 每vCPU小时
+	COST_METRIC_CPU = "cpu"
+	// 每GB内存小时
+	COST_METRIC_MEM = "mem"
+	// 每GB磁盘小时, 规格为存储介质
+	COST_METRIC_DISK = "disk"
+	// 每个弹性IP小时
+	COST_METRIC_EIP = "eip"
+	// 每Mbps带宽小时
+	COST_METRIC_BANDWIDTH = "bandwidth"
+	// 每块GPU小时, 规格为GPU型号
+	COST_METRIC_GPU = "gpu"
+
+	COST_DEFAULT_CURRENCY = "CNY"
+
+	SHOWBACK_GROUP_BY_PROJECT = "project"
+	SHOWBACK_GROUP_BY_DOMAIN  = "domain"
+	SHOWBACK_GROUP_BY_TAG     = "tag"
+
+	SHOWBACK_FORMAT_CSV = "csv"
+)
+
+var COST_METRICS = []string{
+	COST_METRIC_CPU,
+	COST_METRIC_MEM,
+	COST_METRIC_DISK,
+	COST_METRIC_EIP,
+	COST_METRIC_BANDWIDTH,
+	COST_METRIC_GPU,
+}
+
+type CostRateCreateInput struct {
+	apis.StandaloneResourceCreateInput
+
+	// 计量项
+	// enum: cpu, mem, disk, eip, bandwidth, gpu
+	Metric string `json:"metric"`
+
+	// 规格, 磁盘为存储介质(ssd, rotate, hybrid), GPU为型号, 为空表示该计量项的默认价格
+	// example: ssd
+	Spec string `json:"spec"`
+
+	// 单价, 每单位每小时
+	// example: 0.05
+	Price float64 `json:"price"`
+
+	// 货币
+	// default: CNY
+	Currency string `json:"currency"`
+}
+
+type CostRateUpdateInput struct {
+	apis.StandaloneResourceBaseUpdateInput
+
+	Price    *float64 `json:"price"`
+	Currency string   `json:"currency"`
+}
+
+type CostRateListInput struct {
+	apis.StandaloneResourceListInput
+
+	// 以计量项过滤
+	Metric []string `json:"metric"`
+}
+
+type CostRateDetails struct {
+	apis.StandaloneResourceDetails
+
+	SCostRate
+}
+
+type UsageRecordListInput struct {
+	apis.ResourceBaseListInput
+	apis.ProjectizedResourceListInput
+
+	// 以计量项过滤
+	Metric []string `json:"metric"`
+	// 以资源类型过滤
+	ResourceType []string `json:"resource_type"`
+	// 计量开始时间(包含)
+	StartTime time.Time `json:"start_time"`
+	// 计量结束时间(不包含)
+	EndTime time.Time `json:"end_time"`
+}
+
+type UsageRecordDetails struct {
+	apis.ResourceBaseDetails
+	apis.ProjectizedResourceInfo
+
+	SUsageRecord
+}
+
+type ShowbackReportInput struct {
+	// 统计开始时间, 默认为本月初
+	StartTime time.Time `json:"start_time"`
+	// 统计结束时间, 默认为当前时间
+	EndTime time.Time `json:"end_time"`
+
+	// 分组方式
+	// enum: project, domain, tag
+	// default: project
+	GroupBy string `json:"group_by"`
+	// 按标签分组时的标签key
+	// example: user:department
+	TagKey string `json:"tag_key"`
+
+	// 导出格式, 为csv时结果中包含csv内容
+	Format string `json:"format"`
+}
+
+type ShowbackReportRow struct {
+	// 分组的ID, 按标签分组时为标签的值
+	Key string `json:"key"`
+	// 分组的名称
+	Name string `json:"name"`
+
+	Currency string `json:"currency"`
+
+	Cpu       float64 `json:"cpu"`
+	Mem       float64 `json:"mem"`
+	Disk      float64 `json:"disk"`
+	Eip       float64 `json:"eip"`
+	Bandwidth float64 `json:"bandwidth"`
+	Gpu       float64 `json:"gpu"`
+
+	Total float64 `json:"total"`
+}
+
+type ShowbackReportOutput struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	GroupBy   string    `json:"group_by"`
+
+	Rows []ShowbackReportRow `json:"rows"`
+
+	// csv格式的报表内容
+	Csv string `json:"csv,omitempty"`
+}
@@ -54,6 +54,9 @@ const (
 	VM_ATTACH_DISK_FAILED = "attach_disk_fail"
 	VM_DETACH_DISK_FAILED = "detach_disk_fail"
 
+	VM_DISK_CHANGE_STORAGE      = "disk_change_storage"
+	VM_DISK_CHANGE_STORAGE_FAIL = "disk_change_storage_fail"
+
 	VM_START_SUSPEND  = "start_suspend"
 	VM_SUSPENDING     = "suspending"
 	VM_SUSPEND        = "suspend"
@@ -164,6 +167,7 @@ const (
 	HYPERVISOR_ZSTACK    = "zstack"
 	HYPERVISOR_GOOGLE    = "google"
 	HYPERVISOR_CTYUN     = "ctyun"
+	HYPERVISOR_PROXMOX   = "proxmox"
 
 	//	HYPERVISOR_DEFAULT = HYPERVISOR_KVM
 	HYPERVISOR_DEFAULT = HYPERVISOR_KVM
@@ -198,6 +202,7 @@ var HYPERVISORS = []string{
 	HYPERVISOR_ZSTACK,
 	HYPERVISOR_GOOGLE,
 	HYPERVISOR_CTYUN,
+	HYPERVISOR_PROXMOX,
 }
 
 var ONECLOUD_HYPERVISORS = []string{
@@ -221,6 +226,7 @@ var PRIVATE_CLOUD_HYPERVISORS = []string{
 	HYPERVISOR_ZSTACK,
 	HYPERVISOR_OPENSTACK,
 	HYPERVISOR_APSARA,
+	HYPERVISOR_PROXMOX,
 }
 
 // var HYPERVISORS = []string{HYPERVISOR_ALIYUN}
@@ -241,6 +247,7 @@ var HYPERVISOR_HOSTTYPE = map[string]string{
 	HYPERVISOR_ZSTACK:    HOST_TYPE_ZSTACK,
 	HYPERVISOR_GOOGLE:    HOST_TYPE_GOOGLE,
 	HYPERVISOR_CTYUN:     HOST_TYPE_CTYUN,
+	HYPERVISOR_PROXMOX:   HOST_TYPE_PROXMOX,
 }
 
 var HOSTTYPE_HYPERVISOR = map[string]string{
@@ -259,6 +266,7 @@ var HOSTTYPE_HYPERVISOR = map[string]string{
 	HOST_TYPE_ZSTACK:     HYPERVISOR_ZSTACK,
 	HOST_TYPE_GOOGLE:     HYPERVISOR_GOOGLE,
 	HOST_TYPE_CTYUN:      HYPERVISOR_CTYUN,
+	HOST_TYPE_PROXMOX:    HYPERVISOR_PROXMOX,
 }
 
 const (
@@ -6,4 +6,49 @@ const (
 	MIRROR_JOB_FAILED = "failed"
 )
 
+const (
+	// progress of running change-disk-storage mirror job, percentage in float
+	CHANGE_DISK_STORAGE_PROGRESS = "__change_disk_storage_progress"
+)
+
+const (
+	// restart priority of guest on host down, larger is earlier
+	VM_METADATA_HA_PRIORITY = "__ha_priority"
+	// id of guest rebuilt from instance snapshot on host down
+	VM_METADATA_HA_REBUILT_GUEST = "__ha_rebuilt_guest_id"
+	// id of origin guest which this guest rebuilt from
+	VM_METADATA_HA_REBUILT_FROM = "__ha_rebuilt_from"
+
+	VM_HA_PRIORITY_MIN = 0
+	VM_HA_PRIORITY_MAX = 100
+)
+
+const (
+	// "required" makes the metadata service reject requests without session token
+	VM_METADATA_HTTP_TOKENS          = "metadata_http_tokens"
+	VM_METADATA_HTTP_TOKENS_REQUIRED = "required"
+	VM_METADATA_HTTP_TOKENS_OPTIONAL = "optional"
+	// ip ttl of session token response, limits how many hops away from guest the token can be got
+	VM_METADATA_HTTP_PUT_RESPONSE_HOP_LIMIT = "metadata_http_put_response_hop_limit"
+	// vendor data of cloud-init served by metadata service, overrides the one of host
+	VM_METADATA_VENDOR_DATA = "vendor_data"
+)
+
+const (
+	// keystone role bound to guest, tokens of the role are served to guest by metadata service
+	VM_METADATA_INSTANCE_ROLE_ID = "__instance_role_id"
+	// project tokens of the instance role are scoped to
+	VM_METADATA_INSTANCE_ROLE_PROJECT_ID = "__instance_role_project_id"
+)
+
+const (
+	// volume label of config drive attached to guest, empty means no config drive
+	VM_METADATA_CONFIG_DRIVE = "config_drive"
+
+	CONFIG_DRIVE_OPENSTACK = "config-2"
+	CONFIG_DRIVE_NOCLOUD   = "cidata"
+)
+
+var CONFIG_DRIVE_FORMATS = []string{CONFIG_DRIVE_OPENSTACK, CONFIG_DRIVE_NOCLOUD}
+
 const BASE_INSTANCE_SNAPSHOT_ID = "__base_instance_snapshot_id"
@@ -17,6 +17,8 @@ package compute
 import (
 	"time"
 
+	"yunion.io/x/jsonutils"
+
 	"yunion.io/x/onecloud/pkg/apis"
 	"yunion.io/x/onecloud/pkg/apis/billing"
 )
@@ -378,6 +380,23 @@ type GuestLiveMigrateInput struct {
 	SkipCpuCheck *bool `json:"skip_cpu_check"`
 }
 
+type ServerChangeDiskStorageInput struct {
+	// 要更换存储的磁盘Id或名称, 磁盘必须挂载在该虚拟机上
+	DiskId string `json:"disk_id"`
+	// 目标存储Id或名称, 存储必须挂载在虚拟机所在宿主机上
+	TargetStorageId string `json:"target_storage_id"`
+	// 迁移完成后是否保留源磁盘
+	KeepOriginDisk bool `json:"keep_origin_disk"`
+}
+
+type ServerChangeDiskStorageInternalInput struct {
+	ServerChangeDiskStorageInput
+
+	StorageId    string `json:"storage_id"`
+	TargetDiskId string `json:"target_disk_id"`
+	GuestStatus  string `json:"guest_status"`
+}
+
 type GuestSetSecgroupInput struct {
 	// 安全组Id列表
 	// 实例必须处于运行,休眠或者关机状态
@@ -507,3 +526,60 @@ type ServerDeleteInput struct {
 	// default: false
 	DeleteDisks bool
 }
+
+type ServerSetHaPriorityInput struct {
+	// 宿主机宕机后的重启优先级, 数值越大越先恢复
+	// minimum: 0
+	// maximum: 100
+	Priority int `json:"priority"`
+}
+
+// InstanceIdentityDocument describes the guest, signed by host serving it in metadata service
+type InstanceIdentityDocument struct {
+	InstanceId   string   `json:"instance_id"`
+	InstanceName string   `json:"instance_name"`
+	ProjectId    string   `json:"project_id"`
+	DomainId     string   `json:"domain_id"`
+	HostId       string   `json:"host_id"`
+	ZoneId       string   `json:"zone_id"`
+	Zone         string   `json:"zone"`
+	RegionId     string   `json:"region_id"`
+	Region       string   `json:"region"`
+	PrivateIps   []string `json:"private_ips"`
+	Hypervisor   string   `json:"hypervisor"`
+	// time the document is issued, truncated to minute
+	IssuedAt time.Time `json:"issued_at"`
+	// document is rejected by verify-identity-document after this time
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ServerVerifyIdentityDocumentInput struct {
+	// instance identity document got from /latest/dynamic/instance-identity/document
+	Document string `json:"document"`
+	// base64 encoded signature got from /latest/dynamic/instance-identity/signature
+	Signature string `json:"signature"`
+}
+
+type ServerVerifyIdentityDocumentOutput struct {
+	Valid bool `json:"valid"`
+	// reason of invalid document
+	Reason string `json:"reason,omitempty"`
+
+	Document *InstanceIdentityDocument `json:"document,omitempty"`
+	// host the guest is on now, differs from host_id of document after migration
+	CurrentHostId string `json:"current_host_id,omitempty"`
+}
+
+type ServerBindInstanceRoleInput struct {
+	// keystone role (ID or Name) bound to the server
+	Role string `json:"role"`
+	// project (ID or Name) tokens of the role are scoped to, default to project of the server
+	Project string `json:"project"`
+}
+
+type ServerInstanceCredentialOutput struct {
+	// keystone v3 token of the instance role
+	Token jsonutils.JSONObject `json:"token"`
+	// time when the token expires
+	ExpiresAt time.Time `json:"expires_at"`
+}
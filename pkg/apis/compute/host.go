@@ -15,6 +15,8 @@
 package compute
 
 import (
+	"time"
+
 	"yunion.io/x/jsonutils"
 
 	"yunion.io/x/onecloud/pkg/apis"
@@ -110,6 +112,14 @@ type HostListInput struct {
 	Version []string `json:"version"`
 	// OVN软件版本
 	OvnVersion []string `json:"ovn_version"`
+	// OVS-DPDK数据面的DPDK版本
+	OvsDpdkVersion []string `json:"ovs_dpdk_version"`
+	// 以关联的BIOS模板过滤
+	BiosProfileId string `json:"bios_profile_id"`
+	// BIOS与模板的一致状态
+	BiosProfileStatus []string `json:"bios_profile_status"`
+	// 硬件健康状态
+	HardwareHealth []string `json:"hardware_health"`
 	// 是否处于维护状态
 	IsMaintenance *bool `json:"is_maintenance"`
 	// 是否为导入的宿主机
@@ -186,6 +196,8 @@ type HostDetails struct {
 	// 允许开启宿主机健康检查
 	AllowHealthCheck      bool `json:"allow_health_check"`
 	AutoMigrateOnHostDown bool `json:"auto_migrate_on_host_down"`
+	// 宿主机宕机时的隔离方式
+	FenceMethodOnHostDown string `json:"fence_method_on_host_down"`
 
 	// reserved resource for isolated device
 	ReservedResourceForGpu IsolatedDeviceReservedResourceInput `json:"reserved_resource_for_gpu"`
@@ -266,6 +278,8 @@ type HostRegisterMetadata struct {
 	SysWarn                      string `json:"sys_warn,allowempty"`
 	RootPartitionTotalCapacityMB int64  `json:"root_partition_total_capacity_mb"`
 	RootPartitionUsedCapacityMB  int64  `json:"root_partition_used_capacity_mb"`
+	// PEM encoded public key verifying instance identity documents signed by host
+	IdentityPublicKey string `json:"identity_public_key,omitempty"`
 }
 
 type HostAccessAttributes struct {
@@ -340,6 +354,8 @@ type HostIpmiAttributes struct {
 	IpmiCdromBoot *bool `json:"ipmi_cdrom_boot"`
 	// ipmi_pxe_boot
 	IpmiPxeBoot *bool `json:"ipmi_pxe_boot"`
+	// 通过Redfish带外配置RAID
+	IpmiRedfishRaid *bool `json:"ipmi_redfish_raid"`
 }
 
 type HostCreateInput struct {
@@ -378,6 +394,8 @@ type HostCreateInput struct {
 	Version string `json:"version"`
 	// OVN软件版本
 	OvnVersion string `json:"ovn_version"`
+	// OVS-DPDK数据面的DPDK版本
+	OvsDpdkVersion string `json:"ovs_dpdk_version"`
 
 	// 是否为导入的宿主机
 	IsImport *bool `json:"is_import"`
@@ -399,6 +417,15 @@ type HostUpdateInput struct {
 	// IPMI info
 	IpmiInfo jsonutils.JSONObject `json:"ipmi_info"`
 
+	// 固件清单
+	FirmwareInventory jsonutils.JSONObject `json:"firmware_inventory"`
+	// BIOS与模板的一致状态
+	BiosProfileStatus string `json:"bios_profile_status"`
+	// BIOS与模板不一致的属性
+	BiosDrift jsonutils.JSONObject `json:"bios_drift"`
+	// 硬件健康状态
+	HardwareHealth string `json:"hardware_health"`
+
 	// 机架
 	Rack string `json:"rack"`
 	// 机位
@@ -416,6 +443,8 @@ type HostUpdateInput struct {
 	Version string `json:"version"`
 	// OVN软件版本
 	OvnVersion string `json:"ovn_version"`
+	// OVS-DPDK数据面的DPDK版本
+	OvsDpdkVersion string `json:"ovs_dpdk_version"`
 	// 是否为裸金属
 	IsBaremetal *bool `json:"is_baremetal"`
 
@@ -428,3 +457,61 @@ type HostUpdateInput struct {
 	// 主机启动模式, 可能值位PXE和ISO
 	BootMode string `json:"boot_mode"`
 }
+
+type HostFirmwareUpdateInput struct {
+	// 固件镜像地址, BMC通过SimpleUpdate下载, 支持http/https等协议
+	ImageUri string `json:"image_uri"`
+	// 更新方式, simple: BMC通过ImageUri拉取镜像; push: baremetal agent下载镜像后通过multipart推送给BMC
+	// enum: simple, push
+	Method string `json:"method"`
+	// 需要更新的固件, FirmwareInventory中的资源路径, 为空时由BMC根据镜像决定
+	Targets []string `json:"targets"`
+	// 固件生效需要重启时是否自动重启
+	AutoReboot bool `json:"auto_reboot"`
+	// 维护窗口开始时间, 重启只在维护窗口内进行
+	// 自动重启时维护窗口开始时间距当前不能超过region的firmware_maintenance_window_max_wait_seconds
+	// 固件暂存完成时已错过维护窗口则不重启, 固件保持暂存状态, 下次重启时生效
+	MaintenanceWindowStart time.Time `json:"maintenance_window_start"`
+	// 维护窗口结束时间
+	MaintenanceWindowEnd time.Time `json:"maintenance_window_end"`
+}
+
+type HostAutoMigrateOnHostDownInput struct {
+	// 宿主机宕机时是否自动迁移虚拟机
+	// enum: enable, disable
+	AutoMigrateOnHostDown string `json:"auto_migrate_on_host_down"`
+
+	// 宿主机宕机后迁移虚拟机前的隔离方式
+	// enum: none, ipmi, watchdog
+	// default: none
+	FenceMethod string `json:"fence_method"`
+}
+
+type HostEvacuateInput struct {
+	// 隔离宿主机的方式
+	// enum: none, ipmi, watchdog
+	// default: none
+	FenceMethod string `json:"fence_method"`
+
+	// 共享存储虚拟机优先迁移的目标宿主机
+	PreferHostId string `json:"prefer_host_id"`
+
+	// 本地存储虚拟机是否使用最新的主机快照重建, 仅在 ipmi 隔离宿主机后允许
+	// default: false
+	RebuildLocalGuests *bool `json:"rebuild_local_guests"`
+}
+
+type HostRebalanceMigration struct {
+	GuestId     string `json:"guest_id"`
+	GuestName   string `json:"guest_name"`
+	SrcHostId   string `json:"src_host_id"`
+	SrcHostName string `json:"src_host_name"`
+	DstHostId   string `json:"dst_host_id"`
+	DstHostName string `json:"dst_host_name"`
+	Reason      string `json:"reason"`
+}
+
+type HostRebalancePlanOutput struct {
+	// 最近一轮负载均衡计划中迁出或迁入该宿主机的虚拟机
+	Migrations []HostRebalanceMigration `json:"migrations"`
+}
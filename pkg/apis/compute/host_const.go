@@ -34,6 +34,7 @@ const (
 	HOST_TYPE_ZSTACK    = "zstack"
 	HOST_TYPE_GOOGLE    = "google"
 	HOST_TYPE_CTYUN     = "ctyun"
+	HOST_TYPE_PROXMOX   = "proxmox"
 
 	HOST_TYPE_DEFAULT = HOST_TYPE_HYPERVISOR
 
@@ -79,16 +80,61 @@ const (
 	BAREMETAL_EJECTING_ISO    = "ejecting_iso"
 	BAREMETAL_EJECT_FAIL      = "eject_fail"
 
+	BAREMETAL_START_FIRMWARE_UPDATE = "start_firmware_update"
+	BAREMETAL_FIRMWARE_UPDATING     = "firmware_updating"
+	BAREMETAL_FIRMWARE_UPDATE_FAIL  = "firmware_update_fail"
+
+	BAREMETAL_START_BIOS_APPLY = "start_bios_apply"
+	BAREMETAL_BIOS_APPLYING    = "bios_applying"
+	BAREMETAL_BIOS_APPLY_FAIL  = "bios_apply_fail"
+
+	HOST_HARDWARE_HEALTH_OK       = "ok"
+	HOST_HARDWARE_HEALTH_WARNING  = "warning"
+	HOST_HARDWARE_HEALTH_CRITICAL = "critical"
+
+	HOST_START_EVACUATE = "start_evacuate"
+	HOST_FENCING        = "fencing"
+	HOST_FENCE_FAIL     = "fence_fail"
+	HOST_EVACUATING     = "evacuating"
+	HOST_EVACUATE_FAIL  = "evacuate_fail"
+
 	HOST_STATUS_RUNNING = BAREMETAL_RUNNING
 	HOST_STATUS_READY   = BAREMETAL_READY
 	HOST_STATUS_UNKNOWN = BAREMETAL_UNKNOWN
 )
 
+const (
+	// do not fence host, only guests on shared storage are safe to restart
+	HOST_FENCE_METHOD_NONE = "none"
+	// power off host through ipmi or redfish bmc
+	HOST_FENCE_METHOD_IPMI = "ipmi"
+	// host shutdown servers by itself on losing health lease, just wait
+	HOST_FENCE_METHOD_WATCHDOG = "watchdog"
+)
+
+var HOST_FENCE_METHODS = []string{
+	HOST_FENCE_METHOD_NONE,
+	HOST_FENCE_METHOD_IPMI,
+	HOST_FENCE_METHOD_WATCHDOG,
+}
+
 const (
 	BAREMETAL_CDROM_ACTION_INSERT = "insert"
 	BAREMETAL_CDROM_ACTION_EJECT  = "eject"
 )
 
+const (
+	// BMC fetch firmware image by itself through UpdateService.SimpleUpdate
+	FIRMWARE_UPDATE_METHOD_SIMPLE = "simple"
+	// baremetal agent download image and push to BMC through MultipartHttpPushUri
+	FIRMWARE_UPDATE_METHOD_PUSH = "push"
+)
+
+var FIRMWARE_UPDATE_METHODS = []string{
+	FIRMWARE_UPDATE_METHOD_SIMPLE,
+	FIRMWARE_UPDATE_METHOD_PUSH,
+}
+
 const (
 	HostResourceTypeShared         = "shared"
 	HostResourceTypeDefault        = HostResourceTypeShared
@@ -113,6 +159,7 @@ var HOST_TYPES = []string{
 	HOST_TYPE_ZSTACK,
 	HOST_TYPE_CTYUN,
 	HOST_TYPE_GOOGLE,
+	HOST_TYPE_PROXMOX,
 }
 
 var NIC_TYPES = []string{NIC_TYPE_IPMI, NIC_TYPE_ADMIN}
@@ -54,6 +54,9 @@ type IsolatedDeviceListInput struct {
 	// 设备VENDOE编号
 	VendorDeviceId []string `json:"vendor_device_id"`
 
+	// SR-IOV VF 所属物理网卡的二层网络
+	WireId []string `json:"wire_id"`
+
 	// 展示物理机的上的设备
 	ShowBaremetalIsolatedDevices bool `json:"show_baremetal_isolated_devices"`
 }
@@ -78,6 +81,9 @@ type IsolatedDeviceCreateInput struct {
 
 	// 设备VendorId
 	VendorDeviceId string `json:"vendor_device_id"`
+
+	// SR-IOV VF 所属物理网卡的二层网络
+	WireId string `json:"wire_id"`
 }
 
 type IsolatedDeviceReservedResourceInput struct {
@@ -94,4 +100,7 @@ type IsolatedDeviceReservedResourceInput struct {
 type IsolatedDeviceUpdateInput struct {
 	apis.StandaloneResourceBaseUpdateInput
 	IsolatedDeviceReservedResourceInput
+
+	// SR-IOV VF 所属物理网卡的二层网络
+	WireId string `json:"wire_id"`
 }
@@ -20,6 +20,7 @@ const (
 	GPU_VGA_TYPE    = "GPU-VGA" // # for display
 	USB_TYPE        = "USB"
 	NIC_TYPE        = "NIC"
+	SRIOV_VF_TYPE   = "SRIOV-VF" // # SR-IOV virtual function of nic
 
 	NVIDIA_VENDOR_ID = "10de"
 	AMD_VENDOR_ID    = "1002"
@@ -27,6 +28,7 @@ const (
 
 var VALID_GPU_TYPES = []string{GPU_HPC_TYPE, GPU_VGA_TYPE}
 
+// SR-IOV VF is allocated along with guest network, not in VALID_PASSTHROUGH_TYPES
 var VALID_PASSTHROUGH_TYPES = []string{DIRECT_PCI_TYPE, USB_TYPE, NIC_TYPE, GPU_HPC_TYPE, GPU_VGA_TYPE}
 
 var ID_VENDOR_MAP = map[string]string{
@@ -45,6 +45,11 @@ const (
 	NETWORK_STATUS_DELETING      = "deleting"
 	NETWORK_STATUS_DELETED       = "deleted"
 	NETWORK_STATUS_DELETE_FAILED = "delete_failed"
+
+	// guest nic passthrough a SR-IOV VF of host nic
+	NETWORK_DRIVER_VFIO = "vfio-pci"
+	// guest nic attached as vhost-user port of OVS-DPDK datapath
+	NETWORK_DRIVER_VHOST_USER = "vhost-user"
 )
 
 var (
@@ -0,0 +1,170 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	STACK_STATUS_CREATING      = "creating"
+	STACK_STATUS_CREATE_FAILED = "create_failed"
+	STACK_STATUS_UPDATING      = "updating"
+	STACK_STATUS_UPDATE_FAILED = "update_failed"
+	STACK_STATUS_READY         = "ready"
+	STACK_STATUS_DELETING      = "deleting"
+	STACK_STATUS_DELETE_FAILED = "delete_failed"
+
+	STACK_RESOURCE_STATUS_PENDING       = "pending"
+	STACK_RESOURCE_STATUS_APPLYING      = "applying"
+	STACK_RESOURCE_STATUS_APPLY_FAILED  = "apply_failed"
+	STACK_RESOURCE_STATUS_READY         = "ready"
+	STACK_RESOURCE_STATUS_DELETING      = "deleting"
+	STACK_RESOURCE_STATUS_DELETE_FAILED = "delete_failed"
+
+	// 底层资源与模板一致
+	STACK_DRIFT_IN_SYNC = "in_sync"
+	// 底层资源被修改
+	STACK_DRIFT_MODIFIED = "modified"
+	// 底层资源被删除
+	STACK_DRIFT_MISSING = "missing"
+
+	STACK_RESOURCE_TYPE_NETWORK      = "network"
+	STACK_RESOURCE_TYPE_SECGROUP     = "secgroup"
+	STACK_RESOURCE_TYPE_DISK         = "disk"
+	STACK_RESOURCE_TYPE_SERVER       = "server"
+	STACK_RESOURCE_TYPE_EIP          = "eip"
+	STACK_RESOURCE_TYPE_LOADBALANCER = "loadbalancer"
+
+	STACK_PLAN_CREATE  = "create"
+	STACK_PLAN_UPDATE  = "update"
+	STACK_PLAN_REPLACE = "replace"
+	STACK_PLAN_DELETE  = "delete"
+	STACK_PLAN_NOOP    = "noop"
+
+	STACK_PARAMETER_STRING  = "string"
+	STACK_PARAMETER_NUMBER  = "number"
+	STACK_PARAMETER_BOOLEAN = "boolean"
+)
+
+var STACK_RESOURCE_TYPES = []string{
+	STACK_RESOURCE_TYPE_NETWORK,
+	STACK_RESOURCE_TYPE_SECGROUP,
+	STACK_RESOURCE_TYPE_DISK,
+	STACK_RESOURCE_TYPE_SERVER,
+	STACK_RESOURCE_TYPE_EIP,
+	STACK_RESOURCE_TYPE_LOADBALANCER,
+}
+
+// StackTemplate describes resources of a stack,
+// {"ref": "<name>"} in properties refers to a parameter or id of another resource of the stack
+type StackTemplate struct {
+	// 模板参数
+	Parameters map[string]StackParameter `json:"parameters"`
+
+	// 模板资源, key为资源在模板内的名称
+	Resources map[string]StackResourceTemplate `json:"resources"`
+}
+
+type StackParameter struct {
+	// 参数类型
+	// enum: string,number,boolean
+	Type string `json:"type"`
+
+	// 默认值, 未指定默认值的参数必须在创建时提供
+	Default jsonutils.JSONObject `json:"default"`
+
+	Description string `json:"description"`
+}
+
+type StackResourceTemplate struct {
+	// 资源类型
+	// enum: network,secgroup,disk,server,eip,loadbalancer
+	Type string `json:"type"`
+
+	// 资源创建参数, 与对应资源的创建接口一致
+	Properties jsonutils.JSONObject `json:"properties"`
+
+	// 显式依赖的资源
+	DependsOn []string `json:"depends_on"`
+}
+
+type StackCreateInput struct {
+	apis.VirtualResourceCreateInput
+
+	// 模板内容, YAML或JSON格式
+	// required: true
+	Template string `json:"template"`
+
+	// 模板参数取值
+	Parameters jsonutils.JSONObject `json:"parameters"`
+}
+
+type StackListInput struct {
+	apis.VirtualResourceListInput
+}
+
+type StackDetails struct {
+	apis.VirtualResourceDetails
+
+	SStack
+
+	// 栈管理的资源数量
+	ResourceCount int `json:"resource_count"`
+}
+
+type StackPlanInput struct {
+	// 新的模板内容, 为空则使用当前模板
+	Template string `json:"template"`
+
+	// 新的模板参数取值, 为空则使用当前取值
+	Parameters jsonutils.JSONObject `json:"parameters"`
+}
+
+type StackPlanStep struct {
+	// 资源在模板内的名称
+	Name string `json:"name"`
+	Type string `json:"type"`
+
+	// 执行的操作
+	// enum: create,update,replace,delete,noop
+	Action string `json:"action"`
+
+	// 底层资源ID
+	ResourceId string `json:"resource_id"`
+
+	// 变化的属性
+	Changes []string `json:"changes"`
+}
+
+type StackPlanOutput struct {
+	Steps []StackPlanStep `json:"steps"`
+}
+
+type StackResourceOutput struct {
+	Name         string               `json:"name"`
+	Type         string               `json:"type"`
+	Status       string               `json:"status"`
+	ResourceId   string               `json:"resource_id"`
+	Properties   jsonutils.JSONObject `json:"properties"`
+	DriftStatus  string               `json:"drift_status"`
+	DriftChanges []string             `json:"drift_changes"`
+}
+
+type StackResourcesOutput struct {
+	Resources []StackResourceOutput `json:"resources"`
+}
@@ -84,6 +84,12 @@ const (
 	STORAGE_ZSTACK_LOCAL_STORAGE = "localstorage"
 	STORAGE_ZSTACK_CEPH          = "ceph"
 
+	// Proxmox storage type
+	STORAGE_PROXMOX_DIR     = "dir"
+	STORAGE_PROXMOX_LVM     = "lvm"
+	STORAGE_PROXMOX_LVMTHIN = "lvmthin"
+	STORAGE_PROXMOX_ZFSPOOL = "zfspool"
+
 	// Google storage type
 	STORAGE_GOOGLE_LOCAL_SSD   = "local-ssd"   //本地SSD暂存盘 (最多8个)
 	STORAGE_GOOGLE_PD_STANDARD = "pd-standard" //标准永久性磁盘
@@ -135,9 +141,11 @@ var (
 		STORAGE_OPENSTACK_ISCSI, STORAGE_UCLOUD_CLOUD_NORMAL, STORAGE_UCLOUD_CLOUD_SSD,
 		STORAGE_UCLOUD_LOCAL_NORMAL, STORAGE_UCLOUD_LOCAL_SSD, STORAGE_UCLOUD_EXCLUSIVE_LOCAL_DISK,
 		STORAGE_ZSTACK_LOCAL_STORAGE, STORAGE_ZSTACK_CEPH, STORAGE_GPFS, STORAGE_CIFS,
+		STORAGE_PROXMOX_DIR, STORAGE_PROXMOX_LVM, STORAGE_PROXMOX_LVMTHIN, STORAGE_PROXMOX_ZFSPOOL,
 	}
 
-	HOST_STORAGE_LOCAL_TYPES = []string{STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_ZSTACK_LOCAL_STORAGE, STORAGE_OPENSTACK_NOVA}
+	HOST_STORAGE_LOCAL_TYPES = []string{STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_ZSTACK_LOCAL_STORAGE, STORAGE_OPENSTACK_NOVA,
+		STORAGE_PROXMOX_DIR, STORAGE_PROXMOX_LVM, STORAGE_PROXMOX_LVMTHIN, STORAGE_PROXMOX_ZFSPOOL}
 
 	STORAGE_LIMITED_TYPES = []string{STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_NAS, STORAGE_RBD, STORAGE_NFS, STORAGE_GPFS, STORAGE_VSAN, STORAGE_CIFS}
 
@@ -21,6 +21,52 @@ import (
 	"yunion.io/x/onecloud/pkg/apis/cloudprovider"
 )
 
+// SApprovalPolicy is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SApprovalPolicy.
+type SApprovalPolicy struct {
+	apis.SEnabledStatusInfrasResourceBase
+	// 适用的资源类型
+	ResourceType string `json:"resource_type"`
+	// 需要审批的操作, 为空表示全部操作
+	Actions interface{} `json:"actions"`
+	// 适用的项目ID, 为空表示全部项目
+	Projects       interface{} `json:"projects"`
+	MinVcpuCount   int         `json:"min_vcpu_count"`
+	MinVmemSizeMb  int         `json:"min_vmem_size_mb"`
+	MinDiskSizeMb  int         `json:"min_disk_size_mb"`
+	MinCount       int         `json:"min_count"`
+	MinMonthlyCost float64     `json:"min_monthly_cost"`
+	// 审批人用户ID
+	Approvers interface{} `json:"approvers"`
+	// 申请超时时间(小时)
+	ExpireHours int `json:"expire_hours"`
+}
+
+// SApprovalRequest is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SApprovalRequest.
+type SApprovalRequest struct {
+	apis.SVirtualResourceBase
+	// 匹配的审批策略
+	PolicyId string `json:"policy_id"`
+	// 资源类型
+	ResourceType string `json:"resource_type"`
+	// 申请的操作
+	Action string `json:"action"`
+	// 操作的资源ID, 创建申请执行后为新建资源的ID
+	ResourceId string `json:"resource_id"`
+	// 申请的资源规格
+	Size        interface{} `json:"size"`
+	RequesterId string      `json:"requester_id"`
+	Requester   string      `json:"requester"`
+	// 审批人
+	ApproverId string `json:"approver_id"`
+	Approver   string `json:"approver"`
+	// 审批意见或驳回原因
+	Comment string `json:"comment"`
+	// 超时时间
+	ExpiredAt time.Time `json:"expired_at"`
+	// 执行结果
+	Result interface{} `json:"result"`
+}
+
 // SAwsCachedLb is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SAwsCachedLb.
 type SAwsCachedLb struct {
 	apis.SVirtualResourceBase
@@ -65,6 +111,15 @@ type SBaremetalagent struct {
 	StoragecacheId string `json:"storagecache_id"`
 }
 
+// SBiosProfile is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SBiosProfile.
+type SBiosProfile struct {
+	apis.SEnabledStatusInfrasResourceBase
+	// 适用的服务器厂商
+	Manufacture string `json:"manufacture"`
+	// 期望的BIOS属性
+	Attributes interface{} `json:"attributes"`
+}
+
 // SBillingResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SBillingResourceBase.
 type SBillingResourceBase struct {
 	// 计费类型, 按量、包年包月
@@ -316,6 +371,19 @@ type SCloudregionschedtag struct {
 	SCloudregionResourceBase
 }
 
+// SCostRate is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SCostRate.
+type SCostRate struct {
+	apis.SStandaloneResourceBase
+	// 计量项
+	Metric string `json:"metric"`
+	// 规格, 为空表示默认价格
+	Spec string `json:"spec"`
+	// 单价, 每单位每小时
+	Price float64 `json:"price"`
+	// 货币
+	Currency string `json:"currency"`
+}
+
 // SDBInstance is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDBInstance.
 type SDBInstance struct {
 	apis.SVirtualResourceBase
@@ -1093,6 +1161,17 @@ type SHost struct {
 	IpmiIp string `json:"ipmi_ip"`
 	// IPMI详情
 	IpmiInfo interface{} `json:"ipmi_info"`
+	// 固件清单, 通过Redfish UpdateService/FirmwareInventory采集
+	FirmwareInventory interface{} `json:"firmware_inventory"`
+	// 关联的BIOS模板
+	BiosProfileId string `json:"bios_profile_id"`
+	// BIOS与模板的一致状态
+	BiosProfileStatus string `json:"bios_profile_status"`
+	// BIOS与模板不一致的属性
+	BiosDrift interface{} `json:"bios_drift"`
+	// 硬件健康状态, 由BMC的Redfish事件更新
+	// example: ok
+	HardwareHealth string `json:"hardware_health"`
 	// 宿主机状态
 	// example: online
 	HostStatus string `json:"host_status"`
@@ -1101,8 +1180,10 @@ type SHost struct {
 	// host服务软件版本
 	Version string `json:"version"`
 	// OVN软件版本
-	OvnVersion  string `json:"ovn_version"`
-	IsBaremetal bool   `json:"is_baremetal"`
+	OvnVersion string `json:"ovn_version"`
+	// OVS-DPDK数据面的DPDK版本, 为空表示不支持
+	OvsDpdkVersion string `json:"ovs_dpdk_version"`
+	IsBaremetal    bool   `json:"is_baremetal"`
 	// 是否处于维护状态
 	IsMaintenance     bool   `json:"is_maintenance"`
 	EnableHealthCheck bool   `json:"enable_health_check"`
@@ -1292,6 +1373,12 @@ type SIsolatedDevice struct {
 	// # pci address of `Bus:Device.Function` format, or usb bus address of `bus.addr`
 	Addr           string `json:"addr"`
 	VendorDeviceId string `json:"vendor_device_id"`
+	// # wire of SR-IOV physical function, only for SRIOV-VF
+	// 二层网络Id
+	WireId string `json:"wire_id"`
+	// # index of guest network which passthrough this SRIOV-VF
+	// 绑定的云主机网卡序号
+	NetworkIndex int `json:"network_index"`
 	// reserved memory size for isolated device, default 8G
 	ReservedMemory int `json:"reserved_memory"`
 	// reserved cpu count for isolated device, default 8
@@ -2231,6 +2318,29 @@ type SSnapshotPolicyResourceBase struct {
 	SnapshotpolicyId string `json:"snapshotpolicy_id"`
 }
 
+// SStack is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SStack.
+type SStack struct {
+	apis.SVirtualResourceBase
+	// 模板内容
+	Template string `json:"template"`
+	// 模板参数取值, 包含默认值
+	Parameters interface{} `json:"parameters"`
+}
+
+// SStackResource is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SStackResource.
+type SStackResource struct {
+	apis.SStatusStandaloneResourceBase
+	StackId      string `json:"stack_id"`
+	ResourceType string `json:"resource_type"`
+	// id of the underlying resource
+	ResourceId string `json:"resource_id"`
+	// applied properties, parameters are substituted and refs to resources are kept
+	Properties   interface{} `json:"properties"`
+	DependsOn    interface{} `json:"depends_on"`
+	DriftStatus  string      `json:"drift_status"`
+	DriftChanges interface{} `json:"drift_changes"`
+}
+
 // SStorage is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SStorage.
 type SStorage struct {
 	apis.SEnabledStatusInfrasResourceBase
@@ -2322,6 +2432,34 @@ type STimer struct {
 	IsExpired bool   `json:"is_expired"`
 }
 
+// SUsageRecord is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SUsageRecord.
+type SUsageRecord struct {
+	apis.SResourceBase
+	apis.SProjectizedResourceBase
+	// 自增Id
+	Id int64 `json:"id"`
+	// 计量的小时(UTC整点)
+	UsageHour time.Time `json:"usage_hour"`
+	// 资源类型
+	ResourceType string `json:"resource_type"`
+	// 计量项
+	Metric string `json:"metric"`
+	// 规格
+	Spec string `json:"spec"`
+	// 资源标签
+	Tags interface{} `json:"tags"`
+	// 资源数量
+	ResourceCount int `json:"resource_count"`
+	// 用量, 单位小时数, 例如vCPU小时
+	Quantity float64 `json:"quantity"`
+	// 计量时的单价
+	Price float64 `json:"price"`
+	// 费用
+	Cost float64 `json:"cost"`
+	// 货币
+	Currency string `json:"currency"`
+}
+
 // SVCenter is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SVCenter.
 type SVCenter struct {
 	apis.SEnabledStatusStandaloneResourceBase
@@ -30,6 +30,9 @@ const (
 	SystemAdminProject = "system"
 	SystemAdminRole    = "admin"
 
+	// name prefix of system accounts acting as instance roles
+	InstanceRoleUserPrefix = "instance-role."
+
 	AUTH_METHOD_PASSWORD = "password"
 	AUTH_METHOD_TOKEN    = "token"
 	AUTH_METHOD_AKSK     = "aksk"
@@ -37,6 +40,8 @@ const (
 	AUTH_METHOD_SAML     = "saml"
 	AUTH_METHOD_OIDC     = "oidc"
 	AUTH_METHOD_OAuth2   = "oauth2"
+	// short-lived token of instance role, minted by region for guests
+	AUTH_METHOD_INSTANCE = "instance"
 
 	// AUTH_METHOD_ID_PASSWORD = 1
 	// AUTH_METHOD_ID_TOKEN    = 2
@@ -84,7 +89,7 @@ const (
 )
 
 var (
-	AUTH_METHODS = []string{AUTH_METHOD_PASSWORD, AUTH_METHOD_TOKEN, AUTH_METHOD_AKSK, AUTH_METHOD_CAS}
+	AUTH_METHODS = []string{AUTH_METHOD_PASSWORD, AUTH_METHOD_TOKEN, AUTH_METHOD_AKSK, AUTH_METHOD_CAS, AUTH_METHOD_INSTANCE}
 
 	PASSWORD_PROTECTED_IDPS = []string{
 		IdentityDriverSQL,
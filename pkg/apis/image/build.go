@@ -0,0 +1,96 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+type ImageBuildFile struct {
+	// 文件在镜像中的绝对路径
+	// required: true
+	// example: /etc/motd
+	Path string `json:"path"`
+
+	// 文件内容
+	Content string `json:"content"`
+
+	// 八进制表示的文件权限, 默认0644
+	// example: 0755
+	Mode string `json:"mode"`
+}
+
+type ImageBuildRecipe struct {
+	// 在隔离的libguestfs虚拟机中安装的软件包
+	// example: ["nginx", "qemu-guest-agent"]
+	Packages []string `json:"packages"`
+
+	// 注入镜像的文件
+	Files []ImageBuildFile `json:"files"`
+
+	// 在隔离的libguestfs虚拟机中依次执行的shell脚本, 每个脚本不超过64KiB
+	Scripts []string `json:"scripts"`
+
+	// 设置为开机自启动的服务
+	// example: ["nginx"]
+	EnableServices []string `json:"enable_services"`
+
+	// 清空/etc/machine-id
+	ResetMachineId bool `json:"reset_machine_id"`
+
+	// 删除ssh host key
+	RemoveSshHostKeys bool `json:"remove_ssh_host_keys"`
+
+	// 清理/var/log及临时目录
+	CleanLogs bool `json:"clean_logs"`
+}
+
+type ImageBuildCreateInput struct {
+	apis.VirtualResourceCreateInput
+
+	// 基础镜像的ID或名称, 必须是可用的qcow2格式Linux镜像
+	// required: true
+	BaseImage string `json:"base_image"`
+
+	// 基础镜像ID
+	// swagger:ignore
+	BaseImageId string `json:"base_image_id"`
+
+	// 构建配方
+	// required: true
+	Recipe *ImageBuildRecipe `json:"recipe"`
+}
+
+type ImageBuildListInput struct {
+	apis.VirtualResourceListInput
+
+	// 以基础镜像过滤
+	BaseImage string `json:"base_image"`
+
+	// 以构建生成的镜像过滤
+	Image string `json:"image"`
+}
+
+type ImageBuildDetails struct {
+	apis.VirtualResourceDetails
+
+	SImageBuild
+
+	// 基础镜像名称
+	BaseImage string `json:"base_image"`
+
+	// 构建生成的镜像名称
+	Image string `json:"image"`
+}
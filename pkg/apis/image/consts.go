@@ -52,8 +52,30 @@ const (
 	IMAGE_PARTITION_TYPE      = "partition_type"
 	IMAGE_INSTALLED_CLOUDINIT = "installed_cloud_init"
 	IMAGE_DISABLE_USB_KBD     = "disable_usb_kbd"
+	IMAGE_VCPU_COUNT          = "vcpu_count"
+	IMAGE_NIC_COUNT           = "nic_count"
 
 	IMAGE_STATUS_UPDATING = "updating"
+
+	// image signature status
+	IMAGE_SIGNATURE_UNSIGNED = "unsigned"
+	IMAGE_SIGNATURE_VERIFIED = "verified"
+	IMAGE_SIGNATURE_INVALID  = "invalid"
+
+	// guest image import formats
+	GUEST_IMAGE_IMPORT_FORMAT_OVA = "ova"
+	GUEST_IMAGE_IMPORT_FORMAT_OVF = "ovf"
+
+	// image build status
+	IMAGE_BUILD_STATUS_BUILDING = "building"
+	IMAGE_BUILD_STATUS_READY    = "ready"
+	IMAGE_BUILD_STATUS_FAILED   = "build_failed"
+
+	// image build provenance properties
+	IMAGE_BUILD_ID                  = "build_id"
+	IMAGE_BUILD_BASE_IMAGE_ID       = "build_base_image_id"
+	IMAGE_BUILD_BASE_IMAGE_CHECKSUM = "build_base_image_checksum"
+	IMAGE_BUILD_RECIPE_SHA256       = "build_recipe_sha256"
 )
 
 const (
@@ -64,4 +86,6 @@ const (
 
 var (
 	ImageDeadStatus = []string{IMAGE_STATUS_DEACTIVATED, IMAGE_STATUS_KILLED, IMAGE_STATUS_DELETED, IMAGE_STATUS_PENDING_DELETE}
+
+	GuestImageImportFormats = []string{GUEST_IMAGE_IMPORT_FORMAT_OVA, GUEST_IMAGE_IMPORT_FORMAT_OVF}
 )
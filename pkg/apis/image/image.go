@@ -99,3 +99,17 @@ type ImageUpdateStatusInput struct {
 	// 更新镜像状态原因
 	Reason string `json:"reason"`
 }
+
+type ImageUploadSession struct {
+	// 已上传的字节数, 下一个分片应从此偏移开始上传
+	UploadOffset int64 `json:"upload_offset"`
+}
+
+type ImageFinishUploadInput struct {
+	// 镜像文件总大小, 单位Byte, 指定时校验已上传的大小
+	Size int64 `json:"size"`
+
+	// 镜像文件的MD5校验和
+	// required: true
+	Checksum string `json:"checksum"`
+}
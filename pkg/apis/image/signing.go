@@ -0,0 +1,56 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+type ImageSigningKeyCreateInput struct {
+	apis.StandaloneResourceCreateInput
+	apis.EnabledBaseResourceCreateInput
+
+	// PEM格式的RSA公钥
+	// required: true
+	PublicKey string `json:"public_key"`
+}
+
+type ImageSigningKeyListInput struct {
+	apis.StandaloneResourceListInput
+	apis.EnabledResourceBaseListInput
+
+	// 以公钥指纹过滤
+	Fingerprint []string `json:"fingerprint"`
+}
+
+type ImageSigningKeyDetails struct {
+	apis.StandaloneResourceDetails
+
+	SImageSigningKey
+
+	// 使用该公钥签名的镜像数量
+	ImageCount int `json:"image_count"`
+}
+
+type ImageSignInput struct {
+	// 签名公钥的ID或名称
+	// required: true
+	SigningKey string `json:"signing_key"`
+
+	// base64编码的镜像文件签名, 签名算法为RSASSA-PKCS1-v1_5 with SHA256
+	// 可用 openssl dgst -sha256 -sign key.pem image.qcow2 | base64 -w0 生成
+	// required: true
+	Signature string `json:"signature"`
+}
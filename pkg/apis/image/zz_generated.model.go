@@ -62,6 +62,31 @@ type SImage struct {
 	// image copy from url, save origin checksum before probe
 	// 从镜像时长导入的镜像校验和
 	OssChecksum string `json:"oss_checksum"`
+	// 镜像文件的SHA256, 签名时计算
+	Sha256 string `json:"sha256"`
+	// 签名公钥ID
+	SigningKeyId string `json:"signing_key_id"`
+	// base64编码的镜像签名
+	Signature string `json:"signature"`
+	// 签名状态, 可能值为: unsigned, verified, invalid
+	SignatureStatus string `json:"signature_status"`
+}
+
+// SImageBuild is an autogenerated struct via yunion.io/x/onecloud/pkg/image/models.SImageBuild.
+type SImageBuild struct {
+	apis.SVirtualResourceBase
+	// 基础镜像ID
+	BaseImageId string `json:"base_image_id"`
+	// 构建时基础镜像的校验和
+	BaseImageChecksum string `json:"base_image_checksum"`
+	// 构建生成的镜像ID
+	ImageId string `json:"image_id"`
+	// 构建配方
+	Recipe interface{} `json:"recipe"`
+	// 构建配方的SHA256
+	RecipeSha256 string `json:"recipe_sha256"`
+	// 构建日志
+	BuildLog string `json:"build_log"`
 }
 
 // SImageMember is an autogenerated struct via yunion.io/x/onecloud/pkg/image/models.SImageMember.
@@ -93,6 +118,7 @@ type SImageSubformat struct {
 	Location        string `json:"location"`
 	Checksum        string `json:"checksum"`
 	FastHash        string `json:"fast_hash"`
+	Sha256          string `json:"sha256"`
 	Status          string `json:"status"`
 	TorrentSize     int64  `json:"torrent_size"`
 	TorrentLocation string `json:"torrent_location"`
@@ -100,6 +126,16 @@ type SImageSubformat struct {
 	TorrentStatus   string `json:"torrent_status"`
 }
 
+// SImageSigningKey is an autogenerated struct via yunion.io/x/onecloud/pkg/image/models.SImageSigningKey.
+type SImageSigningKey struct {
+	apis.SStandaloneResourceBase
+	apis.SEnabledResourceBase
+	// PEM格式的RSA公钥
+	PublicKey string `json:"public_key"`
+	// 公钥指纹, 公钥DER编码的SHA256
+	Fingerprint string `json:"fingerprint"`
+}
+
 // SImageTag is an autogenerated struct via yunion.io/x/onecloud/pkg/image/models.SImageTag.
 type SImageTag struct {
 	SImagePeripheral
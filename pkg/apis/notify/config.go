@@ -32,13 +32,18 @@ type ConfigCreateInput struct {
 	// required: true
 	// example: {"app_id": "123456", "app_secret": "feishu_nihao"}
 	Content jsonutils.JSONObject `json:"content"`
+
+	// description: retry and rate limit of delivering notifications by this type
+	DeliveryPolicy *SDeliveryPolicy `json:"delivery_policy"`
 }
 
 type ConfigUpdateInput struct {
 	// description: config content
-	// required: true
 	// example: {"app_id": "123456", "app_secret": "feishu_nihao"}
 	Content jsonutils.JSONObject `json:"content"`
+
+	// description: retry and rate limit of delivering notifications by this type
+	DeliveryPolicy *SDeliveryPolicy `json:"delivery_policy"`
 }
 
 type ConfigDetails struct {
@@ -37,6 +37,13 @@ const (
 	RECEIVER_NOTIFICATION_SENT     = "sending"   // Nofity module has sent notification, but result unkown
 	RECEIVER_NOTIFICATION_OK       = "sent_ok"   // Notification was sent successfully
 	RECEIVER_NOTIFICATION_FAIL     = "sent_fail" // That sent a notification is failed
+	RECEIVER_NOTIFICATION_PENDING  = "pending"   // Notification is held in a bucket for digest or quiet hours
+	RECEIVER_NOTIFICATION_DEDUPED  = "deduped"   // Notification is dropped as identical one was sent recently
+
+	RECEIVER_NOTIFICATION_DEAD_LETTER = "dead_letter" // Notification is not retried anymore, waiting for replay
+
+	DELIVERY_ATTEMPT_OK     = "ok"
+	DELIVERY_ATTEMPT_FAILED = "failed"
 
 	VERIFICATION_SENT          = "sent"      // Verification was sent
 	VERIFICATION_SENT_FAIL     = "sent_fail" // Verification was sent failed
@@ -52,6 +59,12 @@ const (
 	NOTIFICATION_PRIORITY_CRITICAL  = "fatal"
 	NOTIFICATION_PRIORITY_NORMAL    = "normal"
 
+	// topic of template rendering digest of pending notifications
+	NOTIFICATION_DIGEST_TOPIC = "NOTIFICATION_DIGEST"
+
+	NOTIFICATION_BUCKET_REASON_DIGEST = "digest"
+	NOTIFICATION_BUCKET_REASON_QUIET  = "quiet"
+
 	NOTIFICATION_STATUS_RECEIVED = "received"
 	NOTIFICATION_STATUS_SENDING  = "sending"
 	NOTIFICATION_STATUS_FAILED   = "failed"
@@ -60,6 +73,11 @@ const (
 
 	NOTIFICATION_TAG_ALERT = "alert"
 
+	ESCALATION_STATUS_ESCALATING   = "escalating"
+	ESCALATION_STATUS_ACKNOWLEDGED = "acknowledged"
+	ESCALATION_STATUS_EXHAUSTED    = "exhausted"
+	ESCALATION_STATUS_CANCELLED    = "cancelled"
+
 	TEMPLATE_TYPE_TITLE   = "title"
 	TEMPLATE_TYPE_CONTENT = "content"
 	TEMPLATE_TYPE_REMOTE  = "remote"
@@ -0,0 +1,129 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"reflect"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+// SDeliveryPolicy controls retry and rate limit of delivering notifications by a contact type,
+// zero fields fall back to defaults in service options
+type SDeliveryPolicy struct {
+	// description: max attempts to deliver a notification before it is moved to dead letter queue
+	// example: 3
+	MaxAttempts int `json:"max_attempts"`
+
+	// description: seconds to wait before the first retry, doubled for every later retry
+	// example: 60
+	BackoffSeconds int `json:"backoff_seconds"`
+
+	// description: upper bound of seconds to wait between retries
+	// example: 3600
+	MaxBackoffSeconds int `json:"max_backoff_seconds"`
+
+	// description: messages sent by the send agent per second, 0 for unlimited
+	// example: 20
+	RateLimit float64 `json:"rate_limit"`
+
+	// description: max messages sent by the send agent in a burst
+	// example: 20
+	RateBurst int `json:"rate_burst"`
+}
+
+func (p SDeliveryPolicy) String() string {
+	return jsonutils.Marshal(p).String()
+}
+
+func (p SDeliveryPolicy) IsZero() bool {
+	return p == SDeliveryPolicy{}
+}
+
+type DeliveryAttemptListInput struct {
+	apis.StandaloneAnonResourceListInput
+
+	// description: filter by notification
+	NotificationId string `json:"notification_id"`
+
+	// description: filter by receiver
+	ReceiverId string `json:"receiver_id"`
+
+	// description: filter by contact type
+	// example: email
+	ContactType string `json:"contact_type"`
+
+	// description: filter by status of attempt
+	// enum: ok,failed
+	Status string `json:"status"`
+}
+
+type DeliveryAttemptDetails struct {
+	apis.StandaloneAnonResourceDetails
+}
+
+type NotificationDeadLetterListInput struct {
+	// description: filter by notification
+	NotificationId string `json:"notification_id"`
+
+	// description: filter by contact type
+	// example: email
+	ContactType string `json:"contact_type"`
+
+	// description: max count of dead letters returned, default 20
+	Limit int `json:"limit"`
+
+	Offset int `json:"offset"`
+}
+
+type NotificationDeadLetter struct {
+	NotificationId string    `json:"notification_id"`
+	ReceiverId     string    `json:"receiver_id"`
+	Contact        string    `json:"contact"`
+	ContactType    string    `json:"contact_type"`
+	Topic          string    `json:"topic"`
+	Attempts       int       `json:"attempts"`
+	FailedReason   string    `json:"failed_reason"`
+	SendAt         time.Time `json:"send_at"`
+}
+
+type NotificationDeadLetterListOutput struct {
+	Data  []NotificationDeadLetter `json:"data"`
+	Total int                      `json:"total"`
+}
+
+type NotificationReplayDeadLettersInput struct {
+	// description: replay dead letters of these notifications, all if empty
+	NotificationIds []string `json:"notification_ids"`
+
+	// description: replay dead letters of this contact type only
+	// example: email
+	ContactType string `json:"contact_type"`
+}
+
+type NotificationReplayDeadLettersOutput struct {
+	// description: count of dead letters replayed
+	Count int `json:"count"`
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SDeliveryPolicy{}), func() gotypes.ISerializable {
+		return &SDeliveryPolicy{}
+	})
+}
@@ -0,0 +1,168 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"reflect"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+type OncallScheduleCreateInput struct {
+	apis.StandaloneResourceCreateInput
+	apis.EnabledBaseResourceCreateInput
+
+	// description: ids or names of receiver taking turns to be on call, in order of rotation
+	// example: {"zhangsan", "lisi"}
+	Participants []string `json:"participants"`
+
+	// description: time of the first handoff, default to now
+	// example: 2021-01-01T09:00:00Z
+	RotationStart time.Time `json:"rotation_start"`
+
+	// description: hours every participant stays on call
+	// example: 24
+	ShiftHours int `json:"shift_hours"`
+}
+
+type OncallScheduleUpdateInput struct {
+	apis.StandaloneResourceBaseUpdateInput
+
+	// description: ids or names of receiver taking turns to be on call, in order of rotation
+	// example: {"zhangsan", "lisi"}
+	Participants []string `json:"participants"`
+
+	// description: time of the first handoff
+	// example: 2021-01-01T09:00:00Z
+	RotationStart *time.Time `json:"rotation_start"`
+
+	// description: hours every participant stays on call
+	// example: 24
+	ShiftHours *int `json:"shift_hours"`
+}
+
+type OncallScheduleListInput struct {
+	apis.StandaloneResourceListInput
+	apis.EnabledResourceBaseListInput
+}
+
+type OncallOverride struct {
+	// example: 5d65667d-112e-47ef-8d4b-8a7f1e5b1e25
+	Id string `json:"id"`
+	IDAndName
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
+}
+
+type OncallScheduleDetails struct {
+	apis.StandaloneResourceDetails
+
+	// description: names of receiver taking turns to be on call
+	ParticipantNames []string `json:"participant_names"`
+	// description: receiver on call now
+	CurrentOncall IDAndName `json:"current_oncall"`
+	// description: time of the next handoff
+	NextHandoffAt time.Time `json:"next_handoff_at"`
+	// description: overrides not ended yet
+	Overrides []OncallOverride `json:"overrides"`
+}
+
+type OncallScheduleAddOverrideInput struct {
+	// description: id or name of receiver on call during the override
+	// required: true
+	// example: zhangsan
+	Receiver string `json:"receiver"`
+	// required: true
+	StartAt time.Time `json:"start_at"`
+	// required: true
+	EndAt time.Time `json:"end_at"`
+}
+
+type OncallScheduleRemoveOverrideInput struct {
+	// description: id of override
+	// required: true
+	OverrideId string `json:"override_id"`
+}
+
+type SEscalationLevel struct {
+	// description: ids of receiver notified at this level
+	Receivers []string `json:"receivers"`
+	// description: ids of oncall schedule whose current oncall receiver is notified at this level
+	OncallSchedules []string `json:"oncall_schedules"`
+	// description: minutes to wait for acknowledgement before escalating to the next level
+	// example: 15
+	TimeoutMinutes int `json:"timeout_minutes"`
+}
+
+type SEscalationLevels []SEscalationLevel
+
+func (levels SEscalationLevels) String() string {
+	return jsonutils.Marshal(levels).String()
+}
+
+func (levels SEscalationLevels) IsZero() bool {
+	return len(levels) == 0
+}
+
+type EscalationPolicyCreateInput struct {
+	apis.StandaloneResourceCreateInput
+	apis.EnabledBaseResourceCreateInput
+
+	// description: levels notified one by one until the notification is acknowledged,
+	// receivers and oncall_schedules accept id or name
+	Levels SEscalationLevels `json:"levels"`
+
+	// description: only notifications of this priority or higher are escalated
+	// enum: normal,important,fatal
+	// example: important
+	MinPriority string `json:"min_priority"`
+}
+
+type EscalationPolicyUpdateInput struct {
+	apis.StandaloneResourceBaseUpdateInput
+
+	Levels SEscalationLevels `json:"levels"`
+
+	// enum: normal,important,fatal
+	MinPriority string `json:"min_priority"`
+}
+
+type EscalationPolicyListInput struct {
+	apis.StandaloneResourceListInput
+	apis.EnabledResourceBaseListInput
+}
+
+type EscalationPolicyDetails struct {
+	apis.StandaloneResourceDetails
+}
+
+type SubscriptionSetEscalationPolicyInput struct {
+	// description: id or name of escalation policy, empty to unset
+	// example: oncall-sre
+	EscalationPolicy string `json:"escalation_policy"`
+}
+
+type NotificationAcknowledgeInput struct {
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SEscalationLevels{}), func() gotypes.ISerializable {
+		return &SEscalationLevels{}
+	})
+}
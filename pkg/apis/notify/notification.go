@@ -54,6 +54,10 @@ type NotificationCreateInput struct {
 	Tag                       string                 `json:"tag"`
 	Metadata                  map[string]interface{} `json:"metadata"`
 	IgnoreNonexistentReceiver bool                   `json:"ignore_nonexistent_receiver"`
+	// description: id or name of escalation policy notifying its levels until the notification is acknowledged
+	// required: false
+	// example: oncall-sre
+	EscalationPolicy string `json:"escalation_policy"`
 }
 
 type ReceiveDetail struct {
@@ -74,6 +78,18 @@ type NotificationDetails struct {
 	Title          string          `json:"title"`
 	Content        string          `json:"content"`
 	ReceiveDetails []ReceiveDetail `json:"receive_details"`
+	// description: escalation the notification belongs to
+	Escalation *EscalationDetail `json:"escalation,omitempty"`
+}
+
+type EscalationDetail struct {
+	Id string `json:"id"`
+	// enum: escalating,acknowledged,exhausted,cancelled
+	Status string `json:"status"`
+	// description: level notified lastly, starting from 1
+	Level          int       `json:"level"`
+	AcknowledgedBy string    `json:"acknowledged_by"`
+	AcknowledgedAt time.Time `json:"acknowledged_at"`
 }
 
 type NotificationListInput struct {
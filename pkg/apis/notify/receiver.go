@@ -43,6 +43,32 @@ type ReceiverCreateInput struct {
 	// description: enabled contact types for user
 	// example: {"email", "mobile", "feishu", "dingtalk", "workwx"}
 	EnabledContactTypes []string `json:"enabled_contact_types"`
+
+	ReceiverNotifyPolicyInput
+}
+
+// ReceiverNotifyPolicyInput controls how notifications are delivered to receiver
+type ReceiverNotifyPolicyInput struct {
+	// description: drop notifications identical to one sent within these seconds, 0 to disable
+	// example: 600
+	DedupWindowSeconds *int `json:"dedup_window_seconds"`
+
+	// description: batch normal priority notifications into a digest sent every these seconds, 0 to disable
+	// example: 3600
+	DigestIntervalSeconds *int `json:"digest_interval_seconds"`
+
+	// description: start of quiet hours in local time, format HH:MM
+	// example: 22:00
+	QuietHoursStart *string `json:"quiet_hours_start"`
+
+	// description: end of quiet hours in local time, format HH:MM
+	// example: 08:00
+	QuietHoursEnd *string `json:"quiet_hours_end"`
+
+	// description: notifications of this priority or higher are still sent in quiet hours
+	// example: fatal
+	// enum: normal,important,fatal
+	QuietHoursOverridePriority string `json:"quiet_hours_override_priority"`
 }
 
 type SInternationalMobile struct {
@@ -127,6 +153,8 @@ type ReceiverUpdateInput struct {
 	// description: enabled contacts for user
 	// example: {"email", "mobile", "feishu", "dingtalk", "workwx"}
 	EnabledContactTypes []string `json:"enabled_contact_types"`
+
+	ReceiverNotifyPolicyInput
 }
 
 type ReceiverTriggerVerifyInput struct {
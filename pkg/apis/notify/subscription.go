@@ -32,6 +32,8 @@ type SubscriptionDetails struct {
 	// description: webhook send message
 	// example: webhook
 	Webhook string `json:"webhook"`
+	// description: escalation policy of notifications sent for subscribed events
+	EscalationPolicy IDAndName `json:"escalation_policy"`
 }
 
 type IDAndName struct {
@@ -451,8 +451,30 @@ func updateInContextHandler(ctx context.Context, w http.ResponseWriter, r *http.
 	handleUpdate(ctx, w, manager, params["<resid>"], ctxIds, mergeQueryParams(params, query, ctxKeys...), body, r)
 }
 
+// AddModelUpdateSpecDispatcher registers a dedicated handler named update_<spec> for updating a single spec,
+// so that its handler info can be customized apart from the generic update_spec handler
+func AddModelUpdateSpecDispatcher(prefix string, app *appsrv.Application, manager IModelDispatchHandler, spec string) {
+	metadata := map[string]interface{}{"manager": manager}
+	tags := map[string]string{"resource": manager.KeywordPlural()}
+	h := app.AddHandler2("PUT",
+		fmt.Sprintf("%s/%s/<resid>/%s", prefix, manager.KeywordPlural(), spec),
+		manager.Filter(fixedUpdateSpecHandler(spec)), metadata, "update_"+strings.Replace(spec, "-", "_", -1), tags)
+	manager.CustomizeHandlerInfo(h)
+}
+
+func fixedUpdateSpecHandler(spec string) func(context.Context, http.ResponseWriter, *http.Request) {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		manager, params, query, body := fetchEnv(ctx, w, r)
+		updateSpec(ctx, w, r, manager, params, query, body, spec)
+	}
+}
+
 func updateSpecHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	manager, params, query, body := fetchEnv(ctx, w, r)
+	updateSpec(ctx, w, r, manager, params, query, body, params["<spec>"])
+}
+
+func updateSpec(ctx context.Context, w http.ResponseWriter, r *http.Request, manager IModelDispatchHandler, params map[string]string, query jsonutils.JSONObject, body jsonutils.JSONObject, spec string) {
 	var data jsonutils.JSONObject
 	var err error
 	if body != nil {
@@ -472,7 +494,7 @@ func updateSpecHandler(ctx context.Context, w http.ResponseWriter, r *http.Reque
 			return
 		}
 	}
-	result, err := manager.UpdateSpec(ctx, params["<resid>"], params["<spec>"], mergeQueryParams(params, query, "<resid>", "<spec>"), data)
+	result, err := manager.UpdateSpec(ctx, params["<resid>"], spec, mergeQueryParams(params, query, "<resid>", "<spec>"), data)
 	if err != nil {
 		httperrors.GeneralServerError(ctx, w, err)
 		return
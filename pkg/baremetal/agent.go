@@ -41,11 +41,9 @@ var (
 	baremetalAgent *SBaremetalAgent
 )
 
-//
 // BaremetalAgent has two types of address
 // - AccessAddress/Address: this is the address controller to accesss the agent
 // - ListenAddress: this is the address baremetal to access the agent
-//
 type SBaremetalAgent struct {
 	agent.SBaseAgent
 
@@ -140,6 +138,7 @@ func (agent *SBaremetalAgent) StartService() error {
 
 	agent.startPXEServices(manager)
 	agent.startFileServer()
+	agent.startRedfishEventServer()
 
 	agent.DoOnline(agent.GetAdminSession())
 	return nil
@@ -193,8 +192,10 @@ func (agent *SBaremetalAgent) startFileServer() {
 	if err != nil {
 		log.Fatalf("Get dhcp listen ip address error: %v", err)
 	}
-	fs := http.FileServer(httputils.Dir(o.Options.TftpRoot))
+	fs := pxe.NewHTTPHandler(o.Options.TftpRoot)
+	go fs.LogChecksums()
 	http.Handle("/tftp/", http.StripPrefix("/tftp/", fs))
+	http.Handle("/ipxe/", http.StripPrefix("/ipxe/", &pxe.IPXEHandler{BaremetalManager: agent.Manager}))
 	cacheFs := http.FileServer(httputils.Dir(o.Options.CachePath))
 	http.Handle("/images/", http.StripPrefix("/images/", cacheFs))
 	isoFs := http.FileServer(httputils.Dir(o.Options.BootIsoPath))
@@ -206,6 +207,25 @@ func (agent *SBaremetalAgent) startFileServer() {
 	}()
 }
 
+func (agent *SBaremetalAgent) startRedfishEventServer() {
+	if !o.Options.EnableRedfishEvents {
+		return
+	}
+	if len(o.Options.SslCertfile) == 0 || len(o.Options.SslKeyfile) == 0 {
+		log.Errorf("Redfish events require ssl certfile and keyfile, fallback to polling system logs")
+		o.Options.EnableRedfishEvents = false
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle(redfishEventPathPrefix, http.StripPrefix(redfishEventPathPrefix, http.HandlerFunc(agent.Manager.handleRedfishEvent)))
+	go func() {
+		addr := fmt.Sprintf("0.0.0.0:%d", getRedfishEventPort())
+		if err := http.ListenAndServeTLS(addr, o.Options.SslCertfile, o.Options.SslKeyfile, mux); err != nil {
+			log.Errorf("start redfish event server: %v", err)
+		}
+	}()
+}
+
 func Start(app *appsrv.Application) error {
 	var err error
 	if baremetalAgent != nil {
@@ -185,6 +185,11 @@ func (job *SLogFetchJob) Do(ctx context.Context, now time.Time) error {
 	if !job.baremetal.isRedfishCapable() {
 		return nil
 	}
+	if len(job.baremetal.eventSubscription) > 0 {
+		// events are pushed by BMC, no need to poll
+		job.lastTime = now
+		return nil
+	}
 	err := fetchLogs(job.baremetal, ctx, redfish.EVENT_TYPE_SYSTEM)
 	if err != nil {
 		return errors.Wrap(err, "fetchLogs api.EVENT_TYPE_SYSTEM")
@@ -0,0 +1,312 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baremetal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	o "yunion.io/x/onecloud/pkg/baremetal/options"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+)
+
+const (
+	redfishEventPathPrefix = "/redfish/events/"
+
+	// max size of an event request from BMC
+	redfishEventMaxBodySize = 1024 * 1024
+)
+
+// hardware fault categories of redfish alerts, notified as BAREMETAL_<CATEGORY>_FAULT
+const (
+	HARDWARE_FAULT_DISK        = "disk"
+	HARDWARE_FAULT_PSU         = "psu"
+	HARDWARE_FAULT_FAN         = "fan"
+	HARDWARE_FAULT_MEMORY      = "memory"
+	HARDWARE_FAULT_CPU         = "cpu"
+	HARDWARE_FAULT_TEMPERATURE = "temperature"
+	HARDWARE_FAULT_OTHER       = "hardware"
+)
+
+// keywords are matched against MessageId, origin and message of alerts in order,
+// e.g. iDRAC PSU0003, PDR1016 and iLO /Chassis/1/Power
+var hardwareFaultKeywords = []struct {
+	category string
+	keywords []string
+}{
+	{HARDWARE_FAULT_PSU, []string{"psu", "power supply", "powersupply", "/power"}},
+	{HARDWARE_FAULT_DISK, []string{"pdr", "disk", "drive", "storage", "raid", "volume"}},
+	{HARDWARE_FAULT_FAN, []string{"fan"}},
+	{HARDWARE_FAULT_MEMORY, []string{"mem", "dimm"}},
+	{HARDWARE_FAULT_CPU, []string{"cpu", "processor"}},
+	{HARDWARE_FAULT_TEMPERATURE, []string{"tmp", "temp", "thermal"}},
+}
+
+func hardwareFaultCategory(evt redfish.SEvent) string {
+	text := strings.ToLower(strings.Join([]string{evt.MessageId, evt.Origin, evt.Message}, " "))
+	for _, fault := range hardwareFaultKeywords {
+		for _, kw := range fault.keywords {
+			if strings.Contains(text, kw) {
+				return fault.category
+			}
+		}
+	}
+	return HARDWARE_FAULT_OTHER
+}
+
+func severityToHardwareHealth(severity string) string {
+	switch {
+	case strings.EqualFold(severity, redfish.EVENT_SEVERITY_CRITICAL):
+		return api.HOST_HARDWARE_HEALTH_CRITICAL
+	case strings.EqualFold(severity, redfish.EVENT_SEVERITY_WARNING):
+		return api.HOST_HARDWARE_HEALTH_WARNING
+	case strings.EqualFold(severity, redfish.EVENT_SEVERITY_OK):
+		return api.HOST_HARDWARE_HEALTH_OK
+	}
+	return ""
+}
+
+func hardwareHealthLevel(health string) int {
+	switch health {
+	case api.HOST_HARDWARE_HEALTH_CRITICAL:
+		return 2
+	case api.HOST_HARDWARE_HEALTH_WARNING:
+		return 1
+	}
+	return 0
+}
+
+func getRedfishEventPort() int {
+	if o.Options.RedfishEventPort > 0 {
+		return o.Options.RedfishEventPort
+	}
+	return o.Options.Port + 2000
+}
+
+// getRedfishEventContext returns Context of subscription of baremetal,
+// BMC sends it back in every event so that forged events are rejected
+func (m *SBaremetalManager) getRedfishEventContext(bmId string) string {
+	sum := sha256.Sum256([]byte(m.eventSecret + bmId))
+	return "onecloud-" + hex.EncodeToString(sum[:])[:32]
+}
+
+func (m *SBaremetalManager) getRedfishEventDestination(bmId string) (string, error) {
+	accessIP, err := m.Agent.GetAccessIP()
+	if err != nil {
+		return "", errors.Wrap(err, "GetAccessIP")
+	}
+	return fmt.Sprintf("https://%s:%d%s%s", accessIP, getRedfishEventPort(), redfishEventPathPrefix, bmId), nil
+}
+
+// handleRedfishEvent receives events pushed by BMC at /redfish/events/<baremetal id>
+func (m *SBaremetalManager) handleRedfishEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	bmId := strings.Trim(r.URL.Path, "/")
+	bm := m.GetBaremetalById(bmId)
+	if bm == nil {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, redfishEventMaxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	body, err := jsonutils.Parse(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	evtCtx, events, err := redfish.ParseEvents(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if evtCtx != m.getRedfishEventContext(bmId) {
+		log.Warningf("[Redfish] reject events of %s from %s: context mismatch", bm.GetName(), r.RemoteAddr)
+		http.Error(w, "invalid context", http.StatusForbidden)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	// BMC waits for response only a few seconds, handle events asynchronously
+	go bm.onRedfishEvents(context.Background(), events)
+}
+
+func (b *SBaremetalInstance) onRedfishEvents(ctx context.Context, events []redfish.SEvent) {
+	s := auth.GetAdminSession(ctx, consts.GetRegion(), "")
+	worst := api.HOST_HARDWARE_HEALTH_OK
+	for _, evt := range events {
+		log.Infof("[Redfish] baremetal %s event %s %s: %s", b.GetName(), evt.Severity, evt.MessageId, evt.Message)
+		eventData := eventToJson(evt)
+		eventData.Add(jsonutils.NewString(b.GetId()), "host_id")
+		eventData.Add(jsonutils.NewString(b.GetName()), "host_name")
+		eventData.Add(jsonutils.NewString(b.GetIPMINicIPAddr()), "ipmi_ip")
+		if _, err := modules.BaremetalEvents.Create(s, eventData); err != nil {
+			log.Errorf("create baremetal event of %s: %v", b.GetName(), err)
+		}
+		health := severityToHardwareHealth(evt.Severity)
+		if hardwareHealthLevel(health) > hardwareHealthLevel(worst) {
+			worst = health
+		}
+		b.notifyRedfishEvent(ctx, evt, health)
+	}
+	if err := b.syncHardwareHealth(ctx, worst); err != nil {
+		log.Errorf("sync hardware health of %s: %v", b.GetName(), err)
+	}
+}
+
+func (b *SBaremetalInstance) notifyRedfishEvent(ctx context.Context, evt redfish.SEvent, health string) {
+	if hardwareHealthLevel(health) == 0 {
+		return
+	}
+	event := fmt.Sprintf("BAREMETAL_%s_FAULT", strings.ToUpper(hardwareFaultCategory(evt)))
+	reason := evt.Message
+	if len(evt.MessageId) > 0 {
+		reason = fmt.Sprintf("%s (%s)", evt.Message, evt.MessageId)
+	}
+	if health == api.HOST_HARDWARE_HEALTH_CRITICAL {
+		notifyclient.NotifySystemErrorWithCtx(ctx, b.GetId(), b.GetName(), event, reason)
+	} else {
+		notifyclient.NotifySystemWarningWithCtx(ctx, b.GetId(), b.GetName(), event, reason)
+	}
+}
+
+// syncHardwareHealth update hardware_health of host by health rollup of system,
+// health derived from events is used when BMC does not report it
+func (b *SBaremetalInstance) syncHardwareHealth(ctx context.Context, health string) error {
+	redfishApi := b.GetRedfishCli(ctx)
+	if redfishApi != nil {
+		_, sysInfo, err := redfishApi.GetSystemInfo(ctx)
+		if err != nil {
+			log.Warningf("GetSystemInfo of %s: %v", b.GetName(), err)
+		} else if h := severityToHardwareHealth(sysInfo.Health); len(h) > 0 {
+			health = h
+		}
+	}
+	if len(health) == 0 {
+		return nil
+	}
+	data := jsonutils.NewDict()
+	data.Add(jsonutils.NewString(health), "hardware_health")
+	_, err := modules.Hosts.Update(b.GetClientSession(), b.GetId(), data)
+	if err != nil {
+		return errors.Wrap(err, "modules.Hosts.Update")
+	}
+	return nil
+}
+
+// syncRedfishEventSubscription make sure BMC has exactly one valid subscription
+// to this agent, stale ones left by agent restart or re-registration are removed
+func (b *SBaremetalInstance) syncRedfishEventSubscription(ctx context.Context) error {
+	redfishApi := b.GetRedfishCli(ctx)
+	if redfishApi == nil {
+		return errors.Wrap(httperrors.ErrNotSupported, "no valid redfish api")
+	}
+	dest, err := b.manager.getRedfishEventDestination(b.GetId())
+	if err != nil {
+		return errors.Wrap(err, "getRedfishEventDestination")
+	}
+	evtCtx := b.manager.getRedfishEventContext(b.GetId())
+	subs, err := redfishApi.GetEventSubscriptions(ctx)
+	if err != nil {
+		b.eventSubscription = ""
+		return errors.Wrap(err, "GetEventSubscriptions")
+	}
+	var subPath string
+	for _, sub := range subs {
+		if sub.Destination != dest {
+			continue
+		}
+		if sub.Context == evtCtx && len(subPath) == 0 {
+			subPath = sub.Path
+			continue
+		}
+		if err := redfishApi.DeleteEventSubscription(ctx, sub.Path); err != nil {
+			log.Warningf("delete stale subscription %s of %s: %v", sub.Path, b.GetName(), err)
+		}
+	}
+	if len(subPath) == 0 {
+		subPath, err = redfishApi.CreateEventSubscription(ctx, dest, evtCtx)
+		if err != nil {
+			b.eventSubscription = ""
+			return errors.Wrap(err, "CreateEventSubscription")
+		}
+		log.Infof("[Redfish] baremetal %s subscribed events to %s", b.GetName(), dest)
+	}
+	b.eventSubscription = subPath
+	return nil
+}
+
+func (b *SBaremetalInstance) clearRedfishEventSubscription(ctx context.Context) {
+	if len(b.eventSubscription) == 0 {
+		return
+	}
+	redfishApi := b.GetRedfishCli(ctx)
+	if redfishApi == nil {
+		return
+	}
+	if err := redfishApi.DeleteEventSubscription(ctx, b.eventSubscription); err != nil {
+		log.Warningf("delete event subscription of %s: %v", b.GetName(), err)
+	}
+	b.eventSubscription = ""
+}
+
+type SEventSubscribeJob struct {
+	SBaseBaremetalCronJob
+}
+
+func NewEventSubscribeJob(baremetal *SBaremetalInstance, interval time.Duration) IBaremetalCronJob {
+	return &SEventSubscribeJob{
+		SBaseBaremetalCronJob: SBaseBaremetalCronJob{
+			baremetal: baremetal,
+			interval:  interval,
+		},
+	}
+}
+
+func (job *SEventSubscribeJob) Name() string {
+	return "EventSubscribeJob"
+}
+
+func (job *SEventSubscribeJob) Do(ctx context.Context, now time.Time) error {
+	if !o.Options.EnableRedfishEvents || !job.baremetal.isRedfishCapable() {
+		return nil
+	}
+	job.lastTime = now
+	err := job.baremetal.syncRedfishEventSubscription(ctx)
+	if err != nil {
+		return errors.Wrap(err, "syncRedfishEventSubscription")
+	}
+	return nil
+}
@@ -50,6 +50,7 @@ import (
 	"yunion.io/x/onecloud/pkg/baremetal/utils/disktool"
 	"yunion.io/x/onecloud/pkg/baremetal/utils/ipmitool"
 	raiddrivers "yunion.io/x/onecloud/pkg/baremetal/utils/raid/drivers"
+	"yunion.io/x/onecloud/pkg/baremetal/utils/raid/redfishraid"
 	"yunion.io/x/onecloud/pkg/cloudcommon/types"
 	"yunion.io/x/onecloud/pkg/compute/baremetal"
 	"yunion.io/x/onecloud/pkg/hostman/guestfs"
@@ -74,6 +75,8 @@ type SBaremetalManager struct {
 	Agent      *SBaremetalAgent
 	configPath string
 	baremetals *sBaremetalMap
+	// secret to sign Context of redfish event subscriptions
+	eventSecret string
 }
 
 func NewBaremetalManager(agent *SBaremetalAgent) (*SBaremetalManager, error) {
@@ -83,9 +86,10 @@ func NewBaremetalManager(agent *SBaremetalAgent) (*SBaremetalManager, error) {
 		return nil, err
 	}
 	return &SBaremetalManager{
-		Agent:      agent,
-		configPath: bmPaths,
-		baremetals: newBaremetalMap(),
+		Agent:       agent,
+		configPath:  bmPaths,
+		baremetals:  newBaremetalMap(),
+		eventSecret: seclib.RandomPassword(32),
 	}, nil
 }
 
@@ -510,6 +514,8 @@ type SBaremetalInstance struct {
 	serverLock *sync.Mutex
 
 	cronJobs []IBaremetalCronJob
+	// path of redfish event subscription in BMC, empty if not subscribed
+	eventSubscription string
 }
 
 func newBaremetalInstance(man *SBaremetalManager, desc jsonutils.JSONObject) (*SBaremetalInstance, error) {
@@ -524,6 +530,7 @@ func newBaremetalInstance(man *SBaremetalManager, desc jsonutils.JSONObject) (*S
 		NewStatusProbeJob(bm, time.Duration(o.Options.StatusProbeIntervalSeconds)*time.Second),
 		NewLogFetchJob(bm, time.Duration(o.Options.LogFetchIntervalSeconds)*time.Second),
 		NewSendMetricsJob(bm, time.Duration(o.Options.SendMetricsIntervalSeconds)*time.Second),
+		NewEventSubscribeJob(bm, time.Duration(o.Options.RedfishEventSubscribeIntervalSeconds)*time.Second),
 	}
 	err := os.MkdirAll(bm.GetDir(), 0755)
 	if err != nil {
@@ -970,11 +977,11 @@ func (b *SBaremetalInstance) GetDHCPConfig(cliMac net.HardwareAddr) (*dhcp.Respo
 	if nic == nil {
 		return nil, fmt.Errorf("GetNicDHCPConfig no nic found by mac: %s", cliMac)
 	}
-	return b.getDHCPConfig(nic, hostname, false, 0)
+	return b.getDHCPConfig(nic, hostname, false, 0, pxe.BootClientPXE)
 }
 
-func (b *SBaremetalInstance) GetPXEDHCPConfig(arch uint16) (*dhcp.ResponseConfig, error) {
-	return b.getDHCPConfig(b.GetAdminNic(), "", true, arch)
+func (b *SBaremetalInstance) GetPXEDHCPConfig(arch uint16, client pxe.BootClient) (*dhcp.ResponseConfig, error) {
+	return b.getDHCPConfig(b.GetAdminNic(), "", true, arch, client)
 }
 
 func (b *SBaremetalInstance) getDHCPConfig(
@@ -982,6 +989,7 @@ func (b *SBaremetalInstance) getDHCPConfig(
 	hostName string,
 	isPxe bool,
 	arch uint16,
+	client pxe.BootClient,
 ) (*dhcp.ResponseConfig, error) {
 	if hostName == "" {
 		hostName = b.GetName()
@@ -990,7 +998,7 @@ func (b *SBaremetalInstance) getDHCPConfig(
 	if err != nil {
 		return nil, err
 	}
-	return GetNicDHCPConfig(nic, serverIP.String(), hostName, isPxe, arch)
+	return GetNicDHCPConfig(nic, serverIP.String(), hostName, isPxe, arch, client)
 }
 
 func (b *SBaremetalInstance) GetNotifyUrl() string {
@@ -1003,7 +1011,7 @@ func (b *SBaremetalInstance) getTftpFileUrl(filename string) string {
 		log.Errorf("Get http file server: %v", err)
 		return filename
 	}
-	return fmt.Sprintf("http://%s:%d/tftp/%s", serverIP, o.Options.Port+1000, filename)
+	return GetHttpFileUrl(serverIP.String(), "tftp/"+filename)
 }
 
 func (b *SBaremetalInstance) GetImageCacheUrl() string {
@@ -1030,6 +1038,27 @@ func (b *SBaremetalInstance) GetTFTPResponse() string {
 	return b.getSyslinuxConf(true)
 }
 
+// GetIPXEScript returns the script served to iPXE clients, which fetch
+// kernel and initramfs through HTTP instead of TFTP
+func (b *SBaremetalInstance) GetIPXEScript() string {
+	resp := "#!ipxe\n"
+	if b.NeedPXEBoot() {
+		args := []string{
+			"initrd=initramfs",
+			fmt.Sprintf("token=%s", auth.GetTokenString()),
+			fmt.Sprintf("url=%s", b.GetNotifyUrl()),
+			fmt.Sprintf("bootmod=%s", api.BOOT_MODE_PXE),
+		}
+		resp += fmt.Sprintf("kernel %s %s\n", b.getTftpFileUrl("kernel"), strings.Join(args, " "))
+		resp += fmt.Sprintf("initrd %s\n", b.getTftpFileUrl("initramfs"))
+		resp += "boot\n"
+	} else {
+		resp += "sanboot --no-describe --drive 0x80\n"
+		b.ClearSSHConfig()
+	}
+	return resp
+}
+
 func (b *SBaremetalInstance) getIsolinuxConf() string {
 	return b.getSyslinuxConf(false)
 }
@@ -1514,6 +1543,7 @@ func (b *SBaremetalInstance) DelayedRemove(_ jsonutils.JSONObject) (jsonutils.JS
 }
 
 func (b *SBaremetalInstance) remove() {
+	b.clearRedfishEventSubscription(context.Background())
 	b.manager.CleanBaremetal(b.GetId())
 	b.manager = nil
 	b.desc = nil
@@ -1554,6 +1584,16 @@ func (b *SBaremetalInstance) StartBaremetalIpmiProbeTask(userCred mcclient.Token
 	return nil
 }
 
+func (b *SBaremetalInstance) StartBaremetalFirmwareUpdateTask(userCred mcclient.TokenCredential, taskId string, data jsonutils.JSONObject) error {
+	b.StartNewTask(tasks.NewBaremetalFirmwareUpdateTask, userCred, taskId, data)
+	return nil
+}
+
+func (b *SBaremetalInstance) StartBaremetalBiosApplyTask(userCred mcclient.TokenCredential, taskId string, data jsonutils.JSONObject) error {
+	b.StartNewTask(tasks.NewBaremetalBiosApplyTask, userCred, taskId, data)
+	return nil
+}
+
 func (b *SBaremetalInstance) StartBaremetalCdromTask(userCred mcclient.TokenCredential, taskId string, data jsonutils.JSONObject) error {
 	b.StartNewTask(tasks.NewBaremetalCdromTask, userCred, taskId, data)
 	return nil
@@ -2107,8 +2147,51 @@ func (s *SBaremetalServer) GetDiskConfig() ([]*api.BaremetalDiskConfig, error) {
 	return baremetal.GetLayoutRaidConfig(layouts), nil
 }
 
+func (s *SBaremetalServer) detectStorageInfo(term *ssh.Client, wait bool) ([]*baremetal.BaremetalStorage, []*baremetal.BaremetalStorage, []*baremetal.BaremetalStorage, error) {
+	raidDrvs := detect_storages.GetRaidDrivers(context.Background(), term, s.baremetal.GetRawIPMIConfig())
+	return detect_storages.DetectStorageInfoWithDrivers(term, raidDrvs, wait)
+}
+
+// DoRedfishDiskConfig build RAID out-of-band through Redfish before booting into agent image,
+// in-band DoDiskConfig skips these RAID because no in-band driver is registered for them
+func (s *SBaremetalServer) DoRedfishDiskConfig(ctx context.Context) error {
+	ipmiInfo := s.baremetal.GetRawIPMIConfig()
+	if ipmiInfo == nil || !ipmiInfo.RedfishRaid {
+		return nil
+	}
+	drv := s.baremetal.GetRedfishCli(ctx)
+	if drv == nil {
+		return errors.Wrap(httperrors.ErrNotSupported, "BMC not redfish-compatible")
+	}
+	raidDrv := redfishraid.NewRedfishRaid(ctx, drv)
+	if err := raidDrv.ParsePhyDevs(); err != nil {
+		return errors.Wrapf(err, "RaidDriver %s parse physical devices", raidDrv.GetName())
+	}
+	storages := make([]*baremetal.BaremetalStorage, 0)
+	if err := s.baremetal.desc.Unmarshal(&storages, "storage_info"); err != nil {
+		return errors.Wrap(err, "unmarshal storage_info")
+	}
+	confs, err := s.GetDiskConfig()
+	if err != nil {
+		return err
+	}
+	layouts, err := baremetal.CalculateLayout(confs, storages)
+	if err != nil {
+		return errors.Wrap(err, "CalculateLayout")
+	}
+	for _, dConf := range baremetal.GroupLayoutResultsByDriverAdapter(layouts) {
+		if dConf.Driver != baremetal.DISK_DRIVER_REDFISH {
+			continue
+		}
+		if err := raiddrivers.BuildRaid(raidDrv, dConf.Configs, dConf.Adapter); err != nil {
+			return errors.Wrapf(err, "Build %s raid failed", raidDrv.GetName())
+		}
+	}
+	return nil
+}
+
 func (s *SBaremetalServer) DoDiskConfig(term *ssh.Client) error {
-	raid, nonRaid, pcie, err := detect_storages.DetectStorageInfo(term, true)
+	raid, nonRaid, pcie, err := s.detectStorageInfo(term, true)
 	if err != nil {
 		return err
 	}
@@ -2214,7 +2297,7 @@ func (s *SBaremetalServer) doCreateRoot(term *ssh.Client, devName string) error
 }
 
 func (s *SBaremetalServer) DoPartitionDisk(term *ssh.Client) ([]*disktool.Partition, error) {
-	raid, nonRaid, pcie, err := detect_storages.DetectStorageInfo(term, false)
+	raid, nonRaid, pcie, err := s.detectStorageInfo(term, false)
 	if err != nil {
 		return nil, err
 	}
@@ -2291,7 +2374,7 @@ func (s *SBaremetalServer) DoPartitionDisk(term *ssh.Client) ([]*disktool.Partit
 }
 
 func (s *SBaremetalServer) DoRebuildRootDisk(term *ssh.Client) ([]*disktool.Partition, error) {
-	raid, nonRaid, pcie, err := detect_storages.DetectStorageInfo(term, false)
+	raid, nonRaid, pcie, err := s.detectStorageInfo(term, false)
 	if err != nil {
 		return nil, err
 	}
@@ -2399,7 +2482,7 @@ func (s *SBaremetalServer) DoDeploy(term *ssh.Client, data jsonutils.JSONObject,
 }
 
 func (s *SBaremetalServer) deployFs(term *ssh.Client, deployInfo *deployapi.DeployInfo) (jsonutils.JSONObject, error) {
-	raid, nonRaid, pcie, err := detect_storages.DetectStorageInfo(term, false)
+	raid, nonRaid, pcie, err := s.detectStorageInfo(term, false)
 	if err != nil {
 		return nil, err
 	}
@@ -24,6 +24,7 @@ import (
 	"yunion.io/x/pkg/util/netutils"
 
 	o "yunion.io/x/onecloud/pkg/baremetal/options"
+	"yunion.io/x/onecloud/pkg/baremetal/pxe"
 	"yunion.io/x/onecloud/pkg/cloudcommon/types"
 	"yunion.io/x/onecloud/pkg/util/dhcp"
 )
@@ -34,6 +35,7 @@ func GetNicDHCPConfig(
 	hostName string,
 	isPxe bool,
 	arch uint16,
+	client pxe.BootClient,
 ) (*dhcp.ResponseConfig, error) {
 	if n == nil {
 		return nil, fmt.Errorf("Nic is nil")
@@ -68,7 +70,18 @@ func GetNicDHCPConfig(
 		RenewalTime:   time.Duration(o.Options.DhcpRenewalTime) * time.Second,
 	}
 
-	if isPxe {
+	if isPxe && client == pxe.BootClientHTTP {
+		// UEFI HTTP Boot takes the whole URL as boot file
+		bootFile := "bootx64.efi"
+		if arch == 15 {
+			bootFile = "bootia32.efi"
+		}
+		conf.BootFile = GetHttpFileUrl(serverIP, "tftp/"+bootFile)
+		conf.VendorClassId = pxe.VendorClassHTTPClient
+	} else if isPxe && client == pxe.BootClientIPXE {
+		conf.BootServer = serverIP
+		conf.BootFile = GetHttpFileUrl(serverIP, "ipxe/"+n.Mac)
+	} else if isPxe {
 		conf.BootServer = serverIP
 		switch arch {
 		case 7, 9:
@@ -81,7 +94,11 @@ func GetNicDHCPConfig(
 			//}else {
 			// bootFile := "pxelinux.0"
 			//}
-			conf.BootFile = "lpxelinux.0"
+			if o.Options.EnableIpxeBoot {
+				conf.BootFile = "undionly.kpxe"
+			} else {
+				conf.BootFile = "lpxelinux.0"
+			}
 		}
 		pxePath := filepath.Join(o.Options.TftpRoot, conf.BootFile)
 		if f, err := os.Open(pxePath); err != nil {
@@ -101,3 +118,8 @@ func GetNicDHCPConfig(
 	}
 	return conf, nil
 }
+
+// GetHttpFileUrl returns the url of file served by the http file server of agent
+func GetHttpFileUrl(serverIP string, path string) string {
+	return fmt.Sprintf("http://%s:%d/%s", serverIP, o.Options.Port+1000, path)
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baremetal
+
+import (
+	"testing"
+
+	o "yunion.io/x/onecloud/pkg/baremetal/options"
+	"yunion.io/x/onecloud/pkg/baremetal/pxe"
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+)
+
+func TestGetNicDHCPConfigBootClient(t *testing.T) {
+	port := o.Options.Port
+	defer func() { o.Options.Port = port }()
+	o.Options.Port = 8879
+
+	nic := &types.SNic{
+		Mac:     "00:22:33:44:55:66",
+		IpAddr:  "10.168.26.10",
+		MaskLen: 24,
+		Gateway: "10.168.26.1",
+	}
+	cases := []struct {
+		name          string
+		arch          uint16
+		client        pxe.BootClient
+		bootServer    string
+		bootFile      string
+		vendorClassId string
+	}{
+		{
+			name:          "UEFI HTTP Boot x64",
+			arch:          16,
+			client:        pxe.BootClientHTTP,
+			bootFile:      "http://10.168.26.2:9879/tftp/bootx64.efi",
+			vendorClassId: pxe.VendorClassHTTPClient,
+		},
+		{
+			name:          "UEFI HTTP Boot ia32",
+			arch:          15,
+			client:        pxe.BootClientHTTP,
+			bootFile:      "http://10.168.26.2:9879/tftp/bootia32.efi",
+			vendorClassId: pxe.VendorClassHTTPClient,
+		},
+		{
+			name:       "iPXE",
+			arch:       0,
+			client:     pxe.BootClientIPXE,
+			bootServer: "10.168.26.2",
+			bootFile:   "http://10.168.26.2:9879/ipxe/00:22:33:44:55:66",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			conf, err := GetNicDHCPConfig(nic, "10.168.26.2", "host01", true, c.arch, c.client)
+			if err != nil {
+				t.Fatalf("GetNicDHCPConfig: %v", err)
+			}
+			if conf.BootServer != c.bootServer {
+				t.Errorf("BootServer = %q, want %q", conf.BootServer, c.bootServer)
+			}
+			if conf.BootFile != c.bootFile {
+				t.Errorf("BootFile = %q, want %q", conf.BootFile, c.bootFile)
+			}
+			if conf.VendorClassId != c.vendorClassId {
+				t.Errorf("VendorClassId = %q, want %q", conf.VendorClassId, c.vendorClassId)
+			}
+		})
+	}
+}
@@ -43,13 +43,25 @@ type BaremetalOptions struct {
 	WindowsDefaultAdminUser bool `default:"true" help:"Default account for Windows system is Administrator"`
 	// EnableTftpHttpDownload  bool `default:"true" help:"Pxelinux download file through http"`
 
-	CachePath     string `help:"local image cache directory"`
-	EnablePxeBoot bool   `help:"Enable DHCP PXE boot" default:"true"`
-	BootIsoPath   string `help:"iso boot image path"`
+	CachePath      string `help:"local image cache directory"`
+	EnablePxeBoot  bool   `help:"Enable DHCP PXE boot" default:"true"`
+	EnableHttpBoot bool   `help:"Enable UEFI HTTP boot for clients of vendor class HTTPClient" default:"true"`
+	EnableIpxeBoot bool   `help:"Chainload iPXE for legacy BIOS clients and fetch kernel and initramfs through HTTP" default:"false"`
+	BootIsoPath    string `help:"iso boot image path"`
 
 	StatusProbeIntervalSeconds int `help:"interval to probe baremetal status, default is 60 seconds" default:"60"`
 	LogFetchIntervalSeconds    int `help:"interval to fetch baremetal log, default is 900 seconds" default:"900"`
 	SendMetricsIntervalSeconds int `help:"interval to send baremetal metrics, default is 300 seconds" default:"300"`
+
+	FirmwareUpdatePollIntervalSeconds       int `help:"interval to poll firmware update task of BMC, default is 30 seconds" default:"30"`
+	FirmwareUpdateTimeoutSeconds            int `help:"timeout of staging or applying firmware update, default is 3600 seconds" default:"3600"`
+	FirmwareMaintenanceWindowMaxWaitSeconds int `help:"max seconds to wait for maintenance window after firmware staged, firmware is left staged if the window starts later, default is 900 seconds" default:"900"`
+	BiosApplyPollIntervalSeconds            int `help:"interval to check bios settings converged after reboot, default is 60 seconds" default:"60"`
+	BiosApplyTimeoutSeconds                 int `help:"timeout of waiting bios settings applied after reboot, default is 1800 seconds" default:"1800"`
+
+	EnableRedfishEvents                  bool `help:"Subscribe Redfish events of BMC instead of polling system logs, requires ssl certfile and keyfile" default:"false"`
+	RedfishEventPort                     int  `help:"https port receiving Redfish events, default is port+2000"`
+	RedfishEventSubscribeIntervalSeconds int  `help:"interval to check Redfish event subscriptions of BMC, default is 3600 seconds" default:"3600"`
 }
 
 var (
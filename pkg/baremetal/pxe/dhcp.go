@@ -56,6 +56,7 @@ type dhcpRequest struct {
 	RelayAddr             net.IP           // IP address of DHCP relay agent
 	Options               dhcp.Options     // dhcp packet options
 	VendorClassId         string
+	UserClass             string
 	ClientArch            uint16
 	NetworkInterfaceIdent NetworkInterfaceIdent
 	ClientGuid            string
@@ -105,6 +106,7 @@ func (h *DHCPHandler) newRequest(pkt dhcp.Packet, man IBaremetalManager) (*dhcpR
 
 	var (
 		vendorClsId string
+		userCls     string
 		cliArch     uint16
 		err         error
 		netIfIdent  NetworkInterfaceIdent
@@ -115,6 +117,8 @@ func (h *DHCPHandler) newRequest(pkt dhcp.Packet, man IBaremetalManager) (*dhcpR
 		switch optCode {
 		case dhcp.OptionVendorClassIdentifier:
 			vendorClsId, err = req.Options.String(optCode)
+		case dhcp.OptionUserClass:
+			userCls, err = req.Options.String(optCode)
 		case dhcp.OptionClientArchitecture:
 			cliArch, err = req.Options.Uint16(optCode)
 		case dhcp.OptionClientNetworkInterfaceIdentifier:
@@ -152,6 +156,7 @@ func (h *DHCPHandler) newRequest(pkt dhcp.Packet, man IBaremetalManager) (*dhcpR
 		cliUUIDStr = formatUuidString([]byte(cliGuid)[1:])
 	}
 	req.VendorClassId = vendorClsId
+	req.UserClass = userCls
 	req.ClientArch = cliArch
 	req.NetworkInterfaceIdent = netIfIdent
 	req.ClientGuid = cliUUIDStr
@@ -159,6 +164,18 @@ func (h *DHCPHandler) newRequest(pkt dhcp.Packet, man IBaremetalManager) (*dhcpR
 	return req, err
 }
 
+// getBootClient tells UEFI HTTP Boot and iPXE clients from firmware PXE ROMs
+func (req *dhcpRequest) getBootClient() BootClient {
+	if strings.HasPrefix(req.VendorClassId, VendorClassHTTPClient) {
+		return BootClientHTTP
+	}
+	// iPXE may prefix the user class with its length
+	if o.Options.EnableIpxeBoot && strings.HasSuffix(req.UserClass, UserClassIPXE) {
+		return BootClientIPXE
+	}
+	return BootClientPXE
+}
+
 func swapBytes(input []byte) []byte {
 	output := make([]byte, len(input))
 	for i := range input {
@@ -195,6 +212,9 @@ func (req *dhcpRequest) fetchConfig(session *mcclient.ClientSession) (*dhcp.Resp
 		if !o.Options.EnablePxeBoot {
 			return nil, nil, errors.Error("PXE Boot disabled")
 		}
+		if req.getBootClient() == BootClientHTTP && !o.Options.EnableHttpBoot {
+			return nil, nil, errors.Error("UEFI HTTP Boot disabled")
+		}
 		// handle PXE DHCP request
 		log.Infof("DHCP relay from %s(%s) for %s, find matched networks: %#v", req.RelayAddr, req.ClientAddr, req.ClientMac, netConf)
 		bmDesc, err := req.createOrUpdateBaremetal(session)
@@ -208,7 +228,7 @@ func (req *dhcpRequest) fetchConfig(session *mcclient.ClientSession) (*dhcp.Resp
 		// always response PXE request
 		// let bootloader decide boot local or remote
 		// if req.baremetalInstance.NeedPXEBoot() {
-		conf, err := req.baremetalInstance.GetPXEDHCPConfig(req.ClientArch)
+		conf, err := req.baremetalInstance.GetPXEDHCPConfig(req.ClientArch, req.getBootClient())
 		if err != nil {
 			return nil, nil, errors.Wrap(err, "req.baremetalInstance.GetPXEDHCPConfig")
 		}
@@ -0,0 +1,49 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pxe
+
+import (
+	"testing"
+
+	o "yunion.io/x/onecloud/pkg/baremetal/options"
+)
+
+func TestDhcpRequestGetBootClient(t *testing.T) {
+	enableIpxe := o.Options.EnableIpxeBoot
+	defer func() { o.Options.EnableIpxeBoot = enableIpxe }()
+
+	cases := []struct {
+		name       string
+		vendorCls  string
+		userCls    string
+		enableIpxe bool
+		want       BootClient
+	}{
+		{"PXE ROM", "PXEClient:Arch:00000:UNDI:002001", "", true, BootClientPXE},
+		{"UEFI HTTP Boot", "HTTPClient:Arch:00016:UNDI:003000", "", false, BootClientHTTP},
+		{"iPXE", "PXEClient:Arch:00000:UNDI:002001", "iPXE", true, BootClientIPXE},
+		{"iPXE with length prefix", "PXEClient:Arch:00000:UNDI:002001", "\x04iPXE", true, BootClientIPXE},
+		{"iPXE disabled", "PXEClient:Arch:00000:UNDI:002001", "iPXE", false, BootClientPXE},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			o.Options.EnableIpxeBoot = c.enableIpxe
+			req := &dhcpRequest{VendorClassId: c.vendorCls, UserClass: c.userCls}
+			if got := req.getBootClient(); got != c.want {
+				t.Errorf("getBootClient() = %s, want %s", got, c.want)
+			}
+		})
+	}
+}
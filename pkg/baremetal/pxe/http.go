@@ -0,0 +1,203 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pxe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+const (
+	// ChecksumsFile lists sha256 checksums of boot artifacts in sha256sum format
+	ChecksumsFile = "SHA256SUMS"
+
+	HeaderChecksumSha256 = "X-Checksum-Sha256"
+)
+
+type sArtifactChecksum struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+// HTTPHandler serves the boot artifacts of TFTP root through HTTP for
+// UEFI HTTP Boot and iPXE clients, with sha256 checksums of each file
+type HTTPHandler struct {
+	RootDir string
+
+	fs        http.Handler
+	lock      sync.Mutex
+	checksums map[string]*sArtifactChecksum
+}
+
+func NewHTTPHandler(rootDir string) *HTTPHandler {
+	return &HTTPHandler{
+		RootDir:   rootDir,
+		fs:        http.FileServer(httputils.Dir(rootDir)),
+		checksums: make(map[string]*sArtifactChecksum),
+	}
+}
+
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean("/" + r.URL.Path)
+	if name == "/"+ChecksumsFile {
+		h.serveChecksums(w)
+		return
+	}
+	fp := filepath.Join(h.RootDir, filepath.FromSlash(name))
+	if info, err := os.Stat(fp); err == nil && info.Mode().IsRegular() {
+		sum, err := h.GetChecksum(fp)
+		if err != nil {
+			log.Warningf("[HTTP] checksum %s: %v", fp, err)
+		} else {
+			w.Header().Set(HeaderChecksumSha256, sum)
+			w.Header().Set("ETag", fmt.Sprintf("%q", sum))
+		}
+	}
+	h.fs.ServeHTTP(w, r)
+}
+
+// GetChecksum returns sha256 checksum of file, which is cached until
+// the size or modification time of the file changes
+func (h *HTTPHandler) GetChecksum(fp string) (string, error) {
+	info, err := os.Stat(fp)
+	if err != nil {
+		return "", err
+	}
+	h.lock.Lock()
+	cached, ok := h.checksums[fp]
+	h.lock.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.sum, nil
+	}
+	sum, err := fileSha256(fp)
+	if err != nil {
+		return "", err
+	}
+	h.lock.Lock()
+	h.checksums[fp] = &sArtifactChecksum{
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		sum:     sum,
+	}
+	h.lock.Unlock()
+	return sum, nil
+}
+
+// GetChecksums returns checksums of regular files directly under root dir
+func (h *HTTPHandler) GetChecksums() (map[string]string, error) {
+	infos, err := ioutil.ReadDir(h.RootDir)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]string)
+	for _, info := range infos {
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		sum, err := h.GetChecksum(filepath.Join(h.RootDir, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+		ret[info.Name()] = sum
+	}
+	return ret, nil
+}
+
+// LogChecksums logs checksums of boot artifacts, so that the artifacts
+// fetched by clients can be verified against them
+func (h *HTTPHandler) LogChecksums() {
+	sums, err := h.GetChecksums()
+	if err != nil {
+		log.Errorf("[HTTP] checksum boot artifacts in %s: %v", h.RootDir, err)
+		return
+	}
+	for _, name := range sortedKeys(sums) {
+		log.Infof("[HTTP] boot artifact %s sha256 %s", name, sums[name])
+	}
+}
+
+func (h *HTTPHandler) serveChecksums(w http.ResponseWriter) {
+	sums, err := h.GetChecksums()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var buf strings.Builder
+	for _, name := range sortedKeys(sums) {
+		fmt.Fprintf(&buf, "%s  %s\n", sums[name], name)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, buf.String())
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func fileSha256(fp string) (string, error) {
+	f, err := os.Open(fp)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// IPXEHandler serves iPXE boot scripts at /<mac>
+type IPXEHandler struct {
+	BaremetalManager IBaremetalManager
+}
+
+func (h *IPXEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	mac, err := net.ParseMAC(strings.Trim(r.URL.Path, "/"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid mac %q", r.URL.Path), http.StatusBadRequest)
+		return
+	}
+	bmInstance := h.BaremetalManager.GetBaremetalByMac(mac)
+	if bmInstance == nil {
+		http.NotFound(w, r)
+		return
+	}
+	log.Infof("[iPXE] serve boot script of %s", mac)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, bmInstance.GetIPXEScript())
+}
@@ -0,0 +1,144 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pxe
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	testArtifact       = "hello"
+	testArtifactSha256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pxe-http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fp := filepath.Join(dir, "bootx64.efi")
+	if err := ioutil.WriteFile(fp, []byte(testArtifact), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHTTPHandler(dir)
+
+	t.Run("artifact with checksum", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/bootx64.efi", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d", w.Code)
+		}
+		if got := w.Header().Get(HeaderChecksumSha256); got != testArtifactSha256 {
+			t.Errorf("checksum header %q, want %q", got, testArtifactSha256)
+		}
+		if got := w.Body.String(); got != testArtifact {
+			t.Errorf("body %q, want %q", got, testArtifact)
+		}
+	})
+
+	t.Run("checksums file", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/"+ChecksumsFile, nil))
+		want := fmt.Sprintf("%s  bootx64.efi\n", testArtifactSha256)
+		if got := w.Body.String(); got != want {
+			t.Errorf("checksums %q, want %q", got, want)
+		}
+	})
+
+	t.Run("checksum refreshed after file changed", func(t *testing.T) {
+		if err := ioutil.WriteFile(fp, []byte("hello world"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		future := time.Now().Add(time.Hour)
+		if err := os.Chtimes(fp, future, future); err != nil {
+			t.Fatal(err)
+		}
+		sum, err := h.GetChecksum(fp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum == testArtifactSha256 {
+			t.Errorf("checksum not refreshed")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/notexist", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status %d, want 404", w.Code)
+		}
+		if got := w.Header().Get(HeaderChecksumSha256); got != "" {
+			t.Errorf("unexpected checksum header %q", got)
+		}
+	})
+}
+
+type fakeBaremetalManager struct {
+	IBaremetalManager
+	instances map[string]IBaremetalInstance
+}
+
+func (m *fakeBaremetalManager) GetBaremetalByMac(mac net.HardwareAddr) IBaremetalInstance {
+	return m.instances[mac.String()]
+}
+
+type fakeBaremetalInstance struct {
+	IBaremetalInstance
+	script string
+}
+
+func (i *fakeBaremetalInstance) GetIPXEScript() string {
+	return i.script
+}
+
+func TestIPXEHandler(t *testing.T) {
+	h := &IPXEHandler{
+		BaremetalManager: &fakeBaremetalManager{
+			instances: map[string]IBaremetalInstance{
+				"00:22:33:44:55:66": &fakeBaremetalInstance{script: "#!ipxe\nboot\n"},
+			},
+		},
+	}
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/00:22:33:44:55:66", http.StatusOK, "#!ipxe\nboot\n"},
+		{"/00:22:33:44:55:77", http.StatusNotFound, ""},
+		{"/not-a-mac", http.StatusBadRequest, ""},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", c.path, nil))
+		if w.Code != c.code {
+			t.Errorf("GET %s status %d, want %d", c.path, w.Code, c.code)
+			continue
+		}
+		if c.code == http.StatusOK && w.Body.String() != c.body {
+			t.Errorf("GET %s body %q, want %q", c.path, w.Body.String(), c.body)
+		}
+	}
+}
@@ -80,6 +80,34 @@ const (
 	FirmwareUnknown
 )
 
+// BootClient describes how a booting machine fetches its boot files
+type BootClient int
+
+const (
+	BootClientPXE  BootClient = iota // firmware PXE ROM, boot files are fetched by TFTP
+	BootClientHTTP                   // UEFI HTTP Boot, boot files are fetched by HTTP
+	BootClientIPXE                   // iPXE chainloaded by PXE ROM, boot script is fetched by HTTP
+)
+
+const (
+	// VendorClassHTTPClient is the vendor class prefix sent by UEFI HTTP Boot clients,
+	// which must be echoed back in option 60 of the offer
+	VendorClassHTTPClient = "HTTPClient"
+	// UserClassIPXE is the user class (option 77) sent by iPXE
+	UserClassIPXE = "iPXE"
+)
+
+func (c BootClient) String() string {
+	switch c {
+	case BootClientHTTP:
+		return "HTTP"
+	case BootClientIPXE:
+		return "iPXE"
+	default:
+		return "PXE"
+	}
+}
+
 type IBaremetalManager interface {
 	GetZoneId() string
 	GetBaremetalByMac(mac net.HardwareAddr) IBaremetalInstance
@@ -90,11 +118,12 @@ type IBaremetalManager interface {
 type IBaremetalInstance interface {
 	NeedPXEBoot() bool
 	GetIPMINic(cliMac net.HardwareAddr) *types.SNic
-	GetPXEDHCPConfig(arch uint16) (*dhcp.ResponseConfig, error)
+	GetPXEDHCPConfig(arch uint16, client BootClient) (*dhcp.ResponseConfig, error)
 	GetDHCPConfig(cliMac net.HardwareAddr) (*dhcp.ResponseConfig, error)
 	InitAdminNetif(cliMac net.HardwareAddr, wireId, nicType, netType string, isDoImport bool, ipAddr string) error
 	RegisterNetif(cliMac net.HardwareAddr, wireId string) error
 	GetTFTPResponse() string
+	GetIPXEScript() string
 }
 
 type Server struct {
@@ -15,6 +15,7 @@
 package tasks
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"strings"
@@ -107,7 +108,8 @@ func (task *sBaremetalPrepareTask) prepareBaremetalInfo(cli *ssh.Client) (*barem
 		return nil, err
 	}
 
-	raidDiskInfo, nonRaidDiskInfo, pcieDiskInfo, err := detect_storages.DetectStorageInfo(cli, true)
+	raidDrvs := detect_storages.GetRaidDrivers(context.Background(), cli, task.baremetal.GetRawIPMIConfig())
+	raidDiskInfo, nonRaidDiskInfo, pcieDiskInfo, err := detect_storages.DetectStorageInfoWithDrivers(cli, raidDrvs, true)
 	if err != nil {
 		return nil, err
 	}
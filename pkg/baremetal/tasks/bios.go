@@ -0,0 +1,163 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	o "yunion.io/x/onecloud/pkg/baremetal/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+)
+
+// SBaremetalBiosApplyTask diff current bios attributes against bios profile,
+// patch the difference to Bios/Settings and verify convergence after reboot
+type SBaremetalBiosApplyTask struct {
+	SBaremetalTaskBase
+
+	desired    *jsonutils.JSONDict
+	autoReboot bool
+	drv        redfish.IRedfishDriver
+	deadline   time.Time
+}
+
+func NewBaremetalBiosApplyTask(
+	userCred mcclient.TokenCredential,
+	baremetal IBaremetal,
+	taskId string,
+	data jsonutils.JSONObject,
+) ITask {
+	task := &SBaremetalBiosApplyTask{
+		SBaremetalTaskBase: newBaremetalTaskBase(userCred, baremetal, taskId, data),
+	}
+	task.SetVirtualObject(task)
+	task.SetStage(task.DoBiosApply)
+	return task
+}
+
+func (self *SBaremetalBiosApplyTask) GetName() string {
+	return "BaremetalBiosApplyTask"
+}
+
+func (self *SBaremetalBiosApplyTask) executeAfter(d time.Duration) {
+	time.AfterFunc(d, func() {
+		self.Execute(nil)
+	})
+}
+
+func (self *SBaremetalBiosApplyTask) DoBiosApply(ctx context.Context, args interface{}) error {
+	if self.data == nil {
+		return errors.Wrap(httperrors.ErrMissingParameter, "attributes")
+	}
+	attrs, _ := self.data.Get("attributes")
+	desired, ok := attrs.(*jsonutils.JSONDict)
+	if !ok || desired.Length() == 0 {
+		return errors.Wrap(httperrors.ErrMissingParameter, "attributes")
+	}
+	self.desired = desired
+	self.autoReboot = jsonutils.QueryBoolean(self.data, "auto_reboot", false)
+	checkOnly := jsonutils.QueryBoolean(self.data, "check_only", false)
+
+	ipmiInfo := self.Baremetal.GetRawIPMIConfig()
+	if ipmiInfo == nil || ipmiInfo.IpAddr == "" {
+		return errors.Error("empty IPMI ip_addr")
+	}
+	self.drv = redfish.NewRedfishDriver(ctx, "https://"+ipmiInfo.IpAddr, ipmiInfo.Username, ipmiInfo.Password, false)
+	if self.drv == nil {
+		return errors.Wrap(httperrors.ErrNotSupported, "BMC not redfish-compatible")
+	}
+
+	current, err := self.drv.GetBiosAttributes(ctx)
+	if err != nil {
+		return errors.Wrap(err, "GetBiosAttributes")
+	}
+	diff, unknown := redfish.DiffBiosAttributes(current, self.desired)
+	if len(unknown) > 0 {
+		return errors.Wrapf(httperrors.ErrNotSupported, "unknown bios attributes %s", strings.Join(unknown, ","))
+	}
+	if diff.Length() == 0 {
+		return self.onBiosApplyComplete(ctx, api.HOST_BIOS_PROFILE_CONVERGED, nil, "bios settings converged")
+	}
+	if checkOnly {
+		return self.onBiosApplyComplete(ctx, api.HOST_BIOS_PROFILE_DRIFT, diff, "bios settings drift")
+	}
+	log.Infof("Apply bios attributes %s to %s", diff, self.Baremetal.GetName())
+	err = self.drv.SetBiosAttributes(ctx, diff)
+	if err != nil {
+		return errors.Wrap(err, "SetBiosAttributes")
+	}
+	if !self.autoReboot {
+		return self.onBiosApplyComplete(ctx, api.HOST_BIOS_PROFILE_PENDING, diff, "bios settings pending, apply on next reboot")
+	}
+	if err := rebootRedfishSystem(ctx, self.drv); err != nil {
+		return errors.Wrap(err, "reboot to apply bios settings")
+	}
+	self.deadline = time.Now().Add(time.Duration(o.Options.BiosApplyTimeoutSeconds) * time.Second)
+	self.SetStage(self.WaitBiosConverged)
+	self.executeAfter(time.Duration(o.Options.BiosApplyPollIntervalSeconds) * time.Second)
+	return nil
+}
+
+func (self *SBaremetalBiosApplyTask) WaitBiosConverged(ctx context.Context, args interface{}) error {
+	current, err := self.drv.GetBiosAttributes(ctx)
+	if err != nil {
+		// BMC may report stale or no attributes during POST
+		log.Warningf("GetBiosAttributes of %s: %s", self.Baremetal.GetName(), err)
+	} else {
+		diff, _ := redfish.DiffBiosAttributes(current, self.desired)
+		if diff.Length() == 0 {
+			return self.onBiosApplyComplete(ctx, api.HOST_BIOS_PROFILE_CONVERGED, nil, "bios settings applied")
+		}
+		if time.Now().After(self.deadline) {
+			self.updateHostBiosStatus(api.HOST_BIOS_PROFILE_DRIFT, diff)
+			return errors.Errorf("bios settings not converged after reboot: %s", diff)
+		}
+	}
+	if time.Now().After(self.deadline) {
+		return errors.Wrapf(httperrors.ErrTimeout, "wait bios settings converged")
+	}
+	self.executeAfter(time.Duration(o.Options.BiosApplyPollIntervalSeconds) * time.Second)
+	return nil
+}
+
+func (self *SBaremetalBiosApplyTask) updateHostBiosStatus(status string, drift *jsonutils.JSONDict) {
+	data := jsonutils.NewDict()
+	data.Add(jsonutils.NewString(status), "bios_profile_status")
+	if drift == nil {
+		drift = jsonutils.NewDict()
+	}
+	data.Add(drift, "bios_drift")
+	_, err := modules.Hosts.Update(self.Baremetal.GetClientSession(), self.Baremetal.GetId(), data)
+	if err != nil {
+		log.Errorf("Update bios profile status of %s: %s", self.Baremetal.GetName(), err)
+	}
+}
+
+func (self *SBaremetalBiosApplyTask) onBiosApplyComplete(ctx context.Context, status string, drift *jsonutils.JSONDict, msg string) error {
+	self.updateHostBiosStatus(status, drift)
+	self.Baremetal.SyncStatus("", fmt.Sprintf("Bios apply finished: %s", msg))
+	SetTaskComplete(self, jsonutils.NewString(msg))
+	return nil
+}
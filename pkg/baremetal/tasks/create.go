@@ -15,10 +15,12 @@
 package tasks
 
 import (
+	"context"
 	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
 
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/util/ssh"
@@ -38,7 +40,7 @@ func NewBaremetalServerCreateTask(
 		SBaremetalServerBaseDeployTask: newBaremetalServerBaseDeployTask(userCred, baremetal, taskId, data),
 	}
 	task.SetVirtualObject(task)
-	task.SetStage(task.InitPXEBootTask)
+	task.SetStage(task.DoRedfishDiskConfig)
 	return task
 }
 
@@ -46,6 +48,15 @@ func (self *SBaremetalServerCreateTask) GetName() string {
 	return "BaremetalServerCreateTask"
 }
 
+// DoRedfishDiskConfig build RAID through BMC before PXE boot if baremetal configures RAID out-of-band
+func (self *SBaremetalServerCreateTask) DoRedfishDiskConfig(ctx context.Context, args interface{}) error {
+	if err := self.Baremetal.GetServer().DoRedfishDiskConfig(ctx); err != nil {
+		self.Baremetal.AutoSyncStatus()
+		return errors.Wrap(err, "DoRedfishDiskConfig")
+	}
+	return self.InitPXEBootTask(ctx, args)
+}
+
 func (self *SBaremetalServerCreateTask) DoDeploys(term *ssh.Client) (jsonutils.JSONObject, error) {
 	// Build raid
 	err := self.Baremetal.GetServer().DoDiskConfig(term)
@@ -0,0 +1,268 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	o "yunion.io/x/onecloud/pkg/baremetal/options"
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+)
+
+// SBaremetalFirmwareUpdateTask stage firmware image to BMC through Redfish UpdateService,
+// then reboot baremetal in maintenance window to apply it
+type SBaremetalFirmwareUpdateTask struct {
+	SBaremetalTaskBase
+
+	input    api.HostFirmwareUpdateInput
+	drv      redfish.IRedfishDriver
+	taskPath string
+	deadline time.Time
+}
+
+func NewBaremetalFirmwareUpdateTask(
+	userCred mcclient.TokenCredential,
+	baremetal IBaremetal,
+	taskId string,
+	data jsonutils.JSONObject,
+) ITask {
+	task := &SBaremetalFirmwareUpdateTask{
+		SBaremetalTaskBase: newBaremetalTaskBase(userCred, baremetal, taskId, data),
+	}
+	task.SetVirtualObject(task)
+	task.SetStage(task.DoFirmwareUpdate)
+	return task
+}
+
+func (self *SBaremetalFirmwareUpdateTask) GetName() string {
+	return "BaremetalFirmwareUpdateTask"
+}
+
+func (self *SBaremetalFirmwareUpdateTask) pollInterval() time.Duration {
+	return time.Duration(o.Options.FirmwareUpdatePollIntervalSeconds) * time.Second
+}
+
+func (self *SBaremetalFirmwareUpdateTask) resetDeadline() {
+	self.deadline = time.Now().Add(time.Duration(o.Options.FirmwareUpdateTimeoutSeconds) * time.Second)
+}
+
+// executeAfter run current stage later instead of sleeping in task worker
+func (self *SBaremetalFirmwareUpdateTask) executeAfter(d time.Duration) {
+	time.AfterFunc(d, func() {
+		self.Execute(nil)
+	})
+}
+
+func (self *SBaremetalFirmwareUpdateTask) DoFirmwareUpdate(ctx context.Context, args interface{}) error {
+	if self.data != nil {
+		if err := self.data.Unmarshal(&self.input); err != nil {
+			return errors.Wrap(err, "unmarshal firmware update input")
+		}
+	}
+	ipmiInfo := self.Baremetal.GetRawIPMIConfig()
+	if ipmiInfo == nil || ipmiInfo.IpAddr == "" {
+		return errors.Error("empty IPMI ip_addr")
+	}
+	self.drv = redfish.NewRedfishDriver(ctx, "https://"+ipmiInfo.IpAddr, ipmiInfo.Username, ipmiInfo.Password, false)
+	if self.drv == nil {
+		return errors.Wrap(httperrors.ErrNotSupported, "BMC not redfish-compatible")
+	}
+
+	var err error
+	switch self.input.Method {
+	case api.FIRMWARE_UPDATE_METHOD_PUSH:
+		self.taskPath, err = self.pushFirmware(ctx)
+	default:
+		self.taskPath, err = self.drv.SimpleUpdateFirmware(ctx, self.input.ImageUri, self.input.Targets, true)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "%s firmware %s", self.input.Method, self.input.ImageUri)
+	}
+	log.Infof("Firmware %s staging on %s, task %q", self.input.ImageUri, self.Baremetal.GetName(), self.taskPath)
+
+	self.resetDeadline()
+	self.SetStage(self.WaitFirmwareStaged)
+	self.executeAfter(self.pollInterval())
+	return nil
+}
+
+func (self *SBaremetalFirmwareUpdateTask) pushFirmware(ctx context.Context) (string, error) {
+	client := httputils.GetAdaptiveTimeoutClient()
+	resp, err := httputils.Request(client, ctx, httputils.GET, self.input.ImageUri, nil, nil, false)
+	if err != nil {
+		return "", errors.Wrap(err, "download firmware image")
+	}
+	defer httputils.CloseResponse(resp)
+	if resp.StatusCode >= 300 {
+		return "", errors.Wrapf(httperrors.ErrInvalidStatus, "download firmware image: %s", resp.Status)
+	}
+	filename := self.input.ImageUri
+	if u, err := url.Parse(self.input.ImageUri); err == nil {
+		filename = path.Base(u.Path)
+	}
+	return self.drv.PushFirmware(ctx, filename, resp.Body, self.input.Targets, true)
+}
+
+// getTask return nil task if BMC doesn't expose the update task
+func (self *SBaremetalFirmwareUpdateTask) getTask(ctx context.Context) (*redfish.STaskInfo, error) {
+	task, err := self.drv.GetTask(ctx, self.taskPath)
+	if err != nil {
+		if errors.Cause(err) == httperrors.ErrMissingParameter {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+func taskFailReason(task *redfish.STaskInfo) error {
+	return errors.Errorf("firmware update task %s %s: %s", task.TaskState, task.TaskStatus, strings.Join(task.Messages, "; "))
+}
+
+func (self *SBaremetalFirmwareUpdateTask) WaitFirmwareStaged(ctx context.Context, args interface{}) error {
+	task, err := self.getTask(ctx)
+	if err != nil {
+		log.Warningf("get firmware update task %s: %s", self.taskPath, err)
+	}
+	if task != nil && task.IsFinished() && !task.IsSucceeded() {
+		return taskFailReason(task)
+	}
+	if err != nil || (task != nil && !task.IsFinished() && !task.IsPending()) {
+		if time.Now().After(self.deadline) {
+			return errors.Wrapf(httperrors.ErrTimeout, "wait firmware staged")
+		}
+		self.executeAfter(self.pollInterval())
+		return nil
+	}
+	if !self.input.AutoReboot {
+		return self.onFirmwareUpdateComplete(ctx, "firmware staged, apply on next reboot")
+	}
+	self.SetStage(self.WaitMaintenanceWindow)
+	self.Execute(nil)
+	return nil
+}
+
+// maintenanceWindowWait return how long to wait before rebooting in maintenance window.
+// Waiting is kept in memory and lost when baremetal agent restarts,
+// so a window starting later than maxWait is not waited for, firmware is left staged.
+func maintenanceWindowWait(now, start, end time.Time, maxWait time.Duration) (time.Duration, error) {
+	if start.IsZero() {
+		return 0, nil
+	}
+	if now.After(end) {
+		return 0, errors.Errorf("maintenance window %s - %s missed, firmware staged, apply on next reboot", start, end)
+	}
+	if !now.Before(start) {
+		return 0, nil
+	}
+	wait := start.Sub(now)
+	if wait > maxWait {
+		return 0, errors.Errorf("maintenance window %s starts in %s, longer than %s, firmware staged, apply on next reboot", start, wait, maxWait)
+	}
+	return wait, nil
+}
+
+func (self *SBaremetalFirmwareUpdateTask) WaitMaintenanceWindow(ctx context.Context, args interface{}) error {
+	maxWait := time.Duration(o.Options.FirmwareMaintenanceWindowMaxWaitSeconds) * time.Second
+	wait, err := maintenanceWindowWait(time.Now(), self.input.MaintenanceWindowStart, self.input.MaintenanceWindowEnd, maxWait)
+	if err != nil {
+		// staging succeeded, the firmware takes effect on next reboot
+		log.Warningf("Baremetal %s skip rebooting to apply firmware: %s", self.Baremetal.GetName(), err)
+		return self.onFirmwareUpdateComplete(ctx, err.Error())
+	}
+	if wait > 0 {
+		log.Infof("Baremetal %s wait maintenance window %s to apply firmware", self.Baremetal.GetName(), self.input.MaintenanceWindowStart)
+		self.executeAfter(wait)
+		return nil
+	}
+	if err := rebootRedfishSystem(ctx, self.drv); err != nil {
+		return errors.Wrap(err, "reboot to apply firmware")
+	}
+	self.resetDeadline()
+	self.SetStage(self.WaitFirmwareApplied)
+	self.executeAfter(self.pollInterval())
+	return nil
+}
+
+// rebootRedfishSystem power on or restart system so that pending settings take effect
+func rebootRedfishSystem(ctx context.Context, drv redfish.IRedfishDriver) error {
+	_, sysInfo, err := drv.GetSystemInfo(ctx)
+	if err != nil {
+		return errors.Wrap(err, "GetSystemInfo")
+	}
+	if sysInfo.PowerState == types.POWER_STATUS_OFF {
+		return drv.Reset(ctx, "On")
+	}
+	err = drv.Reset(ctx, "GracefulRestart")
+	if err != nil {
+		log.Warningf("GracefulRestart fail %s, try ForceRestart", err)
+		return drv.Reset(ctx, "ForceRestart")
+	}
+	return nil
+}
+
+func (self *SBaremetalFirmwareUpdateTask) WaitFirmwareApplied(ctx context.Context, args interface{}) error {
+	task, err := self.getTask(ctx)
+	if err != nil {
+		// BMC may be unavailable while applying its own firmware
+		log.Warningf("get firmware update task %s: %s", self.taskPath, err)
+	}
+	if task != nil && task.IsFinished() {
+		if !task.IsSucceeded() {
+			return taskFailReason(task)
+		}
+		return self.onFirmwareUpdateComplete(ctx, "firmware applied")
+	}
+	if err == nil && task == nil {
+		return self.onFirmwareUpdateComplete(ctx, "baremetal rebooted to apply firmware")
+	}
+	if time.Now().After(self.deadline) {
+		return errors.Wrapf(httperrors.ErrTimeout, "wait firmware applied")
+	}
+	self.executeAfter(self.pollInterval())
+	return nil
+}
+
+func (self *SBaremetalFirmwareUpdateTask) onFirmwareUpdateComplete(ctx context.Context, msg string) error {
+	fws, err := self.drv.GetFirmwareInventory(ctx)
+	if err != nil {
+		log.Errorf("GetFirmwareInventory fail: %s", err)
+	} else {
+		data := jsonutils.NewDict()
+		data.Add(jsonutils.Marshal(fws), "firmware_inventory")
+		_, err = modules.Hosts.Update(self.Baremetal.GetClientSession(), self.Baremetal.GetId(), data)
+		if err != nil {
+			log.Errorf("Update firmware inventory of %s: %s", self.Baremetal.GetName(), err)
+		}
+	}
+	self.Baremetal.SyncStatus("", fmt.Sprintf("Firmware update finished: %s", msg))
+	SetTaskComplete(self, jsonutils.NewString(msg))
+	return nil
+}
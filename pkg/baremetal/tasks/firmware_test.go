@@ -0,0 +1,68 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowWait(t *testing.T) {
+	now := time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC)
+	maxWait := 15 * time.Minute
+	cases := map[string]struct {
+		start   time.Time
+		end     time.Time
+		want    time.Duration
+		wantErr bool
+	}{
+		"No maintenance window": {},
+		"In maintenance window": {
+			start: now.Add(-time.Minute),
+			end:   now.Add(time.Hour),
+		},
+		"Window starts soon": {
+			start: now.Add(10 * time.Minute),
+			end:   now.Add(time.Hour),
+			want:  10 * time.Minute,
+		},
+		"Window too far": {
+			start:   now.Add(2 * time.Hour),
+			end:     now.Add(3 * time.Hour),
+			wantErr: true,
+		},
+		"Window missed": {
+			start:   now.Add(-2 * time.Hour),
+			end:     now.Add(-time.Hour),
+			wantErr: true,
+		},
+	}
+	for name, c := range cases {
+		wait, err := maintenanceWindowWait(now, c.start, c.end, maxWait)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("TestCase %q expect error, got wait %s", name, wait)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("TestCase %q failed: %v", name, err)
+			continue
+		}
+		if wait != c.want {
+			t.Errorf("TestCase %q failed, output: %s, expected: %s", name, wait, c.want)
+		}
+	}
+}
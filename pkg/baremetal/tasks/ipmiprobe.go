@@ -121,6 +121,12 @@ func (self *SBaremetalIpmiProbeTask) doRedfishIpmiProbe(ctx context.Context, drv
 	}
 	updateInfo["sys_info"] = dmiSysInfo
 	updateInfo["is_baremetal"] = true
+	fws, err := drv.GetFirmwareInventory(ctx)
+	if err != nil {
+		log.Warningf("drv.GetFirmwareInventory fail: %s", err)
+	} else {
+		updateInfo["firmware_inventory"] = fws
+	}
 	ipmiInfo := self.Baremetal.GetRawIPMIConfig()
 	if ipmiInfo == nil {
 		ipmiInfo = &types.SIPMIInfo{}
@@ -15,6 +15,7 @@
 package types
 
 import (
+	"context"
 	"net"
 
 	"yunion.io/x/jsonutils"
@@ -30,6 +31,7 @@ type IBaremetalServer interface {
 	RemoveDesc()
 	DoDiskUnconfig(term *ssh.Client) error
 	DoDiskConfig(term *ssh.Client) error
+	DoRedfishDiskConfig(ctx context.Context) error
 	DoEraseDisk(term *ssh.Client) error
 	DoPartitionDisk(term *ssh.Client) ([]*disktool.Partition, error)
 	DoRebuildRootDisk(term *ssh.Client) ([]*disktool.Partition, error)
@@ -15,6 +15,7 @@
 package detect_storages
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 
 	"yunion.io/x/onecloud/pkg/baremetal/utils/raid"
 	"yunion.io/x/onecloud/pkg/baremetal/utils/raid/drivers"
+	"yunion.io/x/onecloud/pkg/baremetal/utils/raid/redfishraid"
 	"yunion.io/x/onecloud/pkg/cloudcommon/types"
 	"yunion.io/x/onecloud/pkg/compute/baremetal"
 	"yunion.io/x/onecloud/pkg/util/sysutils"
@@ -47,12 +49,28 @@ func GetRaidLogicVolumes(drv raid.IRaidDriver) ([]*raid.RaidLogicalVolume, error
 	return lvs, nil
 }
 
+// GetRaidDrivers return Redfish RAID driver if RAID of baremetal is configured out-of-band, otherwise in-band drivers
+func GetRaidDrivers(ctx context.Context, term raid.IExecTerm, ipmiInfo *types.SIPMIInfo) []raid.IRaidDriver {
+	if ipmiInfo != nil && ipmiInfo.RedfishRaid {
+		drv, err := redfishraid.NewRedfishRaidFromIPMI(ctx, ipmiInfo)
+		if err == nil {
+			return []raid.IRaidDriver{drv}
+		}
+		log.Errorf("Redfish raid driver: %v, fallback to in-band drivers", err)
+	}
+	return drivers.GetDrivers(term)
+}
+
 func DetectStorageInfo(term raid.IExecTerm, wait bool) ([]*baremetal.BaremetalStorage, []*baremetal.BaremetalStorage, []*baremetal.BaremetalStorage, error) {
+	return DetectStorageInfoWithDrivers(term, drivers.GetDrivers(term), wait)
+}
+
+func DetectStorageInfoWithDrivers(term raid.IExecTerm, raidDrvs []raid.IRaidDriver, wait bool) ([]*baremetal.BaremetalStorage, []*baremetal.BaremetalStorage, []*baremetal.BaremetalStorage, error) {
 	raidDiskInfo := make([]*baremetal.BaremetalStorage, 0)
 	lvDiskInfo := make([]*raid.RaidLogicalVolume, 0)
 
 	raidDrivers := []string{}
-	for _, drv := range drivers.GetDrivers(term) {
+	for _, drv := range raidDrvs {
 		if err := drv.ParsePhyDevs(); err != nil {
 			log.Warningf("Raid driver %s ParsePhyDevs: %v", drv.GetName(), err)
 			continue
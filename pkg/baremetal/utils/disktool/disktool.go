@@ -305,6 +305,10 @@ func (ps *DiskPartitions) GetDevName() string {
 	if !ps.IsRaidDriver() || ps.raidConfig == baremetal.DISK_CONF_NONE {
 		return devName
 	}
+	if ps.driver == baremetal.DISK_DRIVER_REDFISH {
+		// volumes built out-of-band have no in-band tool to map block device
+		return devName
+	}
 	raidDrv, err := raiddrivers.GetDriverWithInit(ps.driver, ps.tool.runner.Term())
 	if err != nil {
 		log.Errorf("Failed to find %s raid driver: %v", ps.driver, err)
@@ -0,0 +1,290 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfishraid
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/tristate"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/baremetal/utils/raid"
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+	"yunion.io/x/onecloud/pkg/compute/baremetal"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+)
+
+const (
+	taskPollInterval = 5 * time.Second
+	taskTimeout      = 10 * time.Minute
+)
+
+type RedfishRaidPhyDev struct {
+	*raid.RaidBasePhyDev
+	slot int
+	path string
+}
+
+func NewRedfishRaidPhyDev(adapter int, slot int, drive redfish.SStorageDrive) *RedfishRaidPhyDev {
+	b := raid.NewRaidBasePhyDev(baremetal.DISK_DRIVER_REDFISH)
+	b.Adapter = adapter
+	b.Size = drive.CapacityBytes / 1024 / 1024 // MB
+	b.Model = strings.TrimSpace(drive.Model)
+	switch drive.MediaType {
+	case "HDD":
+		b.Rotate = tristate.True
+	case "SSD":
+		b.Rotate = tristate.False
+	}
+	b.Status = strings.ToLower(drive.Health)
+	return &RedfishRaidPhyDev{
+		RaidBasePhyDev: b,
+		slot:           slot,
+		path:           drive.Path,
+	}
+}
+
+func (dev *RedfishRaidPhyDev) ToBaremetalStorage(idx int) *baremetal.BaremetalStorage {
+	s := dev.RaidBasePhyDev.ToBaremetalStorage(idx)
+	s.Slot = dev.slot
+	return s
+}
+
+type RedfishRaidAdaptor struct {
+	index int
+	raid  *RedfishRaid
+	ctrl  redfish.SStorageController
+	devs  []*RedfishRaidPhyDev
+}
+
+func NewRedfishRaidAdaptor(index int, raid *RedfishRaid, ctrl redfish.SStorageController) *RedfishRaidAdaptor {
+	return &RedfishRaidAdaptor{
+		index: index,
+		raid:  raid,
+		ctrl:  ctrl,
+		devs:  make([]*RedfishRaidPhyDev, 0),
+	}
+}
+
+func (adapter *RedfishRaidAdaptor) GetIndex() int {
+	return adapter.index
+}
+
+func (adapter *RedfishRaidAdaptor) ParsePhyDevs() error {
+	drives, err := adapter.raid.drv.GetStorageDrives(adapter.raid.ctx, adapter.ctrl)
+	if err != nil {
+		return errors.Wrapf(err, "GetStorageDrives of %s", adapter.ctrl.Id)
+	}
+	for i := range drives {
+		adapter.devs = append(adapter.devs, NewRedfishRaidPhyDev(adapter.index, i, drives[i]))
+	}
+	return nil
+}
+
+func (adapter *RedfishRaidAdaptor) GetDevices() []*baremetal.BaremetalStorage {
+	ret := []*baremetal.BaremetalStorage{}
+	for idx, dev := range adapter.devs {
+		ret = append(ret, dev.ToBaremetalStorage(idx))
+	}
+	return ret
+}
+
+func (adapter *RedfishRaidAdaptor) getVolumes() ([]redfish.SStorageVolume, error) {
+	return adapter.raid.drv.GetStorageVolumes(adapter.raid.ctx, adapter.ctrl)
+}
+
+func (adapter *RedfishRaidAdaptor) GetLogicVolumes() ([]*raid.RaidLogicalVolume, error) {
+	vols, err := adapter.getVolumes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetStorageVolumes of %s", adapter.ctrl.Id)
+	}
+	lvs := make([]*raid.RaidLogicalVolume, 0)
+	for i := range vols {
+		lvs = append(lvs, &raid.RaidLogicalVolume{
+			Index:   i,
+			Adapter: adapter.index,
+		})
+	}
+	return lvs, nil
+}
+
+func (adapter *RedfishRaidAdaptor) RemoveLogicVolumes() error {
+	vols, err := adapter.getVolumes()
+	if err != nil {
+		return errors.Wrapf(err, "GetStorageVolumes of %s", adapter.ctrl.Id)
+	}
+	for i := len(vols) - 1; i >= 0; i-- {
+		taskPath, err := adapter.raid.drv.DeleteStorageVolume(adapter.raid.ctx, vols[i].Path)
+		if err != nil {
+			return errors.Wrapf(err, "remove volume %s", vols[i].Path)
+		}
+		if err := adapter.raid.waitTask(taskPath); err != nil {
+			return errors.Wrapf(err, "remove volume %s", vols[i].Path)
+		}
+	}
+	return nil
+}
+
+func (adapter *RedfishRaidAdaptor) PreBuildRaid(confs []*api.BaremetalDiskConfig) error {
+	if len(adapter.ctrl.SupportedRAIDTypes) == 0 {
+		return nil
+	}
+	for _, conf := range confs {
+		if conf.Conf == baremetal.DISK_CONF_NONE {
+			continue
+		}
+		if raidType := strings.ToUpper(conf.Conf); !utils.IsInStringArray(raidType, adapter.ctrl.SupportedRAIDTypes) {
+			return errors.Wrapf(httperrors.ErrNotSupported, "storage %s supports %s, not %s", adapter.ctrl.Id, adapter.ctrl.SupportedRAIDTypes, raidType)
+		}
+	}
+	return nil
+}
+
+func (adapter *RedfishRaidAdaptor) buildRaid(raidType string, devs []*baremetal.BaremetalStorage) error {
+	drives := make([]string, 0)
+	for _, dev := range devs {
+		if dev.Slot < 0 || dev.Slot >= len(adapter.devs) {
+			return fmt.Errorf("invalid slot %d of storage %s", dev.Slot, adapter.ctrl.Id)
+		}
+		drives = append(drives, adapter.devs[dev.Slot].path)
+	}
+	taskPath, err := adapter.raid.drv.CreateStorageVolume(adapter.raid.ctx, adapter.ctrl, raidType, drives)
+	if err != nil {
+		return err
+	}
+	return adapter.raid.waitTask(taskPath)
+}
+
+func (adapter *RedfishRaidAdaptor) BuildRaid0(devs []*baremetal.BaremetalStorage, conf *api.BaremetalDiskConfig) error {
+	return adapter.buildRaid(redfish.RAID_TYPE_RAID0, devs)
+}
+
+func (adapter *RedfishRaidAdaptor) BuildRaid1(devs []*baremetal.BaremetalStorage, conf *api.BaremetalDiskConfig) error {
+	return adapter.buildRaid(redfish.RAID_TYPE_RAID1, devs)
+}
+
+func (adapter *RedfishRaidAdaptor) BuildRaid5(devs []*baremetal.BaremetalStorage, conf *api.BaremetalDiskConfig) error {
+	return adapter.buildRaid(redfish.RAID_TYPE_RAID5, devs)
+}
+
+func (adapter *RedfishRaidAdaptor) BuildRaid10(devs []*baremetal.BaremetalStorage, conf *api.BaremetalDiskConfig) error {
+	return adapter.buildRaid(redfish.RAID_TYPE_RAID10, devs)
+}
+
+func (adapter *RedfishRaidAdaptor) BuildNoneRaid(devs []*baremetal.BaremetalStorage) error {
+	// Redfish has no standard way to switch drives to JBOD, drives left out of
+	// volumes are exposed as raw disks only if controller runs in non-RAID mode
+	log.Warningf("Storage %s: %d drives are left unconfigured for none raid", adapter.ctrl.Id, len(devs))
+	return nil
+}
+
+// RedfishRaid configure RAID out-of-band through Storage and Volumes collections of BMC
+type RedfishRaid struct {
+	ctx      context.Context
+	drv      redfish.IRedfishDriver
+	adapters []*RedfishRaidAdaptor
+}
+
+func NewRedfishRaid(ctx context.Context, drv redfish.IRedfishDriver) raid.IRaidDriver {
+	return &RedfishRaid{
+		ctx:      ctx,
+		drv:      drv,
+		adapters: make([]*RedfishRaidAdaptor, 0),
+	}
+}
+
+func (r *RedfishRaid) GetName() string {
+	return baremetal.DISK_DRIVER_REDFISH
+}
+
+func (r *RedfishRaid) ParsePhyDevs() error {
+	ctrls, err := r.drv.GetStorageControllers(r.ctx)
+	if err != nil {
+		return errors.Wrap(err, "GetStorageControllers")
+	}
+	r.adapters = make([]*RedfishRaidAdaptor, 0)
+	for i := range ctrls {
+		adapter := NewRedfishRaidAdaptor(i, r, ctrls[i])
+		if err := adapter.ParsePhyDevs(); err != nil {
+			return err
+		}
+		r.adapters = append(r.adapters, adapter)
+	}
+	if len(r.adapters) > 0 {
+		return nil
+	}
+	return fmt.Errorf("Empty adapters")
+}
+
+func (r *RedfishRaid) PreBuildRaid(_ []*api.BaremetalDiskConfig, _ int) error {
+	return nil
+}
+
+func (r *RedfishRaid) GetAdapters() []raid.IRaidAdapter {
+	ret := make([]raid.IRaidAdapter, 0)
+	for _, a := range r.adapters {
+		ret = append(ret, a)
+	}
+	return ret
+}
+
+func (r *RedfishRaid) CleanRaid() error {
+	for _, a := range r.adapters {
+		if err := a.RemoveLogicVolumes(); err != nil {
+			return errors.Wrapf(err, "clean storage %s", a.ctrl.Id)
+		}
+	}
+	return nil
+}
+
+// waitTask wait asynchronous volume operation, BMC without task returns empty path
+func (r *RedfishRaid) waitTask(path string) error {
+	if len(path) == 0 {
+		return nil
+	}
+	deadline := time.Now().Add(taskTimeout)
+	for time.Now().Before(deadline) {
+		task, err := r.drv.GetTask(r.ctx, path)
+		if err != nil {
+			log.Warningf("get task %s: %s", path, err)
+		} else if task.IsFinished() {
+			if !task.IsSucceeded() {
+				return errors.Errorf("task %s %s %s: %s", path, task.TaskState, task.TaskStatus, strings.Join(task.Messages, "; "))
+			}
+			return nil
+		}
+		time.Sleep(taskPollInterval)
+	}
+	return errors.Wrapf(httperrors.ErrTimeout, "wait task %s", path)
+}
+
+// NewRedfishRaidFromIPMI probe Redfish API of BMC by IPMI config of baremetal
+func NewRedfishRaidFromIPMI(ctx context.Context, ipmiInfo *types.SIPMIInfo) (raid.IRaidDriver, error) {
+	if ipmiInfo == nil || ipmiInfo.IpAddr == "" {
+		return nil, errors.Error("empty IPMI ip_addr")
+	}
+	drv := redfish.NewRedfishDriver(ctx, "https://"+ipmiInfo.IpAddr, ipmiInfo.Username, ipmiInfo.Password, false)
+	if drv == nil {
+		return nil, errors.Wrap(httperrors.ErrNotSupported, "BMC not redfish-compatible")
+	}
+	return NewRedfishRaid(ctx, drv), nil
+}
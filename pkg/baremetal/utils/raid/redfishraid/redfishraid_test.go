@@ -0,0 +1,174 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfishraid
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/compute/baremetal"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+)
+
+type fakeRedfishDriver struct {
+	redfish.IRedfishDriver
+
+	drives  []redfish.SStorageDrive
+	volumes []redfish.SStorageVolume
+	task    redfish.STaskInfo
+
+	created [][]string
+	deleted []string
+}
+
+func (d *fakeRedfishDriver) GetStorageDrives(ctx context.Context, ctrl redfish.SStorageController) ([]redfish.SStorageDrive, error) {
+	return d.drives, nil
+}
+
+func (d *fakeRedfishDriver) GetStorageVolumes(ctx context.Context, ctrl redfish.SStorageController) ([]redfish.SStorageVolume, error) {
+	return d.volumes, nil
+}
+
+func (d *fakeRedfishDriver) CreateStorageVolume(ctx context.Context, ctrl redfish.SStorageController, raidType string, drives []string) (string, error) {
+	d.created = append(d.created, append([]string{raidType}, drives...))
+	return "/redfish/v1/TaskService/Tasks/1", nil
+}
+
+func (d *fakeRedfishDriver) DeleteStorageVolume(ctx context.Context, path string) (string, error) {
+	d.deleted = append(d.deleted, path)
+	return "", nil
+}
+
+func (d *fakeRedfishDriver) GetTask(ctx context.Context, path string) (redfish.STaskInfo, error) {
+	return d.task, nil
+}
+
+func newTestAdapter(drv *fakeRedfishDriver, ctrl redfish.SStorageController) *RedfishRaidAdaptor {
+	r := NewRedfishRaid(context.Background(), drv).(*RedfishRaid)
+	return NewRedfishRaidAdaptor(0, r, ctrl)
+}
+
+func TestNewRedfishRaidPhyDev(t *testing.T) {
+	drive := redfish.SStorageDrive{
+		Path:          "/redfish/v1/Systems/1/Storage/RAID.1/Drives/Disk.0",
+		Model:         " ST1000NM0055 ",
+		MediaType:     "HDD",
+		CapacityBytes: 1000 * 1024 * 1024 * 1024,
+		Health:        "OK",
+	}
+	s := NewRedfishRaidPhyDev(1, 3, drive).ToBaremetalStorage(0)
+	want := &baremetal.BaremetalStorage{
+		Adapter: 1,
+		Slot:    3,
+		Status:  "ok",
+		Size:    1000 * 1024,
+		Model:   "ST1000NM0055",
+		Rotate:  true,
+		Driver:  baremetal.DISK_DRIVER_REDFISH,
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("ToBaremetalStorage = %#v, want %#v", s, want)
+	}
+}
+
+func TestRedfishRaidAdaptor_PreBuildRaid(t *testing.T) {
+	cases := map[string]struct {
+		supported []string
+		confs     []string
+		wantErr   bool
+	}{
+		"Controller reports no raid types": {
+			confs: []string{baremetal.DISK_CONF_RAID5},
+		},
+		"Supported raid": {
+			supported: []string{redfish.RAID_TYPE_RAID0, redfish.RAID_TYPE_RAID1},
+			confs:     []string{baremetal.DISK_CONF_RAID1, baremetal.DISK_CONF_NONE},
+		},
+		"Unsupported raid": {
+			supported: []string{redfish.RAID_TYPE_RAID0, redfish.RAID_TYPE_RAID1},
+			confs:     []string{baremetal.DISK_CONF_RAID5},
+			wantErr:   true,
+		},
+	}
+	for name, c := range cases {
+		adapter := newTestAdapter(&fakeRedfishDriver{}, redfish.SStorageController{Id: "RAID.1", SupportedRAIDTypes: c.supported})
+		confs := make([]*api.BaremetalDiskConfig, 0)
+		for _, conf := range c.confs {
+			confs = append(confs, &api.BaremetalDiskConfig{Conf: conf})
+		}
+		err := adapter.PreBuildRaid(confs)
+		if (err != nil) != c.wantErr {
+			t.Errorf("TestCase %q failed, err: %v, wantErr: %v", name, err, c.wantErr)
+		}
+	}
+}
+
+func TestRedfishRaidAdaptor_BuildRaid(t *testing.T) {
+	drv := &fakeRedfishDriver{
+		drives: []redfish.SStorageDrive{
+			{Path: "/drives/0"},
+			{Path: "/drives/1"},
+			{Path: "/drives/2"},
+		},
+		task: redfish.STaskInfo{TaskState: redfish.TASK_STATE_COMPLETED, TaskStatus: redfish.TASK_STATUS_OK},
+	}
+	adapter := newTestAdapter(drv, redfish.SStorageController{Id: "RAID.1"})
+	if err := adapter.ParsePhyDevs(); err != nil {
+		t.Fatalf("ParsePhyDevs: %v", err)
+	}
+	devs := adapter.GetDevices()
+	if err := adapter.BuildRaid1(devs[1:], nil); err != nil {
+		t.Fatalf("BuildRaid1: %v", err)
+	}
+	want := [][]string{{redfish.RAID_TYPE_RAID1, "/drives/1", "/drives/2"}}
+	if !reflect.DeepEqual(drv.created, want) {
+		t.Errorf("created volumes %v, want %v", drv.created, want)
+	}
+
+	if err := adapter.BuildRaid0([]*baremetal.BaremetalStorage{{Slot: 5}}, nil); err == nil {
+		t.Errorf("expect error of invalid slot")
+	}
+
+	drv.task = redfish.STaskInfo{TaskState: redfish.TASK_STATE_EXCEPTION, TaskStatus: redfish.TASK_STATUS_CRITICAL}
+	if err := adapter.BuildRaid0(devs[:1], nil); err == nil {
+		t.Errorf("expect error of failed task")
+	}
+}
+
+func TestRedfishRaidAdaptor_RemoveLogicVolumes(t *testing.T) {
+	drv := &fakeRedfishDriver{
+		volumes: []redfish.SStorageVolume{
+			{Path: "/volumes/0"},
+			{Path: "/volumes/1"},
+		},
+	}
+	adapter := newTestAdapter(drv, redfish.SStorageController{Id: "RAID.1"})
+	lvs, err := adapter.GetLogicVolumes()
+	if err != nil {
+		t.Fatalf("GetLogicVolumes: %v", err)
+	}
+	if len(lvs) != 2 {
+		t.Errorf("GetLogicVolumes got %d volumes, want 2", len(lvs))
+	}
+	if err := adapter.RemoveLogicVolumes(); err != nil {
+		t.Fatalf("RemoveLogicVolumes: %v", err)
+	}
+	want := []string{"/volumes/1", "/volumes/0"}
+	if !reflect.DeepEqual(drv.deleted, want) {
+		t.Errorf("deleted volumes %v, want %v", drv.deleted, want)
+	}
+}
@@ -175,7 +175,7 @@ func ParseNetworkConfig(desc string, idx int) (*compute.NetworkConfig, error) {
 			netConfig.StandbyPortCount, _ = strconv.Atoi(p[len("standby-port="):])
 		} else if strings.HasPrefix(p, "standby-addr=") {
 			netConfig.StandbyAddrCount, _ = strconv.Atoi(p[len("standby-addr="):])
-		} else if utils.IsInStringArray(p, []string{"virtio", "e1000", "vmxnet3"}) {
+		} else if utils.IsInStringArray(p, []string{"virtio", "e1000", "vmxnet3", compute.NETWORK_DRIVER_VHOST_USER}) {
 			netConfig.Driver = p
 		} else if regutils.MatchSize(p) {
 			bw, err := fileutils.GetSizeMb(p, 'M', 1000)
@@ -83,6 +83,8 @@ const (
 	ACT_PROBE             = "probe"
 	ACT_PROBE_FAIL        = "probe_fail"
 	ACT_IMAGE_DELETE_FAIL = "delete_fail"
+	ACT_SIGN              = "sign"
+	ACT_SIGN_FAIL         = "sign_fail"
 
 	ACT_SWITCHED      = "switched"
 	ACT_SWITCH_FAILED = "switch_failed"
@@ -153,6 +155,11 @@ const (
 	ACT_VM_IO_THROTTLE      = "io_throttle"
 	ACT_VM_IO_THROTTLE_FAIL = "io_throttle_fail"
 
+	ACT_DISK_CHANGING_STORAGE      = "disk_changing_storage"
+	ACT_DISK_CHANGE_STORAGE        = "disk_change_storage"
+	ACT_DISK_CHANGE_STORAGE_FAIL   = "disk_change_storage_fail"
+	ACT_DISK_CHANGE_STORAGE_CANCEL = "disk_change_storage_cancel"
+
 	ACT_REBUILDING_ROOT   = "rebuilding_root"
 	ACT_REBUILD_ROOT      = "rebuild_root"
 	ACT_REBUILD_ROOT_FAIL = "rebuild_root_fail"
@@ -244,6 +251,14 @@ const (
 	ACT_GUEST_PANICKED                   = "guest_panicked"
 	ACT_HOST_MAINTENANCE                 = "host_maintenance"
 	ACT_HOST_DOWN                        = "host_down"
+	ACT_HOST_FENCE                       = "host_fence"
+	ACT_HOST_FENCE_FAIL                  = "host_fence_fail"
+	ACT_HOST_EVACUATE                    = "host_evacuate"
+	ACT_HOST_EVACUATE_FAIL               = "host_evacuate_fail"
+	ACT_GUEST_HA_REBUILD                 = "guest_ha_rebuild"
+	ACT_GUEST_HA_REBUILD_FAIL            = "guest_ha_rebuild_fail"
+	ACT_REBALANCE_RECOMMEND              = "rebalance_recommend"
+	ACT_REBALANCE_MIGRATE                = "rebalance_migrate"
 
 	ACT_UPLOAD_OBJECT  = "upload_obj"
 	ACT_DELETE_OBJECT  = "delete_obj"
@@ -37,6 +37,11 @@ const (
 	IMAGE_ACTIVED = "IMAGE_ACTIVED"
 
 	USER_LOGIN_EXCEPTION = "USER_LOGIN_EXCEPTION"
+
+	APPROVAL_REQUEST_SUBMITTED = "APPROVAL_REQUEST_SUBMITTED"
+	APPROVAL_REQUEST_APPROVED  = "APPROVAL_REQUEST_APPROVED"
+	APPROVAL_REQUEST_REJECTED  = "APPROVAL_REQUEST_REJECTED"
+	APPROVAL_REQUEST_EXPIRED   = "APPROVAL_REQUEST_EXPIRED"
 )
 
 var (
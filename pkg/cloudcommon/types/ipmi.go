@@ -31,6 +31,8 @@ type SIPMIInfo struct {
 	RedfishApi bool   `json:"redfish_api,omitfalse"`
 	CdromBoot  bool   `json:"cdrom_boot,omitfalse"`
 	PxeBoot    bool   `json:"pxe_boot,omitfalse"`
+	// configure RAID through Redfish Storage instead of in-band tools
+	RedfishRaid bool `json:"redfish_raid,omitfalse"`
 }
 
 func (info SIPMIInfo) ToPrepareParams() jsonutils.JSONObject {
@@ -58,5 +60,8 @@ func (info SIPMIInfo) ToPrepareParams() jsonutils.JSONObject {
 	if info.PxeBoot {
 		data.Add(jsonutils.JSONTrue, "ipmi_pxe_boot")
 	}
+	if info.RedfishRaid {
+		data.Add(jsonutils.JSONTrue, "ipmi_redfish_raid")
+	}
 	return data
 }
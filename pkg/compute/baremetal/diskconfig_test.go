@@ -1255,3 +1255,26 @@ func TestGetSplitSizes(t *testing.T) {
 		})
 	}
 }
+
+func TestMeetConfigRedfish(t *testing.T) {
+	storages := []*BaremetalStorage{
+		{Driver: DISK_DRIVER_REDFISH, Adapter: 0, Slot: 0, Size: 1024},
+		{Driver: DISK_DRIVER_REDFISH, Adapter: 0, Slot: 1, Size: 1024},
+	}
+	tests := []struct {
+		name    string
+		conf    string
+		wantErr bool
+	}{
+		{name: "raid1", conf: DISK_CONF_RAID1, wantErr: false},
+		{name: "none", conf: DISK_CONF_NONE, wantErr: false},
+		{name: "raid5_not_enough_disks", conf: DISK_CONF_RAID5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := MeetConfig(&api.BaremetalDiskConfig{Conf: tt.conf}, storages); (err != nil) != tt.wantErr {
+				t.Errorf("MeetConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
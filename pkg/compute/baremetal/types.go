@@ -41,6 +41,7 @@ const (
 	DISK_DRIVER_MPT2SAS    = api.DISK_DRIVER_MPT2SAS
 	DISK_DRIVER_MARVELRAID = api.DISK_DRIVER_MARVELRAID
 	DISK_DRIVER_PCIE       = api.DISK_DRIVER_PCIE
+	DISK_DRIVER_REDFISH    = api.DISK_DRIVER_REDFISH
 
 	HDD_DISK_SPEC_TYPE = api.HDD_DISK_SPEC_TYPE
 	SSD_DISK_SPEC_TYPE = api.SSD_DISK_SPEC_TYPE
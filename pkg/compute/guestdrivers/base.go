@@ -414,6 +414,18 @@ func (self *SBaseGuestDriver) RequestLiveMigrate(ctx context.Context, guest *mod
 	return fmt.Errorf("Not Implement RequestLiveMigrate")
 }
 
+func (self *SBaseGuestDriver) ValidateChangeDiskStorage(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, disk *models.SDisk, targetStorage *models.SStorage) error {
+	return httperrors.NewNotAcceptableError("Not allow for hypervisor %s", guest.GetHypervisor())
+}
+
+func (self *SBaseGuestDriver) RequestChangeDiskStorage(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, input *api.ServerChangeDiskStorageInternalInput, task taskman.ITask) error {
+	return fmt.Errorf("Not Implement RequestChangeDiskStorage")
+}
+
+func (self *SBaseGuestDriver) RequestCancelChangeDiskStorage(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, diskId string) error {
+	return fmt.Errorf("Not Implement RequestCancelChangeDiskStorage")
+}
+
 func (self *SBaseGuestDriver) RequestRemoteUpdate(ctx context.Context, guest *models.SGuest, userCred mcclient.TokenCredential, replaceTags bool) error {
 	// nil ops
 	return nil
@@ -757,6 +757,56 @@ func (self *SKVMGuestDriver) CheckLiveMigrate(guest *models.SGuest, userCred mcc
 	return nil
 }
 
+func (self *SKVMGuestDriver) ValidateChangeDiskStorage(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, disk *models.SDisk, targetStorage *models.SStorage) error {
+	if !guest.CheckQemuVersion(guest.GetQemuVersion(userCred), "1.3.0") {
+		return httperrors.NewBadRequestError("Cannot change disk storage, too low qemu version")
+	}
+	host := guest.GetHost()
+	if host == nil {
+		return httperrors.NewNotFoundError("Guest %s host not found", guest.Name)
+	}
+	if host.GetHoststorageOfId(targetStorage.Id) == nil {
+		return httperrors.NewBadRequestError("Storage %s not attached to host %s", targetStorage.Name, host.Name)
+	}
+	if targetStorage.Enabled.IsFalse() {
+		return httperrors.NewBadRequestError("Storage %s is disabled", targetStorage.Name)
+	}
+	if targetStorage.Status != api.STORAGE_ONLINE {
+		return httperrors.NewBadRequestError("Storage %s is not online", targetStorage.Name)
+	}
+	if !utils.IsInStringArray(targetStorage.StorageType, []string{api.STORAGE_LOCAL, api.STORAGE_NFS, api.STORAGE_GPFS, api.STORAGE_RBD}) {
+		return httperrors.NewBadRequestError("Not support change disk to storage type %s", targetStorage.StorageType)
+	}
+	if int64(disk.DiskSize) > targetStorage.GetFreeCapacity() {
+		return httperrors.NewOutOfResourceError("Storage %s free capacity %dMB not enough for disk size %dMB",
+			targetStorage.Name, targetStorage.GetFreeCapacity(), disk.DiskSize)
+	}
+	return nil
+}
+
+func (self *SKVMGuestDriver) RequestChangeDiskStorage(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, input *api.ServerChangeDiskStorageInternalInput, task taskman.ITask) error {
+	host := guest.GetHost()
+	body := jsonutils.NewDict()
+	body.Set("disk_id", jsonutils.NewString(input.DiskId))
+	body.Set("target_storage_id", jsonutils.NewString(input.TargetStorageId))
+	body.Set("target_disk_id", jsonutils.NewString(input.TargetDiskId))
+	url := fmt.Sprintf("%s/servers/%s/change-disk-storage", host.ManagerUri, guest.Id)
+	header := self.getTaskRequestHeader(task)
+	_, _, err := httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, body, false)
+	return err
+}
+
+func (self *SKVMGuestDriver) RequestCancelChangeDiskStorage(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, diskId string) error {
+	host := guest.GetHost()
+	// only the mirror job of the disk is canceled, other block jobs of the guest keep running
+	body := jsonutils.NewDict()
+	body.Set("disk_id", jsonutils.NewString(diskId))
+	url := fmt.Sprintf("%s/servers/%s/cancel-change-disk-storage", host.ManagerUri, guest.Id)
+	header := mcclient.GetTokenHeaders(userCred)
+	_, _, err := httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, body, false)
+	return err
+}
+
 func (self *SKVMGuestDriver) ValidateDetachNetwork(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest) error {
 	if guest.Status == api.VM_RUNNING && guest.GetMetadata("hot_remove_nic", nil) != "enable" {
 		return httperrors.NewBadRequestError("Guest %s can't hot remove nic", guest.GetName())
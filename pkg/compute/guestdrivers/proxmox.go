@@ -0,0 +1,180 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestdrivers
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/billing"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+type SProxmoxGuestDriver struct {
+	SManagedVirtualizedGuestDriver
+}
+
+func init() {
+	driver := SProxmoxGuestDriver{}
+	models.RegisterGuestDriver(&driver)
+}
+
+func (self *SProxmoxGuestDriver) DoScheduleCPUFilter() bool { return true }
+
+func (self *SProxmoxGuestDriver) DoScheduleMemoryFilter() bool { return true }
+
+func (self *SProxmoxGuestDriver) DoScheduleSKUFilter() bool { return false }
+
+func (self *SProxmoxGuestDriver) DoScheduleStorageFilter() bool { return true }
+
+func (self *SProxmoxGuestDriver) GetHypervisor() string {
+	return api.HYPERVISOR_PROXMOX
+}
+
+func (self *SProxmoxGuestDriver) GetProvider() string {
+	return api.CLOUD_PROVIDER_PROXMOX
+}
+
+func (self *SProxmoxGuestDriver) GetComputeQuotaKeys(scope rbacutils.TRbacScope, ownerId mcclient.IIdentityProvider, brand string) models.SComputeResourceKeys {
+	keys := models.SComputeResourceKeys{}
+	keys.SBaseProjectQuotaKeys = quotas.OwnerIdProjectQuotaKeys(scope, ownerId)
+	keys.CloudEnv = api.CLOUD_ENV_PRIVATE_CLOUD
+	keys.Provider = api.CLOUD_PROVIDER_PROXMOX
+	keys.Brand = brand
+	keys.Hypervisor = api.HYPERVISOR_PROXMOX
+	return keys
+}
+
+func (self *SProxmoxGuestDriver) GetDefaultSysDiskBackend() string {
+	return api.STORAGE_PROXMOX_LVMTHIN
+}
+
+func (self *SProxmoxGuestDriver) GetMinimalSysDiskSizeGb() int {
+	return 1
+}
+
+func (self *SProxmoxGuestDriver) GetStorageTypes() []string {
+	return []string{
+		api.STORAGE_PROXMOX_DIR,
+		api.STORAGE_PROXMOX_LVM,
+		api.STORAGE_PROXMOX_LVMTHIN,
+		api.STORAGE_PROXMOX_ZFSPOOL,
+		api.STORAGE_NFS,
+		api.STORAGE_CIFS,
+		api.STORAGE_RBD,
+	}
+}
+
+func (self *SProxmoxGuestDriver) GetMaxSecurityGroupCount() int {
+	return 0
+}
+
+func (self *SProxmoxGuestDriver) ChooseHostStorage(host *models.SHost, guest *models.SGuest, diskConfig *api.DiskConfig, storageIds []string) (*models.SStorage, error) {
+	return self.chooseHostStorage(self, host, diskConfig.Backend, storageIds), nil
+}
+
+func (self *SProxmoxGuestDriver) GetDetachDiskStatus() ([]string, error) {
+	return []string{api.VM_READY}, nil
+}
+
+func (self *SProxmoxGuestDriver) GetAttachDiskStatus() ([]string, error) {
+	return []string{api.VM_READY}, nil
+}
+
+func (self *SProxmoxGuestDriver) GetRebuildRootStatus() ([]string, error) {
+	return []string{}, nil
+}
+
+func (self *SProxmoxGuestDriver) GetChangeConfigStatus(guest *models.SGuest) ([]string, error) {
+	return []string{api.VM_READY}, nil
+}
+
+func (self *SProxmoxGuestDriver) GetDeployStatus() ([]string, error) {
+	return []string{}, nil
+}
+
+func (self *SProxmoxGuestDriver) IsNeedRestartForResetLoginInfo() bool {
+	return false
+}
+
+func (self *SProxmoxGuestDriver) ValidateResizeDisk(guest *models.SGuest, disk *models.SDisk, storage *models.SStorage) error {
+	if !utils.IsInStringArray(guest.Status, []string{api.VM_READY, api.VM_RUNNING}) {
+		return fmt.Errorf("Cannot resize disk when guest in status %s", guest.Status)
+	}
+	return nil
+}
+
+func (self *SProxmoxGuestDriver) ValidateCreateEip(ctx context.Context, userCred mcclient.TokenCredential, data jsonutils.JSONObject) error {
+	return httperrors.NewInputParameterError("%s not support create eip", self.GetHypervisor())
+}
+
+func (self *SProxmoxGuestDriver) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, input *api.ServerCreateInput) (*api.ServerCreateInput, error) {
+	input, err := self.SManagedVirtualizedGuestDriver.ValidateCreateData(ctx, userCred, input)
+	if err != nil {
+		return nil, err
+	}
+	if len(input.Eip) > 0 || input.EipBw > 0 {
+		return nil, httperrors.NewUnsupportOperationError("%s not support create virtual machine with eip", self.GetHypervisor())
+	}
+	return input, nil
+}
+
+func (self *SProxmoxGuestDriver) GetGuestInitialStateAfterCreate() string {
+	return api.VM_RUNNING
+}
+
+func (self *SProxmoxGuestDriver) GetGuestInitialStateAfterRebuild() string {
+	return api.VM_READY
+}
+
+func (self *SProxmoxGuestDriver) IsNeedInjectPasswordByCloudInit(desc *cloudprovider.SManagedVMCreateConfig) bool {
+	return true
+}
+
+func (self *SProxmoxGuestDriver) GetUserDataType() string {
+	return cloudprovider.CLOUD_CONFIG
+}
+
+func (self *SProxmoxGuestDriver) GetInstanceCapability() cloudprovider.SInstanceCapability {
+	return cloudprovider.SInstanceCapability{
+		Hypervisor: self.GetHypervisor(),
+		Provider:   self.GetProvider(),
+		DefaultAccount: cloudprovider.SDefaultAccount{
+			Linux: cloudprovider.SOsDefaultAccount{
+				DefaultAccount: api.VM_DEFAULT_LINUX_LOGIN_USER,
+			},
+			Windows: cloudprovider.SOsDefaultAccount{
+				DefaultAccount: api.VM_DEFAULT_WINDOWS_LOGIN_USER,
+			},
+		},
+	}
+}
+
+func (self *SProxmoxGuestDriver) AllowReconfigGuest() bool {
+	return true
+}
+
+func (self *SProxmoxGuestDriver) IsSupportedBillingCycle(bc billing.SBillingCycle) bool {
+	return false
+}
@@ -0,0 +1,377 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"net/http"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/fileutils"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/appctx"
+	"yunion.io/x/onecloud/pkg/appsrv/dispatcher"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+// ApprovalDescribeFunc computes the resources a request asks for and the quota
+// to reserve while it is pending, obj is nil for create requests
+type ApprovalDescribeFunc func(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	obj db.IModel,
+	data jsonutils.JSONObject,
+	count int,
+) (api.ApprovalSize, *SQuota, error)
+
+var approvalHandlers = map[string]map[string]ApprovalDescribeFunc{}
+
+// RegisterApprovalHandler make an action of a resource type subject to approval policies
+func RegisterApprovalHandler(resourceType string, action string, describe ApprovalDescribeFunc) {
+	if _, ok := approvalHandlers[resourceType]; !ok {
+		approvalHandlers[resourceType] = map[string]ApprovalDescribeFunc{}
+	}
+	approvalHandlers[resourceType][action] = describe
+}
+
+func init() {
+	RegisterApprovalHandler(api.APPROVAL_RESOURCE_SERVER, api.APPROVAL_ACTION_CREATE, describeServerCreate)
+	RegisterApprovalHandler(api.APPROVAL_RESOURCE_SERVER, api.APPROVAL_ACTION_CHANGE_CONFIG, describeServerChangeConfig)
+	RegisterApprovalHandler(api.APPROVAL_RESOURCE_SERVER, api.APPROVAL_ACTION_DELETE, describeServerDelete)
+	RegisterApprovalHandler(api.APPROVAL_RESOURCE_DISK, api.APPROVAL_ACTION_CREATE, describeDiskCreate)
+	RegisterApprovalHandler(api.APPROVAL_RESOURCE_DISK, api.APPROVAL_ACTION_DELETE, describeDiskDelete)
+}
+
+func approvalQuotaKeys(ownerId mcclient.IIdentityProvider, hypervisor string) SComputeResourceKeys {
+	if !utils.IsInStringArray(hypervisor, api.ONECLOUD_HYPERVISORS) {
+		hypervisor = ""
+	}
+	return fetchComputeQuotaKeys(rbacutils.ScopeProject, ownerId, nil, nil, hypervisor)
+}
+
+func describeServerCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, obj db.IModel, data jsonutils.JSONObject, count int) (api.ApprovalSize, *SQuota, error) {
+	size := api.ApprovalSize{Count: count}
+	input := api.ServerCreateInput{}
+	err := data.Unmarshal(&input)
+	if err != nil {
+		return size, nil, httperrors.NewInputParameterError("unmarshal input: %s", err)
+	}
+	size.Hypervisor = input.Hypervisor
+	if len(size.Hypervisor) == 0 {
+		size.Hypervisor = api.HYPERVISOR_KVM
+	}
+	vcpuCount, vmemSize := input.VcpuCount, input.VmemSize
+	if len(input.InstanceType) > 0 && (vcpuCount == 0 || vmemSize == 0) {
+		sku, err := ServerSkuManager.FetchSkuByNameAndProvider(input.InstanceType, GetDriver(size.Hypervisor).GetProvider(), false)
+		if err != nil {
+			return size, nil, httperrors.NewResourceNotFoundError2("instance_type", input.InstanceType)
+		}
+		vcpuCount, vmemSize = sku.CpuCoreCount, sku.MemorySizeMB
+	}
+	if vcpuCount == 0 {
+		vcpuCount = 1
+	}
+	diskSize := 0
+	for _, disk := range input.Disks {
+		if disk != nil {
+			diskSize += disk.SizeMb
+		}
+	}
+	size.VcpuCount = vcpuCount * count
+	size.VmemSizeMb = vmemSize * count
+	size.DiskSizeMb = diskSize * count
+	quota := &SQuota{
+		Count:   count,
+		Cpu:     size.VcpuCount,
+		Memory:  size.VmemSizeMb,
+		Storage: size.DiskSizeMb,
+	}
+	quota.SetKeys(approvalQuotaKeys(ownerId, size.Hypervisor))
+	return size, quota, nil
+}
+
+func describeServerChangeConfig(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, obj db.IModel, data jsonutils.JSONObject, count int) (api.ApprovalSize, *SQuota, error) {
+	guest := obj.(*SGuest)
+	size := api.ApprovalSize{
+		Count:      1,
+		VcpuCount:  int(guest.VcpuCount),
+		VmemSizeMb: guest.VmemSize,
+		DiskSizeMb: guest.getDiskSize(),
+		Hypervisor: guest.Hypervisor,
+	}
+	skuId := jsonutils.GetAnyString(data, []string{"instance_type", "sku", "flavor"})
+	if len(skuId) > 0 {
+		sku, err := ServerSkuManager.FetchSkuByNameAndProvider(skuId, guest.GetDriver().GetProvider(), false)
+		if err != nil {
+			return size, nil, httperrors.NewResourceNotFoundError2("instance_type", skuId)
+		}
+		size.VcpuCount, size.VmemSizeMb = sku.CpuCoreCount, sku.MemorySizeMB
+	} else {
+		if vcpuCount, err := data.Int("vcpu_count"); err == nil && vcpuCount > 0 {
+			size.VcpuCount = int(vcpuCount)
+		}
+		if vmemSize, err := data.GetString("vmem_size"); err == nil && len(vmemSize) > 0 {
+			vmemSizeMb, err := fileutils.GetSizeMb(vmemSize, 'M', 1024)
+			if err != nil {
+				return size, nil, httperrors.NewInputParameterError("invalid vmem_size %s", vmemSize)
+			}
+			size.VmemSizeMb = vmemSizeMb
+		}
+	}
+	inputDisks := []api.DiskConfig{}
+	if disksConf, err := data.Get("disks"); err == nil {
+		disksConf.Unmarshal(&inputDisks)
+	}
+	disks := guest.GetDisks()
+	addDisk := 0
+	// the first disk in input is the first data disk
+	for i, diskIdx := 0, 1; i < len(inputDisks); i, diskIdx = i+1, diskIdx+1 {
+		if diskIdx >= len(disks) {
+			addDisk += inputDisks[i].SizeMb
+		} else if oldSize := disks[diskIdx].GetDisk().DiskSize; inputDisks[i].SizeMb > oldSize {
+			addDisk += inputDisks[i].SizeMb - oldSize
+		}
+	}
+	size.DiskSizeMb += addDisk
+
+	quota := &SQuota{Storage: addDisk}
+	if size.VcpuCount > int(guest.VcpuCount) {
+		quota.Cpu = size.VcpuCount - int(guest.VcpuCount)
+	}
+	if size.VmemSizeMb > guest.VmemSize {
+		quota.Memory = size.VmemSizeMb - guest.VmemSize
+	}
+	keys, err := guest.GetQuotaKeys()
+	if err != nil {
+		return size, nil, errors.Wrap(err, "GetQuotaKeys")
+	}
+	quota.SetKeys(keys)
+	return size, quota, nil
+}
+
+func describeServerDelete(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, obj db.IModel, data jsonutils.JSONObject, count int) (api.ApprovalSize, *SQuota, error) {
+	guest := obj.(*SGuest)
+	return api.ApprovalSize{
+		Count:      1,
+		VcpuCount:  int(guest.VcpuCount),
+		VmemSizeMb: guest.VmemSize,
+		DiskSizeMb: guest.getDiskSize(),
+		Hypervisor: guest.Hypervisor,
+	}, nil, nil
+}
+
+func describeDiskCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, obj db.IModel, data jsonutils.JSONObject, count int) (api.ApprovalSize, *SQuota, error) {
+	size := api.ApprovalSize{Count: count}
+	input := api.DiskCreateInput{}
+	err := data.Unmarshal(&input)
+	if err != nil {
+		return size, nil, httperrors.NewInputParameterError("unmarshal input: %s", err)
+	}
+	size.Hypervisor = input.Hypervisor
+	if input.DiskConfig != nil {
+		size.DiskSizeMb = input.SizeMb * count
+	}
+	quota := &SQuota{Storage: size.DiskSizeMb}
+	quota.SetKeys(approvalQuotaKeys(ownerId, size.Hypervisor))
+	return size, quota, nil
+}
+
+func describeDiskDelete(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, obj db.IModel, data jsonutils.JSONObject, count int) (api.ApprovalSize, *SQuota, error) {
+	disk := obj.(*SDisk)
+	return api.ApprovalSize{Count: 1, DiskSizeMb: disk.DiskSize}, nil, nil
+}
+
+// SApprovalDispatcher holds back requests matching approval policies, the
+// request is persisted as an approval request instead of being executed
+type SApprovalDispatcher struct {
+	*db.DBModelDispatcher
+
+	manager db.IModelManager
+}
+
+func NewApprovalModelHandler(manager db.IModelManager) *SApprovalDispatcher {
+	return &SApprovalDispatcher{
+		DBModelDispatcher: db.NewModelHandler(manager),
+		manager:           manager,
+	}
+}
+
+func (dispatcher *SApprovalDispatcher) submit(
+	ctx context.Context,
+	action string,
+	idStr string,
+	query jsonutils.JSONObject,
+	data jsonutils.JSONObject,
+	count int,
+	ctxIds []dispatcher.SResourceContext,
+) (*SApprovalRequest, error) {
+	resourceType := dispatcher.manager.Keyword()
+	describe, ok := approvalHandlers[resourceType][action]
+	if !ok {
+		return nil, nil
+	}
+	userCred := policy.FetchUserCredential(ctx)
+	if userCred == nil || userCred.HasSystemAdminPrivilege() {
+		return nil, nil
+	}
+	if data == nil {
+		data = jsonutils.NewDict()
+	}
+
+	var obj db.IModel
+	var ownerId mcclient.IIdentityProvider
+	var err error
+	if len(idStr) > 0 {
+		obj, err = db.FetchByIdOrName(dispatcher.manager, userCred, idStr)
+		if err != nil {
+			// leave the error to the model dispatcher
+			return nil, nil
+		}
+		if action == api.APPROVAL_ACTION_DELETE {
+			err = db.IsObjectRbacAllowed(obj, userCred, policy.PolicyActionDelete)
+		} else {
+			err = db.IsObjectRbacAllowed(obj, userCred, policy.PolicyActionPerform, action)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ownerId = obj.GetOwnerId()
+	} else {
+		if !dispatcher.manager.AllowCreateItem(ctx, userCred, query, data) {
+			return nil, httperrors.NewForbiddenError("Not allow to create item")
+		}
+		ownerId, err = dispatcher.manager.FetchOwnerId(ctx, data)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+		if ownerId == nil {
+			ownerId = userCred
+		}
+	}
+
+	size, quota, err := describe(ctx, userCred, ownerId, obj, data, count)
+	if err != nil {
+		return nil, err
+	}
+	approvalPolicy, err := ApprovalPolicyManager.FetchMatchedPolicy(ctx, resourceType, action, ownerId, size)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	if approvalPolicy == nil {
+		return nil, nil
+	}
+	params := sApprovalParams{
+		Query:  query,
+		Data:   data,
+		Count:  count,
+		CtxIds: ctxIds,
+	}
+	return ApprovalRequestManager.submit(ctx, userCred, ownerId, approvalPolicy, action, obj, params, size, quota)
+}
+
+func (dispatcher *SApprovalDispatcher) Create(ctx context.Context, query jsonutils.JSONObject, data jsonutils.JSONObject, ctxIds []dispatcher.SResourceContext) (jsonutils.JSONObject, error) {
+	req, err := dispatcher.submit(ctx, api.APPROVAL_ACTION_CREATE, "", query, data, 1, ctxIds)
+	if err != nil {
+		return nil, err
+	}
+	if req != nil {
+		return jsonutils.Marshal(req), nil
+	}
+	return dispatcher.DBModelDispatcher.Create(ctx, query, data, ctxIds)
+}
+
+func (dispatcher *SApprovalDispatcher) BatchCreate(ctx context.Context, query jsonutils.JSONObject, data jsonutils.JSONObject, count int, ctxIds []dispatcher.SResourceContext) ([]modulebase.SubmitResult, error) {
+	req, err := dispatcher.submit(ctx, api.APPROVAL_ACTION_CREATE, "", query, data, count, ctxIds)
+	if err != nil {
+		return nil, err
+	}
+	if req != nil {
+		return []modulebase.SubmitResult{
+			{
+				Status: http.StatusAccepted,
+				Id:     req.Id,
+				Data:   jsonutils.Marshal(req),
+			},
+		}, nil
+	}
+	return dispatcher.DBModelDispatcher.BatchCreate(ctx, query, data, count, ctxIds)
+}
+
+func (dispatcher *SApprovalDispatcher) PerformAction(ctx context.Context, idStr string, action string, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if action != api.APPROVAL_ACTION_CREATE && action != api.APPROVAL_ACTION_DELETE {
+		req, err := dispatcher.submit(ctx, action, idStr, query, data, 1, nil)
+		if err != nil {
+			return nil, err
+		}
+		if req != nil {
+			return jsonutils.Marshal(req), nil
+		}
+	}
+	return dispatcher.DBModelDispatcher.PerformAction(ctx, idStr, action, query, data)
+}
+
+func (dispatcher *SApprovalDispatcher) Delete(ctx context.Context, idstr string, query jsonutils.JSONObject, data jsonutils.JSONObject, ctxIds []dispatcher.SResourceContext) (jsonutils.JSONObject, error) {
+	req, err := dispatcher.submit(ctx, api.APPROVAL_ACTION_DELETE, idstr, query, data, 1, ctxIds)
+	if err != nil {
+		return nil, err
+	}
+	if req != nil {
+		return jsonutils.Marshal(req), nil
+	}
+	return dispatcher.DBModelDispatcher.Delete(ctx, idstr, query, data, ctxIds)
+}
+
+type sApprovalParams struct {
+	Query  jsonutils.JSONObject
+	Data   jsonutils.JSONObject
+	Count  int
+	CtxIds []dispatcher.SResourceContext
+}
+
+// replay the original request with the requester's credential
+func (params *sApprovalParams) execute(ctx context.Context, requester mcclient.TokenCredential, manager db.IModelManager, action string, resourceId string) (jsonutils.JSONObject, error) {
+	ctx = context.WithValue(ctx, appctx.APP_CONTEXT_KEY_AUTH_TOKEN, requester)
+	handler := db.NewModelHandler(manager)
+	switch action {
+	case api.APPROVAL_ACTION_CREATE:
+		if params.Count > 1 {
+			results, err := handler.BatchCreate(ctx, params.Query, params.Data, params.Count, params.CtxIds)
+			if err != nil {
+				return nil, err
+			}
+			for i := range results {
+				if results[i].Status >= 300 {
+					log.Errorf("batch create %s #%d fail: %s", manager.Keyword(), i, results[i].Data)
+				}
+			}
+			return modulebase.SubmitResults2JSON(results), nil
+		}
+		return handler.Create(ctx, params.Query, params.Data, params.CtxIds)
+	case api.APPROVAL_ACTION_DELETE:
+		return handler.Delete(ctx, resourceId, params.Query, params.Data, params.CtxIds)
+	default:
+		return handler.PerformAction(ctx, resourceId, action, params.Query, params.Data)
+	}
+}
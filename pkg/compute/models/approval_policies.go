@@ -0,0 +1,375 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// ApprovalCostEstimator estimates the monthly cost of a request, policies with
+// min_monthly_cost are only effective when a cost model is plugged in
+var ApprovalCostEstimator func(ctx context.Context, resourceType string, size api.ApprovalSize) (float64, error)
+
+// SApprovalPolicyManager manage rules deciding which resource requests need approval
+type SApprovalPolicyManager struct {
+	db.SEnabledStatusInfrasResourceBaseManager
+}
+
+var ApprovalPolicyManager *SApprovalPolicyManager
+
+func init() {
+	ApprovalPolicyManager = &SApprovalPolicyManager{
+		SEnabledStatusInfrasResourceBaseManager: db.NewEnabledStatusInfrasResourceBaseManager(
+			SApprovalPolicy{},
+			"approval_policies_tbl",
+			"approval_policy",
+			"approval_policies",
+		),
+	}
+	ApprovalPolicyManager.SetVirtualObject(ApprovalPolicyManager)
+}
+
+type SApprovalPolicy struct {
+	db.SEnabledStatusInfrasResourceBase
+
+	// 适用的资源类型
+	ResourceType string `width:"32" charset:"ascii" nullable:"false" list:"domain" create:"domain_required"`
+
+	// 需要审批的操作, 为空表示全部操作
+	Actions jsonutils.JSONObject `nullable:"true" list:"domain" update:"domain" create:"domain_optional"`
+	// 适用的项目ID, 为空表示全部项目
+	Projects jsonutils.JSONObject `nullable:"true" list:"domain" update:"domain" create:"domain_optional"`
+
+	MinVcpuCount   int     `nullable:"false" default:"0" list:"domain" update:"domain" create:"domain_optional"`
+	MinVmemSizeMb  int     `nullable:"false" default:"0" list:"domain" update:"domain" create:"domain_optional"`
+	MinDiskSizeMb  int     `nullable:"false" default:"0" list:"domain" update:"domain" create:"domain_optional"`
+	MinCount       int     `nullable:"false" default:"0" list:"domain" update:"domain" create:"domain_optional"`
+	MinMonthlyCost float64 `nullable:"false" default:"0" list:"domain" update:"domain" create:"domain_optional"`
+
+	// 审批人用户ID
+	Approvers jsonutils.JSONObject `nullable:"false" list:"domain" update:"domain" create:"domain_required"`
+
+	// 申请超时时间(小时)
+	ExpireHours int `nullable:"false" default:"72" list:"domain" update:"domain" create:"domain_optional"`
+}
+
+func (manager *SApprovalPolicyManager) AllowCreateItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsDomainAllowCreate(userCred, manager)
+}
+
+func validateApprovalActions(resourceType string, actions []string) error {
+	for _, action := range actions {
+		if _, ok := approvalHandlers[resourceType][action]; !ok {
+			return httperrors.NewInputParameterError("action %s of %s does not support approval", action, resourceType)
+		}
+	}
+	return nil
+}
+
+func validateApprovalProjects(ctx context.Context, domainId string, projects []string) ([]string, error) {
+	ret := make([]string, 0, len(projects))
+	for _, idOrName := range projects {
+		tenant, err := db.TenantCacheManager.FetchTenantByIdOrName(ctx, idOrName)
+		if err != nil {
+			return nil, httperrors.NewResourceNotFoundError2("project", idOrName)
+		}
+		if len(domainId) > 0 && tenant.DomainId != domainId {
+			return nil, httperrors.NewInputParameterError("project %s does not belong to domain %s", tenant.Name, domainId)
+		}
+		ret = append(ret, tenant.Id)
+	}
+	return ret, nil
+}
+
+func validateApprovers(ctx context.Context, approvers []string) ([]string, error) {
+	if len(approvers) == 0 {
+		return nil, httperrors.NewMissingParameterError("approvers")
+	}
+	ret := make([]string, 0, len(approvers))
+	for _, idOrName := range approvers {
+		user, err := db.UserCacheManager.FetchUserByIdOrName(ctx, idOrName)
+		if err != nil {
+			return nil, httperrors.NewResourceNotFoundError2("user", idOrName)
+		}
+		ret = append(ret, user.Id)
+	}
+	return ret, nil
+}
+
+func (manager *SApprovalPolicyManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.ApprovalPolicyCreateInput,
+) (api.ApprovalPolicyCreateInput, error) {
+	if _, ok := approvalHandlers[input.ResourceType]; !ok {
+		return input, httperrors.NewInputParameterError("unsupported resource_type %q", input.ResourceType)
+	}
+	err := validateApprovalActions(input.ResourceType, input.Actions)
+	if err != nil {
+		return input, err
+	}
+	input.Projects, err = validateApprovalProjects(ctx, ownerId.GetProjectDomainId(), input.Projects)
+	if err != nil {
+		return input, err
+	}
+	input.Approvers, err = validateApprovers(ctx, input.Approvers)
+	if err != nil {
+		return input, err
+	}
+	if input.MinVcpuCount < 0 || input.MinVmemSizeMb < 0 || input.MinDiskSizeMb < 0 || input.MinCount < 0 || input.MinMonthlyCost < 0 {
+		return input, httperrors.NewInputParameterError("thresholds must not be negative")
+	}
+	if input.ExpireHours < 0 {
+		return input, httperrors.NewInputParameterError("expire_hours must not be negative")
+	}
+	if input.ExpireHours == 0 {
+		input.ExpireHours = api.APPROVAL_DEFAULT_EXPIRE_HOURS
+	}
+	input.Status = api.APPROVAL_POLICY_STATUS_AVAILABLE
+	input.EnabledStatusInfrasResourceBaseCreateInput, err = manager.SEnabledStatusInfrasResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusInfrasResourceBaseCreateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.ValidateCreateData")
+	}
+	return input, nil
+}
+
+func (self *SApprovalPolicy) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.ApprovalPolicyUpdateInput,
+) (api.ApprovalPolicyUpdateInput, error) {
+	var err error
+	if input.Actions != nil {
+		err = validateApprovalActions(self.ResourceType, input.Actions)
+		if err != nil {
+			return input, err
+		}
+	}
+	if input.Projects != nil {
+		input.Projects, err = validateApprovalProjects(ctx, self.DomainId, input.Projects)
+		if err != nil {
+			return input, err
+		}
+	}
+	if input.Approvers != nil {
+		input.Approvers, err = validateApprovers(ctx, input.Approvers)
+		if err != nil {
+			return input, err
+		}
+	}
+	for _, v := range []*int{input.MinVcpuCount, input.MinVmemSizeMb, input.MinDiskSizeMb, input.MinCount, input.ExpireHours} {
+		if v != nil && *v < 0 {
+			return input, httperrors.NewInputParameterError("thresholds must not be negative")
+		}
+	}
+	if input.MinMonthlyCost != nil && *input.MinMonthlyCost < 0 {
+		return input, httperrors.NewInputParameterError("min_monthly_cost must not be negative")
+	}
+	input.EnabledStatusInfrasResourceBaseUpdateInput, err = self.SEnabledStatusInfrasResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusInfrasResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SEnabledStatusInfrasResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
+func jsonStringArray(obj jsonutils.JSONObject) []string {
+	ret := []string{}
+	if obj != nil {
+		obj.Unmarshal(&ret)
+	}
+	return ret
+}
+
+func (self *SApprovalPolicy) GetActions() []string {
+	return jsonStringArray(self.Actions)
+}
+
+func (self *SApprovalPolicy) GetProjectIds() []string {
+	return jsonStringArray(self.Projects)
+}
+
+func (self *SApprovalPolicy) GetApproverIds() []string {
+	return jsonStringArray(self.Approvers)
+}
+
+func (self *SApprovalPolicy) GetExpireHours() int {
+	if self.ExpireHours <= 0 {
+		return api.APPROVAL_DEFAULT_EXPIRE_HOURS
+	}
+	return self.ExpireHours
+}
+
+// IsMatch check whether a request has to be approved according to this policy,
+// a request is matched once it reaches any of the configured thresholds,
+// cost < 0 means the cost is unknown and the cost threshold is ignored
+func (self *SApprovalPolicy) IsMatch(action string, projectId string, size api.ApprovalSize, cost float64) bool {
+	if actions := self.GetActions(); len(actions) > 0 && !utils.IsInStringArray(action, actions) {
+		return false
+	}
+	if projects := self.GetProjectIds(); len(projects) > 0 && !utils.IsInStringArray(projectId, projects) {
+		return false
+	}
+	hasThreshold := false
+	for _, threshold := range []struct {
+		min int
+		val int
+	}{
+		{self.MinCount, size.Count},
+		{self.MinVcpuCount, size.VcpuCount},
+		{self.MinVmemSizeMb, size.VmemSizeMb},
+		{self.MinDiskSizeMb, size.DiskSizeMb},
+	} {
+		if threshold.min > 0 {
+			if threshold.val >= threshold.min {
+				return true
+			}
+			hasThreshold = true
+		}
+	}
+	if self.MinMonthlyCost > 0 && cost >= 0 {
+		if cost >= self.MinMonthlyCost {
+			return true
+		}
+		hasThreshold = true
+	}
+	return !hasThreshold
+}
+
+// FetchMatchedPolicy return the earliest enabled policy visible to the owner that matches the request
+func (manager *SApprovalPolicyManager) FetchMatchedPolicy(ctx context.Context, resourceType string, action string, ownerId mcclient.IIdentityProvider, size api.ApprovalSize) (*SApprovalPolicy, error) {
+	q := manager.Query().Equals("resource_type", resourceType).IsTrue("enabled")
+	q = q.Filter(sqlchemy.OR(
+		sqlchemy.Equals(q.Field("domain_id"), ownerId.GetProjectDomainId()),
+		sqlchemy.IsTrue(q.Field("is_public")),
+	))
+	q = q.Asc("created_at")
+	policies := []SApprovalPolicy{}
+	err := db.FetchModelObjects(manager, q, &policies)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	cost := -1.0
+	if ApprovalCostEstimator != nil {
+		cost, err = ApprovalCostEstimator(ctx, resourceType, size)
+		if err != nil {
+			log.Warningf("estimate cost of %s %s fail: %s", action, resourceType, err)
+			cost = -1
+		}
+	}
+	for i := range policies {
+		if policies[i].IsMatch(action, ownerId.GetProjectId(), size, cost) {
+			return &policies[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (self *SApprovalPolicy) GetPendingRequestCount() (int, error) {
+	return ApprovalRequestManager.Query().Equals("policy_id", self.Id).Equals("status", api.APPROVAL_REQUEST_STATUS_PENDING).CountWithError()
+}
+
+func (self *SApprovalPolicy) ValidateDeleteCondition(ctx context.Context) error {
+	cnt, err := self.GetPendingRequestCount()
+	if err != nil {
+		return httperrors.NewInternalServerError("GetPendingRequestCount fail %s", err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("approval policy has %d pending requests", cnt)
+	}
+	return self.SEnabledStatusInfrasResourceBase.ValidateDeleteCondition(ctx)
+}
+
+func (self *SApprovalPolicy) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, isList bool) (api.ApprovalPolicyDetails, error) {
+	return api.ApprovalPolicyDetails{}, nil
+}
+
+func (manager *SApprovalPolicyManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ApprovalPolicyDetails {
+	rows := make([]api.ApprovalPolicyDetails, len(objs))
+	stdRows := manager.SEnabledStatusInfrasResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.ApprovalPolicyDetails{
+			EnabledStatusInfrasResourceBaseDetails: stdRows[i],
+		}
+		policy := objs[i].(*SApprovalPolicy)
+		rows[i].PendingCount, _ = policy.GetPendingRequestCount()
+	}
+	return rows
+}
+
+// 审批策略列表
+func (manager *SApprovalPolicyManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ApprovalPolicyListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusInfrasResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledStatusInfrasResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.ListItemFilter")
+	}
+	if len(query.ResourceType) > 0 {
+		q = q.In("resource_type", query.ResourceType)
+	}
+	return q, nil
+}
+
+func (manager *SApprovalPolicyManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ApprovalPolicyListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusInfrasResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.EnabledStatusInfrasResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SApprovalPolicyManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SEnabledStatusInfrasResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+
+	return q, httperrors.ErrNotFound
+}
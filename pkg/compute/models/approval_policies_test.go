@@ -0,0 +1,67 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestApprovalPolicyIsMatch(t *testing.T) {
+	policy := SApprovalPolicy{
+		Actions:      jsonutils.Marshal([]string{api.APPROVAL_ACTION_CREATE}),
+		Projects:     jsonutils.Marshal([]string{"p1"}),
+		MinVcpuCount: 16,
+		MinCount:     5,
+	}
+	cases := []struct {
+		name    string
+		action  string
+		project string
+		size    api.ApprovalSize
+		cost    float64
+		want    bool
+	}{
+		{"small", api.APPROVAL_ACTION_CREATE, "p1", api.ApprovalSize{Count: 1, VcpuCount: 4}, -1, false},
+		{"vcpu", api.APPROVAL_ACTION_CREATE, "p1", api.ApprovalSize{Count: 1, VcpuCount: 16}, -1, true},
+		{"count", api.APPROVAL_ACTION_CREATE, "p1", api.ApprovalSize{Count: 5, VcpuCount: 5}, -1, true},
+		{"other action", api.APPROVAL_ACTION_DELETE, "p1", api.ApprovalSize{Count: 1, VcpuCount: 32}, -1, false},
+		{"other project", api.APPROVAL_ACTION_CREATE, "p2", api.ApprovalSize{Count: 1, VcpuCount: 32}, -1, false},
+	}
+	for _, c := range cases {
+		if got := policy.IsMatch(c.action, c.project, c.size, c.cost); got != c.want {
+			t.Errorf("%s: want %v got %v", c.name, c.want, got)
+		}
+	}
+
+	all := SApprovalPolicy{}
+	if !all.IsMatch(api.APPROVAL_ACTION_DELETE, "p2", api.ApprovalSize{Count: 1}, -1) {
+		t.Errorf("policy without threshold should match every request")
+	}
+
+	cost := SApprovalPolicy{MinMonthlyCost: 100}
+	if !cost.IsMatch(api.APPROVAL_ACTION_CREATE, "p1", api.ApprovalSize{Count: 1}, -1) {
+		t.Errorf("cost threshold should be ignored when cost is unknown")
+	}
+	if cost.IsMatch(api.APPROVAL_ACTION_CREATE, "p1", api.ApprovalSize{Count: 1}, 50) {
+		t.Errorf("cheap request should not match")
+	}
+	if !cost.IsMatch(api.APPROVAL_ACTION_CREATE, "p1", api.ApprovalSize{Count: 1}, 150) {
+		t.Errorf("expensive request should match")
+	}
+}
@@ -0,0 +1,540 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/mcclient/modules/notify"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// SApprovalRequestManager manage resource requests held back by approval policies
+type SApprovalRequestManager struct {
+	db.SVirtualResourceBaseManager
+}
+
+var ApprovalRequestManager *SApprovalRequestManager
+
+func init() {
+	ApprovalRequestManager = &SApprovalRequestManager{
+		SVirtualResourceBaseManager: db.NewVirtualResourceBaseManager(
+			SApprovalRequest{},
+			"approval_requests_tbl",
+			"approval_request",
+			"approval_requests",
+		),
+	}
+	ApprovalRequestManager.SetVirtualObject(ApprovalRequestManager)
+}
+
+type SApprovalRequest struct {
+	db.SVirtualResourceBase
+
+	// 匹配的审批策略
+	PolicyId string `width:"36" charset:"ascii" nullable:"false" list:"user"`
+
+	// 资源类型
+	ResourceType string `width:"32" charset:"ascii" nullable:"false" list:"user"`
+	// 申请的操作
+	Action string `width:"32" charset:"ascii" nullable:"false" list:"user"`
+	// 操作的资源ID, 创建申请执行后为新建资源的ID
+	ResourceId string `width:"128" charset:"ascii" nullable:"true" list:"user"`
+
+	// 申请的资源规格
+	Size jsonutils.JSONObject `nullable:"true" list:"user"`
+
+	// 原始请求, 可能包含密码等敏感信息, 不对外展示
+	Params jsonutils.JSONObject `nullable:"true"`
+	// 申请人身份, 审批通过后以此身份执行
+	RequesterCred mcclient.TokenCredential `width:"1024" charset:"utf8" nullable:"true"`
+	// 审批期间预留的配额
+	PendingUsage jsonutils.JSONObject `nullable:"true"`
+
+	RequesterId string `width:"128" charset:"ascii" nullable:"false" list:"user"`
+	Requester   string `width:"128" charset:"utf8" nullable:"false" list:"user"`
+
+	// 审批人
+	ApproverId string `width:"128" charset:"ascii" nullable:"true" list:"user"`
+	Approver   string `width:"128" charset:"utf8" nullable:"true" list:"user"`
+	// 审批意见或驳回原因
+	Comment string `charset:"utf8" nullable:"true" list:"user"`
+
+	// 超时时间
+	ExpiredAt time.Time `nullable:"true" list:"user"`
+
+	// 执行结果
+	Result jsonutils.JSONObject `nullable:"true" list:"user"`
+}
+
+func (manager *SApprovalRequestManager) AllowCreateItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	// approval requests are submitted by intercepting resource requests
+	return false
+}
+
+func (manager *SApprovalRequestManager) submit(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	policy *SApprovalPolicy,
+	action string,
+	obj db.IModel,
+	params sApprovalParams,
+	size api.ApprovalSize,
+	quota *SQuota,
+) (*SApprovalRequest, error) {
+	req := &SApprovalRequest{
+		PolicyId:      policy.Id,
+		ResourceType:  policy.ResourceType,
+		Action:        action,
+		Size:          jsonutils.Marshal(size),
+		Params:        jsonutils.Marshal(params),
+		RequesterCred: mcclient.SimplifyToken(userCred),
+		RequesterId:   userCred.GetUserId(),
+		Requester:     userCred.GetUserName(),
+		ExpiredAt:     time.Now().Add(time.Duration(policy.GetExpireHours()) * time.Hour),
+	}
+	req.SetModelManager(manager, req)
+	req.Status = api.APPROVAL_REQUEST_STATUS_PENDING
+	req.ProjectId = ownerId.GetProjectId()
+	req.DomainId = ownerId.GetProjectDomainId()
+	if obj != nil {
+		req.ResourceId = obj.GetId()
+	}
+
+	if quota != nil && !quota.IsEmpty() {
+		err := quotas.CheckSetPendingQuota(ctx, userCred, quota)
+		if err != nil {
+			return nil, httperrors.NewOutOfQuotaError("%s", err)
+		}
+		req.PendingUsage = jsonutils.Marshal(quota)
+	}
+
+	err := func() error {
+		lockman.LockClass(ctx, manager, ownerId.GetProjectId())
+		defer lockman.ReleaseClass(ctx, manager, ownerId.GetProjectId())
+
+		var err error
+		req.Name, err = db.GenerateName(manager, ownerId, fmt.Sprintf("%s-%s", policy.ResourceType, action))
+		if err != nil {
+			return errors.Wrap(err, "GenerateName")
+		}
+		return manager.TableSpec().Insert(ctx, req)
+	}()
+	if err != nil {
+		if quota != nil && req.PendingUsage != nil {
+			quotas.CancelPendingUsage(ctx, userCred, quota, quota, false)
+		}
+		return nil, httperrors.NewGeneralError(err)
+	}
+
+	db.OpsLog.LogEvent(req, db.ACT_CREATE, size, userCred)
+	logclient.AddActionLogWithContext(ctx, req, logclient.ACT_APPROVAL_SUBMIT, size, userCred, true)
+	req.notify(ctx, policy.GetApproverIds(), notifyclient.APPROVAL_REQUEST_SUBMITTED)
+	return req, nil
+}
+
+func (self *SApprovalRequest) notify(ctx context.Context, recipients []string, event string) {
+	kwargs := jsonutils.NewDict()
+	kwargs.Add(jsonutils.NewString(self.Id), "id")
+	kwargs.Add(jsonutils.NewString(self.Name), "name")
+	kwargs.Add(jsonutils.NewString(self.ResourceType), "resource_type")
+	kwargs.Add(jsonutils.NewString(self.Action), "action")
+	kwargs.Add(jsonutils.NewString(self.Requester), "requester")
+	kwargs.Add(jsonutils.NewString(self.ProjectId), "project_id")
+	if self.Size != nil {
+		kwargs.Add(self.Size, "size")
+	}
+	if len(self.Comment) > 0 {
+		kwargs.Add(jsonutils.NewString(self.Comment), "comment")
+	}
+	kwargs.Add(jsonutils.NewTimeString(self.ExpiredAt), "expired_at")
+	notifyclient.NotifyWithCtx(ctx, recipients, false, notify.NotifyPriorityNormal, event, kwargs)
+}
+
+func (self *SApprovalRequest) GetPolicy() (*SApprovalPolicy, error) {
+	policy, err := ApprovalPolicyManager.FetchById(self.PolicyId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch approval policy %s", self.PolicyId)
+	}
+	return policy.(*SApprovalPolicy), nil
+}
+
+func (self *SApprovalRequest) isApprover(userCred mcclient.TokenCredential) bool {
+	policy, err := self.GetPolicy()
+	if err != nil {
+		return false
+	}
+	return utils.IsInStringArray(userCred.GetUserId(), policy.GetApproverIds())
+}
+
+func (self *SApprovalRequest) AllowGetDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return self.SVirtualResourceBase.AllowGetDetails(ctx, userCred, query) || self.isApprover(userCred)
+}
+
+func (self *SApprovalRequest) getParams() (*sApprovalParams, error) {
+	params := &sApprovalParams{}
+	if self.Params == nil {
+		return params, nil
+	}
+	err := self.Params.Unmarshal(params)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unmarshal params")
+	}
+	return params, nil
+}
+
+// releaseQuota cancel usage reserved when the request was submitted
+func (self *SApprovalRequest) releaseQuota(ctx context.Context, userCred mcclient.TokenCredential) {
+	if self.PendingUsage == nil {
+		return
+	}
+	quota := &SQuota{}
+	err := self.PendingUsage.Unmarshal(quota)
+	if err != nil {
+		log.Errorf("unmarshal pending usage of approval request %s fail: %s", self.Id, err)
+		return
+	}
+	err = quotas.CancelPendingUsage(ctx, userCred, quota, quota, false)
+	if err != nil {
+		log.Errorf("cancel pending usage of approval request %s fail: %s", self.Id, err)
+		return
+	}
+	db.Update(self, func() error {
+		self.PendingUsage = nil
+		return nil
+	})
+}
+
+func (self *SApprovalRequest) checkPending() error {
+	if self.Status != api.APPROVAL_REQUEST_STATUS_PENDING {
+		return httperrors.NewInvalidStatusError("approval request is %s", self.Status)
+	}
+	if !self.ExpiredAt.IsZero() && self.ExpiredAt.Before(time.Now()) {
+		return httperrors.NewInvalidStatusError("approval request has expired")
+	}
+	return nil
+}
+
+// close moves the request out of pending with a conditional update, so that
+// concurrent decisions on the same request can't both succeed
+func (self *SApprovalRequest) close(ctx context.Context, userCred mcclient.TokenCredential, status string, comment string) error {
+	sets := []string{"status = ?", "comment = ?", "updated_at = ?", "update_version = update_version + 1"}
+	args := []interface{}{status, comment, time.Now().UTC()}
+	if status != api.APPROVAL_REQUEST_STATUS_EXPIRED && status != api.APPROVAL_REQUEST_STATUS_CANCELLED {
+		sets = append(sets, "approver_id = ?", "approver = ?")
+		args = append(args, userCred.GetUserId(), userCred.GetUserName())
+	}
+	args = append(args, self.Id, api.APPROVAL_REQUEST_STATUS_PENDING)
+	result, err := sqlchemy.GetDB().Exec(
+		fmt.Sprintf(
+			"update %s set %s where id = ? and status = ?",
+			ApprovalRequestManager.TableSpec().Name(), strings.Join(sets, ", "),
+		), args...,
+	)
+	if err != nil {
+		return errors.Wrap(err, "update approval request status")
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "RowsAffected")
+	}
+	if err := self.reload(); err != nil {
+		return err
+	}
+	if rows != 1 {
+		return httperrors.NewInvalidStatusError("approval request is %s", self.Status)
+	}
+	return nil
+}
+
+func (self *SApprovalRequest) reload() error {
+	obj, err := ApprovalRequestManager.FetchById(self.Id)
+	if err != nil {
+		return errors.Wrapf(err, "fetch approval request %s", self.Id)
+	}
+	*self = *obj.(*SApprovalRequest)
+	self.SetModelManager(ApprovalRequestManager, self)
+	return nil
+}
+
+// checkRequester makes sure the requester still has the project and roles
+// the request was submitted with, the request is executed in the requester's name
+func (self *SApprovalRequest) checkRequester(ctx context.Context) error {
+	if self.RequesterCred == nil {
+		return httperrors.NewInternalServerError("approval request has no requester credential")
+	}
+	projectId := self.RequesterCred.GetProjectId()
+	if _, err := db.TenantCacheManager.FetchTenantById(ctx, projectId); err != nil {
+		return httperrors.NewForbiddenError("project %s of requester is no longer valid: %v", self.RequesterCred.GetProjectName(), err)
+	}
+	query := jsonutils.NewDict()
+	query.Add(jsonutils.JSONNull, "effective")
+	query.Add(jsonutils.NewInt(0), "limit")
+	query.Add(jsonutils.NewString(self.RequesterId), "user", "id")
+	query.Add(jsonutils.NewString(projectId), "scope", "project", "id")
+	s := auth.GetAdminSession(ctx, options.Options.Region, "")
+	ret, err := modules.RoleAssignments.List(s, query)
+	if err != nil {
+		return httperrors.NewGeneralError(errors.Wrap(err, "list role assignments of requester"))
+	}
+	roleIds := []string{}
+	for _, ra := range ret.Data {
+		if roleId, _ := ra.GetString("role", "id"); len(roleId) > 0 {
+			roleIds = append(roleIds, roleId)
+		}
+	}
+	if missing := missingRoleIds(self.RequesterCred.GetRoleIds(), roleIds); len(missing) > 0 {
+		return httperrors.NewForbiddenError("requester no longer has roles %s in project %s", missing, self.RequesterCred.GetProjectName())
+	}
+	return nil
+}
+
+// missingRoleIds returns roles granted at submission but not assigned any more
+func missingRoleIds(granted, current []string) []string {
+	missing := []string{}
+	for _, roleId := range granted {
+		if !utils.IsInStringArray(roleId, current) {
+			missing = append(missing, roleId)
+		}
+	}
+	return missing
+}
+
+func (self *SApprovalRequest) AllowPerformApprove(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return (self.isApprover(userCred) && userCred.GetUserId() != self.RequesterId) || db.IsAdminAllowPerform(userCred, self, "approve")
+}
+
+// 批准申请, 以申请人身份执行原始请求
+func (self *SApprovalRequest) PerformApprove(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ApprovalRequestApproveInput) (jsonutils.JSONObject, error) {
+	// the object is fetched before locked, decide on the latest row
+	err := self.reload()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	err = self.checkPending()
+	if err != nil {
+		return nil, err
+	}
+	err = self.checkRequester(ctx)
+	if err != nil {
+		return nil, err
+	}
+	params, err := self.getParams()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	manager := db.GetModelManager(self.ResourceType)
+	if manager == nil {
+		return nil, httperrors.NewInternalServerError("no manager for %s", self.ResourceType)
+	}
+	err = self.close(ctx, userCred, api.APPROVAL_REQUEST_STATUS_APPROVED, input.Comment)
+	if err != nil {
+		return nil, err
+	}
+	db.OpsLog.LogEvent(self, logclient.ACT_APPROVAL_APPROVE, input.Comment, userCred)
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_APPROVAL_APPROVE, input.Comment, userCred, true)
+
+	// the request reserves quota again when executed
+	self.releaseQuota(ctx, userCred)
+
+	result, err := params.execute(ctx, self.RequesterCred, manager, self.Action, self.ResourceId)
+	if err != nil {
+		log.Errorf("execute approval request %s fail: %s", self.Id, err)
+		db.Update(self, func() error {
+			self.Status = api.APPROVAL_REQUEST_STATUS_EXECUTE_FAILED
+			self.Result = jsonutils.Marshal(map[string]string{"error": err.Error()})
+			return nil
+		})
+		logclient.AddActionLogWithContext(ctx, self, logclient.ACT_APPROVAL_EXECUTE, err, self.RequesterCred, false)
+	} else {
+		db.Update(self, func() error {
+			self.Status = api.APPROVAL_REQUEST_STATUS_EXECUTED
+			self.Result = result
+			if self.Action == api.APPROVAL_ACTION_CREATE && result != nil {
+				if id, _ := result.GetString("id"); len(id) > 0 {
+					self.ResourceId = id
+				}
+			}
+			return nil
+		})
+		logclient.AddActionLogWithContext(ctx, self, logclient.ACT_APPROVAL_EXECUTE, result, self.RequesterCred, true)
+	}
+	self.notify(ctx, []string{self.RequesterId}, notifyclient.APPROVAL_REQUEST_APPROVED)
+	return nil, nil
+}
+
+func (self *SApprovalRequest) AllowPerformReject(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.isApprover(userCred) || db.IsAdminAllowPerform(userCred, self, "reject")
+}
+
+// 驳回申请, 释放预留的配额
+func (self *SApprovalRequest) PerformReject(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ApprovalRequestRejectInput) (jsonutils.JSONObject, error) {
+	if len(input.Reason) == 0 {
+		return nil, httperrors.NewMissingParameterError("reason")
+	}
+	err := self.close(ctx, userCred, api.APPROVAL_REQUEST_STATUS_REJECTED, input.Reason)
+	if err != nil {
+		return nil, err
+	}
+	self.releaseQuota(ctx, userCred)
+	db.OpsLog.LogEvent(self, logclient.ACT_APPROVAL_REJECT, input.Reason, userCred)
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_APPROVAL_REJECT, input.Reason, userCred, true)
+	self.notify(ctx, []string{self.RequesterId}, notifyclient.APPROVAL_REQUEST_REJECTED)
+	return nil, nil
+}
+
+func (self *SApprovalRequest) AllowPerformCancel(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return userCred.GetUserId() == self.RequesterId || db.IsAdminAllowPerform(userCred, self, "cancel")
+}
+
+// 撤销申请
+func (self *SApprovalRequest) PerformCancel(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	err := self.close(ctx, userCred, api.APPROVAL_REQUEST_STATUS_CANCELLED, "")
+	if err != nil {
+		return nil, err
+	}
+	self.releaseQuota(ctx, userCred)
+	db.OpsLog.LogEvent(self, logclient.ACT_APPROVAL_CANCEL, "", userCred)
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_APPROVAL_CANCEL, "", userCred, true)
+	return nil, nil
+}
+
+// ExpireApprovalRequests close pending requests nobody decided in time
+func (manager *SApprovalRequestManager) ExpireApprovalRequests(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	q := manager.Query().Equals("status", api.APPROVAL_REQUEST_STATUS_PENDING).LT("expired_at", time.Now())
+	reqs := []SApprovalRequest{}
+	err := db.FetchModelObjects(manager, q, &reqs)
+	if err != nil {
+		log.Errorf("fetch expired approval requests fail: %s", err)
+		return
+	}
+	for i := range reqs {
+		req := &reqs[i]
+		err := req.close(ctx, userCred, api.APPROVAL_REQUEST_STATUS_EXPIRED, "")
+		if err != nil {
+			log.Errorf("expire approval request %s fail: %s", req.Id, err)
+			continue
+		}
+		req.releaseQuota(ctx, userCred)
+		db.OpsLog.LogEvent(req, logclient.ACT_APPROVAL_EXPIRE, "", userCred)
+		logclient.AddActionLogWithContext(ctx, req, logclient.ACT_APPROVAL_EXPIRE, "", userCred, true)
+		req.notify(ctx, []string{req.RequesterId}, notifyclient.APPROVAL_REQUEST_EXPIRED)
+	}
+}
+
+func (self *SApprovalRequest) ValidateDeleteCondition(ctx context.Context) error {
+	if utils.IsInStringArray(self.Status, []string{api.APPROVAL_REQUEST_STATUS_PENDING, api.APPROVAL_REQUEST_STATUS_APPROVED}) {
+		return httperrors.NewInvalidStatusError("cannot delete approval request in status %s", self.Status)
+	}
+	return self.SVirtualResourceBase.ValidateDeleteCondition(ctx)
+}
+
+func (self *SApprovalRequest) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, isList bool) (api.ApprovalRequestDetails, error) {
+	return api.ApprovalRequestDetails{}, nil
+}
+
+func (manager *SApprovalRequestManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ApprovalRequestDetails {
+	rows := make([]api.ApprovalRequestDetails, len(objs))
+	virtRows := manager.SVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.ApprovalRequestDetails{
+			VirtualResourceDetails: virtRows[i],
+		}
+		req := objs[i].(*SApprovalRequest)
+		if policy, err := req.GetPolicy(); err == nil {
+			rows[i].Policy = policy.Name
+		}
+	}
+	return rows
+}
+
+// 审批申请列表
+func (manager *SApprovalRequestManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ApprovalRequestListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, query.VirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.ListItemFilter")
+	}
+	if len(query.ResourceType) > 0 {
+		q = q.In("resource_type", query.ResourceType)
+	}
+	if len(query.Action) > 0 {
+		q = q.In("action", query.Action)
+	}
+	if len(query.PolicyId) > 0 {
+		policy, err := ApprovalPolicyManager.FetchByIdOrName(userCred, query.PolicyId)
+		if err != nil {
+			return nil, httperrors.NewResourceNotFoundError2("approval_policy", query.PolicyId)
+		}
+		q = q.Equals("policy_id", policy.GetId())
+	}
+	if len(query.RequesterId) > 0 {
+		q = q.Equals("requester_id", query.RequesterId)
+	}
+	return q, nil
+}
+
+func (manager *SApprovalRequestManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ApprovalRequestListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.VirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SApprovalRequestManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
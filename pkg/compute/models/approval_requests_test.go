@@ -0,0 +1,41 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMissingRoleIds(t *testing.T) {
+	cases := []struct {
+		name    string
+		granted []string
+		current []string
+		want    []string
+	}{
+		{"unchanged", []string{"member", "admin"}, []string{"admin", "member"}, []string{}},
+		{"more roles", []string{"member"}, []string{"admin", "member"}, []string{}},
+		{"role revoked", []string{"member", "admin"}, []string{"member"}, []string{"admin"}},
+		{"removed from project", []string{"member"}, nil, []string{"member"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := missingRoleIds(c.granted, c.current); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("want %v, got %v", c.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// SBiosProfileManager manage declarative bios settings for baremetals
+type SBiosProfileManager struct {
+	db.SEnabledStatusInfrasResourceBaseManager
+}
+
+var BiosProfileManager *SBiosProfileManager
+
+func init() {
+	BiosProfileManager = &SBiosProfileManager{
+		SEnabledStatusInfrasResourceBaseManager: db.NewEnabledStatusInfrasResourceBaseManager(
+			SBiosProfile{},
+			"bios_profiles_tbl",
+			"bios_profile",
+			"bios_profiles",
+		),
+	}
+	BiosProfileManager.SetVirtualObject(BiosProfileManager)
+}
+
+type SBiosProfile struct {
+	db.SEnabledStatusInfrasResourceBase
+
+	// 适用的服务器厂商
+	Manufacture string `width:"64" charset:"utf8" nullable:"true" list:"domain" create:"domain_optional"`
+
+	// 期望的BIOS属性
+	Attributes jsonutils.JSONObject `nullable:"false" list:"domain" update:"domain" create:"domain_required"`
+}
+
+func (manager *SBiosProfileManager) AllowCreateItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowCreate(userCred, manager)
+}
+
+func validateBiosAttributes(attrs jsonutils.JSONObject) error {
+	dict, ok := attrs.(*jsonutils.JSONDict)
+	if !ok || dict.Length() == 0 {
+		return httperrors.NewInputParameterError("attributes must be a non-empty dict")
+	}
+	for key, val := range dict.Value() {
+		switch val.(type) {
+		case *jsonutils.JSONString, *jsonutils.JSONInt, *jsonutils.JSONBool:
+		default:
+			return httperrors.NewInputParameterError("value of bios attribute %s must be string, integer or bool", key)
+		}
+	}
+	return nil
+}
+
+func (manager *SBiosProfileManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.BiosProfileCreateInput,
+) (api.BiosProfileCreateInput, error) {
+	if input.Attributes == nil {
+		return input, httperrors.NewMissingParameterError("attributes")
+	}
+	if err := validateBiosAttributes(input.Attributes); err != nil {
+		return input, err
+	}
+	input.Status = api.BIOS_PROFILE_STATUS_AVAILABLE
+	var err error
+	input.EnabledStatusInfrasResourceBaseCreateInput, err = manager.SEnabledStatusInfrasResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusInfrasResourceBaseCreateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.ValidateCreateData")
+	}
+	return input, nil
+}
+
+func (self *SBiosProfile) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.BiosProfileUpdateInput,
+) (api.BiosProfileUpdateInput, error) {
+	if input.Attributes != nil {
+		if err := validateBiosAttributes(input.Attributes); err != nil {
+			return input, err
+		}
+	}
+	var err error
+	input.EnabledStatusInfrasResourceBaseUpdateInput, err = self.SEnabledStatusInfrasResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusInfrasResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SEnabledStatusInfrasResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
+func (self *SBiosProfile) PostUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.SEnabledStatusInfrasResourceBase.PostUpdate(ctx, userCred, query, data)
+	if data.Contains("attributes") {
+		// desired attributes changed, hosts have to be checked again
+		hosts, _ := self.GetHosts()
+		for i := range hosts {
+			db.Update(&hosts[i], func() error {
+				hosts[i].BiosProfileStatus = api.HOST_BIOS_PROFILE_DRIFT
+				return nil
+			})
+		}
+	}
+}
+
+func (self *SBiosProfile) GetHostQuery() *sqlchemy.SQuery {
+	return HostManager.Query().Equals("bios_profile_id", self.Id)
+}
+
+func (self *SBiosProfile) GetHosts() ([]SHost, error) {
+	hosts := []SHost{}
+	err := db.FetchModelObjects(HostManager, self.GetHostQuery(), &hosts)
+	if err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+func (self *SBiosProfile) GetHostCount() (int, error) {
+	return self.GetHostQuery().CountWithError()
+}
+
+func (self *SBiosProfile) GetAttributes() *jsonutils.JSONDict {
+	if dict, ok := self.Attributes.(*jsonutils.JSONDict); ok {
+		return dict
+	}
+	return jsonutils.NewDict()
+}
+
+// IsApplicable check whether the profile match manufacture of the host
+func (self *SBiosProfile) IsApplicable(host *SHost) bool {
+	if len(self.Manufacture) == 0 {
+		return true
+	}
+	var manufacture string
+	if host.SysInfo != nil {
+		manufacture, _ = host.SysInfo.GetString("manufacture")
+	}
+	return strings.EqualFold(strings.TrimSpace(manufacture), strings.TrimSpace(self.Manufacture))
+}
+
+func (self *SBiosProfile) ValidateDeleteCondition(ctx context.Context) error {
+	cnt, err := self.GetHostCount()
+	if err != nil {
+		return httperrors.NewInternalServerError("GetHostCount fail %s", err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("bios profile is attached to %d hosts", cnt)
+	}
+	return self.SEnabledStatusInfrasResourceBase.ValidateDeleteCondition(ctx)
+}
+
+func (self *SBiosProfile) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, isList bool) (api.BiosProfileDetails, error) {
+	return api.BiosProfileDetails{}, nil
+}
+
+func (manager *SBiosProfileManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.BiosProfileDetails {
+	rows := make([]api.BiosProfileDetails, len(objs))
+	stdRows := manager.SEnabledStatusInfrasResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.BiosProfileDetails{
+			EnabledStatusInfrasResourceBaseDetails: stdRows[i],
+		}
+		profile := objs[i].(*SBiosProfile)
+		rows[i].HostCount, _ = profile.GetHostCount()
+	}
+	return rows
+}
+
+// BIOS模板列表
+func (manager *SBiosProfileManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.BiosProfileListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusInfrasResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledStatusInfrasResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Manufacture) > 0 {
+		q = q.In("manufacture", query.Manufacture)
+	}
+	return q, nil
+}
+
+func (manager *SBiosProfileManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.BiosProfileListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SEnabledStatusInfrasResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.EnabledStatusInfrasResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SBiosProfileManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	var err error
+
+	q, err = manager.SEnabledStatusInfrasResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+
+	return q, httperrors.ErrNotFound
+}
@@ -138,7 +138,12 @@ func (manager *SBucketManager) syncBuckets(ctx context.Context, userCred mcclien
 		return syncResult
 	}
 
+	plan := newSyncPlanScope(ctx, manager, len(dbBuckets))
 	for i := 0; i < len(removed); i += 1 {
+		bucket := &removed[i]
+		if plan.Remove(bucket, func() error { return bucket.syncRemoveCloudBucket(ctx, userCred) }) {
+			continue
+		}
 		err = removed[i].syncRemoveCloudBucket(ctx, userCred)
 		if err != nil {
 			syncResult.DeleteError(err)
@@ -147,6 +152,9 @@ func (manager *SBucketManager) syncBuckets(ctx context.Context, userCred mcclien
 		}
 	}
 	for i := 0; i < len(commondb); i += 1 {
+		if plan.Update(&commondb[i], commonext[i]) {
+			continue
+		}
 		err = commondb[i].syncWithCloudBucket(ctx, userCred, commonext[i], provider, false)
 		if err != nil {
 			syncResult.UpdateError(err)
@@ -155,6 +163,9 @@ func (manager *SBucketManager) syncBuckets(ctx context.Context, userCred mcclien
 		}
 	}
 	for i := 0; i < len(added); i += 1 {
+		if plan.Add(added[i]) {
+			continue
+		}
 		_, err := manager.newFromCloudBucket(ctx, userCred, added[i], provider, region)
 		if err != nil {
 			syncResult.AddError(err)
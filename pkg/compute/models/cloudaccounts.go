@@ -613,14 +613,17 @@ func (self *SCloudaccount) PerformSync(ctx context.Context, userCred mcclient.To
 	if !self.GetEnabled() {
 		return nil, httperrors.NewInvalidStatusError("Account disabled")
 	}
-	if self.EnableAutoSync {
-		return nil, httperrors.NewInvalidStatusError("Account auto sync enabled")
-	}
 	syncRange := SSyncRange{}
 	err := data.Unmarshal(&syncRange)
 	if err != nil {
 		return nil, httperrors.NewInputParameterError("invalid input %s", err)
 	}
+	if syncRange.DryRun {
+		return nil, self.StartSyncPlanTask(ctx, userCred, &syncRange, "")
+	}
+	if self.EnableAutoSync {
+		return nil, httperrors.NewInvalidStatusError("Account auto sync enabled")
+	}
 	if syncRange.FullSync || len(syncRange.Region) > 0 || len(syncRange.Zone) > 0 || len(syncRange.Host) > 0 {
 		syncRange.DeepSync = true
 	}
@@ -793,6 +796,30 @@ func (self *SCloudaccount) StartSyncCloudProviderInfoTask(ctx context.Context, u
 	return nil
 }
 
+func (self *SCloudaccount) StartSyncPlanTask(ctx context.Context, userCred mcclient.TokenCredential, syncRange *SSyncRange, parentTaskId string) error {
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.Marshal(syncRange), "sync_range")
+	task, err := taskman.TaskManager.NewTask(ctx, "CloudAccountSyncPlanTask", self, userCred, params, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
+func (self *SCloudaccount) AllowGetDetailsSyncPlan(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowGetSpec(userCred, self, "sync-plan")
+}
+
+// 获取最近一次预同步(dry run)的变更计划
+func (self *SCloudaccount) GetDetailsSyncPlan(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	plan := self.GetMetadataJson(CLOUD_ACCOUNT_METADATA_SYNC_PLAN, userCred)
+	if plan == nil {
+		return nil, httperrors.NewNotFoundError("no sync plan, perform sync with dry_run first")
+	}
+	return plan, nil
+}
+
 func (self *SCloudaccount) markStartSync(userCred mcclient.TokenCredential, syncRange *SSyncRange) error {
 	_, err := db.Update(self, func() error {
 		self.SyncStatus = api.CLOUD_PROVIDER_SYNC_STATUS_QUEUED
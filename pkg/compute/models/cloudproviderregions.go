@@ -31,6 +31,7 @@ import (
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/options"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/util/nopanic"
@@ -393,6 +394,13 @@ func (self *SCloudproviderregion) DoSync(ctx context.Context, userCred mcclient.
 	}
 	log.Debugf("need to do deep sync? ... %v", syncRange.DeepSync)
 
+	// hold removals until the whole region is synced to check them against the threshold
+	var plan *SSyncPlan
+	if options.Options.CloudSyncRemovalThresholdPercent > 0 && !syncRange.IgnoreRemovalThreshold {
+		plan = NewSyncPlan(false)
+		ctx = plan.withContext(ctx)
+	}
+
 	if localRegion.isManaged() {
 		remoteRegion, err := driver.GetIRegionById(localRegion.ExternalId)
 		if err != nil {
@@ -405,6 +413,12 @@ func (self *SCloudproviderregion) DoSync(ctx context.Context, userCred mcclient.
 
 	if err != nil {
 		log.Errorf("dosync fail %s", err)
+	} else if plan != nil {
+		err = plan.applyRemovals(syncResults)
+		if err != nil {
+			db.OpsLog.LogEvent(provider, db.ACT_SYNC_HOST_FAILED, err.Error(), userCred)
+			log.Errorf("dosync skip removals: %s", err)
+		}
 	}
 
 	log.Debugf("dosync result: %s", jsonutils.Marshal(syncResults))
@@ -407,6 +407,10 @@ type SSyncRange struct {
 	Force    bool
 	FullSync bool
 	DeepSync bool
+	// only plan the changes sync would make without applying them, the plan is kept in metadata of the cloudaccount
+	DryRun bool
+	// sync even if removals exceed cloud_sync_removal_threshold_percent
+	IgnoreRemovalThreshold bool
 	// ProjectSync bool
 
 	Region []string
@@ -0,0 +1,499 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/compare"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/appctx"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+const (
+	APP_CONTEXT_KEY_SYNC_PLAN = appctx.AppContextKey("syncplan")
+
+	CLOUD_ACCOUNT_METADATA_SYNC_PLAN = "__sync_plan"
+
+	// count of sample resources kept for each kind of change
+	syncPlanSampleCount = 5
+)
+
+// SSyncPlan collects the changes SyncXxx makes after compare.CompareSets. In dry run
+// SyncXxx only reports its changes to the plan and writes nothing. Otherwise updates and
+// additions go on as usual, while removals are held by the plan until the sync finishes
+// and checked against the removal threshold as a whole
+type SSyncPlan struct {
+	DryRun    bool
+	Resources map[string]*api.SyncPlanItem
+	Errors    []string
+
+	// external ids of remote resources seen by the sync, keyed by resource type
+	found map[string]map[string]bool
+	added map[string]map[string]bool
+
+	removals []sSyncPlanRemoval
+	// removals are being applied, they should not be held again
+	applied bool
+}
+
+type sSyncPlanRemoval struct {
+	manager    db.IModelManager
+	externalId string
+	remove     func() error
+}
+
+func NewSyncPlan(dryRun bool) *SSyncPlan {
+	return &SSyncPlan{
+		DryRun:    dryRun,
+		Resources: map[string]*api.SyncPlanItem{},
+		found:     map[string]map[string]bool{},
+		added:     map[string]map[string]bool{},
+	}
+}
+
+func (plan *SSyncPlan) withContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, APP_CONTEXT_KEY_SYNC_PLAN, plan)
+}
+
+func fetchSyncPlan(ctx context.Context) *SSyncPlan {
+	val := ctx.Value(APP_CONTEXT_KEY_SYNC_PLAN)
+	if val == nil {
+		return nil
+	}
+	return val.(*SSyncPlan)
+}
+
+func isSyncDryRun(ctx context.Context) bool {
+	plan := fetchSyncPlan(ctx)
+	return plan != nil && plan.DryRun
+}
+
+func (plan *SSyncPlan) item(manager db.IModelManager) *api.SyncPlanItem {
+	key := manager.KeywordPlural()
+	if _, ok := plan.Resources[key]; !ok {
+		plan.Resources[key] = &api.SyncPlanItem{}
+	}
+	return plan.Resources[key]
+}
+
+func (plan *SSyncPlan) error(err error) {
+	log.Errorf("plan sync: %v", err)
+	plan.Errors = append(plan.Errors, err.Error())
+}
+
+// result keeps the errors SyncXxx ran into, resources under them are not compared
+func (plan *SSyncPlan) result(manager db.IModelManager, result compare.SyncResult) {
+	if result.IsError() {
+		plan.error(errors.Wrapf(result.AllError(), "sync %s", manager.KeywordPlural()))
+	}
+}
+
+func markSyncPlanId(ids map[string]map[string]bool, manager db.IModelManager, externalId string) {
+	key := manager.KeywordPlural()
+	if _, ok := ids[key]; !ok {
+		ids[key] = map[string]bool{}
+	}
+	ids[key][externalId] = true
+}
+
+func appendSyncPlanSample(samples []api.SyncPlanSample, sample api.SyncPlanSample) []api.SyncPlanSample {
+	if len(samples) >= syncPlanSampleCount {
+		return samples
+	}
+	return append(samples, sample)
+}
+
+func dropSyncPlanSample(samples []api.SyncPlanSample, externalId string) []api.SyncPlanSample {
+	ret := []api.SyncPlanSample{}
+	for i := range samples {
+		if samples[i].ExternalId != externalId {
+			ret = append(ret, samples[i])
+		}
+	}
+	return ret
+}
+
+// sSyncPlanScope reports the result of compare.CompareSets in a SyncXxx call to the plan,
+// all methods are no-op on nil scope, i.e. when the sync runs without plan
+type sSyncPlanScope struct {
+	plan    *SSyncPlan
+	manager db.IModelManager
+	item    *api.SyncPlanItem
+}
+
+func newSyncPlanScope(ctx context.Context, manager db.IModelManager, localCnt int) *sSyncPlanScope {
+	plan := fetchSyncPlan(ctx)
+	if plan == nil || plan.applied {
+		return nil
+	}
+	item := plan.item(manager)
+	item.LocalCnt += localCnt
+	return &sSyncPlanScope{plan: plan, manager: manager, item: item}
+}
+
+// Remove holds the removal of local until the sync finishes, returns false if SyncXxx should remove it right now
+func (scope *sSyncPlanScope) Remove(local db.IExternalizedModel, remove func() error) bool {
+	if scope == nil {
+		return false
+	}
+	scope.item.DelCnt += 1
+	scope.item.Removed = appendSyncPlanSample(scope.item.Removed, api.SyncPlanSample{Id: local.GetId(), Name: local.GetName(), ExternalId: local.GetExternalId()})
+	scope.plan.removals = append(scope.plan.removals, sSyncPlanRemoval{manager: scope.manager, externalId: local.GetExternalId(), remove: remove})
+	return true
+}
+
+// Update records the update of local, returns true if SyncXxx should skip writing it
+func (scope *sSyncPlanScope) Update(local db.IModel, remote cloudprovider.ICloudResource) bool {
+	if scope == nil {
+		return false
+	}
+	markSyncPlanId(scope.plan.found, scope.manager, remote.GetGlobalId())
+	scope.item.UpdateCnt += 1
+	diff := []string{}
+	if local.GetName() != remote.GetName() {
+		diff = append(diff, fmt.Sprintf("name: %s -> %s", local.GetName(), remote.GetName()))
+	}
+	if statusModel, ok := local.(interface{ GetStatus() string }); ok {
+		if status := remote.GetStatus(); len(status) > 0 && statusModel.GetStatus() != status {
+			diff = append(diff, fmt.Sprintf("status: %s -> %s", statusModel.GetStatus(), status))
+		}
+	}
+	if len(diff) > 0 {
+		scope.item.Updated = appendSyncPlanSample(scope.item.Updated, api.SyncPlanSample{Id: local.GetId(), Name: local.GetName(), ExternalId: remote.GetGlobalId(), Diff: diff})
+	}
+	return scope.plan.DryRun
+}
+
+// Add records the addition of remote, returns true if SyncXxx should skip creating it
+func (scope *sSyncPlanScope) Add(remote cloudprovider.ICloudResource) bool {
+	if scope == nil {
+		return false
+	}
+	markSyncPlanId(scope.plan.found, scope.manager, remote.GetGlobalId())
+	markSyncPlanId(scope.plan.added, scope.manager, remote.GetGlobalId())
+	scope.item.AddCnt += 1
+	scope.item.Added = appendSyncPlanSample(scope.item.Added, api.SyncPlanSample{Name: remote.GetName(), ExternalId: remote.GetGlobalId()})
+	return scope.plan.DryRun
+}
+
+// IsDryRun tells SyncXxx to skip the writes before compare.CompareSets
+func (scope *sSyncPlanScope) IsDryRun() bool {
+	return scope != nil && scope.plan.DryRun
+}
+
+// settle drops removals of guests and disks found again under another host or storage of
+// the provider, SyncXxx moves them by external id instead of removing, i.e. a migration
+func (plan *SSyncPlan) settle() {
+	movable := []string{GuestManager.KeywordPlural(), DiskManager.KeywordPlural()}
+	removals := make([]sSyncPlanRemoval, 0, len(plan.removals))
+	for _, removal := range plan.removals {
+		key := removal.manager.KeywordPlural()
+		if !utils.IsInStringArray(key, movable) || !plan.found[key][removal.externalId] {
+			removals = append(removals, removal)
+			continue
+		}
+		item := plan.Resources[key]
+		item.DelCnt -= 1
+		item.Removed = dropSyncPlanSample(item.Removed, removal.externalId)
+		if plan.added[key][removal.externalId] {
+			item.AddCnt -= 1
+			item.UpdateCnt += 1
+			item.Added = dropSyncPlanSample(item.Added, removal.externalId)
+		}
+	}
+	plan.removals = removals
+}
+
+// applyRemovals runs the removals held during the sync, unless they exceed the removal threshold,
+// which usually means the provider api misbehaves rather than the resources are really gone
+func (plan *SSyncPlan) applyRemovals(syncResults SSyncResultSet) error {
+	plan.settle()
+	threshold := options.Options.CloudSyncRemovalThresholdPercent
+	exceeded := plan.RemovalExceeded(threshold, options.Options.CloudSyncRemovalCheckMinCount)
+	if len(exceeded) > 0 {
+		msgs := []string{}
+		for _, key := range exceeded {
+			item := plan.Resources[key]
+			msgs = append(msgs, fmt.Sprintf("%s %d/%d", key, item.DelCnt, item.LocalCnt))
+		}
+		return errors.Wrapf(errors.ErrInvalidStatus, "removals exceed %d%%: %s", threshold, strings.Join(msgs, ", "))
+	}
+	plan.applied = true
+	for _, removal := range plan.removals {
+		result := compare.SyncResult{}
+		err := removal.remove()
+		if err != nil {
+			result.DeleteError(err)
+		} else {
+			result.Delete()
+		}
+		syncResults.Add(removal.manager, result)
+	}
+	return nil
+}
+
+func (plan *SSyncPlan) Merge(other *SSyncPlan) {
+	for key, o := range other.Resources {
+		if _, ok := plan.Resources[key]; !ok {
+			plan.Resources[key] = &api.SyncPlanItem{}
+		}
+		item := plan.Resources[key]
+		item.LocalCnt += o.LocalCnt
+		item.AddCnt += o.AddCnt
+		item.UpdateCnt += o.UpdateCnt
+		item.DelCnt += o.DelCnt
+		for _, sample := range o.Added {
+			item.Added = appendSyncPlanSample(item.Added, sample)
+		}
+		for _, sample := range o.Updated {
+			item.Updated = appendSyncPlanSample(item.Updated, sample)
+		}
+		for _, sample := range o.Removed {
+			item.Removed = appendSyncPlanSample(item.Removed, sample)
+		}
+	}
+	plan.Errors = append(plan.Errors, other.Errors...)
+}
+
+// RemovalExceeded returns resource types which would lose more than threshold percent of local records
+func (plan *SSyncPlan) RemovalExceeded(threshold int, minCount int) []string {
+	ret := []string{}
+	if threshold <= 0 {
+		return ret
+	}
+	for key, item := range plan.Resources {
+		if item.LocalCnt < minCount || item.LocalCnt == 0 {
+			continue
+		}
+		if item.DelCnt*100 > item.LocalCnt*threshold {
+			ret = append(ret, key)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (plan *SSyncPlan) Output() api.CloudaccountSyncPlanOutput {
+	return api.CloudaccountSyncPlanOutput{
+		Resources:       plan.Resources,
+		RemovalExceeded: plan.RemovalExceeded(options.Options.CloudSyncRemovalThresholdPercent, options.Options.CloudSyncRemovalCheckMinCount),
+		Errors:          plan.Errors,
+		PlannedAt:       time.Now().UTC(),
+	}
+}
+
+// PlanSync runs the SyncXxx of DoSync in dry run and collects the changes they would make
+func (self *SCloudproviderregion) PlanSync(ctx context.Context, userCred mcclient.TokenCredential, syncRange *SSyncRange) (*SSyncPlan, error) {
+	plan := NewSyncPlan(true)
+	ctx = plan.withContext(ctx)
+	localRegion := self.GetRegion()
+	provider := self.GetProvider()
+	if localRegion == nil || provider == nil {
+		return nil, errors.Wrapf(errors.ErrNotFound, "region or provider of %s", self.GetId())
+	}
+	driver, err := provider.GetProvider()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetProvider")
+	}
+	if localRegion.isManaged() {
+		remoteRegion, err := driver.GetIRegionById(localRegion.ExternalId)
+		if err != nil {
+			return nil, errors.Wrap(err, "GetIRegionById")
+		}
+		planPublicCloudSync(ctx, userCred, plan, provider, driver, localRegion, remoteRegion, syncRange)
+	} else {
+		planOnPremiseSync(ctx, userCred, plan, provider, driver, syncRange)
+	}
+	plan.settle()
+	return plan, nil
+}
+
+func planRegionBuckets(ctx context.Context, userCred mcclient.TokenCredential, plan *SSyncPlan, provider *SCloudprovider, localRegion *SCloudregion, remoteRegion cloudprovider.ICloudRegion) {
+	buckets, err := remoteRegion.GetIBuckets()
+	if err != nil {
+		plan.error(errors.Wrapf(err, "GetIBuckets for region %s", remoteRegion.GetName()))
+		return
+	}
+	plan.result(BucketManager, BucketManager.syncBuckets(ctx, userCred, provider, localRegion, buckets))
+}
+
+func planPublicCloudSync(ctx context.Context, userCred mcclient.TokenCredential, plan *SSyncPlan, provider *SCloudprovider, driver cloudprovider.ICloudProvider, localRegion *SCloudregion, remoteRegion cloudprovider.ICloudRegion, syncRange *SSyncRange) {
+	if len(syncRange.Region) > 0 && !utils.IsInStringArray(localRegion.Id, syncRange.Region) {
+		return
+	}
+	if cloudprovider.IsSupportObjectstore(driver) {
+		planRegionBuckets(ctx, userCred, plan, provider, localRegion, remoteRegion)
+	}
+	if !cloudprovider.IsSupportCompute(driver) {
+		return
+	}
+	izones, err := remoteRegion.GetIZones()
+	if err != nil {
+		plan.error(errors.Wrapf(err, "GetIZones for region %s", remoteRegion.GetName()))
+		return
+	}
+	localZones, remoteZones, result := ZoneManager.SyncZones(ctx, userCred, localRegion, izones)
+	plan.result(ZoneManager, result)
+
+	ivpcs, err := remoteRegion.GetIVpcs()
+	if err != nil {
+		plan.error(errors.Wrapf(err, "GetIVpcs for region %s", remoteRegion.GetName()))
+	} else {
+		localVpcs, remoteVpcs, result := VpcManager.SyncVPCs(ctx, userCred, provider, localRegion, ivpcs)
+		plan.result(VpcManager, result)
+		for i := range localVpcs {
+			iwires, err := remoteVpcs[i].GetIWires()
+			if err != nil {
+				plan.error(errors.Wrapf(err, "GetIWires for vpc %s", remoteVpcs[i].GetName()))
+				continue
+			}
+			localWires, remoteWires, result := WireManager.SyncWires(ctx, userCred, &localVpcs[i], iwires, provider)
+			plan.result(WireManager, result)
+			for j := range localWires {
+				inets, err := remoteWires[j].GetINetworks()
+				if err != nil {
+					plan.error(errors.Wrapf(err, "GetINetworks for wire %s", remoteWires[j].GetName()))
+					continue
+				}
+				_, _, result := NetworkManager.SyncNetworks(ctx, userCred, &localWires[j], inets, provider)
+				plan.result(NetworkManager, result)
+			}
+		}
+	}
+
+	ieips, err := remoteRegion.GetIEips()
+	if err != nil {
+		plan.error(errors.Wrapf(err, "GetIEips for region %s", remoteRegion.GetName()))
+	} else {
+		plan.result(ElasticipManager, ElasticipManager.SyncEips(ctx, userCred, provider, localRegion, ieips, provider.GetOwnerId()))
+	}
+
+	for i := range localZones {
+		if len(syncRange.Zone) > 0 && !utils.IsInStringArray(localZones[i].Id, syncRange.Zone) {
+			continue
+		}
+		istorages, err := remoteZones[i].GetIStorages()
+		if err != nil {
+			plan.error(errors.Wrapf(err, "GetIStorages for zone %s", remoteZones[i].GetName()))
+		} else {
+			localStorages, remoteStorages, result := StorageManager.SyncStorages(ctx, userCred, provider, &localZones[i], istorages)
+			plan.result(StorageManager, result)
+			for j := range localStorages {
+				idisks, err := remoteStorages[j].GetIDisks()
+				if err != nil {
+					plan.error(errors.Wrapf(err, "GetIDisks for storage %s", remoteStorages[j].GetName()))
+					continue
+				}
+				_, _, result := DiskManager.SyncDisks(ctx, userCred, driver, &localStorages[j], idisks, provider.GetOwnerId())
+				plan.result(DiskManager, result)
+			}
+		}
+		ihosts, err := remoteZones[i].GetIHosts()
+		if err != nil {
+			plan.error(errors.Wrapf(err, "GetIHosts for zone %s", remoteZones[i].GetName()))
+			continue
+		}
+		planHostsSync(ctx, userCred, plan, provider, driver, &localZones[i], ihosts, syncRange)
+	}
+
+	isnapshots, err := remoteRegion.GetISnapshots()
+	if err != nil {
+		plan.error(errors.Wrapf(err, "GetISnapshots for region %s", remoteRegion.GetName()))
+	} else {
+		plan.result(SnapshotManager, SnapshotManager.SyncSnapshots(ctx, userCred, provider, localRegion, isnapshots, provider.GetOwnerId()))
+	}
+}
+
+func planOnPremiseSync(ctx context.Context, userCred mcclient.TokenCredential, plan *SSyncPlan, provider *SCloudprovider, driver cloudprovider.ICloudProvider, syncRange *SSyncRange) {
+	iregion, err := driver.GetOnPremiseIRegion()
+	if err != nil {
+		plan.error(errors.Wrapf(err, "GetOnPremiseIRegion for provider %s", provider.GetName()))
+		return
+	}
+	if cloudprovider.IsSupportObjectstore(driver) {
+		planRegionBuckets(ctx, userCred, plan, provider, CloudregionManager.FetchDefaultRegion(), iregion)
+	}
+	if !cloudprovider.IsSupportCompute(driver) {
+		return
+	}
+	ihosts, err := iregion.GetIHosts()
+	if err != nil {
+		plan.error(errors.Wrapf(err, "GetIHosts for provider %s", provider.GetName()))
+		return
+	}
+	planHostsSync(ctx, userCred, plan, provider, driver, nil, ihosts, syncRange)
+}
+
+func planHostsSync(ctx context.Context, userCred mcclient.TokenCredential, plan *SSyncPlan, provider *SCloudprovider, driver cloudprovider.ICloudProvider, localZone *SZone, ihosts []cloudprovider.ICloudHost, syncRange *SSyncRange) {
+	localHosts, remoteHosts, result := HostManager.SyncHosts(ctx, userCred, provider, localZone, ihosts)
+	plan.result(HostManager, result)
+	for i := range localHosts {
+		if len(syncRange.Host) > 0 && !utils.IsInStringArray(localHosts[i].Id, syncRange.Host) {
+			continue
+		}
+		istorages, err := remoteHosts[i].GetIStorages()
+		if err != nil {
+			plan.error(errors.Wrapf(err, "GetIStorages for host %s", remoteHosts[i].GetName()))
+		} else {
+			_, _, result := localHosts[i].SyncHostStorages(ctx, userCred, istorages, provider)
+			plan.result(HoststorageManager, result)
+		}
+		ivms, err := remoteHosts[i].GetIVMs()
+		if err != nil {
+			plan.error(errors.Wrapf(err, "GetIVMs for host %s", remoteHosts[i].GetName()))
+			continue
+		}
+		_, result := localHosts[i].SyncHostVMs(ctx, userCred, driver, ivms, provider.GetOwnerId())
+		plan.result(GuestManager, result)
+	}
+}
+
+// PlanSync collects changes syncing the account would make in regions of syncRange
+func (self *SCloudaccount) PlanSync(ctx context.Context, userCred mcclient.TokenCredential, syncRange *SSyncRange) (*SSyncPlan, error) {
+	regionIds, err := syncRange.GetRegionIds()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetRegionIds")
+	}
+	plan := NewSyncPlan(true)
+	providers := self.GetEnabledCloudproviders()
+	for i := range providers {
+		cprs := providers[i].GetCloudproviderRegions()
+		for j := range cprs {
+			if !cprs[j].Enabled || (len(regionIds) > 0 && !utils.IsInStringArray(cprs[j].CloudregionId, regionIds)) {
+				continue
+			}
+			cprPlan, err := cprs[j].PlanSync(ctx, userCred, syncRange)
+			if err != nil {
+				plan.error(errors.Wrapf(err, "plan sync of provider %s region %s", providers[i].Name, cprs[j].CloudregionId))
+				continue
+			}
+			plan.Merge(cprPlan)
+		}
+	}
+	return plan, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+)
+
+func TestSyncPlanRemovalExceeded(t *testing.T) {
+	plan := NewSyncPlan(true)
+	plan.Resources["servers"] = &api.SyncPlanItem{LocalCnt: 20, DelCnt: 12}
+	plan.Resources["disks"] = &api.SyncPlanItem{LocalCnt: 40, DelCnt: 4}
+	plan.Resources["vpcs"] = &api.SyncPlanItem{LocalCnt: 2, DelCnt: 2}
+
+	other := NewSyncPlan(true)
+	other.Resources["disks"] = &api.SyncPlanItem{LocalCnt: 10, DelCnt: 10}
+	other.Errors = []string{"GetIVMs for host h1 failed"}
+	plan.Merge(other)
+
+	if plan.Resources["disks"].LocalCnt != 50 || plan.Resources["disks"].DelCnt != 14 {
+		t.Errorf("merged disks %#v", plan.Resources["disks"])
+	}
+	if len(plan.Errors) != 1 {
+		t.Errorf("merged errors %v", plan.Errors)
+	}
+
+	cases := []struct {
+		threshold int
+		want      []string
+	}{
+		{0, []string{}},
+		{50, []string{"servers"}},
+		{20, []string{"disks", "servers"}},
+	}
+	for _, c := range cases {
+		got := plan.RemovalExceeded(c.threshold, 10)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("RemovalExceeded(%d) = %v, want %v", c.threshold, got, c.want)
+		}
+	}
+}
+
+func TestAppendSyncPlanSample(t *testing.T) {
+	samples := []api.SyncPlanSample{}
+	for i := 0; i < syncPlanSampleCount+3; i++ {
+		samples = appendSyncPlanSample(samples, api.SyncPlanSample{Name: "vm"})
+	}
+	if len(samples) != syncPlanSampleCount {
+		t.Errorf("want %d samples, got %d", syncPlanSampleCount, len(samples))
+	}
+}
+
+type fakeSyncPlanVM struct {
+	cloudprovider.ICloudVM
+	id   string
+	name string
+}
+
+func (vm *fakeSyncPlanVM) GetGlobalId() string { return vm.id }
+func (vm *fakeSyncPlanVM) GetName() string     { return vm.name }
+func (vm *fakeSyncPlanVM) GetStatus() string   { return api.VM_RUNNING }
+
+func newSyncPlanGuest(id, externalId string) *SGuest {
+	guest := &SGuest{}
+	guest.Id = id
+	guest.Name = id
+	guest.ExternalId = externalId
+	guest.Status = api.VM_RUNNING
+	return guest
+}
+
+func TestSyncPlanSettle(t *testing.T) {
+	plan := NewSyncPlan(true)
+	ctx := plan.withContext(context.Background())
+
+	// vm-1 migrated from host1 to host2, vm-2 is gone
+	host1 := newSyncPlanScope(ctx, GuestManager, 2)
+	vm1, vm2 := newSyncPlanGuest("g1", "vm-1"), newSyncPlanGuest("g2", "vm-2")
+	if !host1.Remove(vm1, nil) || !host1.Remove(vm2, nil) {
+		t.Fatalf("dry run should hold removals")
+	}
+	host2 := newSyncPlanScope(ctx, GuestManager, 1)
+	if !host2.Update(newSyncPlanGuest("g3", "vm-3"), &fakeSyncPlanVM{id: "vm-3", name: "g3"}) {
+		t.Fatalf("dry run should skip updates")
+	}
+	if !host2.Add(&fakeSyncPlanVM{id: "vm-1", name: "g1"}) {
+		t.Fatalf("dry run should skip additions")
+	}
+	plan.settle()
+
+	item := plan.Resources[GuestManager.KeywordPlural()]
+	want := api.SyncPlanItem{LocalCnt: 3, AddCnt: 0, UpdateCnt: 2, DelCnt: 1}
+	got := api.SyncPlanItem{LocalCnt: item.LocalCnt, AddCnt: item.AddCnt, UpdateCnt: item.UpdateCnt, DelCnt: item.DelCnt}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("settled guests %#v, want %#v", got, want)
+	}
+	if len(item.Removed) != 1 || item.Removed[0].ExternalId != "vm-2" || len(item.Added) != 0 {
+		t.Errorf("settled samples removed %v added %v", item.Removed, item.Added)
+	}
+	if len(plan.removals) != 1 || plan.removals[0].externalId != "vm-2" {
+		t.Errorf("settled removals %v", plan.removals)
+	}
+}
+
+func TestSyncPlanScopeWithoutPlan(t *testing.T) {
+	scope := newSyncPlanScope(context.Background(), GuestManager, 1)
+	if scope.Remove(newSyncPlanGuest("g1", "vm-1"), nil) || scope.Add(&fakeSyncPlanVM{id: "vm-1"}) || scope.IsDryRun() {
+		t.Errorf("sync without plan should go on as usual")
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// hours used to turn hourly prices into a monthly estimation
+const costHoursPerMonth = 730
+
+// SCostRateManager manage unit prices of on-premise resources
+type SCostRateManager struct {
+	db.SStandaloneResourceBaseManager
+}
+
+var CostRateManager *SCostRateManager
+
+func init() {
+	CostRateManager = &SCostRateManager{
+		SStandaloneResourceBaseManager: db.NewStandaloneResourceBaseManager(
+			SCostRate{},
+			"cost_rates_tbl",
+			"cost_rate",
+			"cost_rates",
+		),
+	}
+	CostRateManager.SetVirtualObject(CostRateManager)
+
+	ApprovalCostEstimator = CostRateManager.EstimateMonthlyCost
+}
+
+type SCostRate struct {
+	db.SStandaloneResourceBase
+
+	// 计量项
+	Metric string `width:"16" charset:"ascii" nullable:"false" list:"user" create:"admin_required"`
+	// 规格, 为空表示默认价格
+	Spec string `width:"64" charset:"utf8" nullable:"false" default:"" list:"user" create:"admin_optional"`
+	// 单价, 每单位每小时
+	Price float64 `nullable:"false" default:"0" list:"user" update:"admin" create:"admin_required"`
+	// 货币
+	Currency string `width:"8" charset:"ascii" nullable:"false" default:"CNY" list:"user" update:"admin" create:"admin_optional"`
+}
+
+func (manager *SCostRateManager) AllowListItems(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return true
+}
+
+func (self *SCostRate) AllowGetDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return true
+}
+
+func (manager *SCostRateManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.CostRateCreateInput,
+) (api.CostRateCreateInput, error) {
+	if !utils.IsInStringArray(input.Metric, api.COST_METRICS) {
+		return input, httperrors.NewInputParameterError("invalid metric %q, must be one of %s", input.Metric, api.COST_METRICS)
+	}
+	if input.Metric == api.COST_METRIC_DISK && len(input.Spec) > 0 && !utils.IsInStringArray(input.Spec, api.DISK_TYPES) {
+		return input, httperrors.NewInputParameterError("spec of disk must be one of %s", api.DISK_TYPES)
+	}
+	if input.Price < 0 {
+		return input, httperrors.NewInputParameterError("price must not be negative")
+	}
+	if len(input.Currency) == 0 {
+		input.Currency = api.COST_DEFAULT_CURRENCY
+	}
+	cnt, err := manager.Query().Equals("metric", input.Metric).Equals("spec", input.Spec).CountWithError()
+	if err != nil {
+		return input, httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return input, httperrors.NewDuplicateResourceError("price of %s %s already exists", input.Metric, input.Spec)
+	}
+	input.StandaloneResourceCreateInput, err = manager.SStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StandaloneResourceCreateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SStandaloneResourceBaseManager.ValidateCreateData")
+	}
+	return input, nil
+}
+
+func (self *SCostRate) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.CostRateUpdateInput,
+) (api.CostRateUpdateInput, error) {
+	if input.Price != nil && *input.Price < 0 {
+		return input, httperrors.NewInputParameterError("price must not be negative")
+	}
+	var err error
+	input.StandaloneResourceBaseUpdateInput, err = self.SStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.StandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SStandaloneResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
+// SCostRates is a snapshot of all prices, looked up by metric and spec
+type SCostRates map[string]map[string]SCostRate
+
+func (manager *SCostRateManager) FetchRates() (SCostRates, error) {
+	rates := []SCostRate{}
+	err := db.FetchModelObjects(manager, manager.Query(), &rates)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	ret := SCostRates{}
+	for i := range rates {
+		if _, ok := ret[rates[i].Metric]; !ok {
+			ret[rates[i].Metric] = map[string]SCostRate{}
+		}
+		ret[rates[i].Metric][rates[i].Spec] = rates[i]
+	}
+	return ret, nil
+}
+
+// Get return the price of the spec, the default price of the metric is used if the spec has no price
+func (rates SCostRates) Get(metric string, spec string) (float64, string) {
+	specs, ok := rates[metric]
+	if !ok {
+		return 0, api.COST_DEFAULT_CURRENCY
+	}
+	rate, ok := specs[spec]
+	if !ok {
+		rate, ok = specs[""]
+		if !ok {
+			return 0, api.COST_DEFAULT_CURRENCY
+		}
+	}
+	return rate.Price, rate.Currency
+}
+
+// EstimateMonthlyCost estimate how much the requested resources cost in a month
+func (manager *SCostRateManager) EstimateMonthlyCost(ctx context.Context, resourceType string, size api.ApprovalSize) (float64, error) {
+	rates, err := manager.FetchRates()
+	if err != nil {
+		return 0, errors.Wrap(err, "FetchRates")
+	}
+	if len(rates) == 0 {
+		return -1, nil
+	}
+	cpu, _ := rates.Get(api.COST_METRIC_CPU, "")
+	mem, _ := rates.Get(api.COST_METRIC_MEM, "")
+	disk, _ := rates.Get(api.COST_METRIC_DISK, "")
+	hourly := cpu*float64(size.VcpuCount) + mem*float64(size.VmemSizeMb)/1024 + disk*float64(size.DiskSizeMb)/1024
+	return hourly * costHoursPerMonth, nil
+}
+
+func (self *SCostRate) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, isList bool) (api.CostRateDetails, error) {
+	return api.CostRateDetails{}, nil
+}
+
+func (manager *SCostRateManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.CostRateDetails {
+	rows := make([]api.CostRateDetails, len(objs))
+	stdRows := manager.SStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.CostRateDetails{
+			StandaloneResourceDetails: stdRows[i],
+		}
+	}
+	return rows
+}
+
+// 价格列表
+func (manager *SCostRateManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.CostRateListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Metric) > 0 {
+		q = q.In("metric", query.Metric)
+	}
+	return q, nil
+}
+
+func (manager *SCostRateManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.CostRateListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
@@ -1313,7 +1313,12 @@ func (manager *SDiskManager) SyncDisks(ctx context.Context, userCred mcclient.To
 		return nil, nil, syncResult
 	}
 
+	plan := newSyncPlanScope(ctx, manager, len(dbDisks))
 	for i := 0; i < len(removed); i += 1 {
+		disk := &removed[i]
+		if plan.Remove(disk, func() error { return disk.syncRemoveCloudDisk(ctx, userCred) }) {
+			continue
+		}
 		err = removed[i].syncRemoveCloudDisk(ctx, userCred)
 		if err != nil {
 			syncResult.DeleteError(err)
@@ -1323,6 +1328,11 @@ func (manager *SDiskManager) SyncDisks(ctx context.Context, userCred mcclient.To
 	}
 
 	for i := 0; i < len(commondb); i += 1 {
+		if plan.Update(&commondb[i], commonext[i]) {
+			localDisks = append(localDisks, commondb[i])
+			remoteDisks = append(remoteDisks, commonext[i])
+			continue
+		}
 		err = commondb[i].syncWithCloudDisk(ctx, userCred, provider, commonext[i], -1, syncOwnerId, storage.ManagerId)
 		if err != nil {
 			syncResult.UpdateError(err)
@@ -1335,6 +1345,9 @@ func (manager *SDiskManager) SyncDisks(ctx context.Context, userCred mcclient.To
 	}
 
 	for i := 0; i < len(added); i += 1 {
+		if plan.Add(added[i]) {
+			continue
+		}
 		extId := added[i].GetGlobalId()
 		_disk, err := db.FetchByExternalIdAndManagerId(manager, extId, func(q *sqlchemy.SQuery) *sqlchemy.SQuery {
 			sq := StorageManager.Query().SubQuery()
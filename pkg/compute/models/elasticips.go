@@ -340,7 +340,12 @@ func (manager *SElasticipManager) SyncEips(ctx context.Context, userCred mcclien
 		return syncResult
 	}
 
+	plan := newSyncPlanScope(ctx, manager, len(dbEips))
 	for i := 0; i < len(removed); i += 1 {
+		eip := &removed[i]
+		if plan.Remove(eip, func() error { return eip.syncRemoveCloudEip(ctx, userCred) }) {
+			continue
+		}
 		err = removed[i].syncRemoveCloudEip(ctx, userCred)
 		if err != nil {
 			syncResult.DeleteError(err)
@@ -349,6 +354,9 @@ func (manager *SElasticipManager) SyncEips(ctx context.Context, userCred mcclien
 		}
 	}
 	for i := 0; i < len(commondb); i += 1 {
+		if plan.Update(&commondb[i], commonext[i]) {
+			continue
+		}
 		err = commondb[i].SyncWithCloudEip(ctx, userCred, provider, commonext[i], syncOwnerId)
 		if err != nil {
 			syncResult.UpdateError(err)
@@ -358,6 +366,9 @@ func (manager *SElasticipManager) SyncEips(ctx context.Context, userCred mcclien
 		}
 	}
 	for i := 0; i < len(added); i += 1 {
+		if plan.Add(added[i]) {
+			continue
+		}
 		new, err := manager.newFromCloudEip(ctx, userCred, added[i], provider, region, syncOwnerId)
 		if err != nil {
 			syncResult.AddError(err)
@@ -51,6 +51,7 @@ import (
 	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
 	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
 	"yunion.io/x/onecloud/pkg/cloudcommon/userdata"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
 	"yunion.io/x/onecloud/pkg/cloudprovider"
 	guestdriver_types "yunion.io/x/onecloud/pkg/compute/guestdrivers/types"
 	"yunion.io/x/onecloud/pkg/compute/options"
@@ -463,6 +464,122 @@ func (self *SGuest) StartGuestLiveMigrateTask(ctx context.Context, userCred mccl
 	return nil
 }
 
+func (self *SGuest) AllowPerformChangeDiskStorage(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "change-disk-storage")
+}
+
+// 在线更换虚拟机磁盘所在的存储
+func (self *SGuest) PerformChangeDiskStorage(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerChangeDiskStorageInput) (jsonutils.JSONObject, error) {
+	if self.Status != api.VM_RUNNING {
+		return nil, httperrors.NewInvalidStatusError("Cannot change disk storage in status %s", self.Status)
+	}
+	if len(self.BackupHostId) > 0 {
+		return nil, httperrors.NewBadRequestError("Cannot change disk storage of guest with backup")
+	}
+
+	if len(input.DiskId) == 0 {
+		return nil, httperrors.NewMissingParameterError("disk_id")
+	}
+	diskObj, err := validators.ValidateModel(userCred, DiskManager, &input.DiskId)
+	if err != nil {
+		return nil, err
+	}
+	disk := diskObj.(*SDisk)
+	if self.GetGuestDisk(disk.Id) == nil {
+		return nil, httperrors.NewBadRequestError("Disk %s not attached to guest %s", disk.Name, self.Name)
+	}
+	if disk.Status != api.DISK_READY {
+		return nil, httperrors.NewInvalidStatusError("Cannot change storage of disk in status %s", disk.Status)
+	}
+
+	if len(input.TargetStorageId) == 0 {
+		return nil, httperrors.NewMissingParameterError("target_storage_id")
+	}
+	storageObj, err := validators.ValidateModel(userCred, StorageManager, &input.TargetStorageId)
+	if err != nil {
+		return nil, err
+	}
+	targetStorage := storageObj.(*SStorage)
+	if targetStorage.Id == disk.StorageId {
+		return nil, httperrors.NewBadRequestError("Disk %s already on storage %s", disk.Name, targetStorage.Name)
+	}
+	if err := self.GetDriver().ValidateChangeDiskStorage(ctx, userCred, self, disk, targetStorage); err != nil {
+		return nil, err
+	}
+
+	internalInput := &api.ServerChangeDiskStorageInternalInput{
+		ServerChangeDiskStorageInput: input,
+		StorageId:                    disk.StorageId,
+		GuestStatus:                  self.Status,
+	}
+	return nil, self.StartChangeDiskStorageTask(ctx, userCred, disk, targetStorage, internalInput, "")
+}
+
+func (self *SGuest) StartChangeDiskStorageTask(ctx context.Context, userCred mcclient.TokenCredential, disk *SDisk, targetStorage *SStorage, input *api.ServerChangeDiskStorageInternalInput, parentTaskId string) error {
+	diskConfig := &api.DiskConfig{
+		SizeMb:   disk.DiskSize,
+		Format:   disk.DiskFormat,
+		DiskType: disk.DiskType,
+		Backend:  targetStorage.StorageType,
+		Medium:   targetStorage.MediumType,
+		Storage:  targetStorage.Id,
+	}
+	// target disk is created empty and filled by drive mirror,
+	// image and fs info are copied from source disk after mirror completed
+	targetDisk, err := targetStorage.createDisk(ctx, disk.Name, diskConfig, userCred, self.GetOwnerId(), disk.AutoDelete, disk.IsSystem, disk.BillingType, disk.BillingCycle)
+	if err != nil {
+		return errors.Wrap(err, "create target disk")
+	}
+	input.TargetDiskId = targetDisk.Id
+
+	self.SetStatus(userCred, api.VM_DISK_CHANGE_STORAGE, fmt.Sprintf("change disk %s to storage %s", disk.Name, targetStorage.Name))
+	task, err := taskman.TaskManager.NewTask(ctx, "GuestChangeDiskStorageTask", self, userCred, jsonutils.Marshal(input).(*jsonutils.JSONDict), parentTaskId, "", nil)
+	if err != nil {
+		return err
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
+func (self *SGuest) AllowPerformCancelChangeDiskStorage(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "cancel-change-disk-storage")
+}
+
+// 取消正在进行的磁盘存储更换
+func (self *SGuest) PerformCancelChangeDiskStorage(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if self.Status != api.VM_DISK_CHANGE_STORAGE {
+		return nil, httperrors.NewInvalidStatusError("Guest %s not in status %s", self.Name, api.VM_DISK_CHANGE_STORAGE)
+	}
+	diskId, err := self.getChangingStorageDiskId()
+	if err != nil {
+		return nil, err
+	}
+	if err := self.GetDriver().RequestCancelChangeDiskStorage(ctx, userCred, self, diskId); err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	db.OpsLog.LogEvent(self, db.ACT_DISK_CHANGE_STORAGE_CANCEL, diskId, userCred)
+	return nil, nil
+}
+
+// getChangingStorageDiskId returns the disk moved by the running change disk storage task
+func (self *SGuest) getChangingStorageDiskId() (string, error) {
+	tasks, err := taskman.TaskManager.FetchIncompleteTasksOfObject(self)
+	if err != nil {
+		return "", httperrors.NewGeneralError(errors.Wrap(err, "FetchIncompleteTasksOfObject"))
+	}
+	for i := range tasks {
+		if tasks[i].TaskName != "GuestChangeDiskStorageTask" {
+			continue
+		}
+		input := api.ServerChangeDiskStorageInternalInput{}
+		if err := tasks[i].Params.Unmarshal(&input); err != nil {
+			return "", httperrors.NewGeneralError(errors.Wrap(err, "unmarshal task params"))
+		}
+		return input.DiskId, nil
+	}
+	return "", httperrors.NewInvalidStatusError("no change disk storage task of guest %s", self.Name)
+}
+
 func (self *SGuest) AllowPerformClone(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
 	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "clone")
 }
@@ -1830,6 +1947,9 @@ func (self *SGuest) PerformDetachIsolatedDevice(ctx context.Context, userCred mc
 		lockman.LockObject(ctx, host)
 		defer lockman.ReleaseObject(ctx, host)
 		for i := 0; i < len(devs); i++ {
+			if devs[i].IsSriovVf() {
+				continue
+			}
 			err := self.detachIsolateDevice(ctx, userCred, &devs[i])
 			if err != nil {
 				return nil, err
@@ -1864,6 +1984,11 @@ func (self *SGuest) detachIsolateDevice(ctx context.Context, userCred mcclient.T
 		logclient.AddActionLogWithContext(ctx, self, logclient.ACT_GUEST_DETACH_ISOLATED_DEVICE, msg, userCred, false)
 		return httperrors.NewBadRequestError(msg)
 	}
+	if dev.IsSriovVf() {
+		msg := "SR-IOV VF is released along with guest network"
+		logclient.AddActionLogWithContext(ctx, self, logclient.ACT_GUEST_DETACH_ISOLATED_DEVICE, msg, userCred, false)
+		return httperrors.NewBadRequestError(msg)
+	}
 	_, err := db.Update(dev, func() error {
 		dev.GuestId = ""
 		return nil
@@ -1963,6 +2088,9 @@ func (self *SGuest) attachIsolatedDevice(ctx context.Context, userCred mcclient.
 	if len(dev.GuestId) > 0 {
 		return fmt.Errorf("Isolated device already attached to another guest: %s", dev.GuestId)
 	}
+	if dev.IsSriovVf() {
+		return fmt.Errorf("SR-IOV VF should be attached by guest network with driver %s", api.NETWORK_DRIVER_VFIO)
+	}
 	if dev.HostId != self.HostId {
 		return fmt.Errorf("Isolated device and guest are not located in the same host")
 	}
@@ -2267,6 +2395,17 @@ func (self *SGuest) PerformAttachnetwork(ctx context.Context, userCred mcclient.
 		if err != nil {
 			return nil, err
 		}
+		if input.Nets[i].Driver == api.NETWORK_DRIVER_VFIO && self.Status != api.VM_READY {
+			return nil, httperrors.NewInvalidStatusError("Only allowed to attach SR-IOV VF network when guest is ready")
+		}
+		if input.Nets[i].Driver == api.NETWORK_DRIVER_VHOST_USER {
+			if self.Status != api.VM_READY {
+				return nil, httperrors.NewInvalidStatusError("Only allowed to attach vhost-user network when guest is ready")
+			}
+			if host := self.GetHost(); host == nil || len(host.OvsDpdkVersion) == 0 {
+				return nil, httperrors.NewNotSupportedError("host of guest doesn't support OVS-DPDK datapath")
+			}
+		}
 		if IsExitNetworkInfo(input.Nets[i]) {
 			enicCnt = count
 			// ebw = input.BwLimit
@@ -5245,3 +5384,73 @@ func (self *SGuest) PerformListForward(ctx context.Context, userCred mcclient.To
 	}
 	return resp.JSON(), nil
 }
+
+// identityDocumentClockSkew tolerates clock difference between host and region
+const identityDocumentClockSkew = 5 * time.Minute
+
+// checkIdentityDocumentTime rejects documents without validity period, issued in the future or expired
+func checkIdentityDocumentTime(doc *api.InstanceIdentityDocument, now time.Time) error {
+	if doc.IssuedAt.IsZero() || doc.ExpiresAt.IsZero() {
+		return fmt.Errorf("document has no issued_at or expires_at")
+	}
+	if doc.IssuedAt.After(now.Add(identityDocumentClockSkew)) {
+		return fmt.Errorf("document issued at %s in the future", doc.IssuedAt)
+	}
+	if now.After(doc.ExpiresAt.Add(identityDocumentClockSkew)) {
+		return fmt.Errorf("document expired at %s", doc.ExpiresAt)
+	}
+	return nil
+}
+
+func (manager *SGuestManager) AllowPerformVerifyIdentityDocument(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerVerifyIdentityDocumentInput) bool {
+	return db.IsProjectAllowClassPerform(userCred, manager, "verify-identity-document")
+}
+
+// PerformVerifyIdentityDocument verifies instance identity document got from metadata service
+// against public key of the host signing it
+func (manager *SGuestManager) PerformVerifyIdentityDocument(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerVerifyIdentityDocumentInput) (*api.ServerVerifyIdentityDocumentOutput, error) {
+	if len(input.Document) == 0 {
+		return nil, httperrors.NewMissingParameterError("document")
+	}
+	if len(input.Signature) == 0 {
+		return nil, httperrors.NewMissingParameterError("signature")
+	}
+	docJson, err := jsonutils.ParseString(input.Document)
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("invalid document: %v", err)
+	}
+	doc := &api.InstanceIdentityDocument{}
+	err = docJson.Unmarshal(doc)
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("invalid document: %v", err)
+	}
+	output := &api.ServerVerifyIdentityDocumentOutput{Document: doc}
+	host := HostManager.FetchHostById(doc.HostId)
+	if host == nil {
+		output.Reason = fmt.Sprintf("host %s not found", doc.HostId)
+		return output, nil
+	}
+	pubKey := host.GetMetadata("identity_public_key", nil)
+	if len(pubKey) == 0 {
+		output.Reason = fmt.Sprintf("host %s has no identity public key", host.Name)
+		return output, nil
+	}
+	err = seclib2.VerifySHA256(pubKey, []byte(input.Document), input.Signature)
+	if err != nil {
+		output.Reason = fmt.Sprintf("signature mismatch: %v", err)
+		return output, nil
+	}
+	err = checkIdentityDocumentTime(doc, time.Now())
+	if err != nil {
+		output.Reason = err.Error()
+		return output, nil
+	}
+	guest := manager.FetchGuestById(doc.InstanceId)
+	if guest == nil {
+		output.Reason = fmt.Sprintf("guest %s not found", doc.InstanceId)
+		return output, nil
+	}
+	output.Valid = true
+	output.CurrentHostId = guest.HostId
+	return output, nil
+}
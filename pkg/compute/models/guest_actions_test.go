@@ -0,0 +1,68 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestCheckIdentityDocumentTime(t *testing.T) {
+	now := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name      string
+		issuedAt  time.Time
+		expiresAt time.Time
+		wantErr   bool
+	}{
+		{
+			name:      "valid",
+			issuedAt:  now.Add(-time.Minute),
+			expiresAt: now.Add(9 * time.Minute),
+		},
+		{
+			name:      "host clock slightly ahead",
+			issuedAt:  now.Add(time.Minute),
+			expiresAt: now.Add(11 * time.Minute),
+		},
+		{
+			name:    "no validity period",
+			wantErr: true,
+		},
+		{
+			name:      "issued in the future",
+			issuedAt:  now.Add(time.Hour),
+			expiresAt: now.Add(time.Hour + 10*time.Minute),
+			wantErr:   true,
+		},
+		{
+			name:      "expired",
+			issuedAt:  now.Add(-time.Hour),
+			expiresAt: now.Add(-50 * time.Minute),
+			wantErr:   true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc := &api.InstanceIdentityDocument{IssuedAt: c.issuedAt, ExpiresAt: c.expiresAt}
+			err := checkIdentityDocumentTime(doc, now)
+			if (err != nil) != c.wantErr {
+				t.Errorf("checkIdentityDocumentTime() error = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+func (self *SGuest) AllowPerformBindInstanceRole(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerBindInstanceRoleInput) bool {
+	// binding grants the role to whoever runs in guest, so only admin can do it
+	return db.IsAdminAllowPerform(userCred, self, "bind-instance-role")
+}
+
+// 为虚拟机绑定实例角色，虚拟机内可通过metadata服务获取该角色的短期token
+func (self *SGuest) PerformBindInstanceRole(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerBindInstanceRoleInput) (jsonutils.JSONObject, error) {
+	if self.Hypervisor != api.HYPERVISOR_KVM {
+		return nil, httperrors.NewNotSupportedError("instance role is not supported by %s", self.Hypervisor)
+	}
+	if len(input.Role) == 0 {
+		return nil, httperrors.NewMissingParameterError("role")
+	}
+	role, err := db.RoleCacheManager.FetchRoleByIdOrName(ctx, input.Role)
+	if err != nil {
+		return nil, httperrors.NewResourceNotFoundError2("role", input.Role)
+	}
+	projectId := self.ProjectId
+	if len(input.Project) > 0 {
+		project, err := db.TenantCacheManager.FetchTenantByIdOrName(ctx, input.Project)
+		if err != nil {
+			return nil, httperrors.NewResourceNotFoundError2("project", input.Project)
+		}
+		projectId = project.Id
+	}
+	err = self.SetAllMetadata(ctx, map[string]interface{}{
+		api.VM_METADATA_INSTANCE_ROLE_ID:         role.Id,
+		api.VM_METADATA_INSTANCE_ROLE_PROJECT_ID: projectId,
+	}, userCred)
+	if err != nil {
+		return nil, errors.Wrap(err, "SetAllMetadata")
+	}
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_VM_BIND_INSTANCE_ROLE, input, userCred, true)
+	return nil, nil
+}
+
+func (self *SGuest) AllowPerformUnbindInstanceRole(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "unbind-instance-role")
+}
+
+// 解除虚拟机绑定的实例角色，已签发的token在过期前仍然有效
+func (self *SGuest) PerformUnbindInstanceRole(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	for _, key := range []string{api.VM_METADATA_INSTANCE_ROLE_ID, api.VM_METADATA_INSTANCE_ROLE_PROJECT_ID} {
+		err := self.RemoveMetadata(ctx, key, userCred)
+		if err != nil {
+			return nil, errors.Wrapf(err, "RemoveMetadata %s", key)
+		}
+	}
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_VM_UNBIND_INSTANCE_ROLE, nil, userCred, true)
+	return nil, nil
+}
+
+func (self *SGuest) AllowPerformInstanceCredential(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	// called by host agent on behalf of guest
+	return db.IsAdminAllowPerform(userCred, self, "instance-credential")
+}
+
+// 为绑定了实例角色的虚拟机签发短期token, 由宿主机metadata服务调用
+func (self *SGuest) PerformInstanceCredential(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (*api.ServerInstanceCredentialOutput, error) {
+	roleId := self.GetMetadata(api.VM_METADATA_INSTANCE_ROLE_ID, nil)
+	if len(roleId) == 0 {
+		return nil, httperrors.NewNotFoundError("no instance role bound to server %s", self.Name)
+	}
+	projectId := self.GetMetadata(api.VM_METADATA_INSTANCE_ROLE_PROJECT_ID, nil)
+	if len(projectId) == 0 {
+		projectId = self.ProjectId
+	}
+	if hostId, _ := data.GetString("host_id"); len(hostId) > 0 && hostId != self.HostId {
+		return nil, httperrors.NewForbiddenError("server %s is not on host %s", self.Name, hostId)
+	}
+	token, err := auth.Client().AuthenticateInstance(auth.AdminCredential().GetTokenString(), self.Id, roleId, projectId)
+	if err != nil {
+		return nil, errors.Wrap(err, "AuthenticateInstance")
+	}
+	return &api.ServerInstanceCredentialOutput{
+		Token:     jsonutils.Marshal(token),
+		ExpiresAt: token.GetExpires(),
+	}, nil
+}
@@ -159,6 +159,7 @@ var HypervisorBrandMap = map[string]string{
 	computeapis.HYPERVISOR_ZSTACK:    computeapis.CLOUD_PROVIDER_ZSTACK,
 	computeapis.HYPERVISOR_GOOGLE:    computeapis.CLOUD_PROVIDER_GOOGLE,
 	computeapis.HYPERVISOR_CTYUN:     computeapis.CLOUD_PROVIDER_CTYUN,
+	computeapis.HYPERVISOR_PROXMOX:   computeapis.CLOUD_PROVIDER_PROXMOX,
 }
 
 var BrandHypervisorMap = map[string]string{
@@ -174,6 +175,7 @@ var BrandHypervisorMap = map[string]string{
 	computeapis.CLOUD_PROVIDER_ZSTACK:    computeapis.HYPERVISOR_ZSTACK,
 	computeapis.CLOUD_PROVIDER_GOOGLE:    computeapis.HYPERVISOR_GOOGLE,
 	computeapis.CLOUD_PROVIDER_CTYUN:     computeapis.HYPERVISOR_CTYUN,
+	computeapis.CLOUD_PROVIDER_PROXMOX:   computeapis.HYPERVISOR_PROXMOX,
 }
 
 func Hypervisor2Brand(hypervisor string) string {
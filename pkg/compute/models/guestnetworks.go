@@ -629,6 +629,9 @@ func (self *SGuestnetwork) ValidateUpdateData(
 ) (api.GuestnetworkUpdateInput, error) {
 	if input.Index != nil {
 		index := *input.Index
+		if self.Driver == api.NETWORK_DRIVER_VFIO && index != self.Index {
+			return input, httperrors.NewUnsupportOperationError("cannot change index of nic with SR-IOV VF")
+		}
 		q := GuestnetworkManager.Query().SubQuery()
 		count, err := q.Query().Filter(sqlchemy.Equals(q.Field("guest_id"), self.GuestId)).
 			Filter(sqlchemy.NotEquals(q.Field("network_id"), self.NetworkId)).
@@ -660,6 +663,12 @@ func (manager *SGuestnetworkManager) DeleteGuestNics(ctx context.Context, userCr
 				// netman.get_manager().netmap_remove_node(gn.ip_addr)
 			}
 		}
+		if gn.Driver == api.NETWORK_DRIVER_VFIO {
+			err := IsolatedDeviceManager.releaseSriovVfsOfGuestnetwork(ctx, userCred, guest, &gn)
+			if err != nil {
+				log.Errorf("release SR-IOV VF of guest %s nic %d: %s", guest.Name, gn.Index, err)
+			}
+		}
 		// ??
 		// gn.Delete(ctx, userCred)
 		err := gn.Delete(ctx, userCred)
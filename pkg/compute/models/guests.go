@@ -1490,6 +1490,15 @@ func (manager *SGuestManager) validateCreateData(
 		return nil, httperrors.NewInputParameterError("Invalid userdata: %v", err)
 	}
 
+	if len(input.ConfigDrive) > 0 {
+		if !utils.IsInStringArray(input.ConfigDrive, api.CONFIG_DRIVE_FORMATS) {
+			return nil, httperrors.NewInputParameterError("config_drive should be one of %s", api.CONFIG_DRIVE_FORMATS)
+		}
+		if input.Hypervisor != api.HYPERVISOR_KVM {
+			return nil, httperrors.NewNotSupportedError("config_drive is not supported by hypervisor %s", input.Hypervisor)
+		}
+	}
+
 	err = manager.ValidatePolicyDefinitions(ctx, userCred, ownerId, query, input)
 	if err != nil {
 		return nil, err
@@ -1820,6 +1829,10 @@ func (guest *SGuest) PostCreate(ctx context.Context, userCred mcclient.TokenCred
 	if len(userData) > 0 {
 		guest.setUserData(ctx, userCred, userData)
 	}
+	configDrive, _ := data.GetString("config_drive")
+	if len(configDrive) > 0 {
+		guest.SetMetadata(ctx, api.VM_METADATA_CONFIG_DRIVE, configDrive, userCred)
+	}
 	secgroups, _ := jsonutils.GetStringArray(data, "secgroups")
 	for _, secgroupId := range secgroups {
 		if secgroupId != guest.SecgrpId {
@@ -2874,6 +2887,13 @@ func (self *SGuest) attach2NetworkOnce(
 	if err != nil {
 		return nil, errors.Wrap(err, "GuestnetworkManager.newGuestNetwork")
 	}
+	if guestnic.Driver == api.NETWORK_DRIVER_VFIO {
+		err = IsolatedDeviceManager.attachSriovVfToGuestnetwork(ctx, userCred, self, guestnic, args.network)
+		if err != nil {
+			GuestnetworkManager.DeleteGuestNics(ctx, userCred, []SGuestnetwork{*guestnic}, false)
+			return nil, errors.Wrap(err, "attachSriovVfToGuestnetwork")
+		}
+	}
 	var (
 		network      = args.network
 		pendingUsage = args.pendingUsage
@@ -5408,13 +5428,17 @@ func (self *SGuest) ToIsolatedDevicesConfig() []*api.IsolatedDeviceConfig {
 	if len(guestIsolatedDevices) == 0 {
 		return nil
 	}
-	ret := make([]*api.IsolatedDeviceConfig, len(guestIsolatedDevices))
-	for idx, guestIsolatedDevice := range guestIsolatedDevices {
+	ret := make([]*api.IsolatedDeviceConfig, 0, len(guestIsolatedDevices))
+	for _, guestIsolatedDevice := range guestIsolatedDevices {
+		if guestIsolatedDevice.IsSriovVf() {
+			// allocated along with guest network
+			continue
+		}
 		devConf := new(api.IsolatedDeviceConfig)
 		devConf.Model = guestIsolatedDevice.Model
 		devConf.Vendor = guestIsolatedDevice.getVendor()
 		devConf.DevType = guestIsolatedDevice.DevType
-		ret[idx] = devConf
+		ret = append(ret, devConf)
 	}
 	return ret
 }
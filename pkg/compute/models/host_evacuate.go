@@ -0,0 +1,399 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/baremetal/utils/ipmitool"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/redfish"
+	_ "yunion.io/x/onecloud/pkg/util/redfish/loader"
+)
+
+const (
+	HOST_METADATA_FENCE_METHOD_ON_HOST_DOWN = "__fence_method_on_host_down"
+)
+
+func (host *SHost) GetFenceMethodOnHostDown() string {
+	method := host.GetMetadata(HOST_METADATA_FENCE_METHOD_ON_HOST_DOWN, nil)
+	if len(method) == 0 {
+		return api.HOST_FENCE_METHOD_NONE
+	}
+	return method
+}
+
+func (host *SHost) IsEvacuating() bool {
+	return utils.IsInStringArray(host.Status, []string{api.HOST_START_EVACUATE, api.HOST_FENCING, api.HOST_EVACUATING})
+}
+
+func (host *SHost) validateFenceMethod(method string) error {
+	if !utils.IsInStringArray(method, api.HOST_FENCE_METHODS) {
+		return httperrors.NewInputParameterError("invalid fence method %q, want %s", method, api.HOST_FENCE_METHODS)
+	}
+	if method == api.HOST_FENCE_METHOD_IPMI {
+		info, err := host.GetIpmiInfo()
+		if err != nil {
+			return httperrors.NewInternalServerError("get ipmi info: %v", err)
+		}
+		if len(info.IpAddr) == 0 || len(info.Username) == 0 || len(info.Password) == 0 {
+			return httperrors.NewInputParameterError("host %s has no valid ipmi information", host.Name)
+		}
+	}
+	return nil
+}
+
+// FenceHost make sure the down host can not write shared storage any more
+func (host *SHost) FenceHost(ctx context.Context, method string) error {
+	switch method {
+	case api.HOST_FENCE_METHOD_IPMI:
+		return host.fenceByIpmi(ctx)
+	case api.HOST_FENCE_METHOD_WATCHDOG:
+		// host health manager of hostman shutdown servers and exit on losing health lease
+		time.Sleep(time.Duration(options.Options.HostFenceWatchdogWait) * time.Second)
+		return nil
+	case api.HOST_FENCE_METHOD_NONE:
+		return nil
+	}
+	return errors.Wrapf(httperrors.ErrNotSupported, "fence method %s", method)
+}
+
+func (host *SHost) fenceByIpmi(ctx context.Context) error {
+	info, err := host.GetIpmiInfo()
+	if err != nil {
+		return errors.Wrap(err, "GetIpmiInfo")
+	}
+	password, err := utils.DescryptAESBase64(host.Id, info.Password)
+	if err != nil {
+		return errors.Wrap(err, "descrypt ipmi password")
+	}
+	deadline := time.Now().Add(time.Duration(options.Options.HostFenceIpmiTimeout) * time.Second)
+	if info.RedfishApi {
+		drv := redfish.NewRedfishDriver(ctx, "https://"+info.IpAddr, info.Username, password, false)
+		if drv != nil {
+			if err := drv.Reset(ctx, "ForceOff"); err != nil {
+				return errors.Wrap(err, "redfish reset ForceOff")
+			}
+			for time.Now().Before(deadline) {
+				_, sysInfo, err := drv.GetSystemInfo(ctx)
+				if err == nil && sysInfo.PowerState == "Off" {
+					return nil
+				}
+				time.Sleep(5 * time.Second)
+			}
+			return errors.Wrapf(errors.ErrTimeout, "wait host %s power off", host.Name)
+		}
+		log.Warningf("no redfish driver found for host %s, fallback to ipmitool", host.Name)
+	}
+	cli := ipmitool.NewLanPlusIPMI(info.IpAddr, info.Username, password)
+	if err := ipmitool.DoHardShutdown(cli); err != nil {
+		return errors.Wrap(err, "ipmitool power off")
+	}
+	for time.Now().Before(deadline) {
+		status, err := ipmitool.GetChassisPowerStatus(cli)
+		if err == nil && status == "off" {
+			return nil
+		}
+		time.Sleep(5 * time.Second)
+	}
+	return errors.Wrapf(errors.ErrTimeout, "wait host %s power off", host.Name)
+}
+
+func (self *SGuest) GetHaPriority() int {
+	priority, _ := strconv.Atoi(self.GetMetadata(api.VM_METADATA_HA_PRIORITY, nil))
+	return priority
+}
+
+// getEvacuateGuests return guests on host sorted by ha priority, higher priority first
+func (host *SHost) getEvacuateGuests() ([]SGuest, error) {
+	guests, err := host.GetGuests()
+	if err != nil {
+		return nil, errors.Wrapf(err, "host %s(%s) get guests", host.Name, host.Id)
+	}
+	priorities := make(map[string]int, len(guests))
+	for i := range guests {
+		priorities[guests[i].Id] = guests[i].GetHaPriority()
+	}
+	sortGuestsByHaPriority(guests, priorities)
+	return guests, nil
+}
+
+func sortGuestsByHaPriority(guests []SGuest, priorities map[string]int) {
+	sort.SliceStable(guests, func(i, j int) bool {
+		return priorities[guests[i].Id] > priorities[guests[j].Id]
+	})
+}
+
+// batchGuestsByHaPriority split guest ids already sorted by ha priority into
+// batches of the same priority, a batch is rebuilt after the previous one finished
+func batchGuestsByHaPriority(guestIds []string, priorities map[string]int) [][]string {
+	batches := [][]string{}
+	for i, guestId := range guestIds {
+		if i == 0 || priorities[guestId] != priorities[guestIds[i-1]] {
+			batches = append(batches, []string{})
+		}
+		batches[len(batches)-1] = append(batches[len(batches)-1], guestId)
+	}
+	return batches
+}
+
+const (
+	evacuateSkip = iota
+	evacuateMigrate
+	evacuateRebuild
+)
+
+// getEvacuateAction decide how to recover guest on the down host, migrateErr
+// is the result of validating the guest for rescue mode migration
+func getEvacuateAction(guest *SGuest, migrateErr error, rebuildLocal bool) int {
+	if len(guest.BackupHostId) > 0 {
+		// handled by switch to backup
+		return evacuateSkip
+	}
+	if utils.IsInStringArray(guest.Status, []string{api.VM_START_MIGRATE, api.VM_MIGRATING}) {
+		// already being migrated by a previous evacuation
+		return evacuateSkip
+	}
+	if migrateErr == nil {
+		return evacuateMigrate
+	}
+	if rebuildLocal && guest.Hypervisor == api.HYPERVISOR_KVM {
+		return evacuateRebuild
+	}
+	return evacuateSkip
+}
+
+// PrepareEvacuateGuests split guests on the down host into guests to be migrated in rescue mode
+// and batches of guests with local disks to be rebuilt from instance snapshot, both in ha priority order
+func (host *SHost) PrepareEvacuateGuests(
+	ctx context.Context, userCred mcclient.TokenCredential, rebuildLocal bool,
+) ([]*api.GuestBatchMigrateParams, [][]string, error) {
+	guests, err := host.getEvacuateGuests()
+	if err != nil {
+		return nil, nil, err
+	}
+	migrates := []*api.GuestBatchMigrateParams{}
+	rebuilds := []string{}
+	priorities := map[string]int{}
+	for i := 0; i < len(guests); i++ {
+		guest := &guests[i]
+		if len(guest.GetMetadata(api.VM_METADATA_HA_REBUILT_GUEST, nil)) > 0 {
+			// already rebuilt by a previous evacuation
+			continue
+		}
+		lockman.LockObject(ctx, guest)
+		_, err := guest.validateForBatchMigrate(ctx, true)
+		switch getEvacuateAction(guest, err, rebuildLocal) {
+		case evacuateMigrate:
+			migrates = append(migrates, &api.GuestBatchMigrateParams{
+				Id:          guest.Id,
+				LiveMigrate: false,
+				RescueMode:  true,
+				OldStatus:   guest.Status,
+			})
+			guest.SetStatus(userCred, api.VM_START_MIGRATE, "host evacuate")
+		case evacuateRebuild:
+			if _, err := guest.GetLatestInstanceSnapshotWithoutHost(host.Id); err == nil {
+				rebuilds = append(rebuilds, guest.Id)
+				priorities[guest.Id] = guest.GetHaPriority()
+			} else {
+				db.OpsLog.LogEvent(guest, db.ACT_GUEST_HA_REBUILD_FAIL, err.Error(), userCred)
+			}
+		}
+		lockman.ReleaseObject(ctx, guest)
+	}
+	return migrates, batchGuestsByHaPriority(rebuilds, priorities), nil
+}
+
+func (self *SInstanceSnapshot) isAvailableWithoutHost(hostId string) bool {
+	snapshots, err := self.GetSnapshots()
+	if err != nil || len(snapshots) == 0 {
+		return false
+	}
+	for i := range snapshots {
+		storage := snapshots[i].GetStorage()
+		if storage == nil {
+			return false
+		}
+		available := false
+		hosts := storage.GetAttachedHosts()
+		for j := range hosts {
+			if hosts[j].Id != hostId && hosts[j].HostStatus == api.HOST_ONLINE && hosts[j].Enabled.IsTrue() {
+				available = true
+				break
+			}
+		}
+		if !available {
+			return false
+		}
+	}
+	return true
+}
+
+// GetLatestInstanceSnapshotWithoutHost return the latest ready instance snapshot
+// whose disk snapshots still can be read without the given host
+func (self *SGuest) GetLatestInstanceSnapshotWithoutHost(hostId string) (*SInstanceSnapshot, error) {
+	q := InstanceSnapshotManager.Query().Equals("guest_id", self.Id).
+		Equals("status", api.INSTANCE_SNAPSHOT_READY).Desc("created_at")
+	isps := make([]SInstanceSnapshot, 0)
+	if err := db.FetchModelObjects(InstanceSnapshotManager, q, &isps); err != nil {
+		return nil, errors.Wrap(err, "fetch instance snapshots")
+	}
+	for i := range isps {
+		if isps[i].isAvailableWithoutHost(hostId) {
+			return &isps[i], nil
+		}
+	}
+	return nil, errors.Wrapf(errors.ErrNotFound, "no available instance snapshot for guest %s", self.Name)
+}
+
+// HaRebuildFromInstanceSnapshot create a new guest from the latest available instance snapshot
+// to take place of the guest on down host
+func (self *SGuest) HaRebuildFromInstanceSnapshot(ctx context.Context, userCred mcclient.TokenCredential) (*SGuest, error) {
+	isp, err := self.GetLatestInstanceSnapshotWithoutHost(self.HostId)
+	if err != nil {
+		return nil, err
+	}
+	params := jsonutils.NewDict()
+	params.Set("name", jsonutils.NewString(fmt.Sprintf("%s-ha", self.Name)))
+	params.Set("hypervisor", jsonutils.NewString(self.Hypervisor))
+	params.Set("auto_start", jsonutils.JSONTrue)
+	newGuest, input, err := GuestManager.CreateGuestFromInstanceSnapshot(ctx, userCred, params, isp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create guest from instance snapshot %s", isp.Name)
+	}
+	isp.AddRefCount(ctx)
+	self.SetMetadata(ctx, api.VM_METADATA_HA_REBUILT_GUEST, newGuest.Id, userCred)
+	newGuest.SetMetadata(ctx, api.VM_METADATA_HA_REBUILT_FROM, self.Id, userCred)
+	GuestManager.OnCreateComplete(ctx, []db.IModel{newGuest}, userCred, userCred, nil, input)
+	db.OpsLog.LogEvent(self, db.ACT_GUEST_HA_REBUILD,
+		fmt.Sprintf("rebuild as %s from instance snapshot %s", newGuest.Name, isp.Name), userCred)
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_VM_HA_REBUILD, newGuest.Id, userCred, true)
+	return newGuest, nil
+}
+
+func (host *SHost) StartEvacuateTask(
+	ctx context.Context, userCred mcclient.TokenCredential, input api.HostEvacuateInput, parentTaskId string,
+) error {
+	params := jsonutils.Marshal(input).(*jsonutils.JSONDict)
+	params.Set("origin_status", jsonutils.NewString(host.Status))
+	host.SetStatus(userCred, api.HOST_START_EVACUATE, "")
+	task, err := taskman.TaskManager.NewTask(ctx, "HostEvacuateTask", host, userCred, params, parentTaskId, "", nil)
+	if err != nil {
+		return err
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
+func (host *SHost) AllowPerformEvacuate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, host, "evacuate")
+}
+
+// 宿主机宕机后隔离宿主机, 并按优先级恢复其上的虚拟机
+func (host *SHost) PerformEvacuate(
+	ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.HostEvacuateInput,
+) (jsonutils.JSONObject, error) {
+	if host.HostType != api.HOST_TYPE_HYPERVISOR {
+		return nil, httperrors.NewNotSupportedError("host type %s not support evacuate", host.HostType)
+	}
+	if host.HostStatus != api.HOST_OFFLINE {
+		return nil, httperrors.NewInvalidStatusError("host %s status %s can't evacuate", host.Name, host.HostStatus)
+	}
+	if host.IsEvacuating() {
+		return nil, httperrors.NewInvalidStatusError("host %s is evacuating", host.Name)
+	}
+	if len(input.FenceMethod) == 0 {
+		input.FenceMethod = api.HOST_FENCE_METHOD_NONE
+	}
+	if err := host.validateFenceMethod(input.FenceMethod); err != nil {
+		return nil, err
+	}
+	if len(input.PreferHostId) > 0 {
+		if _, err := validators.ValidateModel(userCred, HostManager, &input.PreferHostId); err != nil {
+			return nil, err
+		}
+		if input.PreferHostId == host.Id {
+			return nil, httperrors.NewInputParameterError("prefer host can't be the evacuating host")
+		}
+	}
+	if err := validateEvacuateRebuildLocal(&input); err != nil {
+		return nil, err
+	}
+	return nil, host.StartEvacuateTask(ctx, userCred, input, "")
+}
+
+// validateEvacuateRebuildLocal only allow rebuilding guests with local storage
+// when the down host will be confirmed powered off, otherwise the origin guests
+// may be still running and the rebuilt ones cause split brain
+func validateEvacuateRebuildLocal(input *api.HostEvacuateInput) error {
+	if input.RebuildLocalGuests == nil {
+		rebuild := false
+		input.RebuildLocalGuests = &rebuild
+	}
+	if *input.RebuildLocalGuests && input.FenceMethod != api.HOST_FENCE_METHOD_IPMI {
+		return httperrors.NewInputParameterError("rebuild_local_guests requires fence method %s", api.HOST_FENCE_METHOD_IPMI)
+	}
+	return nil
+}
+
+// StartEvacuateRebuildTask create the task rebuilding batches of guests one after another,
+// the caller schedules it after all sibling subtasks are created
+func (host *SHost) StartEvacuateRebuildTask(
+	ctx context.Context, userCred mcclient.TokenCredential, batches [][]string, parentTaskId string,
+) (*taskman.STask, error) {
+	params := jsonutils.NewDict()
+	params.Set("rebuild_batches", jsonutils.Marshal(batches))
+	return taskman.TaskManager.NewTask(ctx, "HostEvacuateRebuildTask", host, userCred, params, parentTaskId, "", nil)
+}
+
+func (self *SGuest) StartHaRebuildTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) (*taskman.STask, error) {
+	return taskman.TaskManager.NewTask(ctx, "GuestHaRebuildTask", self, userCred, nil, parentTaskId, "", nil)
+}
+
+func (self *SGuest) AllowPerformSetHaPriority(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "set-ha-priority")
+}
+
+// 设置宿主机宕机后虚拟机的恢复优先级
+func (self *SGuest) PerformSetHaPriority(
+	ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerSetHaPriorityInput,
+) (jsonutils.JSONObject, error) {
+	if input.Priority < api.VM_HA_PRIORITY_MIN || input.Priority > api.VM_HA_PRIORITY_MAX {
+		return nil, httperrors.NewOutOfRangeError("priority should be in range %d-%d", api.VM_HA_PRIORITY_MIN, api.VM_HA_PRIORITY_MAX)
+	}
+	if err := self.SetMetadata(ctx, api.VM_METADATA_HA_PRIORITY, strconv.Itoa(input.Priority), userCred); err != nil {
+		return nil, err
+	}
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_VM_SET_HA_PRIORITY, input, userCred, true)
+	return nil, nil
+}
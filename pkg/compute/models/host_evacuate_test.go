@@ -0,0 +1,110 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestValidateEvacuateRebuildLocal(t *testing.T) {
+	yes, no := true, false
+	cases := []struct {
+		name        string
+		fenceMethod string
+		rebuild     *bool
+		want        bool
+		wantErr     bool
+	}{
+		{"default without fence", api.HOST_FENCE_METHOD_NONE, nil, false, false},
+		{"default with ipmi", api.HOST_FENCE_METHOD_IPMI, nil, false, false},
+		{"rebuild with ipmi", api.HOST_FENCE_METHOD_IPMI, &yes, true, false},
+		{"rebuild without fence", api.HOST_FENCE_METHOD_NONE, &yes, true, true},
+		{"rebuild with watchdog", api.HOST_FENCE_METHOD_WATCHDOG, &yes, true, true},
+		{"no rebuild", api.HOST_FENCE_METHOD_NONE, &no, false, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			input := api.HostEvacuateInput{FenceMethod: c.fenceMethod, RebuildLocalGuests: c.rebuild}
+			err := validateEvacuateRebuildLocal(&input)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("want error %v, got %v", c.wantErr, err)
+			}
+			if input.RebuildLocalGuests == nil || *input.RebuildLocalGuests != c.want {
+				t.Errorf("want rebuild_local_guests %v, got %v", c.want, input.RebuildLocalGuests)
+			}
+		})
+	}
+}
+
+func TestGetEvacuateAction(t *testing.T) {
+	errLocal := errors.Error("can't rescue guest with local storage")
+	cases := []struct {
+		name         string
+		guest        SGuest
+		status       string
+		migrateErr   error
+		rebuildLocal bool
+		want         int
+	}{
+		{"shared storage", SGuest{Hypervisor: api.HYPERVISOR_KVM}, "", nil, false, evacuateMigrate},
+		{"local storage", SGuest{Hypervisor: api.HYPERVISOR_KVM}, "", errLocal, false, evacuateSkip},
+		{"local storage rebuild", SGuest{Hypervisor: api.HYPERVISOR_KVM}, "", errLocal, true, evacuateRebuild},
+		{"has backup", SGuest{Hypervisor: api.HYPERVISOR_KVM, BackupHostId: "backup"}, "", nil, true, evacuateSkip},
+		{"not kvm", SGuest{Hypervisor: api.HYPERVISOR_BAREMETAL}, "", errLocal, true, evacuateSkip},
+		{"migrating", SGuest{Hypervisor: api.HYPERVISOR_KVM}, api.VM_START_MIGRATE, nil, true, evacuateSkip},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.guest.Status = c.status
+			if got := getEvacuateAction(&c.guest, c.migrateErr, c.rebuildLocal); got != c.want {
+				t.Errorf("want action %d, got %d", c.want, got)
+			}
+		})
+	}
+}
+
+func TestSortGuestsByHaPriority(t *testing.T) {
+	guests := []SGuest{}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		guest := SGuest{}
+		guest.Id = id
+		guests = append(guests, guest)
+	}
+	sortGuestsByHaPriority(guests, map[string]int{"b": 10, "c": -1, "d": 10})
+	ids := []string{}
+	for i := range guests {
+		ids = append(ids, guests[i].Id)
+	}
+	// same priority keeps the origin order
+	if want := []string{"b", "d", "a", "c"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("want %v, got %v", want, ids)
+	}
+}
+
+func TestBatchGuestsByHaPriority(t *testing.T) {
+	priorities := map[string]int{"a": 10, "b": 10, "c": 5, "d": 0, "e": 0}
+	batches := batchGuestsByHaPriority([]string{"a", "b", "c", "d", "e"}, priorities)
+	if want := [][]string{{"a", "b"}, {"c"}, {"d", "e"}}; !reflect.DeepEqual(batches, want) {
+		t.Errorf("want %v, got %v", want, batches)
+	}
+	if batches := batchGuestsByHaPriority(nil, priorities); len(batches) != 0 {
+		t.Errorf("want no batch, got %v", batches)
+	}
+}
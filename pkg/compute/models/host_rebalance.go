@@ -0,0 +1,109 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+const (
+	// migrations of the latest rebalance round related to the host, stored in
+	// db so that every region replica can answer, not only the cron leader
+	HOST_METADATA_REBALANCE_PLAN = "__rebalance_plan"
+)
+
+// groupRebalanceMigrationsByHost return migrations related to each host, as source or destination
+func groupRebalanceMigrationsByHost(migrations []api.HostRebalanceMigration) map[string][]api.HostRebalanceMigration {
+	plans := map[string][]api.HostRebalanceMigration{}
+	for _, m := range migrations {
+		plans[m.SrcHostId] = append(plans[m.SrcHostId], m)
+		if m.DstHostId != m.SrcHostId {
+			plans[m.DstHostId] = append(plans[m.DstHostId], m)
+		}
+	}
+	return plans
+}
+
+// SaveRebalancePlan replace the plan of the latest rebalance round
+func (manager *SHostManager) SaveRebalancePlan(ctx context.Context, userCred mcclient.TokenCredential, migrations []api.HostRebalanceMigration) error {
+	plans := groupRebalanceMigrationsByHost(migrations)
+	// hosts not involved in the latest round drop their stale plans
+	q := db.Metadata.Query("obj_id").Equals("obj_type", manager.Keyword()).
+		Equals("key", HOST_METADATA_REBALANCE_PLAN).IsFalse("deleted")
+	rows, err := q.Rows()
+	if err != nil {
+		return errors.Wrap(err, "query rebalance plans")
+	}
+	stale := []string{}
+	for rows.Next() {
+		var hostId string
+		if err := rows.Scan(&hostId); err != nil {
+			rows.Close()
+			return errors.Wrap(err, "scan rebalance plans")
+		}
+		if _, ok := plans[hostId]; !ok {
+			stale = append(stale, hostId)
+		}
+	}
+	rows.Close()
+	for _, hostId := range stale {
+		plans[hostId] = nil
+	}
+
+	for hostId, plan := range plans {
+		host := manager.FetchHostById(hostId)
+		if host == nil {
+			continue
+		}
+		var value interface{} = "none"
+		if len(plan) > 0 {
+			value = jsonutils.Marshal(plan)
+		}
+		if err := host.SetMetadata(ctx, HOST_METADATA_REBALANCE_PLAN, value, userCred); err != nil {
+			log.Errorf("save rebalance plan of host %s: %s", host.Name, err)
+		}
+	}
+	return nil
+}
+
+func (self *SHost) AllowGetDetailsRebalancePlan(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowGetSpec(userCred, self, "rebalance-plan")
+}
+
+// 获取最近一轮负载均衡计划中与该宿主机相关的迁移
+func (self *SHost) GetDetailsRebalancePlan(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if !options.Options.EnableRebalance {
+		return nil, httperrors.NewNotSupportedError("rebalance controller is not enabled")
+	}
+	out := api.HostRebalancePlanOutput{
+		Migrations: []api.HostRebalanceMigration{},
+	}
+	if plan := self.GetMetadataJson(HOST_METADATA_REBALANCE_PLAN, nil); plan != nil {
+		if err := plan.Unmarshal(&out.Migrations); err != nil {
+			return nil, httperrors.NewInternalServerError("unmarshal rebalance plan: %v", err)
+		}
+	}
+	return jsonutils.Marshal(out), nil
+}
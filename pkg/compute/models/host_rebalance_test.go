@@ -0,0 +1,47 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestGroupRebalanceMigrationsByHost(t *testing.T) {
+	plans := groupRebalanceMigrationsByHost([]api.HostRebalanceMigration{
+		{GuestId: "g1", SrcHostId: "h1", DstHostId: "h2"},
+		{GuestId: "g2", SrcHostId: "h3", DstHostId: "h1"},
+		{GuestId: "g3", SrcHostId: "h3", DstHostId: "h2"},
+	})
+	want := map[string][]string{
+		"h1": {"g1", "g2"},
+		"h2": {"g1", "g3"},
+		"h3": {"g2", "g3"},
+	}
+	if len(plans) != len(want) {
+		t.Fatalf("want plans of %d hosts, got %v", len(want), plans)
+	}
+	for hostId, guestIds := range want {
+		got := []string{}
+		for _, m := range plans[hostId] {
+			got = append(got, m.GuestId)
+		}
+		if !reflect.DeepEqual(got, guestIds) {
+			t.Errorf("host %s want migrations of %v, got %v", hostId, guestIds, got)
+		}
+	}
+}
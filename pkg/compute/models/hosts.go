@@ -150,6 +150,20 @@ type SHost struct {
 	// IPMI详情
 	IpmiInfo jsonutils.JSONObject `nullable:"true" get:"domain" update:"domain" create:"domain_optional"`
 
+	// 固件清单, 通过Redfish UpdateService/FirmwareInventory采集
+	FirmwareInventory jsonutils.JSONObject `nullable:"true" get:"domain" update:"domain"`
+
+	// 关联的BIOS模板
+	BiosProfileId string `width:"36" charset:"ascii" nullable:"true" list:"domain"`
+	// BIOS与模板的一致状态
+	BiosProfileStatus string `width:"16" charset:"ascii" nullable:"true" list:"domain" update:"domain"`
+	// BIOS与模板不一致的属性
+	BiosDrift jsonutils.JSONObject `nullable:"true" get:"domain" update:"domain"`
+
+	// 硬件健康状态, 由BMC的Redfish事件更新
+	// example: ok
+	HardwareHealth string `width:"16" charset:"ascii" nullable:"true" list:"domain" update:"domain"`
+
 	// 宿主机状态
 	// example: online
 	HostStatus string `width:"16" charset:"ascii" nullable:"false" default:"offline" list:"domain"`
@@ -161,6 +175,8 @@ type SHost struct {
 	Version string `width:"64" charset:"ascii" list:"domain" update:"domain" create:"domain_optional"`
 	// OVN软件版本
 	OvnVersion string `width:"64" charset:"ascii" list:"domain" update:"domain" create:"domain_optional"`
+	// OVS-DPDK数据面的DPDK版本, 为空表示不支持
+	OvsDpdkVersion string `width:"64" charset:"ascii" list:"domain" update:"domain" create:"domain_optional"`
 
 	IsBaremetal bool `nullable:"true" default:"false" list:"domain" update:"domain" create:"domain_optional"`
 
@@ -413,6 +429,25 @@ func (manager *SHostManager) ListItemFilter(
 	if len(query.OvnVersion) > 0 {
 		q = q.In("ovn_version", query.OvnVersion)
 	}
+	if len(query.OvsDpdkVersion) > 0 {
+		q = q.In("ovs_dpdk_version", query.OvsDpdkVersion)
+	}
+	if len(query.BiosProfileId) > 0 {
+		profileObj, err := BiosProfileManager.FetchByIdOrName(userCred, query.BiosProfileId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(BiosProfileManager.Keyword(), query.BiosProfileId)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		q = q.Equals("bios_profile_id", profileObj.GetId())
+	}
+	if len(query.BiosProfileStatus) > 0 {
+		q = q.In("bios_profile_status", query.BiosProfileStatus)
+	}
+	if len(query.HardwareHealth) > 0 {
+		q = q.In("hardware_health", query.HardwareHealth)
+	}
 	if query.IsMaintenance != nil {
 		if *query.IsMaintenance {
 			q = q.IsTrue("is_maintenance")
@@ -1669,10 +1704,15 @@ func (manager *SHostManager) SyncHosts(ctx context.Context, userCred mcclient.To
 		return nil, nil, syncResult
 	}
 
+	plan := newSyncPlanScope(ctx, manager, len(dbHosts))
 	for i := 0; i < len(removed); i += 1 {
 		if removed[i].IsPrepaidRecycleResource() {
 			continue
 		}
+		host := &removed[i]
+		if plan.Remove(host, func() error { return host.syncRemoveCloudHost(ctx, userCred) }) {
+			continue
+		}
 		err = removed[i].syncRemoveCloudHost(ctx, userCred)
 		if err != nil {
 			syncResult.DeleteError(err)
@@ -1681,6 +1721,11 @@ func (manager *SHostManager) SyncHosts(ctx context.Context, userCred mcclient.To
 		}
 	}
 	for i := 0; i < len(commondb); i += 1 {
+		if plan.Update(&commondb[i], commonext[i]) {
+			localHosts = append(localHosts, commondb[i])
+			remoteHosts = append(remoteHosts, commonext[i])
+			continue
+		}
 		err = commondb[i].syncWithCloudHost(ctx, userCred, commonext[i], provider)
 		if err != nil {
 			syncResult.UpdateError(err)
@@ -1691,6 +1736,9 @@ func (manager *SHostManager) SyncHosts(ctx context.Context, userCred mcclient.To
 		}
 	}
 	for i := 0; i < len(added); i += 1 {
+		if plan.Add(added[i]) {
+			continue
+		}
 		new, err := manager.newFromCloudHost(ctx, userCred, added[i], provider, zone)
 		if err != nil {
 			syncResult.AddError(err)
@@ -2050,7 +2098,9 @@ func (self *SHost) SyncHostStorages(ctx context.Context, userCred mcclient.Token
 	for i := 0; i < len(hostStorages); i += 1 {
 		storage := hostStorages[i].GetStorage()
 		if storage == nil {
-			hostStorages[i].Delete(ctx, userCred)
+			if !isSyncDryRun(ctx) {
+				hostStorages[i].Delete(ctx, userCred)
+			}
 		} else {
 			dbStorages = append(dbStorages, *storage)
 		}
@@ -2069,14 +2119,23 @@ func (self *SHost) SyncHostStorages(ctx context.Context, userCred mcclient.Token
 		return nil, nil, syncResult
 	}
 
+	plan := newSyncPlanScope(ctx, HoststorageManager, len(dbStorages))
 	for i := 0; i < len(removed); i += 1 {
 		log.Infof("host %s not connected with %s any more, to detach...", self.Id, removed[i].Id)
-		err := self.syncRemoveCloudHostStorage(ctx, userCred, &removed[i])
-		if errors.Cause(err) == ErrStorageInUse && removed[i].StorageType == api.STORAGE_LOCAL {
-			removed[i].SetStatus(userCred, api.STORAGE_OFFLINE, "the only host used this local storage has detached")
-			// prevent generating a delete error for syncResult
+		storage := &removed[i]
+		detach := func() error {
+			err := self.syncRemoveCloudHostStorage(ctx, userCred, storage)
+			if errors.Cause(err) == ErrStorageInUse && storage.StorageType == api.STORAGE_LOCAL {
+				storage.SetStatus(userCred, api.STORAGE_OFFLINE, "the only host used this local storage has detached")
+				// prevent generating a delete error for syncResult
+				return nil
+			}
+			return err
+		}
+		if plan.Remove(storage, detach) {
 			continue
 		}
+		err := detach()
 		if err != nil {
 			syncResult.DeleteError(err)
 		} else {
@@ -2086,6 +2145,11 @@ func (self *SHost) SyncHostStorages(ctx context.Context, userCred mcclient.Token
 
 	for i := 0; i < len(commondb); i += 1 {
 		log.Infof("host %s is still connected with %s, to update ...", self.Id, commondb[i].Id)
+		if plan.Update(&commondb[i], commonext[i]) {
+			localStorages = append(localStorages, commondb[i])
+			remoteStorages = append(remoteStorages, commonext[i])
+			continue
+		}
 		err := self.syncWithCloudHostStorage(ctx, userCred, &commondb[i], commonext[i], provider)
 		if err != nil {
 			syncResult.UpdateError(err)
@@ -2098,6 +2162,9 @@ func (self *SHost) SyncHostStorages(ctx context.Context, userCred mcclient.Token
 
 	for i := 0; i < len(added); i += 1 {
 		log.Infof("host %s is found connected with %s, to add ...", self.Id, added[i].GetId())
+		if plan.Add(added[i]) {
+			continue
+		}
 		local, err := self.newCloudHostStorage(ctx, userCred, added[i], provider)
 		if err != nil {
 			syncResult.AddError(err)
@@ -2328,7 +2395,12 @@ func (self *SHost) SyncHostVMs(ctx context.Context, userCred mcclient.TokenCrede
 		return nil, syncResult
 	}
 
+	plan := newSyncPlanScope(ctx, GuestManager, len(dbVMs))
 	for i := 0; i < len(removed); i += 1 {
+		guest := &removed[i]
+		if plan.Remove(guest, func() error { return guest.syncRemoveCloudVM(ctx, userCred) }) {
+			continue
+		}
 		err := removed[i].syncRemoveCloudVM(ctx, userCred)
 		if err != nil {
 			syncResult.DeleteError(err)
@@ -2338,6 +2410,9 @@ func (self *SHost) SyncHostVMs(ctx context.Context, userCred mcclient.TokenCrede
 	}
 
 	for i := 0; i < len(commondb); i += 1 {
+		if plan.Update(&commondb[i], commonext[i]) {
+			continue
+		}
 		err := commondb[i].syncWithCloudVM(ctx, userCred, iprovider, self, commonext[i], syncOwnerId)
 		if err != nil {
 			syncResult.UpdateError(err)
@@ -2353,6 +2428,9 @@ func (self *SHost) SyncHostVMs(ctx context.Context, userCred mcclient.TokenCrede
 	}
 
 	for i := 0; i < len(added); i += 1 {
+		if plan.Add(added[i]) {
+			continue
+		}
 		vm, err := db.FetchByExternalIdAndManagerId(GuestManager, added[i].GetGlobalId(), func(q *sqlchemy.SQuery) *sqlchemy.SQuery {
 			sq := HostManager.Query().SubQuery()
 			return q.Join(sq, sqlchemy.Equals(sq.Field("id"), q.Field("host_id"))).Filter(sqlchemy.Equals(sq.Field("manager_id"), self.ManagerId))
@@ -2934,6 +3012,7 @@ func (self *SHost) getMoreDetails(ctx context.Context, out api.HostDetails, show
 	}
 	if self.GetMetadata("__auto_migrate_on_host_down", nil) == "enable" {
 		out.AutoMigrateOnHostDown = true
+		out.FenceMethodOnHostDown = self.GetFenceMethodOnHostDown()
 	}
 
 	if count, rs := self.GetReservedResourceForIsolatedDevice(); rs != nil {
@@ -3470,6 +3549,13 @@ func (self *SHost) ValidateUpdateData(ctx context.Context, userCred mcclient.Tok
 		val.Update(ipmiInfoJson)
 		input.IpmiInfo = val
 	}
+	if len(input.HardwareHealth) > 0 && !utils.IsInStringArray(input.HardwareHealth, []string{
+		api.HOST_HARDWARE_HEALTH_OK,
+		api.HOST_HARDWARE_HEALTH_WARNING,
+		api.HOST_HARDWARE_HEALTH_CRITICAL,
+	}) {
+		return input, httperrors.NewInputParameterError("invalid hardware_health %q", input.HardwareHealth)
+	}
 	input.EnabledStatusInfrasResourceBaseUpdateInput, err = self.SEnabledStatusInfrasResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusInfrasResourceBaseUpdateInput)
 	if err != nil {
 		return input, errors.Wrap(err, "SEnabledStatusInfrasResourceBase.ValidateUpdateData")
@@ -3589,6 +3675,9 @@ func fetchIpmiInfo(data api.HostIpmiAttributes, hostId string) (types.SIPMIInfo,
 	if data.IpmiPxeBoot != nil {
 		info.PxeBoot = *data.IpmiPxeBoot
 	}
+	if data.IpmiRedfishRaid != nil {
+		info.RedfishRaid = *data.IpmiRedfishRaid
+	}
 	return info, nil
 }
 
@@ -3844,15 +3933,20 @@ func (self *SHost) AllowPerformAutoMigrateOnHostDown(ctx context.Context,
 }
 
 func (self *SHost) PerformAutoMigrateOnHostDown(
-	ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject,
+	ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.HostAutoMigrateOnHostDownInput,
 ) (jsonutils.JSONObject, error) {
-	val, _ := data.GetString("auto_migrate_on_host_down")
-
 	var meta map[string]interface{}
-	if val == "enable" {
+	if input.AutoMigrateOnHostDown == "enable" {
+		if len(input.FenceMethod) == 0 {
+			input.FenceMethod = api.HOST_FENCE_METHOD_NONE
+		}
+		if err := self.validateFenceMethod(input.FenceMethod); err != nil {
+			return nil, err
+		}
 		meta = map[string]interface{}{
-			"__auto_migrate_on_host_down": "enable",
-			"__on_host_down":              "shutdown-servers",
+			"__auto_migrate_on_host_down":           "enable",
+			"__on_host_down":                        "shutdown-servers",
+			HOST_METADATA_FENCE_METHOD_ON_HOST_DOWN: input.FenceMethod,
 		}
 		_, err := self.Request(ctx, userCred, "POST", "/hosts/shutdown-servers-on-host-down",
 			mcclient.GetTokenHeaders(userCred), nil)
@@ -3861,8 +3955,9 @@ func (self *SHost) PerformAutoMigrateOnHostDown(
 		}
 	} else {
 		meta = map[string]interface{}{
-			"__auto_migrate_on_host_down": "disable",
-			"__on_host_down":              "",
+			"__auto_migrate_on_host_down":           "disable",
+			"__on_host_down":                        "",
+			HOST_METADATA_FENCE_METHOD_ON_HOST_DOWN: "",
 		}
 	}
 
@@ -5451,39 +5546,25 @@ func (host *SHost) switchWithBackup(ctx context.Context, userCred mcclient.Token
 
 func (host *SHost) migrateOnHostDown(ctx context.Context, userCred mcclient.TokenCredential) {
 	if host.GetMetadata("__auto_migrate_on_host_down", nil) == "enable" {
-		if err := host.MigrateSharedStorageServers(ctx, userCred); err != nil {
-			db.OpsLog.LogEvent(host, db.ACT_HOST_DOWN, fmt.Sprintf("migrate servers failed %s", err), userCred)
+		lockman.LockObject(ctx, host)
+		defer lockman.ReleaseObject(ctx, host)
+		if host.IsEvacuating() {
+			// guests are being recovered by a running evacuation
+			log.Infof("host %s is evacuating, skip migrating guests on host down", host.Name)
+			return
 		}
-	}
-}
-
-func (host *SHost) MigrateSharedStorageServers(ctx context.Context, userCred mcclient.TokenCredential) error {
-	guests, err := host.GetGuests()
-	if err != nil {
-		return errors.Wrapf(err, "host %s(%s) get guests", host.Name, host.Id)
-	}
-	hostGuests := []*api.GuestBatchMigrateParams{}
-
-	for i := 0; i < len(guests); i++ {
-		lockman.LockObject(ctx, &guests[i])
-		defer lockman.ReleaseObject(ctx, &guests[i])
-		_, err := guests[i].validateForBatchMigrate(ctx, true)
-		if err != nil {
-			continue
-		} else {
-			bmp := &api.GuestBatchMigrateParams{
-				Id:          guests[i].Id,
-				LiveMigrate: false,
-				RescueMode:  true,
-				OldStatus:   guests[i].Status,
-			}
-			guests[i].SetStatus(userCred, api.VM_START_MIGRATE, "host down")
-			hostGuests = append(hostGuests, bmp)
+		// only rebuild guests with local storage when the host is fenced,
+		// otherwise the origin guests may be still running
+		fenceMethod := host.GetFenceMethodOnHostDown()
+		rebuildLocal := fenceMethod == api.HOST_FENCE_METHOD_IPMI
+		input := api.HostEvacuateInput{
+			FenceMethod:        fenceMethod,
+			RebuildLocalGuests: &rebuildLocal,
+		}
+		if err := host.StartEvacuateTask(ctx, userCred, input, ""); err != nil {
+			db.OpsLog.LogEvent(host, db.ACT_HOST_DOWN, fmt.Sprintf("evacuate servers failed %s", err), userCred)
 		}
 	}
-	kwargs := jsonutils.NewDict()
-	kwargs.Set("guests", jsonutils.Marshal(hostGuests))
-	return GuestManager.StartHostGuestsMigrateTask(ctx, userCred, host, kwargs, "")
 }
 
 func (host *SHost) SetStatus(userCred mcclient.TokenCredential, status string, reason string) error {
@@ -5690,6 +5771,167 @@ func (self *SHost) StartEjectIsoTask(ctx context.Context, userCred mcclient.Toke
 	}
 }
 
+func (self *SHost) AllowPerformFirmwareUpdate(ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, self, "firmware-update")
+}
+
+func (self *SHost) PerformFirmwareUpdate(
+	ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.HostFirmwareUpdateInput,
+) (jsonutils.JSONObject, error) {
+	if !self.IsBaremetal {
+		return nil, httperrors.NewBadRequestError("Firmware update is only supported for baremetal")
+	}
+	if !utils.IsInStringArray(self.Status, []string{api.BAREMETAL_READY, api.BAREMETAL_RUNNING, api.BAREMETAL_FIRMWARE_UPDATE_FAIL}) {
+		return nil, httperrors.NewInvalidStatusError("Cannot do firmware-update in status %s", self.Status)
+	}
+	ipmiInfo, err := self.GetIpmiInfo()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	if !ipmiInfo.RedfishApi {
+		return nil, httperrors.NewNotSupportedError("BMC of host doesn't support Redfish API")
+	}
+	if len(input.ImageUri) == 0 {
+		return nil, httperrors.NewMissingParameterError("image_uri")
+	}
+	if len(input.Method) == 0 {
+		input.Method = api.FIRMWARE_UPDATE_METHOD_SIMPLE
+	}
+	if !utils.IsInStringArray(input.Method, api.FIRMWARE_UPDATE_METHODS) {
+		return nil, httperrors.NewInputParameterError("invalid method %s, must be one of %s", input.Method, api.FIRMWARE_UPDATE_METHODS)
+	}
+	if input.MaintenanceWindowStart.IsZero() != input.MaintenanceWindowEnd.IsZero() {
+		return nil, httperrors.NewInputParameterError("maintenance_window_start and maintenance_window_end must be set together")
+	}
+	if !input.MaintenanceWindowEnd.IsZero() {
+		if !input.MaintenanceWindowEnd.After(input.MaintenanceWindowStart) {
+			return nil, httperrors.NewInputParameterError("maintenance_window_end must be after maintenance_window_start")
+		}
+		if input.MaintenanceWindowEnd.Before(time.Now()) {
+			return nil, httperrors.NewInputParameterError("maintenance window has passed")
+		}
+		// baremetal agent only waits for the window in memory for a limited time
+		maxWait := time.Duration(options.Options.FirmwareMaintenanceWindowMaxWaitSeconds) * time.Second
+		if input.AutoReboot && time.Until(input.MaintenanceWindowStart) > maxWait {
+			return nil, httperrors.NewInputParameterError("maintenance window must start within %s, or update without auto_reboot and reboot in the window manually", maxWait)
+		}
+	}
+	return nil, self.StartFirmwareUpdateTask(ctx, userCred, jsonutils.Marshal(input).(*jsonutils.JSONDict), "")
+}
+
+func (self *SHost) StartFirmwareUpdateTask(ctx context.Context, userCred mcclient.TokenCredential, data *jsonutils.JSONDict, parentTaskId string) error {
+	self.SetStatus(userCred, api.BAREMETAL_START_FIRMWARE_UPDATE, "start firmware update task")
+	if task, err := taskman.TaskManager.NewTask(ctx, "BaremetalFirmwareUpdateTask", self, userCred, data, parentTaskId, "", nil); err != nil {
+		log.Errorln(err)
+		return err
+	} else {
+		task.ScheduleRun(nil)
+		return nil
+	}
+}
+
+func (self *SHost) AllowPerformApplyBiosProfile(ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, self, "apply-bios-profile")
+}
+
+// 关联BIOS模板, 下发与模板不一致的BIOS属性并检查是否生效
+func (self *SHost) PerformApplyBiosProfile(
+	ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.HostApplyBiosProfileInput,
+) (jsonutils.JSONObject, error) {
+	if !self.IsBaremetal {
+		return nil, httperrors.NewBadRequestError("BIOS profile is only supported for baremetal")
+	}
+	if !utils.IsInStringArray(self.Status, []string{api.BAREMETAL_READY, api.BAREMETAL_RUNNING, api.BAREMETAL_BIOS_APPLY_FAIL}) {
+		return nil, httperrors.NewInvalidStatusError("Cannot do apply-bios-profile in status %s", self.Status)
+	}
+	ipmiInfo, err := self.GetIpmiInfo()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	if !ipmiInfo.RedfishApi {
+		return nil, httperrors.NewNotSupportedError("BMC of host doesn't support Redfish API")
+	}
+	if len(input.BiosProfileId) == 0 {
+		input.BiosProfileId = self.BiosProfileId
+	}
+	if len(input.BiosProfileId) == 0 {
+		return nil, httperrors.NewMissingParameterError("bios_profile_id")
+	}
+	profileObj, err := BiosProfileManager.FetchByIdOrName(userCred, input.BiosProfileId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, httperrors.NewResourceNotFoundError2(BiosProfileManager.Keyword(), input.BiosProfileId)
+		}
+		return nil, httperrors.NewGeneralError(err)
+	}
+	profile := profileObj.(*SBiosProfile)
+	if !profile.GetEnabled() {
+		return nil, httperrors.NewInvalidStatusError("bios profile %s is disabled", profile.Name)
+	}
+	if !profile.IsApplicable(self) {
+		return nil, httperrors.NewConflictError("bios profile %s is for manufacture %s", profile.Name, profile.Manufacture)
+	}
+	if self.BiosProfileId != profile.Id {
+		_, err = db.Update(self, func() error {
+			self.BiosProfileId = profile.Id
+			self.BiosProfileStatus = api.HOST_BIOS_PROFILE_DRIFT
+			self.BiosDrift = nil
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "update bios_profile_id")
+		}
+		db.OpsLog.LogEvent(self, db.ACT_UPDATE, fmt.Sprintf("attach bios profile %s", profile.Name), userCred)
+	}
+	params := jsonutils.NewDict()
+	params.Add(profile.GetAttributes(), "attributes")
+	params.Add(jsonutils.NewBool(input.AutoReboot), "auto_reboot")
+	params.Add(jsonutils.NewBool(input.CheckOnly), "check_only")
+	return nil, self.StartBiosApplyTask(ctx, userCred, params, "")
+}
+
+func (self *SHost) StartBiosApplyTask(ctx context.Context, userCred mcclient.TokenCredential, data *jsonutils.JSONDict, parentTaskId string) error {
+	self.SetStatus(userCred, api.BAREMETAL_START_BIOS_APPLY, "start bios apply task")
+	if task, err := taskman.TaskManager.NewTask(ctx, "BaremetalBiosApplyTask", self, userCred, data, parentTaskId, "", nil); err != nil {
+		log.Errorln(err)
+		return err
+	} else {
+		task.ScheduleRun(nil)
+		return nil
+	}
+}
+
+func (self *SHost) AllowPerformDetachBiosProfile(ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, self, "detach-bios-profile")
+}
+
+// 解除关联BIOS模板, 不会修改BIOS当前设置
+func (self *SHost) PerformDetachBiosProfile(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if len(self.BiosProfileId) == 0 {
+		return nil, nil
+	}
+	_, err := db.Update(self, func() error {
+		self.BiosProfileId = ""
+		self.BiosProfileStatus = ""
+		self.BiosDrift = nil
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "clear bios_profile_id")
+	}
+	db.OpsLog.LogEvent(self, db.ACT_UPDATE, "detach bios profile", userCred)
+	return nil, nil
+}
+
 func (self *SHost) AllowPerformSyncConfig(ctx context.Context,
 	userCred mcclient.TokenCredential,
 	query jsonutils.JSONObject,
@@ -16,6 +16,7 @@ package models
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 	"time"
@@ -95,6 +96,14 @@ type SIsolatedDevice struct {
 
 	VendorDeviceId string `width:"16" charset:"ascii" nullable:"true" list:"domain" create:"domain_optional"`
 
+	// # wire of SR-IOV physical function, only for SRIOV-VF
+	// 二层网络Id
+	WireId string `width:"36" charset:"ascii" nullable:"true" index:"true" list:"domain" create:"domain_optional" update:"domain"`
+
+	// # index of guest network which passthrough this SRIOV-VF
+	// 绑定的云主机网卡序号
+	NetworkIndex int `nullable:"true" default:"-1" list:"domain"`
+
 	// reserved memory size for isolated device, default 8G
 	ReservedMemory int `nullable:"true" default:"8192" list:"domain" update:"domain" create:"domain_optional"`
 
@@ -152,9 +161,26 @@ func (manager *SIsolatedDeviceManager) ValidateCreateData(ctx context.Context,
 	if input.ReservedStorage != nil && *input.ReservedStorage < 0 {
 		return input, httperrors.NewInputParameterError("reserved storage must >= 0")
 	}
+	if len(input.WireId) > 0 {
+		input.WireId, err = validateIsolatedDeviceWire(userCred, input.WireId)
+		if err != nil {
+			return input, err
+		}
+	}
 	return input, nil
 }
 
+func validateIsolatedDeviceWire(userCred mcclient.TokenCredential, wireId string) (string, error) {
+	wire, err := WireManager.FetchByIdOrName(userCred, wireId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return "", httperrors.NewResourceNotFoundError2(WireManager.Keyword(), wireId)
+		}
+		return "", httperrors.NewGeneralError(err)
+	}
+	return wire.GetId(), nil
+}
+
 func (self *SIsolatedDevice) AllowUpdateItem(ctx context.Context, userCred mcclient.TokenCredential) bool {
 	return db.IsAdminAllowUpdate(userCred, self)
 }
@@ -178,6 +204,12 @@ func (self *SIsolatedDevice) ValidateUpdateData(
 	if input.ReservedStorage != nil && *input.ReservedStorage < 0 {
 		return input, httperrors.NewInputParameterError("reserved storage must >= 0")
 	}
+	if len(input.WireId) > 0 {
+		input.WireId, err = validateIsolatedDeviceWire(userCred, input.WireId)
+		if err != nil {
+			return input, err
+		}
+	}
 	return input, nil
 }
 
@@ -223,6 +255,9 @@ func (manager *SIsolatedDeviceManager) ListItemFilter(
 	if len(query.VendorDeviceId) > 0 {
 		q = q.In("vendor_device_id", query.VendorDeviceId)
 	}
+	if len(query.WireId) > 0 {
+		q = q.In("wire_id", query.WireId)
+	}
 
 	if !query.ShowBaremetalIsolatedDevices {
 		sq := HostManager.Query("id").Equals("host_type", api.HOST_TYPE_HYPERVISOR).SubQuery()
@@ -505,6 +540,7 @@ func (manager *SIsolatedDeviceManager) ReleaseDevicesOfGuest(ctx context.Context
 	for _, dev := range devs {
 		_, err := db.Update(&dev, func() error {
 			dev.GuestId = ""
+			dev.NetworkIndex = -1
 			return nil
 		})
 		if err != nil {
@@ -593,6 +629,10 @@ func (self *SIsolatedDevice) getDesc() *jsonutils.JSONDict {
 	desc.Add(jsonutils.NewString(self.Addr), "addr")
 	desc.Add(jsonutils.NewString(self.VendorDeviceId), "vendor_device_id")
 	desc.Add(jsonutils.NewString(self.getVendor()), "vendor")
+	if self.IsSriovVf() {
+		desc.Add(jsonutils.NewString(self.WireId), "wire_id")
+		desc.Add(jsonutils.NewInt(int64(self.NetworkIndex)), "network_index")
+	}
 	return desc
 }
 
@@ -601,6 +641,10 @@ func (man *SIsolatedDeviceManager) GetSpecShouldCheckStatus(query *jsonutils.JSO
 }
 
 func (self *SIsolatedDevice) GetSpec(statusCheck bool) *jsonutils.JSONDict {
+	if self.IsSriovVf() {
+		// VF is allocated along with guest network
+		return nil
+	}
 	if statusCheck {
 		if len(self.GuestId) > 0 {
 			return nil
@@ -0,0 +1,115 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+func (self *SIsolatedDevice) IsSriovVf() bool {
+	return self.DevType == api.SRIOV_VF_TYPE
+}
+
+func (manager *SIsolatedDeviceManager) FindUnusedSriovVfsOnHost(hostId string, wireId string) ([]SIsolatedDevice, error) {
+	devs := make([]SIsolatedDevice, 0)
+	q := manager.findUnusedQuery()
+	q = q.Equals("dev_type", api.SRIOV_VF_TYPE).Equals("host_id", hostId).Equals("wire_id", wireId)
+	err := db.FetchModelObjects(manager, q, &devs)
+	if err != nil {
+		return nil, err
+	}
+	return devs, nil
+}
+
+func (manager *SIsolatedDeviceManager) findSriovVfsOfGuestnetwork(guestId string, index int8) ([]SIsolatedDevice, error) {
+	devs := make([]SIsolatedDevice, 0)
+	q := manager.Query().Equals("guest_id", guestId).Equals("dev_type", api.SRIOV_VF_TYPE).Equals("network_index", index)
+	err := db.FetchModelObjects(manager, q, &devs)
+	if err != nil {
+		return nil, err
+	}
+	return devs, nil
+}
+
+// attachSriovVfToGuestnetwork allocate an unused VF whose physical function
+// connects to the wire of guest network on the host of guest
+func (manager *SIsolatedDeviceManager) attachSriovVfToGuestnetwork(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest, gn *SGuestnetwork, network *SNetwork) error {
+	if guest.Hypervisor != api.HYPERVISOR_KVM {
+		return httperrors.NewNotSupportedError("SR-IOV VF not supported by hypervisor %s", guest.Hypervisor)
+	}
+	host := guest.GetHost()
+	if host == nil {
+		return errors.Wrap(httperrors.ErrNotFound, "guest host")
+	}
+	wire := network.GetWire()
+	if wire == nil {
+		return errors.Wrapf(httperrors.ErrNotFound, "wire of network %s", network.Name)
+	}
+
+	lockman.LockRawObject(ctx, manager.Keyword(), host.Id)
+	defer lockman.ReleaseRawObject(ctx, manager.Keyword(), host.Id)
+
+	devs, err := manager.FindUnusedSriovVfsOnHost(host.Id, wire.Id)
+	if err != nil {
+		return errors.Wrap(err, "FindUnusedSriovVfsOnHost")
+	}
+	if len(devs) == 0 {
+		return httperrors.NewInsufficientResourceError("no available SR-IOV VF on host %s of wire %s", host.Name, wire.Name)
+	}
+	dev := &devs[0]
+	_, err = db.Update(dev, func() error {
+		dev.GuestId = guest.Id
+		dev.NetworkIndex = int(gn.Index)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "update isolated device")
+	}
+	db.OpsLog.LogEvent(guest, db.ACT_GUEST_ATTACH_ISOLATED_DEVICE, dev.GetShortDesc(ctx), userCred)
+	go host.ClearSchedDescCache()
+	return nil
+}
+
+func (manager *SIsolatedDeviceManager) releaseSriovVfsOfGuestnetwork(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest, gn *SGuestnetwork) error {
+	devs, err := manager.findSriovVfsOfGuestnetwork(guest.Id, gn.Index)
+	if err != nil {
+		return errors.Wrap(err, "findSriovVfsOfGuestnetwork")
+	}
+	for i := range devs {
+		dev := &devs[i]
+		_, err := db.Update(dev, func() error {
+			dev.GuestId = ""
+			dev.NetworkIndex = -1
+			return nil
+		})
+		if err != nil {
+			db.OpsLog.LogEvent(guest, db.ACT_GUEST_DETACH_ISOLATED_DEVICE_FAIL, dev.GetShortDesc(ctx), userCred)
+			return errors.Wrap(err, "update isolated device")
+		}
+		db.OpsLog.LogEvent(guest, db.ACT_GUEST_DETACH_ISOLATED_DEVICE, dev.GetShortDesc(ctx), userCred)
+	}
+	if host := guest.GetHost(); host != nil && len(devs) > 0 {
+		go host.ClearSchedDescCache()
+	}
+	return nil
+}
@@ -680,7 +680,12 @@ func (manager *SNetworkManager) SyncNetworks(ctx context.Context, userCred mccli
 		return nil, nil, syncResult
 	}
 
+	plan := newSyncPlanScope(ctx, manager, len(dbNets))
 	for i := 0; i < len(removed); i += 1 {
+		network := &removed[i]
+		if plan.Remove(network, func() error { return network.syncRemoveCloudNetwork(ctx, userCred) }) {
+			continue
+		}
 		err = removed[i].syncRemoveCloudNetwork(ctx, userCred)
 		if err != nil {
 			syncResult.DeleteError(err)
@@ -689,6 +694,11 @@ func (manager *SNetworkManager) SyncNetworks(ctx context.Context, userCred mccli
 		}
 	}
 	for i := 0; i < len(commondb); i += 1 {
+		if plan.Update(&commondb[i], commonext[i]) {
+			localNets = append(localNets, commondb[i])
+			remoteNets = append(remoteNets, commonext[i])
+			continue
+		}
 		err = commondb[i].SyncWithCloudNetwork(ctx, userCred, commonext[i], syncOwnerId, provider)
 		if err != nil {
 			syncResult.UpdateError(err)
@@ -700,6 +710,9 @@ func (manager *SNetworkManager) SyncNetworks(ctx context.Context, userCred mccli
 		}
 	}
 	for i := 0; i < len(added); i += 1 {
+		if plan.Add(added[i]) {
+			continue
+		}
 		new, err := manager.newFromCloudNetwork(ctx, userCred, added[i], wire, syncOwnerId, provider)
 		if err != nil {
 			syncResult.AddError(err)
@@ -983,7 +983,12 @@ func (manager *SSnapshotManager) SyncSnapshots(ctx context.Context, userCred mcc
 		syncResult.Error(err)
 		return syncResult
 	}
+	plan := newSyncPlanScope(ctx, manager, len(dbSnapshots))
 	for i := 0; i < len(removed); i += 1 {
+		snapshot := &removed[i]
+		if plan.Remove(snapshot, func() error { return snapshot.syncRemoveCloudSnapshot(ctx, userCred) }) {
+			continue
+		}
 		err = removed[i].syncRemoveCloudSnapshot(ctx, userCred)
 		if err != nil {
 			syncResult.DeleteError(err)
@@ -992,6 +997,9 @@ func (manager *SSnapshotManager) SyncSnapshots(ctx context.Context, userCred mcc
 		}
 	}
 	for i := 0; i < len(commondb); i += 1 {
+		if plan.Update(&commondb[i], commonext[i]) {
+			continue
+		}
 		err = commondb[i].SyncWithCloudSnapshot(ctx, userCred, commonext[i], syncOwnerId, region)
 		if err != nil {
 			syncResult.UpdateError(err)
@@ -1001,6 +1009,9 @@ func (manager *SSnapshotManager) SyncSnapshots(ctx context.Context, userCred mcc
 		}
 	}
 	for i := 0; i < len(added); i += 1 {
+		if plan.Add(added[i]) {
+			continue
+		}
 		local, err := manager.newFromCloudSnapshot(ctx, userCred, added[i], region, syncOwnerId, provider)
 		if err != nil {
 			syncResult.AddError(err)
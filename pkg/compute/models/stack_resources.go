@@ -0,0 +1,359 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+const (
+	STACK_RESOURCE_WAIT_TIMEOUT  = 30 * time.Minute
+	STACK_RESOURCE_WAIT_INTERVAL = 5 * time.Second
+)
+
+// SStackResourceManager tracks resources owned by stacks
+type SStackResourceManager struct {
+	db.SStatusStandaloneResourceBaseManager
+}
+
+var StackResourceManager *SStackResourceManager
+
+func init() {
+	StackResourceManager = &SStackResourceManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SStackResource{},
+			"stack_resources_tbl",
+			"stack_resource",
+			"stack_resources",
+		),
+	}
+	StackResourceManager.SetVirtualObject(StackResourceManager)
+}
+
+// SStackResource is a resource of stack, name is the name of resource in template
+type SStackResource struct {
+	db.SStatusStandaloneResourceBase
+
+	StackId string `width:"36" charset:"ascii" nullable:"false" index:"true"`
+
+	ResourceType string `width:"32" charset:"ascii" nullable:"false"`
+	// id of the underlying resource
+	ResourceId string `width:"36" charset:"ascii" nullable:"true"`
+
+	// applied properties, parameters are substituted and refs to resources are kept
+	Properties jsonutils.JSONObject `nullable:"true"`
+	DependsOn  jsonutils.JSONObject `nullable:"true"`
+
+	DriftStatus  string               `width:"16" charset:"ascii" nullable:"true"`
+	DriftChanges jsonutils.JSONObject `nullable:"true"`
+}
+
+type sStackResourceType struct {
+	manager     db.IModelManager
+	module      modulebase.Manager
+	readyStatus []string
+}
+
+func getStackResourceType(resType string) (sStackResourceType, error) {
+	switch resType {
+	case api.STACK_RESOURCE_TYPE_NETWORK:
+		return sStackResourceType{NetworkManager, &modules.Networks, []string{api.NETWORK_STATUS_AVAILABLE}}, nil
+	case api.STACK_RESOURCE_TYPE_SECGROUP:
+		return sStackResourceType{SecurityGroupManager, &modules.SecGroups, []string{api.SECGROUP_STATUS_READY}}, nil
+	case api.STACK_RESOURCE_TYPE_DISK:
+		return sStackResourceType{DiskManager, &modules.Disks, []string{api.DISK_READY}}, nil
+	case api.STACK_RESOURCE_TYPE_SERVER:
+		return sStackResourceType{GuestManager, &modules.Servers, []string{api.VM_READY, api.VM_RUNNING}}, nil
+	case api.STACK_RESOURCE_TYPE_EIP:
+		return sStackResourceType{ElasticipManager, &modules.Elasticips, []string{api.EIP_STATUS_READY}}, nil
+	case api.STACK_RESOURCE_TYPE_LOADBALANCER:
+		return sStackResourceType{LoadbalancerManager, &modules.Loadbalancers, []string{api.LB_STATUS_ENABLED}}, nil
+	}
+	return sStackResourceType{}, errors.Wrapf(errors.ErrNotSupported, "stack resource type %s", resType)
+}
+
+func (manager *SStackResourceManager) fetchByStack(stackId string) ([]SStackResource, error) {
+	q := manager.Query().Equals("stack_id", stackId).Asc("created_at")
+	ret := make([]SStackResource, 0)
+	err := db.FetchModelObjects(manager, q, &ret)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	return ret, nil
+}
+
+func (self *SStackResource) GetStack() (*SStack, error) {
+	obj, err := StackManager.FetchById(self.StackId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch stack %s", self.StackId)
+	}
+	return obj.(*SStack), nil
+}
+
+func (self *SStackResource) getDependsOn() []string {
+	if self.DependsOn == nil {
+		return nil
+	}
+	return self.DependsOn.(*jsonutils.JSONArray).GetStringArray()
+}
+
+func (self *SStackResource) getState() sStackResourceState {
+	return sStackResourceState{
+		Name:       self.Name,
+		Type:       self.ResourceType,
+		ResourceId: self.ResourceId,
+		Properties: self.Properties,
+		DependsOn:  self.getDependsOn(),
+	}
+}
+
+func (self *SStackResource) getOutput() api.StackResourceOutput {
+	out := api.StackResourceOutput{
+		Name:        self.Name,
+		Type:        self.ResourceType,
+		Status:      self.Status,
+		ResourceId:  self.ResourceId,
+		Properties:  self.Properties,
+		DriftStatus: self.DriftStatus,
+	}
+	if self.DriftChanges != nil {
+		self.DriftChanges.Unmarshal(&out.DriftChanges)
+	}
+	return out
+}
+
+func (self *SStackResource) StartApplyTask(ctx context.Context, userCred mcclient.TokenCredential, params *jsonutils.JSONDict, parentTaskId string) error {
+	task, err := taskman.TaskManager.NewTask(ctx, "StackResourceApplyTask", self, userCred, params, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
+// Apply requests creating, updating or deleting the underlying resource with credential of the stack operator,
+// it doesn't wait for the resource, CheckApplied tells whether the resource is ready or gone
+func (self *SStackResource) Apply(ctx context.Context, userCred mcclient.TokenCredential, action string, resType string, props jsonutils.JSONObject, dependsOn []string) error {
+	stack, err := self.GetStack()
+	if err != nil {
+		return err
+	}
+	if action == api.STACK_PLAN_DELETE {
+		resType = self.ResourceType
+	}
+	rt, err := getStackResourceType(resType)
+	if err != nil {
+		return err
+	}
+	s := auth.GetSession(ctx, userCred, options.Options.Region, "")
+	switch action {
+	case api.STACK_PLAN_CREATE:
+		return self.create(ctx, userCred, s, stack, rt, resType, props, dependsOn)
+	case api.STACK_PLAN_UPDATE:
+		return self.update(ctx, s, stack, rt, props, dependsOn)
+	case api.STACK_PLAN_DELETE:
+		return self.delete(ctx, s, rt)
+	}
+	return errors.Wrapf(errors.ErrNotSupported, "action %s", action)
+}
+
+func (self *SStackResource) create(ctx context.Context, userCred mcclient.TokenCredential, s *mcclient.ClientSession, stack *SStack, rt sStackResourceType, resType string, props jsonutils.JSONObject, dependsOn []string) error {
+	ids, err := stack.getResourceIds()
+	if err != nil {
+		return err
+	}
+	resolved, err := resolveStackProperties(props, ids)
+	if err != nil {
+		return err
+	}
+	params := resolved.(*jsonutils.JSONDict)
+	if !params.Contains("name") && !params.Contains("generate_name") {
+		params.Set("generate_name", jsonutils.NewString(fmt.Sprintf("%s-%s", stack.Name, self.Name)))
+	}
+	if stack.ProjectId != userCred.GetProjectId() {
+		params.Set("project_id", jsonutils.NewString(stack.ProjectId))
+	}
+	ret, err := rt.module.Create(s, params)
+	if err != nil {
+		return errors.Wrapf(err, "create %s %s", resType, self.Name)
+	}
+	resId, _ := ret.GetString("id")
+	if len(resId) == 0 {
+		return errors.Wrapf(errors.ErrInvalidStatus, "no id found in result of creating %s", self.Name)
+	}
+	_, err = db.Update(self, func() error {
+		self.ResourceType = resType
+		self.ResourceId = resId
+		self.Properties = props
+		self.DependsOn = jsonutils.NewStringArray(dependsOn)
+		self.DriftStatus = api.STACK_DRIFT_IN_SYNC
+		self.DriftChanges = nil
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "db.Update")
+	}
+	return nil
+}
+
+func (self *SStackResource) update(ctx context.Context, s *mcclient.ClientSession, stack *SStack, rt sStackResourceType, props jsonutils.JSONObject, dependsOn []string) error {
+	ids, err := stack.getResourceIds()
+	if err != nil {
+		return err
+	}
+	resolved, err := resolveStackProperties(props, ids)
+	if err != nil {
+		return err
+	}
+	changes := jsonutils.NewDict()
+	config := jsonutils.NewDict()
+	for _, key := range diffStackProperties(self.Properties, props) {
+		val, _ := resolved.Get(key)
+		if val == nil {
+			continue
+		}
+		if self.ResourceType == api.STACK_RESOURCE_TYPE_SERVER && utils.IsInStringArray(key, []string{"vcpu_count", "vmem_size"}) {
+			config.Set(key, val)
+		} else {
+			changes.Set(key, val)
+		}
+	}
+	if changes.Length() > 0 {
+		if _, err := rt.module.Update(s, self.ResourceId, changes); err != nil {
+			return errors.Wrapf(err, "update %s", self.Name)
+		}
+	}
+	if config.Length() > 0 {
+		if _, err := rt.module.PerformAction(s, self.ResourceId, "change-config", config); err != nil {
+			return errors.Wrapf(err, "change config of %s", self.Name)
+		}
+	}
+	_, err = db.Update(self, func() error {
+		self.Properties = props
+		self.DependsOn = jsonutils.NewStringArray(dependsOn)
+		self.DriftStatus = api.STACK_DRIFT_IN_SYNC
+		self.DriftChanges = nil
+		return nil
+	})
+	return err
+}
+
+func (self *SStackResource) delete(ctx context.Context, s *mcclient.ClientSession, rt sStackResourceType) error {
+	if len(self.ResourceId) == 0 {
+		return nil
+	}
+	query := jsonutils.NewDict()
+	query.Set("override_pending_delete", jsonutils.JSONTrue)
+	_, err := rt.module.DeleteWithParam(s, self.ResourceId, query, nil)
+	if err != nil && httputils.ErrorCode(err) != 404 {
+		return errors.Wrapf(err, "delete %s", self.Name)
+	}
+	return nil
+}
+
+// CheckApplied checks once whether the underlying resource requested by Apply is ready, or gone for delete
+func (self *SStackResource) CheckApplied(ctx context.Context, userCred mcclient.TokenCredential, action string) (bool, error) {
+	if action == api.STACK_PLAN_DELETE && len(self.ResourceId) == 0 {
+		return true, nil
+	}
+	rt, err := getStackResourceType(self.ResourceType)
+	if err != nil {
+		return false, err
+	}
+	s := auth.GetSession(ctx, userCred, options.Options.Region, "")
+	ret, err := rt.module.Get(s, self.ResourceId, nil)
+	if err != nil {
+		if action == api.STACK_PLAN_DELETE && httputils.ErrorCode(err) == 404 {
+			_, err = db.Update(self, func() error {
+				self.ResourceId = ""
+				return nil
+			})
+			return err == nil, err
+		}
+		return false, errors.Wrapf(err, "get %s", self.Name)
+	}
+	status, _ := ret.GetString("status")
+	if strings.HasSuffix(status, "fail") || strings.HasSuffix(status, "failed") {
+		return false, errors.Wrapf(errors.ErrInvalidStatus, "%s %s status %s", self.ResourceType, self.Name, status)
+	}
+	if action != api.STACK_PLAN_DELETE && utils.IsInStringArray(status, rt.readyStatus) {
+		return true, nil
+	}
+	log.Debugf("wait stack resource %s %s, current status %s", self.Name, action, status)
+	return false, nil
+}
+
+// detectDrift compares the underlying resource with applied properties
+func (self *SStackResource) detectDrift(ids map[string]string) (string, []string) {
+	if len(self.ResourceId) == 0 {
+		return api.STACK_DRIFT_MISSING, nil
+	}
+	rt, err := getStackResourceType(self.ResourceType)
+	if err != nil {
+		return api.STACK_DRIFT_MISSING, nil
+	}
+	obj, err := rt.manager.FetchById(self.ResourceId)
+	if err != nil {
+		return api.STACK_DRIFT_MISSING, nil
+	}
+	if pd, ok := obj.(db.IPendingDeletable); ok && pd.GetPendingDeleted() {
+		return api.STACK_DRIFT_MISSING, nil
+	}
+	if self.Properties == nil {
+		return api.STACK_DRIFT_IN_SYNC, nil
+	}
+	resolved, err := resolveStackProperties(self.Properties, ids)
+	if err != nil {
+		return api.STACK_DRIFT_MODIFIED, nil
+	}
+	actual := jsonutils.Marshal(obj).(*jsonutils.JSONDict)
+	changes := make([]string, 0)
+	for key, val := range resolved.(*jsonutils.JSONDict).Value() {
+		actualVal, _ := actual.Get(key)
+		if actualVal == nil {
+			continue
+		}
+		switch val.(type) {
+		case *jsonutils.JSONString, *jsonutils.JSONInt, *jsonutils.JSONFloat, *jsonutils.JSONBool:
+		default:
+			continue
+		}
+		if actualVal.String() != val.String() {
+			changes = append(changes, key)
+		}
+	}
+	if len(changes) > 0 {
+		return api.STACK_DRIFT_MODIFIED, changes
+	}
+	return api.STACK_DRIFT_IN_SYNC, nil
+}
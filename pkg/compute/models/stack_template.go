@@ -0,0 +1,427 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"sort"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+// sStackResourceState is the applied state of a stack resource, used to compute plan
+type sStackResourceState struct {
+	Name       string
+	Type       string
+	ResourceId string
+	Properties jsonutils.JSONObject
+	DependsOn  []string
+}
+
+// SStackStep is a single step executed by stack tasks
+type SStackStep struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+}
+
+func parseStackTemplate(content string) (*api.StackTemplate, error) {
+	obj, err := jsonutils.ParseYAML(content)
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("invalid template: %v", err)
+	}
+	tmpl := &api.StackTemplate{}
+	if err := obj.Unmarshal(tmpl); err != nil {
+		return nil, httperrors.NewInputParameterError("invalid template: %v", err)
+	}
+	if len(tmpl.Resources) == 0 {
+		return nil, httperrors.NewInputParameterError("template has no resources")
+	}
+	for name, param := range tmpl.Parameters {
+		if !utils.IsInStringArray(param.Type, []string{api.STACK_PARAMETER_STRING, api.STACK_PARAMETER_NUMBER, api.STACK_PARAMETER_BOOLEAN}) {
+			return nil, httperrors.NewInputParameterError("invalid type %q of parameter %s", param.Type, name)
+		}
+		if param.Default != nil {
+			if err := checkStackParameterType(name, param.Type, param.Default); err != nil {
+				return nil, err
+			}
+		}
+	}
+	deps := make(map[string][]string)
+	for name, res := range tmpl.Resources {
+		if _, ok := tmpl.Parameters[name]; ok {
+			return nil, httperrors.NewInputParameterError("resource %s conflicts with parameter of the same name", name)
+		}
+		if !utils.IsInStringArray(res.Type, api.STACK_RESOURCE_TYPES) {
+			return nil, httperrors.NewInputParameterError("unsupported type %q of resource %s", res.Type, name)
+		}
+		if res.Properties == nil {
+			res.Properties = jsonutils.NewDict()
+			tmpl.Resources[name] = res
+		}
+		if _, ok := res.Properties.(*jsonutils.JSONDict); !ok {
+			return nil, httperrors.NewInputParameterError("properties of resource %s must be a dict", name)
+		}
+		for _, ref := range stackTemplateRefs(res.Properties) {
+			_, isParam := tmpl.Parameters[ref]
+			_, isRes := tmpl.Resources[ref]
+			if !isParam && !isRes {
+				return nil, httperrors.NewInputParameterError("resource %s refers to unknown %s", name, ref)
+			}
+		}
+		for _, dep := range res.DependsOn {
+			if _, ok := tmpl.Resources[dep]; !ok {
+				return nil, httperrors.NewInputParameterError("resource %s depends on unknown resource %s", name, dep)
+			}
+		}
+		deps[name] = stackResourceDeps(res.Properties, res.DependsOn)
+	}
+	if _, err := sortStackResources(deps); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+func checkStackParameterType(name, paramType string, val jsonutils.JSONObject) error {
+	ok := false
+	switch val.(type) {
+	case *jsonutils.JSONString:
+		ok = paramType == api.STACK_PARAMETER_STRING
+	case *jsonutils.JSONInt, *jsonutils.JSONFloat:
+		ok = paramType == api.STACK_PARAMETER_NUMBER
+	case *jsonutils.JSONBool:
+		ok = paramType == api.STACK_PARAMETER_BOOLEAN
+	}
+	if !ok {
+		return httperrors.NewInputParameterError("parameter %s should be %s", name, paramType)
+	}
+	return nil
+}
+
+// resolveStackParameters returns values of all parameters, defaults are used for missing ones
+func resolveStackParameters(tmpl *api.StackTemplate, values jsonutils.JSONObject) (*jsonutils.JSONDict, error) {
+	ret := jsonutils.NewDict()
+	input := jsonutils.NewDict()
+	if values != nil {
+		dict, ok := values.(*jsonutils.JSONDict)
+		if !ok {
+			return nil, httperrors.NewInputParameterError("parameters must be a dict")
+		}
+		input = dict
+	}
+	for key := range input.Value() {
+		if _, ok := tmpl.Parameters[key]; !ok {
+			return nil, httperrors.NewInputParameterError("unknown parameter %s", key)
+		}
+	}
+	for name, param := range tmpl.Parameters {
+		val, _ := input.Get(name)
+		if val == nil {
+			val = param.Default
+		}
+		if val == nil {
+			return nil, httperrors.NewMissingParameterError(name)
+		}
+		if err := checkStackParameterType(name, param.Type, val); err != nil {
+			return nil, err
+		}
+		ret.Set(name, val)
+	}
+	return ret, nil
+}
+
+func stackRefName(obj jsonutils.JSONObject) (string, bool) {
+	dict, ok := obj.(*jsonutils.JSONDict)
+	if !ok || dict.Length() != 1 {
+		return "", false
+	}
+	ref, err := dict.GetString("ref")
+	if err != nil {
+		return "", false
+	}
+	return ref, true
+}
+
+// stackTemplateRefs returns names referred by {"ref": "<name>"} in obj
+func stackTemplateRefs(obj jsonutils.JSONObject) []string {
+	refs := make([]string, 0)
+	if ref, ok := stackRefName(obj); ok {
+		return append(refs, ref)
+	}
+	switch val := obj.(type) {
+	case *jsonutils.JSONDict:
+		for _, v := range val.Value() {
+			refs = append(refs, stackTemplateRefs(v)...)
+		}
+	case *jsonutils.JSONArray:
+		for _, v := range val.Value() {
+			refs = append(refs, stackTemplateRefs(v)...)
+		}
+	}
+	return refs
+}
+
+// replaceStackRefs replaces {"ref": "<name>"} found by lookup, refs not found are kept
+func replaceStackRefs(obj jsonutils.JSONObject, lookup func(name string) jsonutils.JSONObject) jsonutils.JSONObject {
+	if ref, ok := stackRefName(obj); ok {
+		if val := lookup(ref); val != nil {
+			return val
+		}
+		return obj
+	}
+	switch val := obj.(type) {
+	case *jsonutils.JSONDict:
+		ret := jsonutils.NewDict()
+		for k, v := range val.Value() {
+			ret.Set(k, replaceStackRefs(v, lookup))
+		}
+		return ret
+	case *jsonutils.JSONArray:
+		ret := jsonutils.NewArray()
+		for _, v := range val.Value() {
+			ret.Add(replaceStackRefs(v, lookup))
+		}
+		return ret
+	}
+	return obj
+}
+
+// renderStackProperties substitutes parameters, refs to resources are kept
+func renderStackProperties(props jsonutils.JSONObject, params *jsonutils.JSONDict) jsonutils.JSONObject {
+	return replaceStackRefs(props, func(name string) jsonutils.JSONObject {
+		val, _ := params.Get(name)
+		return val
+	})
+}
+
+// resolveStackProperties substitutes refs with ids of resources
+func resolveStackProperties(props jsonutils.JSONObject, ids map[string]string) (jsonutils.JSONObject, error) {
+	for _, ref := range stackTemplateRefs(props) {
+		if len(ids[ref]) == 0 {
+			return nil, httperrors.NewResourceNotReadyError("referred resource %s not ready", ref)
+		}
+	}
+	return replaceStackRefs(props, func(name string) jsonutils.JSONObject {
+		return jsonutils.NewString(ids[name])
+	}), nil
+}
+
+func stackResourceDeps(props jsonutils.JSONObject, dependsOn []string) []string {
+	deps := make([]string, 0)
+	for _, name := range append(stackTemplateRefs(props), dependsOn...) {
+		if !utils.IsInStringArray(name, deps) {
+			deps = append(deps, name)
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// sortStackResources sorts resources topologically, dependencies come first,
+// names not in deps such as parameters are ignored
+func sortStackResources(deps map[string][]string) ([]string, error) {
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	order := make([]string, 0, len(names))
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return httperrors.NewInputParameterError("circular dependency found at resource %s", name)
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range deps[name] {
+			if _, ok := deps[dep]; !ok {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// diffStackProperties returns top level keys of properties which are changed
+func diffStackProperties(old, new jsonutils.JSONObject) []string {
+	oldDict, _ := old.(*jsonutils.JSONDict)
+	newDict, _ := new.(*jsonutils.JSONDict)
+	if oldDict == nil {
+		oldDict = jsonutils.NewDict()
+	}
+	if newDict == nil {
+		newDict = jsonutils.NewDict()
+	}
+	changes := make([]string, 0)
+	for k, v := range newDict.Value() {
+		ov, _ := oldDict.Get(k)
+		if ov == nil || ov.String() != v.String() {
+			changes = append(changes, k)
+		}
+	}
+	for k := range oldDict.Value() {
+		if !newDict.Contains(k) {
+			changes = append(changes, k)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// stackUpdatableKeys returns properties can be changed in place, others require replacement
+func stackUpdatableKeys(resType string) []string {
+	keys := []string{"name", "description"}
+	if resType == api.STACK_RESOURCE_TYPE_SERVER {
+		keys = append(keys, "vcpu_count", "vmem_size")
+	}
+	return keys
+}
+
+// planStack compares the template with the applied state, steps are in dependency order
+func planStack(tmpl *api.StackTemplate, params *jsonutils.JSONDict, current []sStackResourceState) ([]api.StackPlanStep, error) {
+	curMap := make(map[string]sStackResourceState)
+	for _, cur := range current {
+		curMap[cur.Name] = cur
+	}
+	deps := make(map[string][]string)
+	for name, res := range tmpl.Resources {
+		deps[name] = stackResourceDeps(res.Properties, res.DependsOn)
+	}
+	order, err := sortStackResources(deps)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]api.StackPlanStep, 0, len(order)+len(current))
+	replaced := make(map[string]bool)
+	for _, name := range order {
+		res := tmpl.Resources[name]
+		step := api.StackPlanStep{Name: name, Type: res.Type}
+		cur, ok := curMap[name]
+		if !ok || len(cur.ResourceId) == 0 {
+			step.Action = api.STACK_PLAN_CREATE
+			steps = append(steps, step)
+			continue
+		}
+		step.ResourceId = cur.ResourceId
+		step.Changes = diffStackProperties(cur.Properties, renderStackProperties(res.Properties, params))
+		switch {
+		case cur.Type != res.Type:
+			step.Action = api.STACK_PLAN_REPLACE
+		case len(step.Changes) == 0:
+			step.Action = api.STACK_PLAN_NOOP
+		default:
+			step.Action = api.STACK_PLAN_UPDATE
+			updatable := stackUpdatableKeys(res.Type)
+			for _, key := range step.Changes {
+				if !utils.IsInStringArray(key, updatable) {
+					step.Action = api.STACK_PLAN_REPLACE
+					break
+				}
+			}
+		}
+		// resources refer to replaced ones have to be replaced as well
+		for _, dep := range deps[name] {
+			if replaced[dep] {
+				step.Action = api.STACK_PLAN_REPLACE
+				break
+			}
+		}
+		if step.Action == api.STACK_PLAN_REPLACE {
+			replaced[name] = true
+		}
+		steps = append(steps, step)
+	}
+
+	oldDeps := make(map[string][]string)
+	for _, cur := range current {
+		oldDeps[cur.Name] = cur.DependsOn
+	}
+	oldOrder, err := sortStackResources(oldDeps)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(oldOrder) - 1; i >= 0; i-- {
+		name := oldOrder[i]
+		if _, ok := tmpl.Resources[name]; ok {
+			continue
+		}
+		cur := curMap[name]
+		steps = append(steps, api.StackPlanStep{
+			Name:       name,
+			Type:       cur.Type,
+			Action:     api.STACK_PLAN_DELETE,
+			ResourceId: cur.ResourceId,
+		})
+	}
+	return steps, nil
+}
+
+// stackExecutionSteps expands plan to steps executed one by one,
+// replaced resources are deleted in reverse order before anything is created
+func stackExecutionSteps(plan []api.StackPlanStep) []SStackStep {
+	steps := make([]SStackStep, 0, len(plan))
+	for i := len(plan) - 1; i >= 0; i-- {
+		if plan[i].Action == api.STACK_PLAN_REPLACE {
+			steps = append(steps, SStackStep{Name: plan[i].Name, Action: api.STACK_PLAN_DELETE})
+		}
+	}
+	for _, step := range plan {
+		switch step.Action {
+		case api.STACK_PLAN_CREATE, api.STACK_PLAN_REPLACE:
+			steps = append(steps, SStackStep{Name: step.Name, Action: api.STACK_PLAN_CREATE})
+		case api.STACK_PLAN_UPDATE, api.STACK_PLAN_DELETE:
+			steps = append(steps, SStackStep{Name: step.Name, Action: step.Action})
+		}
+	}
+	return steps
+}
+
+// stackDeleteSteps returns steps deleting all resources, dependents are deleted first
+func stackDeleteSteps(current []sStackResourceState) ([]SStackStep, error) {
+	deps := make(map[string][]string)
+	for _, cur := range current {
+		deps[cur.Name] = cur.DependsOn
+	}
+	order, err := sortStackResources(deps)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]SStackStep, 0, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		steps = append(steps, SStackStep{Name: order[i], Action: api.STACK_PLAN_DELETE})
+	}
+	return steps, nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+const testStackTemplate = `
+parameters:
+  cpu:
+    type: number
+    default: 2
+  image:
+    type: string
+resources:
+  net:
+    type: network
+    properties:
+      guest_ip_prefix: 10.0.0.0/24
+  sg:
+    type: secgroup
+  vm:
+    type: server
+    depends_on: [sg]
+    properties:
+      vcpu_count: {ref: cpu}
+      disks:
+      - image_id: {ref: image}
+      nets:
+      - network: {ref: net}
+`
+
+func TestParseStackTemplate(t *testing.T) {
+	tmpl, err := parseStackTemplate(testStackTemplate)
+	if err != nil {
+		t.Fatalf("parseStackTemplate: %v", err)
+	}
+	deps := map[string][]string{}
+	for name, res := range tmpl.Resources {
+		deps[name] = stackResourceDeps(res.Properties, res.DependsOn)
+	}
+	if want := []string{"image", "cpu", "net", "sg"}; len(deps["vm"]) != len(want) {
+		t.Errorf("deps of vm = %v", deps["vm"])
+	}
+	order, err := sortStackResources(deps)
+	if err != nil {
+		t.Fatalf("sortStackResources: %v", err)
+	}
+	if want := []string{"net", "sg", "vm"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+
+	for _, bad := range []string{
+		"resources: {a: {type: server, properties: {x: {ref: b}}}, b: {type: disk, properties: {y: {ref: a}}}}",
+		"resources: {a: {type: server, properties: {x: {ref: nonexist}}}}",
+		"resources: {a: {type: vpc}}",
+		"parameters: {a: {type: string}}\nresources: {a: {type: disk}}",
+	} {
+		if _, err := parseStackTemplate(bad); err == nil {
+			t.Errorf("expect error for template %s", bad)
+		}
+	}
+}
+
+func TestResolveStackParameters(t *testing.T) {
+	tmpl, _ := parseStackTemplate(testStackTemplate)
+	if _, err := resolveStackParameters(tmpl, nil); err == nil {
+		t.Errorf("expect missing parameter image")
+	}
+	if _, err := resolveStackParameters(tmpl, jsonutils.Marshal(map[string]int{"image": 1})); err == nil {
+		t.Errorf("expect invalid type of image")
+	}
+	params, err := resolveStackParameters(tmpl, jsonutils.Marshal(map[string]string{"image": "centos"}))
+	if err != nil {
+		t.Fatalf("resolveStackParameters: %v", err)
+	}
+	props := renderStackProperties(tmpl.Resources["vm"].Properties, params)
+	if cpu, _ := props.Int("vcpu_count"); cpu != 2 {
+		t.Errorf("vcpu_count = %d", cpu)
+	}
+	resolved, err := resolveStackProperties(props, map[string]string{"net": "net-id"})
+	if err != nil {
+		t.Fatalf("resolveStackProperties: %v", err)
+	}
+	nets, _ := resolved.GetArray("nets")
+	if net, _ := nets[0].GetString("network"); net != "net-id" {
+		t.Errorf("network = %s", net)
+	}
+	if _, err := resolveStackProperties(props, nil); err == nil {
+		t.Errorf("expect error of unresolved net")
+	}
+}
+
+func TestPlanStack(t *testing.T) {
+	tmpl, _ := parseStackTemplate(testStackTemplate)
+	params, _ := resolveStackParameters(tmpl, jsonutils.Marshal(map[string]string{"image": "centos"}))
+	state := func(name, resType string) sStackResourceState {
+		res := tmpl.Resources[name]
+		return sStackResourceState{
+			Name:       name,
+			Type:       resType,
+			ResourceId: name + "-id",
+			Properties: renderStackProperties(res.Properties, params),
+			DependsOn:  stackResourceDeps(res.Properties, res.DependsOn),
+		}
+	}
+	actions := func(steps []api.StackPlanStep) map[string]string {
+		ret := map[string]string{}
+		for _, step := range steps {
+			ret[step.Name] = step.Action
+		}
+		return ret
+	}
+
+	steps, err := planStack(tmpl, params, nil)
+	if err != nil {
+		t.Fatalf("planStack: %v", err)
+	}
+	if len(stackExecutionSteps(steps)) != 3 {
+		t.Errorf("expect 3 creations, got %v", steps)
+	}
+
+	current := []sStackResourceState{state("net", "network"), state("sg", "secgroup"), state("vm", "server"),
+		{Name: "old", Type: "disk", ResourceId: "old-id"}}
+	params.Set("cpu", jsonutils.NewInt(4))
+	steps, _ = planStack(tmpl, params, current)
+	want := map[string]string{"net": api.STACK_PLAN_NOOP, "sg": api.STACK_PLAN_NOOP, "vm": api.STACK_PLAN_UPDATE, "old": api.STACK_PLAN_DELETE}
+	if got := actions(steps); !reflect.DeepEqual(got, want) {
+		t.Errorf("plan = %v, want %v", got, want)
+	}
+
+	// replacing network replaces the server on it
+	current[0].Properties = jsonutils.Marshal(map[string]string{"guest_ip_prefix": "10.0.1.0/24"})
+	steps, _ = planStack(tmpl, params, current)
+	got := actions(steps)
+	if got["net"] != api.STACK_PLAN_REPLACE || got["vm"] != api.STACK_PLAN_REPLACE {
+		t.Errorf("plan = %v", got)
+	}
+	exec := stackExecutionSteps(steps)
+	wantExec := []SStackStep{
+		{"vm", api.STACK_PLAN_DELETE}, {"net", api.STACK_PLAN_DELETE},
+		{"net", api.STACK_PLAN_CREATE}, {"vm", api.STACK_PLAN_CREATE}, {"old", api.STACK_PLAN_DELETE},
+	}
+	if !reflect.DeepEqual(exec, wantExec) {
+		t.Errorf("execution steps = %v, want %v", exec, wantExec)
+	}
+}
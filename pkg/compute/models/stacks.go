@@ -0,0 +1,472 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// SStackManager manages stacks, a stack creates and tracks a group of resources described by a template
+type SStackManager struct {
+	db.SVirtualResourceBaseManager
+}
+
+var StackManager *SStackManager
+
+func init() {
+	StackManager = &SStackManager{
+		SVirtualResourceBaseManager: db.NewVirtualResourceBaseManager(
+			SStack{},
+			"stacks_tbl",
+			"stack",
+			"stacks",
+		),
+	}
+	StackManager.SetVirtualObject(StackManager)
+}
+
+type SStack struct {
+	db.SVirtualResourceBase
+
+	// 模板内容
+	Template string `length:"text" nullable:"false" list:"user" create:"required"`
+
+	// 模板参数取值, 包含默认值
+	Parameters jsonutils.JSONObject `nullable:"true" list:"user" create:"optional"`
+}
+
+func (manager *SStackManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.StackCreateInput,
+) (api.StackCreateInput, error) {
+	if len(input.Template) == 0 {
+		return input, httperrors.NewMissingParameterError("template")
+	}
+	tmpl, err := parseStackTemplate(input.Template)
+	if err != nil {
+		return input, err
+	}
+	input.Parameters, err = resolveStackParameters(tmpl, input.Parameters)
+	if err != nil {
+		return input, err
+	}
+	input.Status = api.STACK_STATUS_CREATING
+	input.VirtualResourceCreateInput, err = manager.SVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.VirtualResourceCreateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SVirtualResourceBaseManager.ValidateCreateData")
+	}
+	return input, nil
+}
+
+func (self *SStack) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.SVirtualResourceBase.PostCreate(ctx, userCred, ownerId, query, data)
+	if err := self.StartApplyTask(ctx, userCred, ""); err != nil {
+		self.SetStatus(userCred, api.STACK_STATUS_CREATE_FAILED, err.Error())
+	}
+}
+
+func (self *SStack) getTemplate() (*api.StackTemplate, error) {
+	return parseStackTemplate(self.Template)
+}
+
+func (self *SStack) getParameters() *jsonutils.JSONDict {
+	if dict, ok := self.Parameters.(*jsonutils.JSONDict); ok {
+		return dict
+	}
+	return jsonutils.NewDict()
+}
+
+func (self *SStack) GetResources() ([]SStackResource, error) {
+	return StackResourceManager.fetchByStack(self.Id)
+}
+
+func (self *SStack) GetResource(name string) (*SStackResource, error) {
+	q := StackResourceManager.Query().Equals("stack_id", self.Id).Equals("name", name)
+	res := &SStackResource{}
+	res.SetModelManager(StackResourceManager, res)
+	if err := q.First(res); err != nil {
+		return nil, errors.Wrapf(err, "fetch stack resource %s", name)
+	}
+	return res, nil
+}
+
+func (self *SStack) getResourceStates() ([]sStackResourceState, error) {
+	resources, err := self.GetResources()
+	if err != nil {
+		return nil, err
+	}
+	states := make([]sStackResourceState, 0, len(resources))
+	for i := range resources {
+		states = append(states, resources[i].getState())
+	}
+	return states, nil
+}
+
+// getResourceIds returns ids of underlying resources keyed by name in template
+func (self *SStack) getResourceIds() (map[string]string, error) {
+	resources, err := self.GetResources()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]string)
+	for i := range resources {
+		if len(resources[i].ResourceId) > 0 {
+			ids[resources[i].Name] = resources[i].ResourceId
+		}
+	}
+	return ids, nil
+}
+
+// Plan computes steps applying the current template
+func (self *SStack) Plan() ([]api.StackPlanStep, error) {
+	tmpl, err := self.getTemplate()
+	if err != nil {
+		return nil, err
+	}
+	states, err := self.getResourceStates()
+	if err != nil {
+		return nil, err
+	}
+	return planStack(tmpl, self.getParameters(), states)
+}
+
+// GetApplySteps returns steps applying the current template one by one
+func (self *SStack) GetApplySteps() ([]SStackStep, error) {
+	plan, err := self.Plan()
+	if err != nil {
+		return nil, err
+	}
+	return stackExecutionSteps(plan), nil
+}
+
+// GetDeleteSteps returns steps deleting all resources of the stack
+func (self *SStack) GetDeleteSteps() ([]SStackStep, error) {
+	states, err := self.getResourceStates()
+	if err != nil {
+		return nil, err
+	}
+	return stackDeleteSteps(states)
+}
+
+// RenderResource returns type, properties with parameters substituted and dependencies of the resource in template
+func (self *SStack) RenderResource(name string) (string, jsonutils.JSONObject, []string, error) {
+	tmpl, err := self.getTemplate()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	res, ok := tmpl.Resources[name]
+	if !ok {
+		return "", nil, nil, errors.Wrapf(errors.ErrNotFound, "resource %s not in template", name)
+	}
+	return res.Type, renderStackProperties(res.Properties, self.getParameters()), stackResourceDeps(res.Properties, res.DependsOn), nil
+}
+
+// EnsureResource returns the stack resource of the name, it is created if not exists
+func (self *SStack) EnsureResource(ctx context.Context, userCred mcclient.TokenCredential, name string, resType string) (*SStackResource, error) {
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	res, err := self.GetResource(name)
+	if err == nil {
+		return res, nil
+	}
+	if errors.Cause(err) != sqlchemy.ErrEmptyQuery {
+		return nil, err
+	}
+	res = &SStackResource{}
+	res.SetModelManager(StackResourceManager, res)
+	res.Name = name
+	res.StackId = self.Id
+	res.ResourceType = resType
+	res.Status = api.STACK_RESOURCE_STATUS_PENDING
+	if err := StackResourceManager.TableSpec().Insert(ctx, res); err != nil {
+		return nil, errors.Wrap(err, "insert stack resource")
+	}
+	return res, nil
+}
+
+func (self *SStack) StartApplyTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+	task, err := taskman.TaskManager.NewTask(ctx, "StackApplyTask", self, userCred, nil, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
+func (self *SStack) AllowPerformPlan(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "plan")
+}
+
+// 预览模板变更
+//
+// 计算使用新模板或参数更新栈时需要创建, 修改, 替换和删除的资源, 不做实际变更
+func (self *SStack) PerformPlan(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.StackPlanInput) (api.StackPlanOutput, error) {
+	output := api.StackPlanOutput{}
+	tmpl, params, err := self.validatePlanInput(input)
+	if err != nil {
+		return output, err
+	}
+	states, err := self.getResourceStates()
+	if err != nil {
+		return output, httperrors.NewGeneralError(err)
+	}
+	output.Steps, err = planStack(tmpl, params, states)
+	if err != nil {
+		return output, err
+	}
+	return output, nil
+}
+
+func (self *SStack) validatePlanInput(input api.StackPlanInput) (*api.StackTemplate, *jsonutils.JSONDict, error) {
+	content := input.Template
+	if len(content) == 0 {
+		content = self.Template
+	}
+	tmpl, err := parseStackTemplate(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	values := input.Parameters
+	if values == nil {
+		// keep values of parameters still in template
+		current := jsonutils.NewDict()
+		for key, val := range self.getParameters().Value() {
+			if _, ok := tmpl.Parameters[key]; ok {
+				current.Set(key, val)
+			}
+		}
+		values = current
+	}
+	params, err := resolveStackParameters(tmpl, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tmpl, params, nil
+}
+
+func (self *SStack) AllowPerformApply(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "apply")
+}
+
+// 更新栈
+//
+// 使用新模板或参数更新栈, 按依赖顺序创建, 修改, 替换和删除底层资源, 也可用于重试失败的创建或更新
+func (self *SStack) PerformApply(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.StackPlanInput) (api.StackPlanOutput, error) {
+	output := api.StackPlanOutput{}
+	if !utils.IsInStringArray(self.Status, []string{api.STACK_STATUS_READY, api.STACK_STATUS_CREATE_FAILED, api.STACK_STATUS_UPDATE_FAILED}) {
+		return output, httperrors.NewInvalidStatusError("cannot apply stack in status %s", self.Status)
+	}
+	tmpl, params, err := self.validatePlanInput(input)
+	if err != nil {
+		return output, err
+	}
+	states, err := self.getResourceStates()
+	if err != nil {
+		return output, httperrors.NewGeneralError(err)
+	}
+	output.Steps, err = planStack(tmpl, params, states)
+	if err != nil {
+		return output, err
+	}
+	_, err = db.Update(self, func() error {
+		if len(input.Template) > 0 {
+			self.Template = input.Template
+		}
+		self.Parameters = params
+		return nil
+	})
+	if err != nil {
+		return output, httperrors.NewGeneralError(err)
+	}
+	self.SetStatus(userCred, api.STACK_STATUS_UPDATING, "")
+	if err := self.StartApplyTask(ctx, userCred, ""); err != nil {
+		return output, httperrors.NewGeneralError(err)
+	}
+	return output, nil
+}
+
+func (self *SStack) AllowGetDetailsResources(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowGetSpec(userCred, self, "resources")
+}
+
+// 获取栈管理的资源
+func (self *SStack) GetDetailsResources(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (api.StackResourcesOutput, error) {
+	output := api.StackResourcesOutput{Resources: []api.StackResourceOutput{}}
+	resources, err := self.GetResources()
+	if err != nil {
+		return output, httperrors.NewGeneralError(err)
+	}
+	for i := range resources {
+		output.Resources = append(output.Resources, resources[i].getOutput())
+	}
+	return output, nil
+}
+
+func (self *SStack) AllowPerformDetectDrift(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "detect-drift")
+}
+
+// 检测资源漂移
+//
+// 检查栈管理的底层资源是否被删除或在栈之外被修改
+func (self *SStack) PerformDetectDrift(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (api.StackResourcesOutput, error) {
+	output := api.StackResourcesOutput{Resources: []api.StackResourceOutput{}}
+	resources, err := self.GetResources()
+	if err != nil {
+		return output, httperrors.NewGeneralError(err)
+	}
+	ids, err := self.getResourceIds()
+	if err != nil {
+		return output, httperrors.NewGeneralError(err)
+	}
+	drifted := 0
+	for i := range resources {
+		status, changes := resources[i].detectDrift(ids)
+		_, err := db.Update(&resources[i], func() error {
+			resources[i].DriftStatus = status
+			if len(changes) > 0 {
+				resources[i].DriftChanges = jsonutils.NewStringArray(changes)
+			} else {
+				resources[i].DriftChanges = nil
+			}
+			return nil
+		})
+		if err != nil {
+			log.Errorf("update drift status of stack resource %s: %v", resources[i].Name, err)
+		}
+		if status != api.STACK_DRIFT_IN_SYNC {
+			drifted++
+		}
+		output.Resources = append(output.Resources, resources[i].getOutput())
+	}
+	db.OpsLog.LogEvent(self, db.ACT_SYNC_STATUS, jsonutils.Marshal(map[string]int{"drifted": drifted}), userCred)
+	return output, nil
+}
+
+func (self *SStack) Delete(ctx context.Context, userCred mcclient.TokenCredential) error {
+	log.Infof("stack delete do nothing")
+	return nil
+}
+
+func (self *SStack) RealDelete(ctx context.Context, userCred mcclient.TokenCredential) error {
+	resources, err := self.GetResources()
+	if err != nil {
+		return err
+	}
+	for i := range resources {
+		if err := db.DeleteModel(ctx, userCred, &resources[i]); err != nil {
+			return errors.Wrapf(err, "delete stack resource %s", resources[i].Name)
+		}
+	}
+	return db.DeleteModel(ctx, userCred, self)
+}
+
+func (self *SStack) CustomizeDelete(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	return self.StartDeleteTask(ctx, userCred, "")
+}
+
+func (self *SStack) StartDeleteTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+	self.SetStatus(userCred, api.STACK_STATUS_DELETING, "")
+	task, err := taskman.TaskManager.NewTask(ctx, "StackDeleteTask", self, userCred, nil, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
+func (self *SStack) ValidateDeleteCondition(ctx context.Context) error {
+	if utils.IsInStringArray(self.Status, []string{api.STACK_STATUS_CREATING, api.STACK_STATUS_UPDATING}) {
+		return httperrors.NewInvalidStatusError("cannot delete stack in status %s", self.Status)
+	}
+	return self.SVirtualResourceBase.ValidateDeleteCondition(ctx)
+}
+
+func (self *SStack) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, isList bool) (api.StackDetails, error) {
+	return api.StackDetails{}, nil
+}
+
+func (manager *SStackManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.StackDetails {
+	rows := make([]api.StackDetails, len(objs))
+	virtRows := manager.SVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.StackDetails{
+			VirtualResourceDetails: virtRows[i],
+		}
+		stack := objs[i].(*SStack)
+		rows[i].ResourceCount, _ = StackResourceManager.Query().Equals("stack_id", stack.Id).CountWithError()
+	}
+	return rows
+}
+
+// 资源栈列表
+func (manager *SStackManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.StackListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, query.VirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.ListItemFilter")
+	}
+	return q, nil
+}
+
+func (manager *SStackManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.StackListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.VirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SStackManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
@@ -681,10 +681,12 @@ func (manager *SStorageManager) SyncStorages(ctx context.Context, userCred mccli
 	remoteStorages := make([]cloudprovider.ICloudStorage, 0)
 	syncResult := compare.SyncResult{}
 
-	err := manager.scanLegacyStorages()
-	if err != nil {
-		syncResult.Error(err)
-		return nil, nil, syncResult
+	if !isSyncDryRun(ctx) {
+		err := manager.scanLegacyStorages()
+		if err != nil {
+			syncResult.Error(err)
+			return nil, nil, syncResult
+		}
 	}
 
 	dbStorages, err := manager.getStoragesByZoneId(zone.Id, provider)
@@ -704,11 +706,16 @@ func (manager *SStorageManager) SyncStorages(ctx context.Context, userCred mccli
 		return nil, nil, syncResult
 	}
 
+	plan := newSyncPlanScope(ctx, manager, len(dbStorages))
 	for i := 0; i < len(removed); i += 1 {
 		// may be a fake storage for prepaid recycle host
 		if removed[i].IsPrepaidRecycleResource() {
 			continue
 		}
+		storage := &removed[i]
+		if plan.Remove(storage, func() error { return storage.syncRemoveCloudStorage(ctx, userCred) }) {
+			continue
+		}
 		err = removed[i].syncRemoveCloudStorage(ctx, userCred)
 		if err != nil {
 			syncResult.DeleteError(err)
@@ -717,6 +724,11 @@ func (manager *SStorageManager) SyncStorages(ctx context.Context, userCred mccli
 		}
 	}
 	for i := 0; i < len(commondb); i += 1 {
+		if plan.Update(&commondb[i], commonext[i]) {
+			localStorages = append(localStorages, commondb[i])
+			remoteStorages = append(remoteStorages, commonext[i])
+			continue
+		}
 		err = commondb[i].syncWithCloudStorage(ctx, userCred, commonext[i], provider)
 		if err != nil {
 			syncResult.UpdateError(err)
@@ -728,6 +740,9 @@ func (manager *SStorageManager) SyncStorages(ctx context.Context, userCred mccli
 		}
 	}
 	for i := 0; i < len(added); i += 1 {
+		if plan.Add(added[i]) {
+			continue
+		}
 		new, err := manager.newFromCloudStorage(ctx, userCred, added[i], provider, zone)
 		if err != nil {
 			syncResult.AddError(err)
@@ -0,0 +1,705 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/policy"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/excelutils"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// at most so many hours are metered again after the service was down
+const usageMeterMaxBackfillHours = 24
+
+// SUsageRecordManager keep hourly metered usage of on-premise resources
+type SUsageRecordManager struct {
+	db.SResourceBaseManager
+	db.SProjectizedResourceBaseManager
+}
+
+var UsageRecordManager *SUsageRecordManager
+
+func init() {
+	UsageRecordManager = &SUsageRecordManager{
+		SResourceBaseManager: db.NewResourceBaseManager(
+			SUsageRecord{},
+			"usage_records_tbl",
+			"usage_record",
+			"usage_records",
+		),
+	}
+	UsageRecordManager.SetVirtualObject(UsageRecordManager)
+}
+
+type SUsageRecord struct {
+	db.SResourceBase
+	db.SProjectizedResourceBase
+
+	// 自增Id
+	Id int64 `primary:"true" auto_increment:"true" list:"user"`
+
+	// 计量的小时(UTC整点)
+	UsageHour time.Time `nullable:"false" index:"true" list:"user"`
+
+	// 资源类型
+	ResourceType string `width:"32" charset:"ascii" nullable:"false" list:"user"`
+	// 计量项
+	Metric string `width:"16" charset:"ascii" nullable:"false" list:"user"`
+	// 规格
+	Spec string `width:"64" charset:"utf8" nullable:"false" default:"" list:"user"`
+	// 资源标签
+	Tags jsonutils.JSONObject `nullable:"true" list:"user"`
+
+	// 资源数量
+	ResourceCount int `nullable:"false" default:"0" list:"user"`
+	// 用量, 单位小时数, 例如vCPU小时
+	Quantity float64 `nullable:"false" default:"0" list:"user"`
+	// 计量时的单价
+	Price float64 `nullable:"false" default:"0" list:"user"`
+	// 费用
+	Cost float64 `nullable:"false" default:"0" list:"user"`
+	// 货币
+	Currency string `width:"8" charset:"ascii" nullable:"false" default:"CNY" list:"user"`
+}
+
+func (manager *SUsageRecordManager) AllowListItems(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsProjectAllowList(userCred, manager)
+}
+
+func (manager *SUsageRecordManager) AllowCreateItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return false
+}
+
+func (self *SUsageRecord) AllowGetDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return false
+}
+
+func (self *SUsageRecord) AllowUpdateItem(ctx context.Context, userCred mcclient.TokenCredential) bool {
+	return false
+}
+
+func (self *SUsageRecord) AllowDeleteItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return false
+}
+
+type sStatusEvent struct {
+	At   time.Time
+	From string
+	To   string
+}
+
+// parseStatusNotes parse notes of status change log, e.g. running=>ready: reason
+func parseStatusNotes(notes string) (string, string, bool) {
+	pos := strings.Index(notes, "=>")
+	if pos < 0 {
+		return "", "", false
+	}
+	from, to := notes[:pos], notes[pos+2:]
+	if colon := strings.Index(to, ":"); colon >= 0 {
+		to = to[:colon]
+	}
+	return strings.TrimSpace(from), strings.TrimSpace(to), true
+}
+
+// runningHours return how long a resource stayed in running status within [start, end),
+// events are status changes sorted by time, those after end only tell the status before them
+func runningHours(current string, events []sStatusEvent, start, end time.Time, isRunning func(string) bool) float64 {
+	if !end.After(start) {
+		return 0
+	}
+	running := isRunning(current)
+	if len(events) > 0 {
+		running = isRunning(events[0].From)
+	}
+	secs := 0.0
+	cur := start
+	for _, ev := range events {
+		if !ev.At.Before(end) {
+			break
+		}
+		if ev.At.After(cur) {
+			if running {
+				secs += ev.At.Sub(cur).Seconds()
+			}
+			cur = ev.At
+		}
+		running = isRunning(ev.To)
+	}
+	if running {
+		secs += end.Sub(cur).Seconds()
+	}
+	return secs / 3600
+}
+
+// lifeHours return the part of [start, end) during which the resource existed
+func lifeHours(createdAt time.Time, deleted bool, deletedAt time.Time, start, end time.Time) (time.Time, time.Time) {
+	if createdAt.After(start) {
+		start = createdAt
+	}
+	if deleted && deletedAt.Before(end) {
+		end = deletedAt
+	}
+	return start, end
+}
+
+type sUsageKey struct {
+	projectId    string
+	domainId     string
+	resourceType string
+	metric       string
+	spec         string
+	tags         string
+}
+
+type sUsageAmount struct {
+	count    int
+	quantity float64
+}
+
+type sUsageMeter struct {
+	start  time.Time
+	end    time.Time
+	usages map[sUsageKey]*sUsageAmount
+}
+
+func newUsageMeter(start time.Time) *sUsageMeter {
+	return &sUsageMeter{
+		start:  start,
+		end:    start.Add(time.Hour),
+		usages: map[sUsageKey]*sUsageAmount{},
+	}
+}
+
+func (meter *sUsageMeter) add(owner mcclient.IIdentityProvider, resourceType string, metric string, spec string, tags string, quantity float64) {
+	if quantity <= 0 {
+		return
+	}
+	key := sUsageKey{
+		projectId:    owner.GetProjectId(),
+		domainId:     owner.GetProjectDomainId(),
+		resourceType: resourceType,
+		metric:       metric,
+		spec:         spec,
+		tags:         tags,
+	}
+	amount, ok := meter.usages[key]
+	if !ok {
+		amount = &sUsageAmount{}
+		meter.usages[key] = amount
+	}
+	amount.count += 1
+	amount.quantity += quantity
+}
+
+func resourceTags(obj interface {
+	GetAllUserMetadata() (map[string]string, error)
+}) string {
+	tags, _ := obj.GetAllUserMetadata()
+	if len(tags) == 0 {
+		return ""
+	}
+	return jsonutils.Marshal(tags).String()
+}
+
+func isGuestRunningStatus(status string) bool {
+	return status == api.VM_RUNNING
+}
+
+func alwaysRunning(status string) bool {
+	return true
+}
+
+// rawQueryAlive query resources existed within the hour, including those deleted during it
+func (meter *sUsageMeter) rawQueryAlive(manager db.IModelManager) *sqlchemy.SQuery {
+	q := manager.RawQuery()
+	q = q.Filter(sqlchemy.LT(q.Field("created_at"), meter.end))
+	q = q.Filter(sqlchemy.OR(
+		sqlchemy.IsFalse(q.Field("deleted")),
+		sqlchemy.GE(q.Field("deleted_at"), meter.start),
+	))
+	return q
+}
+
+func (meter *sUsageMeter) fetchStatusEvents(objType string) (map[string][]sStatusEvent, error) {
+	q := db.OpsLog.Query().Equals("obj_type", objType).Equals("action", db.ACT_UPDATE_STATUS).GE("ops_time", meter.start).Asc("ops_time")
+	logs := []db.SOpsLog{}
+	err := db.FetchModelObjects(db.OpsLog, q, &logs)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	ret := map[string][]sStatusEvent{}
+	for i := range logs {
+		from, to, ok := parseStatusNotes(logs[i].Notes)
+		if !ok {
+			continue
+		}
+		ret[logs[i].ObjId] = append(ret[logs[i].ObjId], sStatusEvent{At: logs[i].OpsTime, From: from, To: to})
+	}
+	return ret, nil
+}
+
+func (meter *sUsageMeter) meterGuests() error {
+	q := meter.rawQueryAlive(GuestManager).NotIn("hypervisor", api.PUBLIC_CLOUD_HYPERVISORS)
+	guests := []SGuest{}
+	err := db.FetchModelObjects(GuestManager, q, &guests)
+	if err != nil {
+		return errors.Wrap(err, "fetch guests")
+	}
+	events, err := meter.fetchStatusEvents(GuestManager.Keyword())
+	if err != nil {
+		return errors.Wrap(err, "fetch guest status events")
+	}
+	for i := range guests {
+		guest := &guests[i]
+		start, end := lifeHours(guest.CreatedAt, guest.Deleted, guest.DeletedAt, meter.start, meter.end)
+		hours := runningHours(guest.Status, events[guest.Id], start, end, isGuestRunningStatus)
+		if hours <= 0 {
+			continue
+		}
+		owner := guest.GetOwnerId()
+		tags := resourceTags(guest)
+		meter.add(owner, api.APPROVAL_RESOURCE_SERVER, api.COST_METRIC_CPU, "", tags, float64(guest.VcpuCount)*hours)
+		meter.add(owner, api.APPROVAL_RESOURCE_SERVER, api.COST_METRIC_MEM, "", tags, float64(guest.VmemSize)/1024*hours)
+		for _, dev := range guest.GetIsolatedDevices() {
+			if utils.IsInStringArray(dev.DevType, api.VALID_GPU_TYPES) {
+				meter.add(owner, api.APPROVAL_RESOURCE_SERVER, api.COST_METRIC_GPU, dev.Model, tags, hours)
+			}
+		}
+	}
+	return nil
+}
+
+func (meter *sUsageMeter) onPremiseManagerFilter(q *sqlchemy.SQuery, field sqlchemy.IQueryField) *sqlchemy.SQuery {
+	providers := CloudproviderManager.Query("id").In("provider", append(api.PRIVATE_CLOUD_PROVIDERS, api.CLOUD_PROVIDER_VMWARE)).SubQuery()
+	return q.Filter(sqlchemy.OR(
+		sqlchemy.IsNullOrEmpty(field),
+		sqlchemy.In(field, providers),
+	))
+}
+
+func (meter *sUsageMeter) meterDisks() error {
+	q := meter.rawQueryAlive(DiskManager)
+	storages := StorageManager.Query().SubQuery()
+	q = q.Join(storages, sqlchemy.Equals(q.Field("storage_id"), storages.Field("id")))
+	q = meter.onPremiseManagerFilter(q, storages.Field("manager_id"))
+	q = q.AppendField(storages.Field("medium_type"))
+	disks := []struct {
+		SDisk
+		MediumType string
+	}{}
+	err := q.All(&disks)
+	if err != nil {
+		return errors.Wrap(err, "fetch disks")
+	}
+	for i := range disks {
+		disk := &disks[i].SDisk
+		disk.SetModelManager(DiskManager, disk)
+		start, end := lifeHours(disk.CreatedAt, disk.Deleted, disk.DeletedAt, meter.start, meter.end)
+		hours := runningHours("", nil, start, end, alwaysRunning)
+		meter.add(disk.GetOwnerId(), api.APPROVAL_RESOURCE_DISK, api.COST_METRIC_DISK, disks[i].MediumType, resourceTags(disk), float64(disk.DiskSize)/1024*hours)
+	}
+	return nil
+}
+
+func (meter *sUsageMeter) meterEips() error {
+	q := meter.rawQueryAlive(ElasticipManager)
+	q = meter.onPremiseManagerFilter(q, q.Field("manager_id"))
+	eips := []SElasticip{}
+	err := db.FetchModelObjects(ElasticipManager, q, &eips)
+	if err != nil {
+		return errors.Wrap(err, "fetch eips")
+	}
+	for i := range eips {
+		eip := &eips[i]
+		start, end := lifeHours(eip.CreatedAt, eip.Deleted, eip.DeletedAt, meter.start, meter.end)
+		hours := runningHours("", nil, start, end, alwaysRunning)
+		owner := eip.GetOwnerId()
+		tags := resourceTags(eip)
+		meter.add(owner, ElasticipManager.Keyword(), api.COST_METRIC_EIP, "", tags, hours)
+		meter.add(owner, ElasticipManager.Keyword(), api.COST_METRIC_BANDWIDTH, "", tags, float64(eip.Bandwidth)*hours)
+	}
+	return nil
+}
+
+// save rewrites records of the hour in one transaction, so an hour interrupted
+// halfway is neither left partial nor doubled when metered again
+func (meter *sUsageMeter) save(ctx context.Context, rates SCostRates) error {
+	tx, err := sqlchemy.GetDB().Begin()
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	table := UsageRecordManager.TableSpec().Name()
+	_, err = tx.Exec(fmt.Sprintf("delete from %s where usage_hour = ?", table), meter.start)
+	if err != nil {
+		return errors.Wrapf(err, "delete usage records of %s", meter.start)
+	}
+	insertSql := fmt.Sprintf(
+		"insert into %s (usage_hour, resource_type, metric, spec, tags, resource_count, quantity, price, cost, currency, tenant_id, domain_id, created_at, updated_at) "+
+			"values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", table,
+	)
+	now := time.Now().UTC()
+	for key, amount := range meter.usages {
+		price, currency := rates.Get(key.metric, key.spec)
+		var tags interface{}
+		if len(key.tags) > 0 {
+			tags = key.tags
+		}
+		_, err := tx.Exec(insertSql,
+			meter.start, key.resourceType, key.metric, key.spec, tags, amount.count, amount.quantity,
+			price, price*amount.quantity, currency, key.projectId, key.domainId, now, now,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "insert usage record of %s", key.projectId)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "commit usage records")
+	}
+	return nil
+}
+
+func (manager *SUsageRecordManager) meterHour(ctx context.Context, hour time.Time, rates SCostRates) error {
+	meter := newUsageMeter(hour)
+	for _, f := range []func() error{
+		meter.meterGuests,
+		meter.meterDisks,
+		meter.meterEips,
+	} {
+		err := f()
+		if err != nil {
+			return err
+		}
+	}
+	return meter.save(ctx, rates)
+}
+
+func (manager *SUsageRecordManager) lastMeteredHour() (time.Time, error) {
+	q := manager.Query().Desc("usage_hour")
+	record := SUsageRecord{}
+	err := q.First(&record)
+	if err != nil {
+		if errors.Cause(err) == sqlchemy.ErrEmptyQuery {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return record.UsageHour, nil
+}
+
+// MeterUsages aggregate usage of every finished hour not metered yet
+func (manager *SUsageRecordManager) MeterUsages(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	current := time.Now().UTC().Truncate(time.Hour)
+	last, err := manager.lastMeteredHour()
+	if err != nil {
+		log.Errorf("fetch last metered hour fail: %s", err)
+		return
+	}
+	hour := last.Add(time.Hour)
+	if earliest := current.Add(-usageMeterMaxBackfillHours * time.Hour); hour.Before(earliest) {
+		if !last.IsZero() {
+			log.Errorf("usage from %s to %s is not metered, more than %d hours behind", hour, earliest, usageMeterMaxBackfillHours)
+		}
+		hour = earliest
+	}
+	if last.IsZero() {
+		hour = current.Add(-time.Hour)
+	}
+	rates, err := CostRateManager.FetchRates()
+	if err != nil {
+		log.Errorf("fetch cost rates fail: %s", err)
+		return
+	}
+	for ; hour.Before(current); hour = hour.Add(time.Hour) {
+		err := manager.meterHour(ctx, hour, rates)
+		if err != nil {
+			log.Errorf("meter usage of %s fail: %s", hour, err)
+			return
+		}
+	}
+}
+
+func (manager *SUsageRecordManager) AllowGetPropertyShowbackReport(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsProjectAllowList(userCred, manager)
+}
+
+type sShowbackCost struct {
+	TenantId string
+	DomainId string
+	Metric   string
+	Currency string
+	Tags     string
+	Cost     float64
+}
+
+// 费用分摊报表
+func (manager *SUsageRecordManager) GetPropertyShowbackReport(ctx context.Context, userCred mcclient.TokenCredential, input api.ShowbackReportInput) (jsonutils.JSONObject, error) {
+	if input.EndTime.IsZero() {
+		input.EndTime = time.Now().UTC()
+	}
+	if input.StartTime.IsZero() {
+		year, month, _ := input.EndTime.Date()
+		input.StartTime = time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if !input.EndTime.After(input.StartTime) {
+		return nil, httperrors.NewInputParameterError("end_time must be later than start_time")
+	}
+	if len(input.GroupBy) == 0 {
+		input.GroupBy = api.SHOWBACK_GROUP_BY_PROJECT
+	}
+	switch input.GroupBy {
+	case api.SHOWBACK_GROUP_BY_PROJECT, api.SHOWBACK_GROUP_BY_DOMAIN:
+	case api.SHOWBACK_GROUP_BY_TAG:
+		input.TagKey = strings.TrimPrefix(input.TagKey, db.USER_TAG_PREFIX)
+		if len(input.TagKey) == 0 {
+			return nil, httperrors.NewMissingParameterError("tag_key")
+		}
+	default:
+		return nil, httperrors.NewInputParameterError("invalid group_by %s", input.GroupBy)
+	}
+
+	scope := policy.PolicyManager.AllowScope(userCred, consts.GetServiceType(), manager.KeywordPlural(), policy.PolicyActionList)
+	q := manager.Query().GE("usage_hour", input.StartTime).LT("usage_hour", input.EndTime)
+	q = manager.FilterByOwner(q, userCred, scope)
+	records := q.SubQuery()
+	sq := records.Query(
+		records.Field("tenant_id"),
+		records.Field("domain_id"),
+		records.Field("metric"),
+		records.Field("currency"),
+		records.Field("tags"),
+		sqlchemy.SUM("cost", records.Field("cost")),
+	).GroupBy(
+		records.Field("tenant_id"),
+		records.Field("domain_id"),
+		records.Field("metric"),
+		records.Field("currency"),
+		records.Field("tags"),
+	)
+	costs := []sShowbackCost{}
+	err := sq.All(&costs)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "query costs"))
+	}
+
+	output := api.ShowbackReportOutput{
+		StartTime: input.StartTime,
+		EndTime:   input.EndTime,
+		GroupBy:   input.GroupBy,
+		Rows:      showbackReportRows(ctx, costs, input.GroupBy, input.TagKey),
+	}
+	if input.Format == api.SHOWBACK_FORMAT_CSV {
+		output.Csv, err = showbackReportCsv(output.Rows)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(err)
+		}
+	}
+	return jsonutils.Marshal(output), nil
+}
+
+func showbackReportRows(ctx context.Context, costs []sShowbackCost, groupBy string, tagKey string) []api.ShowbackReportRow {
+	rows := map[string]*api.ShowbackReportRow{}
+	for _, cost := range costs {
+		var key string
+		switch groupBy {
+		case api.SHOWBACK_GROUP_BY_DOMAIN:
+			key = cost.DomainId
+		case api.SHOWBACK_GROUP_BY_TAG:
+			if len(cost.Tags) > 0 {
+				if tags, err := jsonutils.ParseString(cost.Tags); err == nil {
+					key, _ = tags.GetString(tagKey)
+				}
+			}
+		default:
+			key = cost.TenantId
+		}
+		rowKey := fmt.Sprintf("%s/%s", key, cost.Currency)
+		row, ok := rows[rowKey]
+		if !ok {
+			row = &api.ShowbackReportRow{Key: key, Name: key, Currency: cost.Currency}
+			rows[rowKey] = row
+		}
+		switch cost.Metric {
+		case api.COST_METRIC_CPU:
+			row.Cpu += cost.Cost
+		case api.COST_METRIC_MEM:
+			row.Mem += cost.Cost
+		case api.COST_METRIC_DISK:
+			row.Disk += cost.Cost
+		case api.COST_METRIC_EIP:
+			row.Eip += cost.Cost
+		case api.COST_METRIC_BANDWIDTH:
+			row.Bandwidth += cost.Cost
+		case api.COST_METRIC_GPU:
+			row.Gpu += cost.Cost
+		}
+		row.Total += cost.Cost
+	}
+	ret := make([]api.ShowbackReportRow, 0, len(rows))
+	for _, row := range rows {
+		switch groupBy {
+		case api.SHOWBACK_GROUP_BY_PROJECT:
+			if tenant, err := db.TenantCacheManager.FetchTenantById(ctx, row.Key); err == nil {
+				row.Name = tenant.Name
+			}
+		case api.SHOWBACK_GROUP_BY_DOMAIN:
+			if domain, err := db.TenantCacheManager.FetchDomainById(ctx, row.Key); err == nil {
+				row.Name = domain.Name
+			}
+		}
+		ret = append(ret, *row)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Total != ret[j].Total {
+			return ret[i].Total > ret[j].Total
+		}
+		return ret[i].Key < ret[j].Key
+	})
+	return ret
+}
+
+func showbackReportCsv(rows []api.ShowbackReportRow) (string, error) {
+	data := make([]jsonutils.JSONObject, len(rows))
+	for i := range rows {
+		data[i] = jsonutils.Marshal(rows[i])
+	}
+	keys := []string{"key", "name", "currency", "cpu", "mem", "disk", "eip", "bandwidth", "gpu", "total"}
+	texts := []string{"Key", "Name", "Currency", "CPU", "Memory", "Disk", "EIP", "Bandwidth", "GPU", "Total"}
+	buf := &bytes.Buffer{}
+	err := excelutils.ExportCsv(data, keys, texts, buf)
+	if err != nil {
+		return "", errors.Wrap(err, "ExportCsv")
+	}
+	return buf.String(), nil
+}
+
+func (manager *SUsageRecordManager) ResourceScope() rbacutils.TRbacScope {
+	return manager.SProjectizedResourceBaseManager.ResourceScope()
+}
+
+func (manager *SUsageRecordManager) NamespaceScope() rbacutils.TRbacScope {
+	return manager.SProjectizedResourceBaseManager.NamespaceScope()
+}
+
+func (manager *SUsageRecordManager) FilterByOwner(q *sqlchemy.SQuery, owner mcclient.IIdentityProvider, scope rbacutils.TRbacScope) *sqlchemy.SQuery {
+	return manager.SProjectizedResourceBaseManager.FilterByOwner(q, owner, scope)
+}
+
+func (manager *SUsageRecordManager) FetchOwnerId(ctx context.Context, data jsonutils.JSONObject) (mcclient.IIdentityProvider, error) {
+	return manager.SProjectizedResourceBaseManager.FetchOwnerId(ctx, data)
+}
+
+func (self *SUsageRecord) GetOwnerId() mcclient.IIdentityProvider {
+	return self.SProjectizedResourceBase.GetOwnerId()
+}
+
+func (self *SUsageRecord) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, isList bool) (api.UsageRecordDetails, error) {
+	return api.UsageRecordDetails{}, nil
+}
+
+func (manager *SUsageRecordManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.UsageRecordDetails {
+	rows := make([]api.UsageRecordDetails, len(objs))
+	baseRows := manager.SResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	projRows := manager.SProjectizedResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.UsageRecordDetails{
+			ResourceBaseDetails:     baseRows[i],
+			ProjectizedResourceInfo: projRows[i],
+		}
+	}
+	return rows
+}
+
+// 计量记录列表
+func (manager *SUsageRecordManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.UsageRecordListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.ListItemFilter(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Metric) > 0 {
+		q = q.In("metric", query.Metric)
+	}
+	if len(query.ResourceType) > 0 {
+		q = q.In("resource_type", query.ResourceType)
+	}
+	if !query.StartTime.IsZero() {
+		q = q.GE("usage_hour", query.StartTime)
+	}
+	if !query.EndTime.IsZero() {
+		q = q.LT("usage_hour", query.EndTime)
+	}
+	return q, nil
+}
+
+func (manager *SUsageRecordManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.UsageRecordListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SResourceBaseManager.OrderByExtraFields")
+	}
+	q, err = manager.SProjectizedResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.ProjectizedResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SProjectizedResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SUsageRecordManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SProjectizedResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunningHours(t *testing.T) {
+	start := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	at := func(min int) time.Time {
+		return start.Add(time.Duration(min) * time.Minute)
+	}
+	cases := []struct {
+		name    string
+		current string
+		events  []sStatusEvent
+		want    float64
+	}{
+		{"running all the hour", "running", nil, 1},
+		{"ready all the hour", "ready", nil, 0},
+		{"started in the hour", "running", []sStatusEvent{{at(30), "ready", "running"}}, 0.5},
+		{"stopped in the hour", "ready", []sStatusEvent{{at(15), "running", "ready"}}, 0.25},
+		{
+			"restarted in the hour",
+			"running",
+			[]sStatusEvent{
+				{at(10), "running", "stopping"},
+				{at(12), "stopping", "ready"},
+				{at(40), "ready", "running"},
+			},
+			(10.0 + 20.0) / 60,
+		},
+		{"stopped after the hour", "ready", []sStatusEvent{{end.Add(time.Minute), "running", "ready"}}, 1},
+	}
+	for _, c := range cases {
+		got := runningHours(c.current, c.events, start, end, isGuestRunningStatus)
+		if got-c.want > 1e-9 || c.want-got > 1e-9 {
+			t.Errorf("%s: want %f got %f", c.name, c.want, got)
+		}
+	}
+}
@@ -469,7 +469,12 @@ func (manager *SVpcManager) SyncVPCs(ctx context.Context, userCred mcclient.Toke
 		return nil, nil, syncResult
 	}
 
+	plan := newSyncPlanScope(ctx, manager, len(dbVPCs))
 	for i := 0; i < len(removed); i += 1 {
+		vpc := &removed[i]
+		if plan.Remove(vpc, func() error { return vpc.syncRemoveCloudVpc(ctx, userCred) }) {
+			continue
+		}
 		err = removed[i].syncRemoveCloudVpc(ctx, userCred)
 		if err != nil {
 			syncResult.DeleteError(err)
@@ -478,6 +483,11 @@ func (manager *SVpcManager) SyncVPCs(ctx context.Context, userCred mcclient.Toke
 		}
 	}
 	for i := 0; i < len(commondb); i += 1 {
+		if plan.Update(&commondb[i], commonext[i]) {
+			localVPCs = append(localVPCs, commondb[i])
+			remoteVPCs = append(remoteVPCs, commonext[i])
+			continue
+		}
 		err = commondb[i].SyncWithCloudVpc(ctx, userCred, commonext[i], provider)
 		if err != nil {
 			syncResult.UpdateError(err)
@@ -493,6 +503,9 @@ func (manager *SVpcManager) SyncVPCs(ctx context.Context, userCred mcclient.Toke
 		}
 	}
 	for i := 0; i < len(added); i += 1 {
+		if plan.Add(added[i]) {
+			continue
+		}
 		newVpc, err := manager.newFromCloudVpc(ctx, userCred, added[i], provider, region)
 		if err != nil {
 			syncResult.AddError(err)
@@ -312,7 +312,12 @@ func (manager *SWireManager) SyncWires(ctx context.Context, userCred mcclient.To
 		return nil, nil, syncResult
 	}
 
+	plan := newSyncPlanScope(ctx, manager, len(dbWires))
 	for i := 0; i < len(removed); i += 1 {
+		wire := &removed[i]
+		if plan.Remove(wire, func() error { return wire.syncRemoveCloudWire(ctx, userCred) }) {
+			continue
+		}
 		err = removed[i].syncRemoveCloudWire(ctx, userCred)
 		if err != nil { // cannot delete
 			syncResult.DeleteError(err)
@@ -321,6 +326,11 @@ func (manager *SWireManager) SyncWires(ctx context.Context, userCred mcclient.To
 		}
 	}
 	for i := 0; i < len(commondb); i += 1 {
+		if plan.Update(&commondb[i], commonext[i]) {
+			localWires = append(localWires, commondb[i])
+			remoteWires = append(remoteWires, commonext[i])
+			continue
+		}
 		err = commondb[i].syncWithCloudWire(ctx, userCred, commonext[i], vpc, provider)
 		if err != nil {
 			syncResult.UpdateError(err)
@@ -332,6 +342,9 @@ func (manager *SWireManager) SyncWires(ctx context.Context, userCred mcclient.To
 		}
 	}
 	for i := 0; i < len(added); i += 1 {
+		if plan.Add(added[i]) {
+			continue
+		}
 		new, err := manager.newFromCloudWire(ctx, userCred, added[i], vpc, provider)
 		if err != nil {
 			syncResult.AddError(err)
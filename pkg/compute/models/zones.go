@@ -215,7 +215,12 @@ func (manager *SZoneManager) SyncZones(ctx context.Context, userCred mcclient.To
 		return nil, nil, syncResult
 	}
 
+	plan := newSyncPlanScope(ctx, manager, len(dbZones))
 	for i := 0; i < len(removed); i += 1 {
+		zone := &removed[i]
+		if plan.Remove(zone, func() error { return zone.syncRemoveCloudZone(ctx, userCred) }) {
+			continue
+		}
 		err = removed[i].syncRemoveCloudZone(ctx, userCred)
 		if err != nil {
 			syncResult.DeleteError(err)
@@ -224,6 +229,11 @@ func (manager *SZoneManager) SyncZones(ctx context.Context, userCred mcclient.To
 		}
 	}
 	for i := 0; i < len(commondb); i += 1 {
+		if plan.Update(&commondb[i], commonext[i]) {
+			localZones = append(localZones, commondb[i])
+			remoteZones = append(remoteZones, commonext[i])
+			continue
+		}
 		err = commondb[i].syncWithCloudZone(ctx, userCred, commonext[i], region)
 		if err != nil {
 			syncResult.UpdateError(err)
@@ -235,6 +245,9 @@ func (manager *SZoneManager) SyncZones(ctx context.Context, userCred mcclient.To
 		}
 	}
 	for i := 0; i < len(added); i += 1 {
+		if plan.Add(added[i]) {
+			continue
+		}
 		new, err := manager.newFromCloudZone(ctx, userCred, added[i], region)
 		if err != nil {
 			syncResult.AddError(err)
@@ -47,6 +47,9 @@ type ComputeOptions struct {
 	PrepaidAutoRenew      bool `default:"true" help:"auto renew prepaid servers when server's auto_renew attr is true"`
 	PrepaidAutoRenewHours int  `default:"3" help:"How long to wait to scan which need renew prepaid VMs, default is 3 hours"`
 
+	ApprovalExpireCheckSeconds   int `default:"300" help:"How long to wait to scan expired approval requests, default is 5 minutes"`
+	UsageMeteringIntervalSeconds int `default:"600" help:"How often to meter usage of on-premise resources into hourly usage records, default is 10 minutes"`
+
 	LoadbalancerPendingDeleteCheckInterval int `default:"3600" help:"Interval between checks of pending deleted loadbalancer objects, defaults to 1h"`
 
 	ImageCacheStoragePolicy string `default:"least_used" choices:"best_fit|least_used" help:"Policy to choose storage for image cache, best_fit or least_used"`
@@ -133,12 +136,19 @@ type ComputeOptions struct {
 
 	SyncPurgeRemovedResources []string `help:"resources that shoud be purged immediately if found removed" default:"server"`
 
+	CloudSyncRemovalThresholdPercent int `help:"abort cloud sync if it would remove more than this percent of local records of any resource type, 0 to disable" default:"0"`
+	CloudSyncRemovalCheckMinCount    int `help:"resource types with less local records than this are not checked against cloud_sync_removal_threshold_percent" default:"10"`
+
 	DisconnectedCloudAccountRetryProbeIntervalHours int `help:"interval to wait to probe status of a disconnected cloud account" default:"2"`
 
 	BaremetalServerReuseHostIp bool `help:"baremetal server reuse host IP address, default true" default:"true"`
 
+	FirmwareMaintenanceWindowMaxWaitSeconds int `help:"reject firmware updates whose maintenance window starts later than this, keep it no more than firmware_maintenance_window_max_wait_seconds of baremetal agent" default:"900"`
+
 	EnableHostHealthCheck bool `help:"enable host health check" default:"true"`
 	HostHealthTimeout     int  `help:"second of wait host reconnect" default:"60"`
+	HostFenceWatchdogWait int  `help:"second of wait host shutdown servers by itself before evacuating" default:"30"`
+	HostFenceIpmiTimeout  int  `help:"second of wait host power off after fenced through ipmi" default:"60"`
 
 	FetchEtcdServiceInfoAndUseEtcdLock bool `default:"true" help:"fetch etcd service info and use etcd lock"`
 
@@ -158,6 +168,7 @@ type ComputeOptions struct {
 
 	SCapabilityOptions
 	SASControllerOptions
+	SRebalanceControllerOptions
 	common_options.CommonOptions
 	common_options.DBOptions
 
@@ -188,6 +199,18 @@ type SASControllerOptions struct {
 	CheckHealthInterval int `help:"The interval bewteen the two check about instance's health unit: m" default:"1"`
 }
 
+type SRebalanceControllerOptions struct {
+	EnableRebalance                bool    `help:"Enable continuous load rebalancing across kvm hosts" default:"false"`
+	RebalanceMode                  string  `help:"Only recommend or execute rebalance migrations" choices:"recommend|execute" default:"recommend"`
+	RebalanceCheckInterval         int     `help:"The interval between two rebalance evaluations, unit: m" default:"30"`
+	RebalanceMetricsWindow         int     `help:"The time window of metrics used to evaluate host load, unit: m" default:"15"`
+	RebalanceCpuThreshold          float64 `help:"Host cpu usage percent above which the host is overloaded" default:"80"`
+	RebalanceMemThreshold          float64 `help:"Host memory usage percent above which the host is overloaded" default:"85"`
+	RebalanceMinImprovement        float64 `help:"Minimal load percent gap between source and target host after a migration" default:"10"`
+	RebalanceMaxMigrationsPerRound int     `help:"The upper limit of concurrent rebalance migrations" default:"2"`
+	RebalanceMaxMigrationsPerHost  int     `help:"The upper limit of rebalance migrations from or to a host in one round" default:"1"`
+}
+
 var (
 	Options ComputeOptions
 )
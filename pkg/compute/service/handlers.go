@@ -93,6 +93,7 @@ func InitHandlers(app *appsrv.Application) {
 		models.ScheduledTaskLabelManager,
 		models.DnsRecordSetTrafficPolicyManager,
 		models.CloudimageManager,
+		models.StackResourceManager,
 	} {
 		db.RegisterModelManager(manager)
 	}
@@ -115,9 +116,7 @@ func InitHandlers(app *appsrv.Application) {
 		models.CachedimageManager,
 		models.HostManager,
 		models.SchedtagManager,
-		models.GuestManager,
 		models.GroupManager,
-		models.DiskManager,
 		models.NetworkManager,
 		models.NetworkAddressManager,
 		models.ReservedipManager,
@@ -203,12 +202,31 @@ func InitHandlers(app *appsrv.Application) {
 
 		models.VpcPeeringConnectionManager,
 		models.InterVpcNetworkManager,
+
+		models.BiosProfileManager,
+
+		models.StackManager,
+
+		models.ApprovalPolicyManager,
+		models.ApprovalRequestManager,
+		models.CostRateManager,
+		models.UsageRecordManager,
 	} {
 		db.RegisterModelManager(manager)
 		handler := db.NewModelHandler(manager)
 		dispatcher.AddModelDispatcher("", app, handler)
 	}
 
+	// requests to these resources may be held back by approval policies
+	for _, manager := range []db.IModelManager{
+		models.GuestManager,
+		models.DiskManager,
+	} {
+		db.RegisterModelManager(manager)
+		handler := models.NewApprovalModelHandler(manager)
+		dispatcher.AddModelDispatcher("", app, handler)
+	}
+
 	for _, manager := range []db.IJointModelManager{
 		models.HostwireManager,
 		models.HostnetworkManager,
@@ -43,6 +43,7 @@ import (
 	_ "yunion.io/x/onecloud/pkg/compute/storagedrivers"
 	_ "yunion.io/x/onecloud/pkg/compute/tasks"
 	"yunion.io/x/onecloud/pkg/controller/autoscaling"
+	"yunion.io/x/onecloud/pkg/controller/rebalance"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/multicloud/esxi"
 	_ "yunion.io/x/onecloud/pkg/multicloud/loader"
@@ -158,11 +159,16 @@ func StartService() {
 
 		cron.AddJobEveryFewHour("InspectAllTemplate", 1, 0, 0, models.GuestTemplateManager.InspectAllTemplate, true)
 
+		cron.AddJobAtIntervals("ExpireApprovalRequests", time.Duration(opts.ApprovalExpireCheckSeconds)*time.Second, models.ApprovalRequestManager.ExpireApprovalRequests)
+		cron.AddJobAtIntervals("MeterUsages", time.Duration(opts.UsageMeteringIntervalSeconds)*time.Second, models.UsageRecordManager.MeterUsages)
+
 		cron.AddJobAtIntervalsWithStartRun("ScheduledTaskCheck", time.Duration(60)*time.Second, models.ScheduledTaskManager.Timer, true)
 		go cron.Start2(ctx, electObj)
 
 		// init auto scaling controller
 		autoscaling.ASController.Init(options.Options.SASControllerOptions, cron)
+		// init host load rebalance controller
+		rebalance.RebalanceController.Init(options.Options.SRebalanceControllerOptions, cron)
 	}
 
 	app_common.ServeForever(app, baseOpts)
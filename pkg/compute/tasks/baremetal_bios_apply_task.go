@@ -0,0 +1,63 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type BaremetalBiosApplyTask struct {
+	SBaremetalBaseTask
+}
+
+func init() {
+	taskman.RegisterTask(BaremetalBiosApplyTask{})
+}
+
+func (self *BaremetalBiosApplyTask) OnInit(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+	baremetal := obj.(*models.SHost)
+	baremetal.SetStatus(self.UserCred, api.BAREMETAL_BIOS_APPLYING, "")
+	url := fmt.Sprintf("/baremetals/%s/bios-apply", baremetal.Id)
+	headers := self.GetTaskRequestHeader()
+	self.SetStage("OnBiosApplyComplete", nil)
+	_, err := baremetal.BaremetalSyncRequest(ctx, "POST", url, headers, self.Params)
+	if err != nil {
+		self.OnFailure(ctx, baremetal, jsonutils.NewString(err.Error()))
+	}
+}
+
+func (self *BaremetalBiosApplyTask) OnFailure(ctx context.Context, baremetal *models.SHost, reason jsonutils.JSONObject) {
+	logclient.AddActionLogWithStartable(self, baremetal, logclient.ACT_BIOS_APPLY, reason, self.UserCred, false)
+	baremetal.SetStatus(self.UserCred, api.BAREMETAL_BIOS_APPLY_FAIL, reason.String())
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *BaremetalBiosApplyTask) OnBiosApplyComplete(ctx context.Context, baremetal *models.SHost, body jsonutils.JSONObject) {
+	logclient.AddActionLogWithStartable(self, baremetal, logclient.ACT_BIOS_APPLY, body, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *BaremetalBiosApplyTask) OnBiosApplyCompleteFailed(ctx context.Context, baremetal *models.SHost, body jsonutils.JSONObject) {
+	self.OnFailure(ctx, baremetal, body)
+}
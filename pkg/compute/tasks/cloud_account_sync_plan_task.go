@@ -0,0 +1,69 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// CloudAccountSyncPlanTask runs a dry run sync of the account and keeps the planned changes in its metadata
+type CloudAccountSyncPlanTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(CloudAccountSyncPlanTask{})
+}
+
+func (self *CloudAccountSyncPlanTask) OnInit(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+	cloudaccount := obj.(*models.SCloudaccount)
+
+	syncRange := models.SSyncRange{}
+	self.Params.Unmarshal(&syncRange, "sync_range")
+
+	self.SetStage("OnSyncPlanned", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		plan, err := cloudaccount.PlanSync(ctx, self.UserCred, &syncRange)
+		if err != nil {
+			return nil, errors.Wrap(err, "PlanSync")
+		}
+		return jsonutils.Marshal(plan.Output()), nil
+	})
+}
+
+func (self *CloudAccountSyncPlanTask) OnSyncPlanned(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+	cloudaccount := obj.(*models.SCloudaccount)
+	err := cloudaccount.SetMetadata(ctx, models.CLOUD_ACCOUNT_METADATA_SYNC_PLAN, body, self.UserCred)
+	if err != nil {
+		self.OnSyncPlannedFailed(ctx, obj, jsonutils.NewString(err.Error()))
+		return
+	}
+	logclient.AddActionLogWithStartable(self, cloudaccount, logclient.ACT_CLOUD_SYNC, "dry run", self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *CloudAccountSyncPlanTask) OnSyncPlannedFailed(ctx context.Context, obj db.IStandaloneModel, err jsonutils.JSONObject) {
+	cloudaccount := obj.(*models.SCloudaccount)
+	logclient.AddActionLogWithStartable(self, cloudaccount, logclient.ACT_CLOUD_SYNC, err, self.UserCred, false)
+	self.SetStageFailed(ctx, err)
+}
@@ -0,0 +1,195 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type GuestChangeDiskStorageTask struct {
+	SGuestBaseTask
+}
+
+func init() {
+	taskman.RegisterTask(GuestChangeDiskStorageTask{})
+}
+
+func (self *GuestChangeDiskStorageTask) getInput() (*api.ServerChangeDiskStorageInternalInput, error) {
+	input := new(api.ServerChangeDiskStorageInternalInput)
+	if err := self.GetParams().Unmarshal(input); err != nil {
+		return nil, errors.Wrap(err, "Unmarshal input")
+	}
+	return input, nil
+}
+
+func (self *GuestChangeDiskStorageTask) getDisk(diskId string) (*models.SDisk, error) {
+	obj, err := models.DiskManager.FetchById(diskId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch disk %s", diskId)
+	}
+	return obj.(*models.SDisk), nil
+}
+
+func (self *GuestChangeDiskStorageTask) taskFailed(ctx context.Context, guest *models.SGuest, reason jsonutils.JSONObject) {
+	input, err := self.getInput()
+	if err == nil {
+		if targetDisk, err := self.getDisk(input.TargetDiskId); err == nil {
+			// the target disk has not been attached to guest, clean it
+			if err := targetDisk.StartDiskDeleteTask(ctx, self.UserCred, "", false, true, false); err != nil {
+				log.Errorf("start delete target disk %s failed: %s", targetDisk.Id, err)
+			}
+		}
+	}
+	guest.SetMetadata(ctx, api.CHANGE_DISK_STORAGE_PROGRESS, "", self.UserCred)
+	db.OpsLog.LogEvent(guest, db.ACT_DISK_CHANGE_STORAGE_FAIL, reason, self.UserCred)
+	logclient.AddActionLogWithContext(ctx, guest, logclient.ACT_VM_CHANGE_DISK_STORAGE, reason, self.UserCred, false)
+	// the guest keeps running on the source disk, restore its status
+	if input != nil && len(input.GuestStatus) > 0 {
+		guest.SetStatus(self.UserCred, input.GuestStatus, reason.String())
+	} else {
+		guest.StartSyncstatus(ctx, self.UserCred, "")
+	}
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *GuestChangeDiskStorageTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	guest := obj.(*models.SGuest)
+	input, err := self.getInput()
+	if err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+		return
+	}
+	targetDisk, err := self.getDisk(input.TargetDiskId)
+	if err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+		return
+	}
+	db.OpsLog.LogEvent(guest, db.ACT_DISK_CHANGING_STORAGE,
+		fmt.Sprintf("disk %s from storage %s to %s", input.DiskId, input.StorageId, input.TargetStorageId), self.UserCred)
+	self.SetStage("OnTargetDiskCreated", nil)
+	if err := targetDisk.StartDiskCreateTask(ctx, self.UserCred, false, "", self.GetTaskId()); err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+	}
+}
+
+func (self *GuestChangeDiskStorageTask) OnTargetDiskCreated(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	input, err := self.getInput()
+	if err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+		return
+	}
+	self.SetStage("OnDiskMirrorComplete", nil)
+	if err := guest.GetDriver().RequestChangeDiskStorage(ctx, self.UserCred, guest, input, self); err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+	}
+}
+
+func (self *GuestChangeDiskStorageTask) OnTargetDiskCreatedFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, guest, data)
+}
+
+func (self *GuestChangeDiskStorageTask) OnDiskMirrorComplete(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	input, err := self.getInput()
+	if err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+		return
+	}
+	srcDisk, err := self.getDisk(input.DiskId)
+	if err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+		return
+	}
+	targetDisk, err := self.getDisk(input.TargetDiskId)
+	if err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+		return
+	}
+	guestdisk := guest.GetGuestDisk(srcDisk.Id)
+	if guestdisk == nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(fmt.Sprintf("disk %s not attached to guest", srcDisk.Id)))
+		return
+	}
+
+	// qemu already pivot to target disk, switch records in db
+	_, err = db.Update(targetDisk, func() error {
+		targetDisk.TemplateId = srcDisk.TemplateId
+		targetDisk.SnapshotId = srcDisk.SnapshotId
+		targetDisk.FsFormat = srcDisk.FsFormat
+		targetDisk.DiskType = srcDisk.DiskType
+		targetDisk.OsArch = srcDisk.OsArch
+		targetDisk.Nonpersistent = srcDisk.Nonpersistent
+		return nil
+	})
+	if err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+		return
+	}
+	_, err = db.Update(guestdisk, func() error {
+		guestdisk.DiskId = targetDisk.Id
+		return nil
+	})
+	if err != nil {
+		self.taskFailed(ctx, guest, jsonutils.NewString(err.Error()))
+		return
+	}
+	db.OpsLog.LogDetachEvent(ctx, guest, srcDisk, self.UserCred, nil)
+	db.OpsLog.LogAttachEvent(ctx, guest, targetDisk, self.UserCred, nil)
+	guest.SetMetadata(ctx, api.CHANGE_DISK_STORAGE_PROGRESS, "100", self.UserCred)
+
+	self.SetStage("OnGuestSyncComplete", nil)
+	if err := guest.StartSyncTask(ctx, self.UserCred, false, self.GetTaskId()); err != nil {
+		self.OnGuestSyncCompleteFailed(ctx, guest, jsonutils.NewString(err.Error()))
+	}
+}
+
+func (self *GuestChangeDiskStorageTask) OnDiskMirrorCompleteFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, guest, data)
+}
+
+func (self *GuestChangeDiskStorageTask) OnGuestSyncComplete(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	input, _ := self.getInput()
+	if !input.KeepOriginDisk {
+		srcDisk, err := self.getDisk(input.DiskId)
+		if err == nil {
+			err = srcDisk.StartDiskDeleteTask(ctx, self.UserCred, "", false, true, false)
+		}
+		if err != nil {
+			log.Errorf("clean source disk %s failed: %s", input.DiskId, err)
+		}
+	}
+	db.OpsLog.LogEvent(guest, db.ACT_DISK_CHANGE_STORAGE,
+		fmt.Sprintf("disk %s changed to %s on storage %s", input.DiskId, input.TargetDiskId, input.TargetStorageId), self.UserCred)
+	logclient.AddActionLogWithContext(ctx, guest, logclient.ACT_VM_CHANGE_DISK_STORAGE, input, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *GuestChangeDiskStorageTask) OnGuestSyncCompleteFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	// disk records already switched, do not delete target disk
+	db.OpsLog.LogEvent(guest, db.ACT_DISK_CHANGE_STORAGE_FAIL, data, self.UserCred)
+	logclient.AddActionLogWithContext(ctx, guest, logclient.ACT_VM_CHANGE_DISK_STORAGE, data, self.UserCred, false)
+	guest.SetStatus(self.UserCred, api.VM_DISK_CHANGE_STORAGE_FAIL, data.String())
+	self.SetStageFailed(ctx, data)
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+func init() {
+	taskman.RegisterTask(GuestHaRebuildTask{})
+}
+
+// GuestHaRebuildTask rebuild a guest of the evacuated host from its latest
+// available instance snapshot, one task for each guest
+type GuestHaRebuildTask struct {
+	SGuestBaseTask
+}
+
+func (self *GuestHaRebuildTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	guest := obj.(*models.SGuest)
+	self.SetStage("OnGuestRebuilt", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		newGuest, err := guest.HaRebuildFromInstanceSnapshot(ctx, self.UserCred)
+		if err != nil {
+			return nil, err
+		}
+		ret := jsonutils.NewDict()
+		ret.Set("guest_id", jsonutils.NewString(newGuest.Id))
+		return ret, nil
+	})
+}
+
+func (self *GuestHaRebuildTask) OnGuestRebuilt(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	self.SetStageComplete(ctx, data.(*jsonutils.JSONDict))
+}
+
+func (self *GuestHaRebuildTask) OnGuestRebuiltFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	reason, _ := data.GetString("__reason__")
+	if len(reason) == 0 {
+		reason = data.String()
+	}
+	log.Errorf("rebuild guest %s failed: %s", guest.Name, reason)
+	db.OpsLog.LogEvent(guest, db.ACT_GUEST_HA_REBUILD_FAIL, reason, self.UserCred)
+	logclient.AddActionLogWithContext(ctx, guest, logclient.ACT_VM_HA_REBUILD, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, jsonutils.NewString(fmt.Sprintf("%s: %s", guest.Name, reason)))
+}
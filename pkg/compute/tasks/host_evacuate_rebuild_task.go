@@ -0,0 +1,112 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+)
+
+func init() {
+	taskman.RegisterTask(HostEvacuateRebuildTask{})
+}
+
+// HostEvacuateRebuildTask rebuild guests of the evacuated host batch by batch,
+// guests of a batch share the same ha priority and are rebuilt concurrently
+type HostEvacuateRebuildTask struct {
+	taskman.STask
+}
+
+func (self *HostEvacuateRebuildTask) getBatches() [][]string {
+	batches := [][]string{}
+	self.GetParams().Unmarshal(&batches, "rebuild_batches")
+	return batches
+}
+
+func (self *HostEvacuateRebuildTask) saveRebuildFailed(reason string) {
+	failed, _ := jsonutils.GetStringArray(self.GetParams(), "rebuild_failed")
+	params := jsonutils.NewDict()
+	params.Set("rebuild_failed", jsonutils.NewStringArray(append(failed, reason)))
+	self.SaveParams(params)
+}
+
+func (self *HostEvacuateRebuildTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	self.rebuildBatch(ctx, obj.(*models.SHost), 0)
+}
+
+func (self *HostEvacuateRebuildTask) rebuildBatch(ctx context.Context, host *models.SHost, index int) {
+	batches := self.getBatches()
+	if index >= len(batches) {
+		self.onAllBatchesRebuilt(ctx, host)
+		return
+	}
+	params := jsonutils.NewDict()
+	params.Set("batch_index", jsonutils.NewInt(int64(index)))
+	self.SaveParams(params)
+	self.SetStage("OnBatchRebuilt", nil)
+	// create all subtasks before running any of them, so the stage won't be
+	// regarded complete before the last subtask is created
+	subtasks := []*taskman.STask{}
+	for _, guestId := range batches[index] {
+		guest := models.GuestManager.FetchGuestById(guestId)
+		if guest == nil {
+			continue
+		}
+		task, err := guest.StartHaRebuildTask(ctx, self.UserCred, self.GetTaskId())
+		if err != nil {
+			log.Errorf("start ha rebuild task for guest %s failed: %s", guest.Name, err)
+			self.saveRebuildFailed(fmt.Sprintf("%s: %s", guest.Name, err))
+			continue
+		}
+		subtasks = append(subtasks, task)
+	}
+	if len(subtasks) == 0 {
+		self.rebuildBatch(ctx, host, index+1)
+		return
+	}
+	for i := range subtasks {
+		subtasks[i].ScheduleRun(nil)
+	}
+}
+
+func (self *HostEvacuateRebuildTask) OnBatchRebuiltFailed(ctx context.Context, host *models.SHost, data jsonutils.JSONObject) {
+	// failed guests are aggregated from subtasks, go on with the next batch
+	self.OnBatchRebuilt(ctx, host, data)
+}
+
+func (self *HostEvacuateRebuildTask) OnBatchRebuilt(ctx context.Context, host *models.SHost, data jsonutils.JSONObject) {
+	index, _ := self.GetParams().Int("batch_index")
+	self.rebuildBatch(ctx, host, int(index)+1)
+}
+
+func (self *HostEvacuateRebuildTask) onAllBatchesRebuilt(ctx context.Context, host *models.SHost) {
+	errs, _ := jsonutils.GetStringArray(self.GetParams(), "rebuild_failed")
+	for _, subtask := range taskman.SubTaskManager.GetTotalSubtasks(self.Id, "OnBatchRebuilt", taskman.SUBTASK_FAIL) {
+		errs = append(errs, getSubtaskFailedReason(subtask.Result))
+	}
+	if len(errs) > 0 {
+		self.SetStageFailed(ctx, jsonutils.NewString(strings.Join(errs, "; ")))
+		return
+	}
+	self.SetStageComplete(ctx, nil)
+}
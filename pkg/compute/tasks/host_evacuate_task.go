@@ -0,0 +1,153 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+func init() {
+	taskman.RegisterTask(HostEvacuateTask{})
+}
+
+// HostEvacuateTask fence the down host, then migrate guests on shared storage
+// and rebuild guests with local storage from instance snapshot in ha priority order
+type HostEvacuateTask struct {
+	taskman.STask
+}
+
+func (self *HostEvacuateTask) getInput() api.HostEvacuateInput {
+	input := api.HostEvacuateInput{}
+	self.GetParams().Unmarshal(&input)
+	return input
+}
+
+func (self *HostEvacuateTask) taskFailed(ctx context.Context, host *models.SHost, status, action string, reason jsonutils.JSONObject) {
+	host.SetStatus(self.UserCred, status, reason.String())
+	db.OpsLog.LogEvent(host, action, reason, self.UserCred)
+	logclient.AddActionLogWithContext(ctx, host, logclient.ACT_HOST_EVACUATE, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *HostEvacuateTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	host := obj.(*models.SHost)
+	input := self.getInput()
+	host.SetStatus(self.UserCred, api.HOST_FENCING, input.FenceMethod)
+	self.SetStage("OnHostFenced", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		return nil, host.FenceHost(ctx, input.FenceMethod)
+	})
+}
+
+func (self *HostEvacuateTask) OnHostFencedFailed(ctx context.Context, host *models.SHost, data jsonutils.JSONObject) {
+	logclient.AddActionLogWithContext(ctx, host, logclient.ACT_HOST_FENCE, data, self.UserCred, false)
+	self.taskFailed(ctx, host, api.HOST_FENCE_FAIL, db.ACT_HOST_FENCE_FAIL, data)
+}
+
+func (self *HostEvacuateTask) OnHostFenced(ctx context.Context, host *models.SHost, data jsonutils.JSONObject) {
+	input := self.getInput()
+	db.OpsLog.LogEvent(host, db.ACT_HOST_FENCE, input.FenceMethod, self.UserCred)
+	logclient.AddActionLogWithContext(ctx, host, logclient.ACT_HOST_FENCE, input.FenceMethod, self.UserCred, true)
+	host.SetStatus(self.UserCred, api.HOST_EVACUATING, "")
+
+	rebuildLocal := input.RebuildLocalGuests != nil && *input.RebuildLocalGuests
+	migrates, rebuilds, err := host.PrepareEvacuateGuests(ctx, self.UserCred, rebuildLocal)
+	if err != nil {
+		self.taskFailed(ctx, host, api.HOST_EVACUATE_FAIL, db.ACT_HOST_EVACUATE_FAIL, jsonutils.NewString(err.Error()))
+		return
+	}
+
+	// migrations and rebuilds run side by side, rebuilds don't wait for unrelated migrations
+	self.SetStage("OnGuestsRecovered", nil)
+	var rebuildTask *taskman.STask
+	if len(rebuilds) > 0 {
+		// created before the migrate task starts, so the stage won't be
+		// regarded complete if migrations finish first
+		rebuildTask, err = host.StartEvacuateRebuildTask(ctx, self.UserCred, rebuilds, self.GetTaskId())
+		if err != nil {
+			log.Errorf("start evacuate rebuild task for host %s failed: %s", host.Name, err)
+			self.saveRecoverFailed(fmt.Sprintf("rebuild guests: %s", err))
+		}
+	}
+	if len(migrates) > 0 {
+		kwargs := jsonutils.NewDict()
+		kwargs.Set("guests", jsonutils.Marshal(migrates))
+		if len(input.PreferHostId) > 0 {
+			kwargs.Set("prefer_host_id", jsonutils.NewString(input.PreferHostId))
+		}
+		if err := models.GuestManager.StartHostGuestsMigrateTask(ctx, self.UserCred, host, kwargs, self.GetTaskId()); err != nil {
+			log.Errorf("start guests migrate task for host %s failed: %s", host.Name, err)
+			self.saveRecoverFailed(fmt.Sprintf("migrate guests: %s", err))
+		}
+	}
+	if rebuildTask != nil {
+		rebuildTask.ScheduleRun(nil)
+		return
+	}
+	if len(taskman.SubTaskManager.GetTotalSubtasks(self.Id, "OnGuestsRecovered", "")) == 0 {
+		self.OnGuestsRecovered(ctx, host, nil)
+	}
+}
+
+func (self *HostEvacuateTask) saveRecoverFailed(reason string) {
+	failed, _ := jsonutils.GetStringArray(self.GetParams(), "recover_failed")
+	params := jsonutils.NewDict()
+	params.Set("recover_failed", jsonutils.NewStringArray(append(failed, reason)))
+	self.SaveParams(params)
+}
+
+func (self *HostEvacuateTask) OnGuestsRecoveredFailed(ctx context.Context, host *models.SHost, data jsonutils.JSONObject) {
+	// results of migrations and rebuilds are aggregated from subtasks
+	self.OnGuestsRecovered(ctx, host, data)
+}
+
+func (self *HostEvacuateTask) OnGuestsRecovered(ctx context.Context, host *models.SHost, data jsonutils.JSONObject) {
+	errs, _ := jsonutils.GetStringArray(self.GetParams(), "recover_failed")
+	for _, subtask := range taskman.SubTaskManager.GetTotalSubtasks(self.Id, "OnGuestsRecovered", taskman.SUBTASK_FAIL) {
+		errs = append(errs, getSubtaskFailedReason(subtask.Result))
+	}
+	if len(errs) > 0 {
+		self.taskFailed(ctx, host, api.HOST_EVACUATE_FAIL, db.ACT_HOST_EVACUATE_FAIL, jsonutils.NewString(strings.Join(errs, "; ")))
+		return
+	}
+
+	originStatus, _ := self.GetParams().GetString("origin_status")
+	host.SetStatus(self.UserCred, originStatus, "evacuated")
+	db.OpsLog.LogEvent(host, db.ACT_HOST_EVACUATE, "", self.UserCred)
+	logclient.AddActionLogWithContext(ctx, host, logclient.ACT_HOST_EVACUATE, "", self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func getSubtaskFailedReason(result string) string {
+	body, err := jsonutils.ParseString(result)
+	if err != nil {
+		return result
+	}
+	if reason, _ := body.GetString("__reason__", "reason"); len(reason) > 0 {
+		return reason
+	}
+	return result
+}
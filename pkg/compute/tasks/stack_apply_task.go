@@ -0,0 +1,138 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type StackApplyTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(StackApplyTask{})
+}
+
+func (self *StackApplyTask) isCreate() bool {
+	return jsonutils.QueryBoolean(self.Params, "is_create", false)
+}
+
+func (self *StackApplyTask) taskFailed(ctx context.Context, stack *models.SStack, reason jsonutils.JSONObject) {
+	action, status := logclient.ACT_UPDATE, api.STACK_STATUS_UPDATE_FAILED
+	if self.isCreate() {
+		action, status = logclient.ACT_CREATE, api.STACK_STATUS_CREATE_FAILED
+	}
+	stack.SetStatus(self.UserCred, status, reason.String())
+	logclient.AddActionLogWithStartable(self, stack, action, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *StackApplyTask) OnInit(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+	stack := obj.(*models.SStack)
+	steps, err := stack.GetApplySteps()
+	if err != nil {
+		self.taskFailed(ctx, stack, jsonutils.NewString(err.Error()))
+		return
+	}
+	params := jsonutils.NewDict()
+	params.Set("steps", jsonutils.Marshal(steps))
+	params.Set("is_create", jsonutils.NewBool(stack.Status == api.STACK_STATUS_CREATING))
+	self.SaveParams(params)
+	self.applyStep(ctx, stack, 0)
+}
+
+func (self *StackApplyTask) getSteps() []models.SStackStep {
+	steps := []models.SStackStep{}
+	self.Params.Unmarshal(&steps, "steps")
+	return steps
+}
+
+// applyStep applies resources one by one in subtasks, each subtask resumes the task when finished
+func (self *StackApplyTask) applyStep(ctx context.Context, stack *models.SStack, idx int) {
+	steps := self.getSteps()
+	if idx >= len(steps) {
+		self.taskComplete(ctx, stack)
+		return
+	}
+	step := steps[idx]
+	self.SetStage("OnResourceApplied", jsonutils.Marshal(map[string]int{"step_index": idx}).(*jsonutils.JSONDict))
+
+	params := jsonutils.NewDict()
+	params.Set("action", jsonutils.NewString(step.Action))
+	var res *models.SStackResource
+	var err error
+	if step.Action == api.STACK_PLAN_DELETE {
+		res, err = stack.GetResource(step.Name)
+	} else {
+		var resType string
+		var props jsonutils.JSONObject
+		var dependsOn []string
+		resType, props, dependsOn, err = stack.RenderResource(step.Name)
+		if err == nil {
+			params.Set("type", jsonutils.NewString(resType))
+			params.Set("properties", props)
+			params.Set("depends_on", jsonutils.NewStringArray(dependsOn))
+			res, err = stack.EnsureResource(ctx, self.UserCred, step.Name, resType)
+		}
+	}
+	if err == nil {
+		err = res.StartApplyTask(ctx, self.UserCred, params, self.GetTaskId())
+	}
+	if err != nil {
+		self.taskFailed(ctx, stack, jsonutils.NewString(errors.Wrapf(err, "%s %s", step.Action, step.Name).Error()))
+	}
+}
+
+func (self *StackApplyTask) OnResourceApplied(ctx context.Context, stack *models.SStack, body jsonutils.JSONObject) {
+	idx, _ := self.Params.Int("step_index")
+	steps := self.getSteps()
+	step := steps[idx]
+	if step.Action == api.STACK_PLAN_DELETE {
+		// resources removed from template are not tracked any more
+		if _, _, _, err := stack.RenderResource(step.Name); errors.Cause(err) == errors.ErrNotFound {
+			if res, err := stack.GetResource(step.Name); err == nil {
+				if err := db.DeleteModel(ctx, self.UserCred, res); err != nil {
+					self.taskFailed(ctx, stack, jsonutils.NewString(errors.Wrapf(err, "delete stack resource %s", step.Name).Error()))
+					return
+				}
+			}
+		}
+	}
+	self.applyStep(ctx, stack, int(idx)+1)
+}
+
+func (self *StackApplyTask) OnResourceAppliedFailed(ctx context.Context, stack *models.SStack, reason jsonutils.JSONObject) {
+	self.taskFailed(ctx, stack, reason)
+}
+
+func (self *StackApplyTask) taskComplete(ctx context.Context, stack *models.SStack) {
+	action := logclient.ACT_UPDATE
+	if self.isCreate() {
+		action = logclient.ACT_CREATE
+	}
+	stack.SetStatus(self.UserCred, api.STACK_STATUS_READY, "")
+	logclient.AddActionLogWithStartable(self, stack, action, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
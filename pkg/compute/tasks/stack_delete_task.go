@@ -0,0 +1,94 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type StackDeleteTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(StackDeleteTask{})
+}
+
+func (self *StackDeleteTask) taskFailed(ctx context.Context, stack *models.SStack, reason jsonutils.JSONObject) {
+	stack.SetStatus(self.UserCred, api.STACK_STATUS_DELETE_FAILED, reason.String())
+	db.OpsLog.LogEvent(stack, db.ACT_DELETE_FAIL, reason, self.UserCred)
+	logclient.AddActionLogWithStartable(self, stack, logclient.ACT_DELETE, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *StackDeleteTask) OnInit(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+	stack := obj.(*models.SStack)
+	steps, err := stack.GetDeleteSteps()
+	if err != nil {
+		self.taskFailed(ctx, stack, jsonutils.NewString(err.Error()))
+		return
+	}
+	params := jsonutils.NewDict()
+	params.Set("steps", jsonutils.Marshal(steps))
+	self.SaveParams(params)
+	self.deleteStep(ctx, stack, 0)
+}
+
+// deleteStep deletes resources one by one, dependents are deleted first
+func (self *StackDeleteTask) deleteStep(ctx context.Context, stack *models.SStack, idx int) {
+	steps := []models.SStackStep{}
+	self.Params.Unmarshal(&steps, "steps")
+	if idx >= len(steps) {
+		self.taskComplete(ctx, stack)
+		return
+	}
+	self.SetStage("OnResourceDeleted", jsonutils.Marshal(map[string]int{"step_index": idx}).(*jsonutils.JSONDict))
+	res, err := stack.GetResource(steps[idx].Name)
+	if err == nil {
+		params := jsonutils.NewDict()
+		params.Set("action", jsonutils.NewString(api.STACK_PLAN_DELETE))
+		err = res.StartApplyTask(ctx, self.UserCred, params, self.GetTaskId())
+	}
+	if err != nil {
+		self.taskFailed(ctx, stack, jsonutils.NewString(errors.Wrapf(err, "delete %s", steps[idx].Name).Error()))
+	}
+}
+
+func (self *StackDeleteTask) OnResourceDeleted(ctx context.Context, stack *models.SStack, body jsonutils.JSONObject) {
+	idx, _ := self.Params.Int("step_index")
+	self.deleteStep(ctx, stack, int(idx)+1)
+}
+
+func (self *StackDeleteTask) OnResourceDeletedFailed(ctx context.Context, stack *models.SStack, reason jsonutils.JSONObject) {
+	self.taskFailed(ctx, stack, reason)
+}
+
+func (self *StackDeleteTask) taskComplete(ctx context.Context, stack *models.SStack) {
+	if err := stack.RealDelete(ctx, self.UserCred); err != nil {
+		self.taskFailed(ctx, stack, jsonutils.NewString(err.Error()))
+		return
+	}
+	logclient.AddActionLogWithStartable(self, stack, logclient.ACT_DELETE, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
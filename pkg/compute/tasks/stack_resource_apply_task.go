@@ -0,0 +1,115 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+)
+
+// StackResourceApplyTask creates, updates or deletes the underlying resource of a stack resource,
+// it runs as subtask of StackApplyTask and StackDeleteTask
+type StackResourceApplyTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(StackResourceApplyTask{})
+}
+
+func (self *StackResourceApplyTask) OnInit(ctx context.Context, obj db.IStandaloneModel, body jsonutils.JSONObject) {
+	res := obj.(*models.SStackResource)
+	action, _ := self.Params.GetString("action")
+	resType, _ := self.Params.GetString("type")
+	props, _ := self.Params.Get("properties")
+	dependsOn := jsonutils.GetQueryStringArray(self.Params, "depends_on")
+
+	if action == api.STACK_PLAN_DELETE {
+		res.SetStatus(self.UserCred, api.STACK_RESOURCE_STATUS_DELETING, "")
+	} else {
+		res.SetStatus(self.UserCred, api.STACK_RESOURCE_STATUS_APPLYING, action)
+	}
+	self.SetStage("OnResourceRequested", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		return nil, res.Apply(ctx, self.UserCred, action, resType, props, dependsOn)
+	})
+}
+
+func (self *StackResourceApplyTask) OnResourceRequested(ctx context.Context, res *models.SStackResource, body jsonutils.JSONObject) {
+	self.checkApplied(ctx, res, 0)
+}
+
+func (self *StackResourceApplyTask) OnResourceRequestedFailed(ctx context.Context, res *models.SStackResource, reason jsonutils.JSONObject) {
+	self.taskFailed(ctx, res, reason)
+}
+
+// checkApplied polls the underlying resource after delay, the task worker is not occupied while waiting
+func (self *StackResourceApplyTask) checkApplied(ctx context.Context, res *models.SStackResource, delay time.Duration) {
+	action, _ := self.Params.GetString("action")
+	self.SetStage("OnResourceChecked", nil)
+	time.AfterFunc(delay, func() {
+		taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+			done, err := res.CheckApplied(ctx, self.UserCred, action)
+			if err != nil {
+				return nil, err
+			}
+			return jsonutils.Marshal(map[string]bool{"done": done}), nil
+		})
+	})
+}
+
+func (self *StackResourceApplyTask) OnResourceChecked(ctx context.Context, res *models.SStackResource, body jsonutils.JSONObject) {
+	if jsonutils.QueryBoolean(body, "done", false) {
+		self.taskComplete(ctx, res)
+		return
+	}
+	if time.Since(self.CreatedAt) > models.STACK_RESOURCE_WAIT_TIMEOUT {
+		self.taskFailed(ctx, res, jsonutils.NewString(fmt.Sprintf("wait %s timeout", res.Name)))
+		return
+	}
+	self.checkApplied(ctx, res, models.STACK_RESOURCE_WAIT_INTERVAL)
+}
+
+func (self *StackResourceApplyTask) OnResourceCheckedFailed(ctx context.Context, res *models.SStackResource, reason jsonutils.JSONObject) {
+	self.taskFailed(ctx, res, reason)
+}
+
+func (self *StackResourceApplyTask) taskComplete(ctx context.Context, res *models.SStackResource) {
+	action, _ := self.Params.GetString("action")
+	if action == api.STACK_PLAN_DELETE {
+		res.SetStatus(self.UserCred, api.STACK_RESOURCE_STATUS_PENDING, "underlying resource deleted")
+	} else {
+		res.SetStatus(self.UserCred, api.STACK_RESOURCE_STATUS_READY, "")
+	}
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *StackResourceApplyTask) taskFailed(ctx context.Context, res *models.SStackResource, reason jsonutils.JSONObject) {
+	action, _ := self.Params.GetString("action")
+	if action == api.STACK_PLAN_DELETE {
+		res.SetStatus(self.UserCred, api.STACK_RESOURCE_STATUS_DELETE_FAILED, reason.String())
+	} else {
+		res.SetStatus(self.UserCred, api.STACK_RESOURCE_STATUS_APPLY_FAILED, reason.String())
+	}
+	self.SetStageFailed(ctx, reason)
+}
@@ -0,0 +1,277 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rebalance
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/cronman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+const (
+	REBALANCE_MODE_RECOMMEND = "recommend"
+	REBALANCE_MODE_EXECUTE   = "execute"
+)
+
+type SRebalanceController struct {
+	options options.SRebalanceControllerOptions
+
+	lock     sync.Mutex
+	lastPlan []SMigration
+}
+
+var RebalanceController = new(SRebalanceController)
+
+func (rc *SRebalanceController) Init(options options.SRebalanceControllerOptions, cronm *cronman.SCronJobManager) {
+	rc.options = options
+	if !options.EnableRebalance {
+		return
+	}
+	cronm.AddJobAtIntervals("Rebalance", time.Duration(options.RebalanceCheckInterval)*time.Minute, rc.Rebalance)
+}
+
+// LastPlan return migrations planned in the latest round
+func (rc *SRebalanceController) LastPlan() []SMigration {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	return rc.lastPlan
+}
+
+func (rc *SRebalanceController) lastPlanMigrations() []api.HostRebalanceMigration {
+	plan := rc.LastPlan()
+	ret := make([]api.HostRebalanceMigration, 0, len(plan))
+	for _, m := range plan {
+		ret = append(ret, api.HostRebalanceMigration{
+			GuestId:     m.GuestId,
+			GuestName:   m.GuestName,
+			SrcHostId:   m.SrcHostId,
+			SrcHostName: m.SrcHostName,
+			DstHostId:   m.DstHostId,
+			DstHostName: m.DstHostName,
+			Reason:      m.Reason,
+		})
+	}
+	return ret
+}
+
+func (rc *SRebalanceController) Rebalance(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	migrations, err := rc.plan(ctx)
+	if err != nil {
+		log.Errorf("rebalance plan: %s", err)
+		return
+	}
+	rc.lock.Lock()
+	rc.lastPlan = migrations
+	rc.lock.Unlock()
+	if err := models.HostManager.SaveRebalancePlan(ctx, userCred, rc.lastPlanMigrations()); err != nil {
+		log.Errorf("save rebalance plan: %s", err)
+	}
+
+	for _, m := range migrations {
+		log.Infof("rebalance %s", m)
+		guest := models.GuestManager.FetchGuestById(m.GuestId)
+		if guest == nil {
+			continue
+		}
+		if rc.options.RebalanceMode != REBALANCE_MODE_EXECUTE {
+			db.OpsLog.LogEvent(guest, db.ACT_REBALANCE_RECOMMEND, m.String(), userCred)
+			continue
+		}
+		if err := rc.migrate(ctx, userCred, guest, m); err != nil {
+			log.Errorf("rebalance migrate guest %s: %s", guest.Name, err)
+			logclient.AddActionLogWithContext(ctx, guest, logclient.ACT_VM_REBALANCE, err.Error(), userCred, false)
+		}
+	}
+}
+
+func (rc *SRebalanceController) migrate(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, m SMigration) error {
+	lockman.LockObject(ctx, guest)
+	defer lockman.ReleaseObject(ctx, guest)
+
+	// guest may be changed since planned
+	if guest.Status != api.VM_RUNNING || guest.HostId != m.SrcHostId {
+		return errors.Errorf("guest status %s on host %s changed", guest.Status, guest.HostId)
+	}
+	db.OpsLog.LogEvent(guest, db.ACT_REBALANCE_MIGRATE, m.String(), userCred)
+	logclient.AddActionLogWithContext(ctx, guest, logclient.ACT_VM_REBALANCE, m.String(), userCred, true)
+	return guest.StartGuestLiveMigrateTask(ctx, userCred, guest.Status, m.DstHostId, nil, "")
+}
+
+func (rc *SRebalanceController) migratingCount() (int, error) {
+	return models.GuestManager.Query().Equals("hypervisor", api.HYPERVISOR_KVM).
+		In("status", []string{api.VM_START_MIGRATE, api.VM_MIGRATING}).CountWithError()
+}
+
+// candidateHosts return online kvm hosts which are not in maintenance or evacuation
+func (rc *SRebalanceController) candidateHosts() ([]models.SHost, error) {
+	q := models.HostManager.Query().Equals("host_type", api.HOST_TYPE_HYPERVISOR).
+		IsTrue("enabled").Equals("host_status", api.HOST_ONLINE).IsNullOrEmpty("manager_id")
+	hosts := make([]models.SHost, 0)
+	if err := db.FetchModelObjects(models.HostManager, q, &hosts); err != nil {
+		return nil, errors.Wrap(err, "fetch hosts")
+	}
+	ret := make([]models.SHost, 0, len(hosts))
+	for i := range hosts {
+		if hosts[i].IsMaintaining() || hosts[i].IsEvacuating() {
+			continue
+		}
+		ret = append(ret, hosts[i])
+	}
+	return ret, nil
+}
+
+func (rc *SRebalanceController) isGuestMovable(guest *models.SGuest) bool {
+	if len(guest.BackupHostId) > 0 || len(guest.GetIsolatedDevices()) > 0 {
+		return false
+	}
+	if !guest.GetDriver().IsSupportLiveMigrate() {
+		return false
+	}
+	return guest.GetDriver().CheckLiveMigrate(guest, auth.AdminCredential(), api.GuestLiveMigrateInput{}) == nil
+}
+
+func (rc *SRebalanceController) plan(ctx context.Context) ([]SMigration, error) {
+	migrating, err := rc.migratingCount()
+	if err != nil {
+		return nil, errors.Wrap(err, "count migrating guests")
+	}
+	maxMigrations := rc.options.RebalanceMaxMigrationsPerRound - migrating
+	if maxMigrations <= 0 {
+		log.Infof("%d guests are migrating, skip rebalance", migrating)
+		return nil, nil
+	}
+
+	hosts, err := rc.candidateHosts()
+	if err != nil {
+		return nil, err
+	}
+	if len(hosts) < 2 {
+		return nil, nil
+	}
+	reader, err := newMetricsReader(rc.options.RebalanceMetricsWindow)
+	if err != nil {
+		return nil, err
+	}
+	hostCpu, err := reader.HostCpuUsage()
+	if err != nil {
+		return nil, err
+	}
+	hostMem, err := reader.HostMemUsage()
+	if err != nil {
+		return nil, err
+	}
+	guestCpu, err := reader.GuestCpuUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	hostIds := make([]string, 0, len(hosts))
+	loads := make([]*SHostLoad, 0, len(hosts))
+	loadMap := map[string]*SHostLoad{}
+	for i := range hosts {
+		cpu, ok1 := hostCpu[hosts[i].Id]
+		mem, ok2 := hostMem[hosts[i].Id]
+		if !ok1 || !ok2 {
+			// no metrics, we know nothing about it
+			continue
+		}
+		load := &SHostLoad{
+			Id:       hosts[i].Id,
+			Name:     hosts[i].Name,
+			CpuCount: hosts[i].CpuCount,
+			MemSize:  hosts[i].MemSize,
+			CpuUsage: cpu,
+			MemUsage: mem,
+		}
+		hostIds = append(hostIds, hosts[i].Id)
+		loads = append(loads, load)
+		loadMap[load.Id] = load
+	}
+
+	q := models.GuestManager.Query().In("host_id", hostIds).Equals("status", api.VM_RUNNING).
+		Equals("hypervisor", api.HYPERVISOR_KVM).IsNullOrEmpty("backup_host_id")
+	guests := make([]models.SGuest, 0)
+	if err := db.FetchModelObjects(models.GuestManager, q, &guests); err != nil {
+		return nil, errors.Wrap(err, "fetch guests")
+	}
+	guestMap := map[string]*models.SGuest{}
+	for i := range guests {
+		if !rc.isGuestMovable(&guests[i]) {
+			continue
+		}
+		guestMap[guests[i].Id] = &guests[i]
+		loadMap[guests[i].HostId].Guests = append(loadMap[guests[i].HostId].Guests, &SGuestLoad{
+			Id:        guests[i].Id,
+			Name:      guests[i].Name,
+			VcpuCount: guests[i].VcpuCount,
+			VmemSize:  guests[i].VmemSize,
+			CpuUsage:  guestCpu[guests[i].Id],
+		})
+	}
+
+	s := auth.GetAdminSession(ctx, options.Options.Region, "")
+	planner := &SPlanner{
+		CpuThreshold:   rc.options.RebalanceCpuThreshold,
+		MemThreshold:   rc.options.RebalanceMemThreshold,
+		MinImprovement: rc.options.RebalanceMinImprovement,
+		MaxMigrations:  maxMigrations,
+		MaxPerHost:     rc.options.RebalanceMaxMigrationsPerHost,
+		CanPlace: func(g *SGuestLoad, h *SHostLoad) bool {
+			return canMigrateTo(s, guestMap[g.Id], h.Id)
+		},
+	}
+	return planner.Plan(loads), nil
+}
+
+// canMigrateTo ask scheduler whether guest can live migrate to host, which checks
+// resources, cpu compatibility, and affinity rules of instance groups
+func canMigrateTo(s *mcclient.ClientSession, guest *models.SGuest, hostId string) bool {
+	schedDesc := guest.ToSchedDesc()
+	schedDesc.ServerConfig.PreferHost = hostId
+	schedDesc.LiveMigrate = true
+	if guest.GetMetadata("__cpu_mode", nil) != api.CPU_MODE_QEMU {
+		host := guest.GetHost()
+		schedDesc.CpuDesc = host.CpuDesc
+		schedDesc.CpuMicrocode = host.CpuMicrocode
+		schedDesc.CpuMode = api.CPU_MODE_HOST
+	} else {
+		schedDesc.CpuMode = api.CPU_MODE_QEMU
+	}
+	schedDesc.ReuseNetwork = true
+	ok, res, err := modules.SchedManager.DoScheduleForecast(s, schedDesc, 1)
+	if err != nil {
+		log.Errorf("schedule forecast guest %s to host %s: %s", guest.Name, hostId, err)
+		return false
+	}
+	if !ok {
+		log.Debugf("guest %s can't migrate to host %s: %s", guest.Name, hostId, jsonutils.Marshal(res))
+	}
+	return ok
+}
@@ -0,0 +1,84 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rebalance
+
+import (
+	"fmt"
+
+	"yunion.io/x/pkg/errors"
+
+	identity_apis "yunion.io/x/onecloud/pkg/apis/identity"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/util/influxdb"
+)
+
+const (
+	METRICS_DB_NAME = "telegraf"
+)
+
+type sMetricsReader struct {
+	db     *influxdb.SInfluxdb
+	window int
+}
+
+func newMetricsReader(window int) (*sMetricsReader, error) {
+	u, err := auth.GetServiceURL("influxdb", options.Options.Region, "", identity_apis.EndpointInterfaceInternal)
+	if err != nil {
+		return nil, errors.Wrap(err, "get influxdb url")
+	}
+	return &sMetricsReader{db: influxdb.NewInfluxdb(u), window: window}, nil
+}
+
+// meanBy return mean value of field of measurement in metrics window grouped by tag
+func (r *sMetricsReader) meanBy(measurement, field, tag, cond string) (map[string]float64, error) {
+	sql := fmt.Sprintf(`SELECT mean("%s") FROM "%s".."%s" WHERE time > now() - %dm`, field, METRICS_DB_NAME, measurement, r.window)
+	if len(cond) > 0 {
+		sql += " AND " + cond
+	}
+	sql += fmt.Sprintf(` GROUP BY "%s"`, tag)
+	results, err := r.db.Query(sql)
+	if err != nil {
+		return nil, errors.Wrapf(err, "query %s", measurement)
+	}
+	ret := map[string]float64{}
+	if len(results) == 0 {
+		return ret, nil
+	}
+	for _, series := range results[0] {
+		if series.Tags == nil || len(series.Values) == 0 || len(series.Values[0]) < 2 {
+			continue
+		}
+		id, _ := series.Tags.GetString(tag)
+		val, err := series.Values[0][1].Float()
+		if len(id) == 0 || err != nil {
+			continue
+		}
+		ret[id] = val
+	}
+	return ret, nil
+}
+
+func (r *sMetricsReader) HostCpuUsage() (map[string]float64, error) {
+	return r.meanBy("cpu", "usage_active", "host_id", `"cpu" = 'cpu-total'`)
+}
+
+func (r *sMetricsReader) HostMemUsage() (map[string]float64, error) {
+	return r.meanBy("mem", "used_percent", "host_id", "")
+}
+
+func (r *sMetricsReader) GuestCpuUsage() (map[string]float64, error) {
+	return r.meanBy("vm_cpu", "usage_active", "vm_id", "")
+}
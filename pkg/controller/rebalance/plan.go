@@ -0,0 +1,186 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rebalance
+
+import (
+	"fmt"
+	"sort"
+)
+
+type SGuestLoad struct {
+	Id        string
+	Name      string
+	VcpuCount int
+	VmemSize  int
+	// cpu usage percent of guest's own vcpus
+	CpuUsage float64
+}
+
+type SHostLoad struct {
+	Id       string
+	Name     string
+	CpuCount int
+	MemSize  int
+	// cpu and memory usage percent of the whole host
+	CpuUsage float64
+	MemUsage float64
+
+	Guests []*SGuestLoad
+}
+
+// cpuOn return the cpu usage percent the guest contributes to host
+func (g *SGuestLoad) cpuOn(h *SHostLoad) float64 {
+	if h.CpuCount <= 0 {
+		return 0
+	}
+	return g.CpuUsage * float64(g.VcpuCount) / float64(h.CpuCount)
+}
+
+// memOn return the memory usage percent the guest contributes to host
+func (g *SGuestLoad) memOn(h *SHostLoad) float64 {
+	if h.MemSize <= 0 {
+		return 0
+	}
+	return float64(g.VmemSize) * 100 / float64(h.MemSize)
+}
+
+type SMigration struct {
+	GuestId     string
+	GuestName   string
+	SrcHostId   string
+	SrcHostName string
+	DstHostId   string
+	DstHostName string
+	Reason      string
+}
+
+func (m SMigration) String() string {
+	return fmt.Sprintf("migrate %s from %s to %s: %s", m.GuestName, m.SrcHostName, m.DstHostName, m.Reason)
+}
+
+type SPlanner struct {
+	CpuThreshold   float64
+	MemThreshold   float64
+	MinImprovement float64
+	MaxMigrations  int
+	MaxPerHost     int
+
+	// CanPlace check whether guest can be migrated to host, e.g. scheduler predicates
+	CanPlace func(guest *SGuestLoad, host *SHostLoad) bool
+}
+
+// pressure is the ratio of the most stressed resource against its threshold, > 1 means overloaded
+func (p *SPlanner) pressure(h *SHostLoad) float64 {
+	cpu := h.CpuUsage / p.CpuThreshold
+	mem := h.MemUsage / p.MemThreshold
+	if cpu > mem {
+		return cpu
+	}
+	return mem
+}
+
+func (p *SPlanner) isOverloaded(h *SHostLoad) bool {
+	return h.CpuUsage > p.CpuThreshold || h.MemUsage > p.MemThreshold
+}
+
+// Plan greedily move guests from the most overloaded host to the least loaded host which
+// still stays under threshold, until no overloaded host can be relieved or limits reached
+func (p *SPlanner) Plan(hosts []*SHostLoad) []SMigration {
+	ret := []SMigration{}
+	moved := map[string]bool{}
+	hostMoves := map[string]int{}
+	for len(ret) < p.MaxMigrations {
+		sort.SliceStable(hosts, func(i, j int) bool {
+			return p.pressure(hosts[i]) > p.pressure(hosts[j])
+		})
+		migration := p.planOne(hosts, moved, hostMoves)
+		if migration == nil {
+			break
+		}
+		ret = append(ret, *migration)
+	}
+	return ret
+}
+
+func (p *SPlanner) planOne(hosts []*SHostLoad, moved map[string]bool, hostMoves map[string]int) *SMigration {
+	for _, src := range hosts {
+		if !p.isOverloaded(src) {
+			// hosts are sorted by pressure, the rest are not overloaded either
+			return nil
+		}
+		if hostMoves[src.Id] >= p.MaxPerHost {
+			continue
+		}
+		cpuBound := src.CpuUsage/p.CpuThreshold >= src.MemUsage/p.MemThreshold
+		guests := make([]*SGuestLoad, 0, len(src.Guests))
+		for _, g := range src.Guests {
+			if !moved[g.Id] {
+				guests = append(guests, g)
+			}
+		}
+		// try guests which relieve the stressed resource most first
+		sort.SliceStable(guests, func(i, j int) bool {
+			if cpuBound {
+				return guests[i].cpuOn(src) > guests[j].cpuOn(src)
+			}
+			return guests[i].memOn(src) > guests[j].memOn(src)
+		})
+		for _, g := range guests {
+			for i := len(hosts) - 1; i >= 0; i-- {
+				dst := hosts[i]
+				if dst.Id == src.Id || hostMoves[dst.Id] >= p.MaxPerHost {
+					continue
+				}
+				dstCpu := dst.CpuUsage + g.cpuOn(dst)
+				dstMem := dst.MemUsage + g.memOn(dst)
+				if dstCpu > p.CpuThreshold || dstMem > p.MemThreshold {
+					continue
+				}
+				var reason string
+				if cpuBound {
+					if src.CpuUsage-dstCpu < p.MinImprovement {
+						continue
+					}
+					reason = fmt.Sprintf("host cpu usage %.1f%% exceeds %.1f%%", src.CpuUsage, p.CpuThreshold)
+				} else {
+					if src.MemUsage-dstMem < p.MinImprovement {
+						continue
+					}
+					reason = fmt.Sprintf("host memory usage %.1f%% exceeds %.1f%%", src.MemUsage, p.MemThreshold)
+				}
+				if p.CanPlace != nil && !p.CanPlace(g, dst) {
+					continue
+				}
+				src.CpuUsage -= g.cpuOn(src)
+				src.MemUsage -= g.memOn(src)
+				dst.CpuUsage = dstCpu
+				dst.MemUsage = dstMem
+				moved[g.Id] = true
+				hostMoves[src.Id]++
+				hostMoves[dst.Id]++
+				return &SMigration{
+					GuestId:     g.Id,
+					GuestName:   g.Name,
+					SrcHostId:   src.Id,
+					SrcHostName: src.Name,
+					DstHostId:   dst.Id,
+					DstHostName: dst.Name,
+					Reason:      reason,
+				}
+			}
+		}
+	}
+	return nil
+}
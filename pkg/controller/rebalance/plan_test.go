@@ -0,0 +1,104 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rebalance
+
+import (
+	"testing"
+)
+
+func newTestHosts() []*SHostLoad {
+	return []*SHostLoad{
+		{
+			Id: "h1", Name: "h1", CpuCount: 10, MemSize: 1000, CpuUsage: 95, MemUsage: 50,
+			Guests: []*SGuestLoad{
+				{Id: "g1", Name: "g1", VcpuCount: 2, VmemSize: 100, CpuUsage: 50},
+				{Id: "g2", Name: "g2", VcpuCount: 4, VmemSize: 100, CpuUsage: 100},
+			},
+		},
+		{Id: "h2", Name: "h2", CpuCount: 10, MemSize: 1000, CpuUsage: 20, MemUsage: 30},
+		{Id: "h3", Name: "h3", CpuCount: 10, MemSize: 1000, CpuUsage: 50, MemUsage: 30},
+	}
+}
+
+func TestPlanner(t *testing.T) {
+	cases := []struct {
+		name    string
+		planner SPlanner
+		hosts   []*SHostLoad
+		want    []SMigration
+	}{
+		{
+			name: "move busiest guest to idlest host",
+			planner: SPlanner{
+				CpuThreshold: 80, MemThreshold: 80, MinImprovement: 10, MaxMigrations: 2, MaxPerHost: 1,
+			},
+			hosts: newTestHosts(),
+			want: []SMigration{
+				{GuestId: "g2", SrcHostId: "h1", DstHostId: "h2"},
+			},
+		},
+		{
+			name: "respect placement check",
+			planner: SPlanner{
+				CpuThreshold: 80, MemThreshold: 80, MinImprovement: 10, MaxMigrations: 2, MaxPerHost: 1,
+				CanPlace: func(g *SGuestLoad, h *SHostLoad) bool {
+					return h.Id != "h2"
+				},
+			},
+			hosts: newTestHosts(),
+			want: []SMigration{
+				{GuestId: "g1", SrcHostId: "h1", DstHostId: "h3"},
+			},
+		},
+		{
+			name: "no overloaded host",
+			planner: SPlanner{
+				CpuThreshold: 99, MemThreshold: 80, MinImprovement: 10, MaxMigrations: 2, MaxPerHost: 1,
+			},
+			hosts: newTestHosts(),
+			want:  []SMigration{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := c.planner.Plan(c.hosts)
+			if len(got) != len(c.want) {
+				t.Fatalf("want %d migrations, got %v", len(c.want), got)
+			}
+			for i := range got {
+				if got[i].GuestId != c.want[i].GuestId || got[i].SrcHostId != c.want[i].SrcHostId || got[i].DstHostId != c.want[i].DstHostId {
+					t.Errorf("want %#v, got %#v", c.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSRebalanceController_lastPlanMigrations(t *testing.T) {
+	rc := new(SRebalanceController)
+	if got := rc.lastPlanMigrations(); len(got) != 0 {
+		t.Errorf("want empty plan, got %v", got)
+	}
+	rc.lastPlan = []SMigration{
+		{GuestId: "g1", GuestName: "g1", SrcHostId: "h1", SrcHostName: "h1", DstHostId: "h2", DstHostName: "h2", Reason: "cpu"},
+	}
+	got := rc.lastPlanMigrations()
+	if len(got) != 1 {
+		t.Fatalf("want 1 migration, got %v", got)
+	}
+	if got[0].GuestId != "g1" || got[0].SrcHostId != "h1" || got[0].DstHostId != "h2" || got[0].Reason != "cpu" {
+		t.Errorf("bad migration %#v", got[0])
+	}
+}
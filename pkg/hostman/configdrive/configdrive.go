@@ -0,0 +1,157 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdrive
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+	"yunion.io/x/onecloud/pkg/util/netutils2"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+// GetFormat returns the config drive volume label requested by guest metadata
+func GetFormat(guestDesc jsonutils.JSONObject) string {
+	format, _ := guestDesc.GetString("metadata", api.VM_METADATA_CONFIG_DRIVE)
+	if !utils.IsInStringArray(format, api.CONFIG_DRIVE_FORMATS) {
+		return ""
+	}
+	return format
+}
+
+// Generate returns files of config drive, keyed by path relative to volume root
+func Generate(guestDesc jsonutils.JSONObject, format string) (map[string][]byte, error) {
+	nics, err := guestNics(guestDesc)
+	if err != nil {
+		return nil, errors.Wrap(err, "guestNics")
+	}
+	switch format {
+	case api.CONFIG_DRIVE_OPENSTACK:
+		return openstackFiles(guestDesc, nics), nil
+	case api.CONFIG_DRIVE_NOCLOUD:
+		return nocloudFiles(guestDesc, nics), nil
+	default:
+		return nil, errors.Wrapf(errors.ErrNotSupported, "config drive format %q", format)
+	}
+}
+
+// BuildIso writes ISO9660 config drive of guest to output
+func BuildIso(guestDesc jsonutils.JSONObject, format string, output string) error {
+	files, err := Generate(guestDesc, format)
+	if err != nil {
+		return err
+	}
+	tmpDir, err := ioutil.TempDir(filepath.Dir(output), "configdrive")
+	if err != nil {
+		return errors.Wrap(err, "TempDir")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	root := filepath.Join(tmpDir, "root")
+	for name, cont := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return errors.Wrapf(err, "mkdir %s", filepath.Dir(path))
+		}
+		if err := ioutil.WriteFile(path, cont, 0644); err != nil {
+			return errors.Wrapf(err, "write %s", path)
+		}
+	}
+	tmpIso := filepath.Join(tmpDir, "configdrive.iso")
+	out, err := procutils.NewCommand("mkisofs", "-quiet", "-J", "-R",
+		"-input-charset", "utf-8", "-V", format, "-o", tmpIso, root).Output()
+	if err != nil {
+		return errors.Wrapf(err, "mkisofs %s", out)
+	}
+	// rename is atomic, the running guest never sees a partial volume
+	if err := os.Rename(tmpIso, output); err != nil {
+		return errors.Wrap(err, "rename")
+	}
+	return nil
+}
+
+// UserData returns the decoded user data of guest
+func UserData(guestDesc jsonutils.JSONObject) string {
+	userData, _ := guestDesc.GetString("user_data")
+	if len(userData) == 0 {
+		return ""
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(userData); err == nil {
+		return string(decoded)
+	}
+	return userData
+}
+
+func guestNics(guestDesc jsonutils.JSONObject) ([]*types.SServerNic, error) {
+	nics := make([]*types.SServerNic, 0)
+	if guestDesc.Contains("nics") {
+		if err := guestDesc.Unmarshal(&nics, "nics"); err != nil {
+			return nil, errors.Wrap(err, "unmarshal nics")
+		}
+	}
+	for i := range nics {
+		nics[i].Name = fmt.Sprintf("eth%d", nics[i].Index)
+	}
+	return nics, nil
+}
+
+func mainNicIp(nics []*types.SServerNic) string {
+	if len(nics) == 0 {
+		return ""
+	}
+	mainNic, err := netutils2.GetMainNicFromDeployApi(nics)
+	if err != nil {
+		return ""
+	}
+	return mainNic.Ip
+}
+
+func nicDns(nic *types.SServerNic) []string {
+	dns := make([]string, 0)
+	for _, addr := range strings.Split(nic.Dns, ",") {
+		if addr = strings.TrimSpace(addr); len(addr) > 0 {
+			dns = append(dns, addr)
+		}
+	}
+	return dns
+}
+
+func nicRoutes(nic *types.SServerNic, mainIp string, nicCnt int) [][]string {
+	routes := make([][]string, 0)
+	netutils2.AddNicRoutes(&routes, nic, mainIp, nicCnt, nil)
+	return routes
+}
+
+func publicKeys(guestDesc jsonutils.JSONObject) map[string]string {
+	keys := make(map[string]string)
+	if pubkey, _ := guestDesc.GetString("pubkey"); len(pubkey) > 0 {
+		keyName, _ := guestDesc.GetString("keypair")
+		if len(keyName) == 0 {
+			keyName = "default"
+		}
+		keys[keyName] = pubkey
+	}
+	return keys
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdrive
+
+import (
+	"strings"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func testGuestDesc(format string) jsonutils.JSONObject {
+	desc := jsonutils.NewDict()
+	desc.Set("uuid", jsonutils.NewString("0bc0ae0c-5c4d-4c4b-8a6e-3f1c3a7c6e00"))
+	desc.Set("name", jsonutils.NewString("vm1"))
+	desc.Set("pubkey", jsonutils.NewString("ssh-rsa AAAA test"))
+	desc.Set("keypair", jsonutils.NewString("key1"))
+	desc.Set("user_data", jsonutils.NewString("#cloud-config\npackages: [vim]\n"))
+	desc.Set("metadata", jsonutils.Marshal(map[string]string{api.VM_METADATA_CONFIG_DRIVE: format}))
+	nic := jsonutils.NewDict()
+	nic.Set("index", jsonutils.NewInt(0))
+	nic.Set("mac", jsonutils.NewString("00:22:33:44:55:66"))
+	nic.Set("ip", jsonutils.NewString("192.168.1.10"))
+	nic.Set("masklen", jsonutils.NewInt(24))
+	nic.Set("gateway", jsonutils.NewString("192.168.1.1"))
+	nic.Set("dns", jsonutils.NewString("114.114.114.114,8.8.8.8"))
+	nic.Set("mtu", jsonutils.NewInt(1450))
+	desc.Set("nics", jsonutils.NewArray(nic))
+	return desc
+}
+
+func TestGetFormat(t *testing.T) {
+	for _, c := range []struct {
+		format string
+		want   string
+	}{
+		{api.CONFIG_DRIVE_OPENSTACK, api.CONFIG_DRIVE_OPENSTACK},
+		{api.CONFIG_DRIVE_NOCLOUD, api.CONFIG_DRIVE_NOCLOUD},
+		{"", ""},
+		{"vfat", ""},
+	} {
+		if got := GetFormat(testGuestDesc(c.format)); got != c.want {
+			t.Errorf("GetFormat(%q) = %q, want %q", c.format, got, c.want)
+		}
+	}
+}
+
+func TestGenerateNoCloud(t *testing.T) {
+	files, err := Generate(testGuestDesc(api.CONFIG_DRIVE_NOCLOUD), api.CONFIG_DRIVE_NOCLOUD)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	meta := string(files["meta-data"])
+	for _, want := range []string{"instance-id: 0bc0ae0c-5c4d-4c4b-8a6e-3f1c3a7c6e00", "local-hostname: vm1", "ssh-rsa AAAA test"} {
+		if !strings.Contains(meta, want) {
+			t.Errorf("meta-data missing %q:\n%s", want, meta)
+		}
+	}
+	if string(files["user-data"]) != "#cloud-config\npackages: [vim]\n" {
+		t.Errorf("unexpected user-data %q", files["user-data"])
+	}
+	netconf := string(files["network-config"])
+	for _, want := range []string{"version: 2", "macaddress: 00:22:33:44:55:66", "192.168.1.10/24", "to: 0.0.0.0/0", "via: 192.168.1.1", "- 8.8.8.8", "mtu: 1450"} {
+		if !strings.Contains(netconf, want) {
+			t.Errorf("network-config missing %q:\n%s", want, netconf)
+		}
+	}
+}
+
+func TestGenerateOpenStack(t *testing.T) {
+	files, err := Generate(testGuestDesc(api.CONFIG_DRIVE_OPENSTACK), api.CONFIG_DRIVE_OPENSTACK)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	meta, err := jsonutils.Parse(files["openstack/latest/meta_data.json"])
+	if err != nil {
+		t.Fatalf("parse meta_data.json: %v", err)
+	}
+	if key, _ := meta.GetString("public_keys", "key1"); key != "ssh-rsa AAAA test" {
+		t.Errorf("unexpected public key %q", key)
+	}
+	netData, err := jsonutils.Parse(files["openstack/latest/network_data.json"])
+	if err != nil {
+		t.Fatalf("parse network_data.json: %v", err)
+	}
+	networks, _ := netData.GetArray("networks")
+	if len(networks) != 1 {
+		t.Fatalf("expect 1 network, got %d", len(networks))
+	}
+	if netmask, _ := networks[0].GetString("netmask"); netmask != "255.255.255.0" {
+		t.Errorf("unexpected netmask %q", netmask)
+	}
+	routes, _ := networks[0].GetArray("routes")
+	if len(routes) != 1 {
+		t.Fatalf("expect 1 route, got %d", len(routes))
+	}
+	if gw, _ := routes[0].GetString("gateway"); gw != "192.168.1.1" {
+		t.Errorf("unexpected gateway %q", gw)
+	}
+	if _, ok := files["openstack/latest/user_data"]; !ok {
+		t.Errorf("user_data missing")
+	}
+}
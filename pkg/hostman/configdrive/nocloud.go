@@ -0,0 +1,112 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdrive
+
+import (
+	"fmt"
+	"sort"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+)
+
+func nocloudMetaData(guestDesc jsonutils.JSONObject) jsonutils.JSONObject {
+	meta := jsonutils.NewDict()
+	uuid, _ := guestDesc.GetString("uuid")
+	name, _ := guestDesc.GetString("name")
+	meta.Set("instance-id", jsonutils.NewString(uuid))
+	meta.Set("local-hostname", jsonutils.NewString(name))
+	keys := publicKeys(guestDesc)
+	if len(keys) > 0 {
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		pubkeys := make([]string, 0, len(keys))
+		for _, name := range names {
+			pubkeys = append(pubkeys, keys[name])
+		}
+		meta.Set("public-keys", jsonutils.NewStringArray(pubkeys))
+	}
+	return meta
+}
+
+// nocloudNetworkConfig returns network config version 2 of guest
+func nocloudNetworkConfig(nics []*types.SServerNic) jsonutils.JSONObject {
+	ethernets := jsonutils.NewDict()
+	mainIp := mainNicIp(nics)
+	for _, nic := range nics {
+		eth := jsonutils.NewDict()
+		match := jsonutils.NewDict()
+		match.Set("macaddress", jsonutils.NewString(nic.Mac))
+		eth.Set("match", match)
+		eth.Set("set-name", jsonutils.NewString(nic.Name))
+		if nic.Mtu > 0 {
+			eth.Set("mtu", jsonutils.NewInt(int64(nic.Mtu)))
+		}
+		if len(nic.Ip) == 0 {
+			eth.Set("dhcp4", jsonutils.JSONTrue)
+			ethernets.Set(nic.Name, eth)
+			continue
+		}
+		eth.Set("addresses", jsonutils.NewStringArray([]string{fmt.Sprintf("%s/%d", nic.Ip, nic.Masklen)}))
+		routes := jsonutils.NewArray()
+		if len(nic.Gateway) > 0 && nic.Ip == mainIp {
+			routes.Add(nocloudRoute("0.0.0.0/0", nic.Gateway))
+		}
+		for _, r := range nicRoutes(nic, mainIp, len(nics)) {
+			routes.Add(nocloudRoute(r[0], r[1]))
+		}
+		if routes.Length() > 0 {
+			eth.Set("routes", routes)
+		}
+		if dns := nicDns(nic); len(dns) > 0 {
+			nameservers := jsonutils.NewDict()
+			nameservers.Set("addresses", jsonutils.NewStringArray(dns))
+			if len(nic.Domain) > 0 {
+				nameservers.Set("search", jsonutils.NewStringArray([]string{nic.Domain}))
+			}
+			eth.Set("nameservers", nameservers)
+		}
+		ethernets.Set(nic.Name, eth)
+	}
+	conf := jsonutils.NewDict()
+	conf.Set("version", jsonutils.NewInt(2))
+	conf.Set("ethernets", ethernets)
+	return conf
+}
+
+func nocloudRoute(to, via string) jsonutils.JSONObject {
+	route := jsonutils.NewDict()
+	route.Set("to", jsonutils.NewString(to))
+	route.Set("via", jsonutils.NewString(via))
+	return route
+}
+
+func nocloudFiles(guestDesc jsonutils.JSONObject, nics []*types.SServerNic) map[string][]byte {
+	files := map[string][]byte{
+		"meta-data":      []byte(nocloudMetaData(guestDesc).YAMLString()),
+		"network-config": []byte(nocloudNetworkConfig(nics).YAMLString()),
+		// cloud-init NoCloud datasource requires user-data, even empty
+		"user-data": []byte(UserData(guestDesc)),
+	}
+	if vendorData, _ := guestDesc.GetString("metadata", api.VM_METADATA_VENDOR_DATA); len(vendorData) > 0 {
+		files["vendor-data"] = []byte(vendorData)
+	}
+	return files
+}
@@ -0,0 +1,134 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdrive
+
+import (
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/types"
+	"yunion.io/x/onecloud/pkg/util/netutils2"
+)
+
+// OpenStackMetaData returns openstack/latest/meta_data.json of guest
+func OpenStackMetaData(guestDesc jsonutils.JSONObject) jsonutils.JSONObject {
+	meta := jsonutils.NewDict()
+	uuid, _ := guestDesc.GetString("uuid")
+	name, _ := guestDesc.GetString("name")
+	meta.Set("uuid", jsonutils.NewString(uuid))
+	meta.Set("name", jsonutils.NewString(name))
+	meta.Set("hostname", jsonutils.NewString(name))
+	meta.Set("launch_index", jsonutils.NewInt(0))
+	if zone, _ := guestDesc.GetString("zone"); len(zone) > 0 {
+		meta.Set("availability_zone", jsonutils.NewString(zone))
+	}
+	if projectId, _ := guestDesc.GetString("tenant_id"); len(projectId) > 0 {
+		meta.Set("project_id", jsonutils.NewString(projectId))
+	}
+	if keys := publicKeys(guestDesc); len(keys) > 0 {
+		meta.Set("public_keys", jsonutils.Marshal(keys))
+	}
+	return meta
+}
+
+// openStackNetworkData returns openstack/latest/network_data.json of guest
+func openStackNetworkData(nics []*types.SServerNic) jsonutils.JSONObject {
+	links := jsonutils.NewArray()
+	networks := jsonutils.NewArray()
+	services := jsonutils.NewArray()
+	dnsAdded := make(map[string]bool)
+	mainIp := mainNicIp(nics)
+	for _, nic := range nics {
+		linkId := fmt.Sprintf("tap%d", nic.Index)
+		link := jsonutils.NewDict()
+		link.Set("id", jsonutils.NewString(linkId))
+		link.Set("type", jsonutils.NewString("phy"))
+		link.Set("ethernet_mac_address", jsonutils.NewString(nic.Mac))
+		if nic.Mtu > 0 {
+			link.Set("mtu", jsonutils.NewInt(int64(nic.Mtu)))
+		}
+		links.Add(link)
+
+		network := jsonutils.NewDict()
+		network.Set("id", jsonutils.NewString(fmt.Sprintf("network%d", nic.Index)))
+		network.Set("link", jsonutils.NewString(linkId))
+		if len(nic.Ip) == 0 {
+			network.Set("type", jsonutils.NewString("ipv4_dhcp"))
+			networks.Add(network)
+			continue
+		}
+		network.Set("type", jsonutils.NewString("ipv4"))
+		network.Set("ip_address", jsonutils.NewString(nic.Ip))
+		network.Set("netmask", jsonutils.NewString(netutils2.Netlen2Mask(nic.Masklen)))
+		routes := jsonutils.NewArray()
+		if len(nic.Gateway) > 0 && nic.Ip == mainIp {
+			routes.Add(openStackRoute("0.0.0.0", 0, nic.Gateway))
+		}
+		for _, r := range nicRoutes(nic, mainIp, len(nics)) {
+			net, masklen, err := netutils2.PrefixSplit(r[0])
+			if err != nil {
+				continue
+			}
+			routes.Add(openStackRoute(net, masklen, r[1]))
+		}
+		network.Set("routes", routes)
+		dns := nicDns(nic)
+		if len(dns) > 0 {
+			network.Set("dns_nameservers", jsonutils.NewStringArray(dns))
+		}
+		networks.Add(network)
+		for _, addr := range dns {
+			if dnsAdded[addr] {
+				continue
+			}
+			dnsAdded[addr] = true
+			service := jsonutils.NewDict()
+			service.Set("type", jsonutils.NewString("dns"))
+			service.Set("address", jsonutils.NewString(addr))
+			services.Add(service)
+		}
+	}
+	data := jsonutils.NewDict()
+	data.Set("links", links)
+	data.Set("networks", networks)
+	data.Set("services", services)
+	return data
+}
+
+func openStackRoute(net string, masklen int, gateway string) jsonutils.JSONObject {
+	route := jsonutils.NewDict()
+	route.Set("network", jsonutils.NewString(net))
+	route.Set("netmask", jsonutils.NewString(netutils2.Netlen2Mask(masklen)))
+	route.Set("gateway", jsonutils.NewString(gateway))
+	return route
+}
+
+func openstackFiles(guestDesc jsonutils.JSONObject, nics []*types.SServerNic) map[string][]byte {
+	files := map[string][]byte{
+		"openstack/latest/meta_data.json":    []byte(OpenStackMetaData(guestDesc).String()),
+		"openstack/latest/network_data.json": []byte(openStackNetworkData(nics).String()),
+	}
+	if userData := UserData(guestDesc); len(userData) > 0 {
+		files["openstack/latest/user_data"] = []byte(userData)
+	}
+	if vendorData, _ := guestDesc.GetString("metadata", api.VM_METADATA_VENDOR_DATA); len(vendorData) > 0 {
+		data := jsonutils.NewDict()
+		data.Set("cloud-init", jsonutils.NewString(vendorData))
+		files["openstack/latest/vendor_data.json"] = []byte(data.String())
+	}
+	return files
+}
@@ -16,13 +16,18 @@ package downloader
 
 import (
 	"compress/zlib"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/util/httputils"
 )
 
 const (
@@ -35,13 +40,52 @@ type SDownloadProvider struct {
 	w         http.ResponseWriter
 	rateLimit int
 	compress  bool
+
+	// Range header of the request, only honored for uncompressed transfer
+	rangeStr string
 }
 
 func NewDownloadProvider(w http.ResponseWriter, compress bool, rateLimit int) *SDownloadProvider {
 	if rateLimit <= 0 {
 		rateLimit = DEFAULT_RATE_LIMIT
 	}
-	return &SDownloadProvider{w, rateLimit, compress}
+	return &SDownloadProvider{w: w, rateLimit: rateLimit, compress: compress}
+}
+
+// SetRange makes the provider send only the requested range of the file,
+// so that an interrupted download can be resumed
+func (d *SDownloadProvider) SetRange(rangeStr string) {
+	d.rangeStr = rangeStr
+}
+
+// seekRange positions fi at the start of the requested range and returns the reader of the range
+func (d *SDownloadProvider) seekRange(fi *os.File) (io.Reader, error) {
+	if d.compress || len(d.rangeStr) == 0 {
+		return fi, nil
+	}
+	stat, err := fi.Stat()
+	if err != nil {
+		return nil, errors.Wrap(err, "stat")
+	}
+	size := stat.Size()
+	start, end, err := httputils.ParseRange(d.rangeStr, size)
+	if err != nil {
+		if errors.Cause(err) == httputils.ErrRangeNotSatisfiable {
+			d.w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			return nil, httputils.NewJsonClientError(http.StatusRequestedRangeNotSatisfiable, string(httputils.ErrRangeNotSatisfiable), "%s", err)
+		}
+		// unsupported range, send the whole file
+		return fi, nil
+	}
+	_, err = fi.Seek(start, io.SeekStart)
+	if err != nil {
+		return nil, errors.Wrap(err, "seek")
+	}
+	log.Infof("Downloader send range %d-%d of %d", start, end, size)
+	d.w.Header().Set("Content-Range", httputils.FormatContentRange(start, end, size))
+	d.w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	d.w.WriteHeader(http.StatusPartialContent)
+	return io.LimitReader(fi, end-start+1), nil
 }
 
 func (d *SDownloadProvider) Start(
@@ -73,6 +117,15 @@ func (d *SDownloadProvider) Start(
 	}
 	defer fi.Close()
 
+	if !d.compress {
+		d.w.Header().Set("Accept-Ranges", "bytes")
+	}
+	reader, err := d.seekRange(fi)
+	if err != nil {
+		log.Errorln(err)
+		return err
+	}
+
 	var (
 		end                  = false
 		chunk                = make([]byte, CHUNK_SIZE)
@@ -94,7 +147,7 @@ func (d *SDownloadProvider) Start(
 	}
 
 	for !end {
-		size, err := fi.Read(chunk)
+		size, err := reader.Read(chunk)
 		if err != nil {
 			if err != io.EOF {
 				log.Errorln(err)
@@ -86,6 +86,7 @@ func download(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	switch action {
 	case "images":
 		hand := NewImageCacheDownloadProvider(w, compress, rateLimit, id)
+		hand.SetRange(r.Header.Get("Range"))
 		if !fileutils2.Exists(hand.downloadFilePath()) {
 			httperrors.NotFoundError(ctx, w, "Image cache %s not found", id)
 		} else {
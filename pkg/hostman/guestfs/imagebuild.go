@@ -0,0 +1,202 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/hostman/guestfs/fsdriver"
+	deployapi "yunion.io/x/onecloud/pkg/hostman/hostdeployer/apis"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+// the image service always hands a qcow2 clone of the base image to the builder
+const imageBuildDiskFormat = "qcow2"
+
+// virt-customize runs the commands inside the libguestfs appliance, a throwaway VM
+// with the image attached, so that nothing from the recipe is executed on the host
+var runVirtCustomize = func(args ...string) ([]byte, error) {
+	return procutils.NewCommand("virt-customize", args...).Output()
+}
+
+// SImageBuilder customizes an image with a recipe. Files are injected into and cleaned up from
+// the mounted root filesystem, packages, scripts and services are handled inside the appliance
+type SImageBuilder struct {
+	recipe   *deployapi.ImageBuildRecipe
+	buildLog bytes.Buffer
+}
+
+func NewImageBuilder(recipe *deployapi.ImageBuildRecipe) (*SImageBuilder, error) {
+	if err := recipe.Validate(); err != nil {
+		return nil, err
+	}
+	return &SImageBuilder{recipe: recipe}, nil
+}
+
+func (b *SImageBuilder) logf(format string, args ...interface{}) {
+	fmt.Fprintf(&b.buildLog, "[%s] ", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b.buildLog, format, args...)
+	b.buildLog.WriteByte('\n')
+}
+
+// Finish records the result of the build and returns the build log
+func (b *SImageBuilder) Finish(err error) string {
+	if err != nil {
+		b.logf("build failed: %s", err)
+	} else {
+		b.logf("build success")
+	}
+	return b.buildLog.String()
+}
+
+// HasCommands tells whether the recipe has anything to run inside the image
+func (b *SImageBuilder) HasCommands() bool {
+	return len(b.recipe.Packages) > 0 || len(b.recipe.Scripts) > 0 || len(b.recipe.EnableServices) > 0
+}
+
+// InjectFiles writes the files of the recipe into the mounted root filesystem
+func (b *SImageBuilder) InjectFiles(rootfs fsdriver.IRootFsDriver) error {
+	part := rootfs.GetPartition()
+	caseInsensitive := rootfs.IsFsCaseInsensitive()
+	b.logf("rootfs %s, os %s", rootfs, rootfs.GetOs())
+	if b.HasCommands() && rootfs.GetOs() != "Linux" {
+		return errors.Wrapf(errors.ErrNotSupported, "packages, scripts and services on %s", rootfs.GetOs())
+	}
+	for _, f := range b.recipe.Files {
+		b.logf("inject file %s", f.Path)
+		err := rootfs.DeployFiles([]*deployapi.DeployContent{{Path: f.Path, Content: f.Content}})
+		if err != nil {
+			return errors.Wrapf(err, "inject file %s", f.Path)
+		}
+		if !part.Exists(f.Path, caseInsensitive) {
+			// DeployFiles skips empty content
+			err = part.FilePutContents(f.Path, "", false, caseInsensitive)
+			if err != nil {
+				return errors.Wrapf(err, "create file %s", f.Path)
+			}
+		}
+		if f.Mode > 0 {
+			err = part.Chmod(f.Path, uint32(f.Mode), caseInsensitive)
+			if err != nil {
+				return errors.Wrapf(err, "chmod %s", f.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// RunCommands installs packages, runs scripts and enables services of the recipe
+// inside the libguestfs appliance, the disk must not be connected on the host meanwhile
+func (b *SImageBuilder) RunCommands(diskPath string) error {
+	if !b.HasCommands() {
+		return nil
+	}
+	scriptDir, err := ioutil.TempDir("", "image-build")
+	if err != nil {
+		return errors.Wrap(err, "create script dir")
+	}
+	defer os.RemoveAll(scriptDir)
+
+	args := []string{"-a", diskPath, "--format", imageBuildDiskFormat}
+	if len(b.recipe.Packages) > 0 {
+		b.logf("install packages %s", strings.Join(b.recipe.Packages, " "))
+		args = append(args, "--install", strings.Join(b.recipe.Packages, ","))
+	}
+	for i, script := range b.recipe.Scripts {
+		name := fmt.Sprintf(".image-build-%d.sh", i)
+		err = ioutil.WriteFile(filepath.Join(scriptDir, name), []byte(script), 0600)
+		if err != nil {
+			return errors.Wrapf(err, "write script %d", i)
+		}
+		b.logf("script %d:\n%s", i, script)
+		guestPath := "/tmp/" + name
+		args = append(args,
+			"--upload", filepath.Join(scriptDir, name)+":"+guestPath,
+			"--run-command", "/bin/sh -e "+guestPath,
+			"--delete", guestPath,
+		)
+	}
+	for _, svc := range b.recipe.EnableServices {
+		b.logf("enable service %s", svc)
+		args = append(args, "--run-command", enableServiceCommand(svc))
+	}
+	output, err := runVirtCustomize(args...)
+	if len(output) > 0 {
+		b.buildLog.Write(output)
+		if output[len(output)-1] != '\n' {
+			b.buildLog.WriteByte('\n')
+		}
+	}
+	if err != nil {
+		return errors.Wrap(err, "virt-customize")
+	}
+	return nil
+}
+
+// enableServiceCommand picks the init system inside the image, the name is validated by the recipe
+func enableServiceCommand(svc string) string {
+	return fmt.Sprintf("if command -v systemctl >/dev/null 2>&1; then systemctl enable %[1]s; "+
+		"elif command -v chkconfig >/dev/null 2>&1; then chkconfig %[1]s on; "+
+		"elif command -v rc-update >/dev/null 2>&1; then rc-update add %[1]s default; "+
+		"elif command -v update-rc.d >/dev/null 2>&1; then update-rc.d %[1]s defaults; "+
+		"else echo 'no init system found to enable %[1]s' >&2; exit 1; fi", svc)
+}
+
+// Cleanup removes machine specific data from the mounted root filesystem after the commands ran
+func (b *SImageBuilder) Cleanup(rootfs fsdriver.IRootFsDriver) error {
+	part := rootfs.GetPartition()
+	caseInsensitive := rootfs.IsFsCaseInsensitive()
+	if b.recipe.ResetMachineId {
+		b.logf("reset machine-id")
+		// an empty machine-id is regenerated on first boot
+		if part.Exists("/etc/machine-id", caseInsensitive) {
+			err := part.FilePutContents("/etc/machine-id", "", false, caseInsensitive)
+			if err != nil {
+				return errors.Wrap(err, "reset machine-id")
+			}
+		}
+		part.Remove("/var/lib/dbus/machine-id", caseInsensitive)
+	}
+	if b.recipe.RemoveSshHostKeys && part.Exists("/etc/ssh", caseInsensitive) {
+		b.logf("remove ssh host keys")
+		for _, f := range part.ListDir("/etc/ssh", caseInsensitive) {
+			if strings.HasPrefix(f, "ssh_host_") {
+				part.Remove("/etc/ssh/"+f, caseInsensitive)
+			}
+		}
+	}
+	if b.recipe.CleanLogs {
+		b.logf("clean logs and temporary files")
+		for _, dir := range []string{"/var/log", "/tmp", "/var/tmp"} {
+			if !part.Exists(dir, caseInsensitive) {
+				continue
+			}
+			// keep the directory tree of /var/log, some services refuse to start without it
+			err := part.Cleandir(dir, dir == "/var/log", caseInsensitive)
+			if err != nil {
+				return errors.Wrapf(err, "clean %s", dir)
+			}
+		}
+	}
+	return nil
+}
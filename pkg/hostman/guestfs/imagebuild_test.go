@@ -0,0 +1,256 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestfs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/hostman/guestfs/fsdriver"
+	deployapi "yunion.io/x/onecloud/pkg/hostman/hostdeployer/apis"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+)
+
+// testPartition is a partition mounted at a local directory
+type testPartition struct {
+	fsdriver.IDiskPartition
+	dir string
+}
+
+func (p *testPartition) path(sPath string) string {
+	return filepath.Join(p.dir, sPath)
+}
+
+func (p *testPartition) Exists(sPath string, caseInsensitive bool) bool {
+	_, err := os.Stat(p.path(sPath))
+	return err == nil
+}
+
+func (p *testPartition) Mkdir(sPath string, mode int, caseInsensitive bool) error {
+	return os.MkdirAll(p.path(sPath), os.FileMode(mode))
+}
+
+func (p *testPartition) FilePutContents(sPath, content string, modAppend, caseInsensitive bool) error {
+	return ioutil.WriteFile(p.path(sPath), []byte(content), 0644)
+}
+
+func (p *testPartition) Chmod(sPath string, mode uint32, caseInsensitive bool) error {
+	return os.Chmod(p.path(sPath), os.FileMode(mode))
+}
+
+func (p *testPartition) ListDir(sPath string, caseInsensitive bool) []string {
+	files, _ := ioutil.ReadDir(p.path(sPath))
+	ret := []string{}
+	for _, f := range files {
+		ret = append(ret, f.Name())
+	}
+	return ret
+}
+
+func (p *testPartition) Remove(sPath string, caseInsensitive bool) {
+	os.Remove(p.path(sPath))
+}
+
+func (p *testPartition) Cleandir(dir string, keepdir, caseInsensitive bool) error {
+	return fileutils2.Cleandir(p.path(dir), keepdir)
+}
+
+func newTestRootfs(t *testing.T, files map[string]string) (string, fsdriver.IRootFsDriver) {
+	dir, err := ioutil.TempDir("", "imagebuild")
+	if err != nil {
+		t.Fatalf("create temp dir: %s", err)
+	}
+	for path, content := range files {
+		localPath := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+			t.Fatalf("mkdir %s: %s", path, err)
+		}
+		if err := ioutil.WriteFile(localPath, []byte(content), 0644); err != nil {
+			t.Fatalf("write %s: %s", path, err)
+		}
+	}
+	return dir, fsdriver.NewCentosRootFs(&testPartition{dir: dir})
+}
+
+func TestImageBuilderInjectFilesAndCleanup(t *testing.T) {
+	dir, rootfs := newTestRootfs(t, map[string]string{
+		"etc/machine-id":             "0123456789abcdef\n",
+		"var/lib/dbus/machine-id":    "0123456789abcdef\n",
+		"etc/ssh/ssh_host_rsa_key":   "key",
+		"etc/ssh/sshd_config":        "PermitRootLogin no\n",
+		"var/log/messages":           "boot\n",
+		"var/log/nginx/access.log":   "GET /\n",
+		"tmp/.image-build-leftover":  "x",
+		"var/tmp/.image-build-cache": "x",
+	})
+	defer os.RemoveAll(dir)
+
+	builder, err := NewImageBuilder(&deployapi.ImageBuildRecipe{
+		Files: []*deployapi.ImageBuildFile{
+			{Path: "/etc/motd", Content: "welcome\n", Mode: 0600},
+			{Path: "/etc/app/empty.conf"},
+		},
+		ResetMachineId:    true,
+		RemoveSshHostKeys: true,
+		CleanLogs:         true,
+	})
+	if err != nil {
+		t.Fatalf("NewImageBuilder: %s", err)
+	}
+	if err := builder.InjectFiles(rootfs); err != nil {
+		t.Fatalf("InjectFiles: %s", err)
+	}
+	if err := builder.Cleanup(rootfs); err != nil {
+		t.Fatalf("Cleanup: %s", err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "etc/motd"))
+	if err != nil || string(content) != "welcome\n" {
+		t.Errorf("motd: %q %v", content, err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "etc/motd")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("motd mode: %v %v", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "etc/app/empty.conf")); err != nil {
+		t.Errorf("empty file not created: %s", err)
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(dir, "etc/machine-id")); len(content) > 0 {
+		t.Errorf("machine-id not reset: %q", content)
+	}
+	for _, path := range []string{
+		"var/lib/dbus/machine-id",
+		"etc/ssh/ssh_host_rsa_key",
+		"var/log/messages",
+		"var/log/nginx/access.log",
+		"tmp/.image-build-leftover",
+		"var/tmp/.image-build-cache",
+	} {
+		if _, err := os.Stat(filepath.Join(dir, path)); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed: %v", path, err)
+		}
+	}
+	for _, path := range []string{"etc/ssh/sshd_config", "var/log/nginx"} {
+		if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+			t.Errorf("%s should be kept: %s", path, err)
+		}
+	}
+	if log := builder.Finish(nil); !strings.Contains(log, "build success") {
+		t.Errorf("unexpected build log %s", log)
+	}
+}
+
+func TestImageBuilderInjectFilesNotLinux(t *testing.T) {
+	dir, _ := newTestRootfs(t, nil)
+	defer os.RemoveAll(dir)
+	rootfs := fsdriver.NewWindowsRootFs(&testPartition{dir: dir})
+
+	builder, err := NewImageBuilder(&deployapi.ImageBuildRecipe{Scripts: []string{"true"}})
+	if err != nil {
+		t.Fatalf("NewImageBuilder: %s", err)
+	}
+	if err := builder.InjectFiles(rootfs); errors.Cause(err) != errors.ErrNotSupported {
+		t.Errorf("want not supported, got %v", err)
+	}
+}
+
+func TestImageBuilderRunCommands(t *testing.T) {
+	defer func(run func(args ...string) ([]byte, error)) { runVirtCustomize = run }(runVirtCustomize)
+
+	var gotArgs []string
+	scripts := map[string]string{}
+	runVirtCustomize = func(args ...string) ([]byte, error) {
+		gotArgs = args
+		for i := range args {
+			if args[i] == "--upload" {
+				local := args[i+1][:strings.LastIndexByte(args[i+1], ':')]
+				content, err := ioutil.ReadFile(local)
+				if err != nil {
+					return nil, err
+				}
+				scripts[local] = string(content)
+			}
+		}
+		return []byte("[   1.0] Finishing off"), nil
+	}
+
+	builder, err := NewImageBuilder(&deployapi.ImageBuildRecipe{
+		Packages:       []string{"nginx", "qemu-guest-agent"},
+		Scripts:        []string{"echo hello > /etc/hello"},
+		EnableServices: []string{"nginx"},
+	})
+	if err != nil {
+		t.Fatalf("NewImageBuilder: %s", err)
+	}
+	if err := builder.RunCommands("/opt/cloud/build.qcow2"); err != nil {
+		t.Fatalf("RunCommands: %s", err)
+	}
+
+	cmdline := strings.Join(gotArgs, " ")
+	for _, want := range []string{
+		"-a /opt/cloud/build.qcow2 --format qcow2",
+		"--install nginx,qemu-guest-agent",
+		"--run-command /bin/sh -e /tmp/.image-build-0.sh --delete /tmp/.image-build-0.sh",
+		"systemctl enable nginx",
+	} {
+		if !strings.Contains(cmdline, want) {
+			t.Errorf("%q not in %s", want, cmdline)
+		}
+	}
+	if len(scripts) != 1 {
+		t.Fatalf("want 1 uploaded script, got %d", len(scripts))
+	}
+	for local, content := range scripts {
+		if content != "echo hello > /etc/hello" {
+			t.Errorf("script content %q", content)
+		}
+		if _, err := os.Stat(filepath.Dir(local)); !os.IsNotExist(err) {
+			t.Errorf("script dir %s not removed: %v", filepath.Dir(local), err)
+		}
+	}
+	if log := builder.Finish(nil); !strings.Contains(log, "Finishing off\n") {
+		t.Errorf("virt-customize output not logged: %s", log)
+	}
+}
+
+func TestImageBuilderRunCommandsFailed(t *testing.T) {
+	defer func(run func(args ...string) ([]byte, error)) { runVirtCustomize = run }(runVirtCustomize)
+
+	called := false
+	runVirtCustomize = func(args ...string) ([]byte, error) {
+		called = true
+		return []byte("virt-customize: error: no package manager\n"), fmt.Errorf("exit status 1")
+	}
+
+	builder, _ := NewImageBuilder(&deployapi.ImageBuildRecipe{ResetMachineId: true})
+	if err := builder.RunCommands("/opt/cloud/build.qcow2"); err != nil || called {
+		t.Errorf("recipe without commands should not start the appliance: %v", err)
+	}
+
+	builder, _ = NewImageBuilder(&deployapi.ImageBuildRecipe{Packages: []string{"nginx"}})
+	err := builder.RunCommands("/opt/cloud/build.qcow2")
+	if err == nil {
+		t.Fatalf("want error")
+	}
+	log := builder.Finish(err)
+	if !strings.Contains(log, "no package manager") || !strings.Contains(log, "build failed") {
+		t.Errorf("unexpected build log %s", log)
+	}
+}
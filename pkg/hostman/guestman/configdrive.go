@@ -0,0 +1,80 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/hostman/configdrive"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+)
+
+const CONFIG_DRIVE_ID = "configdrive"
+
+func (s *SKVMGuestInstance) GetConfigDrivePath() string {
+	return path.Join(s.HomeDir(), "configdrive.iso")
+}
+
+// prepareConfigDrive regenerates config drive from guest desc,
+// the stale one is removed if config drive is disabled
+func (s *SKVMGuestInstance) prepareConfigDrive() error {
+	isoPath := s.GetConfigDrivePath()
+	format := configdrive.GetFormat(s.Desc)
+	if len(format) == 0 {
+		if fileutils2.Exists(isoPath) {
+			return os.Remove(isoPath)
+		}
+		return nil
+	}
+	if err := configdrive.BuildIso(s.Desc, format, isoPath); err != nil {
+		return errors.Wrapf(err, "build config drive %s", format)
+	}
+	return nil
+}
+
+// getConfigDriveDesc returns qemu options attaching config drive as a scsi cdrom,
+// a dedicated controller keeps it away from the ide cdrom and disks of guest
+func (s *SKVMGuestInstance) getConfigDriveDesc() string {
+	isoPath := s.GetConfigDrivePath()
+	if len(configdrive.GetFormat(s.Desc)) == 0 || !fileutils2.Exists(isoPath) {
+		return ""
+	}
+	cmd := fmt.Sprintf(" -device virtio-scsi-pci,id=%s-scsi", CONFIG_DRIVE_ID)
+	cmd += fmt.Sprintf(" -drive id=%s,if=none,media=cdrom,format=raw,readonly=on,file=%s", CONFIG_DRIVE_ID, isoPath)
+	cmd += fmt.Sprintf(" -device scsi-cd,bus=%s-scsi.0,drive=%s", CONFIG_DRIVE_ID, CONFIG_DRIVE_ID)
+	return cmd
+}
+
+// syncConfigDrive regenerates config drive of running guest and reloads the media,
+// the new volume takes effect after guest started with config drive
+func (s *SKVMGuestInstance) syncConfigDrive() {
+	if err := s.prepareConfigDrive(); err != nil {
+		log.Errorf("guest %s prepare config drive: %v", s.GetName(), err)
+		return
+	}
+	if len(configdrive.GetFormat(s.Desc)) == 0 || s.Monitor == nil {
+		return
+	}
+	s.Monitor.ChangeCdrom(CONFIG_DRIVE_ID, s.GetConfigDrivePath(), func(res string) {
+		if len(res) > 0 {
+			log.Warningf("guest %s reload config drive: %s", s.GetName(), res)
+		}
+	})
+}
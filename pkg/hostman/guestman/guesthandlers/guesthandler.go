@@ -56,30 +56,32 @@ func AddGuestTaskHandler(prefix string, app *appsrv.Application) {
 			auth.Authenticate(deleteGuest))
 
 		for action, f := range map[string]actionFunc{
-			"create":               guestCreate,
-			"deploy":               guestDeploy,
-			"rebuild":              guestRebuild,
-			"start":                guestStart,
-			"stop":                 guestStop,
-			"monitor":              guestMonitor,
-			"sync":                 guestSync,
-			"suspend":              guestSuspend,
-			"io-throttle":          guestIoThrottle,
-			"snapshot":             guestSnapshot,
-			"delete-snapshot":      guestDeleteSnapshot,
-			"reload-disk-snapshot": guestReloadDiskSnapshot,
-			"src-prepare-migrate":  guestSrcPrepareMigrate,
-			"dest-prepare-migrate": guestDestPrepareMigrate,
-			"live-migrate":         guestLiveMigrate,
-			"resume":               guestResume,
-			"drive-mirror":         guestDriveMirror,
-			"hotplug-cpu-mem":      guestHotplugCpuMem,
-			"cancel-block-jobs":    guestCancelBlockJobs,
-			"create-from-libvirt":  guestCreateFromLibvirt,
-			"create-form-esxi":     guestCreateFromEsxi,
-			"open-forward":         guestOpenForward,
-			"list-forward":         guestListForward,
-			"close-forward":        guestCloseForward,
+			"create":                     guestCreate,
+			"deploy":                     guestDeploy,
+			"rebuild":                    guestRebuild,
+			"start":                      guestStart,
+			"stop":                       guestStop,
+			"monitor":                    guestMonitor,
+			"sync":                       guestSync,
+			"suspend":                    guestSuspend,
+			"io-throttle":                guestIoThrottle,
+			"snapshot":                   guestSnapshot,
+			"delete-snapshot":            guestDeleteSnapshot,
+			"reload-disk-snapshot":       guestReloadDiskSnapshot,
+			"src-prepare-migrate":        guestSrcPrepareMigrate,
+			"dest-prepare-migrate":       guestDestPrepareMigrate,
+			"live-migrate":               guestLiveMigrate,
+			"resume":                     guestResume,
+			"drive-mirror":               guestDriveMirror,
+			"change-disk-storage":        guestChangeDiskStorage,
+			"cancel-change-disk-storage": guestCancelChangeDiskStorage,
+			"hotplug-cpu-mem":            guestHotplugCpuMem,
+			"cancel-block-jobs":          guestCancelBlockJobs,
+			"create-from-libvirt":        guestCreateFromLibvirt,
+			"create-form-esxi":           guestCreateFromEsxi,
+			"open-forward":               guestOpenForward,
+			"list-forward":               guestListForward,
+			"close-forward":              guestCloseForward,
 		} {
 			app.AddHandler("POST",
 				fmt.Sprintf("%s/%s/<sid>/%s", prefix, keyWord, action),
@@ -416,6 +418,51 @@ func guestDriveMirror(ctx context.Context, sid string, body jsonutils.JSONObject
 	return nil, nil
 }
 
+func guestChangeDiskStorage(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	if !guestman.GetGuestManager().IsGuestExist(sid) {
+		return nil, httperrors.NewNotFoundError("Guest %s not found", sid)
+	}
+	diskId, err := body.GetString("disk_id")
+	if err != nil {
+		return nil, httperrors.NewMissingParameterError("disk_id")
+	}
+	targetStorageId, err := body.GetString("target_storage_id")
+	if err != nil {
+		return nil, httperrors.NewMissingParameterError("target_storage_id")
+	}
+	targetDiskId, err := body.GetString("target_disk_id")
+	if err != nil {
+		return nil, httperrors.NewMissingParameterError("target_disk_id")
+	}
+	targetStorage := storageman.GetManager().GetStorage(targetStorageId)
+	if targetStorage == nil {
+		return nil, httperrors.NewNotFoundError("Storage %s not found", targetStorageId)
+	}
+	targetDisk, err := targetStorage.GetDiskById(targetDiskId)
+	if err != nil {
+		return nil, httperrors.NewNotFoundError("Disk %s not found on storage %s", targetDiskId, targetStorageId)
+	}
+	hostutils.DelayTaskWithoutReqctx(ctx, guestman.GetGuestManager().ChangeDiskStorage,
+		&guestman.SChangeDiskStorage{
+			Sid:           sid,
+			DiskId:        diskId,
+			TargetStorage: targetStorage,
+			TargetDisk:    targetDisk,
+		})
+	return nil, nil
+}
+
+func guestCancelChangeDiskStorage(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	if !guestman.GetGuestManager().IsGuestExist(sid) {
+		return nil, httperrors.NewNotFoundError("Guest %s not found", sid)
+	}
+	diskId, err := body.GetString("disk_id")
+	if err != nil {
+		return nil, httperrors.NewMissingParameterError("disk_id")
+	}
+	return nil, guestman.GetGuestManager().CancelChangeDiskStorage(sid, diskId)
+}
+
 func guestCancelBlockJobs(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
 	if !guestman.GetGuestManager().IsGuestExist(sid) {
 		return nil, httperrors.NewNotFoundError("Guest %s not found", sid)
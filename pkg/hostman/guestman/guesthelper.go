@@ -66,6 +66,13 @@ type SDriverMirror struct {
 	Desc         jsonutils.JSONObject
 }
 
+type SChangeDiskStorage struct {
+	Sid           string
+	DiskId        string
+	TargetStorage storageman.IStorage
+	TargetDisk    storageman.IDisk
+}
+
 type SGuestHotplugCpuMem struct {
 	Sid         string
 	AddCpuCount int64
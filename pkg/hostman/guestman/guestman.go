@@ -969,6 +969,42 @@ func (m *SGuestManager) StartDriveMirror(ctx context.Context, params interface{}
 	return nil, nil
 }
 
+func (m *SGuestManager) ChangeDiskStorage(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	changeParams, ok := params.(*SChangeDiskStorage)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	guest, ok := m.GetServer(changeParams.Sid)
+	if !ok {
+		return nil, httperrors.NewNotFoundError("guest %s not found", changeParams.Sid)
+	}
+	if !guest.IsRunning() {
+		return nil, httperrors.NewInvalidStatusError("guest is not running")
+	}
+	NewGuestChangeDiskStorageTask(ctx, guest, changeParams).Start()
+	return nil, nil
+}
+
+// CancelChangeDiskStorage cancels the mirror job of the disk, the change disk storage task
+// notices the job is gone and fails while the guest keeps running on the source disk
+func (m *SGuestManager) CancelChangeDiskStorage(sid, diskId string) error {
+	guest, _ := m.GetServer(sid)
+	if !guest.IsRunning() || !guest.IsMonitorAlive() {
+		return httperrors.NewInvalidStatusError("guest is not running")
+	}
+	diskIndex := getDescDiskIndex(guest.Desc, diskId)
+	if diskIndex < 0 {
+		return httperrors.NewNotFoundError("disk %s not found on guest %s", diskId, sid)
+	}
+	driveName := fmt.Sprintf("drive_%d", diskIndex)
+	guest.Monitor.CancelBlockJob(driveName, false, func(res string) {
+		if len(res) > 0 {
+			log.Errorf("cancel block job %s of guest %s: %s", driveName, sid, res)
+		}
+	})
+	return nil
+}
+
 func (m *SGuestManager) CancelBlockJobs(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
 	sid, ok := params.(string)
 	if !ok {
@@ -27,6 +27,7 @@ import (
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/utils"
 
+	"yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/appctx"
 	"yunion.io/x/onecloud/pkg/hostman/hostutils"
 	"yunion.io/x/onecloud/pkg/hostman/options"
@@ -1148,6 +1149,172 @@ func (s *SDriveMirrorTask) startMirror(res string) {
 	}
 }
 
+/**
+ *  GuestChangeDiskStorageTask
+**/
+
+type SGuestChangeDiskStorageTask struct {
+	*SKVMGuestInstance
+
+	ctx    context.Context
+	params *SChangeDiskStorage
+
+	diskIndex    int
+	completing   bool
+	lastProgress int
+}
+
+func NewGuestChangeDiskStorageTask(
+	ctx context.Context, guest *SKVMGuestInstance, params *SChangeDiskStorage,
+) *SGuestChangeDiskStorageTask {
+	return &SGuestChangeDiskStorageTask{
+		SKVMGuestInstance: guest,
+		ctx:               ctx,
+		params:            params,
+		diskIndex:         -1,
+		lastProgress:      -1,
+	}
+}
+
+func (s *SGuestChangeDiskStorageTask) driveName() string {
+	return fmt.Sprintf("drive_%d", s.diskIndex)
+}
+
+func (s *SGuestChangeDiskStorageTask) Start() {
+	s.diskIndex = getDescDiskIndex(s.Desc, s.params.DiskId)
+	if s.diskIndex < 0 {
+		hostutils.TaskFailed(s.ctx, fmt.Sprintf("disk %s not found on this guest", s.params.DiskId))
+		return
+	}
+	if !s.IsMonitorAlive() {
+		hostutils.TaskFailed(s.ctx, "guest monitor not alive")
+		return
+	}
+	s.Monitor.DriveMirror(s.onDriveMirrorStarted, s.driveName(),
+		s.params.TargetDisk.GetPath(), "full", true, false)
+}
+
+func (s *SGuestChangeDiskStorageTask) onDriveMirrorStarted(res string) {
+	if len(res) > 0 {
+		hostutils.TaskFailed(s.ctx, fmt.Sprintf("drive mirror %s failed: %s", s.driveName(), res))
+		return
+	}
+	log.Infof("guest %s start mirror %s to %s", s.Id, s.driveName(), s.params.TargetDisk.GetPath())
+	s.checkMirrorJob()
+}
+
+func (s *SGuestChangeDiskStorageTask) checkMirrorJob() {
+	timeutils2.AddTimeout(5*time.Second, func() {
+		if !s.IsMonitorAlive() {
+			hostutils.TaskFailed(s.ctx, "guest monitor not alive")
+			return
+		}
+		s.Monitor.GetBlockJobs(s.onGetBlockJobs)
+	})
+}
+
+func (s *SGuestChangeDiskStorageTask) onGetBlockJobs(jobs *jsonutils.JSONArray) {
+	if jobs == nil {
+		s.checkMirrorJob()
+		return
+	}
+	var job jsonutils.JSONObject
+	for i := 0; i < jobs.Length(); i++ {
+		j, _ := jobs.GetAt(i)
+		device, _ := j.GetString("device")
+		if device == s.driveName() {
+			job = j
+			break
+		}
+	}
+	if job == nil {
+		if s.completing {
+			s.onMirrorCompleted()
+		} else {
+			// job canceled by cancel-block-jobs or failed by BLOCK_JOB_ERROR
+			hostutils.TaskFailed(s.ctx, fmt.Sprintf("mirror job of %s canceled", s.driveName()))
+		}
+		return
+	}
+
+	offset, _ := job.Int("offset")
+	length, _ := job.Int("len")
+	if length > 0 {
+		s.syncProgress(int(offset * 100 / length))
+	}
+	if jsonutils.QueryBoolean(job, "ready", false) && !s.completing {
+		s.completing = true
+		s.Monitor.BlockJobComplete(s.driveName(), s.onBlockJobComplete)
+		return
+	}
+	s.checkMirrorJob()
+}
+
+func (s *SGuestChangeDiskStorageTask) syncProgress(progress int) {
+	if progress == s.lastProgress {
+		return
+	}
+	s.lastProgress = progress
+	meta := jsonutils.NewDict()
+	meta.Set(compute.CHANGE_DISK_STORAGE_PROGRESS, jsonutils.NewString(fmt.Sprintf("%d", progress)))
+	s.SyncMetadata(meta)
+}
+
+func (s *SGuestChangeDiskStorageTask) onBlockJobComplete(res string) {
+	if len(res) > 0 {
+		hostutils.TaskFailed(s.ctx, fmt.Sprintf("complete block job %s failed: %s", s.driveName(), res))
+		return
+	}
+	s.checkMirrorJob()
+}
+
+func (s *SGuestChangeDiskStorageTask) onMirrorCompleted() {
+	// drive already pivot to target disk, update desc as region will do
+	targetDiskId := s.params.TargetDisk.GetId()
+	changeDescDisk(s.Desc, s.params.DiskId, targetDiskId,
+		s.params.TargetStorage.GetId(), s.params.TargetDisk.GetPath())
+	if err := s.SaveDesc(s.Desc); err != nil {
+		log.Errorf("save guest %s desc failed: %s", s.Id, err)
+	}
+	log.Infof("guest %s disk %s changed to %s", s.Id, s.params.DiskId, targetDiskId)
+	hostutils.TaskComplete(s.ctx, nil)
+}
+
+// getDescDiskIndex returns the drive index of disk in guest desc, -1 if not found
+func getDescDiskIndex(desc jsonutils.JSONObject, diskId string) int {
+	disks, _ := desc.GetArray("disks")
+	for _, disk := range disks {
+		id, _ := disk.GetString("disk_id")
+		if id == diskId {
+			index, _ := disk.Int("index")
+			return int(index)
+		}
+	}
+	return -1
+}
+
+// changeDescDisk replaces disk in guest desc with the target disk,
+// diskPath must come from the target disk itself, since non-file
+// storages such as rbd don't place disks under storage path
+func changeDescDisk(desc jsonutils.JSONObject, diskId, targetDiskId, storageId, diskPath string) bool {
+	disks, _ := desc.GetArray("disks")
+	for _, disk := range disks {
+		id, _ := disk.GetString("disk_id")
+		if id != diskId {
+			continue
+		}
+		diskDesc, ok := disk.(*jsonutils.JSONDict)
+		if !ok {
+			return false
+		}
+		diskDesc.Set("disk_id", jsonutils.NewString(targetDiskId))
+		diskDesc.Set("storage_id", jsonutils.NewString(storageId))
+		diskDesc.Set("path", jsonutils.NewString(diskPath))
+		return true
+	}
+	return false
+}
+
 /**
  *  GuestOnlineResizeDiskTask
 **/
@@ -0,0 +1,124 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+func testGuestDesc() *jsonutils.JSONDict {
+	desc := jsonutils.NewDict()
+	disk0 := jsonutils.NewDict()
+	disk0.Set("disk_id", jsonutils.NewString("disk-0"))
+	disk0.Set("index", jsonutils.NewInt(0))
+	disk0.Set("storage_id", jsonutils.NewString("local-0"))
+	disk0.Set("path", jsonutils.NewString("/opt/cloud/workspace/disks/disk-0"))
+	disk1 := jsonutils.NewDict()
+	disk1.Set("disk_id", jsonutils.NewString("disk-1"))
+	disk1.Set("index", jsonutils.NewInt(1))
+	disk1.Set("storage_id", jsonutils.NewString("local-0"))
+	disk1.Set("path", jsonutils.NewString("/opt/cloud/workspace/disks/disk-1"))
+	desc.Set("disks", jsonutils.NewArray(disk0, disk1))
+	return desc
+}
+
+func TestGetDescDiskIndex(t *testing.T) {
+	desc := testGuestDesc()
+	cases := []struct {
+		diskId string
+		want   int
+	}{
+		{"disk-0", 0},
+		{"disk-1", 1},
+		{"disk-2", -1},
+	}
+	for _, c := range cases {
+		if got := getDescDiskIndex(desc, c.diskId); got != c.want {
+			t.Errorf("getDescDiskIndex(%s) = %d, want %d", c.diskId, got, c.want)
+		}
+	}
+}
+
+func TestChangeDescDisk(t *testing.T) {
+	cases := []struct {
+		name     string
+		diskId   string
+		diskPath string
+		want     bool
+	}{
+		{
+			name:     "local to rbd",
+			diskId:   "disk-1",
+			diskPath: "rbd:pool/disk-new:mon_host=10.0.0.1:key=xxx",
+			want:     true,
+		},
+		{
+			name:     "local to nfs",
+			diskId:   "disk-1",
+			diskPath: "/opt/cloud/nfs/disk-new",
+			want:     true,
+		},
+		{
+			name:   "disk not found",
+			diskId: "disk-2",
+			want:   false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			desc := testGuestDesc()
+			if got := changeDescDisk(desc, c.diskId, "disk-new", "storage-new", c.diskPath); got != c.want {
+				t.Fatalf("changeDescDisk = %v, want %v", got, c.want)
+			}
+			disks, _ := desc.GetArray("disks")
+			disk, _ := disks[1].(*jsonutils.JSONDict)
+			diskId, _ := disk.GetString("disk_id")
+			storageId, _ := disk.GetString("storage_id")
+			diskPath, _ := disk.GetString("path")
+			if !c.want {
+				if diskId != "disk-1" || storageId != "local-0" {
+					t.Errorf("desc changed unexpectedly: %s", disk)
+				}
+				return
+			}
+			if diskId != "disk-new" || storageId != "storage-new" || diskPath != c.diskPath {
+				t.Errorf("desc not changed to target disk: %s", disk)
+			}
+			if index, _ := disk.Int("index"); index != 1 {
+				t.Errorf("disk index changed to %d", index)
+			}
+		})
+	}
+}
+
+func TestSGuestManager_ChangeDiskStorage(t *testing.T) {
+	m := &SGuestManager{Servers: new(sync.Map)}
+	_, err := m.ChangeDiskStorage(context.Background(), &SChangeDiskStorage{Sid: "not-exist", DiskId: "disk-0"})
+	if err == nil {
+		t.Fatalf("change disk storage of guest not on host should fail")
+	}
+	if je, ok := err.(*httputils.JSONClientError); !ok || je.Code != 404 {
+		t.Errorf("want not found error, got %v", err)
+	}
+	if _, err := m.ChangeDiskStorage(context.Background(), "bad params"); err == nil {
+		t.Errorf("bad params should fail")
+	}
+}
@@ -242,6 +242,10 @@ func (s *SKVMGuestInstance) asyncScriptStart(ctx context.Context, params interfa
 
 	hostbridge.CleanDeletedPorts(options.HostOptions.BridgeDriver)
 
+	if err := s.prepareConfigDrive(); err != nil {
+		log.Errorf("guest %s prepare config drive: %v", s.GetName(), err)
+	}
+
 	time.Sleep(100 * time.Millisecond)
 	var isStarted, tried = false, 0
 	var err error
@@ -1141,6 +1145,10 @@ func (s *SKVMGuestInstance) SyncConfig(ctx context.Context, desc jsonutils.JSONO
 		return nil, nil
 	}
 
+	if !fwOnly {
+		s.syncConfigDrive()
+	}
+
 	vncPort := s.GetVncPort()
 	data := jsonutils.NewDict()
 	data.Set("vnc_port", jsonutils.NewInt(int64(vncPort)))
@@ -27,6 +27,9 @@ import (
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/utils"
 
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/hostinfo/hostbridge"
+	"yunion.io/x/onecloud/pkg/hostman/isolated_device"
 	"yunion.io/x/onecloud/pkg/hostman/options"
 	"yunion.io/x/onecloud/pkg/hostman/storageman"
 	"yunion.io/x/onecloud/pkg/util/fileutils2"
@@ -240,6 +243,9 @@ func (s *SKVMGuestInstance) getNetdevDesc(nic jsonutils.JSONObject) (string, err
 	if err := s.generateNicScripts(nic); err != nil {
 		return "", err
 	}
+	if isVhostUserNic(nic) {
+		return s.getVhostUserNetdevDesc(nic)
+	}
 	upscript := s.getNicUpScriptPath(nic)
 	downscript := s.getNicDownScriptPath(nic)
 	cmd := " -netdev type=tap"
@@ -253,8 +259,21 @@ func (s *SKVMGuestInstance) getNetdevDesc(nic jsonutils.JSONObject) (string, err
 	return cmd, nil
 }
 
+// getVhostUserNetdevDesc qemu act as vhost-user server,
+// dpdkvhostuserclient port added by nic up script connects to the socket
+func (s *SKVMGuestInstance) getVhostUserNetdevDesc(nic jsonutils.JSONObject) (string, error) {
+	if !options.HostOptions.EnableOvsDpdk {
+		return "", fmt.Errorf("OVS-DPDK datapath not enabled on host")
+	}
+	ifname, _ := nic.GetString("ifname")
+	cmd := fmt.Sprintf(" -chardev socket,id=chr-%s,path=%s,server,nowait",
+		ifname, hostbridge.GetVhostUserSocketPath(ifname))
+	cmd += fmt.Sprintf(" -netdev type=vhost-user,id=%s,chardev=chr-%s,vhostforce=on", ifname, ifname)
+	return cmd, nil
+}
+
 func (s *SKVMGuestInstance) getNicDeviceModel(name string) string {
-	if name == "virtio" {
+	if name == "virtio" || name == compute.NETWORK_DRIVER_VHOST_USER {
 		return "virtio-net-pci"
 	} else if name == "e1000" {
 		return "e1000-82545em"
@@ -263,6 +282,56 @@ func (s *SKVMGuestInstance) getNicDeviceModel(name string) string {
 	}
 }
 
+func isVfioNic(nic jsonutils.JSONObject) bool {
+	driver, _ := nic.GetString("driver")
+	return driver == compute.NETWORK_DRIVER_VFIO
+}
+
+func isVhostUserNic(nic jsonutils.JSONObject) bool {
+	driver, _ := nic.GetString("driver")
+	return driver == compute.NETWORK_DRIVER_VHOST_USER
+}
+
+func (s *SKVMGuestInstance) hasVhostUserNic() bool {
+	nics, _ := s.Desc.GetArray("nics")
+	for _, nic := range nics {
+		if isVhostUserNic(nic) {
+			return true
+		}
+	}
+	return false
+}
+
+// getSriovVfSetupScripts program mac and vlan of guest nic on the passthrough VF
+func (s *SKVMGuestInstance) getSriovVfSetupScripts(nics []jsonutils.JSONObject) (string, error) {
+	cmd := ""
+	isolatedDevs, _ := s.Desc.GetArray("isolated_devices")
+	for _, nic := range nics {
+		if !isVfioNic(nic) {
+			continue
+		}
+		index, _ := nic.Int("index")
+		mac, _ := nic.GetString("mac")
+		vlan, _ := nic.Int("vlan")
+		var vf isolated_device.ISRIOVNicDevice
+		for _, dev := range isolatedDevs {
+			devType, _ := dev.GetString("dev_type")
+			netIndex, _ := dev.Int("network_index")
+			if devType != compute.SRIOV_VF_TYPE || netIndex != index {
+				continue
+			}
+			addr, _ := dev.GetString("addr")
+			vf = s.manager.GetHost().GetIsolatedDeviceManager().GetSRIOVNicDeviceByAddr(addr)
+			break
+		}
+		if vf == nil {
+			return "", fmt.Errorf("SR-IOV VF of nic %d not found", index)
+		}
+		cmd += vf.GetVfSetupCmd(mac, int(vlan))
+	}
+	return cmd, nil
+}
+
 func (s *SKVMGuestInstance) getNicAddr(index int) int {
 	var pciBase = 10
 	disks, _ := s.Desc.GetArray("disks")
@@ -355,11 +424,24 @@ func (s *SKVMGuestInstance) _generateStartScript(data *jsonutils.JSONDict) (stri
 	isolatedDevsParams := s.manager.GetHost().GetIsolatedDeviceManager().GetQemuParams(devAddrs)
 
 	for _, nic := range nics {
+		if isVfioNic(nic) {
+			continue
+		}
 		downscript := s.getNicDownScriptPath(nic)
-		ifname, _ := nic.GetString("ifnam")
+		ifname, _ := nic.GetString("ifname")
 		cmd += fmt.Sprintf("%s %s\n", downscript, ifname)
+		if isVhostUserNic(nic) {
+			// vhost-user port is not created by qemu, add it to bridge before start
+			cmd += fmt.Sprintf("%s %s\n", s.getNicUpScriptPath(nic), ifname)
+		}
 	}
 
+	vfCmd, err := s.getSriovVfSetupScripts(nics)
+	if err != nil {
+		return "", err
+	}
+	cmd += vfCmd
+
 	if s.manager.host.IsHugepagesEnabled() {
 		cmd += fmt.Sprintf("mkdir -p /dev/hugepages/%s\n", uuid)
 		cmd += fmt.Sprintf("mount -t hugetlbfs -o size=%dM hugetlbfs-%s /dev/hugepages/%s\n",
@@ -490,7 +572,13 @@ function nic_mtu() {
 	cmd += fmt.Sprintf(" -m %dM,slots=4,maxmem=524288M", mem)
 
 	if s.manager.host.IsHugepagesEnabled() {
-		cmd += fmt.Sprintf(" -mem-prealloc -mem-path %s", fmt.Sprintf("/dev/hugepages/%s", uuid))
+		if s.hasVhostUserNic() {
+			// guest memory must be shared with vhost-user backend of OVS-DPDK
+			cmd += fmt.Sprintf(" -object memory-backend-file,id=mem,size=%dM,mem-path=/dev/hugepages/%s,share=on,prealloc=on", mem, uuid)
+			cmd += " -numa node,memdev=mem"
+		} else {
+			cmd += fmt.Sprintf(" -mem-prealloc -mem-path %s", fmt.Sprintf("/dev/hugepages/%s", uuid))
+		}
 	}
 
 	bootOrder, _ := s.Desc.GetString("boot_order")
@@ -607,7 +695,13 @@ function nic_mtu() {
 		}
 	}
 
+	cmd += s.getConfigDriveDesc()
+
 	for i := 0; i < len(nics); i++ {
+		if isVfioNic(nics[i]) {
+			// passthrough by isolated devices
+			continue
+		}
 		if osname == OS_NAME_VMWARE {
 			nics[i].(*jsonutils.JSONDict).Set("driver", jsonutils.NewString("vmxnet3"))
 		}
@@ -708,9 +802,15 @@ func (s *SKVMGuestInstance) generateStopScript(data *jsonutils.JSONDict) string
 		cmd += "fi\n"
 	}
 	for _, nic := range nics {
+		if isVfioNic(nic) {
+			continue
+		}
 		ifname, _ := nic.GetString("ifname")
 		downscript := s.getNicDownScriptPath(nic)
 		cmd += fmt.Sprintf("%s %s\n", downscript, ifname)
+		if isVhostUserNic(nic) {
+			cmd += fmt.Sprintf("rm -f %s\n", hostbridge.GetVhostUserSocketPath(ifname))
+		}
 	}
 	return cmd
 }
@@ -759,6 +859,9 @@ func (s *SKVMGuestInstance) StartPresendArp() {
 		for i := 0; i < 5; i++ {
 			nics, _ := s.Desc.GetArray("nics")
 			for _, nic := range nics {
+				if isVfioNic(nic) || isVhostUserNic(nic) {
+					continue
+				}
 				s.presendArpForNic(nic)
 			}
 			time.Sleep(1 * time.Second)
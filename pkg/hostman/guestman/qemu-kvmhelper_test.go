@@ -0,0 +1,108 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+)
+
+func TestSKVMGuestInstance_getVhostUserNetdevDesc(t *testing.T) {
+	enabled, sockDir := options.HostOptions.EnableOvsDpdk, options.HostOptions.OvsVhostUserSocketDir
+	defer func() {
+		options.HostOptions.EnableOvsDpdk = enabled
+		options.HostOptions.OvsVhostUserSocketDir = sockDir
+	}()
+	options.HostOptions.OvsVhostUserSocketDir = "/var/run/openvswitch/vhost-user"
+
+	nic := jsonutils.NewDict()
+	nic.Set("ifname", jsonutils.NewString("vnet-abc"))
+	nic.Set("driver", jsonutils.NewString(compute.NETWORK_DRIVER_VHOST_USER))
+
+	cases := []struct {
+		name    string
+		enabled bool
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "ovs-dpdk disabled",
+			enabled: false,
+			wantErr: true,
+		},
+		{
+			name:    "ovs-dpdk enabled",
+			enabled: true,
+			want: " -chardev socket,id=chr-vnet-abc,path=/var/run/openvswitch/vhost-user/vnet-abc.sock,server,nowait" +
+				" -netdev type=vhost-user,id=vnet-abc,chardev=chr-vnet-abc,vhostforce=on",
+		},
+	}
+	s := &SKVMGuestInstance{}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			options.HostOptions.EnableOvsDpdk = c.enabled
+			got, err := s.getVhostUserNetdevDesc(nic)
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("expect error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getVhostUserNetdevDesc error: %v", err)
+			}
+			if got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestIsVhostUserNic(t *testing.T) {
+	cases := []struct {
+		driver string
+		want   bool
+	}{
+		{"virtio", false},
+		{compute.NETWORK_DRIVER_VFIO, false},
+		{compute.NETWORK_DRIVER_VHOST_USER, true},
+	}
+	for _, c := range cases {
+		nic := jsonutils.NewDict()
+		nic.Set("driver", jsonutils.NewString(c.driver))
+		if got := isVhostUserNic(nic); got != c.want {
+			t.Errorf("isVhostUserNic(%s) = %v, want %v", c.driver, got, c.want)
+		}
+	}
+}
+
+func TestSKVMGuestInstance_getNicDeviceModel(t *testing.T) {
+	s := &SKVMGuestInstance{}
+	cases := map[string]string{
+		"virtio":                          "virtio-net-pci",
+		compute.NETWORK_DRIVER_VHOST_USER: "virtio-net-pci",
+		"e1000":                           "e1000-82545em",
+		"rtl8139":                         "rtl8139",
+	}
+	for driver, want := range cases {
+		if got := s.getNicDeviceModel(driver); got != want {
+			t.Errorf("getNicDeviceModel(%s) = %q, want %q", driver, got, want)
+		}
+	}
+}
@@ -15,8 +15,10 @@
 package hostman
 
 import (
+	"context"
 	"io/ioutil"
 	"path/filepath"
+	"time"
 
 	execlient "yunion.io/x/executor/client"
 	"yunion.io/x/jsonutils"
@@ -42,6 +44,7 @@ import (
 	"yunion.io/x/onecloud/pkg/hostman/storageman/diskhandlers"
 	"yunion.io/x/onecloud/pkg/hostman/storageman/storagehandler"
 	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
 	"yunion.io/x/onecloud/pkg/util/procutils"
 	"yunion.io/x/onecloud/pkg/util/sysutils"
 )
@@ -119,6 +122,18 @@ func (host *SHostService) RunService() {
 				guestDesc, _ := guestman.GetGuestManager().GetGuestNicDesc("", ip, "", "", false)
 				return guestDesc
 			}),
+			RequireTokens:       options.HostOptions.MetadataHttpTokensRequired,
+			HopLimit:            options.HostOptions.MetadataTokenHopLimit,
+			VendorDataPath:      options.HostOptions.MetadataVendorDataPath,
+			IdentityKey:         hostinfo.Instance().GetIdentityKey(),
+			IdentityDocumentTtl: time.Duration(options.HostOptions.MetadataIdentityDocumentTtlSeconds) * time.Second,
+			RegionId:            hostinfo.Instance().CloudregionId,
+			Region:              hostinfo.Instance().Cloudregion,
+			CredentialGetter: metadata.CredentialGetterFunc(func(guestId string) (jsonutils.JSONObject, error) {
+				params := jsonutils.NewDict()
+				params.Set("host_id", jsonutils.NewString(hostinfo.Instance().HostId))
+				return modules.Servers.PerformAction(hostutils.GetComputeSession(context.Background()), guestId, "instance-credential", params)
+			}),
 		},
 	)
 
@@ -1523,6 +1523,250 @@ func (m *EsxiDisksConnectionInfo) GetDisks() []*EsxiDiskInfo {
 	return nil
 }
 
+type ImageBuildFile struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Content              string   `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Mode                 int32    `protobuf:"varint,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ImageBuildFile) Reset()         { *m = ImageBuildFile{} }
+func (m *ImageBuildFile) String() string { return proto.CompactTextString(m) }
+func (*ImageBuildFile) ProtoMessage()    {}
+func (*ImageBuildFile) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05f09e103004e384, []int{20}
+}
+
+func (m *ImageBuildFile) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ImageBuildFile.Unmarshal(m, b)
+}
+func (m *ImageBuildFile) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ImageBuildFile.Marshal(b, m, deterministic)
+}
+func (m *ImageBuildFile) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImageBuildFile.Merge(m, src)
+}
+func (m *ImageBuildFile) XXX_Size() int {
+	return xxx_messageInfo_ImageBuildFile.Size(m)
+}
+func (m *ImageBuildFile) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImageBuildFile.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ImageBuildFile proto.InternalMessageInfo
+
+func (m *ImageBuildFile) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *ImageBuildFile) GetContent() string {
+	if m != nil {
+		return m.Content
+	}
+	return ""
+}
+
+func (m *ImageBuildFile) GetMode() int32 {
+	if m != nil {
+		return m.Mode
+	}
+	return 0
+}
+
+type ImageBuildRecipe struct {
+	Packages             []string          `protobuf:"bytes,1,rep,name=packages,proto3" json:"packages,omitempty"`
+	Files                []*ImageBuildFile `protobuf:"bytes,2,rep,name=files,proto3" json:"files,omitempty"`
+	Scripts              []string          `protobuf:"bytes,3,rep,name=scripts,proto3" json:"scripts,omitempty"`
+	EnableServices       []string          `protobuf:"bytes,4,rep,name=enable_services,json=enableServices,proto3" json:"enable_services,omitempty"`
+	ResetMachineId       bool              `protobuf:"varint,5,opt,name=reset_machine_id,json=resetMachineId,proto3" json:"reset_machine_id,omitempty"`
+	RemoveSshHostKeys    bool              `protobuf:"varint,6,opt,name=remove_ssh_host_keys,json=removeSshHostKeys,proto3" json:"remove_ssh_host_keys,omitempty"`
+	CleanLogs            bool              `protobuf:"varint,7,opt,name=clean_logs,json=cleanLogs,proto3" json:"clean_logs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *ImageBuildRecipe) Reset()         { *m = ImageBuildRecipe{} }
+func (m *ImageBuildRecipe) String() string { return proto.CompactTextString(m) }
+func (*ImageBuildRecipe) ProtoMessage()    {}
+func (*ImageBuildRecipe) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05f09e103004e384, []int{21}
+}
+
+func (m *ImageBuildRecipe) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ImageBuildRecipe.Unmarshal(m, b)
+}
+func (m *ImageBuildRecipe) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ImageBuildRecipe.Marshal(b, m, deterministic)
+}
+func (m *ImageBuildRecipe) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImageBuildRecipe.Merge(m, src)
+}
+func (m *ImageBuildRecipe) XXX_Size() int {
+	return xxx_messageInfo_ImageBuildRecipe.Size(m)
+}
+func (m *ImageBuildRecipe) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImageBuildRecipe.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ImageBuildRecipe proto.InternalMessageInfo
+
+func (m *ImageBuildRecipe) GetPackages() []string {
+	if m != nil {
+		return m.Packages
+	}
+	return nil
+}
+
+func (m *ImageBuildRecipe) GetFiles() []*ImageBuildFile {
+	if m != nil {
+		return m.Files
+	}
+	return nil
+}
+
+func (m *ImageBuildRecipe) GetScripts() []string {
+	if m != nil {
+		return m.Scripts
+	}
+	return nil
+}
+
+func (m *ImageBuildRecipe) GetEnableServices() []string {
+	if m != nil {
+		return m.EnableServices
+	}
+	return nil
+}
+
+func (m *ImageBuildRecipe) GetResetMachineId() bool {
+	if m != nil {
+		return m.ResetMachineId
+	}
+	return false
+}
+
+func (m *ImageBuildRecipe) GetRemoveSshHostKeys() bool {
+	if m != nil {
+		return m.RemoveSshHostKeys
+	}
+	return false
+}
+
+func (m *ImageBuildRecipe) GetCleanLogs() bool {
+	if m != nil {
+		return m.CleanLogs
+	}
+	return false
+}
+
+type BuildImageParams struct {
+	DiskPath             string            `protobuf:"bytes,1,opt,name=disk_path,json=diskPath,proto3" json:"disk_path,omitempty"`
+	Recipe               *ImageBuildRecipe `protobuf:"bytes,2,opt,name=recipe,proto3" json:"recipe,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *BuildImageParams) Reset()         { *m = BuildImageParams{} }
+func (m *BuildImageParams) String() string { return proto.CompactTextString(m) }
+func (*BuildImageParams) ProtoMessage()    {}
+func (*BuildImageParams) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05f09e103004e384, []int{22}
+}
+
+func (m *BuildImageParams) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BuildImageParams.Unmarshal(m, b)
+}
+func (m *BuildImageParams) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BuildImageParams.Marshal(b, m, deterministic)
+}
+func (m *BuildImageParams) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BuildImageParams.Merge(m, src)
+}
+func (m *BuildImageParams) XXX_Size() int {
+	return xxx_messageInfo_BuildImageParams.Size(m)
+}
+func (m *BuildImageParams) XXX_DiscardUnknown() {
+	xxx_messageInfo_BuildImageParams.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BuildImageParams proto.InternalMessageInfo
+
+func (m *BuildImageParams) GetDiskPath() string {
+	if m != nil {
+		return m.DiskPath
+	}
+	return ""
+}
+
+func (m *BuildImageParams) GetRecipe() *ImageBuildRecipe {
+	if m != nil {
+		return m.Recipe
+	}
+	return nil
+}
+
+type BuildImageResponse struct {
+	Log                  string     `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	ErrorMsg             string     `protobuf:"bytes,2,opt,name=error_msg,json=errorMsg,proto3" json:"error_msg,omitempty"`
+	ImageInfo            *ImageInfo `protobuf:"bytes,3,opt,name=image_info,json=imageInfo,proto3" json:"image_info,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *BuildImageResponse) Reset()         { *m = BuildImageResponse{} }
+func (m *BuildImageResponse) String() string { return proto.CompactTextString(m) }
+func (*BuildImageResponse) ProtoMessage()    {}
+func (*BuildImageResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_05f09e103004e384, []int{23}
+}
+
+func (m *BuildImageResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BuildImageResponse.Unmarshal(m, b)
+}
+func (m *BuildImageResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BuildImageResponse.Marshal(b, m, deterministic)
+}
+func (m *BuildImageResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BuildImageResponse.Merge(m, src)
+}
+func (m *BuildImageResponse) XXX_Size() int {
+	return xxx_messageInfo_BuildImageResponse.Size(m)
+}
+func (m *BuildImageResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BuildImageResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BuildImageResponse proto.InternalMessageInfo
+
+func (m *BuildImageResponse) GetLog() string {
+	if m != nil {
+		return m.Log
+	}
+	return ""
+}
+
+func (m *BuildImageResponse) GetErrorMsg() string {
+	if m != nil {
+		return m.ErrorMsg
+	}
+	return ""
+}
+
+func (m *BuildImageResponse) GetImageInfo() *ImageInfo {
+	if m != nil {
+		return m.ImageInfo
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*GuestDesc)(nil), "apis.GuestDesc")
 	proto.RegisterType((*Disk)(nil), "apis.Disk")
@@ -1544,121 +1788,140 @@ func init() {
 	proto.RegisterType((*EsxiDiskInfo)(nil), "apis.EsxiDiskInfo")
 	proto.RegisterType((*ConnectEsxiDisksParams)(nil), "apis.ConnectEsxiDisksParams")
 	proto.RegisterType((*EsxiDisksConnectionInfo)(nil), "apis.EsxiDisksConnectionInfo")
+	proto.RegisterType((*ImageBuildFile)(nil), "apis.ImageBuildFile")
+	proto.RegisterType((*ImageBuildRecipe)(nil), "apis.ImageBuildRecipe")
+	proto.RegisterType((*BuildImageParams)(nil), "apis.BuildImageParams")
+	proto.RegisterType((*BuildImageResponse)(nil), "apis.BuildImageResponse")
 }
 
 func init() { proto.RegisterFile("deploy.proto", fileDescriptor_05f09e103004e384) }
 
 var fileDescriptor_05f09e103004e384 = []byte{
-	// 1735 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x57, 0x4f, 0x6f, 0x1c, 0x49,
-	0x15, 0xd7, 0xfc, 0x9f, 0x7e, 0xe3, 0xd8, 0x4e, 0xc5, 0xb1, 0x7b, 0x27, 0x9b, 0x5d, 0x6b, 0x10,
-	0xc8, 0x0a, 0x59, 0x4b, 0x38, 0xc0, 0x05, 0x21, 0x11, 0xd9, 0x9b, 0xdd, 0x51, 0xd8, 0xc5, 0x6a,
-	0x27, 0x70, 0xa3, 0x55, 0xee, 0xae, 0x99, 0x29, 0xdc, 0x5d, 0xd5, 0xea, 0xaa, 0x99, 0xc9, 0x20,
-	0xed, 0x37, 0xe2, 0x84, 0xc4, 0x95, 0x23, 0xdf, 0x01, 0x3e, 0x02, 0x5f, 0x80, 0x2b, 0x7a, 0xaf,
-	0xaa, 0x7b, 0x7a, 0xbc, 0x21, 0x9b, 0x0b, 0xa7, 0xae, 0xf7, 0xa7, 0xaa, 0xde, 0xdf, 0xdf, 0xab,
-	0x86, 0xbd, 0x54, 0x14, 0x99, 0xde, 0x9c, 0x17, 0xa5, 0xb6, 0x9a, 0x75, 0x79, 0x21, 0xcd, 0xe4,
-	0x5f, 0x2d, 0x08, 0xbe, 0x5a, 0x0a, 0x63, 0xaf, 0x84, 0x49, 0x18, 0x83, 0xae, 0xe2, 0xb9, 0x08,
-	0x5b, 0xa7, 0xad, 0xb3, 0x20, 0xa2, 0x35, 0xf2, 0x96, 0x4b, 0x99, 0x86, 0x6d, 0xc7, 0xc3, 0x35,
-	0x3b, 0x86, 0x7e, 0xaa, 0x73, 0x2e, 0x55, 0xd8, 0x21, 0xae, 0xa7, 0xd8, 0x53, 0xe8, 0x2a, 0x99,
-	0x98, 0xb0, 0x7b, 0xda, 0x39, 0x1b, 0x5d, 0x04, 0xe7, 0x78, 0xc5, 0xf9, 0xb7, 0x32, 0x89, 0x88,
-	0xcd, 0x9e, 0xc3, 0x1e, 0x7e, 0x63, 0x63, 0xb9, 0x4a, 0x6f, 0x37, 0x61, 0xef, 0xbe, 0xda, 0x08,
-	0xc5, 0x37, 0x4e, 0xca, 0x4e, 0xa1, 0x97, 0x4a, 0x73, 0x67, 0xc2, 0x3e, 0xa9, 0x81, 0x53, 0xbb,
-	0x92, 0xe6, 0x2e, 0x72, 0x02, 0xf6, 0x19, 0xc0, 0xd7, 0x9b, 0x42, 0x94, 0x2b, 0x69, 0x74, 0x19,
-	0x0e, 0xc8, 0x94, 0x06, 0x67, 0xf2, 0xcf, 0x0e, 0x74, 0x51, 0x9f, 0x9d, 0xc0, 0x00, 0x77, 0xc4,
-	0x32, 0xf5, 0xae, 0xf5, 0x91, 0x9c, 0x3a, 0x47, 0x4a, 0xb9, 0x12, 0xa5, 0x77, 0xcf, 0x53, 0xec,
-	0x29, 0x40, 0xc2, 0x93, 0x85, 0x88, 0x73, 0x9d, 0x0a, 0xef, 0x64, 0x40, 0x9c, 0x6f, 0x74, 0x2a,
-	0xd8, 0x27, 0x30, 0xe4, 0x52, 0x3b, 0x61, 0x97, 0x84, 0x03, 0x2e, 0x35, 0x89, 0x18, 0x74, 0x8d,
-	0xfc, 0xb3, 0x08, 0x7b, 0xa7, 0xad, 0xb3, 0x4e, 0x44, 0x6b, 0xf6, 0x39, 0x8c, 0xac, 0xc8, 0x8b,
-	0x8c, 0x5b, 0x81, 0x26, 0xf4, 0x9d, 0xa1, 0x15, 0x6b, 0x9a, 0xe2, 0x75, 0x32, 0xe7, 0x73, 0x11,
-	0x17, 0xdc, 0x2e, 0xbc, 0x23, 0x01, 0x71, 0xae, 0xb9, 0x5d, 0xa0, 0xd8, 0x58, 0x5d, 0xa2, 0x82,
-	0x4c, 0xc3, 0xa1, 0x13, 0x7b, 0xce, 0x34, 0x65, 0x9f, 0x42, 0x90, 0xcb, 0x79, 0xc9, 0xad, 0x54,
-	0xf3, 0x30, 0x38, 0x6d, 0x9d, 0x0d, 0xa3, 0x2d, 0x83, 0x3d, 0x83, 0x87, 0x96, 0x97, 0x73, 0x61,
-	0xe3, 0xc6, 0x19, 0x40, 0x67, 0x1c, 0x38, 0xc1, 0x4d, 0x7d, 0x12, 0x83, 0x2e, 0x59, 0x30, 0x72,
-	0xb9, 0xc6, 0x35, 0x86, 0x68, 0xa6, 0xcb, 0x9c, 0xdb, 0x70, 0xcf, 0x85, 0xc8, 0x51, 0xec, 0x08,
-	0x7a, 0x52, 0xa5, 0xe2, 0x5d, 0xf8, 0xe0, 0xb4, 0x75, 0xd6, 0x8b, 0x1c, 0xc1, 0x7e, 0x0c, 0xfb,
-	0xb9, 0x28, 0xe7, 0x22, 0x36, 0x8a, 0x17, 0x66, 0xa1, 0x6d, 0xb8, 0x4f, 0x06, 0x3d, 0x20, 0xee,
-	0x8d, 0x67, 0xb2, 0x7d, 0x68, 0xcf, 0x4c, 0x78, 0x40, 0x07, 0xb6, 0x67, 0x94, 0xc9, 0x5c, 0x2f,
-	0x95, 0x2d, 0xb4, 0x54, 0x36, 0x3c, 0x74, 0x01, 0xda, 0x72, 0xd8, 0x21, 0x74, 0x52, 0xb1, 0x0a,
-	0x1f, 0x92, 0x00, 0x97, 0x93, 0x7f, 0x77, 0xa1, 0xf3, 0xad, 0x4c, 0x50, 0x92, 0xf3, 0xc4, 0xa7,
-	0x15, 0x97, 0x78, 0xb6, 0x2c, 0x7c, 0x3e, 0xdb, 0xb2, 0x40, 0x0d, 0x25, 0xac, 0x4f, 0x22, 0x2e,
-	0xd9, 0x63, 0xe8, 0x2b, 0x61, 0x31, 0x0e, 0x2e, 0x79, 0x3d, 0x25, 0xec, 0x34, 0x65, 0x21, 0x0c,
-	0x56, 0xb2, 0xb4, 0x4b, 0x9e, 0x51, 0xf6, 0x86, 0x51, 0x45, 0xa2, 0x64, 0xce, 0xad, 0x58, 0xf3,
-	0x8d, 0x4f, 0x5e, 0x45, 0x92, 0x61, 0xca, 0xf8, 0x94, 0xe1, 0xb2, 0xd1, 0x1b, 0xc3, 0x9d, 0xde,
-	0x38, 0x86, 0x7e, 0xa9, 0x97, 0x56, 0x18, 0x4a, 0x51, 0x10, 0x79, 0x0a, 0xf9, 0x72, 0x46, 0x5d,
-	0xe7, 0x92, 0xe2, 0x29, 0xbc, 0x33, 0xe7, 0xe6, 0x2e, 0x13, 0x8a, 0xd2, 0xd1, 0x8b, 0x2a, 0xb2,
-	0x51, 0xb4, 0x7b, 0x3b, 0x45, 0x7b, 0x0c, 0xfd, 0xdb, 0x52, 0xa6, 0x73, 0x41, 0x29, 0x09, 0x22,
-	0x4f, 0x61, 0xf5, 0xaf, 0x65, 0x49, 0x79, 0xdf, 0x77, 0x02, 0x24, 0x5d, 0xba, 0x57, 0x19, 0x57,
-	0x94, 0x87, 0x5e, 0x44, 0x6b, 0x2c, 0x26, 0xa9, 0xac, 0x28, 0x67, 0x3c, 0x11, 0x3e, 0x11, 0x5b,
-	0x06, 0xc6, 0xf6, 0x76, 0x4d, 0x69, 0xe8, 0x45, 0xed, 0xdb, 0xf5, 0xb6, 0x08, 0x58, 0xb3, 0x08,
-	0x3e, 0x87, 0x91, 0x8f, 0x5c, 0x2c, 0x0b, 0x13, 0x3e, 0x3a, 0xed, 0x60, 0x3a, 0x3d, 0x6b, 0x5a,
-	0x18, 0x54, 0x10, 0xef, 0xac, 0x28, 0x95, 0xc8, 0xd0, 0xaa, 0x23, 0x97, 0xef, 0x8a, 0x35, 0x4d,
-	0xd9, 0x13, 0x08, 0xac, 0xe0, 0x79, 0xbc, 0x96, 0x76, 0x11, 0x3e, 0x26, 0xf1, 0x10, 0x19, 0x7f,
-	0x90, 0xae, 0x22, 0x73, 0xae, 0x30, 0x4d, 0xc7, 0x94, 0x26, 0x4f, 0x61, 0x57, 0x2a, 0x99, 0xc4,
-	0x76, 0x53, 0x88, 0xf0, 0xc4, 0xa5, 0x49, 0xc9, 0xe4, 0xcd, 0xa6, 0xa0, 0x10, 0x64, 0x52, 0xdd,
-	0xc5, 0xcb, 0x22, 0x0c, 0xdd, 0x1e, 0x24, 0xdf, 0x52, 0x71, 0xe4, 0x76, 0x19, 0x7e, 0x42, 0xdd,
-	0x8a, 0xcb, 0x1a, 0x03, 0xc7, 0x5b, 0x0c, 0x9c, 0xac, 0x61, 0xf4, 0xfb, 0xab, 0xab, 0xd7, 0x97,
-	0x5a, 0x4d, 0xd5, 0x4c, 0xa3, 0xca, 0x42, 0x1b, 0x5b, 0xc1, 0x24, 0xae, 0xa9, 0x75, 0x74, 0x69,
-	0xa9, 0xee, 0x7a, 0x11, 0xad, 0x09, 0x3a, 0x8d, 0x28, 0x7d, 0xe9, 0xd1, 0x1a, 0x8d, 0x2f, 0xb8,
-	0x31, 0xeb, 0xaa, 0xf6, 0x3c, 0x85, 0x91, 0x5c, 0xe5, 0xa5, 0x98, 0x51, 0xe9, 0x05, 0x91, 0x23,
-	0x26, 0xff, 0x69, 0x03, 0x5c, 0x11, 0x6a, 0xd3, 0xc5, 0xcf, 0x01, 0x8a, 0xe5, 0x6d, 0x26, 0x93,
-	0xf8, 0x4e, 0x6c, 0xe8, 0xfa, 0xd1, 0xc5, 0x03, 0x87, 0x8b, 0x37, 0x37, 0x5f, 0xbf, 0x16, 0x1b,
-	0x13, 0x05, 0x4e, 0xe1, 0xb5, 0xd8, 0xb0, 0x2f, 0x60, 0xe0, 0x10, 0xdf, 0x84, 0x6d, 0x82, 0xd0,
-	0x47, 0x1e, 0x42, 0x89, 0x79, 0xa9, 0x95, 0x15, 0xca, 0x46, 0x95, 0x0e, 0x1b, 0xc3, 0x90, 0x6c,
-	0xd1, 0x65, 0xea, 0x2d, 0xae, 0x69, 0x8c, 0x9f, 0x34, 0xb1, 0x54, 0xd2, 0x92, 0xd9, 0xc3, 0xa8,
-	0x2f, 0xcd, 0x54, 0x49, 0x8b, 0xd0, 0x24, 0x14, 0xbf, 0xcd, 0x44, 0x6c, 0xed, 0xc6, 0xb7, 0x4d,
-	0xe0, 0x38, 0x6f, 0xec, 0x06, 0xc1, 0x27, 0x15, 0x33, 0xbe, 0xcc, 0x6c, 0x5c, 0x6a, 0x6d, 0x63,
-	0x0a, 0x47, 0x9f, 0xb4, 0x0e, 0xbc, 0x20, 0xd2, 0xda, 0xbe, 0xc5, 0xc8, 0xfc, 0x0a, 0xc6, 0x6b,
-	0xa9, 0x52, 0xbd, 0x36, 0x71, 0xb5, 0x87, 0xa7, 0xb9, 0x54, 0x6e, 0xd3, 0x80, 0x36, 0x9d, 0x78,
-	0x8d, 0x2b, 0xa7, 0xf0, 0x12, 0xe5, 0xb4, 0xf9, 0x19, 0x3c, 0xf4, 0x76, 0x24, 0x99, 0x5e, 0xa6,
-	0xce, 0xd4, 0xa1, 0xbb, 0xc8, 0x09, 0x2e, 0x91, 0x4f, 0x36, 0xff, 0x08, 0x1e, 0x64, 0x7a, 0x2e,
-	0x55, 0xcc, 0x93, 0x04, 0x21, 0xc6, 0x37, 0xe4, 0x1e, 0x31, 0x5f, 0x3a, 0xde, 0xe4, 0x2f, 0x2d,
-	0x18, 0xf8, 0x98, 0xa2, 0x93, 0xf7, 0xc2, 0x1e, 0x34, 0xe3, 0x4c, 0x4e, 0x66, 0xc2, 0x8a, 0xb8,
-	0xa1, 0xe5, 0xf0, 0xe7, 0xc0, 0x09, 0xae, 0x6b, 0xdd, 0x33, 0x38, 0x74, 0x4e, 0x35, 0x54, 0x5d,
-	0xb0, 0xf7, 0x89, 0xbf, 0xd5, 0x7c, 0x0e, 0xac, 0x28, 0xf5, 0x9f, 0x44, 0x62, 0x9b, 0xba, 0xae,
-	0x68, 0x0e, 0xbd, 0xa4, 0xd6, 0x9e, 0xbc, 0x85, 0x07, 0x3b, 0x69, 0xad, 0xa1, 0xbc, 0xd5, 0x80,
-	0xf2, 0x10, 0x06, 0x89, 0x13, 0x7b, 0xf3, 0x2a, 0x12, 0xab, 0x92, 0x27, 0x56, 0xea, 0x7a, 0xa0,
-	0x3b, 0x6a, 0x32, 0x80, 0xde, 0x97, 0x79, 0x61, 0x37, 0x93, 0xbf, 0xb5, 0xe0, 0xb1, 0xbb, 0x80,
-	0x5e, 0x0b, 0xaf, 0x4c, 0x24, 0x4c, 0xa1, 0x95, 0x11, 0x84, 0x46, 0xd2, 0xd8, 0x52, 0x37, 0x46,
-	0xab, 0x2d, 0x35, 0xa1, 0xa9, 0x28, 0x0d, 0x9e, 0xe9, 0x2f, 0xf3, 0x24, 0x9a, 0xc6, 0xcb, 0x64,
-	0x51, 0xb5, 0x05, 0xae, 0xb1, 0xf8, 0x32, 0xae, 0xe6, 0x4b, 0x3e, 0xaf, 0x26, 0x6a, 0x4d, 0x23,
-	0xe8, 0x68, 0xe3, 0xfb, 0xa2, 0xad, 0x0d, 0x9e, 0x5c, 0x65, 0xce, 0xa3, 0xb1, 0x27, 0xb1, 0x9b,
-	0x31, 0x48, 0x1e, 0x8d, 0xef, 0xc4, 0x66, 0xf2, 0xf7, 0x16, 0xec, 0x39, 0xbb, 0xaf, 0x79, 0xc9,
-	0x73, 0x83, 0xc8, 0x42, 0x4f, 0x81, 0x46, 0x70, 0x86, 0xc8, 0xa0, 0x41, 0x7b, 0x0e, 0x30, 0x47,
-	0xf7, 0xe2, 0x54, 0x98, 0x84, 0xcc, 0x1e, 0x5d, 0x1c, 0xb8, 0xa6, 0xa9, 0x1f, 0x49, 0x51, 0x30,
-	0xaf, 0xdf, 0x4b, 0x3f, 0x83, 0x91, 0xeb, 0x9e, 0x58, 0xaa, 0x99, 0x26, 0x87, 0x46, 0x17, 0x87,
-	0xcd, 0x2e, 0xc3, 0xb6, 0x8d, 0x20, 0xdd, 0xb6, 0xf0, 0x39, 0x04, 0xab, 0x34, 0xbd, 0x73, 0x1b,
-	0xba, 0xb4, 0xe1, 0xa1, 0xdb, 0xd0, 0x40, 0x98, 0x68, 0x88, 0x3a, 0xb8, 0x9a, 0x7c, 0x07, 0xfb,
-	0x91, 0xc0, 0x57, 0xc4, 0x2b, 0xf3, 0x31, 0x1e, 0x7c, 0x06, 0xb0, 0xd8, 0x3e, 0x89, 0x5c, 0xe0,
-	0x1b, 0x9c, 0xdd, 0xeb, 0x3b, 0x3f, 0x7c, 0xfd, 0x1f, 0x61, 0xff, 0x15, 0xcd, 0xfb, 0x8f, 0xbb,
-	0xfe, 0x09, 0x04, 0x33, 0x13, 0xfb, 0xf7, 0x82, 0xbb, 0x7d, 0x38, 0x33, 0xee, 0x84, 0xfa, 0x25,
-	0xd9, 0xd9, 0xbe, 0x24, 0x27, 0x1a, 0x46, 0x91, 0xc8, 0x04, 0x37, 0x82, 0xa2, 0xf3, 0x7f, 0x2f,
-	0xa6, 0xc9, 0x37, 0xc0, 0x6e, 0xf8, 0x4a, 0xbc, 0xd1, 0x5f, 0x65, 0x5c, 0x25, 0xe2, 0x63, 0x9c,
-	0x1a, 0xc3, 0x30, 0xd1, 0x79, 0x51, 0x0a, 0x63, 0xe8, 0xf6, 0x61, 0x54, 0xd3, 0x13, 0x01, 0x47,
-	0xcd, 0xe3, 0xea, 0xae, 0x38, 0x81, 0x81, 0x36, 0x2e, 0xca, 0xde, 0x13, 0x6d, 0xc8, 0xc3, 0x9f,
-	0xc3, 0x5e, 0xe9, 0x1c, 0x76, 0xd2, 0x76, 0x33, 0x07, 0x8d, 0x50, 0x44, 0xa3, 0x72, 0x4b, 0x4c,
-	0x5e, 0xc0, 0xd1, 0x75, 0xa9, 0x6f, 0xc5, 0x14, 0xdf, 0x84, 0xc8, 0xb9, 0x2e, 0x79, 0xce, 0x3f,
-	0x6c, 0xf7, 0xe4, 0xaf, 0x6d, 0x08, 0xea, 0x0d, 0xec, 0xd9, 0xae, 0x45, 0xef, 0xbd, 0xb3, 0x32,
-	0xd2, 0x59, 0x4f, 0x83, 0xb4, 0x5d, 0x59, 0x4f, 0x73, 0xf4, 0x27, 0x70, 0x20, 0x4d, 0xbc, 0x14,
-	0x33, 0x19, 0x9b, 0x65, 0x41, 0x03, 0xaf, 0xe3, 0xde, 0x77, 0xd2, 0xbc, 0x15, 0x33, 0x79, 0xe3,
-	0x98, 0x08, 0x73, 0xd2, 0xc4, 0xd9, 0x2a, 0x8f, 0x0b, 0x5e, 0x5a, 0x49, 0xc8, 0xe2, 0x06, 0xc7,
-	0xbe, 0x34, 0xbf, 0x5d, 0xe5, 0xd7, 0x15, 0x17, 0x9f, 0x02, 0xd2, 0xc4, 0xa5, 0xe0, 0xa9, 0x56,
-	0x59, 0x35, 0x41, 0x40, 0x9a, 0xc8, 0x73, 0xd8, 0x2f, 0xe1, 0xa4, 0x58, 0x6c, 0x8c, 0x4c, 0x78,
-	0xb6, 0x3d, 0xcc, 0xd9, 0xe6, 0xba, 0xff, 0x71, 0x25, 0xae, 0x0f, 0x25, 0x53, 0x7f, 0x01, 0x27,
-	0x34, 0xb2, 0x8c, 0xe5, 0x59, 0x26, 0xd2, 0xe6, 0x5c, 0x70, 0xb3, 0xe4, 0x08, 0x47, 0x98, 0x97,
-	0xd6, 0xc3, 0x61, 0xf2, 0x53, 0xd8, 0xfb, 0xd2, 0xbc, 0x93, 0xf8, 0xdb, 0x40, 0xa1, 0xf8, 0x60,
-	0x84, 0xbf, 0x83, 0xe3, 0x4b, 0xad, 0x94, 0x48, 0x6c, 0xb5, 0xa7, 0xea, 0x92, 0x9d, 0x3e, 0x6b,
-	0xfd, 0x60, 0x9f, 0xb1, 0x17, 0x30, 0xe2, 0x49, 0x22, 0x8c, 0xa9, 0xaa, 0x02, 0xe7, 0x35, 0x73,
-	0x3b, 0x9a, 0xf6, 0x44, 0xe0, 0xd4, 0xa8, 0x2a, 0x2e, 0xe1, 0xa4, 0xbe, 0xd7, 0xdb, 0x21, 0xfd,
-	0x13, 0xe5, 0xac, 0xfa, 0x79, 0x6a, 0xfd, 0xcf, 0x93, 0x9c, 0xc2, 0xc5, 0x3f, 0x3a, 0x30, 0x72,
-	0x58, 0xf5, 0x72, 0x8e, 0xa3, 0xe0, 0x37, 0xd5, 0x24, 0xf1, 0x40, 0xcf, 0x58, 0x13, 0xcf, 0x9c,
-	0x7b, 0xe3, 0x27, 0x4d, 0xde, 0xfd, 0x89, 0xf0, 0x05, 0x0c, 0x2b, 0xc8, 0x62, 0x47, 0x55, 0x91,
-	0x35, 0x21, 0x6c, 0x3c, 0xf2, 0xe6, 0xe0, 0x68, 0x41, 0xf5, 0x0a, 0x62, 0x2a, 0xf5, 0x5d, 0xc8,
-	0xd9, 0x55, 0xbf, 0x82, 0xbd, 0x66, 0xc7, 0xb1, 0xd0, 0xbf, 0x7f, 0xbe, 0xd7, 0xd4, 0xe3, 0xf1,
-	0xf7, 0x25, 0xb5, 0x8d, 0xbf, 0x86, 0xfd, 0xdd, 0x86, 0x62, 0x5e, 0xfb, 0x7d, 0x6d, 0x36, 0xf6,
-	0x33, 0x60, 0xab, 0xfc, 0x3b, 0x38, 0xbc, 0x9f, 0x78, 0xf6, 0xa9, 0x53, 0x7a, 0x7f, 0x41, 0x8c,
-	0x9f, 0xee, 0x66, 0xe0, 0x7e, 0xbe, 0x5e, 0xc2, 0xa3, 0x2b, 0x69, 0x92, 0xfb, 0x67, 0x7e, 0x78,
-	0xd7, 0x4e, 0x60, 0x6e, 0xfb, 0xf4, 0x5f, 0xff, 0xe2, 0xbf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x30,
-	0xc4, 0xb6, 0xf0, 0xe7, 0x0f, 0x00, 0x00,
+	// 1981 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0x4f, 0x73, 0x1b, 0x49,
+	0x15, 0x2f, 0xfd, 0xb3, 0x35, 0x4f, 0x8e, 0xec, 0x74, 0x1c, 0x7b, 0x56, 0xd9, 0xec, 0xba, 0x86,
+	0x02, 0x5c, 0x21, 0x1b, 0x8a, 0x04, 0xb8, 0x50, 0x50, 0x84, 0x78, 0xb3, 0x51, 0x65, 0xb3, 0xb8,
+	0xc6, 0x09, 0xdc, 0x98, 0x6a, 0xcf, 0xb4, 0xa4, 0xc6, 0x33, 0xd3, 0x53, 0xdd, 0x2d, 0x39, 0xa2,
+	0x6a, 0xaf, 0x7c, 0x05, 0xbe, 0x04, 0x27, 0xaa, 0xb8, 0xf2, 0x3d, 0xe0, 0x23, 0xf0, 0x05, 0xb8,
+	0x52, 0xef, 0x75, 0xcf, 0x68, 0xe4, 0x0d, 0xd9, 0x70, 0xd8, 0x93, 0xfa, 0xfd, 0xde, 0xeb, 0xee,
+	0xd7, 0xef, 0xff, 0x08, 0xf6, 0x32, 0x51, 0xe5, 0x6a, 0xfd, 0xa8, 0xd2, 0xca, 0x2a, 0xd6, 0xe7,
+	0x95, 0x34, 0xd1, 0xbf, 0x3a, 0x10, 0x7c, 0xb1, 0x14, 0xc6, 0x9e, 0x09, 0x93, 0x32, 0x06, 0xfd,
+	0x92, 0x17, 0x22, 0xec, 0x9c, 0x74, 0x4e, 0x83, 0x98, 0xd6, 0x88, 0x2d, 0x97, 0x32, 0x0b, 0xbb,
+	0x0e, 0xc3, 0x35, 0x3b, 0x82, 0x9d, 0x4c, 0x15, 0x5c, 0x96, 0x61, 0x8f, 0x50, 0x4f, 0xb1, 0xfb,
+	0xd0, 0x2f, 0x65, 0x6a, 0xc2, 0xfe, 0x49, 0xef, 0x74, 0xf4, 0x38, 0x78, 0x84, 0x57, 0x3c, 0xfa,
+	0x4a, 0xa6, 0x31, 0xc1, 0xec, 0x21, 0xec, 0xe1, 0x6f, 0x62, 0x2c, 0x2f, 0xb3, 0xcb, 0x75, 0x38,
+	0xb8, 0x29, 0x36, 0x42, 0xf6, 0x85, 0xe3, 0xb2, 0x13, 0x18, 0x64, 0xd2, 0x5c, 0x99, 0x70, 0x87,
+	0xc4, 0xc0, 0x89, 0x9d, 0x49, 0x73, 0x15, 0x3b, 0x06, 0xfb, 0x04, 0xe0, 0xc5, 0xba, 0x12, 0x7a,
+	0x25, 0x8d, 0xd2, 0xe1, 0x2e, 0xa9, 0xd2, 0x42, 0xa2, 0x7f, 0xf6, 0xa0, 0x8f, 0xf2, 0xec, 0x18,
+	0x76, 0x71, 0x47, 0x22, 0x33, 0xff, 0xb4, 0x1d, 0x24, 0xa7, 0xee, 0x21, 0x5a, 0xae, 0x84, 0xf6,
+	0xcf, 0xf3, 0x14, 0xbb, 0x0f, 0x90, 0xf2, 0x74, 0x21, 0x92, 0x42, 0x65, 0xc2, 0x3f, 0x32, 0x20,
+	0xe4, 0x95, 0xca, 0x04, 0xfb, 0x08, 0x86, 0x5c, 0x2a, 0xc7, 0xec, 0x13, 0x73, 0x97, 0x4b, 0x45,
+	0x2c, 0x06, 0x7d, 0x23, 0xff, 0x24, 0xc2, 0xc1, 0x49, 0xe7, 0xb4, 0x17, 0xd3, 0x9a, 0x7d, 0x0a,
+	0x23, 0x2b, 0x8a, 0x2a, 0xe7, 0x56, 0xa0, 0x0a, 0x3b, 0x4e, 0xd1, 0x1a, 0x9a, 0x66, 0x78, 0x9d,
+	0x2c, 0xf8, 0x5c, 0x24, 0x15, 0xb7, 0x0b, 0xff, 0x90, 0x80, 0x90, 0x73, 0x6e, 0x17, 0xc8, 0x36,
+	0x56, 0x69, 0x14, 0x90, 0x59, 0x38, 0x74, 0x6c, 0x8f, 0x4c, 0x33, 0xf6, 0x31, 0x04, 0x85, 0x9c,
+	0x6b, 0x6e, 0x65, 0x39, 0x0f, 0x83, 0x93, 0xce, 0xe9, 0x30, 0xde, 0x00, 0xec, 0x01, 0xdc, 0xb6,
+	0x5c, 0xcf, 0x85, 0x4d, 0x5a, 0x67, 0x00, 0x9d, 0xb1, 0xef, 0x18, 0x17, 0xcd, 0x49, 0x0c, 0xfa,
+	0xa4, 0xc1, 0xc8, 0xf9, 0x1a, 0xd7, 0x68, 0xa2, 0x99, 0xd2, 0x05, 0xb7, 0xe1, 0x9e, 0x33, 0x91,
+	0xa3, 0xd8, 0x21, 0x0c, 0x64, 0x99, 0x89, 0xb7, 0xe1, 0xad, 0x93, 0xce, 0xe9, 0x20, 0x76, 0x04,
+	0xfb, 0x3e, 0x8c, 0x0b, 0xa1, 0xe7, 0x22, 0x31, 0x25, 0xaf, 0xcc, 0x42, 0xd9, 0x70, 0x4c, 0x0a,
+	0xdd, 0x22, 0xf4, 0xc2, 0x83, 0x6c, 0x0c, 0xdd, 0x99, 0x09, 0xf7, 0xe9, 0xc0, 0xee, 0x8c, 0x3c,
+	0x59, 0xa8, 0x65, 0x69, 0x2b, 0x25, 0x4b, 0x1b, 0x1e, 0x38, 0x03, 0x6d, 0x10, 0x76, 0x00, 0xbd,
+	0x4c, 0xac, 0xc2, 0xdb, 0xc4, 0xc0, 0x65, 0xf4, 0xef, 0x3e, 0xf4, 0xbe, 0x92, 0x29, 0x72, 0x0a,
+	0x9e, 0x7a, 0xb7, 0xe2, 0x12, 0xcf, 0x96, 0x95, 0xf7, 0x67, 0x57, 0x56, 0x28, 0x51, 0x0a, 0xeb,
+	0x9d, 0x88, 0x4b, 0x76, 0x17, 0x76, 0x4a, 0x61, 0xd1, 0x0e, 0xce, 0x79, 0x83, 0x52, 0xd8, 0x69,
+	0xc6, 0x42, 0xd8, 0x5d, 0x49, 0x6d, 0x97, 0x3c, 0x27, 0xef, 0x0d, 0xe3, 0x9a, 0x44, 0xce, 0x9c,
+	0x5b, 0x71, 0xcd, 0xd7, 0xde, 0x79, 0x35, 0x49, 0x8a, 0x95, 0xc6, 0xbb, 0x0c, 0x97, 0xad, 0xdc,
+	0x18, 0x6e, 0xe5, 0xc6, 0x11, 0xec, 0x68, 0xb5, 0xb4, 0xc2, 0x90, 0x8b, 0x82, 0xd8, 0x53, 0x88,
+	0xcb, 0x19, 0x65, 0x9d, 0x73, 0x8a, 0xa7, 0xf0, 0xce, 0x82, 0x9b, 0xab, 0x5c, 0x94, 0xe4, 0x8e,
+	0x41, 0x5c, 0x93, 0xad, 0xa0, 0xdd, 0xdb, 0x0a, 0xda, 0x23, 0xd8, 0xb9, 0xd4, 0x32, 0x9b, 0x0b,
+	0x72, 0x49, 0x10, 0x7b, 0x0a, 0xa3, 0xff, 0x5a, 0x6a, 0xf2, 0xfb, 0xd8, 0x31, 0x90, 0x74, 0xee,
+	0x5e, 0xe5, 0xbc, 0x24, 0x3f, 0x0c, 0x62, 0x5a, 0x63, 0x30, 0xc9, 0xd2, 0x0a, 0x3d, 0xe3, 0xa9,
+	0xf0, 0x8e, 0xd8, 0x00, 0x68, 0xdb, 0xcb, 0x6b, 0x72, 0xc3, 0x20, 0xee, 0x5e, 0x5e, 0x6f, 0x82,
+	0x80, 0xb5, 0x83, 0xe0, 0x53, 0x18, 0x79, 0xcb, 0x25, 0xb2, 0x32, 0xe1, 0x9d, 0x93, 0x1e, 0xba,
+	0xd3, 0x43, 0xd3, 0xca, 0xa0, 0x80, 0x78, 0x6b, 0x85, 0x2e, 0x45, 0x8e, 0x5a, 0x1d, 0x3a, 0x7f,
+	0xd7, 0xd0, 0x34, 0x63, 0xf7, 0x20, 0xb0, 0x82, 0x17, 0xc9, 0xb5, 0xb4, 0x8b, 0xf0, 0x2e, 0xb1,
+	0x87, 0x08, 0xfc, 0x5e, 0xba, 0x88, 0x2c, 0x78, 0x89, 0x6e, 0x3a, 0x22, 0x37, 0x79, 0x0a, 0xb3,
+	0xb2, 0x94, 0x69, 0x62, 0xd7, 0x95, 0x08, 0x8f, 0x9d, 0x9b, 0x4a, 0x99, 0xbe, 0x5e, 0x57, 0x64,
+	0x82, 0x5c, 0x96, 0x57, 0xc9, 0xb2, 0x0a, 0x43, 0xb7, 0x07, 0xc9, 0x37, 0x14, 0x1c, 0x85, 0x5d,
+	0x86, 0x1f, 0x51, 0xb6, 0xe2, 0xb2, 0xa9, 0x81, 0x93, 0x4d, 0x0d, 0x8c, 0xae, 0x61, 0xf4, 0xbb,
+	0xb3, 0xb3, 0x97, 0xcf, 0x54, 0x39, 0x2d, 0x67, 0x0a, 0x45, 0x16, 0xca, 0xd8, 0xba, 0x4c, 0xe2,
+	0x1a, 0xb1, 0x4a, 0x69, 0x4b, 0x71, 0x37, 0x88, 0x69, 0x8d, 0xd8, 0xd2, 0x08, 0xed, 0x43, 0x8f,
+	0xd6, 0xa8, 0x7c, 0xc5, 0x8d, 0xb9, 0xae, 0x63, 0xcf, 0x53, 0x68, 0xc9, 0x55, 0xa1, 0xc5, 0x8c,
+	0x42, 0x2f, 0x88, 0x1d, 0x11, 0xfd, 0xa7, 0x0b, 0x70, 0x46, 0x55, 0x9b, 0x2e, 0x7e, 0x08, 0x50,
+	0x2d, 0x2f, 0x73, 0x99, 0x26, 0x57, 0x62, 0x4d, 0xd7, 0x8f, 0x1e, 0xdf, 0x72, 0x75, 0xf1, 0xe2,
+	0xe2, 0xc5, 0x4b, 0xb1, 0x36, 0x71, 0xe0, 0x04, 0x5e, 0x8a, 0x35, 0xfb, 0x0c, 0x76, 0x5d, 0xc5,
+	0x37, 0x61, 0x97, 0x4a, 0xe8, 0x1d, 0x5f, 0x42, 0x09, 0x7c, 0xa6, 0x4a, 0x2b, 0x4a, 0x1b, 0xd7,
+	0x32, 0x6c, 0x02, 0x43, 0xd2, 0x45, 0xe9, 0xcc, 0x6b, 0xdc, 0xd0, 0x68, 0x3f, 0x69, 0x12, 0x59,
+	0x4a, 0x4b, 0x6a, 0x0f, 0xe3, 0x1d, 0x69, 0xa6, 0xa5, 0xb4, 0x58, 0x9a, 0x44, 0xc9, 0x2f, 0x73,
+	0x91, 0x58, 0xbb, 0xf6, 0x69, 0x13, 0x38, 0xe4, 0xb5, 0x5d, 0x63, 0xf1, 0xc9, 0xc4, 0x8c, 0x2f,
+	0x73, 0x9b, 0x68, 0xa5, 0x6c, 0x42, 0xe6, 0xd8, 0x21, 0xa9, 0x7d, 0xcf, 0x88, 0x95, 0xb2, 0x6f,
+	0xd0, 0x32, 0xbf, 0x80, 0xc9, 0xb5, 0x2c, 0x33, 0x75, 0x6d, 0x92, 0x7a, 0x0f, 0xcf, 0x0a, 0x59,
+	0xba, 0x4d, 0xbb, 0xb4, 0xe9, 0xd8, 0x4b, 0x9c, 0x39, 0x81, 0xa7, 0xc8, 0xa7, 0xcd, 0x0f, 0xe0,
+	0xb6, 0xd7, 0x23, 0xcd, 0xd5, 0x32, 0x73, 0xaa, 0x0e, 0xdd, 0x45, 0x8e, 0xf1, 0x0c, 0x71, 0xd2,
+	0xf9, 0x7b, 0x70, 0x2b, 0x57, 0x73, 0x59, 0x26, 0x3c, 0x4d, 0xb1, 0xc4, 0xf8, 0x84, 0xdc, 0x23,
+	0xf0, 0xa9, 0xc3, 0xa2, 0xbf, 0x76, 0x60, 0xd7, 0xdb, 0x14, 0x1f, 0x79, 0xc3, 0xec, 0x41, 0xdb,
+	0xce, 0xf4, 0xc8, 0x5c, 0x58, 0x91, 0xb4, 0xa4, 0x5c, 0xfd, 0xd9, 0x77, 0x8c, 0xf3, 0x46, 0xf6,
+	0x14, 0x0e, 0xdc, 0xa3, 0x5a, 0xa2, 0xce, 0xd8, 0x63, 0xc2, 0x37, 0x92, 0x0f, 0x81, 0x55, 0x5a,
+	0xfd, 0x51, 0xa4, 0xb6, 0x2d, 0xeb, 0x82, 0xe6, 0xc0, 0x73, 0x1a, 0xe9, 0xe8, 0x0d, 0xdc, 0xda,
+	0x72, 0x6b, 0x53, 0xca, 0x3b, 0xad, 0x52, 0x1e, 0xc2, 0x6e, 0xea, 0xd8, 0x5e, 0xbd, 0x9a, 0xc4,
+	0xa8, 0xe4, 0xa9, 0x95, 0xaa, 0x69, 0xe8, 0x8e, 0x8a, 0x76, 0x61, 0xf0, 0x79, 0x51, 0xd9, 0x75,
+	0xf4, 0xf7, 0x0e, 0xdc, 0x75, 0x17, 0xd0, 0xb4, 0xf0, 0xdc, 0xc4, 0xc2, 0x54, 0xaa, 0x34, 0x02,
+	0xb7, 0x66, 0xd2, 0x58, 0xad, 0x5a, 0xad, 0xd5, 0x6a, 0x45, 0xd5, 0x54, 0x68, 0x83, 0x67, 0xfa,
+	0xcb, 0x3c, 0x89, 0xaa, 0x71, 0x9d, 0x2e, 0xea, 0xb4, 0xc0, 0x35, 0x06, 0x5f, 0xce, 0xcb, 0xf9,
+	0x92, 0xcf, 0xeb, 0x8e, 0xda, 0xd0, 0x58, 0x74, 0x94, 0xf1, 0x79, 0xd1, 0x55, 0x06, 0x4f, 0xae,
+	0x3d, 0xe7, 0xab, 0xb1, 0x27, 0x31, 0x9b, 0xd1, 0x48, 0xbe, 0x1a, 0x5f, 0x89, 0x75, 0xf4, 0x8f,
+	0x0e, 0xec, 0x39, 0xbd, 0xcf, 0xb9, 0xe6, 0x85, 0xc1, 0xca, 0x42, 0xa3, 0x40, 0xcb, 0x38, 0x43,
+	0x04, 0xa8, 0xd1, 0x3e, 0x02, 0x98, 0xe3, 0xf3, 0x92, 0x4c, 0x98, 0x94, 0xd4, 0x1e, 0x3d, 0xde,
+	0x77, 0x49, 0xd3, 0x0c, 0x49, 0x71, 0x30, 0xaf, 0x97, 0xec, 0x27, 0x30, 0x72, 0xd9, 0x93, 0xc8,
+	0x72, 0xa6, 0xe8, 0x41, 0xa3, 0xc7, 0x07, 0xed, 0x2c, 0xc3, 0xb4, 0x8d, 0x21, 0x6b, 0xd6, 0xec,
+	0x11, 0x04, 0xab, 0x2c, 0xbb, 0x72, 0x1b, 0xfa, 0xb4, 0xe1, 0xb6, 0xdb, 0xd0, 0xaa, 0x30, 0xf1,
+	0x10, 0x65, 0x70, 0x15, 0x7d, 0x0d, 0xe3, 0x58, 0xe0, 0x14, 0xf1, 0xdc, 0x7c, 0xc8, 0x0b, 0x3e,
+	0x01, 0x58, 0x6c, 0x46, 0x22, 0x67, 0xf8, 0x16, 0xb2, 0x7d, 0x7d, 0xef, 0xdb, 0xaf, 0xff, 0x03,
+	0x8c, 0x9f, 0x53, 0xbf, 0xff, 0xb0, 0xeb, 0xef, 0x41, 0x30, 0x33, 0x89, 0x9f, 0x17, 0xdc, 0xed,
+	0xc3, 0x99, 0x71, 0x27, 0x34, 0x93, 0x64, 0x6f, 0x33, 0x49, 0x46, 0x0a, 0x46, 0xb1, 0xc8, 0x05,
+	0x37, 0x82, 0xac, 0xf3, 0x9d, 0x07, 0x53, 0xf4, 0x0a, 0xd8, 0x05, 0x5f, 0x89, 0xd7, 0xea, 0x8b,
+	0x9c, 0x97, 0xa9, 0xf8, 0x90, 0x47, 0x4d, 0x60, 0x98, 0xaa, 0xa2, 0xd2, 0xc2, 0x18, 0xba, 0x7d,
+	0x18, 0x37, 0x74, 0x24, 0xe0, 0xb0, 0x7d, 0x5c, 0x93, 0x15, 0xc7, 0xb0, 0xab, 0x8c, 0xb3, 0xb2,
+	0x7f, 0x89, 0x32, 0xf4, 0xc2, 0x9f, 0xc2, 0x9e, 0x76, 0x0f, 0x76, 0xdc, 0x6e, 0xdb, 0x07, 0x2d,
+	0x53, 0xc4, 0x23, 0xbd, 0x21, 0xa2, 0x27, 0x70, 0x78, 0xae, 0xd5, 0xa5, 0x98, 0xe2, 0x4c, 0x88,
+	0xc8, 0xb9, 0xe6, 0x05, 0x7f, 0xbf, 0xde, 0xd1, 0xdf, 0xba, 0x10, 0x34, 0x1b, 0xd8, 0x83, 0x6d,
+	0x8d, 0xde, 0x79, 0x67, 0xad, 0xa4, 0xd3, 0x9e, 0x1a, 0x69, 0xb7, 0xd6, 0x9e, 0xfa, 0xe8, 0x0f,
+	0x60, 0x5f, 0x9a, 0x64, 0x29, 0x66, 0x32, 0x31, 0xcb, 0x8a, 0x1a, 0x5e, 0xcf, 0xcd, 0x77, 0xd2,
+	0xbc, 0x11, 0x33, 0x79, 0xe1, 0x40, 0x2c, 0x73, 0xd2, 0x24, 0xf9, 0xaa, 0x48, 0x2a, 0xae, 0xad,
+	0xa4, 0xca, 0xe2, 0x1a, 0xc7, 0x58, 0x9a, 0x2f, 0x57, 0xc5, 0x79, 0x8d, 0xe2, 0x28, 0x20, 0x4d,
+	0xa2, 0x05, 0xcf, 0x54, 0x99, 0xd7, 0x1d, 0x04, 0xa4, 0x89, 0x3d, 0xc2, 0x7e, 0x0e, 0xc7, 0xd5,
+	0x62, 0x6d, 0x64, 0xca, 0xf3, 0xcd, 0x61, 0x4e, 0x37, 0x97, 0xfd, 0x77, 0x6b, 0x76, 0x73, 0x28,
+	0xa9, 0xfa, 0x33, 0x38, 0xa6, 0x96, 0x65, 0x2c, 0xcf, 0x73, 0x91, 0xb5, 0xfb, 0x82, 0xeb, 0x25,
+	0x87, 0xd8, 0xc2, 0x3c, 0xb7, 0x69, 0x0e, 0xd1, 0x8f, 0x60, 0xef, 0x73, 0xf3, 0x56, 0xe2, 0x67,
+	0x03, 0x99, 0xe2, 0xbd, 0x16, 0xfe, 0x1a, 0x8e, 0x9e, 0xa9, 0xb2, 0x14, 0xa9, 0xad, 0xf7, 0xd4,
+	0x59, 0xb2, 0x95, 0x67, 0x9d, 0x6f, 0xcd, 0x33, 0xf6, 0x04, 0x46, 0x3c, 0x4d, 0x85, 0x31, 0x75,
+	0x54, 0x60, 0xbf, 0x66, 0x6e, 0x47, 0x5b, 0x9f, 0x18, 0x9c, 0x18, 0x45, 0xc5, 0x33, 0x38, 0x6e,
+	0xee, 0xf5, 0x7a, 0x48, 0x77, 0x32, 0x3b, 0xad, 0x3f, 0x9e, 0x3a, 0xff, 0xf3, 0x24, 0x27, 0x10,
+	0xc5, 0x30, 0xa6, 0x20, 0xf9, 0xcd, 0x52, 0xe6, 0xd9, 0x73, 0x99, 0x8b, 0xff, 0xb3, 0x75, 0x30,
+	0xe8, 0x37, 0x1f, 0x49, 0x83, 0x98, 0xd6, 0xd1, 0x5f, 0xba, 0x70, 0xb0, 0x39, 0x34, 0x16, 0xa9,
+	0xac, 0x84, 0x9b, 0x2f, 0xd2, 0x2b, 0x3e, 0x17, 0x4e, 0xab, 0x20, 0x6e, 0x68, 0xf6, 0x00, 0x06,
+	0x33, 0x99, 0x8b, 0x7a, 0x50, 0x39, 0x74, 0xea, 0x6e, 0xeb, 0x15, 0x3b, 0x11, 0x54, 0xc5, 0xa4,
+	0x5a, 0x56, 0xd6, 0x84, 0x3d, 0x3a, 0xa6, 0x26, 0xd9, 0x0f, 0xc1, 0xf7, 0xfa, 0xc4, 0x60, 0xbd,
+	0x4b, 0x85, 0xfb, 0x12, 0x0d, 0xe2, 0xb1, 0x83, 0x2f, 0x3c, 0x8a, 0xe1, 0xa9, 0x85, 0x11, 0x36,
+	0x29, 0x78, 0xba, 0x90, 0x25, 0x8d, 0xc6, 0x2e, 0xf2, 0xc6, 0x84, 0xbf, 0x72, 0xf0, 0x34, 0x63,
+	0x3f, 0x86, 0x43, 0x2d, 0x0a, 0xb5, 0x12, 0x89, 0x31, 0x8b, 0x04, 0x27, 0x3d, 0x6c, 0xc3, 0xc6,
+	0xcf, 0x30, 0xb7, 0x1d, 0xef, 0xc2, 0x2c, 0x5e, 0x28, 0x63, 0xeb, 0x59, 0x21, 0xcd, 0x05, 0x2f,
+	0x93, 0x5c, 0xcd, 0x8d, 0x8f, 0xb4, 0x80, 0x90, 0x2f, 0xd5, 0xdc, 0x44, 0x09, 0x1c, 0xd0, 0x83,
+	0xa6, 0xee, 0xe3, 0xee, 0x43, 0x5a, 0xd2, 0x8e, 0x26, 0xfb, 0xf9, 0x4a, 0x71, 0x74, 0xd3, 0x34,
+	0xce, 0xba, 0xb1, 0x97, 0x8a, 0x0c, 0xb0, 0xcd, 0x05, 0x4d, 0x39, 0x3a, 0x80, 0x5e, 0xae, 0xe6,
+	0xf5, 0x57, 0x52, 0xae, 0xe6, 0x78, 0xa9, 0xd0, 0x5a, 0xe9, 0xa4, 0x30, 0xf3, 0xba, 0x52, 0x13,
+	0xf0, 0xca, 0xcc, 0xb1, 0x0f, 0xba, 0xef, 0xd1, 0x56, 0x9b, 0xd8, 0x6f, 0x5d, 0x4c, 0xf1, 0x13,
+	0xc8, 0x7a, 0xf9, 0xf8, 0xcf, 0x7d, 0x18, 0xb9, 0x7e, 0xf7, 0x74, 0x8e, 0x31, 0xf1, 0xeb, 0x7a,
+	0x1a, 0xf1, 0xc3, 0x02, 0x63, 0xed, 0x9e, 0xe8, 0x9e, 0x3d, 0xb9, 0xd7, 0xc6, 0x6e, 0x4e, 0x15,
+	0x9f, 0xc1, 0xb0, 0x6e, 0x7b, 0xec, 0xb0, 0x2e, 0x54, 0xed, 0x36, 0x38, 0x19, 0xf9, 0x90, 0xc6,
+	0xf1, 0x04, 0xc5, 0xeb, 0x36, 0x55, 0x8b, 0x6f, 0xb7, 0xad, 0x6d, 0xf1, 0x33, 0xd8, 0x6b, 0x57,
+	0x6d, 0x16, 0xfa, 0x19, 0xfa, 0x1b, 0x8d, 0x61, 0x32, 0xf9, 0x26, 0xa7, 0xd1, 0xf1, 0x97, 0x30,
+	0xde, 0x2e, 0xca, 0xcc, 0x4b, 0xbf, 0xab, 0x54, 0x4f, 0x6e, 0xda, 0x8f, 0xfd, 0x16, 0x0e, 0x6e,
+	0x16, 0x0f, 0xf6, 0xb1, 0x13, 0x7a, 0x77, 0x51, 0x99, 0xdc, 0xdf, 0xce, 0xe2, 0x9b, 0x39, 0xff,
+	0x14, 0xee, 0x9c, 0x49, 0x93, 0xde, 0x3c, 0xf3, 0xfd, 0xbb, 0xb6, 0x0d, 0xf3, 0x2b, 0x80, 0x4d,
+	0xf4, 0x30, 0x1f, 0x6b, 0x37, 0x03, 0x76, 0x12, 0xde, 0xc4, 0x6b, 0x93, 0x5c, 0xee, 0xd0, 0x7f,
+	0x4b, 0x4f, 0xfe, 0x3b, 0x00, 0xd6, 0x36, 0x2a, 0x24, 0x6b, 0x12, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1680,6 +1943,7 @@ type DeployAgentClient interface {
 	ProbeImageInfo(ctx context.Context, in *ProbeImageInfoPramas, opts ...grpc.CallOption) (*ImageInfo, error)
 	ConnectEsxiDisks(ctx context.Context, in *ConnectEsxiDisksParams, opts ...grpc.CallOption) (*EsxiDisksConnectionInfo, error)
 	DisconnectEsxiDisks(ctx context.Context, in *EsxiDisksConnectionInfo, opts ...grpc.CallOption) (*Empty, error)
+	BuildImage(ctx context.Context, in *BuildImageParams, opts ...grpc.CallOption) (*BuildImageResponse, error)
 }
 
 type deployAgentClient struct {
@@ -1753,6 +2017,15 @@ func (c *deployAgentClient) DisconnectEsxiDisks(ctx context.Context, in *EsxiDis
 	return out, nil
 }
 
+func (c *deployAgentClient) BuildImage(ctx context.Context, in *BuildImageParams, opts ...grpc.CallOption) (*BuildImageResponse, error) {
+	out := new(BuildImageResponse)
+	err := c.cc.Invoke(ctx, "/apis.DeployAgent/BuildImage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DeployAgentServer is the server API for DeployAgent service.
 type DeployAgentServer interface {
 	DeployGuestFs(context.Context, *DeployParams) (*DeployGuestFsResponse, error)
@@ -1762,6 +2035,7 @@ type DeployAgentServer interface {
 	ProbeImageInfo(context.Context, *ProbeImageInfoPramas) (*ImageInfo, error)
 	ConnectEsxiDisks(context.Context, *ConnectEsxiDisksParams) (*EsxiDisksConnectionInfo, error)
 	DisconnectEsxiDisks(context.Context, *EsxiDisksConnectionInfo) (*Empty, error)
+	BuildImage(context.Context, *BuildImageParams) (*BuildImageResponse, error)
 }
 
 // UnimplementedDeployAgentServer can be embedded to have forward compatible implementations.
@@ -1789,6 +2063,9 @@ func (*UnimplementedDeployAgentServer) ConnectEsxiDisks(ctx context.Context, req
 func (*UnimplementedDeployAgentServer) DisconnectEsxiDisks(ctx context.Context, req *EsxiDisksConnectionInfo) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DisconnectEsxiDisks not implemented")
 }
+func (*UnimplementedDeployAgentServer) BuildImage(ctx context.Context, req *BuildImageParams) (*BuildImageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BuildImage not implemented")
+}
 
 func RegisterDeployAgentServer(s *grpc.Server, srv DeployAgentServer) {
 	s.RegisterService(&_DeployAgent_serviceDesc, srv)
@@ -1920,6 +2197,24 @@ func _DeployAgent_DisconnectEsxiDisks_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DeployAgent_BuildImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuildImageParams)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeployAgentServer).BuildImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apis.DeployAgent/BuildImage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeployAgentServer).BuildImage(ctx, req.(*BuildImageParams))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _DeployAgent_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "apis.DeployAgent",
 	HandlerType: (*DeployAgentServer)(nil),
@@ -1952,6 +2247,10 @@ var _DeployAgent_serviceDesc = grpc.ServiceDesc{
 			MethodName: "DisconnectEsxiDisks",
 			Handler:    _DeployAgent_DisconnectEsxiDisks_Handler,
 		},
+		{
+			MethodName: "BuildImage",
+			Handler:    _DeployAgent_BuildImage_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "deploy.proto",
@@ -16,9 +16,13 @@ package apis
 
 import (
 	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
 
 	"yunion.io/x/onecloud/pkg/cloudcommon/types"
 )
@@ -64,6 +68,48 @@ func JsonDeploysToStructs(jdeploys []jsonutils.JSONObject) []*DeployContent {
 	return ret
 }
 
+const (
+	MaxImageBuildScripts    = 16
+	MaxImageBuildScriptSize = 64 * 1024
+)
+
+var imageBuildNameRegexp = regexp.MustCompile(`^[A-Za-z0-9@][A-Za-z0-9._+:@=~-]*$`)
+
+// Validate makes sure package and service names can be passed to the shell inside the image
+// and scripts are non-empty and bounded in size
+func (recipe *ImageBuildRecipe) Validate() error {
+	if len(recipe.Scripts) > MaxImageBuildScripts {
+		return errors.Wrapf(errors.ErrNotSupported, "too many scripts %d > %d", len(recipe.Scripts), MaxImageBuildScripts)
+	}
+	for i, script := range recipe.Scripts {
+		if len(strings.TrimSpace(script)) == 0 {
+			return errors.Wrapf(errors.ErrNotSupported, "script %d is empty", i)
+		}
+		if len(script) > MaxImageBuildScriptSize {
+			return errors.Wrapf(errors.ErrNotSupported, "script %d exceeds %d bytes", i, MaxImageBuildScriptSize)
+		}
+	}
+	for _, pkg := range recipe.Packages {
+		if !imageBuildNameRegexp.MatchString(pkg) {
+			return errors.Wrapf(errors.ErrNotSupported, "invalid package name %q", pkg)
+		}
+	}
+	for _, svc := range recipe.EnableServices {
+		if !imageBuildNameRegexp.MatchString(svc) {
+			return errors.Wrapf(errors.ErrNotSupported, "invalid service name %q", svc)
+		}
+	}
+	for _, f := range recipe.Files {
+		if !path.IsAbs(f.Path) || path.Clean(f.Path) != f.Path {
+			return errors.Wrapf(errors.ErrNotSupported, "invalid file path %q", f.Path)
+		}
+		if f.Mode < 0 || f.Mode > 07777 {
+			return errors.Wrapf(errors.ErrNotSupported, "invalid mode %o of file %s", f.Mode, f.Path)
+		}
+	}
+	return nil
+}
+
 func GetKeys(data jsonutils.JSONObject) *SSHKeys {
 	var ret = new(SSHKeys)
 	ret.PublicKey, _ = data.GetString("public_key")
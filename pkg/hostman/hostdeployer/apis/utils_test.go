@@ -0,0 +1,65 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImageBuildRecipeValidate(t *testing.T) {
+	tooManyScripts := make([]string, MaxImageBuildScripts+1)
+	for i := range tooManyScripts {
+		tooManyScripts[i] = "true"
+	}
+	cases := []struct {
+		name   string
+		recipe ImageBuildRecipe
+		valid  bool
+	}{
+		{
+			name: "valid",
+			recipe: ImageBuildRecipe{
+				Packages:       []string{"nginx", "python3-pip", "libstdc++", "kernel-devel-5.4.0", "pkg=1.0~rc1"},
+				Scripts:        []string{"echo hello > /etc/hello\n"},
+				EnableServices: []string{"nginx", "getty@tty1"},
+				Files:          []*ImageBuildFile{{Path: "/etc/motd", Content: "hi", Mode: 0644}},
+			},
+			valid: true,
+		},
+		{name: "empty", recipe: ImageBuildRecipe{}, valid: true},
+		{name: "empty script", recipe: ImageBuildRecipe{Scripts: []string{""}}},
+		{name: "blank script", recipe: ImageBuildRecipe{Scripts: []string{" \n\t"}}},
+		{name: "oversized script", recipe: ImageBuildRecipe{Scripts: []string{strings.Repeat("#", MaxImageBuildScriptSize+1)}}},
+		{name: "too many scripts", recipe: ImageBuildRecipe{Scripts: tooManyScripts}},
+		{name: "package with shell", recipe: ImageBuildRecipe{Packages: []string{"nginx; rm -rf /"}}},
+		{name: "package with substitution", recipe: ImageBuildRecipe{Packages: []string{"$(reboot)"}}},
+		{name: "package as option", recipe: ImageBuildRecipe{Packages: []string{"--nogpgcheck"}}},
+		{name: "package with space", recipe: ImageBuildRecipe{Packages: []string{"nginx vim"}}},
+		{name: "empty package", recipe: ImageBuildRecipe{Packages: []string{""}}},
+		{name: "service with shell", recipe: ImageBuildRecipe{EnableServices: []string{"nginx && reboot"}}},
+		{name: "relative file", recipe: ImageBuildRecipe{Files: []*ImageBuildFile{{Path: "etc/motd"}}}},
+		{name: "unclean file", recipe: ImageBuildRecipe{Files: []*ImageBuildFile{{Path: "/etc/../root/.ssh/authorized_keys"}}}},
+		{name: "bad mode", recipe: ImageBuildRecipe{Files: []*ImageBuildFile{{Path: "/etc/motd", Mode: 010000}}}},
+	}
+	for _, c := range cases {
+		err := c.recipe.Validate()
+		if c.valid && err != nil {
+			t.Errorf("%s: %s", c.name, err)
+		} else if !c.valid && err == nil {
+			t.Errorf("%s: want error", c.name)
+		}
+	}
+}
@@ -127,3 +127,13 @@ func (c *DeployClient) DisconnectEsxiDisks(
 	client := deployapi.NewDeployAgentClient(conn)
 	return client.DisconnectEsxiDisks(ctx, in, opts...)
 }
+
+func (c *DeployClient) BuildImage(ctx context.Context, in *deployapi.BuildImageParams, opts ...grpc.CallOption) (*deployapi.BuildImageResponse, error) {
+	conn, err := grcpDialWithUnixSocket(ctx, c.socketPath)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	client := deployapi.NewDeployAgentClient(conn)
+	return client.BuildImage(ctx, in, opts...)
+}
@@ -220,6 +220,61 @@ func (s *DeployerServer) ProbeImageInfo(ctx context.Context, req *deployapi.Prob
 	return s.getImageInfo(kvmDisk)
 }
 
+func (s *DeployerServer) BuildImage(ctx context.Context, req *deployapi.BuildImageParams) (*deployapi.BuildImageResponse, error) {
+	log.Infof("********* %s build image", req.DiskPath)
+	if req.Recipe == nil {
+		return new(deployapi.BuildImageResponse), errors.Error("missing image build recipe")
+	}
+	builder, err := guestfs.NewImageBuilder(req.Recipe)
+	if err != nil {
+		return new(deployapi.BuildImageResponse), err
+	}
+
+	// build failures are reported in the response to keep the build log
+	ret := new(deployapi.BuildImageResponse)
+	err = buildImageOnRootfs(req.DiskPath, builder.InjectFiles)
+	if err == nil {
+		// the appliance attaches the disk by itself, so it is disconnected from the host meanwhile
+		err = builder.RunCommands(req.DiskPath)
+	}
+	if err == nil {
+		err = buildImageOnRootfs(req.DiskPath, builder.Cleanup)
+	}
+	ret.Log = builder.Finish(err)
+	if err != nil {
+		ret.ErrorMsg = err.Error()
+		return ret, nil
+	}
+
+	kvmDisk := diskutils.NewKVMGuestDisk(req.DiskPath, DeployOption.ImageDeployDriver)
+	defer kvmDisk.Disconnect()
+	if err := kvmDisk.Connect(); err != nil {
+		log.Infof("Failed to connect kvm disk %s: %s", req.DiskPath, err)
+		return ret, errors.Error("Disk connector failed to connect image")
+	}
+	imageInfo, err := s.getImageInfo(kvmDisk)
+	if err != nil {
+		return ret, err
+	}
+	ret.ImageInfo = imageInfo
+	return ret, nil
+}
+
+// buildImageOnRootfs connects the disk and runs a build step on its mounted root filesystem
+func buildImageOnRootfs(diskPath string, step func(fsdriver.IRootFsDriver) error) error {
+	kvmDisk := diskutils.NewKVMGuestDisk(diskPath, DeployOption.ImageDeployDriver)
+	defer kvmDisk.Disconnect()
+	if err := kvmDisk.Connect(); err != nil {
+		return errors.Wrap(err, "Disk connector failed to connect image")
+	}
+	rootfs := kvmDisk.MountKvmRootfs()
+	if rootfs == nil {
+		return errors.Error("Failed mounting rootfs for kvm disk")
+	}
+	defer kvmDisk.UmountKvmRootfs(rootfs)
+	return step(rootfs)
+}
+
 var connectedEsxiDisks = map[string]*diskutils.VDDKDisk{}
 
 func (*DeployerServer) ConnectEsxiDisks(
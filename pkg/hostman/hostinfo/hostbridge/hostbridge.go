@@ -360,6 +360,9 @@ func (d *SBaseBridgeDriver) DisableDHCPClient() (bool, error) {
 
 func NewDriver(bridgeDriver, bridge, inter, ip string) (IBridgeDriver, error) {
 	if bridgeDriver == DRV_OPEN_VSWITCH {
+		if options.HostOptions.EnableOvsDpdk {
+			return NewOVSDpdkBridgeDriver(bridge, inter, ip)
+		}
 		return NewOVSBridgeDriver(bridge, inter, ip)
 	} else if bridgeDriver == DRV_LINUX_BRIDGE {
 		return NewLinuxBridgeDeriver(bridge, inter, ip)
@@ -369,7 +372,13 @@ func NewDriver(bridgeDriver, bridge, inter, ip string) (IBridgeDriver, error) {
 
 func Prepare(bridgeDriver string) error {
 	if bridgeDriver == DRV_OPEN_VSWITCH {
-		return OVSPrepare()
+		if err := OVSPrepare(); err != nil {
+			return err
+		}
+		if options.HostOptions.EnableOvsDpdk {
+			return OVSDpdkPrepare()
+		}
+		return nil
 	} else if bridgeDriver == DRV_LINUX_BRIDGE {
 		return LinuxBridgePrepare()
 	}
@@ -0,0 +1,250 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostbridge
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/hostman/system_service"
+	"yunion.io/x/onecloud/pkg/util/bwutils"
+	"yunion.io/x/onecloud/pkg/util/netutils2"
+	"yunion.io/x/onecloud/pkg/util/ovsutils"
+)
+
+// SOVSDpdkBridgeDriver use userspace netdev datapath of OVS-DPDK,
+// guest nics with driver vhost-user are attached as dpdkvhostuserclient ports
+type SOVSDpdkBridgeDriver struct {
+	SOVSBridgeDriver
+
+	// physical port bound to userspace driver, which is invisible to kernel
+	dpdkPort string
+}
+
+func GetVhostUserSocketPath(ifname string) string {
+	return path.Join(options.HostOptions.OvsVhostUserSocketDir, ifname+".sock")
+}
+
+func getDpdkDevargs(inter string) (string, error) {
+	for _, conf := range options.HostOptions.OvsDpdkDevargs {
+		segs := strings.SplitN(conf, "/", 2)
+		if len(segs) == 2 && segs[0] == inter {
+			return segs[1], nil
+		}
+	}
+	// ../../../0000:3b:00.0
+	target, err := os.Readlink(path.Join("/sys/class/net", inter, "device"))
+	if err != nil {
+		return "", errors.Wrapf(err, "find pci address of %s", inter)
+	}
+	return path.Base(target), nil
+}
+
+func (o *SOVSDpdkBridgeDriver) FetchConfig() {
+	o.bridge.FetchConfig()
+	if o.inter != nil {
+		o.inter.FetchConfig()
+	}
+}
+
+func (o *SOVSDpdkBridgeDriver) SetupBridgeDev() error {
+	return ovsutils.AddDpdkBridge(o.bridge.String())
+}
+
+func (o *SOVSDpdkBridgeDriver) SetupInterface() error {
+	if o.inter == nil {
+		return nil
+	}
+	devargs, err := getDpdkDevargs(o.inter.String())
+	if err != nil {
+		return err
+	}
+	return ovsutils.AddDpdkPort(o.bridge.String(), o.inter.String(), devargs)
+}
+
+func (o *SOVSDpdkBridgeDriver) Setup(d IBridgeDriver) error {
+	if err := o.SBaseBridgeDriver.Setup(d); err != nil {
+		return err
+	}
+	if len(o.dpdkPort) > 0 {
+		devargs, err := getDpdkDevargs(o.dpdkPort)
+		if err != nil {
+			// already added to bridge before bound to userspace driver
+			return nil
+		}
+		return ovsutils.AddDpdkPort(o.bridge.String(), o.dpdkPort, devargs)
+	}
+	return nil
+}
+
+func (o *SOVSDpdkBridgeDriver) PersistentMac() error {
+	if o.inter == nil || len(o.inter.Mac) == 0 {
+		return nil
+	}
+	return o.SOVSBridgeDriver.PersistentMac()
+}
+
+func (o *SOVSDpdkBridgeDriver) GenerateIfdownScripts(scriptPath string, nic jsonutils.JSONObject) error {
+	return o.generateIfdownScripts(o, scriptPath, nic)
+}
+
+func (o *SOVSDpdkBridgeDriver) GenerateIfupScripts(scriptPath string, nic jsonutils.JSONObject) error {
+	return o.generateIfupScripts(o, scriptPath, nic)
+}
+
+func isVhostUserNic(nic jsonutils.JSONObject) bool {
+	driver, _ := nic.GetString("driver")
+	return driver == compute.NETWORK_DRIVER_VHOST_USER
+}
+
+func (o *SOVSDpdkBridgeDriver) getUpScripts(nic jsonutils.JSONObject) (string, error) {
+	if !isVhostUserNic(nic) {
+		return o.SOVSBridgeDriver.getUpScripts(nic)
+	}
+	var (
+		bridge, _      = nic.GetString("bridge")
+		ifname, _      = nic.GetString("ifname")
+		netId, _       = nic.GetString("net_id")
+		vlan, _        = nic.Int("vlan")
+		vpcProvider, _ = nic.GetString("vpc", "provider")
+	)
+
+	if vpcProvider == compute.VPC_PROVIDER_OVN {
+		bridge = options.HostOptions.OvnIntegrationBridge
+	}
+
+	s := "#!/bin/bash\n\n"
+	s += fmt.Sprintf("SWITCH='%s'\n", bridge)
+	s += fmt.Sprintf("IF='%s'\n", ifname)
+	s += fmt.Sprintf("SOCK='%s'\n", GetVhostUserSocketPath(ifname))
+	s += fmt.Sprintf("VLAN_ID=%d\n", vlan)
+	s += fmt.Sprintf("NET_ID=%s\n", netId)
+	limit, burst, err := bwutils.GetOvsBwValues(nic)
+	if err != nil {
+		return "", err
+	}
+	s += fmt.Sprintf("LIMIT=%d\n", limit)
+	s += fmt.Sprintf("BURST=%d\n", burst)
+	bwDownload, err := bwutils.GetDownloadBwValue(nic, options.HostOptions.BwDownloadBandwidth)
+	if err != nil {
+		return "", err
+	}
+	// egress-policer cir is in bytes per second
+	s += fmt.Sprintf("CIR=%d\n", bwDownload*1000*1000/8)
+	s += "mkdir -p $(dirname $SOCK)\n"
+	s += "ovs-vsctl -- --if-exists del-port $SWITCH $IF\n"
+	s += "if [ \"$VLAN_ID\" -ne \"1\" ]; then\n"
+	s += "    TAG=\"tag=$VLAN_ID\"\n"
+	s += "fi\n"
+	s += "ovs-vsctl add-port $SWITCH $IF $TAG -- set Interface $IF " +
+		"type=" + ovsutils.IFACE_TYPE_DPDK_VHOST_USER_CLIENT + " options:vhost-server-path=$SOCK\n"
+	if vpcProvider == compute.VPC_PROVIDER_OVN {
+		s += "ovs-vsctl set Interface $IF external_ids:iface-id=iface-$NET_ID-$IF\n"
+	}
+	s += "OFCTL=$(ovs-vsctl get-controller $SWITCH)\n"
+	s += "if [ -z \"$OFCTL\" ]; then\n"
+	s += "    ovs-vsctl set Interface $IF ingress_policing_rate=$LIMIT\n"
+	s += "    ovs-vsctl set Interface $IF ingress_policing_burst=$BURST\n"
+	s += "fi\n"
+	s += "if [ \"$CIR\" -gt \"0\" ]; then\n"
+	s += "    ovs-vsctl set Port $IF qos=@q -- --id=@q create QoS type=egress-policer " +
+		"other-config:cir=$CIR other-config:cbs=$((CIR/10))\n"
+	s += "fi\n"
+	return s, nil
+}
+
+func (o *SOVSDpdkBridgeDriver) getDownScripts(nic jsonutils.JSONObject) (string, error) {
+	if !isVhostUserNic(nic) {
+		return o.SOVSBridgeDriver.getDownScripts(nic)
+	}
+	var (
+		bridge, _ = nic.GetString("bridge")
+		ifname, _ = nic.GetString("ifname")
+	)
+
+	s := "#!/bin/bash\n\n"
+	s += fmt.Sprintf("SWITCH='%s'\n", bridge)
+	s += fmt.Sprintf("IF='%s'\n", ifname)
+	s += "QOS=$(ovs-vsctl --if-exists get Port $IF qos)\n"
+	s += "ovs-vsctl -- --if-exists del-port $SWITCH $IF\n"
+	s += "if [ -n \"$QOS\" ] && [ \"$QOS\" != \"[]\" ]; then\n"
+	s += "    ovs-vsctl destroy QoS $QOS\n"
+	s += "fi\n"
+	return s, nil
+}
+
+func (o *SOVSDpdkBridgeDriver) WarmupConfig() error {
+	o.ovsSetParams(map[string]map[string]string{
+		"bridge": {
+			"datapath_type": ovsutils.DATAPATH_TYPE_NETDEV,
+		},
+	})
+	return o.SOVSBridgeDriver.WarmupConfig()
+}
+
+func OVSDpdkPrepare() error {
+	opts := &options.HostOptions
+	err := ovsutils.SetupDpdk(ovsutils.SDpdkConfig{
+		SocketMem:  opts.OvsDpdkSocketMem,
+		LcoreMask:  opts.OvsDpdkLcoreMask,
+		PmdCpuMask: opts.OvsDpdkPmdCpuMask,
+	})
+	if err != nil {
+		return err
+	}
+	if ovsutils.IsDpdkInitialized() {
+		return nil
+	}
+	log.Infof("Restart openvswitch to initialize dpdk")
+	ovs := system_service.GetService("openvswitch")
+	if err := ovs.Stop(false); err != nil {
+		return errors.Wrap(err, "stop openvswitch")
+	}
+	if err := ovs.Start(false); err != nil {
+		return errors.Wrap(err, "start openvswitch")
+	}
+	if !ovsutils.IsDpdkInitialized() {
+		return fmt.Errorf("dpdk of openvswitch not initialized, check hugepages and ovs-vswitchd log")
+	}
+	return nil
+}
+
+func NewOVSDpdkBridgeDriver(bridge, inter, ip string) (*SOVSDpdkBridgeDriver, error) {
+	dpdkPort := ""
+	if len(inter) > 0 && !netutils2.NewNetInterface(inter).Exist() {
+		if len(ip) > 0 {
+			return nil, fmt.Errorf("%s not exists, physical port bound to userspace driver can't carry host ip", inter)
+		}
+		if _, err := getDpdkDevargs(inter); err != nil && ovsutils.GetInterfaceType(inter) != ovsutils.IFACE_TYPE_DPDK {
+			return nil, fmt.Errorf("%s not exists", inter)
+		}
+		dpdkPort, inter = inter, ""
+	}
+	base, err := NewBaseBridgeDriver(bridge, inter, ip)
+	if err != nil {
+		return nil, err
+	}
+	drv := &SOVSDpdkBridgeDriver{SOVSBridgeDriver: SOVSBridgeDriver{*base}, dpdkPort: dpdkPort}
+	drv.drv = drv
+	return drv, nil
+}
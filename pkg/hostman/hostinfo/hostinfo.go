@@ -16,6 +16,7 @@ package hostinfo
 
 import (
 	"context"
+	"crypto/rsa"
 	"fmt"
 	"net"
 	"os"
@@ -52,8 +53,10 @@ import (
 	"yunion.io/x/onecloud/pkg/util/fileutils2"
 	"yunion.io/x/onecloud/pkg/util/k8s/tokens"
 	"yunion.io/x/onecloud/pkg/util/netutils2"
+	"yunion.io/x/onecloud/pkg/util/ovsutils"
 	"yunion.io/x/onecloud/pkg/util/procutils"
 	"yunion.io/x/onecloud/pkg/util/qemutils"
+	"yunion.io/x/onecloud/pkg/util/seclib2"
 	"yunion.io/x/onecloud/pkg/util/sysutils"
 	"yunion.io/x/onecloud/pkg/util/timeutils2"
 )
@@ -92,6 +95,9 @@ type SHostInfo struct {
 	FullName   string
 	SysError   map[string]string
 	SysWarning map[string]string
+
+	// signs instance identity documents served by metadata service
+	identityKey *rsa.PrivateKey
 }
 
 func (h *SHostInfo) GetIsolatedDeviceManager() *isolated_device.IsolatedDeviceManager {
@@ -129,6 +135,10 @@ func (h *SHostInfo) GetZoneName() string {
 	return h.Zone
 }
 
+func (h *SHostInfo) GetIdentityKey() *rsa.PrivateKey {
+	return h.identityKey
+}
+
 func (h *SHostInfo) GetMediumType() string {
 	if h.sysinfo != nil {
 		return h.sysinfo.StorageType
@@ -368,6 +378,9 @@ func (h *SHostInfo) prepareEnv() error {
 		h.DisableKsm()
 	}
 
+	if options.HostOptions.EnableOvsDpdk && options.HostOptions.HugepagesOption != "native" {
+		return fmt.Errorf("OVS-DPDK datapath requires native hugepages")
+	}
 	switch options.HostOptions.HugepagesOption {
 	case "disable":
 		h.DisableHugepages()
@@ -769,6 +782,15 @@ func (h *SHostInfo) getMasterMacWithRefresh(refresh bool) string {
 	return ""
 }
 
+func (h *SHostInfo) getWireIdByIface(iface string) string {
+	for _, nic := range h.Nics {
+		if nic.Inter == iface {
+			return nic.WireId
+		}
+	}
+	return ""
+}
+
 func (h *SHostInfo) GetMatchNic(bridge, iface, mac string) *SNIC {
 	for _, nic := range h.Nics {
 		if nic.BridgeDev.GetMac() == mac ||
@@ -1022,6 +1044,7 @@ func (h *SHostInfo) updateHostRecord(hostId string) {
 	content.Set("__meta__", jsonutils.Marshal(h.getSysInfo()))
 	content.Set("version", jsonutils.NewString(version.GetShortString()))
 	content.Set("ovn_version", jsonutils.NewString(MustGetOvnVersion()))
+	content.Set("ovs_dpdk_version", jsonutils.NewString(h.getOvsDpdkVersion()))
 
 	var (
 		res jsonutils.JSONObject
@@ -1038,6 +1061,13 @@ func (h *SHostInfo) updateHostRecord(hostId string) {
 	}
 }
 
+func (h *SHostInfo) getOvsDpdkVersion() string {
+	if !options.HostOptions.EnableOvsDpdk || options.HostOptions.BridgeDriver != hostbridge.DRV_OPEN_VSWITCH {
+		return ""
+	}
+	return ovsutils.GetDpdkVersion()
+}
+
 func (h *SHostInfo) updateHostMetadata(hostname string) error {
 	onK8s, _ := tokens.IsInsideKubernetesCluster()
 	meta := api.HostRegisterMetadata{
@@ -1052,8 +1082,20 @@ func (h *SHostInfo) updateHostMetadata(hostname string) error {
 	}
 	meta.RootPartitionTotalCapacityMB = int64(storageman.GetRootPartTotalCapacity())
 	meta.RootPartitionUsedCapacityMB = int64(storageman.GetRootPartUsedCapacity())
+	if h.identityKey == nil {
+		key, err := seclib2.LoadOrCreateRSAKey(options.HostOptions.MetadataIdentityKeyPath)
+		if err != nil {
+			return errors.Wrap(err, "load metadata identity key")
+		}
+		h.identityKey = key
+	}
+	pubKey, err := seclib2.RSAPublicKeyPEM(h.identityKey)
+	if err != nil {
+		return errors.Wrap(err, "export metadata identity public key")
+	}
+	meta.IdentityPublicKey = pubKey
 	data := meta.JSON(meta)
-	_, err := modules.Hosts.SetMetadata(h.GetSession(), h.HostId, data)
+	_, err = modules.Hosts.SetMetadata(h.GetSession(), h.HostId, data)
 	return err
 }
 
@@ -1472,6 +1514,14 @@ func (h *SHostInfo) onGetIsolatedDeviceSucc(objs []jsonutils.JSONObject) {
 
 func (h *SHostInfo) uploadIsolatedDevices() {
 	for _, dev := range h.IsolatedDeviceMan.Devices {
+		if vf, ok := dev.(isolated_device.ISRIOVNicDevice); ok {
+			wireId := h.getWireIdByIface(vf.GetPfName())
+			if len(wireId) == 0 {
+				log.Warningf("SR-IOV nic %s not in host networks, skip VF %s", vf.GetPfName(), vf.GetAddr())
+				continue
+			}
+			vf.SetWireId(wireId)
+		}
 		if err := dev.SyncDeviceInfo(h.GetSession(), h.HostId); err != nil {
 			h.onFail(fmt.Sprintf("Sync device %s: %v", dev.String(), err))
 		}
@@ -68,6 +68,10 @@ func (oh *OvnHelper) mustPrepOvsdbConfig() {
 		}
 		args = append(args, fmt.Sprintf("external_ids:ovn-bridge=%s",
 			opts.OvnIntegrationBridge))
+		if opts.EnableOvsDpdk {
+			args = append(args, fmt.Sprintf("external_ids:ovn-bridge-datapath-type=%s",
+				ovsutils.DATAPATH_TYPE_NETDEV))
+		}
 	}
 	{
 		encapIp := opts.OvnEncapIp
@@ -102,8 +102,14 @@ func NewManager(host IHost) (*IsolatedDeviceManager, error) {
 		Devices:         make([]IDevice, 0),
 		DetachedDevices: make([]*CloudDeviceInfo, 0),
 	}
-	err := man.fillPCIDevices()
-	return man, err
+	if err := man.fillPCIDevices(); err != nil {
+		return man, err
+	}
+	if err := man.fillSRIOVNicDevices(); err != nil {
+		// ignore SR-IOV detect error, host can still work without VFs
+		log.Errorf("fillSRIOVNicDevices: %v", err)
+	}
+	return man, nil
 }
 
 func (man *IsolatedDeviceManager) fillPCIDevices() error {
@@ -121,6 +127,27 @@ func (man *IsolatedDeviceManager) fillPCIDevices() error {
 	return nil
 }
 
+func (man *IsolatedDeviceManager) fillSRIOVNicDevices() error {
+	vfs, err := detectSRIOVNicDevices()
+	if err != nil {
+		return fmt.Errorf("detectSRIOVNicDevices: %v", err)
+	}
+	for _, vf := range vfs {
+		man.Devices = append(man.Devices, vf)
+		log.Infof("Add SR-IOV VF device: %s vf %d => %s", vf.GetPfName(), vf.GetVfIndex(), vf.GetAddr())
+	}
+	return nil
+}
+
+func (man *IsolatedDeviceManager) GetSRIOVNicDeviceByAddr(addr string) ISRIOVNicDevice {
+	dev := man.GetDeviceByAddr(addr)
+	if dev == nil {
+		return nil
+	}
+	vf, _ := dev.(ISRIOVNicDevice)
+	return vf
+}
+
 func (man *IsolatedDeviceManager) getSession() *mcclient.ClientSession {
 	return man.host.GetSession()
 }
@@ -813,6 +840,7 @@ func getQemuParams(man *IsolatedDeviceManager, devAddrs []string) *QemuParams {
 	devCmds := []string{}
 	cpuCmd := DEFAULT_CPU_CMD
 	vgaCmd := DEFAULT_VGA_CMD
+	onlyVfs := true
 	for idx, addr := range devAddrs {
 		dev := man.GetDeviceByAddr(addr)
 		if dev == nil {
@@ -820,6 +848,11 @@ func getQemuParams(man *IsolatedDeviceManager, devAddrs []string) *QemuParams {
 			continue
 		}
 		devCmds = append(devCmds, GetDeviceCmd(dev, idx))
+		if dev.GetDeviceType() == api.SRIOV_VF_TYPE {
+			// VF should not change cpu and vga of guest
+			continue
+		}
+		onlyVfs = false
 		if dev.GetVGACmd() != vgaCmd && dev.GetDeviceType() == api.GPU_VGA_TYPE {
 			vgaCmd = dev.GetVGACmd()
 		}
@@ -827,6 +860,9 @@ func getQemuParams(man *IsolatedDeviceManager, devAddrs []string) *QemuParams {
 			cpuCmd = dev.GetCPUCmd()
 		}
 	}
+	if onlyVfs {
+		cpuCmd, vgaCmd = "", ""
+	}
 	return &QemuParams{
 		Cpu:     cpuCmd,
 		Vga:     vgaCmd,
@@ -73,3 +73,33 @@ func Test_parseLspci(t *testing.T) {
 		})
 	}
 }
+
+func Test_sSRIOVNicDevice_GetVfSetupCmd(t *testing.T) {
+	vf := NewSRIOVNicDevice(&PCIDevice{Addr: "3b:02.1"}, "eth1", 3)
+	tests := []struct {
+		name string
+		mac  string
+		vlan int
+		want string
+	}{
+		{
+			name: "untagged",
+			mac:  "00:22:33:44:55:66",
+			vlan: 1,
+			want: "ip link set dev eth1 vf 3 mac 00:22:33:44:55:66 vlan 0 spoofchk on\n",
+		},
+		{
+			name: "tagged",
+			mac:  "00:22:33:44:55:66",
+			vlan: 100,
+			want: "ip link set dev eth1 vf 3 mac 00:22:33:44:55:66 vlan 100 spoofchk on\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := vf.GetVfSetupCmd(tt.mac, tt.vlan); got != tt.want {
+				t.Errorf("GetVfSetupCmd() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
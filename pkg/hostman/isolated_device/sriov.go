@@ -0,0 +1,231 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isolated_device
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+const (
+	SYS_CLASS_NET = "/sys/class/net"
+)
+
+type ISRIOVNicDevice interface {
+	IDevice
+
+	GetPfName() string
+	GetVfIndex() int
+	SetWireId(wireId string)
+	// GetVfSetupCmd return shell command to program mac and vlan on VF
+	GetVfSetupCmd(mac string, vlan int) string
+}
+
+// sSRIOVNicDevice is a virtual function of SR-IOV capable nic
+type sSRIOVNicDevice struct {
+	*sBaseDevice
+
+	pfName  string
+	vfIndex int
+	wireId  string
+}
+
+func NewSRIOVNicDevice(dev *PCIDevice, pfName string, vfIndex int) *sSRIOVNicDevice {
+	vf := &sSRIOVNicDevice{
+		sBaseDevice: newBaseDevice(dev),
+		pfName:      pfName,
+		vfIndex:     vfIndex,
+	}
+	vf.devType = api.SRIOV_VF_TYPE
+	return vf
+}
+
+func (vf *sSRIOVNicDevice) GetPfName() string {
+	return vf.pfName
+}
+
+func (vf *sSRIOVNicDevice) GetVfIndex() int {
+	return vf.vfIndex
+}
+
+func (vf *sSRIOVNicDevice) GetDeviceType() string {
+	return api.SRIOV_VF_TYPE
+}
+
+func (vf *sSRIOVNicDevice) GetCPUCmd() string {
+	return ""
+}
+
+func (vf *sSRIOVNicDevice) GetVGACmd() string {
+	return ""
+}
+
+func (vf *sSRIOVNicDevice) GetVfSetupCmd(mac string, vlan int) string {
+	// vlan 1 means untagged
+	if vlan <= 1 {
+		vlan = 0
+	}
+	return fmt.Sprintf("ip link set dev %s vf %d mac %s vlan %d spoofchk on\n", vf.pfName, vf.vfIndex, mac, vlan)
+}
+
+func (vf *sSRIOVNicDevice) CustomProbe() error {
+	for _, driver := range []string{"vfio", "vfio_iommu_type1", "vfio-pci"} {
+		if err := procutils.NewRemoteCommandAsFarAsPossible("modprobe", driver).Run(); err != nil {
+			return fmt.Errorf("modprobe %s: %v", driver, err)
+		}
+	}
+	if vf.dev.IsVFIOPCIDriverUsed() {
+		return nil
+	}
+	if err := vf.bindVFIOPCIDriver(); err != nil {
+		return fmt.Errorf("bind VF %s of %s to vfio-pci: %v", vf.GetAddr(), vf.pfName, err)
+	}
+	return nil
+}
+
+// bindVFIOPCIDriver use driver_override instead of new_id,
+// because all VFs of the same nic share one vendor device id
+func (vf *sSRIOVNicDevice) bindVFIOPCIDriver() error {
+	if err := vf.dev.unbindDriver(); err != nil {
+		return err
+	}
+	devAddr := fmt.Sprintf("0000:%s", vf.GetAddr())
+	if err := fileutils2.FilePutContents(
+		fmt.Sprintf("/sys/bus/pci/devices/%s/driver_override", devAddr),
+		VFIO_PCI_KERNEL_DRIVER, false); err != nil {
+		return fmt.Errorf("driver_override: %v", err)
+	}
+	return fileutils2.FilePutContents("/sys/bus/pci/drivers_probe", devAddr, false)
+}
+
+func (vf *sSRIOVNicDevice) SetWireId(wireId string) {
+	vf.wireId = wireId
+}
+
+func (vf *sSRIOVNicDevice) SyncDeviceInfo(session *mcclient.ClientSession, hostId string) error {
+	if len(vf.hostId) == 0 {
+		vf.hostId = hostId
+	}
+	data := vf.GetApiResourceData().(*jsonutils.JSONDict)
+	data.Set("wire_id", jsonutils.NewString(vf.wireId))
+	if len(vf.GetCloudId()) != 0 {
+		log.Infof("Update %s isolated_device: %s", vf.GetCloudId(), data.String())
+		_, err := modules.IsolatedDevices.Update(session, vf.GetCloudId(), data)
+		return err
+	}
+	// VF shares resources of host like normal nic
+	for _, k := range []string{"reserved_cpu", "reserved_memory", "reserved_storage"} {
+		data.Set(k, jsonutils.NewInt(0))
+	}
+	log.Infof("Create new isolated_device: %s", data.String())
+	_, err := modules.IsolatedDevices.Create(session, data)
+	return err
+}
+
+// getSRIOVPfNames return names of physical functions which have VFs enabled
+func getSRIOVPfNames() ([]string, error) {
+	ifaces, err := ioutil.ReadDir(SYS_CLASS_NET)
+	if err != nil {
+		return nil, err
+	}
+	ret := []string{}
+	for _, iface := range ifaces {
+		numVfsPath := path.Join(SYS_CLASS_NET, iface.Name(), "device", "sriov_numvfs")
+		if !fileutils2.Exists(numVfsPath) {
+			continue
+		}
+		content, err := fileutils2.FileGetContents(numVfsPath)
+		if err != nil {
+			log.Errorf("read %s: %v", numVfsPath, err)
+			continue
+		}
+		numVfs, _ := strconv.Atoi(strings.TrimSpace(content))
+		if numVfs <= 0 {
+			log.Infof("SR-IOV capable nic %s has no VF enabled, skip it", iface.Name())
+			continue
+		}
+		ret = append(ret, iface.Name())
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+// getSRIOVVfAddrs return pci address of VFs indexed by vf number
+func getSRIOVVfAddrs(pfName string) (map[int]string, error) {
+	devPath := path.Join(SYS_CLASS_NET, pfName, "device")
+	links, err := filepath.Glob(path.Join(devPath, "virtfn*"))
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[int]string)
+	for _, link := range links {
+		idx, err := strconv.Atoi(strings.TrimPrefix(path.Base(link), "virtfn"))
+		if err != nil {
+			continue
+		}
+		target, err := os.Readlink(link)
+		if err != nil {
+			return nil, fmt.Errorf("readlink %s: %v", link, err)
+		}
+		// ../0000:3b:02.0 => 3b:02.0
+		addr := path.Base(target)
+		if len(addr) > 7 {
+			addr = addr[len(addr)-7:]
+		}
+		ret[idx] = addr
+	}
+	return ret, nil
+}
+
+func detectSRIOVNicDevices() ([]*sSRIOVNicDevice, error) {
+	pfNames, err := getSRIOVPfNames()
+	if err != nil {
+		return nil, err
+	}
+	devs := []*sSRIOVNicDevice{}
+	for _, pfName := range pfNames {
+		vfAddrs, err := getSRIOVVfAddrs(pfName)
+		if err != nil {
+			return nil, fmt.Errorf("get VFs of %s: %v", pfName, err)
+		}
+		for idx := 0; idx < len(vfAddrs); idx++ {
+			addr, ok := vfAddrs[idx]
+			if !ok {
+				continue
+			}
+			dev, err := detectPCIDevByAddrWithoutIOMMUGroup(addr)
+			if err != nil {
+				return nil, fmt.Errorf("detect VF %s of %s: %v", addr, pfName, err)
+			}
+			devs = append(devs, NewSRIOVNicDevice(dev, pfName, idx))
+		}
+	}
+	return devs, nil
+}
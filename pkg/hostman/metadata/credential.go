@@ -0,0 +1,117 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+const (
+	// how long a failure of fetching credential is remembered, so that guests without
+	// instance role do not hammer region
+	credentialFailureTtl = time.Minute
+	// credential is refreshed when remaining lifetime is less than this
+	credentialRefreshAhead = 10 * time.Minute
+)
+
+// CredentialGetter mints token of the instance role bound to guest
+type CredentialGetter interface {
+	Get(guestId string) (jsonutils.JSONObject, error)
+}
+
+type CredentialGetterFunc func(guestId string) (jsonutils.JSONObject, error)
+
+func (f CredentialGetterFunc) Get(guestId string) (jsonutils.JSONObject, error) {
+	return f(guestId)
+}
+
+type cachedCredential struct {
+	credential jsonutils.JSONObject
+	expiresAt  time.Time
+	err        error
+	fetchedAt  time.Time
+}
+
+// credentialCache keeps credentials of guests and rotates them before expiration
+type credentialCache struct {
+	lock        sync.Mutex
+	credentials map[string]*cachedCredential
+}
+
+func newCredentialCache() *credentialCache {
+	return &credentialCache{credentials: map[string]*cachedCredential{}}
+}
+
+func (cc *credentialCache) get(getter CredentialGetter, guestId string) (jsonutils.JSONObject, error) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+
+	now := time.Now()
+	if c, ok := cc.credentials[guestId]; ok {
+		if c.err != nil && now.Sub(c.fetchedAt) < credentialFailureTtl {
+			return nil, c.err
+		}
+		if c.err == nil && c.expiresAt.Sub(now) > credentialRefreshAhead {
+			return c.credential, nil
+		}
+	}
+	for id, c := range cc.credentials {
+		if (c.err == nil && now.After(c.expiresAt)) || (c.err != nil && now.Sub(c.fetchedAt) >= credentialFailureTtl) {
+			delete(cc.credentials, id)
+		}
+	}
+
+	c := &cachedCredential{fetchedAt: now}
+	c.credential, c.err = getter.Get(guestId)
+	if c.err == nil {
+		c.expiresAt, c.err = c.credential.GetTime("expires_at")
+		if c.err != nil {
+			c.err = errors.Wrap(c.err, "no expires_at in credential")
+		}
+	}
+	cc.credentials[guestId] = c
+	return c.credential, c.err
+}
+
+// instanceCredential serves token of instance role, session token is always required
+// since the credential is more sensitive than other metadata
+func (s *Service) instanceCredential(ctx context.Context, w http.ResponseWriter, r *http.Request, guestDesc jsonutils.JSONObject) {
+	if s.CredentialGetter == nil {
+		hostutils.Response(ctx, w, httperrors.NewNotSupportedError("instance credential not supported"))
+		return
+	}
+	if len(r.Header.Get(TOKEN_HEADER)) == 0 {
+		hostutils.Response(ctx, w, httperrors.NewUnauthorizedError("missing %s header", TOKEN_HEADER))
+		return
+	}
+	guestId, _ := guestDesc.GetString("uuid")
+	cred, err := s.credentials.get(s.CredentialGetter, guestId)
+	if err != nil {
+		log.Errorf("get instance credential of guest %s: %v", guestId, err)
+		hostutils.Response(ctx, w, httperrors.NewNotFoundError("no instance credential available"))
+		return
+	}
+	hostutils.Response(ctx, w, cred)
+}
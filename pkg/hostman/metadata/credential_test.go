@@ -0,0 +1,67 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+)
+
+func TestCredentialCache(t *testing.T) {
+	calls := 0
+	lifetime := time.Hour
+	getter := CredentialGetterFunc(func(guestId string) (jsonutils.JSONObject, error) {
+		calls++
+		if guestId == "unbound" {
+			return nil, errors.Error("no instance role")
+		}
+		cred := jsonutils.NewDict()
+		cred.Set("expires_at", jsonutils.NewTimeString(time.Now().Add(lifetime)))
+		return cred, nil
+	})
+	cc := newCredentialCache()
+
+	for i := 0; i < 3; i++ {
+		if _, err := cc.get(getter, "guest"); err != nil {
+			t.Fatalf("get credential: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("credential fetched %d times, want 1", calls)
+	}
+
+	// credential about to expire is rotated
+	lifetime = time.Minute
+	cc.credentials = map[string]*cachedCredential{}
+	cc.get(getter, "guest")
+	cc.get(getter, "guest")
+	if calls != 3 {
+		t.Errorf("credential fetched %d times, want 3", calls)
+	}
+
+	// failure is remembered for a while
+	calls = 0
+	for i := 0; i < 3; i++ {
+		if _, err := cc.get(getter, "unbound"); err == nil {
+			t.Fatalf("expect error for unbound guest")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("credential of unbound guest fetched %d times, want 1", calls)
+	}
+}
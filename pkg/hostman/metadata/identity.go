@@ -0,0 +1,93 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/appctx"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/util/seclib2"
+)
+
+func guestPrivateIps(guestDesc jsonutils.JSONObject) []string {
+	ips := make([]string, 0)
+	guestNics, _ := guestDesc.GetArray("nics")
+	for _, nic := range guestNics {
+		if ip, _ := nic.GetString("ip"); len(ip) > 0 {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// identityDocumentIssuePeriod is the granularity of issued_at, documents of the same
+// period are identical so that they match the signature fetched by another request
+const identityDocumentIssuePeriod = time.Minute
+
+// identityDocument returns the instance identity document of guest issued at now
+func (s *Service) identityDocument(guestDesc jsonutils.JSONObject, now time.Time) string {
+	issuedAt := now.UTC().Truncate(identityDocumentIssuePeriod)
+	doc := api.InstanceIdentityDocument{
+		RegionId:   s.RegionId,
+		Region:     s.Region,
+		PrivateIps: guestPrivateIps(guestDesc),
+		IssuedAt:   issuedAt,
+		ExpiresAt:  issuedAt.Add(s.IdentityDocumentTtl),
+	}
+	doc.InstanceId, _ = guestDesc.GetString("uuid")
+	doc.InstanceName, _ = guestDesc.GetString("name")
+	doc.ProjectId, _ = guestDesc.GetString("tenant_id")
+	doc.DomainId, _ = guestDesc.GetString("domain_id")
+	doc.HostId, _ = guestDesc.GetString("host_id")
+	doc.ZoneId, _ = guestDesc.GetString("zone_id")
+	doc.Zone, _ = guestDesc.GetString("zone")
+	doc.Hypervisor, _ = guestDesc.GetString("hypervisor")
+	return jsonutils.Marshal(doc).PrettyString()
+}
+
+func (s *Service) instanceIdentity(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	guestDesc := s.getGuestNicDesc(r)
+	if guestDesc == nil {
+		hostutils.Response(ctx, w, httperrors.NewNotFoundError("no guest found for %s", r.RemoteAddr))
+		return
+	}
+	params := appctx.AppContextParams(ctx)
+	switch params["<doc>"] {
+	case "":
+		hostutils.Response(ctx, w, "document\nsignature")
+	case "document":
+		hostutils.Response(ctx, w, s.identityDocument(guestDesc, time.Now()))
+	case "signature":
+		if s.IdentityKey == nil {
+			hostutils.Response(ctx, w, httperrors.NewNotSupportedError("identity key not configured"))
+			return
+		}
+		sig, err := seclib2.SignSHA256(s.IdentityKey, []byte(s.identityDocument(guestDesc, time.Now())))
+		if err != nil {
+			hostutils.Response(ctx, w, httperrors.NewInternalServerError("sign identity document: %v", err))
+			return
+		}
+		hostutils.Response(ctx, w, sig)
+	default:
+		hostutils.Response(ctx, w, httperrors.NewNotFoundError("Resource not handled"))
+	}
+}
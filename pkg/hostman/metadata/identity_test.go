@@ -0,0 +1,61 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestIdentityDocument(t *testing.T) {
+	s := &Service{RegionId: "default", Region: "Default", IdentityDocumentTtl: 10 * time.Minute}
+	guestDesc := jsonutils.Marshal(map[string]interface{}{
+		"uuid": "guest-1",
+		"name": "vm1",
+		"nics": []map[string]string{{"ip": "10.0.0.2"}},
+	})
+	now := time.Date(2020, 1, 1, 10, 0, 30, 0, time.UTC)
+
+	docStr := s.identityDocument(guestDesc, now)
+	if got := s.identityDocument(guestDesc, now.Add(20*time.Second)); got != docStr {
+		t.Errorf("documents of the same period differ:\n%s\n%s", docStr, got)
+	}
+	if got := s.identityDocument(guestDesc, now.Add(time.Minute)); got == docStr {
+		t.Errorf("documents of different periods are identical")
+	}
+
+	docJson, err := jsonutils.ParseString(docStr)
+	if err != nil {
+		t.Fatalf("parse document: %v", err)
+	}
+	doc := api.InstanceIdentityDocument{}
+	if err := docJson.Unmarshal(&doc); err != nil {
+		t.Fatalf("unmarshal document: %v", err)
+	}
+	issuedAt := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	if !doc.IssuedAt.Equal(issuedAt) {
+		t.Errorf("issued_at %s, want %s", doc.IssuedAt, issuedAt)
+	}
+	if !doc.ExpiresAt.Equal(issuedAt.Add(10 * time.Minute)) {
+		t.Errorf("expires_at %s, want %s", doc.ExpiresAt, issuedAt.Add(10*time.Minute))
+	}
+	if doc.InstanceId != "guest-1" || len(doc.PrivateIps) != 1 || doc.PrivateIps[0] != "10.0.0.2" {
+		t.Errorf("unexpected document %#v", doc)
+	}
+}
@@ -17,16 +17,16 @@ package metadata
 // NOTE keep imports minimal.  DO NOT IMPORT guestman
 import (
 	"context"
-	"encoding/base64"
+	"crypto/rsa"
 	"fmt"
 	"net"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
-	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/util/netutils"
 
 	"yunion.io/x/onecloud/pkg/appsrv"
@@ -56,35 +56,63 @@ type Service struct {
 	Port    int
 
 	DescGetter DescGetter
+
+	// require session token for guests not setting metadata_http_tokens
+	RequireTokens bool
+	// default ip ttl of session token response
+	HopLimit int
+	// path of cloud-init vendor data file
+	VendorDataPath string
+	// key signing instance identity documents
+	IdentityKey *rsa.PrivateKey
+	// validity of instance identity documents
+	IdentityDocumentTtl time.Duration
+	RegionId            string
+	Region              string
+	// mints token of instance role bound to guest
+	CredentialGetter CredentialGetter
+
+	tokens      *tokenStore
+	credentials *credentialCache
 }
 
 func (s *Service) getGuestNicDesc(r *http.Request) (guestDesc jsonutils.JSONObject) {
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		panic(errors.Wrapf(err, "SplitHostPort %s", r.RemoteAddr))
-	}
-	guestDesc = s.DescGetter.Get(ip)
+	guestDesc = s.DescGetter.Get(remoteIp(r))
 	return
 }
 
 func (s *Service) addHandler(app *appsrv.Application) {
 	prefix := ""
+	s.tokens = newTokenStore()
+	s.credentials = newCredentialCache()
+
+	app.AddHandler("PUT", fmt.Sprintf("%s/latest/api/token", prefix), s.issueToken)
 
 	for _, method := range []string{"GET", "HEAD"} {
 		app.AddHandler(method, fmt.Sprintf("%s/<version:%s>",
-			prefix, `(latest|\d{4}-\d{2}-\d{2})`), s.versionOnly)
+			prefix, `(latest|\d{4}-\d{2}-\d{2})`), s.withToken(s.versionOnly))
 	}
 
 	for _, method := range []string{"GET", "HEAD"} {
 		app.AddHandler(method, fmt.Sprintf("%s/<version:%s>/user-data",
-			prefix, `(latest|\d{4}-\d{2}-\d{2})`), s.userData)
+			prefix, `(latest|\d{4}-\d{2}-\d{2})`), s.withToken(s.userData))
 		app.AddHandler(method, fmt.Sprintf("%s/<version:%s>/meta-data",
-			prefix, `(latest|\d{4}-\d{2}-\d{2})`), s.metaData)
+			prefix, `(latest|\d{4}-\d{2}-\d{2})`), s.withToken(s.metaData))
+		app.AddHandler(method, fmt.Sprintf("%s/<version:%s>/vendor-data",
+			prefix, `(latest|\d{4}-\d{2}-\d{2})`), s.withToken(s.vendorDataHandler))
+		app.AddHandler(method, fmt.Sprintf("%s/<version:%s>/dynamic/instance-identity",
+			prefix, `(latest|\d{4}-\d{2}-\d{2})`), s.withToken(s.instanceIdentity))
+		app.AddHandler(method, fmt.Sprintf("%s/<version:%s>/dynamic/instance-identity/<doc>",
+			prefix, `(latest|\d{4}-\d{2}-\d{2})`), s.withToken(s.instanceIdentity))
+
+		app.AddHandler(method, fmt.Sprintf("%s/openstack", prefix), s.withToken(s.openstackVersions))
+		app.AddHandler(method, fmt.Sprintf("%s/openstack/latest", prefix), s.withToken(s.openstackLatest))
+		app.AddHandler(method, fmt.Sprintf("%s/openstack/latest/<file>", prefix), s.withToken(s.openstackData))
 	}
 }
 
 func (s *Service) versionOnly(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-	hostutils.Response(ctx, w, strings.Join([]string{"meta-data", "user-data"}, "\n"))
+	hostutils.Response(ctx, w, strings.Join([]string{"dynamic/", "meta-data", "user-data", "vendor-data"}, "\n"))
 }
 
 func (s *Service) userData(ctx context.Context, w http.ResponseWriter, r *http.Request) {
@@ -93,21 +121,7 @@ func (s *Service) userData(ctx context.Context, w http.ResponseWriter, r *http.R
 		hostutils.Response(ctx, w, "")
 		return
 	}
-
-	if !guestDesc.Contains("user_data") {
-		hostutils.Response(ctx, w, "")
-		return
-	}
-
-	guestUserData, _ := guestDesc.GetString("user_data")
-	userDataDecoded, err := base64.StdEncoding.DecodeString(guestUserData)
-	if err != nil {
-		guestId, _ := guestDesc.GetString("id")
-		log.Errorf("Error format user_data %s, %s", guestId, guestUserData)
-		hostutils.Response(ctx, w, "")
-		return
-	}
-	hostutils.Response(ctx, w, string(userDataDecoded))
+	hostutils.Response(ctx, w, guestUserData(guestDesc))
 }
 
 func (s *Service) metaData(ctx context.Context, w http.ResponseWriter, r *http.Request) {
@@ -143,6 +157,9 @@ func (s *Service) metaData(ctx context.Context, w http.ResponseWriter, r *http.R
 		if guestDesc.Contains("secgroup") {
 			resNames = append(resNames, "security-groups/")
 		}
+		if s.CredentialGetter != nil {
+			resNames = append(resNames, "instance-credential")
+		}
 		hostutils.Response(ctx, w, strings.Join(resNames, "\n"))
 		return
 	} else {
@@ -240,6 +257,9 @@ func (s *Service) metaData(ctx context.Context, w http.ResponseWriter, r *http.R
 				hostutils.Response(ctx, w, guestSecgroup)
 				return
 			}
+		case "instance-credential":
+			s.instanceCredential(ctx, w, r, guestDesc)
+			return
 		case "ami-launch-index":
 			hostutils.Response(ctx, w, "0")
 			return
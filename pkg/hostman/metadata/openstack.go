@@ -0,0 +1,110 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/appctx"
+	"yunion.io/x/onecloud/pkg/hostman/configdrive"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+// guestUserData returns the decoded user data of guest
+func guestUserData(guestDesc jsonutils.JSONObject) string {
+	guestUserData, _ := guestDesc.GetString("user_data")
+	if len(guestUserData) == 0 {
+		return ""
+	}
+	userDataDecoded, err := base64.StdEncoding.DecodeString(guestUserData)
+	if err != nil {
+		guestId, _ := guestDesc.GetString("id")
+		log.Errorf("Error format user_data %s, %s", guestId, guestUserData)
+		return ""
+	}
+	return string(userDataDecoded)
+}
+
+// vendorData returns cloud-init vendor data of guest, the guest metadata overrides the one of host
+func (s *Service) vendorData(guestDesc jsonutils.JSONObject) string {
+	if data := guestMetadata(guestDesc, api.VM_METADATA_VENDOR_DATA); len(data) > 0 {
+		return data
+	}
+	if len(s.VendorDataPath) == 0 {
+		return ""
+	}
+	cont, err := ioutil.ReadFile(s.VendorDataPath)
+	if err != nil {
+		log.Errorf("read vendor data %s: %v", s.VendorDataPath, err)
+		return ""
+	}
+	return string(cont)
+}
+
+func (s *Service) vendorDataHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	guestDesc := s.getGuestNicDesc(r)
+	if guestDesc == nil {
+		hostutils.Response(ctx, w, "")
+		return
+	}
+	hostutils.Response(ctx, w, s.vendorData(guestDesc))
+}
+
+func (s *Service) openstackVersions(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	hostutils.Response(ctx, w, "latest")
+}
+
+func (s *Service) openstackLatest(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	hostutils.Response(ctx, w, strings.Join([]string{"meta_data.json", "user_data", "vendor_data.json"}, "\n"))
+}
+
+// openstackData serves files of openstack config drive layout, used by ConfigDrive
+// and OpenStack datasources of cloud-init
+func (s *Service) openstackData(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	guestDesc := s.getGuestNicDesc(r)
+	if guestDesc == nil {
+		hostutils.Response(ctx, w, httperrors.NewNotFoundError("no guest found for %s", r.RemoteAddr))
+		return
+	}
+	params := appctx.AppContextParams(ctx)
+	switch params["<file>"] {
+	case "meta_data.json":
+		hostutils.Response(ctx, w, configdrive.OpenStackMetaData(guestDesc))
+	case "user_data":
+		userData := guestUserData(guestDesc)
+		if len(userData) == 0 {
+			hostutils.Response(ctx, w, httperrors.NewNotFoundError("user_data not found"))
+			return
+		}
+		hostutils.Response(ctx, w, userData)
+	case "vendor_data.json":
+		data := jsonutils.NewDict()
+		if vendorData := s.vendorData(guestDesc); len(vendorData) > 0 {
+			data.Set("cloud-init", jsonutils.NewString(vendorData))
+		}
+		hostutils.Response(ctx, w, data)
+	default:
+		hostutils.Response(ctx, w, httperrors.NewNotFoundError("Resource not handled"))
+	}
+}
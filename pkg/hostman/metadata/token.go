@@ -0,0 +1,223 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+const (
+	TOKEN_HEADER     = "X-aws-ec2-metadata-token"
+	TOKEN_TTL_HEADER = "X-aws-ec2-metadata-token-ttl-seconds"
+
+	MAX_TOKEN_TTL_SECONDS = 21600
+)
+
+type sessionToken struct {
+	ip     string
+	expire time.Time
+}
+
+// tokenStore keeps session tokens issued by PUT /latest/api/token, a token is only
+// valid for the ip it is issued to
+type tokenStore struct {
+	lock   sync.Mutex
+	tokens map[string]sessionToken
+}
+
+func newTokenStore() *tokenStore {
+	return &tokenStore{tokens: map[string]sessionToken{}}
+}
+
+func (ts *tokenStore) issue(ip string, ttl time.Duration) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.Wrap(err, "rand.Read")
+	}
+	token := hex.EncodeToString(buf)
+	now := time.Now()
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	for k, t := range ts.tokens {
+		if now.After(t.expire) {
+			delete(ts.tokens, k)
+		}
+	}
+	ts.tokens[token] = sessionToken{ip: ip, expire: now.Add(ttl)}
+	return token, nil
+}
+
+func (ts *tokenStore) verify(ip, token string) bool {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	t, ok := ts.tokens[token]
+	if !ok {
+		return false
+	}
+	if time.Now().After(t.expire) {
+		delete(ts.tokens, token)
+		return false
+	}
+	return t.ip == ip
+}
+
+func remoteIp(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		panic(errors.Wrapf(err, "SplitHostPort %s", r.RemoteAddr))
+	}
+	return ip
+}
+
+func guestMetadata(guestDesc jsonutils.JSONObject, key string) string {
+	if guestDesc == nil {
+		return ""
+	}
+	val, _ := guestDesc.GetString("metadata", key)
+	return val
+}
+
+func (s *Service) tokensRequired(guestDesc jsonutils.JSONObject) bool {
+	switch guestMetadata(guestDesc, api.VM_METADATA_HTTP_TOKENS) {
+	case api.VM_METADATA_HTTP_TOKENS_REQUIRED:
+		return true
+	case api.VM_METADATA_HTTP_TOKENS_OPTIONAL:
+		return false
+	}
+	return s.RequireTokens
+}
+
+func (s *Service) hopLimit(guestDesc jsonutils.JSONObject) int {
+	if val := guestMetadata(guestDesc, api.VM_METADATA_HTTP_PUT_RESPONSE_HOP_LIMIT); len(val) > 0 {
+		if limit, err := strconv.Atoi(val); err == nil && limit > 0 && limit < 256 {
+			return limit
+		}
+	}
+	if s.HopLimit > 0 {
+		return s.HopLimit
+	}
+	return 1
+}
+
+// checkToken validates the session token of request, a request with invalid token is always
+// rejected, while one without token is rejected only when tokens are required
+func (s *Service) checkToken(r *http.Request, guestDesc jsonutils.JSONObject) error {
+	token := r.Header.Get(TOKEN_HEADER)
+	if len(token) == 0 {
+		if s.tokensRequired(guestDesc) {
+			return httperrors.NewUnauthorizedError("missing %s header", TOKEN_HEADER)
+		}
+		return nil
+	}
+	if !s.tokens.verify(remoteIp(r), token) {
+		return httperrors.NewUnauthorizedError("invalid or expired session token")
+	}
+	return nil
+}
+
+// withToken guards handler with session token check
+func (s *Service) withToken(handler appsrvHandler) appsrvHandler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		if err := s.checkToken(r, s.getGuestNicDesc(r)); err != nil {
+			hostutils.Response(ctx, w, err)
+			return
+		}
+		handler(ctx, w, r)
+	}
+}
+
+type appsrvHandler func(ctx context.Context, w http.ResponseWriter, r *http.Request)
+
+// issueToken serves PUT /latest/api/token. The response is sent with ip ttl of the hop
+// limit, so that a token can not be got through forwarding proxies or containers
+// more hops away than allowed
+func (s *Service) issueToken(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if len(r.Header.Get("X-Forwarded-For")) > 0 {
+		hostutils.Response(ctx, w, httperrors.NewForbiddenError("forwarded request is not allowed"))
+		return
+	}
+	ttlStr := r.Header.Get(TOKEN_TTL_HEADER)
+	if len(ttlStr) == 0 {
+		hostutils.Response(ctx, w, httperrors.NewMissingParameterError(TOKEN_TTL_HEADER))
+		return
+	}
+	ttl, err := strconv.Atoi(ttlStr)
+	if err != nil || ttl < 1 || ttl > MAX_TOKEN_TTL_SECONDS {
+		hostutils.Response(ctx, w, httperrors.NewInputParameterError("%s must be between 1 and %d", TOKEN_TTL_HEADER, MAX_TOKEN_TTL_SECONDS))
+		return
+	}
+	guestDesc := s.getGuestNicDesc(r)
+	if guestDesc == nil {
+		hostutils.Response(ctx, w, httperrors.NewNotFoundError("no guest found for %s", r.RemoteAddr))
+		return
+	}
+	ip := remoteIp(r)
+	token, err := s.tokens.issue(ip, time.Duration(ttl)*time.Second)
+	if err != nil {
+		hostutils.Response(ctx, w, httperrors.NewInternalServerError("issue token: %v", err))
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		hostutils.Response(ctx, w, httperrors.NewInternalServerError("response is not hijackable"))
+		return
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		hostutils.Response(ctx, w, httperrors.NewInternalServerError("hijack: %v", err))
+		return
+	}
+	defer conn.Close()
+	if err := setConnTTL(conn, s.hopLimit(guestDesc)); err != nil {
+		log.Errorf("set ttl of metadata token response to %s: %v", ip, err)
+		return
+	}
+	fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\n%s: %d\r\nContent-Length: %d\r\nConnection: close\r\n\r\n%s",
+		TOKEN_TTL_HEADER, ttl, len(token), token)
+	if err := buf.Flush(); err != nil {
+		log.Errorf("write metadata token response to %s: %v", ip, err)
+	}
+}
+
+func setConnTTL(conn net.Conn, ttl int) error {
+	addr, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return errors.Errorf("unsupported connection %s", conn.RemoteAddr())
+	}
+	if addr.IP.To4() != nil {
+		return ipv4.NewConn(conn).SetTTL(ttl)
+	}
+	return ipv6.NewConn(conn).SetHopLimit(ttl)
+}
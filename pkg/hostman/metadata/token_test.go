@@ -0,0 +1,63 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+)
+
+func TestCheckToken(t *testing.T) {
+	s := &Service{tokens: newTokenStore()}
+	token, err := s.tokens.issue("10.0.0.2", time.Minute)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	expired, err := s.tokens.issue("10.0.0.2", -time.Second)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	required := jsonutils.Marshal(map[string]interface{}{
+		"metadata": map[string]string{"metadata_http_tokens": "required"},
+	})
+
+	cases := []struct {
+		name      string
+		remote    string
+		token     string
+		guestDesc jsonutils.JSONObject
+		wantErr   bool
+	}{
+		{"no token optional", "10.0.0.2:1234", "", nil, false},
+		{"no token required", "10.0.0.2:1234", "", required, true},
+		{"valid token", "10.0.0.2:1234", token, required, false},
+		{"token of other ip", "10.0.0.3:1234", token, nil, true},
+		{"expired token", "10.0.0.2:1234", expired, nil, true},
+		{"unknown token", "10.0.0.2:1234", "abc", nil, true},
+	}
+	for _, c := range cases {
+		r := &http.Request{RemoteAddr: c.remote, Header: http.Header{}}
+		if len(c.token) > 0 {
+			r.Header.Set(TOKEN_HEADER, c.token)
+		}
+		err := s.checkToken(r, c.guestDesc)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: got error %v, want error %v", c.name, err, c.wantErr)
+		}
+	}
+}
@@ -477,6 +477,10 @@ func (m *HmpMonitor) CancelBlockJob(driveName string, force bool, callback Strin
 	m.Query(cmd, callback)
 }
 
+func (m *HmpMonitor) BlockJobComplete(driveName string, callback StringCallback) {
+	m.Query(fmt.Sprintf("block_job_complete %s", driveName), callback)
+}
+
 func (m *HmpMonitor) NetdevAdd(id, netType string, params map[string]string, callback StringCallback) {
 	cmd := fmt.Sprintf("netdev_add %s,id=%s", netType, id)
 	for k, v := range params {
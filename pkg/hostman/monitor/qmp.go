@@ -833,6 +833,21 @@ func (m *QmpMonitor) CancelBlockJob(driveName string, force bool, callback Strin
 	m.HumanMonitorCommand(cmd, callback)
 }
 
+func (m *QmpMonitor) BlockJobComplete(driveName string, callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "block-job-complete",
+			Args: map[string]interface{}{
+				"device": driveName,
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
 func (m *QmpMonitor) NetdevAdd(id, netType string, params map[string]string, callback StringCallback) {
 	cmd := fmt.Sprintf("netdev_add %s,id=%s", netType, id)
 	for k, v := range params {
@@ -38,6 +38,8 @@ type SHostOptions struct {
 	AgentTempPath  string `help:"Path for ESXi agent"`
 	AgentTempLimit int    `help:"Maximal storage space for ESXi agent, in GB" default:"10"`
 
+	RequireSignedImages bool `help:"Refuse to cache images without a verified signature from a trusted key" default:"false"`
+
 	RecycleDiskfile         bool `help:"Recycle instead of remove deleted disk file" default:"true"`
 	RecycleDiskfileKeepDays int  `help:"How long recycled files kept, default 28 days" default:"28"`
 
@@ -132,12 +134,26 @@ type SHostOptions struct {
 	OvnEipBridge              string `help:"name of bridge for eip traffic management" default:"$HOST_OVN_EIP_BRIDGE|breip"`
 	OvnUnderlayMtu            int    `help:"mtu of ovn underlay network" default:"1500"`
 
+	EnableOvsDpdk         bool     `help:"Use OVS-DPDK userspace datapath for openvswitch bridges, require native hugepages" default:"false"`
+	OvsDpdkSocketMem      string   `help:"hugepage memory in MB preallocated by dpdk for each numa node, e.g. 1024,1024" default:"1024"`
+	OvsDpdkLcoreMask      string   `help:"cpu mask of dpdk lcore threads"`
+	OvsDpdkPmdCpuMask     string   `help:"cpu mask of dpdk pmd threads"`
+	OvsDpdkDevargs        []string `help:"dpdk-devargs of physical interface, format <ifname>/<devargs>, e.g. eth1/0000:3b:00.0"`
+	OvsVhostUserSocketDir string   `help:"directory of vhost-user sockets of guest nics" default:"/var/run/openvswitch/vhost-user"`
+
 	EnableRemoteExecutor bool   `help:"Enable remote executor" default:"false"`
 	EnableHealthChecker  bool   `help:"enable host health checker" default:"true"`
 	HealthDriver         string `help:"Component save host health state" default:"etcd"`
 	HostHealthTimeout    int    `help:"host health timeout" default:"30"`
 	HostLeaseTimeout     int    `help:"lease timeout" default:"10"`
 
+	MetadataIdentityKeyPath    string `help:"path of rsa key signing instance identity documents, generated if not exists" default:"/opt/cloud/workspace/metadata_identity.key"`
+	MetadataHttpTokensRequired bool   `help:"require session token for all guests to access metadata service" default:"false"`
+	MetadataTokenHopLimit      int    `help:"default ip ttl of session token response of metadata service" default:"1"`
+	MetadataVendorDataPath     string `help:"path of cloud-init vendor data served to guests by metadata service"`
+
+	MetadataIdentityDocumentTtlSeconds int `help:"validity seconds of instance identity document served by metadata service" default:"600"`
+
 	SyncStorageInfoDurationSecond int  `help:"sync storage size duration, unit is second" default:"60"`
 	StartHostIgnoreSysError       bool `help:"start host agent ignore sys error" default:"false"`
 }
@@ -27,6 +27,7 @@ import (
 	"yunion.io/x/log"
 
 	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/options"
 	"yunion.io/x/onecloud/pkg/hostman/storageman/remotefile"
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
 	"yunion.io/x/onecloud/pkg/mcclient/modules"
@@ -128,15 +129,18 @@ func (l *SLocalImageCache) Load() bool {
 			}
 		}
 		if len(desc.Chksum) > 0 && len(desc.Id) > 0 && desc.Id == l.imageId {
+			if options.HostOptions.RequireSignedImages && !isImageSignatureVerified(desc) {
+				// cached before signatures were required, the signature is
+				// verified when it is fetched again
+				log.Warningf("image cache %s has no verified signature, refetch it", l.imageId)
+				return false
+			}
 			l.Desc = desc
 			return true
 		}
 	}
 
-	tmpPath := l.GetTmpPath()
-	if fileutils2.Exists(tmpPath) {
-		syscall.Unlink(tmpPath)
-	}
+	// keep the partially downloaded tmp file, the next fetch resumes from it
 	return false
 }
 
@@ -193,6 +197,15 @@ func (l *SLocalImageCache) prepare(ctx context.Context, zone, srcUrl, format str
 func (l *SLocalImageCache) fetch(ctx context.Context, zone, srcUrl, format string) bool {
 	if (fileutils2.Exists(l.GetPath()) && l.remoteFile.VerifyIntegrity()) ||
 		l.remoteFile.Fetch() {
+		err := verifyImageSignature(ctx, zone, l.GetPath(), l.remoteFile.GetInfo())
+		if err != nil {
+			log.Errorf("refuse to cache image %s: %s", l.imageId, err)
+			if err := syscall.Unlink(l.GetPath()); err != nil {
+				log.Errorf("remove refused image %s: %s", l.GetPath(), err)
+			}
+			l.fetchFailed()
+			return false
+		}
 		if len(l.Manager.GetId()) > 0 {
 			_, err := hostutils.RemoteStoragecacheCacheImage(ctx,
 				l.Manager.GetId(), l.imageId, "ready", l.GetPath())
@@ -224,15 +237,19 @@ func (l *SLocalImageCache) fetch(ctx context.Context, zone, srcUrl, format strin
 		}
 		return true
 	} else {
-		l.cond.L.Lock()
-		defer l.cond.L.Unlock()
-		l.Desc = nil
-		l.remoteFile = nil
-		l.cond.Broadcast()
+		l.fetchFailed()
 		return false
 	}
 }
 
+func (l *SLocalImageCache) fetchFailed() {
+	l.cond.L.Lock()
+	defer l.cond.L.Unlock()
+	l.Desc = nil
+	l.remoteFile = nil
+	l.cond.Broadcast()
+}
+
 func (l *SLocalImageCache) Remove(ctx context.Context) error {
 	if fileutils2.Exists(l.GetPath()) {
 		if err := syscall.Unlink(l.GetPath()); err != nil {
@@ -0,0 +1,91 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"context"
+	"encoding/hex"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	imageapi "yunion.io/x/onecloud/pkg/apis/image"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/remotefile"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/seclib2"
+)
+
+const (
+	ErrImageUnsigned         = errors.Error("image is not signed")
+	ErrImageSignatureInvalid = errors.Error("image signature is not verified")
+)
+
+// isImageSignatureVerified tells whether the desc of a cached image records a signature
+// verified by verifyImageSignature when it was fetched
+func isImageSignatureVerified(desc *remotefile.SImageDesc) bool {
+	return len(desc.Signature) > 0 && desc.SignatureStatus == imageapi.IMAGE_SIGNATURE_VERIFIED && len(desc.Sha256) > 0
+}
+
+// verifyImageSignature checks the downloaded file against the sha256 recorded by glance
+// and the signature of the image against the trusted key it was signed with
+func verifyImageSignature(ctx context.Context, zone string, imagePath string, desc *remotefile.SImageDesc) error {
+	if desc == nil {
+		return errors.Wrap(ErrImageSignatureInvalid, "no image desc")
+	}
+	if len(desc.Signature) == 0 {
+		if options.HostOptions.RequireSignedImages {
+			return ErrImageUnsigned
+		}
+		return nil
+	}
+	if desc.SignatureStatus != imageapi.IMAGE_SIGNATURE_VERIFIED {
+		return errors.Wrapf(ErrImageSignatureInvalid, "signature status %s", desc.SignatureStatus)
+	}
+	if len(desc.Sha256) == 0 {
+		return errors.Wrap(ErrImageSignatureInvalid, "no sha256 of the downloaded image")
+	}
+	sha256sum, err := fileutils2.SHA256(imagePath)
+	if err != nil {
+		return errors.Wrap(err, "SHA256")
+	}
+	if sha256sum != desc.Sha256 {
+		return errors.Wrapf(ErrImageSignatureInvalid, "sha256 mismatch, expect %s got %s", desc.Sha256, sha256sum)
+	}
+
+	signedSha256 := desc.ImageSha256
+	if len(signedSha256) == 0 {
+		signedSha256 = desc.Sha256
+	}
+	digest, err := hex.DecodeString(signedSha256)
+	if err != nil {
+		return errors.Wrapf(ErrImageSignatureInvalid, "invalid sha256 %s", signedSha256)
+	}
+	key, err := modules.ImageSigningKeys.Get(hostutils.GetImageSession(ctx, zone), desc.SigningKeyId, nil)
+	if err != nil {
+		return errors.Wrapf(err, "fetch signing key %s", desc.SigningKeyId)
+	}
+	if !jsonutils.QueryBoolean(key, "enabled", false) {
+		return errors.Wrapf(ErrImageSignatureInvalid, "signing key %s is disabled", desc.SigningKeyId)
+	}
+	publicKey, _ := key.GetString("public_key")
+	err = seclib2.VerifySHA256Digest(publicKey, digest, desc.Signature)
+	if err != nil {
+		return errors.Wrap(ErrImageSignatureInvalid, err.Error())
+	}
+	return nil
+}
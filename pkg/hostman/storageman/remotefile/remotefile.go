@@ -17,13 +17,16 @@ package remotefile
 import (
 	"compress/zlib"
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"syscall"
 	"time"
 
 	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
 
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
 	"yunion.io/x/onecloud/pkg/util/fileutils2"
@@ -37,6 +40,14 @@ type SImageDesc struct {
 	Chksum string `json:"chksum"`
 	Path   string `json:"path"`
 	Size   int64  `json:"size"`
+
+	// sha256 of the downloaded file
+	Sha256 string `json:"sha256"`
+	// sha256 of the original image covered by the signature
+	ImageSha256     string `json:"image_sha256"`
+	Signature       string `json:"signature"`
+	SigningKeyId    string `json:"signing_key_id"`
+	SignatureStatus string `json:"signature_status"`
 }
 
 type SRemoteFile struct {
@@ -53,6 +64,12 @@ type SRemoteFile struct {
 	chksum string
 	format string
 	name   string
+
+	sha256          string
+	imageSha256     string
+	signature       string
+	signingKeyId    string
+	signatureStatus string
 }
 
 func NewRemoteFile(
@@ -107,6 +124,12 @@ func (r *SRemoteFile) GetInfo() *SImageDesc {
 		Chksum: r.chksum,
 		Path:   r.localPath,
 		Size:   fi.Size(),
+
+		Sha256:          r.sha256,
+		ImageSha256:     r.imageSha256,
+		Signature:       r.signature,
+		SigningKeyId:    r.signingKeyId,
+		SignatureStatus: r.signatureStatus,
 	}
 }
 
@@ -142,6 +165,9 @@ func (r *SRemoteFile) fetch(preChksum string) bool {
 					fetchSucc = false
 				} else if r.chksum != localChksum {
 					fetchSucc = false
+					// the downloaded data is corrupted and can't be resumed
+					log.Errorf("checksum mismatch, drop downloaded file %s", r.tmpPath)
+					os.Remove(r.tmpPath)
 				}
 			}
 		}
@@ -199,11 +225,16 @@ func (r *SRemoteFile) downloadInternal(getData bool, preChksum string) bool {
 		}
 	}
 	var method, url = "HEAD", r.url
+	var resumeOffset int64
 	if getData {
 		if len(r.downloadUrl) > 0 {
 			url = r.downloadUrl
 		}
 		method = "GET"
+		resumeOffset = r.getResumeOffset()
+		if resumeOffset > 0 {
+			header.Set("Range", fmt.Sprintf("bytes=%d-", resumeOffset))
+		}
 	}
 
 	httpCli := httputils.GetTimeoutClient(r.timeout)
@@ -216,8 +247,7 @@ func (r *SRemoteFile) downloadInternal(getData bool, preChksum string) bool {
 		defer resp.Body.Close()
 		if resp.StatusCode < 300 {
 			if getData {
-				os.Remove(r.tmpPath)
-				fi, err := os.Create(r.tmpPath)
+				fi, err := r.openTmpFile(resp, resumeOffset)
 				if err != nil {
 					log.Errorln(err)
 					return false
@@ -261,6 +291,11 @@ func (r *SRemoteFile) downloadInternal(getData bool, preChksum string) bool {
 			}
 			r.setProperties(resp.Header)
 			return true
+		} else if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			// remote file changed, download from the beginning at next retry
+			log.Errorf("Remote file fetch range %d- not satisfiable, drop partial file %s", resumeOffset, r.tmpPath)
+			os.Remove(r.tmpPath)
+			return false
 		} else if resp.StatusCode == 304 {
 			if fileutils2.Exists(r.tmpPath) {
 				if err := os.Remove(r.tmpPath); err != nil {
@@ -275,6 +310,33 @@ func (r *SRemoteFile) downloadInternal(getData bool, preChksum string) bool {
 	}
 }
 
+// getResumeOffset returns the size of the partially downloaded file which can be resumed
+func (r *SRemoteFile) getResumeOffset() int64 {
+	if r.compress || r.tmpPath == r.localPath {
+		return 0
+	}
+	fi, err := os.Stat(r.tmpPath)
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
+
+// openTmpFile opens the file to write the response body, the partial file is appended
+// if the server returns the requested range, otherwise it is truncated
+func (r *SRemoteFile) openTmpFile(resp *http.Response, resumeOffset int64) (*os.File, error) {
+	if resumeOffset > 0 && resp.StatusCode == http.StatusPartialContent {
+		expect := fmt.Sprintf("bytes %d-", resumeOffset)
+		if contentRange := resp.Header.Get("Content-Range"); strings.HasPrefix(contentRange, expect) {
+			log.Infof("resume download %s from offset %d", r.tmpPath, resumeOffset)
+			return os.OpenFile(r.tmpPath, os.O_WRONLY|os.O_APPEND, 0644)
+		}
+		return nil, errors.Errorf("unexpected Content-Range %q, want %s", resp.Header.Get("Content-Range"), expect)
+	}
+	os.Remove(r.tmpPath)
+	return os.Create(r.tmpPath)
+}
+
 func (r *SRemoteFile) setProperties(header http.Header) {
 	if chksum := header.Get("X-Image-Meta-Checksum"); len(chksum) > 0 {
 		r.chksum = chksum
@@ -285,4 +347,19 @@ func (r *SRemoteFile) setProperties(header http.Header) {
 	if name := header.Get("X-Image-Meta-Name"); len(name) > 0 {
 		r.name = name
 	}
+	if sha256 := header.Get("X-Image-Meta-Sha256"); len(sha256) > 0 {
+		r.sha256 = sha256
+	}
+	if imageSha256 := header.Get("X-Image-Meta-Image_sha256"); len(imageSha256) > 0 {
+		r.imageSha256 = imageSha256
+	}
+	if signature := header.Get("X-Image-Meta-Signature"); len(signature) > 0 {
+		r.signature = signature
+	}
+	if keyId := header.Get("X-Image-Meta-Signing_key_id"); len(keyId) > 0 {
+		r.signingKeyId = keyId
+	}
+	if status := header.Get("X-Image-Meta-Signature_status"); len(status) > 0 {
+		r.signatureStatus = status
+	}
 }
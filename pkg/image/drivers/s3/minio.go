@@ -16,6 +16,7 @@ package s3
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/minio/minio-go"
 
@@ -103,6 +104,34 @@ func Get(fileName string) (*minio.Object, error) {
 	return obj, nil
 }
 
+// GetRange reads the bytes from start to end, both inclusive, of the object
+func GetRange(fileName string, start, end int64) (io.ReadCloser, error) {
+	if client == nil {
+		return nil, ErrClientNotInit
+	}
+	opts := minio.GetObjectOptions{}
+	err := opts.SetRange(start, end)
+	if err != nil {
+		return nil, errors.Wrap(err, "set range")
+	}
+	rc, _, err := minio.Core{Client: client.Client}.GetObject(client.bucket, fileName, opts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get object %s range %d-%d", fileName, start, end)
+	}
+	return rc, nil
+}
+
+func Stat(fileName string) (minio.ObjectInfo, error) {
+	if client == nil {
+		return minio.ObjectInfo{}, ErrClientNotInit
+	}
+	info, err := client.StatObject(client.bucket, fileName, minio.StatObjectOptions{})
+	if err != nil {
+		return info, errors.Wrapf(err, "stat object %s", fileName)
+	}
+	return info, nil
+}
+
 func Remove(fileName string) error {
 	if client == nil {
 		return ErrClientNotInit
@@ -0,0 +1,396 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/image"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	deployapi "yunion.io/x/onecloud/pkg/hostman/hostdeployer/apis"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// max length of build log kept in database, the head of longer logs is dropped
+const maxImageBuildLogLength = 1024 * 1024
+
+// SImageBuildManager records builds that customize a base image offline with a recipe
+type SImageBuildManager struct {
+	db.SVirtualResourceBaseManager
+}
+
+var ImageBuildManager *SImageBuildManager
+
+func init() {
+	ImageBuildManager = &SImageBuildManager{
+		SVirtualResourceBaseManager: db.NewVirtualResourceBaseManager(
+			SImageBuild{},
+			"image_builds_tbl",
+			"image_build",
+			"image_builds",
+		),
+	}
+	ImageBuildManager.SetVirtualObject(ImageBuildManager)
+}
+
+type SImageBuild struct {
+	db.SVirtualResourceBase
+
+	// 基础镜像ID
+	BaseImageId string `width:"128" charset:"ascii" nullable:"false" list:"user" create:"required" index:"true"`
+	// 构建时基础镜像的校验和
+	BaseImageChecksum string `width:"32" charset:"ascii" nullable:"true" list:"user"`
+	// 构建生成的镜像ID
+	ImageId string `width:"128" charset:"ascii" nullable:"true" list:"user" index:"true"`
+	// 构建配方
+	Recipe jsonutils.JSONObject `nullable:"true" get:"user" create:"required"`
+	// 构建配方的SHA256
+	RecipeSha256 string `width:"64" charset:"ascii" nullable:"true" list:"user"`
+	// 构建日志
+	BuildLog string `length:"medium" nullable:"true" get:"user"`
+}
+
+// builds run arbitrary scripts and install packages on the host deployer, only admins may start one
+func (manager *SImageBuildManager) AllowCreateItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowCreate(userCred, manager)
+}
+
+func (manager *SImageBuildManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.ImageBuildCreateInput) (*jsonutils.JSONDict, error) {
+	var err error
+	input.VirtualResourceCreateInput, err = manager.SVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.VirtualResourceCreateInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.ValidateCreateData")
+	}
+	if len(input.BaseImage) == 0 {
+		return nil, httperrors.NewMissingParameterError("base_image")
+	}
+	imgObj, err := ImageManager.FetchByIdOrName(userCred, input.BaseImage)
+	if err != nil {
+		if errors.Cause(err) == sqlchemy.ErrEmptyQuery {
+			return nil, httperrors.NewResourceNotFoundError2(ImageManager.Keyword(), input.BaseImage)
+		}
+		return nil, httperrors.NewGeneralError(err)
+	}
+	baseImage := imgObj.(*SImage)
+	if baseImage.Status != api.IMAGE_STATUS_ACTIVE {
+		return nil, httperrors.NewInvalidStatusError("base image %s is not active", baseImage.Name)
+	}
+	if baseImage.DiskFormat == string(api.ImageTypeISO) || baseImage.IsData.IsTrue() {
+		return nil, httperrors.NewUnsupportOperationError("base image %s is not a system disk image", baseImage.Name)
+	}
+	input.BaseImageId = baseImage.Id
+
+	if input.Recipe == nil {
+		return nil, httperrors.NewMissingParameterError("recipe")
+	}
+	_, err = imageBuildRecipeToDeploy(input.Recipe)
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("invalid recipe: %s", err)
+	}
+
+	pendingUsage := SQuota{Image: 1}
+	pendingUsage.SetKeys(imageCreateInput2QuotaKeys(string(qemuimg.QCOW2), ownerId))
+	if err := quotas.CheckSetPendingQuota(ctx, userCred, &pendingUsage); err != nil {
+		return nil, httperrors.NewOutOfQuotaError("%s", err)
+	}
+	return jsonutils.Marshal(input).(*jsonutils.JSONDict), nil
+}
+
+// imageBuildRecipeToDeploy converts the recipe into the one understood by the host deployer
+func imageBuildRecipeToDeploy(recipe *api.ImageBuildRecipe) (*deployapi.ImageBuildRecipe, error) {
+	ret := &deployapi.ImageBuildRecipe{
+		Packages:          recipe.Packages,
+		Scripts:           recipe.Scripts,
+		EnableServices:    recipe.EnableServices,
+		ResetMachineId:    recipe.ResetMachineId,
+		RemoveSshHostKeys: recipe.RemoveSshHostKeys,
+		CleanLogs:         recipe.CleanLogs,
+	}
+	for _, f := range recipe.Files {
+		mode := int64(0644)
+		if len(f.Mode) > 0 {
+			var err error
+			mode, err = strconv.ParseInt(f.Mode, 8, 32)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid mode %s of file %s", f.Mode, f.Path)
+			}
+		}
+		ret.Files = append(ret.Files, &deployapi.ImageBuildFile{
+			Path:    f.Path,
+			Content: f.Content,
+			Mode:    int32(mode),
+		})
+	}
+	err := ret.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (self *SImageBuild) GetDeployRecipe() (*deployapi.ImageBuildRecipe, error) {
+	recipe := api.ImageBuildRecipe{}
+	if self.Recipe != nil {
+		err := self.Recipe.Unmarshal(&recipe)
+		if err != nil {
+			return nil, errors.Wrap(err, "unmarshal recipe")
+		}
+	}
+	return imageBuildRecipeToDeploy(&recipe)
+}
+
+func (self *SImageBuild) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	err := self.SVirtualResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+	if err != nil {
+		return err
+	}
+	self.Status = api.IMAGE_BUILD_STATUS_BUILDING
+	if self.Recipe != nil {
+		sum := sha256.Sum256([]byte(self.Recipe.String()))
+		self.RecipeSha256 = hex.EncodeToString(sum[:])
+	}
+	return nil
+}
+
+func (self *SImageBuild) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.SVirtualResourceBase.PostCreate(ctx, userCred, ownerId, query, data)
+
+	pendingUsage := SQuota{Image: 1}
+	pendingUsage.SetKeys(imageCreateInput2QuotaKeys(string(qemuimg.QCOW2), ownerId))
+	image, err := self.createImage(ctx, ownerId)
+	if err != nil {
+		quotas.CancelPendingUsage(ctx, userCred, &pendingUsage, &pendingUsage, false)
+		self.SetStatus(userCred, api.IMAGE_BUILD_STATUS_FAILED, err.Error())
+		return
+	}
+	cancelUsage := SQuota{Image: 1}
+	cancelUsage.SetKeys(image.GetQuotaKeys())
+	quotas.CancelPendingUsage(ctx, userCred, &pendingUsage, &cancelUsage, true)
+
+	err = self.StartImageBuildTask(ctx, userCred, "")
+	if err != nil {
+		self.SetStatus(userCred, api.IMAGE_BUILD_STATUS_FAILED, err.Error())
+		image.OnSaveFailed(ctx, userCred, jsonutils.NewString(err.Error()))
+	}
+}
+
+// createImage creates the queued image which receives the build result
+func (self *SImageBuild) createImage(ctx context.Context, ownerId mcclient.IIdentityProvider) (*SImage, error) {
+	baseImage, err := self.GetBaseImage()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetBaseImage")
+	}
+	image := &SImage{}
+	image.SetModelManager(ImageManager, image)
+	image.Name, err = db.GenerateName(ImageManager, ownerId, self.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "GenerateName")
+	}
+	image.Description = fmt.Sprintf("built from image %s by image build %s", baseImage.Name, self.Name)
+	image.Status = api.IMAGE_STATUS_QUEUED
+	image.DiskFormat = string(qemuimg.QCOW2)
+	image.OsArch = baseImage.OsArch
+	image.MinRamMB = baseImage.MinRamMB
+	image.DomainId = ownerId.GetProjectDomainId()
+	image.ProjectId = ownerId.GetProjectId()
+	image.Owner = image.ProjectId
+	err = ImageManager.TableSpec().Insert(ctx, image)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert image")
+	}
+	_, err = db.Update(self, func() error {
+		self.ImageId = image.Id
+		self.BaseImageChecksum = baseImage.Checksum
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "update image_id")
+	}
+	return image, nil
+}
+
+func (self *SImageBuild) StartImageBuildTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+	task, err := taskman.TaskManager.NewTask(ctx, "ImageBuildTask", self, userCred, nil, parentTaskId, "", nil)
+	if err != nil {
+		return err
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
+func (self *SImageBuild) GetBaseImage() (*SImage, error) {
+	obj, err := ImageManager.FetchById(self.BaseImageId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch base image %s", self.BaseImageId)
+	}
+	return obj.(*SImage), nil
+}
+
+func (self *SImageBuild) GetImage() (*SImage, error) {
+	if len(self.ImageId) == 0 {
+		return nil, errors.Wrap(errors.ErrNotFound, "empty image_id")
+	}
+	obj, err := ImageManager.FetchById(self.ImageId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch image %s", self.ImageId)
+	}
+	return obj.(*SImage), nil
+}
+
+// SaveBuildLog keeps the tail of the log returned by the deployer
+func (self *SImageBuild) SaveBuildLog(buildLog string) error {
+	if len(buildLog) > maxImageBuildLogLength {
+		buildLog = buildLog[len(buildLog)-maxImageBuildLogLength:]
+	}
+	_, err := db.Update(self, func() error {
+		self.BuildLog = buildLog
+		return nil
+	})
+	return err
+}
+
+// SaveProvenance records where the image comes from in the image properties
+func (self *SImageBuild) SaveProvenance(ctx context.Context, userCred mcclient.TokenCredential, image *SImage) error {
+	props := jsonutils.NewDict()
+	props.Set(api.IMAGE_BUILD_ID, jsonutils.NewString(self.Id))
+	props.Set(api.IMAGE_BUILD_BASE_IMAGE_ID, jsonutils.NewString(self.BaseImageId))
+	props.Set(api.IMAGE_BUILD_BASE_IMAGE_CHECKSUM, jsonutils.NewString(self.BaseImageChecksum))
+	props.Set(api.IMAGE_BUILD_RECIPE_SHA256, jsonutils.NewString(self.RecipeSha256))
+	return ImagePropertyManager.SaveProperties(ctx, userCred, image.Id, props)
+}
+
+func (self *SImageBuild) OnBuildFailed(ctx context.Context, userCred mcclient.TokenCredential, reason jsonutils.JSONObject) {
+	image, err := self.GetImage()
+	if err != nil {
+		log.Errorf("get image of build %s fail %s", self.Name, err)
+	} else if image.Status != api.IMAGE_STATUS_KILLED {
+		image.OnSaveFailed(ctx, userCred, reason)
+	}
+	self.SetStatus(userCred, api.IMAGE_BUILD_STATUS_FAILED, reason.String())
+}
+
+func (self *SImageBuild) ValidateDeleteCondition(ctx context.Context) error {
+	if self.Status == api.IMAGE_BUILD_STATUS_BUILDING {
+		return httperrors.NewInvalidStatusError("image build is in progress")
+	}
+	return self.SVirtualResourceBase.ValidateDeleteCondition(ctx)
+}
+
+func (self *SImageBuild) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, isList bool) (api.ImageBuildDetails, error) {
+	return api.ImageBuildDetails{}, nil
+}
+
+func (manager *SImageBuildManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ImageBuildDetails {
+	rows := make([]api.ImageBuildDetails, len(objs))
+	virtRows := manager.SVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	imageIds := make([]string, 0)
+	for i := range objs {
+		build := objs[i].(*SImageBuild)
+		imageIds = append(imageIds, build.BaseImageId)
+		if len(build.ImageId) > 0 {
+			imageIds = append(imageIds, build.ImageId)
+		}
+	}
+	images := make(map[string]SImage)
+	err := db.FetchStandaloneObjectsByIds(ImageManager, imageIds, &images)
+	if err != nil {
+		log.Errorf("FetchStandaloneObjectsByIds fail %s", err)
+	}
+	for i := range rows {
+		build := objs[i].(*SImageBuild)
+		rows[i] = api.ImageBuildDetails{
+			VirtualResourceDetails: virtRows[i],
+		}
+		if image, ok := images[build.BaseImageId]; ok {
+			rows[i].BaseImage = image.Name
+		}
+		if image, ok := images[build.ImageId]; ok {
+			rows[i].Image = image.Name
+		}
+	}
+	return rows
+}
+
+// 镜像构建列表
+func (manager *SImageBuildManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ImageBuildListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, query.VirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.ListItemFilter")
+	}
+	if len(query.BaseImage) > 0 {
+		imgObj, err := ImageManager.FetchByIdOrName(userCred, query.BaseImage)
+		if err != nil {
+			if errors.Cause(err) == sqlchemy.ErrEmptyQuery {
+				return nil, httperrors.NewResourceNotFoundError2(ImageManager.Keyword(), query.BaseImage)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		q = q.Equals("base_image_id", imgObj.GetId())
+	}
+	if len(query.Image) > 0 {
+		imgObj, err := ImageManager.FetchByIdOrName(userCred, query.Image)
+		if err != nil {
+			if errors.Cause(err) == sqlchemy.ErrEmptyQuery {
+				return nil, httperrors.NewResourceNotFoundError2(ImageManager.Keyword(), query.Image)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		q = q.Equals("image_id", imgObj.GetId())
+	}
+	return q, nil
+}
+
+func (manager *SImageBuildManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ImageBuildListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.VirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SImageBuildManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return manager.SVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+}
@@ -17,6 +17,7 @@ package models
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sort"
 	"strings"
 	"time"
@@ -40,6 +41,7 @@ import (
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/image/options"
 	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modules"
 	"yunion.io/x/onecloud/pkg/util/logclient"
 	"yunion.io/x/onecloud/pkg/util/rbacutils"
 	"yunion.io/x/onecloud/pkg/util/stringutils2"
@@ -71,9 +73,36 @@ type SGuestImage struct {
 	Protected tristate.TriState `nullable:"false" default:"true" list:"user" get:"user" create:"optional" update:"user"`
 }
 
+func (manager *SGuestImageManager) CustomizeHandlerInfo(info *appsrv.SHandlerInfo) {
+	manager.SSharableVirtualResourceBaseManager.CustomizeHandlerInfo(info)
+
+	switch info.GetName(nil) {
+	case "get_specific", "create":
+		info.SetProcessTimeout(time.Minute * 120).SetWorkerManager(imgStreamingWorkerMan)
+	}
+}
+
+// FetchCreateHeaderData allows uploading an ova with the image meta headers
+func (manager *SGuestImageManager) FetchCreateHeaderData(ctx context.Context, header http.Header) (jsonutils.JSONObject, error) {
+	return modules.FetchImageMeta(header), nil
+}
+
 func (manager *SGuestImageManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential,
 	ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data *jsonutils.JSONDict) (*jsonutils.JSONDict, error) {
 
+	diskFormat, _ := data.GetString("disk_format")
+	if utils.IsInStringArray(diskFormat, api.GuestImageImportFormats) {
+		// the number of sub images is known after parsing the ovf, quota is checked in the import task
+		copyFrom := guestImageCopyFrom(ctx, data)
+		if len(copyFrom) == 0 {
+			appParams := appsrv.AppContextGetParams(ctx)
+			if diskFormat == api.GUEST_IMAGE_IMPORT_FORMAT_OVF || appParams == nil || appParams.Request.ContentLength <= 0 {
+				return nil, httperrors.NewMissingParameterError("copy_from")
+			}
+		}
+		return data, nil
+	}
+
 	if !data.Contains("image_number") {
 		return nil, httperrors.NewMissingParameterError("image_number")
 	}
@@ -105,6 +134,10 @@ func (gi *SGuestImage) PostCreate(ctx context.Context, userCred mcclient.TokenCr
 	ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
 
 	kwargs := data.(*jsonutils.JSONDict)
+	if diskFormat, _ := kwargs.GetString("disk_format"); utils.IsInStringArray(diskFormat, api.GuestImageImportFormats) {
+		gi.postCreateImport(ctx, userCred, diskFormat, guestImageCopyFrom(ctx, kwargs))
+		return
+	}
 	// get image number
 	imageNumber, _ := kwargs.Int("image_number")
 	// deal public params
@@ -168,6 +201,34 @@ func (gi *SGuestImage) PostCreate(ctx context.Context, userCred mcclient.TokenCr
 	gi.SetStatus(userCred, api.IMAGE_STATUS_SAVING, "")
 }
 
+func guestImageCopyFrom(ctx context.Context, data jsonutils.JSONObject) string {
+	copyFrom, _ := data.GetString("copy_from")
+	if len(copyFrom) == 0 {
+		if appParams := appsrv.AppContextGetParams(ctx); appParams != nil {
+			copyFrom = appParams.Request.Header.Get(modules.IMAGE_META_COPY_FROM)
+		}
+	}
+	return copyFrom
+}
+
+func (gi *SGuestImage) postCreateImport(ctx context.Context, userCred mcclient.TokenCredential, format string, copyFrom string) {
+	if len(copyFrom) == 0 {
+		appParams := appsrv.AppContextGetParams(ctx)
+		gi.SetStatus(userCred, api.IMAGE_STATUS_SAVING, "create upload")
+		err := gi.saveImportSource(appParams.Request.Body)
+		if err != nil {
+			log.Errorf("save import source of guest image %s fail %s", gi.Name, err)
+			gi.SetStatus(userCred, api.IMAGE_STATUS_KILLED, fmt.Sprintf("create upload fail %s", err))
+			return
+		}
+	}
+	err := gi.StartImportTask(ctx, userCred, format, copyFrom)
+	if err != nil {
+		log.Errorf("start import task of guest image %s fail %s", gi.Name, err)
+		gi.SetStatus(userCred, api.IMAGE_STATUS_KILLED, err.Error())
+	}
+}
+
 func (gi *SGuestImage) ValidateDeleteCondition(ctx context.Context) error {
 	if gi.Protected.IsTrue() {
 		return httperrors.NewForbiddenError("image is protected")
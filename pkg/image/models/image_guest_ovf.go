@@ -0,0 +1,407 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/image"
+	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/image/options"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+	"yunion.io/x/onecloud/pkg/util/ovfutils"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+)
+
+const ovaSourceName = "source.ova"
+
+func (gi *SGuestImage) getImportWorkspace() string {
+	return filepath.Join(options.Options.FilesystemStoreDatadir, "ovf_import", gi.Id)
+}
+
+// saveImportSource saves the uploaded ova into the import workspace
+func (gi *SGuestImage) saveImportSource(reader io.Reader) error {
+	workspace := gi.getImportWorkspace()
+	err := os.MkdirAll(workspace, 0755)
+	if err != nil {
+		return errors.Wrap(err, "create import workspace")
+	}
+	return downloadToFile(reader, filepath.Join(workspace, ovaSourceName))
+}
+
+func (gi *SGuestImage) StartImportTask(ctx context.Context, userCred mcclient.TokenCredential, format string, copyFrom string) error {
+	params := jsonutils.NewDict()
+	params.Set("format", jsonutils.NewString(format))
+	if len(copyFrom) > 0 {
+		params.Set("copy_from", jsonutils.NewString(copyFrom))
+	}
+	gi.SetStatus(userCred, api.IMAGE_STATUS_SAVING, "import "+format)
+	task, err := taskman.TaskManager.NewTask(ctx, "GuestImageImportTask", gi, userCred, params, "", "", nil)
+	if err != nil {
+		return err
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
+func downloadToFile(reader io.Reader, path string) error {
+	fp, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	_, err = io.Copy(fp, reader)
+	return err
+}
+
+func downloadUrl(ctx context.Context, srcUrl string, path string) error {
+	client := httputils.GetTimeoutClient(0)
+	transport := httputils.GetTransport(true)
+	transport.Proxy = options.Options.HttpTransportProxyFunc()
+	client.Transport = transport
+	resp, err := httputils.Request(client, ctx, httputils.GET, srcUrl, http.Header{}, nil, false)
+	if err != nil {
+		return errors.Wrapf(err, "request %s", srcUrl)
+	}
+	defer resp.Body.Close()
+	return downloadToFile(resp.Body, path)
+}
+
+// fetchImportSource prepares the ovf descriptor and the referenced disks in the workspace
+// and returns the path of the descriptor
+func (gi *SGuestImage) fetchImportSource(ctx context.Context, workspace string, format string, copyFrom string) (string, error) {
+	if format == api.GUEST_IMAGE_IMPORT_FORMAT_OVF {
+		// disks of an ovf are referenced relatively to the descriptor
+		base, err := url.Parse(copyFrom)
+		if err != nil {
+			return "", errors.Wrapf(err, "parse url %s", copyFrom)
+		}
+		ovfPath := filepath.Join(workspace, filepath.Base(base.Path))
+		err = downloadUrl(ctx, copyFrom, ovfPath)
+		if err != nil {
+			return "", err
+		}
+		desc, err := parseOvfFile(ovfPath)
+		if err != nil {
+			return "", err
+		}
+		for _, disk := range desc.Disks {
+			diskUrl, err := ovfutils.ResolveDiskUrl(base, disk.FileHref)
+			if err != nil {
+				return "", err
+			}
+			err = downloadUrl(ctx, diskUrl, filepath.Join(workspace, filepath.Base(disk.FileHref)))
+			if err != nil {
+				return "", err
+			}
+		}
+		return ovfPath, nil
+	}
+
+	ovaPath := filepath.Join(workspace, ovaSourceName)
+	if len(copyFrom) > 0 {
+		err := downloadUrl(ctx, copyFrom, ovaPath)
+		if err != nil {
+			return "", err
+		}
+	}
+	fp, err := os.Open(ovaPath)
+	if err != nil {
+		return "", errors.Wrap(err, "open ova")
+	}
+	defer os.Remove(ovaPath)
+	defer fp.Close()
+	return ovfutils.ExtractOva(fp, workspace)
+}
+
+func parseOvfFile(path string) (*ovfutils.SOvfDescriptor, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read ovf")
+	}
+	return ovfutils.ParseOvf(content)
+}
+
+// ImportOvf creates one sub image per disk described by the ovf, the first disk is the root image
+func (gi *SGuestImage) ImportOvf(ctx context.Context, task taskman.ITask, format string, copyFrom string) error {
+	userCred := task.GetUserCred()
+	workspace := gi.getImportWorkspace()
+	err := os.MkdirAll(workspace, 0755)
+	if err != nil {
+		return errors.Wrap(err, "create import workspace")
+	}
+	defer os.RemoveAll(workspace)
+
+	ovfPath, err := gi.fetchImportSource(ctx, workspace, format, copyFrom)
+	if err != nil {
+		return errors.Wrap(err, "fetch import source")
+	}
+	desc, err := parseOvfFile(ovfPath)
+	if err != nil {
+		return errors.Wrap(err, "parse ovf")
+	}
+	log.Infof("import guest image %s from ovf %s: %d disks", gi.Name, desc.Name, len(desc.Disks))
+	for _, disk := range desc.Disks {
+		err = ovfutils.CheckDiskHref(disk.FileHref)
+		if err != nil {
+			return errors.Wrapf(err, "check disk %s", disk.DiskId)
+		}
+	}
+
+	ownerId := gi.GetOwnerId()
+	pendingUsage := SQuota{Image: len(desc.Disks)}
+	pendingUsage.SetKeys(imageCreateInput2QuotaKeys("qcow2", ownerId))
+	err = quotas.CheckSetPendingQuota(ctx, userCred, &pendingUsage)
+	if err != nil {
+		return httperrors.NewOutOfQuotaError("%s", err)
+	}
+	defer quotas.CancelPendingUsage(ctx, userCred, &pendingUsage, &pendingUsage, true)
+
+	for i, disk := range desc.Disks {
+		image, err := gi.createOvfSubImage(ctx, userCred, ownerId, i)
+		if err != nil {
+			return errors.Wrapf(err, "create sub image of disk %s", disk.DiskId)
+		}
+		err = image.importOvfDisk(ctx, task, desc, filepath.Join(workspace, filepath.Base(disk.FileHref)))
+		if err != nil {
+			image.OnSaveTaskFailed(task, userCred, jsonutils.NewString(err.Error()))
+			return errors.Wrapf(err, "import disk %s", disk.DiskId)
+		}
+	}
+	return nil
+}
+
+// OnImportFailed kills the sub images already created so that the status of the guest image stays killed
+func (gi *SGuestImage) OnImportFailed(ctx context.Context, userCred mcclient.TokenCredential, reason jsonutils.JSONObject) {
+	images, err := GuestImageJointManager.GetImagesByGuestImageId(gi.Id)
+	if err != nil {
+		log.Errorf("get sub images of %s fail %s", gi.Id, err)
+	}
+	for i := range images {
+		if images[i].Status != api.IMAGE_STATUS_KILLED {
+			images[i].OnSaveFailed(ctx, userCred, reason)
+		}
+	}
+	gi.SetStatus(userCred, api.IMAGE_STATUS_KILLED, reason.String())
+}
+
+func (gi *SGuestImage) createOvfSubImage(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, index int) (*SImage, error) {
+	params := jsonutils.NewDict()
+	params.Set("is_guest_image", jsonutils.JSONTrue)
+	params.Set("disk_format", jsonutils.NewString(string(qemuimg.QCOW2)))
+	if len(gi.OsArch) > 0 {
+		params.Set("os_arch", jsonutils.NewString(gi.OsArch))
+	}
+	if index == 0 {
+		params.Set("generate_name", jsonutils.NewString(fmt.Sprintf("%s-%s", gi.Name, "root")))
+	} else {
+		params.Set("generate_name", jsonutils.NewString(fmt.Sprintf("%s-%s-%d", gi.Name, "data", index-1)))
+		params.Set("is_data", jsonutils.JSONTrue)
+	}
+	model, err := db.DoCreate(ImageManager, ctx, userCred, nil, params, ownerId)
+	if err != nil {
+		return nil, err
+	}
+	image := model.(*SImage)
+	_, err = GuestImageJointManager.CreateGuestImageJoint(ctx, gi.Id, image.Id)
+	if err != nil {
+		image.OnJointFailed(ctx, userCred)
+		return nil, errors.Wrap(err, "create guest image joint")
+	}
+	return image, nil
+}
+
+// importOvfDisk converts the disk into qcow2 and saves it as the content of the sub image
+func (self *SImage) importOvfDisk(ctx context.Context, task taskman.ITask, desc *ovfutils.SOvfDescriptor, diskPath string) error {
+	userCred := task.GetUserCred()
+	self.SetStatus(userCred, api.IMAGE_STATUS_SAVING, "import ovf disk")
+
+	img, err := ovfutils.OpenDiskImage(diskPath)
+	if err != nil {
+		return errors.Wrap(err, "open disk")
+	}
+	if !img.IsValid() {
+		return errors.Wrapf(errors.ErrInvalidStatus, "invalid disk %s", filepath.Base(diskPath))
+	}
+	qcow2Path := diskPath + ".qcow2"
+	_, err = img.CloneQcow2(qcow2Path, true)
+	if err != nil {
+		return errors.Wrap(err, "convert to qcow2")
+	}
+	os.Remove(diskPath)
+	defer os.Remove(qcow2Path)
+
+	fp, err := os.Open(qcow2Path)
+	if err != nil {
+		return errors.Wrap(err, "open qcow2")
+	}
+	defer fp.Close()
+	err = self.SaveImageFromStream(fp, true)
+	if err != nil {
+		return errors.Wrap(err, "save image")
+	}
+	if desc.MemoryMb > 0 {
+		_, err = db.Update(self, func() error {
+			self.MinRamMB = int32(desc.MemoryMb)
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "update min ram")
+		}
+	}
+	self.OnSaveTaskSuccess(task, userCred, "import ovf disk success")
+
+	if self.IsData.IsTrue() {
+		self.SetStatus(userCred, api.IMAGE_STATUS_ACTIVE, "data disk image import success")
+		return nil
+	}
+	props := jsonutils.NewDict()
+	props.Set(api.IMAGE_OS_TYPE, jsonutils.NewString(desc.OsType))
+	if desc.Firmware == ovfutils.FIRMWARE_UEFI {
+		props.Set(api.IMAGE_UEFI_SUPPORT, jsonutils.JSONTrue)
+	}
+	if desc.CpuCount > 0 {
+		props.Set(api.IMAGE_VCPU_COUNT, jsonutils.NewInt(int64(desc.CpuCount)))
+	}
+	props.Set(api.IMAGE_NIC_COUNT, jsonutils.NewInt(int64(len(desc.Nics))))
+	err = ImagePropertyManager.SaveProperties(ctx, userCred, self.Id, props)
+	if err != nil {
+		return errors.Wrap(err, "save properties")
+	}
+	return self.ImageProbeAndCustomization(ctx, userCred, false)
+}
+
+func (gi *SGuestImage) AllowGetDetailsOva(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return gi.IsOwner(userCred) || db.IsAdminAllowGetSpec(userCred, gi, "ova")
+}
+
+// GetDetailsOva streams the guest image as an ova archive, sub images are converted
+// into streamOptimized vmdk
+func (gi *SGuestImage) GetDetailsOva(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if gi.Status != api.IMAGE_STATUS_ACTIVE {
+		return nil, httperrors.NewInvalidStatusError("cannot export in status %s", gi.Status)
+	}
+	images, err := GuestImageJointManager.GetImagesByGuestImageId(gi.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get sub images")
+	}
+	if len(images) == 0 {
+		return nil, httperrors.NewInvalidStatusError("guest image has no sub image")
+	}
+	// root image first, data images in the order of name
+	sort.Slice(images, func(i, j int) bool {
+		if images[i].IsData.IsTrue() != images[j].IsData.IsTrue() {
+			return images[j].IsData.IsTrue()
+		}
+		return images[i].Name < images[j].Name
+	})
+
+	workspace, err := ioutil.TempDir(options.Options.FilesystemStoreDatadir, "ovf-export-")
+	if err != nil {
+		return nil, errors.Wrap(err, "create export workspace")
+	}
+	defer os.RemoveAll(workspace)
+
+	desc := &ovfutils.SOvfDescriptor{
+		Name:     gi.Name,
+		OsType:   ovfutils.OS_TYPE_LINUX,
+		Firmware: ovfutils.FIRMWARE_BIOS,
+	}
+	files := make([]string, 0, len(images))
+	for i := range images {
+		image := &images[i]
+		if image.Status != api.IMAGE_STATUS_ACTIVE {
+			return nil, httperrors.NewInvalidStatusError("sub image %s in status %s", image.Name, image.Status)
+		}
+		img, err := qemuimg.NewQemuImage(image.GetLocalLocation())
+		if err != nil {
+			return nil, errors.Wrapf(err, "open sub image %s", image.Name)
+		}
+		vmdkPath := filepath.Join(workspace, fmt.Sprintf("%s-disk%d.vmdk", gi.Name, i+1))
+		_, err = img.CloneVmdk(vmdkPath, true)
+		if err != nil {
+			return nil, errors.Wrapf(err, "convert sub image %s", image.Name)
+		}
+		info, err := os.Stat(vmdkPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "stat vmdk")
+		}
+		desc.Disks = append(desc.Disks, ovfutils.SOvfDisk{
+			FileHref:      filepath.Base(vmdkPath),
+			FileSize:      info.Size(),
+			CapacityBytes: img.SizeBytes,
+		})
+		files = append(files, vmdkPath)
+		if i == 0 {
+			desc.MemoryMb = int(image.MinRamMB)
+			gi.fillOvfDescriptor(desc, image.Id)
+		}
+	}
+	ovf, err := desc.Generate()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate ovf")
+	}
+
+	appParams := appsrv.AppContextGetParams(ctx)
+	header := appParams.Response.Header()
+	header.Set("Content-Type", "application/x-tar")
+	header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", strconv.Quote(gi.Name+".ova")))
+	err = ovfutils.WriteOva(appParams.Response, gi.Name+".ovf", ovf, files)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return nil, nil
+}
+
+func (gi *SGuestImage) fillOvfDescriptor(desc *ovfutils.SOvfDescriptor, rootImageId string) {
+	props, err := ImagePropertyManager.GetProperties(rootImageId)
+	if err != nil {
+		log.Errorf("get properties of %s fail %s", rootImageId, err)
+		return
+	}
+	if props[api.IMAGE_OS_TYPE] == ovfutils.OS_TYPE_WINDOWS {
+		desc.OsType = ovfutils.OS_TYPE_WINDOWS
+	}
+	if utils.ToBool(props[api.IMAGE_UEFI_SUPPORT]) {
+		desc.Firmware = ovfutils.FIRMWARE_UEFI
+	}
+	desc.CpuCount, _ = strconv.Atoi(props[api.IMAGE_VCPU_COUNT])
+	nicCount, err := strconv.Atoi(props[api.IMAGE_NIC_COUNT])
+	if err != nil {
+		nicCount = 1
+	}
+	for i := 0; i < nicCount; i++ {
+		desc.Nics = append(desc.Nics, ovfutils.SOvfNic{})
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/image"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/seclib2"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+// SImageSigningKeyManager keeps public keys trusted to sign images
+type SImageSigningKeyManager struct {
+	db.SStandaloneResourceBaseManager
+	db.SEnabledResourceBaseManager
+}
+
+var ImageSigningKeyManager *SImageSigningKeyManager
+
+func init() {
+	ImageSigningKeyManager = &SImageSigningKeyManager{
+		SStandaloneResourceBaseManager: db.NewStandaloneResourceBaseManager(
+			SImageSigningKey{},
+			"image_signing_keys_tbl",
+			"image_signing_key",
+			"image_signing_keys",
+		),
+	}
+	ImageSigningKeyManager.SetVirtualObject(ImageSigningKeyManager)
+}
+
+type SImageSigningKey struct {
+	db.SStandaloneResourceBase
+	db.SEnabledResourceBase `nullable:"false" default:"true" create:"optional" list:"user"`
+
+	// PEM格式的RSA公钥
+	PublicKey string `type:"text" nullable:"false" list:"user" create:"admin_required"`
+	// 公钥指纹, 公钥DER编码的SHA256
+	Fingerprint string `width:"64" charset:"ascii" nullable:"false" index:"true" list:"user"`
+}
+
+func (manager *SImageSigningKeyManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.ImageSigningKeyCreateInput) (*jsonutils.JSONDict, error) {
+	var err error
+	input.StandaloneResourceCreateInput, err = manager.SStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StandaloneResourceCreateInput)
+	if err != nil {
+		return nil, err
+	}
+	input.PublicKey = strings.TrimSpace(input.PublicKey)
+	if len(input.PublicKey) == 0 {
+		return nil, httperrors.NewMissingParameterError("public_key")
+	}
+	fingerprint, err := seclib2.PublicKeyFingerprint(input.PublicKey)
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("invalid public_key: %s", err)
+	}
+	cnt, err := manager.Query().Equals("fingerprint", fingerprint).CountWithError()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return nil, httperrors.NewDuplicateResourceError("public key with fingerprint %s already exists", fingerprint)
+	}
+	data := jsonutils.Marshal(input).(*jsonutils.JSONDict)
+	data.Set("fingerprint", jsonutils.NewString(fingerprint))
+	return data, nil
+}
+
+func (self *SImageSigningKey) AllowPerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) bool {
+	return db.IsAdminAllowPerform(userCred, self, "enable")
+}
+
+func (self *SImageSigningKey) PerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(self, ctx, userCred, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func (self *SImageSigningKey) AllowPerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) bool {
+	return db.IsAdminAllowPerform(userCred, self, "disable")
+}
+
+// 禁用后, 宿主机不再信任由该公钥签名的镜像
+func (self *SImageSigningKey) PerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(self, ctx, userCred, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func (self *SImageSigningKey) getImageCount() (int, error) {
+	return ImageManager.Query().Equals("signing_key_id", self.Id).CountWithError()
+}
+
+func (self *SImageSigningKey) ValidateDeleteCondition(ctx context.Context) error {
+	cnt, err := self.getImageCount()
+	if err != nil {
+		return httperrors.NewInternalServerError("getImageCount fail %s", err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("key has been used to sign %d images", cnt)
+	}
+	return self.SStandaloneResourceBase.ValidateDeleteCondition(ctx)
+}
+
+func (self *SImageSigningKey) GetExtraDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, isList bool) (api.ImageSigningKeyDetails, error) {
+	return api.ImageSigningKeyDetails{}, nil
+}
+
+func (manager *SImageSigningKeyManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.ImageSigningKeyDetails {
+	rows := make([]api.ImageSigningKeyDetails, len(objs))
+	stdRows := manager.SStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.ImageSigningKeyDetails{
+			StandaloneResourceDetails: stdRows[i],
+		}
+		rows[i].ImageCount, _ = objs[i].(*SImageSigningKey).getImageCount()
+	}
+	return rows
+}
+
+// 镜像签名公钥列表
+func (manager *SImageSigningKeyManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ImageSigningKeyListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneResourceBaseManager.ListItemFilter")
+	}
+	q, err = manager.SEnabledResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledResourceBaseManager.ListItemFilter")
+	}
+	if len(query.Fingerprint) > 0 {
+		q = q.In("fingerprint", query.Fingerprint)
+	}
+	return q, nil
+}
+
+func (manager *SImageSigningKeyManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.ImageSigningKeyListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SImageSigningKeyManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return manager.SStandaloneResourceBaseManager.QueryDistinctExtraField(q, field)
+}
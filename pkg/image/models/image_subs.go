@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
 
 	api "yunion.io/x/onecloud/pkg/apis/image"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
@@ -61,6 +62,7 @@ type SImageSubformat struct {
 	Size     int64  `nullable:"true"`
 	Location string `nullable:"true"`
 	Checksum string `width:"32" charset:"ascii" nullable:"true"`
+	Sha256   string `width:"64" charset:"ascii" nullable:"true"`
 	FastHash string `width:"32" charset:"ascii" nullable:"true"`
 	Status   string `nullable:"false"`
 
@@ -153,10 +155,16 @@ func (self *SImageSubformat) Save(image *SImage) error {
 		log.Errorf("fileutils2.fastChecksum fail %s", err)
 		return err
 	}
+	sha256sum, err := fileutils2.SHA256(location)
+	if err != nil {
+		log.Errorf("fileutils2.SHA256 fail %s", err)
+		return err
+	}
 	_, err = db.Update(self, func() error {
 		self.Location = fmt.Sprintf("%s%s", LocalFilePrefix, location)
 		self.Checksum = checksum
 		self.FastHash = fastHash
+		self.Sha256 = sha256sum
 		self.Size = nimg.ActualSizeBytes
 		return nil
 	})
@@ -167,6 +175,18 @@ func (self *SImageSubformat) Save(image *SImage) error {
 	return nil
 }
 
+func (self *SImageSubformat) saveSha256() error {
+	sha256sum, err := fileutils2.SHA256(self.GetLocalLocation())
+	if err != nil {
+		return errors.Wrap(err, "SHA256")
+	}
+	_, err = db.Update(self, func() error {
+		self.Sha256 = sha256sum
+		return nil
+	})
+	return err
+}
+
 func (self *SImageSubformat) SaveTorrent() error {
 	if self.TorrentStatus == api.IMAGE_STATUS_ACTIVE {
 		return nil
@@ -253,6 +273,7 @@ type SImageSubformatDetails struct {
 	Size     int64
 	Checksum string
 	FastHash string
+	Sha256   string
 	Status   string
 
 	TorrentSize     int64
@@ -269,6 +290,7 @@ func (self *SImageSubformat) GetDetails() SImageSubformatDetails {
 	details.Size = self.Size
 	details.Checksum = self.Checksum
 	details.FastHash = self.FastHash
+	details.Sha256 = self.Sha256
 	details.Status = self.Status
 	details.TorrentSize = self.TorrentSize
 	details.TorrentChecksum = self.TorrentChecksum
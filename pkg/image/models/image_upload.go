@@ -0,0 +1,211 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/image"
+	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+)
+
+// Resumable chunked upload of image content:
+//   POST /images/<id>/start-upload              start or resume an upload session, returns upload_offset
+//   PUT  /images/<id>/upload-chunk?offset=<n>   append a chunk which must start at upload_offset
+//   POST /images/<id>/finish-upload             verify size and checksum, then save the image in a task
+//   POST /images/<id>/abort-upload              drop the uploaded data
+// The session is the partial file itself, so an interrupted upload resumes from its size.
+
+const uploadFileFormat = "upload"
+
+func (self *SImage) getUploadPath() string {
+	return self.GetPath(uploadFileFormat)
+}
+
+func (self *SImage) getUploadOffset() (int64, error) {
+	fi, err := os.Stat(self.getUploadPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return -1, httperrors.NewInvalidStatusError("upload session not started")
+		}
+		return -1, errors.Wrap(err, "stat upload file")
+	}
+	return fi.Size(), nil
+}
+
+func (self *SImage) removeUploadFile() {
+	err := os.Remove(self.getUploadPath())
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorf("remove upload file of image %s fail %s", self.Id, err)
+	}
+}
+
+func (self *SImage) validateUploadStatus() error {
+	if self.Status != api.IMAGE_STATUS_QUEUED {
+		return httperrors.NewInvalidStatusError("cannot upload in status %s", self.Status)
+	}
+	if self.IsGuestImage.IsTrue() {
+		return httperrors.NewForbiddenError("image is the part of guest image")
+	}
+	return nil
+}
+
+func (self *SImage) AllowPerformStartUpload(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "start-upload")
+}
+
+// 开始或恢复分片上传, 返回已上传的字节数
+func (self *SImage) PerformStartUpload(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	err := self.validateUploadStatus()
+	if err != nil {
+		return nil, err
+	}
+	fp, err := os.OpenFile(self.getUploadPath(), os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "create upload file"))
+	}
+	fp.Close()
+	offset, err := self.getUploadOffset()
+	if err != nil {
+		return nil, err
+	}
+	return jsonutils.Marshal(api.ImageUploadSession{UploadOffset: offset}), nil
+}
+
+func (self *SImage) AllowUpdateUploadChunk(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowUpdateSpec(userCred, self, "upload-chunk")
+}
+
+// 上传一个分片, 分片偏移必须等于已上传的字节数
+func (self *SImage) UpdateUploadChunk(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	err := self.validateUploadStatus()
+	if err != nil {
+		return nil, err
+	}
+	if !query.Contains("offset") {
+		return nil, httperrors.NewMissingParameterError("offset")
+	}
+	offset, err := query.Int("offset")
+	if err != nil {
+		return nil, httperrors.NewInputParameterError("invalid offset: %s", err)
+	}
+	current, err := self.getUploadOffset()
+	if err != nil {
+		return nil, err
+	}
+	if offset != current {
+		return nil, httperrors.NewConflictError("chunk offset %d mismatch upload offset %d", offset, current)
+	}
+
+	appParams := appsrv.AppContextGetParams(ctx)
+	fp, err := os.OpenFile(self.getUploadPath(), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "open upload file"))
+	}
+	defer fp.Close()
+	// data written before an interruption is kept, the client resumes from the new upload offset
+	_, err = io.Copy(fp, appParams.Request.Body)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "write chunk"))
+	}
+	current, err = self.getUploadOffset()
+	if err != nil {
+		return nil, err
+	}
+	return jsonutils.Marshal(api.ImageUploadSession{UploadOffset: current}), nil
+}
+
+func (self *SImage) AllowPerformFinishUpload(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ImageFinishUploadInput) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "finish-upload")
+}
+
+// 完成分片上传, 异步校验大小及MD5校验和后保存镜像
+func (self *SImage) PerformFinishUpload(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ImageFinishUploadInput) (jsonutils.JSONObject, error) {
+	err := self.validateUploadStatus()
+	if err != nil {
+		return nil, err
+	}
+	if len(input.Checksum) == 0 {
+		return nil, httperrors.NewMissingParameterError("checksum")
+	}
+	size, err := self.getUploadOffset()
+	if err != nil {
+		return nil, err
+	}
+	if input.Size > 0 && input.Size != size {
+		return nil, httperrors.NewInputParameterError("size %d mismatch uploaded size %d", input.Size, size)
+	}
+	db.OpsLog.LogEvent(self, db.ACT_SAVING, "finish upload", userCred)
+	self.SetStatus(userCred, api.IMAGE_STATUS_SAVING, "finish upload")
+	params := jsonutils.NewDict()
+	params.Set("checksum", jsonutils.NewString(strings.ToLower(input.Checksum)))
+	params.Set("size", jsonutils.NewInt(size))
+	task, err := taskman.TaskManager.NewTask(ctx, "ImageFinishUploadTask", self, userCred, params, "", "", nil)
+	if err != nil {
+		self.SetStatus(userCred, api.IMAGE_STATUS_QUEUED, "start finish upload task fail")
+		return nil, httperrors.NewGeneralError(err)
+	}
+	task.ScheduleRun(nil)
+	return nil, nil
+}
+
+// FinishUpload verifies the uploaded data and saves it as the image content.
+// Corrupted data can not be resumed, it is dropped and the image waits for a new upload
+func (self *SImage) FinishUpload(ctx context.Context, userCred mcclient.TokenCredential, checksum string, size int64) error {
+	uploadPath := self.getUploadPath()
+	uploaded, err := fileutils2.MD5(uploadPath)
+	if err != nil {
+		return errors.Wrap(err, "checksum upload file")
+	}
+	if uploaded != checksum {
+		self.removeUploadFile()
+		msg := fmt.Sprintf("checksum %s mismatch uploaded checksum %s", checksum, uploaded)
+		self.SetStatus(userCred, api.IMAGE_STATUS_QUEUED, msg)
+		return errors.Error(msg)
+	}
+	localPath := self.GetPath("")
+	err = os.Rename(uploadPath, localPath)
+	if err != nil {
+		return errors.Wrap(err, "rename upload file")
+	}
+	return self.saveImageInfo(localPath, size, uploaded)
+}
+
+func (self *SImage) AllowPerformAbortUpload(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "abort-upload")
+}
+
+// 放弃分片上传, 删除已上传的数据
+func (self *SImage) PerformAbortUpload(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	err := self.validateUploadStatus()
+	if err != nil {
+		return nil, err
+	}
+	self.removeUploadFile()
+	return nil, nil
+}
@@ -16,6 +16,8 @@ package models
 
 import (
 	"context"
+	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"math"
@@ -49,10 +51,12 @@ import (
 	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
 	"yunion.io/x/onecloud/pkg/mcclient/modules"
 	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/httputils"
 	"yunion.io/x/onecloud/pkg/util/logclient"
 	"yunion.io/x/onecloud/pkg/util/procutils"
 	"yunion.io/x/onecloud/pkg/util/qemuimg"
 	"yunion.io/x/onecloud/pkg/util/rbacutils"
+	"yunion.io/x/onecloud/pkg/util/seclib2"
 	"yunion.io/x/onecloud/pkg/util/streamutils"
 	"yunion.io/x/onecloud/pkg/util/stringutils2"
 )
@@ -142,13 +146,22 @@ type SImage struct {
 	// image copy from url, save origin checksum before probe
 	// 从镜像时长导入的镜像校验和
 	OssChecksum string `width:"32" charset:"ascii" nullable:"true" get:"user" list:"user"`
+
+	// 镜像文件的SHA256, 签名时计算
+	Sha256 string `width:"64" charset:"ascii" nullable:"true" get:"user" list:"user"`
+	// 签名公钥ID
+	SigningKeyId string `width:"36" charset:"ascii" nullable:"true" get:"user" list:"user"`
+	// base64编码的镜像签名
+	Signature string `type:"text" nullable:"true" get:"user"`
+	// 签名状态, 可能值为: unsigned, verified, invalid
+	SignatureStatus string `width:"16" charset:"ascii" nullable:"false" default:"unsigned" get:"user" list:"user"`
 }
 
 func (manager *SImageManager) CustomizeHandlerInfo(info *appsrv.SHandlerInfo) {
 	manager.SSharableVirtualResourceBaseManager.CustomizeHandlerInfo(info)
 
 	switch info.GetName(nil) {
-	case "get_details", "create", "update":
+	case "get_details", "create", "update", "update_upload_chunk":
 		info.SetProcessTimeout(time.Minute * 120).SetWorkerManager(imgStreamingWorkerMan)
 	}
 }
@@ -240,13 +253,31 @@ func (self *SImage) CustomizedGetDetailsBody(ctx context.Context, userCred mccli
 		return nil, httperrors.NewInvalidStatusError("empty file path")
 	}
 
+	appParams := appsrv.AppContextGetParams(ctx)
+	appParams.Response.Header().Set("Accept-Ranges", "bytes")
+	if rangeStr := appParams.Request.Header.Get("Range"); len(rangeStr) > 0 {
+		size, err := GetImageSize(filePath)
+		if err != nil {
+			return nil, errors.Wrap(err, "get image size")
+		}
+		start, end, err := httputils.ParseRange(rangeStr, size)
+		if err == nil {
+			return nil, sendImageRange(appParams.Response, filePath, start, end, size)
+		}
+		if errors.Cause(err) == httputils.ErrRangeNotSatisfiable {
+			appParams.Response.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			return nil, httputils.NewJsonClientError(http.StatusRequestedRangeNotSatisfiable, string(httputils.ErrRangeNotSatisfiable), "%s", err)
+		}
+		// unsupported range, send the whole image
+		log.Debugf("ignore range of image %s: %s", self.Id, err)
+	}
+
 	size, rc, err := GetImage(filePath)
 	if err != nil {
 		return nil, errors.Wrap(err, "get image")
 	}
 	defer rc.Close()
 
-	appParams := appsrv.AppContextGetParams(ctx)
 	appParams.Response.Header().Set("Content-Length", strconv.FormatInt(size, 10))
 
 	_, err = streamutils.StreamPipe(rc, appParams.Response, false, nil)
@@ -257,6 +288,23 @@ func (self *SImage) CustomizedGetDetailsBody(ctx context.Context, userCred mccli
 	return nil, nil
 }
 
+func sendImageRange(w http.ResponseWriter, filePath string, start, end, size int64) error {
+	rc, err := GetImageRange(filePath, start, end)
+	if err != nil {
+		return errors.Wrap(err, "get image range")
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Range", httputils.FormatContentRange(start, end, size))
+	w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	w.WriteHeader(http.StatusPartialContent)
+	_, err = streamutils.StreamPipe(rc, w, false, nil)
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	return nil
+}
+
 func (self *SImage) getMoreDetails(out api.ImageDetails) api.ImageDetails {
 	properties, err := ImagePropertyManager.GetProperties(self.Id)
 	if err != nil {
@@ -354,6 +402,21 @@ func (self *SImage) GetExtraDetailsHeaders(ctx context.Context, userCred mcclien
 		}
 	}
 
+	if len(self.Signature) > 0 && len(self.Sha256) > 0 {
+		// the signature covers the original image, the served subformat is vouched by its own sha256
+		headers[fmt.Sprintf("%s%s", modules.IMAGE_META, "image_sha256")] = self.Sha256
+		if len(formatStr) > 0 {
+			if subimg := ImageSubformatManager.FetchSubImage(self.Id, formatStr); subimg != nil {
+				if len(subimg.Sha256) > 0 {
+					headers[fmt.Sprintf("%s%s", modules.IMAGE_META, "sha256")] = subimg.Sha256
+				} else {
+					// sha256 of original image doesn't match the subformat
+					delete(headers, fmt.Sprintf("%s%s", modules.IMAGE_META, "sha256"))
+				}
+			}
+		}
+	}
+
 	// none of subimage business
 	var ossChksum = self.OssChecksum
 	if len(self.OssChecksum) == 0 {
@@ -499,6 +562,16 @@ func (self *SImage) SaveImageFromStream(reader io.Reader, calChecksum bool) erro
 		return err
 	}
 
+	checksum := ""
+	if calChecksum {
+		checksum = sp.CheckSum
+	}
+	return self.saveImageInfo(localPath, sp.Size, checksum)
+}
+
+// saveImageInfo updates size, format and location of the image after its file is saved at localPath,
+// checksum and fast hash are updated only if checksum is given
+func (self *SImage) saveImageInfo(localPath string, size int64, checksum string) error {
 	virtualSizeBytes := int64(0)
 	format := ""
 	img, err := qemuimg.NewQemuImage(localPath)
@@ -509,7 +582,7 @@ func (self *SImage) SaveImageFromStream(reader io.Reader, calChecksum bool) erro
 	virtualSizeBytes = img.SizeBytes
 
 	var fastChksum string
-	if calChecksum {
+	if len(checksum) > 0 {
 		fastChksum, err = fileutils2.FastCheckSum(localPath)
 		if err != nil {
 			return err
@@ -517,11 +590,12 @@ func (self *SImage) SaveImageFromStream(reader io.Reader, calChecksum bool) erro
 	}
 
 	_, err = db.Update(self, func() error {
-		self.Size = sp.Size
-		if calChecksum {
-			self.Checksum = sp.CheckSum
+		self.Size = size
+		if len(checksum) > 0 {
+			self.Checksum = checksum
 			self.FastHash = fastChksum
 		}
+		self.ClearSignature()
 		self.Location = fmt.Sprintf("%s%s", LocalFilePrefix, localPath)
 		if len(format) > 0 {
 			self.DiskFormat = format
@@ -1016,6 +1090,7 @@ func (self *SImage) NewSubformat(ctx context.Context, format qemuimg.TImageForma
 		subformat.Size = self.Size
 		subformat.Checksum = self.Checksum
 		subformat.FastHash = self.FastHash
+		subformat.Sha256 = self.Sha256
 		subformat.Status = self.Status
 		subformat.Location = self.Location
 	} else {
@@ -1168,6 +1243,7 @@ func (self *SImage) RemoveFile() error {
 }
 
 func (self *SImage) Remove() error {
+	self.removeUploadFile()
 	subimgs := ImageSubformatManager.GetAllSubImages(self.Id)
 	for i := 0; i < len(subimgs); i += 1 {
 		err := subimgs[i].RemoveFiles()
@@ -1572,3 +1648,81 @@ func (img *SImage) PerformPrivate(ctx context.Context, userCred mcclient.TokenCr
 	}
 	return img.performPrivate(ctx, userCred, query, input)
 }
+
+// ClearSignature resets the signature as the image content is changed, caller should save the image
+func (self *SImage) ClearSignature() {
+	self.Sha256 = ""
+	self.SigningKeyId = ""
+	self.Signature = ""
+	self.SignatureStatus = api.IMAGE_SIGNATURE_UNSIGNED
+}
+
+func (self *SImage) AllowPerformSign(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ImageSignInput) bool {
+	return self.IsOwner(userCred) || db.IsAdminAllowPerform(userCred, self, "sign")
+}
+
+// 上传镜像签名, 使用受信任的公钥验证镜像文件并记录签名状态
+func (self *SImage) PerformSign(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ImageSignInput) (jsonutils.JSONObject, error) {
+	if self.Status != api.IMAGE_STATUS_ACTIVE {
+		return nil, httperrors.NewInvalidStatusError("cannot sign image in status %s", self.Status)
+	}
+	if len(input.SigningKey) == 0 {
+		return nil, httperrors.NewMissingParameterError("signing_key")
+	}
+	if len(input.Signature) == 0 {
+		return nil, httperrors.NewMissingParameterError("signature")
+	}
+	keyObj, err := ImageSigningKeyManager.FetchByIdOrName(userCred, input.SigningKey)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, httperrors.NewResourceNotFoundError2(ImageSigningKeyManager.Keyword(), input.SigningKey)
+		}
+		return nil, httperrors.NewGeneralError(err)
+	}
+	key := keyObj.(*SImageSigningKey)
+	if !key.GetEnabled() {
+		return nil, httperrors.NewForbiddenError("signing key %s is disabled", key.Name)
+	}
+	imagePath := self.GetLocalLocation()
+	if len(imagePath) == 0 {
+		return nil, httperrors.NewNotSupportedError("image at %s cannot be verified", self.Location)
+	}
+	sha256sum, err := fileutils2.SHA256(imagePath)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "SHA256"))
+	}
+	digest, _ := hex.DecodeString(sha256sum)
+	status := api.IMAGE_SIGNATURE_VERIFIED
+	verifyErr := seclib2.VerifySHA256Digest(key.PublicKey, digest, input.Signature)
+	if verifyErr != nil {
+		status = api.IMAGE_SIGNATURE_INVALID
+	}
+	_, err = db.Update(self, func() error {
+		self.Sha256 = sha256sum
+		self.SigningKeyId = key.Id
+		self.Signature = input.Signature
+		self.SignatureStatus = status
+		return nil
+	})
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "save signature"))
+	}
+	if verifyErr != nil {
+		db.OpsLog.LogEvent(self, db.ACT_SIGN_FAIL, verifyErr.Error(), userCred)
+		logclient.AddActionLogWithContext(ctx, self, logclient.ACT_IMAGE_SIGN, verifyErr.Error(), userCred, false)
+		return nil, httperrors.NewForbiddenError("signature does not match image with key %s: %s", key.Name, verifyErr)
+	}
+	// hosts download converted subformats, record their digests to be checked after download
+	for _, subimg := range ImageSubformatManager.GetAllSubImages(self.Id) {
+		if len(subimg.Sha256) > 0 || subimg.Status != api.IMAGE_STATUS_ACTIVE {
+			continue
+		}
+		err := subimg.saveSha256()
+		if err != nil {
+			log.Errorf("save sha256 of %s subformat %s fail: %s", self.Id, subimg.Format, err)
+		}
+	}
+	db.OpsLog.LogEvent(self, db.ACT_SIGN, key.Name, userCred)
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_IMAGE_SIGN, key.Name, userCred, true)
+	return nil, nil
+}
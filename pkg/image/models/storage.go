@@ -46,6 +46,30 @@ func GetImage(location string) (int64, io.ReadCloser, error) {
 	}
 }
 
+// GetImageSize returns the size of the image file without reading it
+func GetImageSize(location string) (int64, error) {
+	switch {
+	case strings.HasPrefix(location, image.S3Prefix):
+		return s3Instance.GetImageSize(location[len(image.S3Prefix):])
+	case strings.HasPrefix(location, image.LocalFilePrefix):
+		return local.GetImageSize(location[len(image.LocalFilePrefix):])
+	default:
+		return local.GetImageSize(location)
+	}
+}
+
+// GetImageRange reads the bytes from start to end, both inclusive, of the image file
+func GetImageRange(location string, start, end int64) (io.ReadCloser, error) {
+	switch {
+	case strings.HasPrefix(location, image.S3Prefix):
+		return s3Instance.GetImageRange(location[len(image.S3Prefix):], start, end)
+	case strings.HasPrefix(location, image.LocalFilePrefix):
+		return local.GetImageRange(location[len(image.LocalFilePrefix):], start, end)
+	default:
+		return local.GetImageRange(location, start, end)
+	}
+}
+
 func RemoveImage(location string) error {
 	switch {
 	case strings.HasPrefix(location, image.S3Prefix):
@@ -83,6 +107,8 @@ type Storage interface {
 	SaveImage(string) (string, error)
 	CleanTempfile(string) error
 	GetImage(string) (int64, io.ReadCloser, error)
+	GetImageSize(string) (int64, error)
+	GetImageRange(string, int64, int64) (io.ReadCloser, error)
 	RemoveImage(string) error
 
 	IsCheckStatusEnabled() bool
@@ -110,6 +136,30 @@ func (s *LocalStorage) GetImage(imagePath string) (int64, io.ReadCloser, error)
 	return fstat.Size(), f, nil
 }
 
+func (s *LocalStorage) GetImageSize(imagePath string) (int64, error) {
+	fstat, err := os.Stat(imagePath)
+	if err != nil {
+		return -1, errors.Wrapf(err, "stat file %s", imagePath)
+	}
+	return fstat.Size(), nil
+}
+
+type sectionReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (s *LocalStorage) GetImageRange(imagePath string, start, end int64) (io.ReadCloser, error) {
+	f, err := os.Open(imagePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open file %s", imagePath)
+	}
+	return &sectionReadCloser{
+		Reader: io.NewSectionReader(f, start, end-start+1),
+		Closer: f,
+	}, nil
+}
+
 func (s *LocalStorage) IsCheckStatusEnabled() bool {
 	return true
 }
@@ -149,6 +199,22 @@ func (s *S3Storage) GetImage(imagePath string) (int64, io.ReadCloser, error) {
 	return objInfo.Size, obj, nil
 }
 
+func (s *S3Storage) GetImageSize(imagePath string) (int64, error) {
+	objInfo, err := s3.Stat(imagePathToName(imagePath))
+	if err != nil {
+		return -1, errors.Wrap(err, "s3 stat image")
+	}
+	return objInfo.Size, nil
+}
+
+func (s *S3Storage) GetImageRange(imagePath string, start, end int64) (io.ReadCloser, error) {
+	rc, err := s3.GetRange(imagePathToName(imagePath), start, end)
+	if err != nil {
+		return nil, errors.Wrap(err, "s3 get image range")
+	}
+	return rc, nil
+}
+
 func (s *S3Storage) IsCheckStatusEnabled() bool {
 	return false
 }
@@ -71,9 +71,16 @@ func InitHandlers(app *appsrv.Application) {
 		models.ImageManager,
 
 		models.GuestImageManager,
+
+		models.ImageSigningKeyManager,
+
+		models.ImageBuildManager,
 	} {
 		db.RegisterModelManager(manager)
 		handler := db.NewModelHandler(manager)
 		dispatcher.AddModelDispatcher(API_VERSION, app, handler)
 	}
+
+	// chunks are streamed by a dedicated handler which runs with the image streaming workers
+	dispatcher.AddModelUpdateSpecDispatcher(API_VERSION, app, db.NewModelHandler(models.ImageManager), "upload-chunk")
 }
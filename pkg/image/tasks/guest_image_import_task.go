@@ -0,0 +1,62 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/image/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type GuestImageImportTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(GuestImageImportTask{})
+}
+
+func (self *GuestImageImportTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	guestImage := obj.(*models.SGuestImage)
+
+	format, _ := self.Params.GetString("format")
+	copyFrom, _ := self.Params.GetString("copy_from")
+
+	log.Infof("Import guest image %s from %s %s", guestImage.Name, format, copyFrom)
+
+	self.SetStage("OnImportComplete", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		return nil, guestImage.ImportOvf(ctx, self, format, copyFrom)
+	})
+}
+
+func (self *GuestImageImportTask) OnImportComplete(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	guestImage := obj.(*models.SGuestImage)
+	logclient.AddActionLogWithStartable(self, guestImage, logclient.ACT_IMAGE_SAVE, "import success", self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *GuestImageImportTask) OnImportCompleteFailed(ctx context.Context, obj db.IStandaloneModel, err jsonutils.JSONObject) {
+	guestImage := obj.(*models.SGuestImage)
+	guestImage.OnImportFailed(ctx, self.UserCred, err)
+	logclient.AddActionLogWithStartable(self, guestImage, logclient.ACT_IMAGE_SAVE, err, self.UserCred, false)
+	self.SetStageFailed(ctx, err)
+}
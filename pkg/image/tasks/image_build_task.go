@@ -0,0 +1,128 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/image"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	deployapi "yunion.io/x/onecloud/pkg/hostman/hostdeployer/apis"
+	"yunion.io/x/onecloud/pkg/hostman/hostdeployer/deployclient"
+	"yunion.io/x/onecloud/pkg/image/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+)
+
+type ImageBuildTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(ImageBuildTask{})
+}
+
+func (self *ImageBuildTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	build := obj.(*models.SImageBuild)
+
+	self.SetStage("OnBuildComplete", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		return nil, self.doBuild(ctx, build)
+	})
+}
+
+func (self *ImageBuildTask) doBuild(ctx context.Context, build *models.SImageBuild) error {
+	baseImage, err := build.GetBaseImage()
+	if err != nil {
+		return err
+	}
+	image, err := build.GetImage()
+	if err != nil {
+		return err
+	}
+	recipe, err := build.GetDeployRecipe()
+	if err != nil {
+		return err
+	}
+	if deployclient.GetDeployClient() == nil {
+		return fmt.Errorf("deploy client not init")
+	}
+	image.SetStatus(self.UserCred, api.IMAGE_STATUS_SAVING, "image build")
+
+	img, err := qemuimg.NewQemuImage(baseImage.GetLocalLocation())
+	if err != nil {
+		return errors.Wrap(err, "open base image")
+	}
+	buildPath := image.GetPath("build")
+	defer os.Remove(buildPath)
+	_, err = img.CloneQcow2(buildPath, false)
+	if err != nil {
+		return errors.Wrap(err, "clone base image")
+	}
+
+	log.Infof("Build image %s from %s", image.Name, baseImage.Name)
+	resp, err := deployclient.GetDeployClient().BuildImage(ctx, &deployapi.BuildImageParams{
+		DiskPath: buildPath,
+		Recipe:   recipe,
+	})
+	if err != nil {
+		return errors.Wrap(err, "BuildImage")
+	}
+	err = build.SaveBuildLog(resp.Log)
+	if err != nil {
+		log.Errorf("save build log of %s fail %s", build.Name, err)
+	}
+	if len(resp.ErrorMsg) > 0 {
+		return fmt.Errorf("build failed: %s", resp.ErrorMsg)
+	}
+
+	fp, err := os.Open(buildPath)
+	if err != nil {
+		return errors.Wrap(err, "open built image")
+	}
+	defer fp.Close()
+	err = image.SaveImageFromStream(fp, true)
+	if err != nil {
+		return errors.Wrap(err, "save image")
+	}
+	image.OnSaveTaskSuccess(self, self.UserCred, "image build success")
+
+	err = build.SaveProvenance(ctx, self.UserCred, image)
+	if err != nil {
+		return errors.Wrap(err, "save provenance")
+	}
+	return image.ImageProbeAndCustomization(ctx, self.UserCred, true)
+}
+
+func (self *ImageBuildTask) OnBuildComplete(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	build := obj.(*models.SImageBuild)
+	build.SetStatus(self.UserCred, api.IMAGE_BUILD_STATUS_READY, "")
+	logclient.AddActionLogWithStartable(self, build, logclient.ACT_IMAGE_SAVE, "build success", self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *ImageBuildTask) OnBuildCompleteFailed(ctx context.Context, obj db.IStandaloneModel, err jsonutils.JSONObject) {
+	build := obj.(*models.SImageBuild)
+	build.OnBuildFailed(ctx, self.UserCred, err)
+	logclient.AddActionLogWithStartable(self, build, logclient.ACT_IMAGE_SAVE, err, self.UserCred, false)
+	self.SetStageFailed(ctx, err)
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/image"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/image/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// ImageFinishUploadTask verifies the data of a chunked upload and saves it as the image,
+// the checksum of a large image takes too long to be computed in the request
+type ImageFinishUploadTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(ImageFinishUploadTask{})
+}
+
+func (self *ImageFinishUploadTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	image := obj.(*models.SImage)
+	checksum, _ := self.Params.GetString("checksum")
+	size, _ := self.Params.Int("size")
+
+	self.SetStage("OnUploadSaved", nil)
+	taskman.LocalTaskRun(self, func() (jsonutils.JSONObject, error) {
+		return nil, image.FinishUpload(ctx, self.UserCred, checksum, size)
+	})
+}
+
+func (self *ImageFinishUploadTask) OnUploadSaved(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	image := obj.(*models.SImage)
+	image.OnSaveTaskSuccess(self, self.UserCred, "finish upload success")
+	if image.IsData.IsTrue() {
+		// no probe
+		image.SetStatus(self.UserCred, api.IMAGE_STATUS_ACTIVE, "data disk image upload success")
+	} else {
+		image.ImageProbeAndCustomization(ctx, self.UserCred, true)
+	}
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *ImageFinishUploadTask) OnUploadSavedFailed(ctx context.Context, obj db.IStandaloneModel, err jsonutils.JSONObject) {
+	image := obj.(*models.SImage)
+	if image.Status == api.IMAGE_STATUS_QUEUED {
+		// corrupted upload was dropped, the image can be uploaded again
+		logclient.AddActionLogWithStartable(self, image, logclient.ACT_IMAGE_SAVE, err, self.UserCred, false)
+	} else {
+		image.OnSaveTaskFailed(self, self.UserCred, err)
+	}
+	self.SetStageFailed(ctx, err)
+}
@@ -118,6 +118,9 @@ func (self *ImageProbeTask) updateImageMetadata(
 	}
 
 	_, err = db.Update(image, func() error {
+		if image.Checksum != chksum {
+			image.ClearSignature()
+		}
 		image.Size = stat.Size()
 		image.Checksum = chksum
 		image.FastHash = fastchksum
@@ -136,6 +136,9 @@ var (
 					"scalinggroups",
 					"scalingactivities",
 					"scalingpolicies",
+					"stacks",
+					"approval_requests",
+					"usage_records",
 					"disks",
 					"networks",
 					"eips",
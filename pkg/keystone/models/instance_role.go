@@ -0,0 +1,71 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/tristate"
+
+	api "yunion.io/x/onecloud/pkg/apis/identity"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+)
+
+// EnsureInstanceRoleUser returns the system account acting as role for guests, the account is
+// created in domain of project on demand and joined into project with the role only,
+// so that tokens of the account carry exactly the role in project
+func (manager *SUserManager) EnsureInstanceRoleUser(ctx context.Context, project *SProject, role *SRole) (*SUser, error) {
+	name := api.InstanceRoleUserPrefix + role.Name
+	lockman.LockRawObject(ctx, manager.Keyword(), project.DomainId+"/"+name)
+	defer lockman.ReleaseRawObject(ctx, manager.Keyword(), project.DomainId+"/"+name)
+
+	usr := &SUser{}
+	usr.SetModelManager(manager, usr)
+	err := manager.Query().Equals("name", name).Equals("domain_id", project.DomainId).First(usr)
+	if err != nil {
+		if errors.Cause(err) != sql.ErrNoRows {
+			return nil, errors.Wrap(err, "query instance role user")
+		}
+		usr.Name = name
+		usr.DomainId = project.DomainId
+		usr.Enabled = tristate.True
+		usr.IsSystemAccount = tristate.True
+		usr.AllowWebConsole = tristate.False
+		usr.EnableMfa = tristate.False
+		usr.Description = "Instance role " + role.Name
+		err = manager.TableSpec().Insert(ctx, usr)
+		if err != nil {
+			return nil, errors.Wrap(err, "insert instance role user")
+		}
+		err = usr.initLocalData("")
+		if err != nil {
+			return nil, errors.Wrap(err, "initLocalData")
+		}
+	}
+
+	cnt, err := AssignmentManager.fetchUserProjectRoleIdsQuery(usr.Id, project.Id).Equals("role_id", role.Id).CountWithError()
+	if err != nil {
+		return nil, errors.Wrap(err, "query assignment")
+	}
+	if cnt == 0 {
+		err = AssignmentManager.add(ctx, api.AssignmentUserProject, usr.Id, project.Id, role.Id)
+		if err != nil {
+			return nil, errors.Wrap(err, "join instance role user into project")
+		}
+	}
+	return usr, nil
+}
@@ -29,6 +29,9 @@ type SKeystoneOptions struct {
 	FernetKeyRepository    string `help:"fernet key repo directory" token:"key_repository" default:"/etc/yunion/keystone/fernet-keys"`
 	SetupCredentialKeys    bool   `help:"setup standalone fernet keys for credentials" token:"setup_credential_key" default:"false" json:",allowfalse"`
 
+	// token of instance role is short-lived, it is rotated by host agent before expiration
+	InstanceTokenExpirationSeconds int `default:"3600" help:"expiration seconds of instance role token"`
+
 	BootstrapAdminUserPassword string `help:"bootstreap sysadmin user password" default:"sysadmin"`
 	ResetAdminUserPassword     bool   `help:"reset sysadmin password if exists and this option is true" json:",allowfalse"`
 
@@ -49,9 +49,46 @@ func authUserByToken(ctx context.Context, tokenStr string) (*api.SUserExtended,
 	if err != nil {
 		return nil, errors.Wrap(err, "token.ParseFernetToken")
 	}
+	if token.Method == api.AUTH_METHOD_INSTANCE {
+		// otherwise a short-lived instance token could be exchanged for a long-lived one
+		return nil, ErrInstanceToken
+	}
 	return models.UserManager.FetchUserExtended(token.UserId, "", "", "")
 }
 
+// authUserByInstanceV3 authenticates the system account of role bound to guest, the request must
+// come from system admin, i.e. region service minting tokens for host agents
+func authUserByInstanceV3(ctx context.Context, input mcclient.SAuthenticationInputV3) (*api.SUserExtended, error) {
+	caller, err := FernetTokenVerifier(ctx, input.Auth.Identity.Token.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, "verify caller token")
+	}
+	if !caller.HasSystemAdminPrivilege() {
+		return nil, ErrNotSystemAdmin
+	}
+	instance := input.Auth.Identity.Instance
+	if len(instance.GuestId) == 0 || len(instance.RoleId) == 0 {
+		return nil, ErrEmptyAuth
+	}
+	if len(input.Auth.Scope.Project.Id) == 0 {
+		return nil, httperrors.NewMissingParameterError("scope.project.id")
+	}
+	project, err := models.ProjectManager.FetchProjectById(input.Auth.Scope.Project.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, "ProjectManager.FetchProjectById")
+	}
+	role, err := models.RoleManager.FetchRoleById(instance.RoleId)
+	if err != nil {
+		return nil, errors.Wrap(err, "RoleManager.FetchRoleById")
+	}
+	usr, err := models.UserManager.EnsureInstanceRoleUser(ctx, project, role)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnsureInstanceRoleUser")
+	}
+	log.Infof("mint token of instance role %s in project %s for guest %s by %s", role.Name, project.Name, instance.GuestId, caller.GetUserName())
+	return models.UserManager.FetchUserExtended(usr.Id, "", "", "")
+}
+
 func authUserByPasswordV2(ctx context.Context, input mcclient.SAuthenticationInputV2) (*api.SUserExtended, error) {
 	ident := mcclient.SAuthenticationIdentity{}
 	ident.Methods = []string{api.AUTH_METHOD_PASSWORD}
@@ -421,6 +458,12 @@ func AuthenticateV3(ctx context.Context, input mcclient.SAuthenticationInputV3)
 		if err != nil {
 			return nil, errors.Wrap(err, "authUserByOAuth2")
 		}
+	case api.AUTH_METHOD_INSTANCE:
+		// auth by region on behalf of guest bound to instance role
+		user, err = authUserByInstanceV3(ctx, input)
+		if err != nil {
+			return nil, errors.Wrap(err, "authUserByInstanceV3")
+		}
 	default:
 		// auth by other methods, e.g. password , etc...
 		user, err = authUserByIdentityV3(ctx, input)
@@ -449,6 +492,10 @@ func AuthenticateV3(ctx context.Context, input mcclient.SAuthenticationInputV3)
 	now := time.Now().UTC()
 	token.ExpiresAt = now.Add(time.Duration(options.Options.TokenExpirationSeconds) * time.Second)
 	token.Context = input.Auth.Context
+	if method == api.AUTH_METHOD_INSTANCE {
+		token.ExpiresAt = now.Add(time.Duration(options.Options.InstanceTokenExpirationSeconds) * time.Second)
+		token.Context.Source = mcclient.AuthSourceInstance
+	}
 
 	if len(input.Auth.Scope.Project.Id) == 0 && len(input.Auth.Scope.Project.Name) == 0 && len(input.Auth.Scope.Domain.Id) == 0 && len(input.Auth.Scope.Domain.Name) == 0 {
 		// unscoped auth
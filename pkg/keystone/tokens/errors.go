@@ -29,4 +29,6 @@ const (
 	ErrUserNotInProject   = errors.Error("user not in project")
 	ErrInvalidAccessKeyId = errors.Error("invalid access key id")
 	ErrExpiredAccessKey   = errors.Error("expired access key")
+	ErrNotSystemAdmin     = errors.Error("not system admin")
+	ErrInstanceToken      = errors.Error("instance token can not be exchanged")
 )
@@ -168,6 +168,10 @@ func (t *SAuthToken) ParseFernetToken(tokenStr string) error {
 	if err != nil {
 		return errors.Wrap(err, "decode error")
 	}
+	// tokens may expire earlier than TokenExpirationSeconds, e.g. tokens of instance role
+	if !t.ExpiresAt.IsZero() && time.Now().After(t.ExpiresAt) {
+		return ErrExpiredToken
+	}
 	return nil
 }
 
@@ -252,6 +256,9 @@ func (t *SAuthToken) getTokenV3(
 	token.Token.AccessKey = akskInfo
 	token.Token.ExpiresAt = t.ExpiresAt
 	token.Token.IssuedAt = t.ExpiresAt.Add(-time.Duration(options.Options.TokenExpirationSeconds) * time.Second)
+	if t.Method == api.AUTH_METHOD_INSTANCE {
+		token.Token.IssuedAt = t.ExpiresAt.Add(-time.Duration(options.Options.InstanceTokenExpirationSeconds) * time.Second)
+	}
 	token.Token.AuditIds = t.AuditIds
 	token.Token.Methods = []string{t.Method}
 	token.Token.User.Id = user.Id
@@ -20,6 +20,7 @@ const (
 	AuthSourceCli      = "cli"
 	AuthSourceSrv      = "srv"
 	AuthSourceOperator = "operator"
+	AuthSourceInstance = "instance"
 )
 
 type SAuthContext struct {
@@ -32,6 +33,7 @@ type SAuthContext struct {
 	// | cli      | climc客户端认证           |
 	// | srv      | 作为服务认证              |
 	// | operator | 作为onecloud-operator认证 |
+	// | instance | 虚拟机实例角色认证        |
 	//
 	Source string `json:"source,omitempty"`
 	// 认证来源IP
@@ -83,6 +85,7 @@ type SAuthenticationIdentity struct {
 	// | saml     | 作为SAML 2.0 SP通过IDP认证                                            |
 	// | oidc     | 作为OpenID Connect/OAuth2 Client认证                                 |
 	// | oauth2   | OAuth2认证                                                          |
+	// | instance | 为绑定了实例角色的虚拟机签发短期token，仅限系统管理员调用                      |
 	//
 	Methods []string `json:"methods,omitempty"`
 	// 当认证方式为password时，通过该字段提供密码认证信息
@@ -128,6 +131,13 @@ type SAuthenticationIdentity struct {
 	OAuth2 struct {
 		Code string `json:"code,omitempty"`
 	}
+	// 当认证方式为instance时，通过该字段提供虚拟机及其绑定的角色，同时需要通过token字段提供调用者的管理员token
+	Instance struct {
+		// 虚拟机ID
+		GuestId string `json:"guest_id,omitempty"`
+		// 虚拟机绑定的角色ID
+		RoleId string `json:"role_id,omitempty"`
+	} `json:"instance,omitempty"`
 }
 
 type SAuthenticationInputV3 struct {
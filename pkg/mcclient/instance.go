@@ -0,0 +1,154 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcclient
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/identity"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+const (
+	DefaultMetadataServiceUrl = "http://169.254.169.254"
+
+	// path of metadata service serving token of instance role
+	InstanceCredentialPath = "/latest/meta-data/instance-credential"
+
+	instanceTokenRefreshAhead = 5 * time.Minute
+	metadataSessionTtlSeconds = 60
+)
+
+// AuthenticateInstance mints token of the role bound to guest, scoped to project, adminToken
+// must be a token of system admin
+func (this *Client) AuthenticateInstance(adminToken, guestId, roleId, projectId string) (TokenCredential, error) {
+	if this.AuthVersion() != "v3" {
+		return nil, httperrors.ErrNotSupported
+	}
+	input := SAuthenticationInputV3{}
+	input.Auth.Identity.Methods = []string{api.AUTH_METHOD_INSTANCE}
+	input.Auth.Identity.Token.Id = adminToken
+	input.Auth.Identity.Instance.GuestId = guestId
+	input.Auth.Identity.Instance.RoleId = roleId
+	input.Auth.Scope.Project.Id = projectId
+	input.Auth.Context = SAuthContext{Source: AuthSourceInstance}
+	return this._authV3Input(input)
+}
+
+// SInstanceCredentialProvider provides token of the instance role bound to the guest it runs in,
+// the token is fetched from metadata service and refreshed before it expires
+type SInstanceCredentialProvider struct {
+	metadataUrl string
+	httpClient  *http.Client
+
+	lock  sync.Mutex
+	token TokenCredential
+}
+
+func NewInstanceCredentialProvider(metadataUrl string) *SInstanceCredentialProvider {
+	if len(metadataUrl) == 0 {
+		metadataUrl = DefaultMetadataServiceUrl
+	}
+	return &SInstanceCredentialProvider{
+		metadataUrl: strings.TrimRight(metadataUrl, "/"),
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// GetToken returns the cached token, or fetches a new one if it is about to expire
+func (p *SInstanceCredentialProvider) GetToken(ctx context.Context) (TokenCredential, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.token != nil && time.Until(p.token.GetExpires()) > instanceTokenRefreshAhead {
+		return p.token, nil
+	}
+	token, err := p.fetchToken(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch instance credential")
+	}
+	p.token = token
+	return token, nil
+}
+
+// NewSession creates session of client with token of the instance role
+func (p *SInstanceCredentialProvider) NewSession(ctx context.Context, client *Client, region, zone, endpointType string) (*ClientSession, error) {
+	token, err := p.GetToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewSession(ctx, region, zone, endpointType, token, ""), nil
+}
+
+func (p *SInstanceCredentialProvider) do(ctx context.Context, method, path string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequest(method, p.metadataUrl+path, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewRequest")
+	}
+	req = req.WithContext(ctx)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s", method, path)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s %s", method, path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+func (p *SInstanceCredentialProvider) fetchToken(ctx context.Context) (TokenCredential, error) {
+	header := http.Header{}
+	header.Set("X-aws-ec2-metadata-token-ttl-seconds", fmt.Sprintf("%d", metadataSessionTtlSeconds))
+	session, err := p.do(ctx, "PUT", "/latest/api/token", header)
+	if err != nil {
+		return nil, errors.Wrap(err, "get metadata session token")
+	}
+	header = http.Header{}
+	header.Set("X-aws-ec2-metadata-token", string(session))
+	body, err := p.do(ctx, "GET", InstanceCredentialPath, header)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := jsonutils.Parse(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse instance credential")
+	}
+	token := &TokenCredentialV3{}
+	err = resp.Unmarshal(token, "token")
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal token")
+	}
+	if len(token.Id) == 0 {
+		return nil, errors.Error("empty token in instance credential")
+	}
+	return token, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import (
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+)
+
+type ApprovalPolicyManager struct {
+	modulebase.ResourceManager
+}
+
+type ApprovalRequestManager struct {
+	modulebase.ResourceManager
+}
+
+var (
+	ApprovalPolicies ApprovalPolicyManager
+	ApprovalRequests ApprovalRequestManager
+)
+
+func init() {
+	ApprovalPolicies = ApprovalPolicyManager{NewComputeManager("approval_policy", "approval_policies",
+		[]string{"ID", "Name", "Enabled", "Resource_type", "Actions", "Projects",
+			"Min_vcpu_count", "Min_vmem_size_mb", "Min_disk_size_mb", "Min_count", "Min_monthly_cost",
+			"Approvers", "Expire_hours", "Pending_count"},
+		[]string{})}
+
+	ApprovalRequests = ApprovalRequestManager{NewComputeManager("approval_request", "approval_requests",
+		[]string{"ID", "Name", "Status", "Resource_type", "Action", "Resource_id", "Size",
+			"Requester", "Approver", "Comment", "Policy", "Project", "Expired_at", "Created_at"},
+		[]string{})}
+
+	registerCompute(&ApprovalPolicies)
+	registerCompute(&ApprovalRequests)
+}
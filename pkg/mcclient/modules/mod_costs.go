@@ -0,0 +1,46 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import (
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+)
+
+type CostRateManager struct {
+	modulebase.ResourceManager
+}
+
+type UsageRecordManager struct {
+	modulebase.ResourceManager
+}
+
+var (
+	CostRates    CostRateManager
+	UsageRecords UsageRecordManager
+)
+
+func init() {
+	CostRates = CostRateManager{NewComputeManager("cost_rate", "cost_rates",
+		[]string{"ID", "Name", "Metric", "Spec", "Price", "Currency"},
+		[]string{})}
+
+	UsageRecords = UsageRecordManager{NewComputeManager("usage_record", "usage_records",
+		[]string{"ID", "Usage_hour", "Resource_type", "Metric", "Spec", "Tags",
+			"Resource_count", "Quantity", "Price", "Cost", "Currency", "Project"},
+		[]string{})}
+
+	registerCompute(&CostRates)
+	registerCompute(&UsageRecords)
+}
@@ -571,6 +571,38 @@ func (this *ImageManager) Download(s *mcclient.ClientSession, id string, format
 	}
 }
 
+// StartUpload starts or resumes a chunked upload of a queued image, returns the offset of the next chunk
+func (this *ImageManager) StartUpload(s *mcclient.ClientSession, id string) (int64, error) {
+	result, err := this.PerformAction(s, id, "start-upload", nil)
+	if err != nil {
+		return -1, err
+	}
+	return result.Int("upload_offset")
+}
+
+// UploadChunk uploads size bytes of body as the chunk at offset, returns the offset of the next chunk
+func (this *ImageManager) UploadChunk(s *mcclient.ClientSession, id string, offset int64, body io.Reader, size int64) (int64, error) {
+	path := fmt.Sprintf("/%s/%s/upload-chunk?offset=%d", this.URLPath(), url.PathEscape(id), offset)
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/octet-stream")
+	headers.Set("Content-Length", fmt.Sprintf("%d", size))
+	resp, err := modulebase.RawRequest(this.ResourceManager, s, "PUT", path, headers, body)
+	_, json, err := s.ParseJSONResponse("", resp, err)
+	if err != nil {
+		return -1, err
+	}
+	return json.Int("image", "upload_offset")
+}
+
+// FinishUpload verifies the size of the uploaded chunks, the image is saved after its md5 checksum
+// is verified in background, poll the image status for the result
+func (this *ImageManager) FinishUpload(s *mcclient.ClientSession, id string, size int64, checksum string) (jsonutils.JSONObject, error) {
+	params := jsonutils.NewDict()
+	params.Set("size", jsonutils.NewInt(size))
+	params.Set("checksum", jsonutils.NewString(checksum))
+	return this.PerformAction(s, id, "finish-upload", params)
+}
+
 var (
 	Images ImageManager
 )
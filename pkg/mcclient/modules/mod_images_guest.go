@@ -14,13 +14,67 @@
 
 package modules
 
-import "yunion.io/x/onecloud/pkg/mcclient/modulebase"
+import (
+	"fmt"
+	"io"
+	"net/url"
 
-var GuestImages modulebase.ResourceManager
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/modulebase"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+type GuestImageManager struct {
+	modulebase.ResourceManager
+}
+
+var GuestImages GuestImageManager
 
 func init() {
-	GuestImages = NewImageManager("guestimage", "guestimages",
+	GuestImages = GuestImageManager{NewImageManager("guestimage", "guestimages",
 		[]string{"ID", "Name", "Status", "Size"},
-		[]string{})
+		[]string{})}
 	register(&GuestImages)
 }
+
+// Import creates a guest image from an ova uploaded as body, or from the ova/ovf url given by copy_from
+func (this *GuestImageManager) Import(s *mcclient.ClientSession, params jsonutils.JSONObject, body io.Reader, size int64) (jsonutils.JSONObject, error) {
+	headers, err := setImageMeta(params)
+	if err != nil {
+		return nil, err
+	}
+	copyFromUrl, _ := params.GetString("copy_from")
+	if len(copyFromUrl) != 0 {
+		if size != 0 {
+			return nil, fmt.Errorf("Can't use copy_from and upload file at the same time")
+		}
+		body = nil
+		headers.Set(IMAGE_META_COPY_FROM, copyFromUrl)
+	}
+	if body != nil {
+		headers.Add("Content-Type", "application/octet-stream")
+		if size > 0 {
+			headers.Add("Content-Length", fmt.Sprintf("%d", size))
+		}
+	}
+	path := fmt.Sprintf("/%s", this.URLPath())
+	resp, err := modulebase.RawRequest(this.ResourceManager, s, httputils.POST, path, headers, body)
+	_, json, err := s.ParseJSONResponse("", resp, err)
+	if err != nil {
+		return nil, err
+	}
+	return json.Get(this.Keyword)
+}
+
+// ExportOva downloads the guest image as an ova archive
+func (this *GuestImageManager) ExportOva(s *mcclient.ClientSession, id string) (io.ReadCloser, error) {
+	path := fmt.Sprintf("/%s/%s/ova", this.URLPath(), url.PathEscape(id))
+	resp, err := modulebase.RawRequest(this.ResourceManager, s, httputils.GET, path, nil, nil)
+	if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.Body, nil
+	}
+	_, _, err = s.ParseJSONResponse("", resp, err)
+	return nil, err
+}
@@ -26,6 +26,9 @@ var (
 	Notification       modulebase.ResourceManager
 	NotifyTemplate     modulebase.ResourceManager
 	NotifySubscription modulebase.ResourceManager
+	OncallSchedule     modulebase.ResourceManager
+	EscalationPolicy   modulebase.ResourceManager
+	DeliveryAttempt    modulebase.ResourceManager
 	Configs            ConfigsManager
 )
 
@@ -69,4 +72,28 @@ func init() {
 		[]string{},
 	)
 	register(&NotifySubscription)
+
+	OncallSchedule = NewNotifyv2Manager(
+		"oncallschedule",
+		"oncallschedules",
+		[]string{"ID", "Name", "Enabled", "Participant_Names", "Shift_Hours", "Current_Oncall", "Next_Handoff_At"},
+		[]string{},
+	)
+	register(&OncallSchedule)
+
+	EscalationPolicy = NewNotifyv2Manager(
+		"escalationpolicy",
+		"escalationpolicies",
+		[]string{"ID", "Name", "Enabled", "Levels", "Min_Priority"},
+		[]string{},
+	)
+	register(&EscalationPolicy)
+
+	DeliveryAttempt = NewNotifyv2Manager(
+		"deliveryattempt",
+		"deliveryattempts",
+		[]string{"ID", "Notification_Id", "Receiver_Id", "Contact_Type", "Attempt", "Status", "Failed_Reason", "Created_At"},
+		[]string{},
+	)
+	register(&DeliveryAttempt)
 }
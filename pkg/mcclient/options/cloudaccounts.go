@@ -294,6 +294,18 @@ func (opts *SZStackCloudAccountCreateOptions) Params() (jsonutils.JSONObject, er
 	return params, nil
 }
 
+type SProxmoxCloudAccountCreateOptions struct {
+	SCloudAccountCreateBaseOptions
+	SAccessKeyCredential
+	AuthURL string `help:"Proxmox VE api url, e.g. https://192.168.1.10:8006" positional:"true" json:"auth_url"`
+}
+
+func (opts *SProxmoxCloudAccountCreateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.Marshal(opts)
+	params.(*jsonutils.JSONDict).Add(jsonutils.NewString("Proxmox"), "provider")
+	return params, nil
+}
+
 type SS3CloudAccountCreateOptions struct {
 	SCloudAccountCreateBaseOptions
 	SAccessKeyCredential
@@ -432,6 +444,15 @@ func (opts *SZStackCloudAccountUpdateCredentialOptions) Params() (jsonutils.JSON
 	return jsonutils.Marshal(opts), nil
 }
 
+type SProxmoxCloudAccountUpdateCredentialOptions struct {
+	SCloudAccountIdOptions
+	SAccessKeyCredential
+}
+
+func (opts *SProxmoxCloudAccountUpdateCredentialOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
 type SS3CloudAccountUpdateCredentialOptions struct {
 	SCloudAccountIdOptions
 	SAccessKeyCredential
@@ -733,6 +754,14 @@ func (opts *SZStackCloudAccountUpdateOptions) Params() (jsonutils.JSONObject, er
 	return jsonutils.Marshal(opts), nil
 }
 
+type SProxmoxCloudAccountUpdateOptions struct {
+	SCloudAccountUpdateBaseOptions
+}
+
+func (opts *SProxmoxCloudAccountUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
 type SS3CloudAccountUpdateOptions struct {
 	SCloudAccountUpdateBaseOptions
 }
@@ -803,6 +832,9 @@ type CloudaccountSyncOptions struct {
 	Region   []string `help:"region to sync"`
 	Zone     []string `help:"region to sync"`
 	Host     []string `help:"region to sync"`
+
+	DryRun                 bool `help:"Only plan the changes sync would make, show them with cloud-account-sync-plan"`
+	IgnoreRemovalThreshold bool `help:"Sync even if removals exceed cloud_sync_removal_threshold_percent"`
 }
 
 func (opts *CloudaccountSyncOptions) Params() (jsonutils.JSONObject, error) {
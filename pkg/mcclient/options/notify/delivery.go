@@ -0,0 +1,77 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type DeliveryAttemptListOptions struct {
+	options.BaseListOptions
+
+	NotificationId string `help:"Id of notification"`
+	ReceiverId     string `help:"Id of receiver"`
+	ContactType    string `help:"Contact type"`
+	Status         string `help:"Status of attempt" choices:"ok|failed"`
+}
+
+func (opts *DeliveryAttemptListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type DeliveryAttemptOptions struct {
+	ID string `help:"Id of delivery attempt"`
+}
+
+func (opts *DeliveryAttemptOptions) GetId() string {
+	return opts.ID
+}
+
+func (opts *DeliveryAttemptOptions) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+type NotificationDeadLetterListOptions struct {
+	NotificationId string `help:"Id of notification"`
+	ContactType    string `help:"Contact type"`
+	Limit          int    `help:"Max count of dead letters" default:"20"`
+	Offset         int    `help:"Offset of dead letters"`
+}
+
+func (opts *NotificationDeadLetterListOptions) GetId() string {
+	return "dead-letters"
+}
+
+func (opts *NotificationDeadLetterListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+type NotificationReplayDeadLettersOptions struct {
+	NotificationId []string `help:"Id of notification whose dead letters are replayed, all if not specified"`
+	ContactType    string   `help:"Replay dead letters of this contact type only"`
+}
+
+func (opts *NotificationReplayDeadLettersOptions) Params() (jsonutils.JSONObject, error) {
+	d := jsonutils.NewDict()
+	if len(opts.NotificationId) > 0 {
+		d.Set("notification_ids", jsonutils.NewStringArray(opts.NotificationId))
+	}
+	if len(opts.ContactType) > 0 {
+		d.Set("contact_type", jsonutils.NewString(opts.ContactType))
+	}
+	return d, nil
+}
@@ -0,0 +1,211 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type OncallScheduleListOptions struct {
+	options.BaseListOptions
+}
+
+func (opts *OncallScheduleListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type OncallScheduleOptions struct {
+	ID string `help:"Id or Name of oncall schedule"`
+}
+
+func (opts *OncallScheduleOptions) GetId() string {
+	return opts.ID
+}
+
+func (opts *OncallScheduleOptions) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+type OncallScheduleCreateOptions struct {
+	NAME          string   `help:"Name of oncall schedule"`
+	Participant   []string `help:"Id or Name of receiver taking turns to be on call, in order of rotation" required:"true"`
+	RotationStart string   `help:"Time of the first handoff, e.g. 2021-01-01T09:00:00Z"`
+	ShiftHours    int      `help:"Hours every participant stays on call" default:"24"`
+}
+
+func (opts *OncallScheduleCreateOptions) Params() (jsonutils.JSONObject, error) {
+	d := jsonutils.NewDict()
+	d.Set("name", jsonutils.NewString(opts.NAME))
+	d.Set("participants", jsonutils.NewStringArray(opts.Participant))
+	if len(opts.RotationStart) > 0 {
+		d.Set("rotation_start", jsonutils.NewString(opts.RotationStart))
+	}
+	d.Set("shift_hours", jsonutils.NewInt(int64(opts.ShiftHours)))
+	return d, nil
+}
+
+type OncallScheduleUpdateOptions struct {
+	OncallScheduleOptions
+
+	Participant   []string `help:"Id or Name of receiver taking turns to be on call, in order of rotation"`
+	RotationStart string   `help:"Time of the first handoff, e.g. 2021-01-01T09:00:00Z"`
+	ShiftHours    int      `help:"Hours every participant stays on call"`
+}
+
+func (opts *OncallScheduleUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	d := jsonutils.NewDict()
+	if len(opts.Participant) > 0 {
+		d.Set("participants", jsonutils.NewStringArray(opts.Participant))
+	}
+	if len(opts.RotationStart) > 0 {
+		d.Set("rotation_start", jsonutils.NewString(opts.RotationStart))
+	}
+	if opts.ShiftHours > 0 {
+		d.Set("shift_hours", jsonutils.NewInt(int64(opts.ShiftHours)))
+	}
+	return d, nil
+}
+
+type OncallScheduleAddOverrideOptions struct {
+	OncallScheduleOptions
+
+	RECEIVER string `help:"Id or Name of receiver on call during the override"`
+	StartAt  string `help:"Start of the override, default to now"`
+	END_AT   string `help:"End of the override, e.g. 2021-01-02T09:00:00Z"`
+}
+
+func (opts *OncallScheduleAddOverrideOptions) Params() (jsonutils.JSONObject, error) {
+	d := jsonutils.NewDict()
+	d.Set("receiver", jsonutils.NewString(opts.RECEIVER))
+	if len(opts.StartAt) > 0 {
+		d.Set("start_at", jsonutils.NewString(opts.StartAt))
+	}
+	d.Set("end_at", jsonutils.NewString(opts.END_AT))
+	return d, nil
+}
+
+type OncallScheduleRemoveOverrideOptions struct {
+	OncallScheduleOptions
+
+	OVERRIDE_ID string `help:"Id of override"`
+}
+
+func (opts *OncallScheduleRemoveOverrideOptions) Params() (jsonutils.JSONObject, error) {
+	d := jsonutils.NewDict()
+	d.Set("override_id", jsonutils.NewString(opts.OVERRIDE_ID))
+	return d, nil
+}
+
+type EscalationPolicyListOptions struct {
+	options.BaseListOptions
+}
+
+func (opts *EscalationPolicyListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type EscalationPolicyOptions struct {
+	ID string `help:"Id or Name of escalation policy"`
+}
+
+func (opts *EscalationPolicyOptions) GetId() string {
+	return opts.ID
+}
+
+func (opts *EscalationPolicyOptions) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+// parseEscalationLevels parses levels in format receivers=<r1>,<r2>;schedules=<s1>;timeout=<minutes>
+func parseEscalationLevels(levelStrs []string) (api.SEscalationLevels, error) {
+	levels := make(api.SEscalationLevels, 0, len(levelStrs))
+	for _, levelStr := range levelStrs {
+		level := api.SEscalationLevel{}
+		for _, seg := range strings.Split(levelStr, ";") {
+			if len(seg) == 0 {
+				continue
+			}
+			kv := strings.SplitN(seg, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid escalation level %q", levelStr)
+			}
+			switch kv[0] {
+			case "receivers":
+				level.Receivers = strings.Split(kv[1], ",")
+			case "schedules":
+				level.OncallSchedules = strings.Split(kv[1], ",")
+			case "timeout":
+				timeout, err := strconv.Atoi(kv[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid timeout of escalation level %q", levelStr)
+				}
+				level.TimeoutMinutes = timeout
+			default:
+				return nil, fmt.Errorf("unknown key %q of escalation level %q", kv[0], levelStr)
+			}
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+type EscalationPolicyCreateOptions struct {
+	NAME        string   `help:"Name of escalation policy"`
+	Level       []string `help:"Escalation level in order, e.g. \"receivers=zhangsan,lisi;schedules=sre-oncall;timeout=15\"" required:"true"`
+	MinPriority string   `help:"Only notifications of this priority or higher are escalated" choices:"normal|important|fatal"`
+}
+
+func (opts *EscalationPolicyCreateOptions) Params() (jsonutils.JSONObject, error) {
+	levels, err := parseEscalationLevels(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	d := jsonutils.NewDict()
+	d.Set("name", jsonutils.NewString(opts.NAME))
+	d.Set("levels", jsonutils.Marshal(levels))
+	if len(opts.MinPriority) > 0 {
+		d.Set("min_priority", jsonutils.NewString(opts.MinPriority))
+	}
+	return d, nil
+}
+
+type EscalationPolicyUpdateOptions struct {
+	EscalationPolicyOptions
+
+	Level       []string `help:"Escalation level in order, e.g. \"receivers=zhangsan,lisi;schedules=sre-oncall;timeout=15\""`
+	MinPriority string   `help:"Only notifications of this priority or higher are escalated" choices:"normal|important|fatal"`
+}
+
+func (opts *EscalationPolicyUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	d := jsonutils.NewDict()
+	if len(opts.Level) > 0 {
+		levels, err := parseEscalationLevels(opts.Level)
+		if err != nil {
+			return nil, err
+		}
+		d.Set("levels", jsonutils.Marshal(levels))
+	}
+	if len(opts.MinPriority) > 0 {
+		d.Set("min_priority", jsonutils.NewString(opts.MinPriority))
+	}
+	return d, nil
+}
@@ -58,6 +58,12 @@ type SreceiverUpdateOptions struct {
 	Mobile             string   `help:"mobile of receiver"`
 	MobileAreaCode     string   `help:"area code of mobile"`
 	EnabledContactType []string `help:"enabled contact type"`
+
+	DedupWindowSeconds         *int    `help:"drop notifications identical to one sent within these seconds, 0 to disable"`
+	DigestIntervalSeconds      *int    `help:"batch normal priority notifications into a digest sent every these seconds, 0 to disable"`
+	QuietHoursStart            *string `help:"start of quiet hours in local time, e.g. 22:00, empty to disable"`
+	QuietHoursEnd              *string `help:"end of quiet hours in local time, e.g. 08:00, empty to disable"`
+	QuietHoursOverridePriority string  `help:"notifications of this priority or higher are still sent in quiet hours" choices:"normal|important|fatal"`
 }
 
 func (ru *ReceiverUpdateOptions) Params() (jsonutils.JSONObject, error) {
@@ -70,6 +76,21 @@ func (ru *ReceiverUpdateOptions) Params() (jsonutils.JSONObject, error) {
 		d.Add(jsonutils.NewString(ru.Mobile), "international_mobile", "mobile")
 		d.Add(jsonutils.NewString(ru.MobileAreaCode), "international_mobile", "area_code")
 	}
+	if ru.DedupWindowSeconds != nil {
+		d.Set("dedup_window_seconds", jsonutils.NewInt(int64(*ru.DedupWindowSeconds)))
+	}
+	if ru.DigestIntervalSeconds != nil {
+		d.Set("digest_interval_seconds", jsonutils.NewInt(int64(*ru.DigestIntervalSeconds)))
+	}
+	if ru.QuietHoursStart != nil {
+		d.Set("quiet_hours_start", jsonutils.NewString(*ru.QuietHoursStart))
+	}
+	if ru.QuietHoursEnd != nil {
+		d.Set("quiet_hours_end", jsonutils.NewString(*ru.QuietHoursEnd))
+	}
+	if len(ru.QuietHoursOverridePriority) > 0 {
+		d.Set("quiet_hours_override_priority", jsonutils.NewString(ru.QuietHoursOverridePriority))
+	}
 	return d, nil
 }
 
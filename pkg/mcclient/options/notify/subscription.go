@@ -83,6 +83,17 @@ func (opts *SubscriptionSetRobotOptions) Params() (jsonutils.JSONObject, error)
 	return jsonutils.Marshal(opts.SsubscriptionSetRobotOptions), nil
 }
 
+type SubscriptionSetEscalationPolicyOptions struct {
+	SubscriptionOptions
+	EscalationPolicy string `help:"Id or Name of escalation policy, empty to unset"`
+}
+
+func (opts *SubscriptionSetEscalationPolicyOptions) Params() (jsonutils.JSONObject, error) {
+	d := jsonutils.NewDict()
+	d.Set("escalation_policy", jsonutils.NewString(opts.EscalationPolicy))
+	return d, nil
+}
+
 type SsubscriptionSetWebhookOptions struct {
 	WEBHOOK string `choices:"webhook"`
 }
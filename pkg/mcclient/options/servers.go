@@ -42,7 +42,7 @@ type ServerListOptions struct {
 	Gpu                *bool  `help:"Show gpu servers"`
 	Secgroup           string `help:"Secgroup ID or Name"`
 	AdminSecgroup      string `help:"AdminSecgroup ID or Name"`
-	Hypervisor         string `help:"Show server of hypervisor" choices:"kvm|esxi|container|baremetal|aliyun|azure|aws|huawei|ucloud|zstack|openstack|google|ctyun"`
+	Hypervisor         string `help:"Show server of hypervisor" choices:"kvm|esxi|container|baremetal|aliyun|azure|aws|huawei|ucloud|zstack|openstack|google|ctyun|proxmox"`
 	Region             string `help:"Show servers in cloudregion"`
 	WithEip            *bool  `help:"Show Servers with EIP"`
 	WithoutEip         *bool  `help:"Show Servers without EIP"`
@@ -216,7 +216,7 @@ type ServerConfigs struct {
 	Host       string `help:"Preferred host where virtual server should be created" json:"prefer_host"`
 	BackupHost string `help:"Perfered host where virtual backup server should be created"`
 
-	Hypervisor                   string `help:"Hypervisor type" choices:"kvm|esxi|baremetal|container|aliyun|azure|qcloud|aws|huawei|openstack|ucloud|zstack|google|ctyun"`
+	Hypervisor                   string `help:"Hypervisor type" choices:"kvm|esxi|baremetal|container|aliyun|azure|qcloud|aws|huawei|openstack|ucloud|zstack|google|ctyun|proxmox"`
 	ResourceType                 string `help:"Resource type" choices:"shared|prepaid|dedicated"`
 	Backup                       bool   `help:"Create server with backup server"`
 	AutoSwitchToBackupOnHostDown bool   `help:"Auto switch to backup server on host down"`
@@ -377,6 +377,7 @@ type ServerCreateOptionalOptions struct {
 	TaskNotify       *bool    `help:"Setup task notify" json:"-"`
 	DryRun           *bool    `help:"Dry run to test scheduler" json:"-"`
 	UserDataFile     string   `help:"user_data file path" json:"-"`
+	ConfigDrive      string   `help:"attach config drive with the volume label, KVM only" choices:"config-2|cidata"`
 	InstanceSnapshot string   `help:"instance snapshot" json:"instance_snapshot"`
 	Secgroups        []string `help:"secgroups" json:"secgroups"`
 
@@ -482,6 +483,7 @@ func (opts *ServerCreateOptionalOptions) OptionalParams() (*computeapi.ServerCre
 		OsType:             opts.OsType,
 		GuestImageID:       opts.GuestImageID,
 		Secgroups:          opts.Secgroups,
+		ConfigDrive:        opts.ConfigDrive,
 	}
 
 	if regutils.MatchSize(opts.MemSpec) {
@@ -903,6 +905,48 @@ func (o *ServerLiveMigrateOptions) Params() (jsonutils.JSONObject, error) {
 	return StructToParams(o)
 }
 
+type ServerChangeDiskStorageOptions struct {
+	ID             string `help:"ID or name of server" json:"-"`
+	DISKID         string `help:"ID or name of disk to change storage" json:"disk_id"`
+	TARGETSTORAGE  string `help:"ID or name of target storage" json:"target_storage_id"`
+	KeepOriginDisk bool   `help:"Keep origin disk after changed" json:"keep_origin_disk"`
+}
+
+func (o *ServerChangeDiskStorageOptions) GetId() string {
+	return o.ID
+}
+
+func (o *ServerChangeDiskStorageOptions) Params() (jsonutils.JSONObject, error) {
+	return StructToParams(o)
+}
+
+type ServerSetHaPriorityOptions struct {
+	ID       string `help:"ID or name of server" json:"-"`
+	PRIORITY int    `help:"Restart priority on host down, larger is earlier, 0-100" json:"priority"`
+}
+
+func (o *ServerSetHaPriorityOptions) GetId() string {
+	return o.ID
+}
+
+func (o *ServerSetHaPriorityOptions) Params() (jsonutils.JSONObject, error) {
+	return StructToParams(o)
+}
+
+type ServerBindInstanceRoleOptions struct {
+	ID      string `help:"ID or name of server" json:"-"`
+	ROLE    string `help:"ID or name of keystone role bound to server" json:"role"`
+	Project string `help:"ID or name of project the role token is scoped to, default to project of server" json:"project"`
+}
+
+func (o *ServerBindInstanceRoleOptions) GetId() string {
+	return o.ID
+}
+
+func (o *ServerBindInstanceRoleOptions) Params() (jsonutils.JSONObject, error) {
+	return StructToParams(o)
+}
+
 type ResourceMetadataOptions struct {
 	ID   string   `help:"ID or name of resources" json:"-"`
 	TAGS []string `help:"Tags info, eg: hypervisor=aliyun、os_type=Linux、os_version"`
@@ -1078,3 +1122,19 @@ type ServerRemoteUpdateOptions struct {
 	ServerIdOptions
 	computeapi.ServerRemoteUpdateInput
 }
+
+type ServerVerifyIdentityDocumentOptions struct {
+	DOCUMENT  string `help:"Path of instance identity document file, got from /latest/dynamic/instance-identity/document"`
+	SIGNATURE string `help:"Signature of the document, got from /latest/dynamic/instance-identity/signature"`
+}
+
+func (o *ServerVerifyIdentityDocumentOptions) Params() (jsonutils.JSONObject, error) {
+	doc, err := ioutil.ReadFile(o.DOCUMENT)
+	if err != nil {
+		return nil, err
+	}
+	params := jsonutils.NewDict()
+	params.Set("document", jsonutils.NewString(string(doc)))
+	params.Set("signature", jsonutils.NewString(strings.TrimSpace(o.SIGNATURE)))
+	return params, nil
+}
@@ -29,6 +29,7 @@ import (
 	_ "yunion.io/x/onecloud/pkg/multicloud/objectstore/provider"
 	_ "yunion.io/x/onecloud/pkg/multicloud/objectstore/xsky/provider"
 	_ "yunion.io/x/onecloud/pkg/multicloud/openstack/provider"
+	_ "yunion.io/x/onecloud/pkg/multicloud/proxmox/provider"
 	_ "yunion.io/x/onecloud/pkg/multicloud/qcloud/provider"
 	_ "yunion.io/x/onecloud/pkg/multicloud/ucloud/provider" // object storages
 	_ "yunion.io/x/onecloud/pkg/multicloud/zstack/provider" // public clouds
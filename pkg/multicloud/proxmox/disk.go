@@ -0,0 +1,253 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// SDisk is a volume of proxmox storage, identified by volume id like local-lvm:vm-100-disk-0
+type SDisk struct {
+	multicloud.SDisk
+	storage *SStorage
+
+	VolId  string `json:"volid"`
+	Format string
+	Size   int64
+	Vmid   int
+
+	// device and cache mode of the vm config the disk is attached to
+	instance *SInstance
+	device   string
+	cache    string
+}
+
+func (region *SRegion) GetDisk(volid string) (*SDisk, error) {
+	zone, err := region.getZone()
+	if err != nil {
+		return nil, err
+	}
+	istorages, err := zone.GetIStorages()
+	if err != nil {
+		return nil, err
+	}
+	for i := range istorages {
+		storage := istorages[i].(*SStorage)
+		if storage.Storage != strings.Split(volid, ":")[0] {
+			continue
+		}
+		disk, err := storage.getDisk(volid)
+		if err == nil {
+			return disk, nil
+		}
+		if errors.Cause(err) != cloudprovider.ErrNotFound {
+			return nil, err
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "disk %s", volid)
+}
+
+func (region *SRegion) ResizeDisk(node string, vmid int, device string, sizeMb int) error {
+	params := map[string]interface{}{
+		"disk": device,
+		"size": fmt.Sprintf("%dM", sizeMb),
+	}
+	upid, err := region.client.put(fmt.Sprintf("/nodes/%s/qemu/%d/resize", node, vmid), jsonutils.Marshal(params))
+	if err != nil {
+		return err
+	}
+	return region.client.waitTask(upid)
+}
+
+func (disk *SDisk) storageName() string {
+	return strings.Split(disk.VolId, ":")[0]
+}
+
+// volumeName returns name of volume, e.g. vm-100-disk-0 of local:100/vm-100-disk-0.qcow2
+func volumeName(volid string) string {
+	segs := strings.SplitN(volid, ":", 2)
+	name := segs[len(segs)-1]
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	return name
+}
+
+// getInstance returns the vm owning the disk, nil for volumes not belonging to any vm
+func (disk *SDisk) getInstance() *SInstance {
+	if disk.instance == nil && disk.Vmid > 0 {
+		instance, err := disk.storage.zone.region.GetInstance(strconv.Itoa(disk.Vmid))
+		if err != nil {
+			log.Errorf("get instance %d of disk %s error: %v", disk.Vmid, disk.VolId, err)
+			return nil
+		}
+		disk.instance = instance
+		disk.device = instance.findDevice(disk.VolId)
+		_, opts := parseOptions(instance.getConfig()[disk.device])
+		disk.cache = opts["cache"]
+	}
+	return disk.instance
+}
+
+func (disk *SDisk) GetId() string {
+	return disk.VolId
+}
+
+func (disk *SDisk) GetName() string {
+	return volumeName(disk.VolId)
+}
+
+func (disk *SDisk) GetGlobalId() string {
+	return disk.VolId
+}
+
+func (disk *SDisk) GetStatus() string {
+	return api.DISK_READY
+}
+
+func (disk *SDisk) Refresh() error {
+	new, err := disk.storage.getDisk(disk.VolId)
+	if err != nil {
+		return err
+	}
+	disk.Size = new.Size
+	return nil
+}
+
+func (disk *SDisk) GetIStorage() (cloudprovider.ICloudStorage, error) {
+	return disk.storage, nil
+}
+
+func (disk *SDisk) GetIStorageId() string {
+	return disk.storage.GetGlobalId()
+}
+
+func (disk *SDisk) GetDiskFormat() string {
+	if len(disk.Format) > 0 {
+		return disk.Format
+	}
+	if strings.HasSuffix(disk.VolId, ".qcow2") {
+		return "qcow2"
+	}
+	return "raw"
+}
+
+func (disk *SDisk) GetDiskSizeMB() int {
+	return int(disk.Size / 1024 / 1024)
+}
+
+func (disk *SDisk) GetIsAutoDelete() bool {
+	return true
+}
+
+func (disk *SDisk) GetTemplateId() string {
+	return ""
+}
+
+func (disk *SDisk) GetDiskType() string {
+	instance := disk.getInstance()
+	if instance != nil && len(disk.device) > 0 && disk.device == instance.bootDevice() {
+		return api.DISK_TYPE_SYS
+	}
+	return api.DISK_TYPE_DATA
+}
+
+func (disk *SDisk) GetFsFormat() string {
+	return ""
+}
+
+func (disk *SDisk) GetIsNonPersistent() bool {
+	return false
+}
+
+func (disk *SDisk) GetDriver() string {
+	disk.getInstance()
+	if match := diskDeviceReg.FindStringSubmatch(disk.device); len(match) > 0 {
+		return match[1]
+	}
+	return "scsi"
+}
+
+func (disk *SDisk) GetCacheMode() string {
+	disk.getInstance()
+	if len(disk.cache) > 0 {
+		return disk.cache
+	}
+	return "none"
+}
+
+func (disk *SDisk) GetMountpoint() string {
+	return ""
+}
+
+func (disk *SDisk) GetAccessPath() string {
+	return ""
+}
+
+// Delete removes the volume, volumes left as unused disk of vm are removed through vm config
+func (disk *SDisk) Delete(ctx context.Context) error {
+	instance := disk.getInstance()
+	if instance != nil && len(disk.device) > 0 {
+		if !strings.HasPrefix(disk.device, "unused") {
+			return fmt.Errorf("disk %s is attached to vm %d as %s", disk.VolId, disk.Vmid, disk.device)
+		}
+		return disk.storage.zone.region.UpdateInstanceConfig(instance.Node, instance.Vmid, map[string]interface{}{"delete": disk.device})
+	}
+	upid, err := disk.storage.zone.region.client.delete(fmt.Sprintf("/nodes/%s/storage/%s/content/%s", disk.storage.node, disk.storage.Storage, url.PathEscape(disk.VolId)), nil)
+	if err != nil {
+		return err
+	}
+	return disk.storage.zone.region.client.waitTask(upid)
+}
+
+func (disk *SDisk) CreateISnapshot(ctx context.Context, name string, desc string) (cloudprovider.ICloudSnapshot, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) GetISnapshots() ([]cloudprovider.ICloudSnapshot, error) {
+	return []cloudprovider.ICloudSnapshot{}, nil
+}
+
+func (disk *SDisk) Resize(ctx context.Context, newSizeMB int64) error {
+	instance := disk.getInstance()
+	if instance == nil || len(disk.device) == 0 || strings.HasPrefix(disk.device, "unused") {
+		return errors.Wrapf(cloudprovider.ErrNotSupported, "resize disk %s not attached", disk.VolId)
+	}
+	return disk.storage.zone.region.ResizeDisk(instance.Node, instance.Vmid, disk.device, int(newSizeMB))
+}
+
+func (disk *SDisk) Reset(ctx context.Context, snapshotId string) (string, error) {
+	return "", cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) Rebuild(ctx context.Context) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (disk *SDisk) GetProjectId() string {
+	return ""
+}
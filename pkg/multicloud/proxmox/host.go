@@ -0,0 +1,370 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+// SHost is a proxmox node
+type SHost struct {
+	multicloud.SHostBase
+	zone *SZone
+
+	Node    string
+	Status  string
+	Maxcpu  int
+	Maxmem  int64
+	Maxdisk int64
+
+	ip     string
+	status *SNodeStatus
+}
+
+type SNodeStatus struct {
+	Cpuinfo struct {
+		Model   string
+		Cpus    int
+		Sockets int
+		Cores   int
+		Mhz     string
+	}
+	Memory struct {
+		Total int64
+		Used  int64
+		Free  int64
+	}
+	Pveversion string
+	Kversion   string
+}
+
+func (region *SRegion) GetHosts() ([]SHost, error) {
+	hosts := []SHost{}
+	err := region.client.get("/nodes", nil, &hosts)
+	if err != nil {
+		return nil, err
+	}
+	status, err := region.GetClusterStatus()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetClusterStatus")
+	}
+	for i := range hosts {
+		for j := range status {
+			if status[j].Type == "node" && status[j].Name == hosts[i].Node {
+				hosts[i].ip = status[j].Ip
+			}
+		}
+	}
+	return hosts, nil
+}
+
+func (host *SHost) isOnline() bool {
+	return host.Status == "online"
+}
+
+func (host *SHost) getStatus() *SNodeStatus {
+	if host.status == nil {
+		status := &SNodeStatus{}
+		err := host.zone.region.client.get(fmt.Sprintf("/nodes/%s/status", host.Node), nil, status)
+		if err != nil {
+			log.Errorf("get status of node %s error: %v", host.Node, err)
+		}
+		host.status = status
+	}
+	return host.status
+}
+
+func (host *SHost) GetId() string {
+	return host.Node
+}
+
+func (host *SHost) GetName() string {
+	return host.Node
+}
+
+func (host *SHost) GetGlobalId() string {
+	return host.GetId()
+}
+
+func (host *SHost) GetStatus() string {
+	if host.isOnline() {
+		return api.HOST_STATUS_RUNNING
+	}
+	return api.HOST_STATUS_UNKNOWN
+}
+
+func (host *SHost) GetHostStatus() string {
+	if host.isOnline() {
+		return api.HOST_ONLINE
+	}
+	return api.HOST_OFFLINE
+}
+
+func (host *SHost) GetEnabled() bool {
+	return true
+}
+
+func (host *SHost) GetAccessIp() string {
+	return host.ip
+}
+
+func (host *SHost) GetAccessMac() string {
+	return ""
+}
+
+func (host *SHost) GetSysInfo() jsonutils.JSONObject {
+	info := jsonutils.NewDict()
+	info.Add(jsonutils.NewString(CLOUD_PROVIDER_PROXMOX), "manufacture")
+	return info
+}
+
+func (host *SHost) GetSN() string {
+	return ""
+}
+
+func (host *SHost) GetCpuCount() int {
+	return host.Maxcpu
+}
+
+func (host *SHost) GetNodeCount() int8 {
+	return int8(host.getStatus().Cpuinfo.Sockets)
+}
+
+func (host *SHost) GetCpuDesc() string {
+	return host.getStatus().Cpuinfo.Model
+}
+
+func (host *SHost) GetCpuMhz() int {
+	mhz, _ := strconv.ParseFloat(host.getStatus().Cpuinfo.Mhz, 64)
+	return int(mhz)
+}
+
+func (host *SHost) GetMemSizeMB() int {
+	return int(host.Maxmem / 1024 / 1024)
+}
+
+func (host *SHost) GetStorageSizeMB() int {
+	storages, err := host.getStorages()
+	if err != nil {
+		return 0
+	}
+	size := int64(0)
+	for i := range storages {
+		size += storages[i].GetCapacityMB()
+	}
+	return int(size)
+}
+
+func (host *SHost) GetStorageType() string {
+	return api.DISK_TYPE_HYBRID
+}
+
+func (host *SHost) GetHostType() string {
+	return api.HOST_TYPE_PROXMOX
+}
+
+func (host *SHost) GetIsMaintenance() bool {
+	return false
+}
+
+func (host *SHost) GetVersion() string {
+	return host.getStatus().Pveversion
+}
+
+func (host *SHost) GetIHostNics() ([]cloudprovider.ICloudHostNetInterface, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (host *SHost) GetIWires() ([]cloudprovider.ICloudWire, error) {
+	wires, err := host.zone.region.GetWires()
+	if err != nil {
+		return nil, err
+	}
+	iwires := []cloudprovider.ICloudWire{}
+	for i := 0; i < len(wires); i++ {
+		if wires[i].hasNode(host.Node) {
+			iwires = append(iwires, &wires[i])
+		}
+	}
+	return iwires, nil
+}
+
+func (host *SHost) getStorages() ([]SStorage, error) {
+	storages := []SStorage{}
+	err := host.zone.region.client.get(fmt.Sprintf("/nodes/%s/storage", host.Node), nil, &storages)
+	if err != nil {
+		return nil, err
+	}
+	ret := []SStorage{}
+	for i := range storages {
+		// storages unable to keep vm disks are useless to compute
+		if !storages[i].isImageStore() {
+			continue
+		}
+		storages[i].zone = host.zone
+		storages[i].node = host.Node
+		ret = append(ret, storages[i])
+	}
+	return ret, nil
+}
+
+func (host *SHost) GetIStorages() ([]cloudprovider.ICloudStorage, error) {
+	storages, err := host.getStorages()
+	if err != nil {
+		return nil, err
+	}
+	istorages := []cloudprovider.ICloudStorage{}
+	for i := range storages {
+		istorages = append(istorages, &storages[i])
+	}
+	return istorages, nil
+}
+
+func (host *SHost) GetIStorageById(id string) (cloudprovider.ICloudStorage, error) {
+	return host.zone.GetIStorageById(id)
+}
+
+func (host *SHost) GetIVMs() ([]cloudprovider.ICloudVM, error) {
+	instances, err := host.zone.region.GetInstances(host.Node)
+	if err != nil {
+		return nil, err
+	}
+	ivms := []cloudprovider.ICloudVM{}
+	for i := 0; i < len(instances); i++ {
+		if instances[i].isTemplate() {
+			continue
+		}
+		instances[i].host = host
+		ivms = append(ivms, &instances[i])
+	}
+	return ivms, nil
+}
+
+func (host *SHost) GetIVMById(id string) (cloudprovider.ICloudVM, error) {
+	instance, err := host.zone.region.GetInstance(id)
+	if err != nil {
+		return nil, err
+	}
+	if instance.Node != host.Node {
+		return nil, errors.Wrapf(cloudprovider.ErrNotFound, "instance %s on node %s", id, instance.Node)
+	}
+	instance.host = host
+	return instance, nil
+}
+
+func sizeGBParam(storage string, sizeGB int) string {
+	return fmt.Sprintf("%s:%d", storage, sizeGB)
+}
+
+func storageName(storageId string) string {
+	segs := strings.Split(storageId, "/")
+	return segs[len(segs)-1]
+}
+
+// CreateVM clones the template given as image when there is one, then applies
+// cpu, memory, network and cloud-init settings and starts the vm
+func (host *SHost) CreateVM(desc *cloudprovider.SManagedVMCreateConfig) (cloudprovider.ICloudVM, error) {
+	region := host.zone.region
+	vmid, err := region.GetNextId()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetNextId")
+	}
+	sysStorage := storageName(desc.SysDisk.StorageExternalId)
+	if len(desc.ExternalImageId) > 0 {
+		template, err := region.GetInstance(desc.ExternalImageId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get template %s", desc.ExternalImageId)
+		}
+		params := map[string]interface{}{
+			"newid":  vmid,
+			"name":   desc.Name,
+			"target": host.Node,
+			"full":   1,
+		}
+		if len(sysStorage) > 0 {
+			params["storage"] = sysStorage
+		}
+		err = region.client.postAndWait(fmt.Sprintf("/nodes/%s/qemu/%d/clone", template.Node, template.Vmid), jsonutils.Marshal(params))
+		if err != nil {
+			return nil, errors.Wrapf(err, "clone template %s", desc.ExternalImageId)
+		}
+	} else {
+		params := map[string]interface{}{
+			"vmid":   vmid,
+			"name":   desc.Name,
+			"scsihw": "virtio-scsi-pci",
+			"scsi0":  sizeGBParam(sysStorage, desc.SysDisk.SizeGB),
+			"boot":   "order=scsi0",
+		}
+		err = region.client.postAndWait(fmt.Sprintf("/nodes/%s/qemu", host.Node), jsonutils.Marshal(params))
+		if err != nil {
+			return nil, errors.Wrap(err, "create vm")
+		}
+	}
+
+	instance, err := host.GetIVMById(strconv.Itoa(vmid))
+	if err != nil {
+		return nil, errors.Wrapf(err, "get new vm %d", vmid)
+	}
+	vm := instance.(*SInstance)
+	err = vm.configure(desc, sysStorage)
+	if err != nil {
+		return nil, errors.Wrap(err, "configure")
+	}
+	err = vm.resizeSysDisk(desc.SysDisk.SizeGB)
+	if err != nil {
+		return nil, errors.Wrap(err, "resize system disk")
+	}
+	for _, disk := range desc.DataDisks {
+		err = vm.addDisk(storageName(disk.StorageExternalId), disk.SizeGB)
+		if err != nil {
+			return nil, errors.Wrap(err, "add data disk")
+		}
+	}
+	err = region.StartVM(vm.Node, vm.Vmid)
+	if err != nil {
+		return nil, errors.Wrap(err, "start vm")
+	}
+	return vm, nil
+}
+
+func (region *SRegion) GetNextId() (int, error) {
+	data, err := region.client.request(httputils.GET, "/cluster/nextid", nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	// proxmox returns the id as string
+	id, err := data.Int()
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid next id %s", data)
+	}
+	return int(id), nil
+}
+
+func sizeGB(sizeMb int) int {
+	return int(math.Ceil(float64(sizeMb) / 1024))
+}
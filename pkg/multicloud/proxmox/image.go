@@ -0,0 +1,102 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"context"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+// SImage is a vm template
+type SImage struct {
+	SInstance
+	cache *SStoragecache
+}
+
+func (image *SImage) GetIStoragecache() cloudprovider.ICloudStoragecache {
+	return image.cache
+}
+
+func (image *SImage) GetStatus() string {
+	return api.CACHED_IMAGE_STATUS_ACTIVE
+}
+
+func (image *SImage) GetImageStatus() string {
+	return cloudprovider.IMAGE_STATUS_ACTIVE
+}
+
+func (image *SImage) Refresh() error {
+	return nil
+}
+
+func (image *SImage) Delete(ctx context.Context) error {
+	return image.cache.region.DeleteVM(image.Node, image.Vmid)
+}
+
+func (image *SImage) GetImageType() cloudprovider.TImageType {
+	return cloudprovider.ImageTypeSystem
+}
+
+func (image *SImage) GetSizeByte() int64 {
+	return image.Maxdisk
+}
+
+func (image *SImage) GetOsType() string {
+	return image.GetOSType()
+}
+
+func (image *SImage) GetOsDist() string {
+	return ""
+}
+
+func (image *SImage) GetOsVersion() string {
+	return ""
+}
+
+func (image *SImage) GetOsArch() string {
+	return ""
+}
+
+func (image *SImage) GetMinOsDiskSizeGb() int {
+	return int(image.Maxdisk / 1024 / 1024 / 1024)
+}
+
+func (image *SImage) GetMinRamSizeMb() int {
+	return 0
+}
+
+func (image *SImage) GetImageFormat() string {
+	return "raw"
+}
+
+func (image *SImage) GetCreatedAt() time.Time {
+	return time.Time{}
+}
+
+func (image *SImage) UEFI() bool {
+	return image.GetBios() == "UEFI"
+}
+
+func (image *SImage) GetPublicScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func (image *SImage) GetSubImages() []cloudprovider.SSubImage {
+	return nil
+}
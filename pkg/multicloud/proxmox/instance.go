@@ -0,0 +1,682 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/util/billing"
+)
+
+var (
+	diskDeviceReg = regexp.MustCompile(`^(ide|sata|scsi|virtio)(\d+)$`)
+	nicDeviceReg  = regexp.MustCompile(`^net(\d+)$`)
+)
+
+// SInstance is a qemu vm of proxmox, lxc containers are not synced
+type SInstance struct {
+	multicloud.SInstanceBase
+	host *SHost
+
+	Id       string
+	Type     string
+	Node     string
+	Vmid     int
+	Name     string
+	Status   string
+	Maxcpu   int
+	Maxmem   int64
+	Maxdisk  int64
+	Template int
+
+	config map[string]string
+}
+
+// GetInstances lists qemu vms of node, or of the whole cluster when node is empty
+func (region *SRegion) GetInstances(node string) ([]SInstance, error) {
+	resources := []SInstance{}
+	err := region.client.get("/cluster/resources", url.Values{"type": []string{"vm"}}, &resources)
+	if err != nil {
+		return nil, err
+	}
+	instances := []SInstance{}
+	for i := range resources {
+		if resources[i].Type != "qemu" {
+			continue
+		}
+		if len(node) > 0 && resources[i].Node != node {
+			continue
+		}
+		instances = append(instances, resources[i])
+	}
+	return instances, nil
+}
+
+func (region *SRegion) GetInstance(id string) (*SInstance, error) {
+	instances, err := region.GetInstances("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range instances {
+		if strconv.Itoa(instances[i].Vmid) == id {
+			zone, err := region.getZone()
+			if err != nil {
+				return nil, err
+			}
+			instances[i].host, err = zone.getHost(instances[i].Node)
+			if err != nil {
+				return nil, err
+			}
+			return &instances[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "instance %s", id)
+}
+
+func (region *SRegion) GetInstanceConfig(node string, vmid int) (map[string]string, error) {
+	config := jsonutils.NewDict()
+	err := region.client.get(fmt.Sprintf("/nodes/%s/qemu/%d/config", node, vmid), nil, config)
+	if err != nil {
+		return nil, err
+	}
+	ret := map[string]string{}
+	for k, v := range config.Value() {
+		ret[k], _ = v.GetString()
+	}
+	return ret, nil
+}
+
+func (region *SRegion) UpdateInstanceConfig(node string, vmid int, params map[string]interface{}) error {
+	upid, err := region.client.put(fmt.Sprintf("/nodes/%s/qemu/%d/config", node, vmid), jsonutils.Marshal(params))
+	if err != nil {
+		return err
+	}
+	return region.client.waitTask(upid)
+}
+
+func (region *SRegion) StartVM(node string, vmid int) error {
+	return region.client.postAndWait(fmt.Sprintf("/nodes/%s/qemu/%d/status/start", node, vmid), nil)
+}
+
+// StopVM powers off the vm when isForce, or asks the guest os to shut down
+func (region *SRegion) StopVM(node string, vmid int, isForce bool) error {
+	action := "shutdown"
+	if isForce {
+		action = "stop"
+	}
+	return region.client.postAndWait(fmt.Sprintf("/nodes/%s/qemu/%d/status/%s", node, vmid, action), nil)
+}
+
+func (region *SRegion) DeleteVM(node string, vmid int) error {
+	upid, err := region.client.delete(fmt.Sprintf("/nodes/%s/qemu/%d", node, vmid), url.Values{"purge": []string{"1"}})
+	if err != nil {
+		return err
+	}
+	return region.client.waitTask(upid)
+}
+
+// parseOptions parses proxmox property strings like local-lvm:vm-100-disk-0,size=32G,
+// the leading segment without "=" is returned as the default value
+func parseOptions(value string) (string, map[string]string) {
+	defaultValue, opts := "", map[string]string{}
+	for i, seg := range strings.Split(value, ",") {
+		kv := strings.SplitN(seg, "=", 2)
+		if len(kv) == 1 {
+			if i == 0 {
+				defaultValue = kv[0]
+			}
+			continue
+		}
+		opts[kv[0]] = kv[1]
+	}
+	return defaultValue, opts
+}
+
+// parseSize parses disk size like 512M, 32G or 1T into MB
+func parseSize(size string) int {
+	if len(size) == 0 {
+		return 0
+	}
+	unit := size[len(size)-1]
+	num, err := strconv.ParseFloat(strings.TrimRight(size, "KMGTkmgt"), 64)
+	if err != nil {
+		return 0
+	}
+	switch unit {
+	case 'K', 'k':
+		return int(num / 1024)
+	case 'M', 'm':
+		return int(num)
+	case 'T', 't':
+		return int(num * 1024 * 1024)
+	case 'G', 'g':
+		return int(num * 1024)
+	default:
+		return int(num / 1024 / 1024)
+	}
+}
+
+func (instance *SInstance) isTemplate() bool {
+	return instance.Template == 1
+}
+
+func (instance *SInstance) getConfig() map[string]string {
+	if instance.config == nil {
+		config, err := instance.host.zone.region.GetInstanceConfig(instance.Node, instance.Vmid)
+		if err != nil {
+			log.Errorf("get config of instance %d error: %v", instance.Vmid, err)
+			return map[string]string{}
+		}
+		instance.config = config
+	}
+	return instance.config
+}
+
+func (instance *SInstance) GetId() string {
+	return strconv.Itoa(instance.Vmid)
+}
+
+func (instance *SInstance) GetName() string {
+	return instance.Name
+}
+
+func (instance *SInstance) GetGlobalId() string {
+	return instance.GetId()
+}
+
+func (instance *SInstance) GetIHost() cloudprovider.ICloudHost {
+	return instance.host
+}
+
+func (instance *SInstance) GetIHostId() string {
+	return instance.Node
+}
+
+func (instance *SInstance) GetStatus() string {
+	switch instance.Status {
+	case "running":
+		return api.VM_RUNNING
+	case "stopped":
+		return api.VM_READY
+	default:
+		log.Errorf("Unknown instance %s status %s", instance.Name, instance.Status)
+		return api.VM_UNKNOWN
+	}
+}
+
+func (instance *SInstance) Refresh() error {
+	new, err := instance.host.zone.region.GetInstance(instance.GetId())
+	if err != nil {
+		return err
+	}
+	instance.config = nil
+	return jsonutils.Update(instance, new)
+}
+
+func (instance *SInstance) GetHypervisor() string {
+	return api.HYPERVISOR_PROXMOX
+}
+
+func (instance *SInstance) GetInstanceType() string {
+	return ""
+}
+
+func (instance *SInstance) GetVcpuCount() int {
+	config := instance.getConfig()
+	cores, _ := strconv.Atoi(config["cores"])
+	sockets, _ := strconv.Atoi(config["sockets"])
+	if cores == 0 {
+		return instance.Maxcpu
+	}
+	if sockets == 0 {
+		sockets = 1
+	}
+	return cores * sockets
+}
+
+func (instance *SInstance) GetVmemSizeMB() int {
+	memory, _ := strconv.Atoi(instance.getConfig()["memory"])
+	if memory == 0 {
+		return int(instance.Maxmem / 1024 / 1024)
+	}
+	return memory
+}
+
+func (instance *SInstance) GetBootOrder() string {
+	return "dcn"
+}
+
+func (instance *SInstance) GetVga() string {
+	vga, _ := parseOptions(instance.getConfig()["vga"])
+	if len(vga) == 0 {
+		return "std"
+	}
+	return vga
+}
+
+func (instance *SInstance) GetVdi() string {
+	return "vnc"
+}
+
+func (instance *SInstance) GetOSType() string {
+	if strings.HasPrefix(instance.getConfig()["ostype"], "w") {
+		return "Windows"
+	}
+	return "Linux"
+}
+
+func (instance *SInstance) GetOSName() string {
+	return instance.getConfig()["ostype"]
+}
+
+func (instance *SInstance) GetBios() string {
+	if instance.getConfig()["bios"] == "ovmf" {
+		return "UEFI"
+	}
+	return "BIOS"
+}
+
+func (instance *SInstance) GetMachine() string {
+	machine := instance.getConfig()["machine"]
+	if strings.Contains(machine, "q35") {
+		return "q35"
+	}
+	return "pc"
+}
+
+// diskDevices returns device names of disks in config sorted, cdroms are excluded
+func (instance *SInstance) diskDevices() []string {
+	devices := []string{}
+	for key, value := range instance.getConfig() {
+		if !diskDeviceReg.MatchString(key) {
+			continue
+		}
+		_, opts := parseOptions(value)
+		if opts["media"] == "cdrom" {
+			continue
+		}
+		devices = append(devices, key)
+	}
+	sort.Strings(devices)
+	return devices
+}
+
+// bootDevice returns device of the system disk, by boot order or legacy bootdisk option
+func (instance *SInstance) bootDevice() string {
+	devices := instance.diskDevices()
+	config := instance.getConfig()
+	_, opts := parseOptions(config["boot"])
+	if order, ok := opts["order"]; ok {
+		for _, dev := range strings.Split(order, ";") {
+			for i := range devices {
+				if devices[i] == dev {
+					return dev
+				}
+			}
+		}
+	}
+	if bootdisk, ok := config["bootdisk"]; ok {
+		return bootdisk
+	}
+	if len(devices) > 0 {
+		return devices[0]
+	}
+	return ""
+}
+
+func (instance *SInstance) getDisks() ([]SDisk, error) {
+	zone, err := instance.host.zone.region.getZone()
+	if err != nil {
+		return nil, err
+	}
+	config := instance.getConfig()
+	disks := []SDisk{}
+	for _, dev := range instance.diskDevices() {
+		volid, opts := parseOptions(config[dev])
+		disk := SDisk{
+			instance: instance,
+			device:   dev,
+			VolId:    volid,
+			Size:     int64(parseSize(opts["size"])) * 1024 * 1024,
+			Vmid:     instance.Vmid,
+			cache:    opts["cache"],
+		}
+		disk.storage, err = zone.getStorageByName(instance.Node, disk.storageName())
+		if err != nil {
+			return nil, err
+		}
+		disks = append(disks, disk)
+	}
+	return disks, nil
+}
+
+func (instance *SInstance) GetIDisks() ([]cloudprovider.ICloudDisk, error) {
+	disks, err := instance.getDisks()
+	if err != nil {
+		return nil, err
+	}
+	idisks := []cloudprovider.ICloudDisk{}
+	for i := 0; i < len(disks); i++ {
+		idisks = append(idisks, &disks[i])
+	}
+	return idisks, nil
+}
+
+func (instance *SInstance) GetINics() ([]cloudprovider.ICloudNic, error) {
+	config := instance.getConfig()
+	inics := []cloudprovider.ICloudNic{}
+	keys := []string{}
+	for key := range config {
+		if nicDeviceReg.MatchString(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		index := nicDeviceReg.FindStringSubmatch(key)[1]
+		nic := &SInstanceNic{instance: instance}
+		for i, seg := range strings.Split(config[key], ",") {
+			kv := strings.SplitN(seg, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch {
+			case i == 0:
+				nic.Model, nic.Mac = kv[0], kv[1]
+			case kv[0] == "bridge":
+				nic.Bridge = kv[1]
+			}
+		}
+		// the address set by cloud-init, dhcp addresses are unknown without guest agent
+		_, opts := parseOptions(config["ipconfig"+index])
+		if ip, ok := opts["ip"]; ok && ip != "dhcp" {
+			nic.IpAddr = strings.Split(ip, "/")[0]
+		}
+		inics = append(inics, nic)
+	}
+	return inics, nil
+}
+
+func (instance *SInstance) GetIEIP() (cloudprovider.ICloudEIP, error) {
+	return nil, nil
+}
+
+func (instance *SInstance) GetSecurityGroupIds() ([]string, error) {
+	return []string{}, nil
+}
+
+func (instance *SInstance) AssignSecurityGroup(secgroupId string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) SetSecurityGroups(secgroupIds []string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) StartVM(ctx context.Context) error {
+	err := instance.host.zone.region.StartVM(instance.Node, instance.Vmid)
+	if err != nil {
+		return err
+	}
+	return cloudprovider.WaitStatus(instance, api.VM_RUNNING, 5*time.Second, 5*time.Minute)
+}
+
+func (instance *SInstance) StopVM(ctx context.Context, opts *cloudprovider.ServerStopOptions) error {
+	err := instance.host.zone.region.StopVM(instance.Node, instance.Vmid, opts.IsForce)
+	if err != nil {
+		return err
+	}
+	return cloudprovider.WaitStatus(instance, api.VM_READY, 5*time.Second, 5*time.Minute)
+}
+
+func (instance *SInstance) DeleteVM(ctx context.Context) error {
+	if instance.Status == "running" {
+		err := instance.host.zone.region.StopVM(instance.Node, instance.Vmid, true)
+		if err != nil {
+			return errors.Wrap(err, "stop vm")
+		}
+	}
+	return instance.host.zone.region.DeleteVM(instance.Node, instance.Vmid)
+}
+
+func (instance *SInstance) UpdateVM(ctx context.Context, name string) error {
+	return instance.host.zone.region.UpdateInstanceConfig(instance.Node, instance.Vmid, map[string]interface{}{"name": name})
+}
+
+func (instance *SInstance) UpdateUserData(userData string) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) RebuildRoot(ctx context.Context, desc *cloudprovider.SManagedVMRebuildRootConfig) (string, error) {
+	return "", cloudprovider.ErrNotSupported
+}
+
+// DeployVM updates cloud-init settings, which take effect on next boot
+func (instance *SInstance) DeployVM(ctx context.Context, name string, username string, password string, publicKey string, deleteKeypair bool, description string) error {
+	params := map[string]interface{}{}
+	if len(username) > 0 {
+		params["ciuser"] = username
+	}
+	if len(password) > 0 {
+		params["cipassword"] = password
+	}
+	if len(publicKey) > 0 {
+		params["sshkeys"] = encodeSshKeys(publicKey)
+	} else if deleteKeypair {
+		params["delete"] = "sshkeys"
+	}
+	if len(description) > 0 {
+		params["description"] = description
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return instance.host.zone.region.UpdateInstanceConfig(instance.Node, instance.Vmid, params)
+}
+
+// encodeSshKeys encodes keys the way proxmox requires, spaces must be %20 instead of +
+func encodeSshKeys(keys string) string {
+	return strings.ReplaceAll(url.QueryEscape(keys), "+", "%20")
+}
+
+func (instance *SInstance) ChangeConfig(ctx context.Context, config *cloudprovider.SManagedVMChangeConfig) error {
+	params := map[string]interface{}{}
+	if config.Cpu > 0 {
+		params["cores"] = config.Cpu
+		params["sockets"] = 1
+	}
+	if config.MemoryMB > 0 {
+		params["memory"] = config.MemoryMB
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return instance.host.zone.region.UpdateInstanceConfig(instance.Node, instance.Vmid, params)
+}
+
+func (instance *SInstance) GetVNCInfo() (jsonutils.JSONObject, error) {
+	params := url.Values{}
+	params.Set("console", "kvm")
+	params.Set("novnc", "1")
+	params.Set("vmid", instance.GetId())
+	params.Set("vmname", instance.Name)
+	params.Set("node", instance.Node)
+	return jsonutils.Marshal(map[string]string{
+		"url":         fmt.Sprintf("%s/?%s", instance.host.zone.region.client.authURL, params.Encode()),
+		"protocol":    "proxmox",
+		"instance_id": instance.GetId(),
+	}), nil
+}
+
+// nextDevice returns the first unused device name of bus, e.g. scsi1
+func (instance *SInstance) nextDevice(bus string) string {
+	config := instance.getConfig()
+	for i := 0; ; i++ {
+		dev := fmt.Sprintf("%s%d", bus, i)
+		if _, ok := config[dev]; !ok {
+			return dev
+		}
+	}
+}
+
+func (instance *SInstance) addDisk(storage string, sizeGB int) error {
+	if len(storage) == 0 {
+		disks, err := instance.getDisks()
+		if err != nil {
+			return err
+		}
+		if len(disks) == 0 {
+			return errors.Wrap(cloudprovider.ErrNotFound, "no storage to create disk")
+		}
+		storage = disks[0].storageName()
+	}
+	dev := instance.nextDevice("scsi")
+	err := instance.host.zone.region.UpdateInstanceConfig(instance.Node, instance.Vmid, map[string]interface{}{dev: sizeGBParam(storage, sizeGB)})
+	if err != nil {
+		return errors.Wrapf(err, "add disk %s", dev)
+	}
+	instance.config = nil
+	return nil
+}
+
+func (instance *SInstance) CreateDisk(ctx context.Context, sizeMb int, uuid string, driver string) error {
+	return instance.addDisk("", sizeGB(sizeMb))
+}
+
+func (instance *SInstance) resizeSysDisk(sizeGB int) error {
+	dev := instance.bootDevice()
+	if len(dev) == 0 || sizeGB == 0 {
+		return nil
+	}
+	_, opts := parseOptions(instance.getConfig()[dev])
+	if parseSize(opts["size"]) >= sizeGB*1024 {
+		return nil
+	}
+	return instance.host.zone.region.ResizeDisk(instance.Node, instance.Vmid, dev, sizeGB*1024)
+}
+
+// findDevice returns the key in config referring to volume, unused disks are included
+func (instance *SInstance) findDevice(volid string) string {
+	for key, value := range instance.getConfig() {
+		if !diskDeviceReg.MatchString(key) && !strings.HasPrefix(key, "unused") {
+			continue
+		}
+		if v, _ := parseOptions(value); v == volid {
+			return key
+		}
+	}
+	return ""
+}
+
+func (instance *SInstance) AttachDisk(ctx context.Context, diskId string) error {
+	params := map[string]interface{}{instance.nextDevice("scsi"): diskId}
+	if dev := instance.findDevice(diskId); strings.HasPrefix(dev, "unused") {
+		params["delete"] = dev
+	}
+	return instance.host.zone.region.UpdateInstanceConfig(instance.Node, instance.Vmid, params)
+}
+
+// DetachDisk detaches the disk from vm, proxmox keeps it as unused disk of the vm
+func (instance *SInstance) DetachDisk(ctx context.Context, diskId string) error {
+	dev := instance.findDevice(diskId)
+	if len(dev) == 0 || strings.HasPrefix(dev, "unused") {
+		return nil
+	}
+	return instance.host.zone.region.UpdateInstanceConfig(instance.Node, instance.Vmid, map[string]interface{}{"delete": dev})
+}
+
+func (instance *SInstance) Renew(bc billing.SBillingCycle) error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (instance *SInstance) GetProjectId() string {
+	return ""
+}
+
+func (instance *SInstance) GetError() error {
+	return nil
+}
+
+// configure applies settings of create config to the new vm
+func (instance *SInstance) configure(desc *cloudprovider.SManagedVMCreateConfig, storage string) error {
+	params := map[string]interface{}{
+		"cores":   desc.Cpu,
+		"sockets": 1,
+		"memory":  desc.MemoryMB,
+	}
+	if len(desc.Description) > 0 {
+		params["description"] = desc.Description
+	}
+	if strings.ToLower(desc.OsType) == "windows" {
+		params["ostype"] = "win10"
+	} else {
+		params["ostype"] = "l26"
+	}
+	if len(desc.ExternalNetworkId) > 0 {
+		network, err := instance.host.zone.region.GetNetwork(desc.ExternalNetworkId)
+		if err != nil {
+			return errors.Wrapf(err, "get network %s", desc.ExternalNetworkId)
+		}
+		params["net0"] = fmt.Sprintf("virtio,bridge=%s", network.wire.Iface)
+		if len(desc.IpAddr) > 0 {
+			ipconfig := fmt.Sprintf("ip=%s/%d", desc.IpAddr, network.GetIpMask())
+			if len(network.GetGateway()) > 0 {
+				ipconfig += ",gw=" + network.GetGateway()
+			}
+			params["ipconfig0"] = ipconfig
+		}
+	}
+	if len(desc.Account) > 0 {
+		params["ciuser"] = desc.Account
+	}
+	if len(desc.Password) > 0 {
+		params["cipassword"] = desc.Password
+	}
+	if len(desc.PublicKey) > 0 {
+		params["sshkeys"] = encodeSshKeys(desc.PublicKey)
+	}
+	if !instance.hasCloudinitDrive() && len(storage) > 0 {
+		params[instance.nextDevice("ide")] = fmt.Sprintf("%s:cloudinit", storage)
+	}
+	err := instance.host.zone.region.UpdateInstanceConfig(instance.Node, instance.Vmid, params)
+	if err != nil {
+		return err
+	}
+	instance.config = nil
+	return nil
+}
+
+func (instance *SInstance) hasCloudinitDrive() bool {
+	for key, value := range instance.getConfig() {
+		if diskDeviceReg.MatchString(key) && strings.Contains(value, "cloudinit") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// snapshot name must start with a letter and contain only letters, digits, '-' and '_'
+var snapnameReg = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+type SInstanceSnapshot struct {
+	multicloud.SVirtualResourceBase
+	instance *SInstance
+
+	Name        string
+	Description string
+	Snaptime    int64
+	Vmstate     int
+	Parent      string
+}
+
+func (region *SRegion) GetInstanceSnapshots(node string, vmid int) ([]SInstanceSnapshot, error) {
+	snapshots := []SInstanceSnapshot{}
+	err := region.client.get(fmt.Sprintf("/nodes/%s/qemu/%d/snapshot", node, vmid), nil, &snapshots)
+	if err != nil {
+		return nil, err
+	}
+	ret := []SInstanceSnapshot{}
+	for i := range snapshots {
+		// "current" is the running state of vm rather than a snapshot
+		if snapshots[i].Name == "current" {
+			continue
+		}
+		ret = append(ret, snapshots[i])
+	}
+	return ret, nil
+}
+
+func snapname(name string) string {
+	name = snapnameReg.ReplaceAllString(name, "_")
+	if len(name) == 0 || !((name[0] >= 'a' && name[0] <= 'z') || (name[0] >= 'A' && name[0] <= 'Z')) {
+		name = "s" + name
+	}
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	return name
+}
+
+func (region *SRegion) CreateInstanceSnapshot(node string, vmid int, name, desc string) error {
+	params := jsonutils.NewDict()
+	params.Set("snapname", jsonutils.NewString(name))
+	if len(desc) > 0 {
+		params.Set("description", jsonutils.NewString(desc))
+	}
+	return region.client.postAndWait(fmt.Sprintf("/nodes/%s/qemu/%d/snapshot", node, vmid), params)
+}
+
+func (region *SRegion) RollbackInstanceSnapshot(node string, vmid int, name string) error {
+	return region.client.postAndWait(fmt.Sprintf("/nodes/%s/qemu/%d/snapshot/%s/rollback", node, vmid, name), nil)
+}
+
+func (region *SRegion) DeleteInstanceSnapshot(node string, vmid int, name string) error {
+	upid, err := region.client.delete(fmt.Sprintf("/nodes/%s/qemu/%d/snapshot/%s", node, vmid, name), nil)
+	if err != nil {
+		return err
+	}
+	return region.client.waitTask(upid)
+}
+
+func (snapshot *SInstanceSnapshot) GetId() string {
+	return fmt.Sprintf("%d/%s", snapshot.instance.Vmid, snapshot.Name)
+}
+
+func (snapshot *SInstanceSnapshot) GetName() string {
+	return snapshot.Name
+}
+
+func (snapshot *SInstanceSnapshot) GetGlobalId() string {
+	return snapshot.GetId()
+}
+
+func (snapshot *SInstanceSnapshot) GetStatus() string {
+	return api.INSTANCE_SNAPSHOT_READY
+}
+
+func (snapshot *SInstanceSnapshot) GetDescription() string {
+	return snapshot.Description
+}
+
+func (snapshot *SInstanceSnapshot) GetCreatedAt() time.Time {
+	return time.Unix(snapshot.Snaptime, 0)
+}
+
+func (snapshot *SInstanceSnapshot) GetProjectId() string {
+	return ""
+}
+
+func (snapshot *SInstanceSnapshot) Delete() error {
+	return snapshot.instance.host.zone.region.DeleteInstanceSnapshot(snapshot.instance.Node, snapshot.instance.Vmid, snapshot.Name)
+}
+
+func (instance *SInstance) GetInstanceSnapshots() ([]cloudprovider.ICloudInstanceSnapshot, error) {
+	snapshots, err := instance.host.zone.region.GetInstanceSnapshots(instance.Node, instance.Vmid)
+	if err != nil {
+		return nil, err
+	}
+	ret := []cloudprovider.ICloudInstanceSnapshot{}
+	for i := range snapshots {
+		snapshots[i].instance = instance
+		ret = append(ret, &snapshots[i])
+	}
+	return ret, nil
+}
+
+func (instance *SInstance) GetInstanceSnapshot(idStr string) (cloudprovider.ICloudInstanceSnapshot, error) {
+	snapshots, err := instance.GetInstanceSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	for i := range snapshots {
+		if snapshots[i].GetGlobalId() == idStr {
+			return snapshots[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "instance snapshot %s", idStr)
+}
+
+func (instance *SInstance) CreateInstanceSnapshot(ctx context.Context, name string, desc string) (cloudprovider.ICloudInstanceSnapshot, error) {
+	name = snapname(name)
+	err := instance.host.zone.region.CreateInstanceSnapshot(instance.Node, instance.Vmid, name, desc)
+	if err != nil {
+		return nil, errors.Wrap(err, "CreateInstanceSnapshot")
+	}
+	return instance.GetInstanceSnapshot(fmt.Sprintf("%d/%s", instance.Vmid, name))
+}
+
+func (instance *SInstance) ResetToInstanceSnapshot(ctx context.Context, idStr string) error {
+	name := idStr
+	if idx := strings.Index(idStr, "/"); idx >= 0 {
+		name = idStr[idx+1:]
+	}
+	return instance.host.zone.region.RollbackInstanceSnapshot(instance.Node, instance.Vmid, name)
+}
@@ -0,0 +1,70 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"testing"
+)
+
+func TestParseOptions(t *testing.T) {
+	cases := []struct {
+		in     string
+		value  string
+		key    string
+		keyVal string
+	}{
+		{"local-lvm:vm-100-disk-0,cache=writeback,size=32G", "local-lvm:vm-100-disk-0", "size", "32G"},
+		{"virtio=DE:AD:BE:EF:00:01,bridge=vmbr0,firewall=1", "", "virtio", "DE:AD:BE:EF:00:01"},
+		{"ip=192.168.1.10/24,gw=192.168.1.1", "", "gw", "192.168.1.1"},
+	}
+	for _, c := range cases {
+		value, opts := parseOptions(c.in)
+		if value != c.value {
+			t.Errorf("parseOptions(%q) value %q, want %q", c.in, value, c.value)
+		}
+		if opts[c.key] != c.keyVal {
+			t.Errorf("parseOptions(%q) %s=%q, want %q", c.in, c.key, opts[c.key], c.keyVal)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int{
+		"":           0,
+		"32G":        32 * 1024,
+		"512M":       512,
+		"1T":         1024 * 1024,
+		"2048K":      2,
+		"1073741824": 1024,
+	}
+	for in, want := range cases {
+		if got := parseSize(in); got != want {
+			t.Errorf("parseSize(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestSnapname(t *testing.T) {
+	cases := map[string]string{
+		"daily-backup": "daily-backup",
+		"2020 backup":  "s2020_backup",
+		"快照":           "s__",
+	}
+	for in, want := range cases {
+		if got := snapname(in); got != want {
+			t.Errorf("snapname(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
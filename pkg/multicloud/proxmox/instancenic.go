@@ -0,0 +1,73 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+)
+
+type SInstanceNic struct {
+	instance *SInstance
+
+	Model  string
+	Mac    string
+	Bridge string
+	IpAddr string
+
+	cloudprovider.DummyICloudNic
+}
+
+func (nic *SInstanceNic) GetId() string {
+	return ""
+}
+
+func (nic *SInstanceNic) GetIP() string {
+	return nic.IpAddr
+}
+
+func (nic *SInstanceNic) GetMAC() string {
+	return nic.Mac
+}
+
+func (nic *SInstanceNic) GetDriver() string {
+	return nic.Model
+}
+
+func (nic *SInstanceNic) InClassicNetwork() bool {
+	return false
+}
+
+// GetINetwork returns network of the bridge nic plugged into
+func (nic *SInstanceNic) GetINetwork() cloudprovider.ICloudNetwork {
+	wires, err := nic.instance.host.zone.region.GetWires()
+	if err != nil {
+		log.Errorf("failed to get wires for nic %s error: %v", nic.Mac, err)
+		return nil
+	}
+	for i := range wires {
+		if wires[i].Iface != nic.Bridge {
+			continue
+		}
+		networks := wires[i].getNetworks()
+		for j := range networks {
+			if len(nic.IpAddr) == 0 || networks[j].Contains(nic.IpAddr) {
+				return &networks[j]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"fmt"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+)
+
+// SNetwork is the subnet of bridge address
+type SNetwork struct {
+	multicloud.SResourceBase
+	wire *SWire
+
+	prefix netutils.IPV4Prefix
+}
+
+func (region *SRegion) GetNetwork(networkId string) (*SNetwork, error) {
+	wires, err := region.GetWires()
+	if err != nil {
+		return nil, err
+	}
+	for i := range wires {
+		networks := wires[i].getNetworks()
+		for j := range networks {
+			if networks[j].GetGlobalId() == networkId {
+				return &networks[j], nil
+			}
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "network %s", networkId)
+}
+
+func (network *SNetwork) GetId() string {
+	return fmt.Sprintf("%s/%s", network.wire.Iface, network.prefix.String())
+}
+
+func (network *SNetwork) GetName() string {
+	return fmt.Sprintf("%s-%s", network.wire.Iface, network.prefix.String())
+}
+
+func (network *SNetwork) GetGlobalId() string {
+	return network.GetId()
+}
+
+func (network *SNetwork) GetStatus() string {
+	return api.NETWORK_STATUS_AVAILABLE
+}
+
+func (network *SNetwork) Delete() error {
+	return cloudprovider.ErrNotSupported
+}
+
+func (network *SNetwork) GetIWire() cloudprovider.ICloudWire {
+	return network.wire
+}
+
+func (network *SNetwork) GetAllocTimeoutSeconds() int {
+	return 120 // 2 minutes
+}
+
+func (network *SNetwork) GetGateway() string {
+	gateway, err := netutils.NewIPV4Addr(network.wire.Gateway)
+	if err != nil || !network.prefix.Contains(gateway) {
+		return ""
+	}
+	return network.wire.Gateway
+}
+
+func (network *SNetwork) GetIpStart() string {
+	return network.prefix.ToIPRange().StartIp().StepUp().String()
+}
+
+func (network *SNetwork) GetIpEnd() string {
+	return network.prefix.ToIPRange().EndIp().StepDown().String()
+}
+
+func (network *SNetwork) Contains(ipAddr string) bool {
+	ip, err := netutils.NewIPV4Addr(ipAddr)
+	if err != nil {
+		return false
+	}
+	return network.prefix.Contains(ip)
+}
+
+func (network *SNetwork) GetIpMask() int8 {
+	return network.prefix.MaskLen
+}
+
+func (network *SNetwork) GetIsPublic() bool {
+	return true
+}
+
+func (network *SNetwork) GetPublicScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func (network *SNetwork) GetServerType() string {
+	return api.NETWORK_TYPE_GUEST
+}
+
+func (network *SNetwork) GetProjectId() string {
+	return ""
+}
@@ -0,0 +1,156 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/multicloud/proxmox"
+)
+
+type SProxmoxProviderFactory struct {
+	cloudprovider.SPrivateCloudBaseProviderFactory
+}
+
+func (self *SProxmoxProviderFactory) GetId() string {
+	return proxmox.CLOUD_PROVIDER_PROXMOX
+}
+
+func (self *SProxmoxProviderFactory) GetName() string {
+	return proxmox.CLOUD_PROVIDER_PROXMOX
+}
+
+func (self *SProxmoxProviderFactory) ValidateCreateCloudaccountData(ctx context.Context, userCred mcclient.TokenCredential, input cloudprovider.SCloudaccountCredential) (cloudprovider.SCloudaccount, error) {
+	output := cloudprovider.SCloudaccount{}
+	if len(input.AuthUrl) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "auth_url")
+	}
+	output.AccessUrl = input.AuthUrl
+	// api token id, e.g. root@pam!onecloud, and the token secret
+	if len(input.AccessKeyId) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "access_key_id")
+	}
+	if len(input.AccessKeySecret) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "access_key_secret")
+	}
+	output.Account = input.AccessKeyId
+	output.Secret = input.AccessKeySecret
+	return output, nil
+}
+
+func (self *SProxmoxProviderFactory) ValidateUpdateCloudaccountCredential(ctx context.Context, userCred mcclient.TokenCredential, input cloudprovider.SCloudaccountCredential, cloudaccount string) (cloudprovider.SCloudaccount, error) {
+	output := cloudprovider.SCloudaccount{}
+	if len(input.AccessKeyId) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "access_key_id")
+	}
+	if len(input.AccessKeySecret) == 0 {
+		return output, errors.Wrap(httperrors.ErrMissingParameter, "access_key_secret")
+	}
+	output.Account = input.AccessKeyId
+	output.Secret = input.AccessKeySecret
+	return output, nil
+}
+
+func (self *SProxmoxProviderFactory) GetProvider(cfg cloudprovider.ProviderConfig) (cloudprovider.ICloudProvider, error) {
+	client, err := proxmox.NewProxmoxClient(
+		proxmox.NewProxmoxClientConfig(
+			cfg.URL, cfg.Account, cfg.Secret,
+		).CloudproviderConfig(cfg),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &SProxmoxProvider{
+		SBaseProvider: cloudprovider.NewBaseProvider(self),
+		client:        client,
+	}, nil
+}
+
+func (self *SProxmoxProviderFactory) GetClientRC(info cloudprovider.SProviderInfo) (map[string]string, error) {
+	return map[string]string{
+		"PROXMOX_AUTH_URL": info.Url,
+		"PROXMOX_TOKEN_ID": info.Account,
+		"PROXMOX_SECRET":   info.Secret,
+	}, nil
+}
+
+func init() {
+	factory := SProxmoxProviderFactory{}
+	cloudprovider.RegisterFactory(&factory)
+}
+
+type SProxmoxProvider struct {
+	cloudprovider.SBaseProvider
+	client *proxmox.SProxmoxClient
+}
+
+func (self *SProxmoxProvider) GetVersion() string {
+	return self.client.GetVersion()
+}
+
+func (self *SProxmoxProvider) GetSysInfo() (jsonutils.JSONObject, error) {
+	return jsonutils.NewDict(), nil
+}
+
+func (self *SProxmoxProvider) GetSubAccounts() ([]cloudprovider.SSubAccount, error) {
+	return self.client.GetSubAccounts()
+}
+
+func (self *SProxmoxProvider) GetAccountId() string {
+	return ""
+}
+
+func (self *SProxmoxProvider) GetIRegions() []cloudprovider.ICloudRegion {
+	return self.client.GetIRegions()
+}
+
+func (self *SProxmoxProvider) GetIRegionById(extId string) (cloudprovider.ICloudRegion, error) {
+	return self.client.GetIRegionById(extId)
+}
+
+func (self *SProxmoxProvider) GetBalance() (float64, string, error) {
+	return 0.0, api.CLOUD_PROVIDER_HEALTH_UNKNOWN, cloudprovider.ErrNotSupported
+}
+
+func (self *SProxmoxProvider) GetCloudRegionExternalIdPrefix() string {
+	return self.client.GetCloudRegionExternalIdPrefix()
+}
+
+func (self *SProxmoxProvider) GetIProjects() ([]cloudprovider.ICloudProject, error) {
+	return self.client.GetIProjects()
+}
+
+func (self *SProxmoxProvider) GetStorageClasses(regionId string) []string {
+	return nil
+}
+
+func (self *SProxmoxProvider) GetBucketCannedAcls(regionId string) []string {
+	return nil
+}
+
+func (self *SProxmoxProvider) GetObjectCannedAcls(regionId string) []string {
+	return nil
+}
+
+func (self *SProxmoxProvider) GetCapabilities() []string {
+	return self.client.GetCapabilities()
+}
@@ -0,0 +1,257 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+const (
+	CLOUD_PROVIDER_PROXMOX = api.CLOUD_PROVIDER_PROXMOX
+	PROXMOX_DEFAULT_REGION = "Proxmox"
+	PROXMOX_API_PREFIX     = "/api2/json"
+
+	PROXMOX_TASK_TIMEOUT = 10 * time.Minute
+)
+
+type ProxmoxClientConfig struct {
+	cpcfg cloudprovider.ProviderConfig
+
+	authURL string
+	// api token id in format of USER@REALM!TOKENID
+	tokenId string
+	secret  string
+
+	debug bool
+}
+
+func NewProxmoxClientConfig(authURL, tokenId, secret string) *ProxmoxClientConfig {
+	cfg := &ProxmoxClientConfig{
+		authURL: strings.TrimSuffix(authURL, "/"),
+		tokenId: tokenId,
+		secret:  secret,
+	}
+	return cfg
+}
+
+func (cfg *ProxmoxClientConfig) CloudproviderConfig(cpcfg cloudprovider.ProviderConfig) *ProxmoxClientConfig {
+	cfg.cpcfg = cpcfg
+	return cfg
+}
+
+func (cfg *ProxmoxClientConfig) Debug(debug bool) *ProxmoxClientConfig {
+	cfg.debug = debug
+	return cfg
+}
+
+type SProxmoxClient struct {
+	*ProxmoxClientConfig
+
+	httpClient *http.Client
+
+	version  string
+	iregions []cloudprovider.ICloudRegion
+}
+
+func NewProxmoxClient(cfg *ProxmoxClientConfig) (*SProxmoxClient, error) {
+	cli := &SProxmoxClient{
+		ProxmoxClientConfig: cfg,
+		httpClient:          cfg.cpcfg.AdaptiveTimeoutHttpClient(),
+	}
+	if err := cli.connect(); err != nil {
+		return nil, err
+	}
+	cli.iregions = []cloudprovider.ICloudRegion{&SRegion{client: cli, Name: PROXMOX_DEFAULT_REGION}}
+	return cli, nil
+}
+
+func (cli *SProxmoxClient) connect() error {
+	version := struct {
+		Version string
+		Release string
+	}{}
+	err := cli.get("/version", nil, &version)
+	if err != nil {
+		return errors.Wrap(err, "connect")
+	}
+	cli.version = version.Version
+	return nil
+}
+
+func (cli *SProxmoxClient) GetVersion() string {
+	return cli.version
+}
+
+func (cli *SProxmoxClient) GetCloudRegionExternalIdPrefix() string {
+	return fmt.Sprintf("%s/%s", CLOUD_PROVIDER_PROXMOX, cli.cpcfg.Id)
+}
+
+func (cli *SProxmoxClient) GetSubAccounts() ([]cloudprovider.SSubAccount, error) {
+	subAccount := cloudprovider.SSubAccount{
+		Account:      cli.tokenId,
+		Name:         cli.cpcfg.Name,
+		HealthStatus: api.CLOUD_PROVIDER_HEALTH_NORMAL,
+	}
+	return []cloudprovider.SSubAccount{subAccount}, nil
+}
+
+func (cli *SProxmoxClient) GetIRegions() []cloudprovider.ICloudRegion {
+	return cli.iregions
+}
+
+func (cli *SProxmoxClient) GetIRegionById(id string) (cloudprovider.ICloudRegion, error) {
+	for i := 0; i < len(cli.iregions); i++ {
+		if cli.iregions[i].GetGlobalId() == id {
+			return cli.iregions[i], nil
+		}
+	}
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (cli *SProxmoxClient) GetIProjects() ([]cloudprovider.ICloudProject, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (cli *SProxmoxClient) GetCapabilities() []string {
+	caps := []string{
+		cloudprovider.CLOUD_CAPABILITY_COMPUTE,
+		cloudprovider.CLOUD_CAPABILITY_NETWORK,
+	}
+	return caps
+}
+
+func (cli *SProxmoxClient) getRequestURL(resource string, params url.Values) string {
+	requestURL := cli.authURL + PROXMOX_API_PREFIX + resource
+	if len(params) > 0 {
+		requestURL += "?" + params.Encode()
+	}
+	return requestURL
+}
+
+// request sends api request authenticated by api token, proxmox wraps every
+// response in {"data": ...}
+func (cli *SProxmoxClient) request(method httputils.THttpMethod, resource string, params url.Values, body jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	header := http.Header{}
+	header.Set("Authorization", fmt.Sprintf("PVEAPIToken=%s=%s", cli.tokenId, cli.secret))
+	if body != nil {
+		header.Set("Content-Type", "application/json")
+	}
+	requestURL := cli.getRequestURL(resource, params)
+	_, resp, err := httputils.JSONRequest(cli.httpClient, context.Background(), method, requestURL, header, body, cli.debug)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s", method, resource)
+	}
+	if resp == nil || !resp.Contains("data") {
+		return jsonutils.JSONNull, nil
+	}
+	data, _ := resp.Get("data")
+	return data, nil
+}
+
+func (cli *SProxmoxClient) get(resource string, params url.Values, retVal interface{}) error {
+	data, err := cli.request(httputils.GET, resource, params, nil)
+	if err != nil {
+		return err
+	}
+	if data == jsonutils.JSONNull {
+		return errors.Wrapf(cloudprovider.ErrNotFound, "GET %s", resource)
+	}
+	return data.Unmarshal(retVal)
+}
+
+// post and put return the task id when proxmox runs the operation asynchronously
+func (cli *SProxmoxClient) post(resource string, params jsonutils.JSONObject) (string, error) {
+	data, err := cli.request(httputils.POST, resource, nil, params)
+	if err != nil {
+		return "", err
+	}
+	upid, _ := data.GetString()
+	return upid, nil
+}
+
+func (cli *SProxmoxClient) put(resource string, params jsonutils.JSONObject) (string, error) {
+	data, err := cli.request(httputils.PUT, resource, nil, params)
+	if err != nil {
+		return "", err
+	}
+	upid, _ := data.GetString()
+	return upid, nil
+}
+
+func (cli *SProxmoxClient) delete(resource string, params url.Values) (string, error) {
+	data, err := cli.request(httputils.DELETE, resource, params, nil)
+	if err != nil {
+		return "", err
+	}
+	upid, _ := data.GetString()
+	return upid, nil
+}
+
+type STaskStatus struct {
+	Upid       string
+	Node       string
+	Type       string
+	Status     string
+	Exitstatus string
+}
+
+// waitTask waits until the task identified by upid, e.g. UPID:pve1:0001A2B3:...:qmstart:100:root@pam:, stops
+func (cli *SProxmoxClient) waitTask(upid string) error {
+	if !strings.HasPrefix(upid, "UPID:") {
+		return nil
+	}
+	segs := strings.Split(upid, ":")
+	if len(segs) < 2 {
+		return fmt.Errorf("invalid task id %s", upid)
+	}
+	resource := fmt.Sprintf("/nodes/%s/tasks/%s/status", segs[1], url.PathEscape(upid))
+	startTime := time.Now()
+	for time.Now().Sub(startTime) < PROXMOX_TASK_TIMEOUT {
+		status := STaskStatus{}
+		err := cli.get(resource, nil, &status)
+		if err != nil {
+			return errors.Wrapf(err, "get task %s status", upid)
+		}
+		if status.Status == "stopped" {
+			if status.Exitstatus != "OK" {
+				return fmt.Errorf("task %s failed: %s", upid, status.Exitstatus)
+			}
+			return nil
+		}
+		time.Sleep(2 * time.Second)
+	}
+	return errors.Wrapf(cloudprovider.ErrTimeout, "wait task %s", upid)
+}
+
+// postAndWait runs an asynchronous operation and waits for its task to complete
+func (cli *SProxmoxClient) postAndWait(resource string, params jsonutils.JSONObject) error {
+	upid, err := cli.post(resource, params)
+	if err != nil {
+		return err
+	}
+	return cli.waitTask(upid)
+}
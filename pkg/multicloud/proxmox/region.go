@@ -0,0 +1,259 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"fmt"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+type SRegion struct {
+	multicloud.SRegion
+	multicloud.SNoObjectStorageRegion
+
+	client *SProxmoxClient
+
+	Name string
+
+	izones []cloudprovider.ICloudZone
+	ivpcs  []cloudprovider.ICloudVpc
+}
+
+func (region *SRegion) GetClient() *SProxmoxClient {
+	return region.client
+}
+
+func (region *SRegion) GetId() string {
+	return region.Name
+}
+
+func (region *SRegion) GetName() string {
+	return region.client.cpcfg.Name
+}
+
+func (region *SRegion) GetI18n() cloudprovider.SModelI18nTable {
+	table := cloudprovider.SModelI18nTable{}
+	table["name"] = cloudprovider.NewSModelI18nEntry(region.GetName()).CN(region.GetName())
+	return table
+}
+
+func (region *SRegion) GetGlobalId() string {
+	return fmt.Sprintf("%s/%s", CLOUD_PROVIDER_PROXMOX, region.client.cpcfg.Id)
+}
+
+func (region *SRegion) GetProvider() string {
+	return CLOUD_PROVIDER_PROXMOX
+}
+
+func (region *SRegion) GetCloudEnv() string {
+	return ""
+}
+
+func (region *SRegion) GetGeographicInfo() cloudprovider.SGeographicInfo {
+	return cloudprovider.SGeographicInfo{}
+}
+
+func (region *SRegion) GetStatus() string {
+	return api.CLOUD_REGION_STATUS_INSERVER
+}
+
+func (region *SRegion) GetZone() *SZone {
+	return &SZone{region: region}
+}
+
+func (region *SRegion) GetIZones() ([]cloudprovider.ICloudZone, error) {
+	if region.izones == nil {
+		zone := region.GetZone()
+		err := zone.fetchClusterName()
+		if err != nil {
+			return nil, err
+		}
+		region.izones = []cloudprovider.ICloudZone{zone}
+	}
+	return region.izones, nil
+}
+
+func (region *SRegion) GetIZoneById(id string) (cloudprovider.ICloudZone, error) {
+	izones, err := region.GetIZones()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(izones); i++ {
+		if izones[i].GetGlobalId() == id {
+			return izones[i], nil
+		}
+	}
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) getZone() (*SZone, error) {
+	izones, err := region.GetIZones()
+	if err != nil {
+		return nil, err
+	}
+	return izones[0].(*SZone), nil
+}
+
+func (region *SRegion) GetVpc() *SVpc {
+	return &SVpc{region: region}
+}
+
+func (region *SRegion) GetIVpcs() ([]cloudprovider.ICloudVpc, error) {
+	region.ivpcs = []cloudprovider.ICloudVpc{region.GetVpc()}
+	return region.ivpcs, nil
+}
+
+func (region *SRegion) GetIVpcById(vpcId string) (cloudprovider.ICloudVpc, error) {
+	vpc := region.GetVpc()
+	if vpc.GetGlobalId() != vpcId {
+		return nil, cloudprovider.ErrNotFound
+	}
+	return vpc, nil
+}
+
+func (region *SRegion) CreateIVpc(name string, desc string, cidr string) (cloudprovider.ICloudVpc, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetIHosts() ([]cloudprovider.ICloudHost, error) {
+	zone, err := region.getZone()
+	if err != nil {
+		return nil, err
+	}
+	return zone.GetIHosts()
+}
+
+func (region *SRegion) GetIHostById(id string) (cloudprovider.ICloudHost, error) {
+	zone, err := region.getZone()
+	if err != nil {
+		return nil, err
+	}
+	return zone.GetIHostById(id)
+}
+
+func (region *SRegion) GetIStorages() ([]cloudprovider.ICloudStorage, error) {
+	zone, err := region.getZone()
+	if err != nil {
+		return nil, err
+	}
+	return zone.GetIStorages()
+}
+
+func (region *SRegion) GetIStorageById(id string) (cloudprovider.ICloudStorage, error) {
+	zone, err := region.getZone()
+	if err != nil {
+		return nil, err
+	}
+	return zone.GetIStorageById(id)
+}
+
+func (region *SRegion) GetIStoragecaches() ([]cloudprovider.ICloudStoragecache, error) {
+	return []cloudprovider.ICloudStoragecache{region.GetStoragecache()}, nil
+}
+
+func (region *SRegion) GetIStoragecacheById(id string) (cloudprovider.ICloudStoragecache, error) {
+	cache := region.GetStoragecache()
+	if cache.GetGlobalId() != id {
+		return nil, cloudprovider.ErrNotFound
+	}
+	return cache, nil
+}
+
+func (region *SRegion) GetIVMById(id string) (cloudprovider.ICloudVM, error) {
+	return region.GetInstance(id)
+}
+
+func (region *SRegion) GetIDiskById(id string) (cloudprovider.ICloudDisk, error) {
+	return region.GetDisk(id)
+}
+
+func (region *SRegion) GetIEips() ([]cloudprovider.ICloudEIP, error) {
+	return []cloudprovider.ICloudEIP{}, nil
+}
+
+func (region *SRegion) GetIEipById(eipId string) (cloudprovider.ICloudEIP, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) CreateEIP(eip *cloudprovider.SEip) (cloudprovider.ICloudEIP, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetISnapshots() ([]cloudprovider.ICloudSnapshot, error) {
+	return []cloudprovider.ICloudSnapshot{}, nil
+}
+
+func (region *SRegion) GetISnapshotById(snapshotId string) (cloudprovider.ICloudSnapshot, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) GetISecurityGroupById(secgroupId string) (cloudprovider.ICloudSecurityGroup, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) GetISecurityGroupByName(opts *cloudprovider.SecurityGroupFilterOptions) (cloudprovider.ICloudSecurityGroup, error) {
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (region *SRegion) CreateISecurityGroup(conf *cloudprovider.SecurityGroupCreateInput) (cloudprovider.ICloudSecurityGroup, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetILoadBalancers() ([]cloudprovider.ICloudLoadbalancer, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetILoadBalancerById(loadbalancerId string) (cloudprovider.ICloudLoadbalancer, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetILoadBalancerAclById(aclId string) (cloudprovider.ICloudLoadbalancerAcl, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetILoadBalancerCertificateById(certId string) (cloudprovider.ICloudLoadbalancerCertificate, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetILoadBalancerAcls() ([]cloudprovider.ICloudLoadbalancerAcl, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetILoadBalancerCertificates() ([]cloudprovider.ICloudLoadbalancerCertificate, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetILoadBalancerBackendGroups() ([]cloudprovider.ICloudLoadbalancerBackendGroup, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) CreateILoadBalancer(loadbalancer *cloudprovider.SLoadbalancer) (cloudprovider.ICloudLoadbalancer, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) CreateILoadBalancerAcl(acl *cloudprovider.SLoadbalancerAccessControlList) (cloudprovider.ICloudLoadbalancerAcl, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) CreateILoadBalancerCertificate(cert *cloudprovider.SLoadbalancerCertificate) (cloudprovider.ICloudLoadbalancerCertificate, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (region *SRegion) GetCapabilities() []string {
+	return region.client.GetCapabilities()
+}
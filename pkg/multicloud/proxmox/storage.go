@@ -0,0 +1,182 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// SStorage is a proxmox storage able to keep vm disks, storages not shared
+// among nodes are distinguished by node
+type SStorage struct {
+	multicloud.SResourceBase
+	zone *SZone
+	// node the storage is fetched from
+	node string
+
+	Storage string
+	Type    string
+	Content string
+	Active  int
+	Enabled int
+	Shared  int
+	Total   int64
+	Used    int64
+	Avail   int64
+}
+
+func storageGlobalId(node, storage string) string {
+	return fmt.Sprintf("%s/%s", node, storage)
+}
+
+func (storage *SStorage) isImageStore() bool {
+	for _, content := range strings.Split(storage.Content, ",") {
+		if content == "images" {
+			return true
+		}
+	}
+	return false
+}
+
+func (storage *SStorage) GetId() string {
+	if storage.Shared == 1 {
+		return storage.Storage
+	}
+	return storageGlobalId(storage.node, storage.Storage)
+}
+
+func (storage *SStorage) GetName() string {
+	if storage.Shared == 1 {
+		return storage.Storage
+	}
+	return fmt.Sprintf("%s-%s", storage.node, storage.Storage)
+}
+
+func (storage *SStorage) GetGlobalId() string {
+	return storage.GetId()
+}
+
+func (storage *SStorage) GetStatus() string {
+	if storage.Active == 1 {
+		return api.STORAGE_ONLINE
+	}
+	return api.STORAGE_OFFLINE
+}
+
+func (storage *SStorage) GetIZone() cloudprovider.ICloudZone {
+	return storage.zone
+}
+
+func (storage *SStorage) GetIStoragecache() cloudprovider.ICloudStoragecache {
+	return storage.zone.region.GetStoragecache()
+}
+
+func (storage *SStorage) GetStorageType() string {
+	return storage.Type
+}
+
+func (storage *SStorage) GetMediumType() string {
+	return api.DISK_TYPE_ROTATE
+}
+
+func (storage *SStorage) GetCapacityMB() int64 {
+	return storage.Total / 1024 / 1024
+}
+
+func (storage *SStorage) GetCapacityUsedMB() int64 {
+	return storage.Used / 1024 / 1024
+}
+
+func (storage *SStorage) GetStorageConf() jsonutils.JSONObject {
+	conf := jsonutils.NewDict()
+	conf.Add(jsonutils.NewString(storage.Storage), "storage")
+	conf.Add(jsonutils.NewString(storage.Type), "type")
+	return conf
+}
+
+func (storage *SStorage) GetEnabled() bool {
+	return storage.Enabled == 1
+}
+
+func (storage *SStorage) GetMountPoint() string {
+	return ""
+}
+
+func (storage *SStorage) IsSysDiskStore() bool {
+	return true
+}
+
+func (storage *SStorage) getDisks() ([]SDisk, error) {
+	resource := fmt.Sprintf("/nodes/%s/storage/%s/content", storage.node, storage.Storage)
+	disks := []SDisk{}
+	err := storage.zone.region.client.get(resource, url.Values{"content": []string{"images"}}, &disks)
+	if err != nil {
+		return nil, err
+	}
+	ret := []SDisk{}
+	for i := range disks {
+		name := volumeName(disks[i].VolId)
+		// cloud-init drives and disks of templates are not managed as disks
+		if strings.Contains(name, "cloudinit") || strings.HasPrefix(name, "base-") {
+			continue
+		}
+		disks[i].storage = storage
+		ret = append(ret, disks[i])
+	}
+	return ret, nil
+}
+
+func (storage *SStorage) getDisk(volid string) (*SDisk, error) {
+	disks, err := storage.getDisks()
+	if err != nil {
+		return nil, err
+	}
+	for i := range disks {
+		if disks[i].VolId == volid {
+			return &disks[i], nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "disk %s", volid)
+}
+
+func (storage *SStorage) GetIDisks() ([]cloudprovider.ICloudDisk, error) {
+	disks, err := storage.getDisks()
+	if err != nil {
+		return nil, err
+	}
+	idisks := []cloudprovider.ICloudDisk{}
+	for i := 0; i < len(disks); i++ {
+		idisks = append(idisks, &disks[i])
+	}
+	return idisks, nil
+}
+
+func (storage *SStorage) GetIDiskById(idStr string) (cloudprovider.ICloudDisk, error) {
+	return storage.getDisk(idStr)
+}
+
+// CreateIDisk is not supported, proxmox volumes must belong to a vm
+func (storage *SStorage) CreateIDisk(conf *cloudprovider.DiskCreateConfig) (cloudprovider.ICloudDisk, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// SStoragecache keeps vm templates of the cluster as images
+type SStoragecache struct {
+	multicloud.SResourceBase
+	region *SRegion
+}
+
+func (region *SRegion) GetStoragecache() *SStoragecache {
+	return &SStoragecache{region: region}
+}
+
+func (scache *SStoragecache) GetId() string {
+	return fmt.Sprintf("%s-%s", scache.region.client.cpcfg.Id, scache.region.GetId())
+}
+
+func (scache *SStoragecache) GetName() string {
+	return fmt.Sprintf("%s-%s", scache.region.client.cpcfg.Name, scache.region.GetId())
+}
+
+func (scache *SStoragecache) GetGlobalId() string {
+	return scache.GetId()
+}
+
+func (scache *SStoragecache) GetStatus() string {
+	return "available"
+}
+
+func (scache *SStoragecache) GetPath() string {
+	return ""
+}
+
+func (scache *SStoragecache) getImages() ([]SImage, error) {
+	zone, err := scache.region.getZone()
+	if err != nil {
+		return nil, err
+	}
+	instances, err := scache.region.GetInstances("")
+	if err != nil {
+		return nil, err
+	}
+	images := []SImage{}
+	for i := range instances {
+		if !instances[i].isTemplate() {
+			continue
+		}
+		instances[i].host, err = zone.getHost(instances[i].Node)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, SImage{cache: scache, SInstance: instances[i]})
+	}
+	return images, nil
+}
+
+func (scache *SStoragecache) GetICloudImages() ([]cloudprovider.ICloudImage, error) {
+	images, err := scache.getImages()
+	if err != nil {
+		return nil, err
+	}
+	iimages := []cloudprovider.ICloudImage{}
+	for i := 0; i < len(images); i++ {
+		iimages = append(iimages, &images[i])
+	}
+	return iimages, nil
+}
+
+func (scache *SStoragecache) GetICustomizedCloudImages() ([]cloudprovider.ICloudImage, error) {
+	return nil, cloudprovider.ErrNotImplemented
+}
+
+func (scache *SStoragecache) GetIImageById(extId string) (cloudprovider.ICloudImage, error) {
+	images, err := scache.getImages()
+	if err != nil {
+		return nil, err
+	}
+	for i := range images {
+		if images[i].GetGlobalId() == extId {
+			return &images[i], nil
+		}
+	}
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (scache *SStoragecache) CreateIImage(snapshotId, imageName, osType, imageDesc string) (cloudprovider.ICloudImage, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+func (scache *SStoragecache) DownloadImage(userCred mcclient.TokenCredential, imageId string, extId string, path string) (jsonutils.JSONObject, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
+
+// UploadImage is not supported, images are made by converting vms to templates in proxmox
+func (scache *SStoragecache) UploadImage(ctx context.Context, userCred mcclient.TokenCredential, image *cloudprovider.SImageCreateOption, isForce bool) (string, error) {
+	return "", cloudprovider.ErrNotSupported
+}
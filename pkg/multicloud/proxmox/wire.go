@@ -0,0 +1,155 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"fmt"
+	"net/url"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// SWire is a linux bridge or ovs bridge, bridges of the same name on nodes are one wire
+type SWire struct {
+	multicloud.SResourceBase
+	vpc *SVpc
+
+	Iface   string
+	Type    string
+	Cidr    string
+	Gateway string
+	Active  int
+
+	nodes []string
+}
+
+func (region *SRegion) GetWires() ([]SWire, error) {
+	zone, err := region.getZone()
+	if err != nil {
+		return nil, err
+	}
+	ihosts, err := zone.GetIHosts()
+	if err != nil {
+		return nil, err
+	}
+	wires := []SWire{}
+	index := map[string]int{}
+	for i := range ihosts {
+		host := ihosts[i].(*SHost)
+		if !host.isOnline() {
+			continue
+		}
+		bridges := []SWire{}
+		err := region.client.get(fmt.Sprintf("/nodes/%s/network", host.Node), url.Values{"type": []string{"any_bridge"}}, &bridges)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get bridges of node %s", host.Node)
+		}
+		for j := range bridges {
+			idx, ok := index[bridges[j].Iface]
+			if !ok {
+				bridges[j].vpc = region.GetVpc()
+				wires = append(wires, bridges[j])
+				idx = len(wires) - 1
+				index[bridges[j].Iface] = idx
+			}
+			wire := &wires[idx]
+			wire.nodes = append(wire.nodes, host.Node)
+			if len(wire.Cidr) == 0 {
+				wire.Cidr = bridges[j].Cidr
+			}
+			if len(wire.Gateway) == 0 {
+				wire.Gateway = bridges[j].Gateway
+			}
+		}
+	}
+	return wires, nil
+}
+
+func (wire *SWire) hasNode(node string) bool {
+	for i := range wire.nodes {
+		if wire.nodes[i] == node {
+			return true
+		}
+	}
+	return false
+}
+
+func (wire *SWire) GetId() string {
+	return wire.Iface
+}
+
+func (wire *SWire) GetName() string {
+	return wire.Iface
+}
+
+func (wire *SWire) GetGlobalId() string {
+	return wire.GetId()
+}
+
+func (wire *SWire) GetStatus() string {
+	return "available"
+}
+
+func (wire *SWire) GetIVpc() cloudprovider.ICloudVpc {
+	return wire.vpc
+}
+
+func (wire *SWire) GetIZone() cloudprovider.ICloudZone {
+	zone, _ := wire.vpc.region.getZone()
+	return zone
+}
+
+func (wire *SWire) GetBandwidth() int {
+	return 10000
+}
+
+// getNetworks returns the network of bridge address, bridges without address carry no network
+func (wire *SWire) getNetworks() []SNetwork {
+	if len(wire.Cidr) == 0 {
+		return []SNetwork{}
+	}
+	prefix, err := netutils.NewIPV4Prefix(wire.Cidr)
+	if err != nil {
+		return []SNetwork{}
+	}
+	return []SNetwork{{wire: wire, prefix: prefix}}
+}
+
+func (wire *SWire) GetINetworks() ([]cloudprovider.ICloudNetwork, error) {
+	networks := wire.getNetworks()
+	inetworks := []cloudprovider.ICloudNetwork{}
+	for i := 0; i < len(networks); i++ {
+		inetworks = append(inetworks, &networks[i])
+	}
+	return inetworks, nil
+}
+
+func (wire *SWire) GetINetworkById(netid string) (cloudprovider.ICloudNetwork, error) {
+	networks := wire.getNetworks()
+	for i := range networks {
+		if networks[i].GetGlobalId() == netid {
+			return &networks[i], nil
+		}
+	}
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (wire *SWire) CreateINetwork(opts *cloudprovider.SNetworkCreateOptions) (cloudprovider.ICloudNetwork, error) {
+	return nil, cloudprovider.ErrNotSupported
+}
@@ -0,0 +1,202 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxmox
+
+import (
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudprovider"
+	"yunion.io/x/onecloud/pkg/multicloud"
+)
+
+// SZone is the proxmox cluster, or the node itself when it does not join any cluster
+type SZone struct {
+	multicloud.SResourceBase
+	region *SRegion
+
+	Name string
+
+	ihosts    []cloudprovider.ICloudHost
+	istorages []cloudprovider.ICloudStorage
+}
+
+type SClusterStatus struct {
+	Type   string
+	Id     string
+	Name   string
+	Ip     string
+	Online int
+	Local  int
+}
+
+func (region *SRegion) GetClusterStatus() ([]SClusterStatus, error) {
+	status := []SClusterStatus{}
+	return status, region.client.get("/cluster/status", nil, &status)
+}
+
+func (zone *SZone) fetchClusterName() error {
+	status, err := zone.region.GetClusterStatus()
+	if err != nil {
+		return errors.Wrap(err, "GetClusterStatus")
+	}
+	for i := range status {
+		if status[i].Type == "cluster" {
+			zone.Name = status[i].Name
+			return nil
+		}
+	}
+	for i := range status {
+		if status[i].Type == "node" {
+			zone.Name = status[i].Name
+			return nil
+		}
+	}
+	return errors.Wrap(cloudprovider.ErrNotFound, "cluster name")
+}
+
+func (zone *SZone) GetId() string {
+	return zone.Name
+}
+
+func (zone *SZone) GetName() string {
+	return zone.Name
+}
+
+func (zone *SZone) GetI18n() cloudprovider.SModelI18nTable {
+	table := cloudprovider.SModelI18nTable{}
+	table["name"] = cloudprovider.NewSModelI18nEntry(zone.GetName()).CN(zone.GetName())
+	return table
+}
+
+func (zone *SZone) GetGlobalId() string {
+	return zone.GetId()
+}
+
+func (zone *SZone) GetStatus() string {
+	return api.ZONE_ENABLE
+}
+
+func (zone *SZone) GetIRegion() cloudprovider.ICloudRegion {
+	return zone.region
+}
+
+func (zone *SZone) fetchHosts() error {
+	hosts, err := zone.region.GetHosts()
+	if err != nil {
+		return err
+	}
+	zone.ihosts = []cloudprovider.ICloudHost{}
+	for i := 0; i < len(hosts); i++ {
+		hosts[i].zone = zone
+		zone.ihosts = append(zone.ihosts, &hosts[i])
+	}
+	return nil
+}
+
+func (zone *SZone) GetIHosts() ([]cloudprovider.ICloudHost, error) {
+	if zone.ihosts == nil {
+		if err := zone.fetchHosts(); err != nil {
+			return nil, err
+		}
+	}
+	return zone.ihosts, nil
+}
+
+func (zone *SZone) GetIHostById(hostId string) (cloudprovider.ICloudHost, error) {
+	ihosts, err := zone.GetIHosts()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(ihosts); i++ {
+		if ihosts[i].GetGlobalId() == hostId {
+			return ihosts[i], nil
+		}
+	}
+	return nil, cloudprovider.ErrNotFound
+}
+
+func (zone *SZone) getHost(node string) (*SHost, error) {
+	ihost, err := zone.GetIHostById(node)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get node %s", node)
+	}
+	return ihost.(*SHost), nil
+}
+
+// fetchStorages collects storages of every node, storages shared among nodes appear once
+func (zone *SZone) fetchStorages() error {
+	ihosts, err := zone.GetIHosts()
+	if err != nil {
+		return err
+	}
+	zone.istorages = []cloudprovider.ICloudStorage{}
+	ids := map[string]bool{}
+	for i := 0; i < len(ihosts); i++ {
+		host := ihosts[i].(*SHost)
+		if !host.isOnline() {
+			continue
+		}
+		storages, err := host.getStorages()
+		if err != nil {
+			return errors.Wrapf(err, "get storages of node %s", host.Node)
+		}
+		for j := 0; j < len(storages); j++ {
+			if ids[storages[j].GetGlobalId()] {
+				continue
+			}
+			ids[storages[j].GetGlobalId()] = true
+			zone.istorages = append(zone.istorages, &storages[j])
+		}
+	}
+	return nil
+}
+
+func (zone *SZone) GetIStorages() ([]cloudprovider.ICloudStorage, error) {
+	if zone.istorages == nil {
+		if err := zone.fetchStorages(); err != nil {
+			return nil, err
+		}
+	}
+	return zone.istorages, nil
+}
+
+func (zone *SZone) GetIStorageById(storageId string) (cloudprovider.ICloudStorage, error) {
+	istorages, err := zone.GetIStorages()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(istorages); i++ {
+		if istorages[i].GetGlobalId() == storageId {
+			return istorages[i], nil
+		}
+	}
+	return nil, cloudprovider.ErrNotFound
+}
+
+// getStorageByName returns storage named name accessible from node
+func (zone *SZone) getStorageByName(node, name string) (*SStorage, error) {
+	istorages, err := zone.GetIStorages()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(istorages); i++ {
+		storage := istorages[i].(*SStorage)
+		if storage.Storage == name && (storage.Shared == 1 || storage.node == node) {
+			return storage, nil
+		}
+	}
+	return nil, errors.Wrapf(cloudprovider.ErrNotFound, "storage %s of node %s", name, node)
+}
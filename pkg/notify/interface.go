@@ -32,6 +32,7 @@ type INotifyService interface {
 	ContactByMobile(ctx context.Context, mobile, serviceName string) (string, error)
 	BatchSend(ctx context.Context, contactType string, args apis.BatchSendParams) ([]*apis.FailedRecord, error)
 	ValidateConfig(ctx context.Context, cType string, configs map[string]string) (isValid bool, message string, err error)
+	SetRateLimit(serviceName string, limit float64, burst int)
 }
 
 type SSendParams struct {
@@ -61,6 +61,8 @@ type SConfig struct {
 
 	Type    string               `width:"15" nullable:"false" create:"required" get:"admin" list:"admin"`
 	Content jsonutils.JSONObject `nullable:"false" create:"required" update:"admin" get:"admin" list:"admin"`
+	// retry and rate limit of delivering notifications by this type
+	DeliveryPolicy *api.SDeliveryPolicy `nullable:"true" create:"admin_optional" update:"admin" get:"admin" list:"admin"`
 }
 
 func (cm *SConfigManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.ConfigCreateInput) (api.ConfigCreateInput, error) {
@@ -75,6 +77,10 @@ func (cm *SConfigManager) ValidateCreateData(ctx context.Context, userCred mccli
 	if input.Content == nil {
 		return input, httperrors.NewMissingParameterError("content")
 	}
+	err = validateDeliveryPolicy(input.DeliveryPolicy)
+	if err != nil {
+		return input, err
+	}
 	config, err := cm.GetConfigByType(input.Type)
 	if err == nil && config != nil {
 		return input, httperrors.NewDuplicateResourceError("duplicate type %q", input.Type)
@@ -105,9 +111,16 @@ func (cm *SConfigManager) ValidateCreateData(ctx context.Context, userCred mccli
 }
 
 func (c *SConfig) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ConfigUpdateInput) (api.ConfigUpdateInput, error) {
+	err := validateDeliveryPolicy(input.DeliveryPolicy)
+	if err != nil {
+		return input, err
+	}
+	if input.Content == nil {
+		return input, nil
+	}
 	// validate
 	configs := make(map[string]string)
-	err := input.Content.Unmarshal(&configs)
+	err = input.Content.Unmarshal(&configs)
 	if err != nil {
 		return input, err
 	}
@@ -129,6 +142,7 @@ func (c *SConfig) ValidateUpdateData(ctx context.Context, userCred mcclient.Toke
 }
 
 func (c *SConfig) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	c.resetRateLimit()
 	err := c.StartRepullSubcontactTask(ctx, userCred)
 	if err != nil {
 		log.Errorf("unable to StartRepullSubcontactTask: %v", err)
@@ -137,6 +151,12 @@ func (c *SConfig) PostCreate(ctx context.Context, userCred mcclient.TokenCredent
 
 func (c *SConfig) PostUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
 	c.SStandaloneResourceBase.PostUpdate(ctx, userCred, query, data)
+	if data.Contains("delivery_policy") {
+		c.resetRateLimit()
+	}
+	if !data.Contains("content") {
+		return
+	}
 	configMap := make(map[string]string)
 	err := c.Content.Unmarshal(&configMap)
 	if err != nil {
@@ -150,6 +170,12 @@ func (c *SConfig) PostUpdate(ctx context.Context, userCred mcclient.TokenCredent
 	}
 }
 
+// resetRateLimit applies rate limit in delivery policy to the send agent
+func (c *SConfig) resetRateLimit() {
+	policy := ConfigManager.DeliveryPolicy(c.Type)
+	NotifyService.SetRateLimit(c.Type, policy.RateLimit, policy.RateBurst)
+}
+
 func (c *SConfig) StartRepullSubcontactTask(ctx context.Context, userCred mcclient.TokenCredential) error {
 	task, err := taskman.TaskManager.NewTask(ctx, "RepullSuncontactTask", c, userCred, nil, "", "")
 	if err != nil {
@@ -0,0 +1,296 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/notify/options"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SDeliveryAttemptManager struct {
+	db.SStandaloneAnonResourceBaseManager
+}
+
+var DeliveryAttemptManager *SDeliveryAttemptManager
+
+func init() {
+	DeliveryAttemptManager = &SDeliveryAttemptManager{
+		SStandaloneAnonResourceBaseManager: db.NewStandaloneAnonResourceBaseManager(
+			SDeliveryAttempt{},
+			"deliveryattempts_tbl",
+			"deliveryattempt",
+			"deliveryattempts",
+		),
+	}
+	DeliveryAttemptManager.SetVirtualObject(DeliveryAttemptManager)
+}
+
+// SDeliveryAttempt records every attempt to deliver a notification to a contact by send agent
+type SDeliveryAttempt struct {
+	db.SStandaloneAnonResourceBase
+
+	NotificationId string `width:"128" charset:"ascii" nullable:"false" index:"true" list:"admin"`
+	ReceiverId     string `width:"128" charset:"ascii" nullable:"false" index:"true" list:"admin"`
+	Contact        string `width:"128" nullable:"true" list:"admin"`
+	ContactType    string `width:"16" nullable:"false" index:"true" list:"admin"`
+	// sequence of this attempt, starts from 1
+	Attempt      int    `nullable:"false" list:"admin"`
+	Status       string `width:"16" charset:"ascii" nullable:"false" list:"admin"`
+	FailedReason string `width:"1024" nullable:"true" list:"admin"`
+}
+
+func (am *SDeliveryAttemptManager) ResourceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func (am *SDeliveryAttemptManager) AllowListItems(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowList(userCred, am)
+}
+
+func (a *SDeliveryAttempt) AllowGetDetails(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowGet(userCred, a)
+}
+
+func (am *SDeliveryAttemptManager) InitializeData() error {
+	return dataCleaning(am.TableSpec().Name())
+}
+
+func (am *SDeliveryAttemptManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, input api.DeliveryAttemptListInput) (*sqlchemy.SQuery, error) {
+	q, err := am.SStandaloneAnonResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StandaloneAnonResourceListInput)
+	if err != nil {
+		return nil, err
+	}
+	if len(input.NotificationId) > 0 {
+		q = q.Equals("notification_id", input.NotificationId)
+	}
+	if len(input.ReceiverId) > 0 {
+		q = q.Equals("receiver_id", input.ReceiverId)
+	}
+	if len(input.ContactType) > 0 {
+		q = q.Equals("contact_type", input.ContactType)
+	}
+	if len(input.Status) > 0 {
+		q = q.Equals("status", input.Status)
+	}
+	return q, nil
+}
+
+func (am *SDeliveryAttemptManager) FetchCustomizeColumns(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, objs []interface{}, fields stringutils2.SSortedStrings, isList bool) []api.DeliveryAttemptDetails {
+	sRows := am.SStandaloneAnonResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	rows := make([]api.DeliveryAttemptDetails, len(objs))
+	for i := range rows {
+		rows[i].StandaloneAnonResourceDetails = sRows[i]
+	}
+	return rows
+}
+
+func (am *SDeliveryAttemptManager) record(ctx context.Context, rn *SReceiverNotification, contactType string, success bool, reason string) {
+	attempt := &SDeliveryAttempt{
+		NotificationId: rn.NotificationID,
+		ReceiverId:     rn.ReceiverID,
+		Contact:        rn.Contact,
+		ContactType:    contactType,
+		Attempt:        rn.Attempts,
+		Status:         api.DELIVERY_ATTEMPT_OK,
+	}
+	if !success {
+		attempt.Status = api.DELIVERY_ATTEMPT_FAILED
+		attempt.FailedReason = reason
+	}
+	attempt.SetModelManager(am, attempt)
+	err := am.TableSpec().Insert(ctx, attempt)
+	if err != nil {
+		log.Errorf("unable to record delivery attempt of notification %s: %v", rn.NotificationID, err)
+	}
+}
+
+// DeliveryPolicy returns delivery policy of contact type, unset fields are filled with defaults in options
+func (cm *SConfigManager) DeliveryPolicy(contactType string) api.SDeliveryPolicy {
+	policy := api.SDeliveryPolicy{}
+	config, err := cm.GetConfigByType(contactType)
+	if err == nil {
+		if config.DeliveryPolicy != nil {
+			policy = *config.DeliveryPolicy
+		}
+	} else if errors.Cause(err) != sql.ErrNoRows {
+		log.Errorf("unable to fetch config of %s: %v", contactType, err)
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = options.Options.DeliveryMaxAttempts
+	}
+	if policy.BackoffSeconds <= 0 {
+		policy.BackoffSeconds = options.Options.DeliveryRetryBackoff
+	}
+	if policy.MaxBackoffSeconds <= 0 {
+		policy.MaxBackoffSeconds = options.Options.DeliveryRetryMaxBackoff
+	}
+	if policy.RateLimit <= 0 {
+		policy.RateLimit = float64(options.Options.DeliveryRateLimit)
+	}
+	if policy.RateBurst <= 0 {
+		policy.RateBurst = options.Options.DeliveryRateBurst
+	}
+	return policy
+}
+
+func validateDeliveryPolicy(policy *api.SDeliveryPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.MaxAttempts < 0 || policy.BackoffSeconds < 0 || policy.MaxBackoffSeconds < 0 || policy.RateLimit < 0 || policy.RateBurst < 0 {
+		return httperrors.NewInputParameterError("fields of delivery_policy must not be negative")
+	}
+	if policy.MaxBackoffSeconds > 0 && policy.MaxBackoffSeconds < policy.BackoffSeconds {
+		return httperrors.NewInputParameterError("max_backoff_seconds must not be less than backoff_seconds")
+	}
+	return nil
+}
+
+// retryBackoff returns time to wait before retrying after the attempts-th failure
+func retryBackoff(policy api.SDeliveryPolicy, attempts int) time.Duration {
+	backoff := time.Duration(policy.BackoffSeconds) * time.Second
+	maxBackoff := time.Duration(policy.MaxBackoffSeconds) * time.Second
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if maxBackoff > 0 && backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+func (nm *SNotificationManager) AllowGetPropertyDeadLetters(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowGetSpec(userCred, nm, "dead-letters")
+}
+
+// GetPropertyDeadLetters lists deliveries which are not retried anymore
+func (nm *SNotificationManager) GetPropertyDeadLetters(ctx context.Context, userCred mcclient.TokenCredential, input api.NotificationDeadLetterListInput) (api.NotificationDeadLetterListOutput, error) {
+	output := api.NotificationDeadLetterListOutput{}
+	rnq := ReceiverNotificationManager.Query().Equals("status", api.RECEIVER_NOTIFICATION_DEAD_LETTER)
+	if len(input.NotificationId) > 0 {
+		rnq = rnq.Equals("notification_id", input.NotificationId)
+	}
+	if len(input.ContactType) > 0 {
+		nq := nm.Query("id").Equals("contact_type", input.ContactType).SubQuery()
+		rnq = rnq.In("notification_id", nq)
+	}
+	total, err := rnq.CountWithError()
+	if err != nil {
+		return output, errors.Wrap(err, "count dead letters")
+	}
+	output.Total = total
+	if input.Limit <= 0 {
+		input.Limit = 20
+	}
+	rnq = rnq.Desc("send_at").Limit(input.Limit).Offset(input.Offset)
+	rns := make([]SReceiverNotification, 0, input.Limit)
+	err = db.FetchModelObjects(ReceiverNotificationManager, rnq, &rns)
+	if err != nil {
+		return output, errors.Wrap(err, "fetch dead letters")
+	}
+	nIds := make([]string, 0, len(rns))
+	for i := range rns {
+		nIds = append(nIds, rns[i].NotificationID)
+	}
+	notifications := make(map[string]SNotification)
+	err = db.FetchStandaloneObjectsByIds(nm, nIds, &notifications)
+	if err != nil {
+		return output, errors.Wrap(err, "fetch notifications")
+	}
+	output.Data = make([]api.NotificationDeadLetter, 0, len(rns))
+	for i := range rns {
+		n := notifications[rns[i].NotificationID]
+		output.Data = append(output.Data, api.NotificationDeadLetter{
+			NotificationId: rns[i].NotificationID,
+			ReceiverId:     rns[i].ReceiverID,
+			Contact:        rns[i].Contact,
+			ContactType:    n.ContactType,
+			Topic:          n.Topic,
+			Attempts:       rns[i].Attempts,
+			FailedReason:   rns[i].FailedReason,
+			SendAt:         rns[i].SendAt,
+		})
+	}
+	return output, nil
+}
+
+func (nm *SNotificationManager) AllowPerformReplayDeadLetters(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowClassPerform(userCred, nm, "replay-dead-letters")
+}
+
+// PerformReplayDeadLetters resets attempts of dead letters and sends them again
+func (nm *SNotificationManager) PerformReplayDeadLetters(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.NotificationReplayDeadLettersInput) (api.NotificationReplayDeadLettersOutput, error) {
+	output := api.NotificationReplayDeadLettersOutput{}
+	rnq := ReceiverNotificationManager.Query().Equals("status", api.RECEIVER_NOTIFICATION_DEAD_LETTER)
+	if len(input.NotificationIds) > 0 {
+		rnq = rnq.In("notification_id", input.NotificationIds)
+	}
+	if len(input.ContactType) > 0 {
+		nq := nm.Query("id").Equals("contact_type", input.ContactType).SubQuery()
+		rnq = rnq.In("notification_id", nq)
+	}
+	rns := make([]SReceiverNotification, 0)
+	err := db.FetchModelObjects(ReceiverNotificationManager, rnq, &rns)
+	if err != nil {
+		return output, errors.Wrap(err, "fetch dead letters")
+	}
+	nIds := make([]string, 0)
+	for i := range rns {
+		_, err := db.Update(&rns[i], func() error {
+			rns[i].Status = api.RECEIVER_NOTIFICATION_RECEIVED
+			rns[i].Attempts = 0
+			rns[i].NextRetryAt = time.Time{}
+			return nil
+		})
+		if err != nil {
+			return output, errors.Wrap(err, "reset dead letter")
+		}
+		if !utils.IsInStringArray(rns[i].NotificationID, nIds) {
+			nIds = append(nIds, rns[i].NotificationID)
+		}
+		output.Count += 1
+	}
+	notifications := make([]SNotification, 0, len(nIds))
+	err = db.FetchModelObjects(nm, nm.Query().In("id", nIds), &notifications)
+	if err != nil {
+		return output, errors.Wrap(err, "fetch notifications")
+	}
+	for i := range notifications {
+		notifications[i].SetStatus(userCred, api.NOTIFICATION_STATUS_RECEIVED, "replay dead letters")
+		task, err := taskman.TaskManager.NewTask(ctx, "NotificationSendTask", &notifications[i], userCred, nil, "", "")
+		if err != nil {
+			log.Errorf("NotificationSendTask newTask error %v", err)
+			continue
+		}
+		task.ScheduleRun(nil)
+	}
+	return output, nil
+}
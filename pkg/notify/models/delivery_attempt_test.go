@@ -0,0 +1,41 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	policy := api.SDeliveryPolicy{BackoffSeconds: 60, MaxBackoffSeconds: 300}
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 5 * time.Minute},
+		{20, 5 * time.Minute},
+	}
+	for _, c := range cases {
+		if got := retryBackoff(policy, c.attempts); got != c.want {
+			t.Errorf("attempts %d want %s, got %s", c.attempts, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/sets"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+const defaultEscalationTimeoutMinutes = 15
+
+type SEscalationPolicyManager struct {
+	db.SStandaloneResourceBaseManager
+	db.SEnabledResourceBaseManager
+}
+
+var EscalationPolicyManager *SEscalationPolicyManager
+
+func init() {
+	EscalationPolicyManager = &SEscalationPolicyManager{
+		SStandaloneResourceBaseManager: db.NewStandaloneResourceBaseManager(
+			SEscalationPolicy{},
+			"escalationpolicies_tbl",
+			"escalationpolicy",
+			"escalationpolicies",
+		),
+	}
+	EscalationPolicyManager.SetVirtualObject(EscalationPolicyManager)
+}
+
+// SEscalationPolicy notifies its levels one by one until the notification is acknowledged
+type SEscalationPolicy struct {
+	db.SStandaloneResourceBase
+	db.SEnabledResourceBase
+
+	Levels *api.SEscalationLevels `nullable:"false" list:"user" create:"required" update:"user"`
+	// only notifications of this priority or higher are escalated
+	MinPriority string `width:"16" charset:"ascii" nullable:"false" default:"important" list:"user" create:"optional" update:"user"`
+}
+
+func (pm *SEscalationPolicyManager) ResourceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func (pm *SEscalationPolicyManager) AllowCreateItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowCreate(userCred, pm)
+}
+
+func (pm *SEscalationPolicyManager) AllowListItems(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowList(userCred, pm)
+}
+
+func (p *SEscalationPolicy) AllowUpdateItem(ctx context.Context, userCred mcclient.TokenCredential) bool {
+	return db.IsAdminAllowUpdate(userCred, p)
+}
+
+func (p *SEscalationPolicy) AllowDeleteItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowDelete(userCred, p)
+}
+
+func (p *SEscalationPolicy) AllowPerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) bool {
+	return db.IsAdminAllowPerform(userCred, p, "enable")
+}
+
+func (p *SEscalationPolicy) PerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(p, ctx, userCred, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func (p *SEscalationPolicy) AllowPerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) bool {
+	return db.IsAdminAllowPerform(userCred, p, "disable")
+}
+
+func (p *SEscalationPolicy) PerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(p, ctx, userCred, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func validateMinPriority(priority string) error {
+	if !utils.IsInStringArray(priority, []string{
+		api.NOTIFICATION_PRIORITY_NORMAL,
+		api.NOTIFICATION_PRIORITY_IMPORTANT,
+		api.NOTIFICATION_PRIORITY_CRITICAL,
+	}) {
+		return httperrors.NewInputParameterError("invalid min_priority %q", priority)
+	}
+	return nil
+}
+
+// validateLevels replaces names of receiver and oncall schedule in levels with ids
+func validateLevels(ctx context.Context, userCred mcclient.TokenCredential, levels api.SEscalationLevels) (api.SEscalationLevels, error) {
+	if len(levels) == 0 {
+		return nil, httperrors.NewMissingParameterError("levels")
+	}
+	for i := range levels {
+		level := &levels[i]
+		if len(level.Receivers)+len(level.OncallSchedules) == 0 {
+			return nil, httperrors.NewInputParameterError("level %d has neither receivers nor oncall_schedules", i+1)
+		}
+		if level.TimeoutMinutes == 0 {
+			level.TimeoutMinutes = defaultEscalationTimeoutMinutes
+		}
+		if level.TimeoutMinutes < 0 {
+			return nil, httperrors.NewInputParameterError("timeout_minutes of level %d must be positive", i+1)
+		}
+		if len(level.Receivers) > 0 {
+			ids, err := fetchParticipantIds(ctx, level.Receivers)
+			if err != nil {
+				return nil, err
+			}
+			level.Receivers = ids
+		}
+		for j := range level.OncallSchedules {
+			schedule, err := OncallScheduleManager.FetchByIdOrName(userCred, level.OncallSchedules[j])
+			if err != nil {
+				if errors.Cause(err) == sql.ErrNoRows {
+					return nil, httperrors.NewResourceNotFoundError2(OncallScheduleManager.Keyword(), level.OncallSchedules[j])
+				}
+				return nil, errors.Wrap(err, "OncallScheduleManager.FetchByIdOrName")
+			}
+			level.OncallSchedules[j] = schedule.GetId()
+		}
+	}
+	return levels, nil
+}
+
+func (pm *SEscalationPolicyManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.EscalationPolicyCreateInput) (api.EscalationPolicyCreateInput, error) {
+	var err error
+	input.Levels, err = validateLevels(ctx, userCred, input.Levels)
+	if err != nil {
+		return input, err
+	}
+	if len(input.MinPriority) == 0 {
+		input.MinPriority = api.NOTIFICATION_PRIORITY_IMPORTANT
+	}
+	err = validateMinPriority(input.MinPriority)
+	if err != nil {
+		return input, err
+	}
+	if input.Enabled == nil {
+		enabled := true
+		input.Enabled = &enabled
+	}
+	input.StandaloneResourceCreateInput, err = pm.SStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StandaloneResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (p *SEscalationPolicy) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.EscalationPolicyUpdateInput) (api.EscalationPolicyUpdateInput, error) {
+	var err error
+	if input.Levels != nil {
+		input.Levels, err = validateLevels(ctx, userCred, input.Levels)
+		if err != nil {
+			return input, err
+		}
+	}
+	if len(input.MinPriority) > 0 {
+		err = validateMinPriority(input.MinPriority)
+		if err != nil {
+			return input, err
+		}
+	}
+	input.StandaloneResourceBaseUpdateInput, err = p.SStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.StandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (p *SEscalationPolicy) ValidateDeleteCondition(ctx context.Context) error {
+	cnt, err := SubscriptionManager.Query().Equals("escalation_policy_id", p.Id).CountWithError()
+	if err != nil {
+		return errors.Wrap(err, "count subscriptions")
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("escalation policy is used by %d subscriptions", cnt)
+	}
+	cnt, err = NotificationEscalationManager.Query().Equals("policy_id", p.Id).Equals("status", api.ESCALATION_STATUS_ESCALATING).CountWithError()
+	if err != nil {
+		return errors.Wrap(err, "count escalations")
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("escalation policy has %d notifications escalating", cnt)
+	}
+	return p.SStandaloneResourceBase.ValidateDeleteCondition(ctx)
+}
+
+func (pm *SEscalationPolicyManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, input api.EscalationPolicyListInput) (*sqlchemy.SQuery, error) {
+	q, err := pm.SStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StandaloneResourceListInput)
+	if err != nil {
+		return nil, err
+	}
+	return pm.SEnabledResourceBaseManager.ListItemFilter(ctx, q, userCred, input.EnabledResourceBaseListInput)
+}
+
+func (pm *SEscalationPolicyManager) FetchCustomizeColumns(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, objs []interface{}, fields stringutils2.SSortedStrings, isList bool) []api.EscalationPolicyDetails {
+	sRows := pm.SStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	rows := make([]api.EscalationPolicyDetails, len(objs))
+	for i := range rows {
+		rows[i].StandaloneResourceDetails = sRows[i]
+	}
+	return rows
+}
+
+func (pm *SEscalationPolicyManager) fetchById(id string) (*SEscalationPolicy, error) {
+	obj, err := pm.FetchById(id)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*SEscalationPolicy), nil
+}
+
+func (p *SEscalationPolicy) levels() api.SEscalationLevels {
+	if p.Levels == nil {
+		return nil
+	}
+	return *p.Levels
+}
+
+// Escalatable tells whether notifications of priority are escalated by the policy
+func (p *SEscalationPolicy) Escalatable(priority string) bool {
+	return p.Enabled.IsTrue() && priorityLevel(priority) >= priorityLevel(p.MinPriority)
+}
+
+// levelReceivers returns ids of receiver notified at level index, with oncall schedules
+// resolved to receivers on call at tm
+func (p *SEscalationPolicy) levelReceivers(index int, tm time.Time) []string {
+	levels := p.levels()
+	if index < 0 || index >= len(levels) {
+		return nil
+	}
+	level := levels[index]
+	ids := sets.NewString(level.Receivers...)
+	for _, scheduleId := range level.OncallSchedules {
+		obj, err := OncallScheduleManager.FetchById(scheduleId)
+		if err != nil {
+			log.Errorf("unable to fetch oncall schedule %s of escalation policy %s: %v", scheduleId, p.Name, err)
+			continue
+		}
+		schedule := obj.(*SOncallSchedule)
+		if schedule.Enabled.IsFalse() {
+			continue
+		}
+		oncall, _, err := schedule.OncallAt(tm)
+		if err != nil {
+			log.Errorf("unable to get oncall of schedule %s: %v", schedule.Name, err)
+			continue
+		}
+		if len(oncall) > 0 {
+			ids.Insert(oncall)
+		}
+	}
+	return ids.UnsortedList()
+}
@@ -36,6 +36,7 @@ import (
 	notifyv2 "yunion.io/x/onecloud/pkg/notify"
 	"yunion.io/x/onecloud/pkg/notify/oldmodels"
 	"yunion.io/x/onecloud/pkg/notify/options"
+	"yunion.io/x/onecloud/pkg/util/logclient"
 	"yunion.io/x/onecloud/pkg/util/rbacutils"
 	"yunion.io/x/onecloud/pkg/util/stringutils2"
 )
@@ -73,6 +74,8 @@ type SNotification struct {
 	AdvanceDays int
 	SendTimes   int
 	Tag         string `width:"16" nullable:"true" index:"true" create:"optional"`
+	// escalation the notification belongs to
+	EscalationId string `width:"36" charset:"ascii" nullable:"true" index:"true" list:"user" get:"user"`
 }
 
 const (
@@ -125,6 +128,16 @@ func (nm *SNotificationManager) ValidateCreateData(ctx context.Context, userCred
 			return input, httperrors.NewInputParameterError("no valid receiver or contact")
 		}
 	}
+	if len(input.EscalationPolicy) > 0 {
+		policy, err := EscalationPolicyManager.FetchByIdOrName(userCred, input.EscalationPolicy)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return input, httperrors.NewResourceNotFoundError2(EscalationPolicyManager.Keyword(), input.EscalationPolicy)
+			}
+			return input, errors.Wrap(err, "EscalationPolicyManager.FetchByIdOrName")
+		}
+		input.EscalationPolicy = policy.GetId()
+	}
 	nowStr := time.Now().Format("2006-01-02 15:04:05")
 	if len(input.Priority) == 0 {
 		input.Priority = api.NOTIFICATION_PRIORITY_NORMAL
@@ -162,6 +175,19 @@ func (n *SNotification) CustomizeCreate(ctx context.Context, userCred mcclient.T
 			return errors.Wrap(err, "ReceiverNotificationManager.Create")
 		}
 	}
+	if len(input.EscalationPolicy) > 0 {
+		policy, err := EscalationPolicyManager.fetchById(input.EscalationPolicy)
+		if err != nil {
+			return errors.Wrap(err, "fetch escalation policy")
+		}
+		if policy.Escalatable(n.Priority) {
+			esc, err := NotificationEscalationManager.Start(ctx, userCred, policy, []string{n.ContactType}, n.Priority, n.Topic, n.Message, n.Event, n.AdvanceDays)
+			if err != nil {
+				return errors.Wrap(err, "start escalation")
+			}
+			n.EscalationId = esc.Id
+		}
+	}
 	return nil
 }
 
@@ -255,6 +281,33 @@ func (nm *SNotificationManager) PerformEventNotify(ctx context.Context, userCred
 
 	message := jsonutils.Marshal(input.ResourceDetails).String()
 
+	// escalation
+	var escalationId string
+	policyIds := sets.NewString()
+	for i := range subscriptions {
+		if len(subscriptions[i].EscalationPolicyId) > 0 {
+			policyIds.Insert(subscriptions[i].EscalationPolicyId)
+		}
+	}
+	for _, policyId := range policyIds.List() {
+		policy, err := EscalationPolicyManager.fetchById(policyId)
+		if err != nil {
+			log.Errorf("unable to fetch escalation policy %s: %v", policyId, err)
+			continue
+		}
+		if !policy.Escalatable(input.Priority) {
+			continue
+		}
+		esc, err := NotificationEscalationManager.Start(ctx, userCred, policy, contactTypes, input.Priority, "", message, input.Event, input.AdvanceDays)
+		if err != nil {
+			log.Errorf("unable to escalate event %s by policy %s: %v", input.Event, policy.Name, err)
+			continue
+		}
+		if len(escalationId) == 0 {
+			escalationId = esc.Id
+		}
+	}
+
 	// fillter non-existed receiver
 	receivers, err := ReceiverManager.FetchByIdOrNames(ctx, receiverIds...)
 	if err != nil {
@@ -274,7 +327,7 @@ func (nm *SNotificationManager) PerformEventNotify(ctx context.Context, userCred
 	receiverIds = idSet.UnsortedList()
 
 	// webconsole
-	err = nm.create(ctx, userCred, api.WEBCONSOLE, receiverIds, webconsoleContacts, input.Priority, "", message, input.Event, input.AdvanceDays)
+	err = nm.create(ctx, userCred, api.WEBCONSOLE, receiverIds, webconsoleContacts, input.Priority, "", message, input.Event, input.AdvanceDays, escalationId)
 	if err != nil {
 		output.FailedList = append(output.FailedList, api.FailedElem{
 			ContactType: api.WEBCONSOLE,
@@ -283,7 +336,7 @@ func (nm *SNotificationManager) PerformEventNotify(ctx context.Context, userCred
 	}
 	// normal contact type
 	for _, ct := range contactTypes {
-		err := nm.create(ctx, userCred, ct, receiverIds, []string{}, input.Priority, "", message, input.Event, input.AdvanceDays)
+		err := nm.create(ctx, userCred, ct, receiverIds, []string{}, input.Priority, "", message, input.Event, input.AdvanceDays, escalationId)
 		if err != nil {
 			output.FailedList = append(output.FailedList, api.FailedElem{
 				ContactType: ct,
@@ -294,7 +347,7 @@ func (nm *SNotificationManager) PerformEventNotify(ctx context.Context, userCred
 	}
 	// robot
 	for _, robot := range robots {
-		err := nm.create(ctx, userCred, robot, []string{}, []string{}, input.Priority, "", message, input.Event, input.AdvanceDays)
+		err := nm.create(ctx, userCred, robot, []string{}, []string{}, input.Priority, "", message, input.Event, input.AdvanceDays, escalationId)
 		if err != nil {
 			output.FailedList = append(output.FailedList, api.FailedElem{
 				ContactType: robot,
@@ -304,7 +357,7 @@ func (nm *SNotificationManager) PerformEventNotify(ctx context.Context, userCred
 	}
 	// webhook
 	for _, webhook := range webhooks {
-		err := nm.create(ctx, userCred, webhook, []string{}, []string{}, input.Priority, "", message, input.Event, input.AdvanceDays)
+		err := nm.create(ctx, userCred, webhook, []string{}, []string{}, input.Priority, "", message, input.Event, input.AdvanceDays, escalationId)
 		if err != nil {
 			output.FailedList = append(output.FailedList, api.FailedElem{
 				ContactType: webhook,
@@ -316,19 +369,20 @@ func (nm *SNotificationManager) PerformEventNotify(ctx context.Context, userCred
 	return output, nil
 }
 
-func (nm *SNotificationManager) create(ctx context.Context, userCred mcclient.TokenCredential, contactType string, receiverIds, contacts []string, priority, topic, message, event string, advanceDays int) error {
+func (nm *SNotificationManager) create(ctx context.Context, userCred mcclient.TokenCredential, contactType string, receiverIds, contacts []string, priority, topic, message, event string, advanceDays int, escalationId string) error {
 	if len(receiverIds)+len(contacts) == 0 {
 		log.Infof("%s: no send", contactType)
 		return nil
 	}
 
 	n := &SNotification{
-		ContactType: contactType,
-		Message:     message,
-		Priority:    priority,
-		ReceivedAt:  time.Now(),
-		Event:       event,
-		AdvanceDays: advanceDays,
+		ContactType:  contactType,
+		Message:      message,
+		Priority:     priority,
+		ReceivedAt:   time.Now(),
+		Event:        event,
+		AdvanceDays:  advanceDays,
+		EscalationId: escalationId,
 	}
 	n.Id = db.DefaultUUIDGenerator()
 	err := nm.TableSpec().Insert(ctx, n)
@@ -392,7 +446,18 @@ func (n *SNotification) GetExtraDetails(
 }
 
 func (n *SNotification) ReceiverNotificationsNotOK() ([]SReceiverNotification, error) {
-	rnq := ReceiverNotificationManager.Query().Equals("notification_id", n.Id).NotEquals("status", api.RECEIVER_NOTIFICATION_OK)
+	rnq := ReceiverNotificationManager.Query().Equals("notification_id", n.Id).NotIn("status", []string{
+		api.RECEIVER_NOTIFICATION_OK,
+		api.RECEIVER_NOTIFICATION_PENDING,
+		api.RECEIVER_NOTIFICATION_DEDUPED,
+		api.RECEIVER_NOTIFICATION_DEAD_LETTER,
+	})
+	// failed ones are retried after backoff
+	rnq = rnq.Filter(sqlchemy.OR(
+		sqlchemy.NotEquals(rnq.Field("status"), api.RECEIVER_NOTIFICATION_FAIL),
+		sqlchemy.IsNull(rnq.Field("next_retry_at")),
+		sqlchemy.LE(rnq.Field("next_retry_at"), time.Now().UTC()),
+	))
 	rns := make([]SReceiverNotification, 0, 1)
 	err := db.FetchModelObjects(ReceiverNotificationManager, rnq, &rns)
 	if err == sql.ErrNoRows {
@@ -448,9 +513,62 @@ func (n *SNotification) getMoreDetails(ctx context.Context, userCred mcclient.To
 	if err != nil {
 		return out, err
 	}
+	if len(n.EscalationId) > 0 {
+		esc, err := NotificationEscalationManager.fetchById(n.EscalationId)
+		if err != nil {
+			return out, errors.Wrapf(err, "fetch escalation %s", n.EscalationId)
+		}
+		out.Escalation = esc.detail()
+	}
 	return out, nil
 }
 
+func (n *SNotification) AllowPerformAcknowledge(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	if db.IsAdminAllowPerform(userCred, n, "acknowledge") {
+		return true
+	}
+	// receivers of the notification are allowed to acknowledge
+	cnt, err := ReceiverNotificationManager.Query().Equals("notification_id", n.Id).Equals("receiver_id", userCred.GetUserId()).CountWithError()
+	return err == nil && cnt > 0
+}
+
+// PerformAcknowledge stops the escalation the notification belongs to
+func (n *SNotification) PerformAcknowledge(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.NotificationAcknowledgeInput) (jsonutils.JSONObject, error) {
+	if len(n.EscalationId) == 0 {
+		return nil, httperrors.NewBadRequestError("notification %s is not escalated", n.Name)
+	}
+	esc, err := NotificationEscalationManager.fetchById(n.EscalationId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch escalation %s", n.EscalationId)
+	}
+	err = esc.acknowledge(ctx, userCred.GetUserName())
+	if err != nil {
+		return nil, err
+	}
+	logclient.AddActionLogWithContext(ctx, n, logclient.ACT_ACKNOWLEDGE, nil, userCred, true)
+	return nil, nil
+}
+
+// AppendAcknowledgeLink appends the acknowledge link of escalation to message
+func (n *SNotification) AppendAcknowledgeLink(lang, message string) string {
+	if len(n.EscalationId) == 0 {
+		return message
+	}
+	esc, err := NotificationEscalationManager.fetchById(n.EscalationId)
+	if err != nil {
+		log.Errorf("unable to fetch escalation %s: %v", n.EscalationId, err)
+		return message
+	}
+	link := esc.AcknowledgeLink()
+	if len(link) == 0 {
+		return message
+	}
+	if lang == api.TEMPLATE_LANG_CN {
+		return fmt.Sprintf("%s\n\n确认告警: %s", message, link)
+	}
+	return fmt.Sprintf("%s\n\nAcknowledge: %s", message, link)
+}
+
 func (n *SNotification) Notification() notifyv2.SNotification {
 	return notifyv2.SNotification{
 		ContactType: n.ContactType,
@@ -686,9 +804,10 @@ func (nm *SNotificationManager) ListItemFilter(ctx context.Context, q *sqlchemy.
 	return q, nil
 }
 
+// ReSend retries notifications having failed deliveries whose backoff has elapsed
 func (nm *SNotificationManager) ReSend(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
-	timeLimit := time.Now().Add(-time.Duration(options.Options.ReSendScope) * time.Second * 2).Format("2006-01-02 15:04:05")
-	q := nm.Query().GT("created_at", timeLimit).In("status", []string{api.NOTIFICATION_STATUS_FAILED, api.NOTIFICATION_STATUS_PART_OK}).LT("send_times", options.Options.MaxSendTimes)
+	rnq := ReceiverNotificationManager.Query("notification_id").Equals("status", api.RECEIVER_NOTIFICATION_FAIL).LE("next_retry_at", time.Now().UTC()).Distinct().SubQuery()
+	q := nm.Query().In("id", rnq)
 	ns := make([]SNotification, 0, 2)
 	err := db.FetchModelObjects(nm, q, &ns)
 	if err != nil {
@@ -0,0 +1,253 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	notifyv2 "yunion.io/x/onecloud/pkg/notify"
+	rpcapi "yunion.io/x/onecloud/pkg/notify/rpc/apis"
+)
+
+type SNotificationBucketManager struct {
+	db.SStandaloneResourceBaseManager
+
+	lock sync.Mutex
+}
+
+// SNotificationBucket holds notifications of a receiver and contact type,
+// which are sent as one digest at ReleaseAt
+// +onecloud:swagger-gen-ignore
+type SNotificationBucket struct {
+	db.SStandaloneResourceBase
+
+	ReceiverID  string `width:"128" charset:"ascii" nullable:"false" index:"true"`
+	ContactType string `width:"16" nullable:"false"`
+	// digest | quiet
+	Reason    string    `width:"16" charset:"ascii" nullable:"false"`
+	ReleaseAt time.Time `nullable:"false" index:"true"`
+}
+
+var NotificationBucketManager *SNotificationBucketManager
+
+func init() {
+	NotificationBucketManager = &SNotificationBucketManager{
+		SStandaloneResourceBaseManager: db.NewStandaloneResourceBaseManager(
+			SNotificationBucket{},
+			"notificationbuckets_tbl",
+			"notificationbucket",
+			"notificationbuckets",
+		),
+	}
+	NotificationBucketManager.SetVirtualObject(NotificationBucketManager)
+}
+
+// fetchOrCreate returns the open bucket of receiver and contactType, notifications
+// held for different reasons share one bucket so that they come in one digest
+func (bm *SNotificationBucketManager) fetchOrCreate(ctx context.Context, receiverId, contactType, reason string, releaseAt time.Time) (*SNotificationBucket, error) {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+
+	q := bm.Query().Equals("receiver_id", receiverId).Equals("contact_type", contactType)
+	bucket := &SNotificationBucket{}
+	bucket.SetModelManager(bm, bucket)
+	err := q.First(bucket)
+	if err == nil {
+		return bucket, nil
+	}
+	if errors.Cause(err) != sql.ErrNoRows {
+		return nil, errors.Wrap(err, "query bucket")
+	}
+	bucket.Id = db.DefaultUUIDGenerator()
+	bucket.Name = fmt.Sprintf("%s-%s", receiverId, contactType)
+	bucket.ReceiverID = receiverId
+	bucket.ContactType = contactType
+	bucket.Reason = reason
+	bucket.ReleaseAt = releaseAt.UTC()
+	err = bm.TableSpec().Insert(ctx, bucket)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert bucket")
+	}
+	return bucket, nil
+}
+
+// FlushDue sends notifications held in buckets whose release time has come
+func (bm *SNotificationBucketManager) FlushDue(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	q := bm.Query().LE("release_at", time.Now().UTC())
+	buckets := make([]SNotificationBucket, 0)
+	err := db.FetchModelObjects(bm, q, &buckets)
+	if err != nil {
+		log.Errorf("fetch due notification buckets: %v", err)
+		return
+	}
+	for i := range buckets {
+		err := buckets[i].flush(ctx, userCred)
+		if err != nil {
+			log.Errorf("flush notification bucket %s: %v", buckets[i].Id, err)
+		}
+	}
+}
+
+func (b *SNotificationBucket) pendingNotifications() ([]SReceiverNotification, error) {
+	q := ReceiverNotificationManager.Query().Equals("bucket_id", b.Id).Equals("status", api.RECEIVER_NOTIFICATION_PENDING)
+	rns := make([]SReceiverNotification, 0)
+	err := db.FetchModelObjects(ReceiverNotificationManager, q, &rns)
+	if err != nil && errors.Cause(err) != sql.ErrNoRows {
+		return nil, err
+	}
+	return rns, nil
+}
+
+func (b *SNotificationBucket) flush(ctx context.Context, userCred mcclient.TokenCredential) error {
+	rns, err := b.pendingNotifications()
+	if err != nil {
+		return errors.Wrap(err, "fetch pending notifications")
+	}
+	if len(rns) == 0 {
+		return b.Delete(ctx, userCred)
+	}
+	failAll := func(reason string) error {
+		for i := range rns {
+			rns[i].DeadLetter(ctx, b.ContactType, reason)
+		}
+		return b.Delete(ctx, userCred)
+	}
+	receivers, err := ReceiverManager.FetchByIDs(ctx, b.ReceiverID)
+	if err != nil {
+		return errors.Wrap(err, "fetch receiver")
+	}
+	if len(receivers) == 0 {
+		return failAll("receiver not found")
+	}
+	receiver := &receivers[0]
+	now := time.Now()
+	// digest must not break quiet hours either, priority of digest items is always below override
+	if end := quietHoursEnd(receiver.QuietHoursStart, receiver.QuietHoursEnd, now); !end.IsZero() && receiver.quietOverrideLevel() > 0 {
+		_, err := db.Update(b, func() error {
+			b.ReleaseAt = end.UTC()
+			return nil
+		})
+		return err
+	}
+	contact, err := receiver.GetContact(b.ContactType)
+	if err != nil {
+		return failAll(fmt.Sprintf("fail to fetch contact: %s", err.Error()))
+	}
+	lang, err := receiver.GetTemplateLang(ctx)
+	if err != nil {
+		return failAll(fmt.Sprintf("fail to GetTemplateLang: %s", err.Error()))
+	}
+	items := make([]sDigestItem, 0, len(rns))
+	for i := range rns {
+		obj, err := NotificationManager.FetchById(rns[i].NotificationID)
+		if err != nil {
+			rns[i].DeadLetter(ctx, b.ContactType, fmt.Sprintf("fail to fetch notification: %s", err.Error()))
+			continue
+		}
+		n := obj.(*SNotification)
+		p, err := n.TemplateStore().FillWithTemplate(ctx, lang, n.Notification())
+		if err != nil {
+			rns[i].DeadLetter(ctx, b.ContactType, err.Error())
+			continue
+		}
+		items = append(items, sDigestItem{
+			Title:      p.Title,
+			Message:    p.Message,
+			Priority:   n.Priority,
+			ReceivedAt: n.ReceivedAt,
+			rn:         &rns[i],
+		})
+	}
+	if len(items) == 0 {
+		return b.Delete(ctx, userCred)
+	}
+	p := renderDigest(ctx, b.ContactType, lang, items)
+	for i := range items {
+		items[i].rn.BeforeSend(ctx, now)
+	}
+	var reason string
+	fds, err := NotifyService.BatchSend(ctx, b.ContactType, rpcapi.BatchSendParams{
+		Contacts: []string{contact},
+		Title:    p.Title,
+		Message:  p.Message,
+		Priority: p.Priority,
+	})
+	if err != nil {
+		reason = err.Error()
+	} else if len(fds) > 0 {
+		reason = fds[0].Reason
+	}
+	policy := ConfigManager.DeliveryPolicy(b.ContactType)
+	for i := range items {
+		items[i].rn.AfterSend(ctx, b.ContactType, policy, len(reason) == 0, reason)
+	}
+	return b.Delete(ctx, userCred)
+}
+
+type sDigestItem struct {
+	Title      string    `json:"title"`
+	Message    string    `json:"message"`
+	Priority   string    `json:"priority"`
+	ReceivedAt time.Time `json:"received_at"`
+
+	rn *SReceiverNotification
+}
+
+// renderDigest renders items by template of topic NOTIFICATION_DIGEST,
+// plain text is used if the template is absent
+func renderDigest(ctx context.Context, contactType, lang string, items []sDigestItem) rpcapi.SendParams {
+	priority := api.NOTIFICATION_PRIORITY_NORMAL
+	for i := range items {
+		if priorityLevel(items[i].Priority) > priorityLevel(priority) {
+			priority = items[i].Priority
+		}
+	}
+	if len(items) == 1 {
+		return rpcapi.SendParams{Title: items[0].Title, Message: items[0].Message, Priority: priority}
+	}
+	msg := jsonutils.NewDict()
+	msg.Add(jsonutils.NewInt(int64(len(items))), "count")
+	msg.Add(jsonutils.Marshal(items), "items")
+	p, err := TemplateManager.FillWithTemplate(ctx, lang, notifyv2.SNotification{
+		ContactType: contactType,
+		Topic:       api.NOTIFICATION_DIGEST_TOPIC,
+		Message:     msg.String(),
+	})
+	if err != nil || p.Title == api.NOTIFICATION_DIGEST_TOPIC {
+		if err != nil {
+			log.Warningf("render notification digest: %v", err)
+		}
+		lines := make([]string, 0, len(items))
+		for i := range items {
+			lines = append(lines, fmt.Sprintf("[%s] %s\n%s", items[i].Priority, items[i].Title, items[i].Message))
+		}
+		p.Title = fmt.Sprintf("Digest of %d notifications", len(items))
+		p.Message = strings.Join(lines, "\n\n")
+	}
+	p.Priority = priority
+	return p
+}
@@ -0,0 +1,310 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/notify/options"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+// AcknowledgeUrlPath is where the acknowledge links in escalated notifications point to
+const AcknowledgeUrlPath = "/v2/acknowledge"
+
+type SNotificationEscalationManager struct {
+	db.SStatusStandaloneResourceBaseManager
+
+	lock sync.Mutex
+}
+
+var NotificationEscalationManager *SNotificationEscalationManager
+
+func init() {
+	NotificationEscalationManager = &SNotificationEscalationManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SNotificationEscalation{},
+			"notificationescalations_tbl",
+			"notificationescalation",
+			"notificationescalations",
+		),
+	}
+	NotificationEscalationManager.SetVirtualObject(NotificationEscalationManager)
+}
+
+// SNotificationEscalation tracks an alert escalated by policy, notifications of
+// every contact type sent for the alert belong to one escalation
+// +onecloud:swagger-gen-ignore
+type SNotificationEscalation struct {
+	db.SStatusStandaloneResourceBase
+
+	PolicyId string `width:"36" charset:"ascii" nullable:"false" index:"true"`
+	AlertKey string `width:"64" charset:"ascii" nullable:"false" index:"true"`
+	// count of levels notified
+	Level        int    `nullable:"false" default:"0"`
+	ContactTypes string `width:"256" charset:"ascii" nullable:"false"`
+
+	Topic       string `width:"128" nullable:"true"`
+	Priority    string `width:"16" nullable:"true"`
+	Message     string
+	Event       string `width:"32" nullable:"true"`
+	AdvanceDays int
+
+	NextEscalateAt time.Time `nullable:"true" index:"true"`
+	AcknowledgedBy string    `width:"128" nullable:"true"`
+	AcknowledgedAt time.Time `nullable:"true"`
+	// secret in acknowledge link
+	Token string `width:"64" charset:"ascii" nullable:"false" index:"true"`
+}
+
+func alertKey(topic, event, message string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{topic, event, message}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+func newAcknowledgeToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// escalationContactTypes keeps contact types reaching a person, escalation makes no sense for robots or webhooks
+func escalationContactTypes(contactTypes []string) []string {
+	ret := make([]string, 0, len(contactTypes))
+	for _, ct := range contactTypes {
+		if (ct == api.WEBCONSOLE || utils.IsInStringArray(ct, PersonalConfigContactTypes)) && !utils.IsInStringArray(ct, ret) {
+			ret = append(ret, ct)
+		}
+	}
+	if len(ret) == 0 {
+		ret = append(ret, api.WEBCONSOLE)
+	}
+	return ret
+}
+
+// Start escalates the alert by policy, or joins the escalation of the same alert not acknowledged yet
+func (em *SNotificationEscalationManager) Start(ctx context.Context, userCred mcclient.TokenCredential, policy *SEscalationPolicy, contactTypes []string, priority, topic, message, event string, advanceDays int) (*SNotificationEscalation, error) {
+	em.lock.Lock()
+	defer em.lock.Unlock()
+
+	contactTypes = escalationContactTypes(contactTypes)
+	key := alertKey(topic, event, message)
+	esc := &SNotificationEscalation{}
+	esc.SetModelManager(em, esc)
+	q := em.Query().Equals("policy_id", policy.Id).Equals("alert_key", key).Equals("status", api.ESCALATION_STATUS_ESCALATING)
+	err := q.First(esc)
+	if err == nil {
+		cts := escalationContactTypes(append(strings.Split(esc.ContactTypes, ","), contactTypes...))
+		if len(cts) > len(strings.Split(esc.ContactTypes, ",")) {
+			_, err := db.Update(esc, func() error {
+				esc.ContactTypes = strings.Join(cts, ",")
+				return nil
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "update contact types")
+			}
+		}
+		return esc, nil
+	}
+	if errors.Cause(err) != sql.ErrNoRows {
+		return nil, errors.Wrap(err, "query escalation")
+	}
+
+	esc.Id = db.DefaultUUIDGenerator()
+	esc.Name = fmt.Sprintf("%s-%s", policy.Name, esc.Id[:8])
+	esc.Status = api.ESCALATION_STATUS_ESCALATING
+	esc.PolicyId = policy.Id
+	esc.AlertKey = key
+	esc.ContactTypes = strings.Join(contactTypes, ",")
+	esc.Topic = topic
+	esc.Priority = priority
+	esc.Message = message
+	esc.Event = event
+	esc.AdvanceDays = advanceDays
+	esc.Token, err = newAcknowledgeToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate acknowledge token")
+	}
+	err = em.TableSpec().Insert(ctx, esc)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert escalation")
+	}
+	err = esc.escalate(ctx, userCred, policy, time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "escalate to level 1")
+	}
+	return esc, nil
+}
+
+// escalate notifies the next level of policy, and marks the escalation exhausted
+// when there is no more level
+func (e *SNotificationEscalation) escalate(ctx context.Context, userCred mcclient.TokenCredential, policy *SEscalationPolicy, now time.Time) error {
+	levels := policy.levels()
+	if e.Level >= len(levels) {
+		log.Warningf("escalation %s of policy %s exhausted without acknowledgement", e.Id, policy.Name)
+		_, err := db.Update(e, func() error {
+			e.Status = api.ESCALATION_STATUS_EXHAUSTED
+			return nil
+		})
+		return err
+	}
+	receiverIds := policy.levelReceivers(e.Level, now)
+	if len(receiverIds) == 0 {
+		log.Warningf("no receiver at level %d of escalation policy %s", e.Level+1, policy.Name)
+	}
+	for _, ct := range strings.Split(e.ContactTypes, ",") {
+		err := NotificationManager.create(ctx, userCred, ct, receiverIds, []string{}, e.Priority, e.Topic, e.Message, e.Event, e.AdvanceDays, e.Id)
+		if err != nil {
+			log.Errorf("unable to notify level %d of escalation %s by %s: %v", e.Level+1, e.Id, ct, err)
+		}
+	}
+	_, err := db.Update(e, func() error {
+		e.NextEscalateAt = now.Add(time.Duration(levels[e.Level].TimeoutMinutes) * time.Minute).UTC()
+		e.Level += 1
+		return nil
+	})
+	return err
+}
+
+// cancel stops the escalation whose policy is gone, otherwise it would stay escalating forever
+func (e *SNotificationEscalation) cancel(reason string) {
+	log.Warningf("escalation %s cancelled: %s", e.Id, reason)
+	_, err := db.Update(e, func() error {
+		e.Status = api.ESCALATION_STATUS_CANCELLED
+		return nil
+	})
+	if err != nil {
+		log.Errorf("unable to cancel escalation %s: %v", e.Id, err)
+	}
+}
+
+// EscalateDue escalates alerts not acknowledged within timeout of the current level
+func (em *SNotificationEscalationManager) EscalateDue(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	now := time.Now()
+	q := em.Query().Equals("status", api.ESCALATION_STATUS_ESCALATING).LE("next_escalate_at", now.UTC())
+	escs := make([]SNotificationEscalation, 0)
+	err := db.FetchModelObjects(em, q, &escs)
+	if err != nil {
+		log.Errorf("fetch due escalations: %v", err)
+		return
+	}
+	for i := range escs {
+		esc := &escs[i]
+		policy, err := EscalationPolicyManager.fetchById(esc.PolicyId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				esc.cancel("policy deleted")
+			} else {
+				log.Errorf("unable to fetch escalation policy %s: %v", esc.PolicyId, err)
+			}
+			continue
+		}
+		if policy.Enabled.IsFalse() {
+			esc.cancel(fmt.Sprintf("policy %s disabled", policy.Name))
+			continue
+		}
+		err = esc.escalate(ctx, userCred, policy, now)
+		if err != nil {
+			log.Errorf("unable to escalate %s: %v", esc.Id, err)
+		}
+	}
+}
+
+func (em *SNotificationEscalationManager) fetchById(id string) (*SNotificationEscalation, error) {
+	obj, err := em.FetchById(id)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*SNotificationEscalation), nil
+}
+
+// FetchByToken returns the escalation of the acknowledge link
+func (em *SNotificationEscalationManager) FetchByToken(token string) (*SNotificationEscalation, error) {
+	if len(token) == 0 {
+		return nil, httperrors.NewMissingParameterError("token")
+	}
+	esc := &SNotificationEscalation{}
+	esc.SetModelManager(em, esc)
+	err := em.Query().Equals("token", token).First(esc)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, httperrors.NewNotFoundError("invalid acknowledge link")
+		}
+		return nil, errors.Wrap(err, "query escalation")
+	}
+	return esc, nil
+}
+
+// AcknowledgeByToken acknowledges the escalation whose acknowledge link is confirmed
+func (em *SNotificationEscalationManager) AcknowledgeByToken(ctx context.Context, token string) error {
+	esc, err := em.FetchByToken(token)
+	if err != nil {
+		return err
+	}
+	return esc.acknowledge(ctx, "link")
+}
+
+func (e *SNotificationEscalation) acknowledge(ctx context.Context, by string) error {
+	if e.Status == api.ESCALATION_STATUS_ACKNOWLEDGED {
+		return nil
+	}
+	_, err := db.Update(e, func() error {
+		e.Status = api.ESCALATION_STATUS_ACKNOWLEDGED
+		e.AcknowledgedBy = by
+		e.AcknowledgedAt = time.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "update escalation")
+	}
+	log.Infof("escalation %s acknowledged by %s at level %d", e.Id, by, e.Level)
+	return nil
+}
+
+// AcknowledgeLink returns the link to acknowledge escalation, empty if no acknowledge_endpoint is configured
+func (e *SNotificationEscalation) AcknowledgeLink() string {
+	if len(options.Options.AcknowledgeEndpoint) == 0 {
+		return ""
+	}
+	return httputils.JoinPath(options.Options.AcknowledgeEndpoint, AcknowledgeUrlPath, e.Token)
+}
+
+func (e *SNotificationEscalation) detail() *api.EscalationDetail {
+	return &api.EscalationDetail{
+		Id:             e.Id,
+		Status:         e.Status,
+		Level:          e.Level,
+		AcknowledgedBy: e.AcknowledgedBy,
+		AcknowledgedAt: e.AcknowledgedAt,
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+const (
+	NotifyPolicySend  = "send"
+	NotifyPolicyDedup = "dedup"
+	NotifyPolicyHold  = "hold"
+
+	quietClockFormat = "15:04"
+)
+
+func priorityLevel(priority string) int {
+	switch priority {
+	case api.NOTIFICATION_PRIORITY_CRITICAL:
+		return 2
+	case api.NOTIFICATION_PRIORITY_IMPORTANT:
+		return 1
+	}
+	return 0
+}
+
+func validateNotifyPolicy(input api.ReceiverNotifyPolicyInput, quietStart, quietEnd string) error {
+	if input.DedupWindowSeconds != nil && *input.DedupWindowSeconds < 0 {
+		return httperrors.NewInputParameterError("dedup_window_seconds must not be negative")
+	}
+	if input.DigestIntervalSeconds != nil && *input.DigestIntervalSeconds < 0 {
+		return httperrors.NewInputParameterError("digest_interval_seconds must not be negative")
+	}
+	if input.QuietHoursStart != nil {
+		quietStart = *input.QuietHoursStart
+	}
+	if input.QuietHoursEnd != nil {
+		quietEnd = *input.QuietHoursEnd
+	}
+	if (len(quietStart) == 0) != (len(quietEnd) == 0) {
+		return httperrors.NewInputParameterError("quiet_hours_start and quiet_hours_end must be set together")
+	}
+	for _, clock := range []string{quietStart, quietEnd} {
+		if len(clock) == 0 {
+			continue
+		}
+		if _, err := time.Parse(quietClockFormat, clock); err != nil {
+			return httperrors.NewInputParameterError("invalid quiet hours %q, format is HH:MM", clock)
+		}
+	}
+	if len(input.QuietHoursOverridePriority) > 0 && !utils.IsInStringArray(input.QuietHoursOverridePriority, []string{
+		api.NOTIFICATION_PRIORITY_NORMAL,
+		api.NOTIFICATION_PRIORITY_IMPORTANT,
+		api.NOTIFICATION_PRIORITY_CRITICAL,
+	}) {
+		return httperrors.NewInputParameterError("invalid quiet_hours_override_priority %q", input.QuietHoursOverridePriority)
+	}
+	return nil
+}
+
+// quietHoursEnd returns end of the quiet hours covering now, zero time if now is out of quiet hours.
+// Quiet hours may span midnight, e.g. 22:00-08:00
+func quietHoursEnd(start, end string, now time.Time) time.Time {
+	if len(start) == 0 || len(end) == 0 {
+		return time.Time{}
+	}
+	startTm, err := time.Parse(quietClockFormat, start)
+	if err != nil {
+		return time.Time{}
+	}
+	endTm, err := time.Parse(quietClockFormat, end)
+	if err != nil {
+		return time.Time{}
+	}
+	startMin := startTm.Hour()*60 + startTm.Minute()
+	endMin := endTm.Hour()*60 + endTm.Minute()
+	if startMin == endMin {
+		return time.Time{}
+	}
+	local := now.In(time.Local)
+	curMin := local.Hour()*60 + local.Minute()
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+	endAt := midnight.Add(time.Duration(endMin) * time.Minute)
+	if startMin < endMin {
+		if curMin >= startMin && curMin < endMin {
+			return endAt
+		}
+		return time.Time{}
+	}
+	if curMin >= startMin {
+		return endAt.AddDate(0, 0, 1)
+	}
+	if curMin < endMin {
+		return endAt
+	}
+	return time.Time{}
+}
+
+func (r *SReceiver) quietOverrideLevel() int {
+	if len(r.QuietHoursOverridePriority) == 0 {
+		return priorityLevel(api.NOTIFICATION_PRIORITY_CRITICAL)
+	}
+	return priorityLevel(r.QuietHoursOverridePriority)
+}
+
+// DedupKey identify notifications of the same event and content
+func (n *SNotification) DedupKey() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{n.ContactType, n.Topic, n.Event, n.Message}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ApplyNotifyPolicy decides whether rn is sent now, dropped as a duplicate,
+// or held in a bucket until digest interval or quiet hours pass
+func (r *SReceiver) ApplyNotifyPolicy(ctx context.Context, n *SNotification, rn *SReceiverNotification, now time.Time) (string, error) {
+	if r.DedupWindowSeconds > 0 {
+		key := n.DedupKey()
+		q := ReceiverNotificationManager.Query().Equals("receiver_id", r.Id).Equals("dedup_key", key)
+		q = q.In("status", []string{api.RECEIVER_NOTIFICATION_OK, api.RECEIVER_NOTIFICATION_SENT, api.RECEIVER_NOTIFICATION_PENDING})
+		q = q.GE("created_at", now.Add(-time.Duration(r.DedupWindowSeconds)*time.Second).UTC())
+		q = q.NotEquals("row_id", rn.RowId)
+		cnt, err := q.CountWithError()
+		if err != nil {
+			return "", errors.Wrap(err, "count identical notifications")
+		}
+		if cnt > 0 {
+			return NotifyPolicyDedup, rn.Dedup(ctx)
+		}
+		_, err = db.Update(rn, func() error {
+			rn.DedupKey = key
+			return nil
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "update dedup key")
+		}
+	}
+
+	level := priorityLevel(n.Priority)
+	var (
+		reason    string
+		releaseAt time.Time
+	)
+	if end := quietHoursEnd(r.QuietHoursStart, r.QuietHoursEnd, now); !end.IsZero() && level < r.quietOverrideLevel() {
+		reason, releaseAt = api.NOTIFICATION_BUCKET_REASON_QUIET, end
+	} else if r.DigestIntervalSeconds > 0 && level == 0 {
+		reason, releaseAt = api.NOTIFICATION_BUCKET_REASON_DIGEST, now.Add(time.Duration(r.DigestIntervalSeconds)*time.Second)
+	} else {
+		return NotifyPolicySend, nil
+	}
+	bucket, err := NotificationBucketManager.fetchOrCreate(ctx, r.Id, n.ContactType, reason, releaseAt)
+	if err != nil {
+		return "", errors.Wrap(err, "fetch notification bucket")
+	}
+	err = rn.Hold(ctx, bucket.Id)
+	if err != nil {
+		return "", errors.Wrap(err, "hold notification")
+	}
+	return NotifyPolicyHold, nil
+}
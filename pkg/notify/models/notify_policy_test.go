@@ -0,0 +1,47 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHoursEnd(t *testing.T) {
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2020, 1, day, hour, min, 0, 0, time.Local)
+	}
+	cases := []struct {
+		start string
+		end   string
+		now   time.Time
+		want  time.Time
+	}{
+		{"22:00", "08:00", at(1, 23, 0), at(2, 8, 0)},
+		{"22:00", "08:00", at(2, 7, 59), at(2, 8, 0)},
+		{"22:00", "08:00", at(2, 8, 0), time.Time{}},
+		{"22:00", "08:00", at(2, 12, 0), time.Time{}},
+		{"12:00", "14:00", at(1, 13, 0), at(1, 14, 0)},
+		{"12:00", "14:00", at(1, 11, 0), time.Time{}},
+		{"", "08:00", at(1, 1, 0), time.Time{}},
+		{"08:00", "08:00", at(1, 8, 0), time.Time{}},
+	}
+	for _, c := range cases {
+		got := quietHoursEnd(c.start, c.end, c.now)
+		if !got.Equal(c.want) {
+			t.Errorf("%s-%s at %s: want %s got %s", c.start, c.end, c.now, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/rbacutils"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SOncallScheduleManager struct {
+	db.SStandaloneResourceBaseManager
+	db.SEnabledResourceBaseManager
+}
+
+var OncallScheduleManager *SOncallScheduleManager
+
+func init() {
+	OncallScheduleManager = &SOncallScheduleManager{
+		SStandaloneResourceBaseManager: db.NewStandaloneResourceBaseManager(
+			SOncallSchedule{},
+			"oncallschedules_tbl",
+			"oncallschedule",
+			"oncallschedules",
+		),
+	}
+	OncallScheduleManager.SetVirtualObject(OncallScheduleManager)
+}
+
+// SOncallSchedule rotates participants on call every ShiftHours since RotationStart,
+// overrides take precedence over the rotation
+type SOncallSchedule struct {
+	db.SStandaloneResourceBase
+	db.SEnabledResourceBase
+
+	// ids of receiver in order of rotation
+	Participants *jsonutils.JSONArray `nullable:"true" list:"user" create:"required" update:"user"`
+	// time of the first handoff
+	RotationStart time.Time `nullable:"false" list:"user" create:"optional" update:"user"`
+	// hours every participant stays on call
+	ShiftHours int `nullable:"false" default:"24" list:"user" create:"optional" update:"user"`
+}
+
+func (sm *SOncallScheduleManager) ResourceScope() rbacutils.TRbacScope {
+	return rbacutils.ScopeSystem
+}
+
+func (sm *SOncallScheduleManager) AllowCreateItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowCreate(userCred, sm)
+}
+
+func (sm *SOncallScheduleManager) AllowListItems(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowList(userCred, sm)
+}
+
+func (s *SOncallSchedule) AllowUpdateItem(ctx context.Context, userCred mcclient.TokenCredential) bool {
+	return db.IsAdminAllowUpdate(userCred, s)
+}
+
+func (s *SOncallSchedule) AllowDeleteItem(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) bool {
+	return db.IsAdminAllowDelete(userCred, s)
+}
+
+func (s *SOncallSchedule) AllowPerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) bool {
+	return db.IsAdminAllowPerform(userCred, s, "enable")
+}
+
+func (s *SOncallSchedule) PerformEnable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformEnableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(s, ctx, userCred, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+func (s *SOncallSchedule) AllowPerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) bool {
+	return db.IsAdminAllowPerform(userCred, s, "disable")
+}
+
+func (s *SOncallSchedule) PerformDisable(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input apis.PerformDisableInput) (jsonutils.JSONObject, error) {
+	err := db.EnabledPerformEnable(s, ctx, userCred, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "EnabledPerformEnable")
+	}
+	return nil, nil
+}
+
+// fetchParticipantIds convert ids or names of receiver to ids and keep the order
+func fetchParticipantIds(ctx context.Context, idOrNames []string) ([]string, error) {
+	receivers, err := ReceiverManager.FetchByIdOrNames(ctx, idOrNames...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch receivers")
+	}
+	ids := make([]string, 0, len(idOrNames))
+	for _, idOrName := range idOrNames {
+		found := false
+		for i := range receivers {
+			if receivers[i].Id == idOrName || receivers[i].Name == idOrName {
+				ids = append(ids, receivers[i].Id)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, httperrors.NewInputParameterError("receiver %q not found", idOrName)
+		}
+	}
+	return ids, nil
+}
+
+func (sm *SOncallScheduleManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.OncallScheduleCreateInput) (api.OncallScheduleCreateInput, error) {
+	var err error
+	if len(input.Participants) == 0 {
+		return input, httperrors.NewMissingParameterError("participants")
+	}
+	input.Participants, err = fetchParticipantIds(ctx, input.Participants)
+	if err != nil {
+		return input, err
+	}
+	if input.ShiftHours == 0 {
+		input.ShiftHours = 24
+	}
+	if input.ShiftHours < 0 {
+		return input, httperrors.NewInputParameterError("shift_hours must be positive")
+	}
+	if input.RotationStart.IsZero() {
+		input.RotationStart = time.Now()
+	}
+	input.RotationStart = input.RotationStart.UTC()
+	if input.Enabled == nil {
+		enabled := true
+		input.Enabled = &enabled
+	}
+	input.StandaloneResourceCreateInput, err = sm.SStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StandaloneResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (s *SOncallSchedule) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.OncallScheduleUpdateInput) (api.OncallScheduleUpdateInput, error) {
+	var err error
+	if input.Participants != nil {
+		if len(input.Participants) == 0 {
+			return input, httperrors.NewInputParameterError("participants must not be empty")
+		}
+		input.Participants, err = fetchParticipantIds(ctx, input.Participants)
+		if err != nil {
+			return input, err
+		}
+	}
+	if input.ShiftHours != nil && *input.ShiftHours <= 0 {
+		return input, httperrors.NewInputParameterError("shift_hours must be positive")
+	}
+	if input.RotationStart != nil {
+		start := input.RotationStart.UTC()
+		input.RotationStart = &start
+	}
+	input.StandaloneResourceBaseUpdateInput, err = s.SStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.StandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (s *SOncallSchedule) ValidateDeleteCondition(ctx context.Context) error {
+	cnt, err := EscalationPolicyManager.Query().Contains("levels", s.Id).CountWithError()
+	if err != nil {
+		return errors.Wrap(err, "count escalation policies")
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("oncall schedule is used by %d escalation policies", cnt)
+	}
+	return s.SStandaloneResourceBase.ValidateDeleteCondition(ctx)
+}
+
+func (s *SOncallSchedule) CustomizeDelete(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	overrides, err := s.overrides(time.Time{})
+	if err != nil {
+		return errors.Wrap(err, "fetch overrides")
+	}
+	for i := range overrides {
+		err := overrides[i].Delete(ctx, userCred)
+		if err != nil {
+			return errors.Wrapf(err, "delete override %s", overrides[i].Id)
+		}
+	}
+	return s.SStandaloneResourceBase.CustomizeDelete(ctx, userCred, query, data)
+}
+
+func (s *SOncallSchedule) participantIds() []string {
+	if s.Participants == nil {
+		return nil
+	}
+	return s.Participants.GetStringArray()
+}
+
+// overrides returns overrides ending after since, latest created first
+func (s *SOncallSchedule) overrides(since time.Time) ([]SOncallOverride, error) {
+	q := OncallOverrideManager.Query().Equals("schedule_id", s.Id)
+	if !since.IsZero() {
+		q = q.GT("end_at", since.UTC())
+	}
+	q = q.Desc("created_at")
+	overrides := make([]SOncallOverride, 0)
+	err := db.FetchModelObjects(OncallOverrideManager, q, &overrides)
+	if err != nil && errors.Cause(err) != sql.ErrNoRows {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// OncallAt returns id of receiver on call at tm and the time this receiver hands off
+func (s *SOncallSchedule) OncallAt(tm time.Time) (string, time.Time, error) {
+	overrides, err := s.overrides(tm)
+	if err != nil {
+		return "", time.Time{}, errors.Wrap(err, "fetch overrides")
+	}
+	for i := range overrides {
+		if !overrides[i].StartAt.After(tm) {
+			return overrides[i].ReceiverId, overrides[i].EndAt, nil
+		}
+	}
+	receiverId, handoffAt := rotationAt(s.participantIds(), s.RotationStart, s.ShiftHours, tm)
+	return receiverId, handoffAt, nil
+}
+
+func rotationAt(participants []string, start time.Time, shiftHours int, tm time.Time) (string, time.Time) {
+	if len(participants) == 0 {
+		return "", time.Time{}
+	}
+	if shiftHours <= 0 || tm.Before(start) {
+		return participants[0], start
+	}
+	shift := time.Duration(shiftHours) * time.Hour
+	n := int64(tm.Sub(start) / shift)
+	return participants[n%int64(len(participants))], start.Add(time.Duration(n+1) * shift)
+}
+
+func (sm *SOncallScheduleManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQuery, userCred mcclient.TokenCredential, input api.OncallScheduleListInput) (*sqlchemy.SQuery, error) {
+	q, err := sm.SStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, input.StandaloneResourceListInput)
+	if err != nil {
+		return nil, err
+	}
+	return sm.SEnabledResourceBaseManager.ListItemFilter(ctx, q, userCred, input.EnabledResourceBaseListInput)
+}
+
+func (sm *SOncallScheduleManager) FetchCustomizeColumns(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, objs []interface{}, fields stringutils2.SSortedStrings, isList bool) []api.OncallScheduleDetails {
+	sRows := sm.SStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	rows := make([]api.OncallScheduleDetails, len(objs))
+	now := time.Now()
+	for i := range rows {
+		rows[i].StandaloneResourceDetails = sRows[i]
+		s := objs[i].(*SOncallSchedule)
+		ids := s.participantIds()
+		oncall, handoffAt, err := s.OncallAt(now)
+		if err != nil {
+			log.Errorf("unable to get oncall of schedule %s: %v", s.Id, err)
+		}
+		rows[i].CurrentOncall.ID = oncall
+		rows[i].NextHandoffAt = handoffAt
+		overrides, err := s.overrides(now)
+		if err != nil {
+			log.Errorf("unable to get overrides of schedule %s: %v", s.Id, err)
+		}
+		receivers, err := ReceiverManager.FetchByIDs(ctx, append(ids, oncall)...)
+		if err != nil {
+			log.Errorf("unable to fetch participants of schedule %s: %v", s.Id, err)
+		}
+		names := make(map[string]string, len(receivers))
+		for j := range receivers {
+			names[receivers[j].Id] = receivers[j].Name
+		}
+		for _, id := range ids {
+			rows[i].ParticipantNames = append(rows[i].ParticipantNames, names[id])
+		}
+		rows[i].CurrentOncall.Name = names[oncall]
+		for j := range overrides {
+			override := api.OncallOverride{
+				Id:      overrides[j].Id,
+				StartAt: overrides[j].StartAt,
+				EndAt:   overrides[j].EndAt,
+			}
+			override.ID = overrides[j].ReceiverId
+			override.Name = names[overrides[j].ReceiverId]
+			rows[i].Overrides = append(rows[i].Overrides, override)
+		}
+	}
+	return rows
+}
+
+func (s *SOncallSchedule) AllowPerformAddOverride(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, s, "add-override")
+}
+
+func (s *SOncallSchedule) PerformAddOverride(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.OncallScheduleAddOverrideInput) (jsonutils.JSONObject, error) {
+	if len(input.Receiver) == 0 {
+		return nil, httperrors.NewMissingParameterError("receiver")
+	}
+	ids, err := fetchParticipantIds(ctx, []string{input.Receiver})
+	if err != nil {
+		return nil, err
+	}
+	if input.StartAt.IsZero() {
+		input.StartAt = time.Now()
+	}
+	if !input.EndAt.After(input.StartAt) {
+		return nil, httperrors.NewInputParameterError("end_at must be after start_at")
+	}
+	override := &SOncallOverride{
+		ScheduleId: s.Id,
+		ReceiverId: ids[0],
+		StartAt:    input.StartAt.UTC(),
+		EndAt:      input.EndAt.UTC(),
+	}
+	override.SetModelManager(OncallOverrideManager, override)
+	err = OncallOverrideManager.TableSpec().Insert(ctx, override)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert override")
+	}
+	db.OpsLog.LogEvent(s, db.ACT_UPDATE, jsonutils.Marshal(override), userCred)
+	return nil, nil
+}
+
+func (s *SOncallSchedule) AllowPerformRemoveOverride(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, s, "remove-override")
+}
+
+func (s *SOncallSchedule) PerformRemoveOverride(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.OncallScheduleRemoveOverrideInput) (jsonutils.JSONObject, error) {
+	if len(input.OverrideId) == 0 {
+		return nil, httperrors.NewMissingParameterError("override_id")
+	}
+	override := &SOncallOverride{}
+	override.SetModelManager(OncallOverrideManager, override)
+	err := OncallOverrideManager.Query().Equals("schedule_id", s.Id).Equals("id", input.OverrideId).First(override)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, httperrors.NewResourceNotFoundError2("override", input.OverrideId)
+		}
+		return nil, errors.Wrap(err, "fetch override")
+	}
+	err = override.Delete(ctx, userCred)
+	if err != nil {
+		return nil, errors.Wrap(err, "delete override")
+	}
+	db.OpsLog.LogEvent(s, db.ACT_UPDATE, jsonutils.Marshal(override), userCred)
+	return nil, nil
+}
+
+type SOncallOverrideManager struct {
+	db.SStandaloneResourceBaseManager
+}
+
+var OncallOverrideManager *SOncallOverrideManager
+
+func init() {
+	OncallOverrideManager = &SOncallOverrideManager{
+		SStandaloneResourceBaseManager: db.NewStandaloneResourceBaseManager(
+			SOncallOverride{},
+			"oncalloverrides_tbl",
+			"oncalloverride",
+			"oncalloverrides",
+		),
+	}
+	OncallOverrideManager.SetVirtualObject(OncallOverrideManager)
+}
+
+// SOncallOverride puts receiver on call in place of the rotation from StartAt to EndAt
+// +onecloud:swagger-gen-ignore
+type SOncallOverride struct {
+	db.SStandaloneResourceBase
+
+	ScheduleId string    `width:"36" charset:"ascii" nullable:"false" index:"true"`
+	ReceiverId string    `width:"128" charset:"ascii" nullable:"false"`
+	StartAt    time.Time `nullable:"false"`
+	EndAt      time.Time `nullable:"false" index:"true"`
+}
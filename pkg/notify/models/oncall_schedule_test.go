@@ -0,0 +1,44 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRotationAt(t *testing.T) {
+	start := time.Date(2021, 1, 1, 9, 0, 0, 0, time.UTC)
+	participants := []string{"a", "b", "c"}
+	cases := []struct {
+		tm      time.Time
+		oncall  string
+		handoff time.Time
+	}{
+		{start.Add(-time.Hour), "a", start},
+		{start, "a", start.Add(24 * time.Hour)},
+		{start.Add(25 * time.Hour), "b", start.Add(48 * time.Hour)},
+		{start.Add(72 * time.Hour), "a", start.Add(96 * time.Hour)},
+	}
+	for _, c := range cases {
+		oncall, handoff := rotationAt(participants, start, 24, c.tm)
+		if oncall != c.oncall || !handoff.Equal(c.handoff) {
+			t.Errorf("at %s want %s until %s, got %s until %s", c.tm, c.oncall, c.handoff, oncall, handoff)
+		}
+	}
+	if oncall, _ := rotationAt(nil, start, 24, start); oncall != "" {
+		t.Errorf("want nobody on call without participants, got %s", oncall)
+	}
+}
@@ -108,6 +108,16 @@ type SReceiver struct {
 	// swagger:ignore
 	VerifiedMobile tristate.TriState `nullable:"false" default:"false" update:"user"`
 
+	// drop notifications identical to one sent within these seconds, 0 to disable
+	DedupWindowSeconds int `nullable:"false" default:"0" list:"user" create:"optional" update:"user"`
+	// batch normal priority notifications into a digest sent every these seconds, 0 to disable
+	DigestIntervalSeconds int `nullable:"false" default:"0" list:"user" create:"optional" update:"user"`
+	// quiet hours in local time, format HH:MM
+	QuietHoursStart string `width:"5" charset:"ascii" nullable:"true" list:"user" create:"optional" update:"user"`
+	QuietHoursEnd   string `width:"5" charset:"ascii" nullable:"true" list:"user" create:"optional" update:"user"`
+	// notifications of this priority or higher are still sent in quiet hours
+	QuietHoursOverridePriority string `width:"16" charset:"ascii" nullable:"true" default:"fatal" list:"user" create:"optional" update:"user"`
+
 	// swagger:ignore
 	subContactCache map[string]*SSubContact `json:"-"`
 }
@@ -280,6 +290,10 @@ func (rm *SReceiverManager) ValidateCreateData(ctx context.Context, userCred mcc
 	if ok := LaxMobileRegexp.MatchString(input.InternationalMobile.Mobile); len(input.InternationalMobile.Mobile) > 0 && !ok {
 		return input, httperrors.NewInputParameterError("invalid mobile")
 	}
+	err = validateNotifyPolicy(input.ReceiverNotifyPolicyInput, "", "")
+	if err != nil {
+		return input, err
+	}
 	return input, nil
 }
 
@@ -700,6 +714,10 @@ func (r *SReceiver) ValidateUpdateData(ctx context.Context, userCred mcclient.To
 	if ok := len(input.InternationalMobile.Mobile) == 0 || LaxMobileRegexp.MatchString(input.InternationalMobile.Mobile); !ok {
 		return input, httperrors.NewInputParameterError("invalid mobile")
 	}
+	err = validateNotifyPolicy(input.ReceiverNotifyPolicyInput, r.QuietHoursStart, r.QuietHoursEnd)
+	if err != nil {
+		return input, err
+	}
 	return input, nil
 }
 
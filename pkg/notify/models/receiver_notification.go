@@ -47,6 +47,13 @@ type SReceiverNotification struct {
 	SendBy       string    `width:"128" nullable:"false"`
 	Status       string    `width:"36" charset:"ascii"`
 	FailedReason string    `width:"1024"`
+	// hash of contact type, topic, event and message, identical notifications share the key
+	DedupKey string `width:"64" charset:"ascii" nullable:"true" index:"true"`
+	// bucket holding this notification for digest or quiet hours
+	BucketID string `width:"128" charset:"ascii" nullable:"true" index:"true"`
+	// count of delivery attempts
+	Attempts    int       `nullable:"false" default:"0"`
+	NextRetryAt time.Time `nullable:"true" index:"true"`
 }
 
 func (self *SReceiverNotificationManager) InitializeData() error {
@@ -105,15 +112,63 @@ func (rn *SReceiverNotification) BeforeSend(ctx context.Context, sendTime time.T
 	return err
 }
 
-func (rn *SReceiverNotification) AfterSend(ctx context.Context, success bool, reason string) error {
+// Hold put notification into bucket, it will be sent when the bucket is flushed
+func (rn *SReceiverNotification) Hold(ctx context.Context, bucketId string) error {
 	_, err := db.Update(rn, func() error {
-		if success {
+		rn.Status = api.RECEIVER_NOTIFICATION_PENDING
+		rn.BucketID = bucketId
+		return nil
+	})
+	return err
+}
+
+// Dedup mark notification dropped as an identical one was sent recently
+func (rn *SReceiverNotification) Dedup(ctx context.Context) error {
+	_, err := db.Update(rn, func() error {
+		rn.Status = api.RECEIVER_NOTIFICATION_DEDUPED
+		return nil
+	})
+	return err
+}
+
+// AfterSend records the delivery attempt, failed delivery is retried after backoff of policy
+// and moved to dead letter queue once attempts are exhausted
+func (rn *SReceiverNotification) AfterSend(ctx context.Context, contactType string, policy api.SDeliveryPolicy, success bool, reason string) error {
+	now := time.Now()
+	_, err := db.Update(rn, func() error {
+		rn.Attempts += 1
+		switch {
+		case success:
 			rn.Status = api.RECEIVER_NOTIFICATION_OK
-		} else {
+		case rn.Attempts >= policy.MaxAttempts:
+			rn.Status = api.RECEIVER_NOTIFICATION_DEAD_LETTER
+			rn.FailedReason = reason
+		default:
 			rn.Status = api.RECEIVER_NOTIFICATION_FAIL
 			rn.FailedReason = reason
+			rn.NextRetryAt = now.Add(retryBackoff(policy, rn.Attempts)).UTC()
 		}
 		return nil
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	DeliveryAttemptManager.record(ctx, rn, contactType, success, reason)
+	return nil
+}
+
+// DeadLetter moves notification to dead letter queue without retry, as it fails before
+// reaching the send agent and retry makes no difference until the cause is fixed
+func (rn *SReceiverNotification) DeadLetter(ctx context.Context, contactType string, reason string) error {
+	_, err := db.Update(rn, func() error {
+		rn.Attempts += 1
+		rn.Status = api.RECEIVER_NOTIFICATION_DEAD_LETTER
+		rn.FailedReason = reason
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	DeliveryAttemptManager.record(ctx, rn, contactType, false, reason)
+	return nil
 }
@@ -16,6 +16,7 @@ package models
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 
@@ -72,6 +73,8 @@ type SSubscription struct {
 	Resources   uint64 `nullable:"false"`
 	Actions     uint32 `nullable:"false"`
 	AdvanceDays int    `nullable:"false"`
+	// escalation policy of notifications sent for subscribed events
+	EscalationPolicyId string `width:"36" charset:"ascii" nullable:"true" list:"user"`
 }
 
 const (
@@ -209,6 +212,15 @@ func (sm *SSubscriptionManager) FetchCustomizeColumns(ctx context.Context, userC
 		rows[i].StandaloneResourceDetails = sRows[i]
 		ss := objs[i].(*SSubscription)
 		rows[i].Resources = ss.getResources()
+		if len(ss.EscalationPolicyId) > 0 {
+			rows[i].EscalationPolicy.ID = ss.EscalationPolicyId
+			policy, err := EscalationPolicyManager.fetchById(ss.EscalationPolicyId)
+			if err != nil {
+				log.Errorf("unable to fetch escalation policy %s: %v", ss.EscalationPolicyId, err)
+			} else {
+				rows[i].EscalationPolicy.Name = policy.Name
+			}
+		}
 		srs, err := ss.subscriptionReceiverDiss()
 		if err != nil {
 			log.Errorf("unable to get subscriptionReceivers: %v", err)
@@ -394,6 +406,29 @@ func (ss *SSubscription) PerformSetWebhook(ctx context.Context, userCred mcclien
 	return nil, err
 }
 
+func (ss *SSubscription) AllowPerformSetEscalationPolicy(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) bool {
+	return db.IsAdminAllowPerform(userCred, ss, "set-escalation-policy")
+}
+
+func (ss *SSubscription) PerformSetEscalationPolicy(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input notify.SubscriptionSetEscalationPolicyInput) (jsonutils.JSONObject, error) {
+	var policyId string
+	if len(input.EscalationPolicy) > 0 {
+		policy, err := EscalationPolicyManager.FetchByIdOrName(userCred, input.EscalationPolicy)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, httperrors.NewResourceNotFoundError2(EscalationPolicyManager.Keyword(), input.EscalationPolicy)
+			}
+			return nil, errors.Wrap(err, "EscalationPolicyManager.FetchByIdOrName")
+		}
+		policyId = policy.GetId()
+	}
+	_, err := db.Update(ss, func() error {
+		ss.EscalationPolicyId = policyId
+		return nil
+	})
+	return nil, err
+}
+
 func (ss *SSubscription) setSingleReceiver(ctx context.Context, re string, reTypes ...string) error {
 	receiverRobot := reTypes
 	if !utils.IsInStringArray(re, receiverRobot) {
@@ -26,13 +26,24 @@ type NotifyOption struct {
 	UpdateInterval int    `help:"Update send services interval(unit:min)" default:"30"`
 
 	ReSendScope  int `help:"Resend all messages that have not been sent successfully within ReSendScope seconds" default:"60"`
-	MaxSendTimes int `help:"Resend all messages whose sendTimes less than MaxSendTimes" default:"2"`
+	MaxSendTimes int `help:"Deprecated, use delivery_max_attempts instead" default:"2"`
 
 	InitNotificationScope int `help:"initialize data of notification with in InitNotificationScope hours" default:"100"`
 	MaxSyncNotification   int `help:"The max number of notification sync from old data source" default:"1000"`
 
 	VerifyExpireInterval int `help:"expire interval of verify message; minutes" default:"2"`
 	VerifyValidInterval  int `help:"valid interval of verify message; miniutes" default:"20"`
+
+	BucketFlushInterval int `help:"interval to send digest of held notifications whose release time has come; seconds" default:"60"`
+
+	EscalationCheckInterval int    `help:"interval to escalate notifications not acknowledged in time; seconds" default:"60"`
+	AcknowledgeEndpoint     string `help:"external url of notify service used in acknowledge links of escalated notifications, e.g. https://192.168.0.10:30777, no link if empty"`
+
+	DeliveryMaxAttempts     int `help:"default max attempts to deliver a notification before it is moved to dead letter queue" default:"3"`
+	DeliveryRetryBackoff    int `help:"default seconds to wait before the first retry of failed delivery, doubled for every later retry" default:"60"`
+	DeliveryRetryMaxBackoff int `help:"default upper bound of seconds to wait between retries of failed delivery" default:"3600"`
+	DeliveryRateLimit       int `help:"default messages sent by a send agent per second, 0 for unlimited" default:"0"`
+	DeliveryRateBurst       int `help:"default max messages sent by a send agent in a burst" default:"10"`
 }
 
 var Options NotifyOption
@@ -22,6 +22,9 @@ import (
 var (
 	notifySystemResources = []string{
 		"configs",
+		"oncallschedules",
+		"escalationpolicies",
+		"deliveryattempts",
 	}
 	notifyDomainResources = []string{}
 	notifyUserResources   = []string{
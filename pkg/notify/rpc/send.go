@@ -22,8 +22,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -52,6 +54,10 @@ type SRpcService struct {
 	socketFileDir string
 	configStore   notifyv2.IServiceConfigStore
 	templateStore notifyv2.ITemplateStore
+
+	// token bucket of every send service to respect quota of provider
+	limiters    map[string]*rate.Limiter
+	limiterLock sync.Mutex
 }
 
 // NewSRpcService create a SRpcService
@@ -62,6 +68,7 @@ func NewSRpcService(socketFileDir string, configStore notifyv2.IServiceConfigSto
 		socketFileDir: socketFileDir,
 		configStore:   configStore,
 		templateStore: tempalteStore,
+		limiters:      make(map[string]*rate.Limiter),
 	}
 }
 
@@ -113,7 +120,10 @@ func (self *SRpcService) Send(ctx context.Context, contactType string, args apis
 	if len(args.RemoteTemplate) == 0 && contactType == api.MOBILE {
 		return fmt.Errorf("empty remote template for mobile type notification")
 	}
-	var err error
+	err := self.wait(ctx, contactType, 1)
+	if err != nil {
+		return errors.Wrap(err, "wait for rate limit")
+	}
 	f := func(service *apis.SendNotificationClient) (interface{}, error) {
 		log.Debugf("send one")
 		return service.Send(ctx, &args)
@@ -135,6 +145,10 @@ func (self *SRpcService) BatchSend(ctx context.Context, contactType string, args
 	if len(args.RemoteTemplate) == 0 && contactType == api.MOBILE {
 		return nil, fmt.Errorf("empty remote template for mobile type notification")
 	}
+	err := self.wait(ctx, contactType, len(args.Contacts))
+	if err != nil {
+		return nil, errors.Wrap(err, "wait for rate limit")
+	}
 	f := func(service *apis.SendNotificationClient) (interface{}, error) {
 		return service.BatchSend(ctx, &args)
 	}
@@ -151,6 +165,59 @@ func (self *SRpcService) BatchSend(ctx context.Context, contactType string, args
 	return reply.FailedRecords, nil
 }
 
+func rateLimit(limit float64) rate.Limit {
+	if limit <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(limit)
+}
+
+// SetRateLimit changes rate limit of send service, limit is messages per second and 0 means unlimited
+func (self *SRpcService) SetRateLimit(serviceName string, limit float64, burst int) {
+	if burst <= 0 {
+		burst = 1
+	}
+	self.limiterLock.Lock()
+	defer self.limiterLock.Unlock()
+	if limiter, ok := self.limiters[serviceName]; ok {
+		limiter.SetLimit(rateLimit(limit))
+		limiter.SetBurst(burst)
+		return
+	}
+	self.limiters[serviceName] = rate.NewLimiter(rateLimit(limit), burst)
+}
+
+func (self *SRpcService) limiter(serviceName string) *rate.Limiter {
+	self.limiterLock.Lock()
+	limiter, ok := self.limiters[serviceName]
+	self.limiterLock.Unlock()
+	if ok {
+		return limiter
+	}
+	policy := models.ConfigManager.DeliveryPolicy(serviceName)
+	self.SetRateLimit(serviceName, policy.RateLimit, policy.RateBurst)
+	self.limiterLock.Lock()
+	defer self.limiterLock.Unlock()
+	return self.limiters[serviceName]
+}
+
+// wait blocks until send service is allowed to send n messages
+func (self *SRpcService) wait(ctx context.Context, serviceName string, n int) error {
+	limiter := self.limiter(serviceName)
+	for n > 0 {
+		k := n
+		if burst := limiter.Burst(); k > burst {
+			k = burst
+		}
+		err := limiter.WaitN(ctx, k)
+		if err != nil {
+			return err
+		}
+		n -= k
+	}
+	return nil
+}
+
 // RestartService can restart remote rpc server and pass config info.
 // This function should be call immediately after init notify server firstly
 // This function should be call immediately after accept the request about changing config.
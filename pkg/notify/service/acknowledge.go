@@ -0,0 +1,100 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+
+	"yunion.io/x/log"
+
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/appctx"
+	"yunion.io/x/onecloud/pkg/appsrv"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/notify/models"
+)
+
+type iEscalationAcknowledger interface {
+	FetchByToken(token string) (*models.SNotificationEscalation, error)
+	AcknowledgeByToken(ctx context.Context, token string) error
+}
+
+var escalationAcknowledger iEscalationAcknowledger = models.NotificationEscalationManager
+
+// addAcknowledgeHandler serves acknowledge links sent in escalated notifications,
+// the token in link authenticates the request so that it works from email and robot messages.
+// Mail scanners and link previews fetch links, so GET only shows a confirmation page
+// and the escalation is acknowledged by POST from its button
+func addAcknowledgeHandler(app *appsrv.Application) {
+	prefix := fmt.Sprintf("%s/<token>", models.AcknowledgeUrlPath)
+	app.AddHandler("GET", prefix, acknowledgePageHandler)
+	app.AddHandler("POST", prefix, acknowledgeHandler)
+}
+
+var acknowledgePageTemplate = template.Must(template.New("acknowledge").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Topic}}</title></head>
+<body>
+<h3>{{.Topic}}</h3>
+<p>Priority: {{.Priority}}</p>
+<pre>{{.Message}}</pre>
+{{if .Acknowledged}}<p>Acknowledged by {{.AcknowledgedBy}} at {{.AcknowledgedAt}}</p>
+{{else}}<form method="post"><button type="submit">Acknowledge</button></form>
+{{end}}</body>
+</html>
+`))
+
+func acknowledgePageHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	params := appctx.AppContextParams(ctx)
+	esc, err := escalationAcknowledger.FetchByToken(params["<token>"])
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	page := struct {
+		Topic          string
+		Priority       string
+		Message        string
+		Acknowledged   bool
+		AcknowledgedBy string
+		AcknowledgedAt string
+	}{
+		Topic:          esc.Topic,
+		Priority:       esc.Priority,
+		Message:        esc.Message,
+		Acknowledged:   esc.Status == api.ESCALATION_STATUS_ACKNOWLEDGED,
+		AcknowledgedBy: esc.AcknowledgedBy,
+		AcknowledgedAt: esc.AcknowledgedAt.Format("2006-01-02 15:04:05 MST"),
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := acknowledgePageTemplate.Execute(w, page); err != nil {
+		log.Errorf("render acknowledge page: %s", err)
+	}
+}
+
+func acknowledgeHandler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	params := appctx.AppContextParams(ctx)
+	err := escalationAcknowledger.AcknowledgeByToken(ctx, params["<token>"])
+	if err != nil {
+		httperrors.GeneralServerError(ctx, w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, "Acknowledged\n")
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/notify"
+	"yunion.io/x/onecloud/pkg/appctx"
+	"yunion.io/x/onecloud/pkg/notify/models"
+)
+
+type fakeAcknowledger struct {
+	esc *models.SNotificationEscalation
+}
+
+func (f *fakeAcknowledger) FetchByToken(token string) (*models.SNotificationEscalation, error) {
+	return f.esc, nil
+}
+
+func (f *fakeAcknowledger) AcknowledgeByToken(ctx context.Context, token string) error {
+	f.esc.Status = api.ESCALATION_STATUS_ACKNOWLEDGED
+	f.esc.AcknowledgedBy = "link"
+	return nil
+}
+
+func TestAcknowledgeHandlers(t *testing.T) {
+	fake := &fakeAcknowledger{esc: &models.SNotificationEscalation{Topic: "disk full", Message: "disk <sda> is full"}}
+	fake.esc.Status = api.ESCALATION_STATUS_ESCALATING
+	origin := escalationAcknowledger
+	escalationAcknowledger = fake
+	defer func() {
+		escalationAcknowledger = origin
+	}()
+	ctx := context.WithValue(context.Background(), appctx.APP_CONTEXT_KEY_PARAMS, map[string]string{"<token>": "token"})
+
+	w := httptest.NewRecorder()
+	acknowledgePageHandler(ctx, w, httptest.NewRequest(http.MethodGet, models.AcknowledgeUrlPath+"/token", nil))
+	if fake.esc.Status != api.ESCALATION_STATUS_ESCALATING {
+		t.Fatalf("GET should not acknowledge, status %s", fake.esc.Status)
+	}
+	page := w.Body.String()
+	for _, want := range []string{"disk full", "disk &lt;sda&gt; is full", `<form method="post">`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("confirmation page should contain %q:\n%s", want, page)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	acknowledgeHandler(ctx, w, httptest.NewRequest(http.MethodPost, models.AcknowledgeUrlPath+"/token", nil))
+	if fake.esc.Status != api.ESCALATION_STATUS_ACKNOWLEDGED {
+		t.Fatalf("POST should acknowledge, status %s", fake.esc.Status)
+	}
+
+	w = httptest.NewRecorder()
+	acknowledgePageHandler(ctx, w, httptest.NewRequest(http.MethodGet, models.AcknowledgeUrlPath+"/token", nil))
+	if page := w.Body.String(); strings.Contains(page, "<form") || !strings.Contains(page, "Acknowledged by link") {
+		t.Errorf("acknowledged page should not show the button:\n%s", page)
+	}
+}
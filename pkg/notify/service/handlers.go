@@ -39,6 +39,8 @@ func InitHandlers(app *appsrv.Application) {
 		models.SubContactManager,
 		models.VerificationManager,
 		models.SubscriptionReceiverManager,
+		models.OncallOverrideManager,
+		models.NotificationEscalationManager,
 	} {
 		db.RegisterModelManager(manager)
 	}
@@ -51,6 +53,9 @@ func InitHandlers(app *appsrv.Application) {
 		models.ConfigManager,
 		models.TemplateManager,
 		models.SubscriptionManager,
+		models.OncallScheduleManager,
+		models.EscalationPolicyManager,
+		models.DeliveryAttemptManager,
 	} {
 		db.RegisterModelManager(manager)
 		handler := db.NewModelHandler(manager)
@@ -63,4 +68,6 @@ func InitHandlers(app *appsrv.Application) {
 		handler := db.NewJointModelHandler(manager)
 		dispatcher.AddJointModelDispatcher(API_VERSION, app, handler)
 	}
+
+	addAcknowledgeHandler(app)
 }
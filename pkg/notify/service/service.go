@@ -74,6 +74,12 @@ func StartService() {
 
 	// wrapped func to resend notifications
 	cron.AddJobAtIntervals("ReSendNotifications", time.Duration(opts.ReSendScope)*time.Second, models.NotificationManager.ReSend)
+
+	// send digest of notifications held for digest or quiet hours
+	cron.AddJobAtIntervals("FlushNotificationBuckets", time.Duration(opts.BucketFlushInterval)*time.Second, models.NotificationBucketManager.FlushDue)
+
+	// notify the next level of escalation policies
+	cron.AddJobAtIntervals("EscalateNotifications", time.Duration(opts.EscalationCheckInterval)*time.Second, models.NotificationEscalationManager.EscalateDue)
 	cron.Start()
 
 	app.ServeForever(applicaion, baseOpts)
@@ -65,12 +65,19 @@ func (self *NotificationSendTask) OnInit(ctx context.Context, obj db.IStandalone
 	}
 
 	failedRecord := make([]string, 0)
+	// failures before reaching send agent are not retried
 	sendFail := func(rn *models.SReceiverNotification, reason string) {
-		rn.AfterSend(ctx, false, reason)
+		rn.DeadLetter(ctx, notification.ContactType, reason)
+		failedRecord = append(failedRecord, fmt.Sprintf("%s: %s", rn.ReceiverID, reason))
+	}
+	policy := models.ConfigManager.DeliveryPolicy(notification.ContactType)
+	deliverFail := func(rn *models.SReceiverNotification, reason string) {
+		rn.AfterSend(ctx, notification.ContactType, policy, false, reason)
 		failedRecord = append(failedRecord, fmt.Sprintf("%s: %s", rn.ReceiverID, reason))
 	}
 
 	// build contactMap
+	policyNow := time.Now()
 	contactMap := make(map[string]*models.SReceiverNotification)
 	contactMapEn := make(map[string]*models.SReceiverNotification)
 	contactmapCn := make(map[string]*models.SReceiverNotification)
@@ -123,6 +130,15 @@ func (self *NotificationSendTask) OnInit(ctx context.Context, obj db.IStandalone
 			sendFail(rnsWithReceiver[i], reason)
 			continue
 		}
+		// dedup, quiet hours and digest of receiver
+		action, err := receiver.ApplyNotifyPolicy(ctx, notification, rnsWithReceiver[i], policyNow)
+		if err != nil {
+			sendFail(rnsWithReceiver[i], fmt.Sprintf("fail to ApplyNotifyPolicy: %s", err.Error()))
+			continue
+		}
+		if action != models.NotifyPolicySend {
+			continue
+		}
 		switch lang {
 		case "":
 			contactMap[contact] = rnsWithReceiver[i]
@@ -152,6 +168,9 @@ func (self *NotificationSendTask) OnInit(ctx context.Context, obj db.IStandalone
 		if err != nil {
 			self.taskFailed(ctx, notification, err.Error(), false)
 		}
+		if len(p.RemoteTemplate) == 0 {
+			p.Message = notification.AppendAcknowledgeLink(lang, p.Message)
+		}
 		// set status before send
 		now := time.Now()
 		contacts := make([]string, 0, len(contactMap))
@@ -172,19 +191,19 @@ func (self *NotificationSendTask) OnInit(ctx context.Context, obj db.IStandalone
 		})
 		if err != nil {
 			for _, rn := range contactMap {
-				sendFail(rn, err.Error())
+				deliverFail(rn, err.Error())
 			}
 			continue
 		}
 		// check result
 		for _, fd := range fds {
 			rn := contactMap[fd.Contact]
-			sendFail(rn, fd.Reason)
+			deliverFail(rn, fd.Reason)
 			delete(contactMap, fd.Contact)
 		}
 		// after send for successful notify
 		for _, rn := range contactMap {
-			rn.AfterSend(ctx, true, "")
+			rn.AfterSend(ctx, notification.ContactType, policy, true, "")
 		}
 	}
 	if len(failedRecord) > 0 && len(failedRecord) == contactLen {
@@ -0,0 +1,51 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predicates
+
+import (
+	computeapi "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+// OvsDpdkPredicate filter out hosts without OVS-DPDK datapath
+// when any network requests nic driver vhost-user.
+type OvsDpdkPredicate struct {
+	BasePredicate
+}
+
+func (p *OvsDpdkPredicate) Name() string {
+	return "host_ovs_dpdk"
+}
+
+func (p *OvsDpdkPredicate) Clone() core.FitPredicate {
+	return &OvsDpdkPredicate{}
+}
+
+func (p *OvsDpdkPredicate) PreExecute(u *core.Unit, cs []core.Candidater) (bool, error) {
+	for _, net := range u.SchedData().Networks {
+		if net.Driver == computeapi.NETWORK_DRIVER_VHOST_USER {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (p *OvsDpdkPredicate) Execute(u *core.Unit, c core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	h := NewPredicateHelper(p, u, c)
+	if !c.Getter().OvsDpdkCapable() {
+		h.Exclude("host doesn't support OVS-DPDK datapath for vhost-user nic")
+	}
+	return h.GetResult()
+}
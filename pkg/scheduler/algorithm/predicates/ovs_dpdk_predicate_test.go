@@ -0,0 +1,103 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predicates
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	computeapi "yunion.io/x/onecloud/pkg/apis/compute"
+	schedapi "yunion.io/x/onecloud/pkg/apis/scheduler"
+	"yunion.io/x/onecloud/pkg/scheduler/api"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	"yunion.io/x/onecloud/pkg/scheduler/test/mock"
+)
+
+func newOvsDpdkTestUnit(drivers ...string) *core.Unit {
+	nets := make([]*computeapi.NetworkConfig, 0)
+	for i, driver := range drivers {
+		nets = append(nets, &computeapi.NetworkConfig{Index: i, Driver: driver})
+	}
+	info := &api.SchedInfo{
+		ScheduleInput: &schedapi.ScheduleInput{
+			ServerConfig: schedapi.ServerConfig{
+				ServerConfigs: &computeapi.ServerConfigs{
+					Networks: nets,
+				},
+			},
+		},
+	}
+	return core.NewScheduleUnit(info, nil)
+}
+
+func TestOvsDpdkPredicate_PreExecute(t *testing.T) {
+	cases := []struct {
+		name    string
+		drivers []string
+		want    bool
+	}{
+		{"no networks", nil, false},
+		{"virtio only", []string{"virtio", "e1000"}, false},
+		{"vhost-user", []string{"virtio", computeapi.NETWORK_DRIVER_VHOST_USER}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := &OvsDpdkPredicate{}
+			got, err := p.PreExecute(newOvsDpdkTestUnit(c.drivers...), nil)
+			if err != nil {
+				t.Fatalf("PreExecute error: %v", err)
+			}
+			if got != c.want {
+				t.Errorf("PreExecute = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestOvsDpdkPredicate_Execute(t *testing.T) {
+	cases := []struct {
+		name    string
+		capable bool
+		want    bool
+	}{
+		{"host with ovs-dpdk", true, true},
+		{"host without ovs-dpdk", false, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			getter := mock.NewMockCandidatePropertyGetter(ctrl)
+			getter.EXPECT().OvsDpdkCapable().Return(c.capable)
+			candi := mock.NewMockCandidater(ctrl)
+			candi.EXPECT().Getter().Return(getter).AnyTimes()
+			candi.EXPECT().IndexKey().Return("host01").AnyTimes()
+
+			p := &OvsDpdkPredicate{}
+			got, reasons, err := p.Execute(newOvsDpdkTestUnit(computeapi.NETWORK_DRIVER_VHOST_USER), candi)
+			if err != nil {
+				t.Fatalf("Execute error: %v", err)
+			}
+			if got != c.want {
+				t.Errorf("Execute = %v, want %v", got, c.want)
+			}
+			if !c.want && len(reasons) == 0 {
+				t.Errorf("expect failure reasons when host is excluded")
+			}
+		})
+	}
+}
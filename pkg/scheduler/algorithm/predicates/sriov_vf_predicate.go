@@ -0,0 +1,98 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predicates
+
+import (
+	"fmt"
+
+	computeapi "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+// SriovVfPredicate check host has enough free SR-IOV VFs on the wires
+// of networks which request nic driver vfio-pci.
+type SriovVfPredicate struct {
+	BasePredicate
+}
+
+func (p *SriovVfPredicate) Name() string {
+	return "host_sriov_vf"
+}
+
+func (p *SriovVfPredicate) Clone() core.FitPredicate {
+	return &SriovVfPredicate{}
+}
+
+func (p *SriovVfPredicate) PreExecute(u *core.Unit, cs []core.Candidater) (bool, error) {
+	for _, net := range u.SchedData().Networks {
+		if net.Driver == computeapi.NETWORK_DRIVER_VFIO {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (p *SriovVfPredicate) Execute(u *core.Unit, c core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	h := NewPredicateHelper(p, u, c)
+	getter := c.Getter()
+	d := u.SchedData()
+
+	freeVfs := make(map[string]int)
+	for _, dev := range getter.UnusedIsolatedDevicesByType(computeapi.SRIOV_VF_TYPE) {
+		freeVfs[dev.WireId] += 1
+	}
+
+	// choose the wire with most free VFs for each request
+	wireRequest := make(map[string]int)
+	for _, reqNet := range d.Networks {
+		if reqNet.Driver != computeapi.NETWORK_DRIVER_VFIO {
+			continue
+		}
+		netTypes := p.GetHypervisorDriver(u).GetRandomNetworkTypes()
+		if len(reqNet.NetType) > 0 {
+			netTypes = []string{reqNet.NetType}
+		}
+		selWire := ""
+		for _, n := range getter.Networks() {
+			if freeVfs[n.WireId] <= freeVfs[selWire] {
+				continue
+			}
+			if IsNetworkAvailable(c, d, reqNet, n, netTypes) != nil {
+				continue
+			}
+			selWire = n.WireId
+		}
+		if len(selWire) == 0 {
+			h.Exclude(fmt.Sprintf("No free SR-IOV VF for network %q", reqNet.Network))
+			return h.GetResult()
+		}
+		wireRequest[selWire] += 1
+	}
+
+	minCapacity := int64(0xFFFFFFFF)
+	for wireId, reqCount := range wireRequest {
+		freeCount := freeVfs[wireId]
+		if freeCount < reqCount {
+			h.Exclude(fmt.Sprintf("SR-IOV VF of wire %q not enough, request: %d, hostFree: %d", wireId, reqCount, freeCount))
+			return h.GetResult()
+		}
+		cap := int64(freeCount / reqCount)
+		if cap < minCapacity {
+			minCapacity = cap
+		}
+	}
+	h.SetCapacity(minCapacity)
+	return h.GetResult()
+}
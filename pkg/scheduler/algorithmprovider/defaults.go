@@ -41,6 +41,8 @@ func defaultPredicates() sets.String {
 		factory.RegisterFitPredicate("i-GuestStorageFilter", &predicateguest.StoragePredicate{}),
 		factory.RegisterFitPredicate("j-GuestNetworkFilter", &predicates.NetworkPredicate{}),
 		factory.RegisterFitPredicate("k-GuestIsolatedDeviceFilter", &predicates.IsolatedDevicePredicate{}),
+		factory.RegisterFitPredicate("k-GuestSriovVfFilter", &predicates.SriovVfPredicate{}),
+		factory.RegisterFitPredicate("k-GuestOvsDpdkFilter", &predicates.OvsDpdkPredicate{}),
 		factory.RegisterFitPredicate("l-GuestResourceTypeFilter", &predicates.ResourceTypePredicate{}),
 		factory.RegisterFitPredicate("m-GuestDiskschedtagFilter", &predicates.DiskSchedtagPredicate{}),
 		factory.RegisterFitPredicate("n-ServerSkuFilter", &predicates.InstanceTypePredicate{}),
@@ -163,6 +163,10 @@ func (b baseHostGetter) OvnCapable() bool {
 	return false
 }
 
+func (b baseHostGetter) OvsDpdkCapable() bool {
+	return false
+}
+
 func (b baseHostGetter) ResourceType() string {
 	return reviseResourceType(b.h.ResourceType)
 }
@@ -449,6 +453,7 @@ func (h *BaseHostDesc) fillIsolatedDevices(b *baseBuilder, host *computemodels.S
 			Model:          devModel.Model,
 			Addr:           devModel.Addr,
 			VendorDeviceID: devModel.VendorDeviceId,
+			WireId:         devModel.WireId,
 		}
 		devs[index] = dev
 	}
@@ -97,6 +97,10 @@ func (h *hostGetter) OvnCapable() bool {
 	return len(h.h.OvnVersion) > 0
 }
 
+func (h *hostGetter) OvsDpdkCapable() bool {
+	return len(h.h.OvsDpdkVersion) > 0
+}
+
 type HostDesc struct {
 	*BaseHostDesc
 
@@ -79,6 +79,7 @@ type CandidatePropertyGetter interface {
 	Storages() []*api.CandidateStorage
 	Networks() []*api.CandidateNetwork
 	OvnCapable() bool
+	OvsDpdkCapable() bool
 	Status() string
 	HostStatus() string
 	Enabled() bool
@@ -239,6 +240,7 @@ type IsolatedDeviceDesc struct {
 	Model          string
 	Addr           string
 	VendorDeviceID string
+	WireId         string
 }
 
 func (i *IsolatedDeviceDesc) VendorID() string {
@@ -431,6 +431,20 @@ func (mr *MockCandidatePropertyGetterMockRecorder) OvnCapable() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OvnCapable", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).OvnCapable))
 }
 
+// OvsDpdkCapable mocks base method
+func (m *MockCandidatePropertyGetter) OvsDpdkCapable() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OvsDpdkCapable")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// OvsDpdkCapable indicates an expected call of OvsDpdkCapable
+func (mr *MockCandidatePropertyGetterMockRecorder) OvsDpdkCapable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OvsDpdkCapable", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).OvsDpdkCapable))
+}
+
 // ProjectGuests mocks base method
 func (m *MockCandidatePropertyGetter) ProjectGuests() map[string]int64 {
 	m.ctrl.T.Helper()
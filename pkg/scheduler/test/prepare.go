@@ -96,6 +96,7 @@ type sGetterParams struct {
 	Storages               []*api.CandidateStorage
 	Networks               []*api.CandidateNetwork
 	OvnCapable             *bool
+	OvsDpdkCapable         *bool
 	Status                 string
 	HostStatus             string
 	Enabled                *bool
@@ -150,6 +151,11 @@ func buildGetter(ctrl *gomock.Controller, param sGetterParams) *mock.MockCandida
 	} else {
 		cg.EXPECT().OvnCapable().AnyTimes().Return(*param.OvnCapable)
 	}
+	if param.OvsDpdkCapable == nil {
+		cg.EXPECT().OvsDpdkCapable().AnyTimes().Return(false)
+	} else {
+		cg.EXPECT().OvsDpdkCapable().AnyTimes().Return(*param.OvsDpdkCapable)
+	}
 	if len(param.Status) == 0 {
 		cg.EXPECT().Status().AnyTimes().Return(computeapi.HOST_HEALTH_STATUS_RUNNING)
 	} else {
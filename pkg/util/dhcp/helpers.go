@@ -50,6 +50,10 @@ type ResponseConfig struct {
 	BootServer string
 	BootFile   string
 	BootBlock  uint16
+
+	// VendorClassId is echoed in option 60, UEFI HTTP Boot clients
+	// ignore offers without "HTTPClient"
+	VendorClassId string
 }
 
 func (conf ResponseConfig) GetHostname() string {
@@ -147,6 +151,9 @@ func makeDHCPReplyPacket(req Packet, conf *ResponseConfig, msgType MessageType)
 		binary.BigEndian.PutUint16(sz, conf.BootBlock)
 		resp.AddOption(OptionBootFileSize, sz)
 	}
+	if conf.VendorClassId != "" {
+		resp.AddOption(OptionVendorClassIdentifier, []byte(conf.VendorClassId))
+	}
 	//if bs, _ := req.ParseOptions().Bytes(OptionClientMachineIdentifier); bs != nil {
 	//resp.AddOption(OptionClientMachineIdentifier, bs)
 	//}
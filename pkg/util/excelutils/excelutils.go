@@ -16,6 +16,7 @@ package excelutils
 
 import (
 	"bytes"
+	"encoding/csv"
 	"fmt"
 	"io"
 	"os"
@@ -113,3 +114,27 @@ func ExportFile(data []jsonutils.JSONObject, keys []string, texts []string, file
 
 	return Export(data, keys, texts, writer)
 }
+
+// ExportCsv write data in csv format, columns are the same as Export
+func ExportCsv(data []jsonutils.JSONObject, keys []string, texts []string, writer io.Writer) error {
+	w := csv.NewWriter(writer)
+	err := w.Write(texts)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(data); i += 1 {
+		row := make([]string, len(keys))
+		for j := 0; j < len(keys); j += 1 {
+			val, _ := data[i].GetIgnoreCases(keys[j])
+			if val != nil {
+				row[j], _ = val.GetString()
+			}
+		}
+		err = w.Write(row)
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -14,7 +14,12 @@
 
 package excelutils
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+)
 
 func arrayEqual(a1, a2 []int) bool {
 	if len(a1) != len(a2) {
@@ -105,3 +110,19 @@ func TestDecimalBaseMaxWidth(t *testing.T) {
 		}
 	}
 }
+
+func TestExportCsv(t *testing.T) {
+	data := []jsonutils.JSONObject{
+		jsonutils.Marshal(map[string]interface{}{"name": "p1", "total": 1.5}),
+		jsonutils.Marshal(map[string]interface{}{"name": "a,b"}),
+	}
+	buf := &bytes.Buffer{}
+	err := ExportCsv(data, []string{"name", "total"}, []string{"Name", "Total"}, buf)
+	if err != nil {
+		t.Fatalf("ExportCsv: %s", err)
+	}
+	want := "Name,Total\np1,1.5\n\"a,b\",\n"
+	if buf.String() != want {
+		t.Errorf("ExportCsv got %q want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httputils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+)
+
+const ErrRangeNotSatisfiable = errors.Error("RangeNotSatisfiable")
+
+// ParseRange parses the Range header of a request for content of the given size,
+// returns the first and the last byte position, both inclusive.
+// Only a single byte range is supported, errors.ErrNotSupported is returned for
+// other forms, in which case the whole content should be served.
+func ParseRange(rangeStr string, size int64) (int64, int64, error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(rangeStr, prefix) {
+		return 0, 0, errors.Wrapf(errors.ErrNotSupported, "range %q", rangeStr)
+	}
+	spec := strings.TrimSpace(rangeStr[len(prefix):])
+	if strings.Contains(spec, ",") {
+		return 0, 0, errors.Wrapf(errors.ErrNotSupported, "multiple ranges %q", rangeStr)
+	}
+	pos := strings.Index(spec, "-")
+	if pos < 0 {
+		return 0, 0, errors.Wrapf(errors.ErrNotSupported, "range %q", rangeStr)
+	}
+	startStr, endStr := strings.TrimSpace(spec[:pos]), strings.TrimSpace(spec[pos+1:])
+	if len(startStr) == 0 {
+		// suffix range, the last N bytes
+		suffix, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || suffix < 0 {
+			return 0, 0, errors.Wrapf(errors.ErrNotSupported, "range %q", rangeStr)
+		}
+		if suffix == 0 || size == 0 {
+			return 0, 0, errors.Wrapf(ErrRangeNotSatisfiable, "range %q of size %d", rangeStr, size)
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return size - suffix, size - 1, nil
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, errors.Wrapf(errors.ErrNotSupported, "range %q", rangeStr)
+	}
+	if start >= size {
+		return 0, 0, errors.Wrapf(ErrRangeNotSatisfiable, "range %q of size %d", rangeStr, size)
+	}
+	end := size - 1
+	if len(endStr) > 0 {
+		end, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, errors.Wrapf(errors.ErrNotSupported, "range %q", rangeStr)
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end, nil
+}
+
+// FormatContentRange formats the Content-Range header of a partial response
+func FormatContentRange(start, end, size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", start, end, size)
+}
@@ -0,0 +1,58 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httputils
+
+import (
+	"testing"
+
+	"yunion.io/x/pkg/errors"
+)
+
+func TestParseRange(t *testing.T) {
+	cases := []struct {
+		in    string
+		size  int64
+		start int64
+		end   int64
+		err   error
+	}{
+		{in: "bytes=0-99", size: 1000, start: 0, end: 99},
+		{in: "bytes=100-", size: 1000, start: 100, end: 999},
+		{in: "bytes=-100", size: 1000, start: 900, end: 999},
+		{in: "bytes=-2000", size: 1000, start: 0, end: 999},
+		{in: "bytes=900-2000", size: 1000, start: 900, end: 999},
+		{in: "bytes=1000-", size: 1000, err: ErrRangeNotSatisfiable},
+		{in: "bytes=-0", size: 1000, err: ErrRangeNotSatisfiable},
+		{in: "bytes=0-9,20-29", size: 1000, err: errors.ErrNotSupported},
+		{in: "bytes=20-10", size: 1000, err: errors.ErrNotSupported},
+		{in: "items=0-9", size: 1000, err: errors.ErrNotSupported},
+	}
+	for _, c := range cases {
+		start, end, err := ParseRange(c.in, c.size)
+		if c.err != nil {
+			if errors.Cause(err) != c.err {
+				t.Errorf("%s: want error %s got %v", c.in, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", c.in, err)
+			continue
+		}
+		if start != c.start || end != c.end {
+			t.Errorf("%s: want %d-%d got %d-%d", c.in, c.start, c.end, start, end)
+		}
+	}
+}
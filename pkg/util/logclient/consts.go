@@ -95,6 +95,7 @@ const (
 	ACT_VM_RESET                     = "vm_reset"
 	ACT_VM_SNAPSHOT_AND_CLONE        = "vm_snapshot_and_clone"
 	ACT_VM_BLOCK_STREAM              = "vm_block_stream"
+	ACT_VM_CHANGE_DISK_STORAGE       = "vm_change_disk_storage"
 	ACT_ATTACH_NETWORK               = "attach_network"
 	ACT_VM_CONVERT                   = "vm_convert"
 	ACT_FREEZE                       = "freeze"
@@ -110,6 +111,7 @@ const (
 
 	ACT_IMAGE_SAVE  = "image_save"
 	ACT_IMAGE_PROBE = "image_probe"
+	ACT_IMAGE_SIGN  = "image_sign"
 
 	ACT_AUTHENTICATE = "authenticate"
 
@@ -126,6 +128,13 @@ const (
 	ACT_GUEST_CREATE_FROM_IMPORT    = "guest_create_from_import"
 	ACT_GUEST_PANICKED              = "guest_panicked"
 	ACT_HOST_MAINTAINING            = "host_maintaining"
+	ACT_HOST_FENCE                  = "host_fence"
+	ACT_HOST_EVACUATE               = "host_evacuate"
+	ACT_VM_HA_REBUILD               = "vm_ha_rebuild"
+	ACT_VM_SET_HA_PRIORITY          = "vm_set_ha_priority"
+	ACT_VM_REBALANCE                = "vm_rebalance"
+	ACT_VM_BIND_INSTANCE_ROLE       = "vm_bind_instance_role"
+	ACT_VM_UNBIND_INSTANCE_ROLE     = "vm_unbind_instance_role"
 
 	ACT_MKDIR          = "mkdir"
 	ACT_DELETE_OBJECT  = "delete_object"
@@ -154,8 +163,17 @@ const (
 	ACT_NATGATEWAY_DISSOCIATE   = "natgateway_dissociate"
 	ACT_LOADBALANCER_DISSOCIATE = "loadbalancer_dissociate"
 
-	ACT_PREPARE = "prepare"
-	ACT_PROBE   = "probe"
+	ACT_PREPARE         = "prepare"
+	ACT_PROBE           = "probe"
+	ACT_FIRMWARE_UPDATE = "firmware_update"
+	ACT_BIOS_APPLY      = "bios_apply"
+
+	ACT_APPROVAL_SUBMIT  = "approval_submit"
+	ACT_APPROVAL_APPROVE = "approval_approve"
+	ACT_APPROVAL_REJECT  = "approval_reject"
+	ACT_APPROVAL_EXPIRE  = "approval_expire"
+	ACT_APPROVAL_CANCEL  = "approval_cancel"
+	ACT_APPROVAL_EXECUTE = "approval_execute"
 
 	ACT_INSTANCE_GROUP_BIND   = "instance_group_bind"
 	ACT_INSTANCE_GROUP_UNBIND = "instance_group_unbind"
@@ -186,6 +204,7 @@ const (
 	ACT_SEND_NOTIFICATION = "send_notification"
 	ACT_SEND_VERIFICATION = "send_verification"
 	ACT_REPULL_SUBCONTACT = "repull_subcontact"
+	ACT_ACKNOWLEDGE       = "acknowledge"
 
 	ACT_SYNC_VPCS        = "sync_vpcs"
 	ACT_SYNC_RECORD_SETS = "sync_record_sets"
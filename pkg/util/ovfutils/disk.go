@@ -0,0 +1,119 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovfutils
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+)
+
+const (
+	ErrUnsafeDiskHref   = errors.Error("UnsafeDiskHref")
+	ErrUnsupportedDisk  = errors.Error("UnsupportedDisk")
+	vmdkSparseMagic     = "KDMV"
+	vmdkMonolithic      = "monolithicSparse"
+	vmdkStreamOptimized = "streamOptimized"
+)
+
+// CheckDiskHref makes sure the href of a disk only references a file next to the descriptor
+func CheckDiskHref(href string) error {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return errors.Wrapf(err, "parse disk href %s", href)
+	}
+	if ref.IsAbs() || len(ref.Host) > 0 || len(ref.Opaque) > 0 || len(ref.Path) == 0 || path.IsAbs(ref.Path) || strings.Contains(ref.Path, "\\") {
+		return errors.Wrapf(ErrUnsafeDiskHref, "disk href %s is not a relative path", href)
+	}
+	for _, seg := range strings.Split(ref.Path, "/") {
+		if seg == ".." {
+			return errors.Wrapf(ErrUnsafeDiskHref, "disk href %s escapes the descriptor directory", href)
+		}
+	}
+	return nil
+}
+
+// ResolveDiskUrl resolves the href of a disk against the url of the descriptor,
+// the result must stay on the same host and under the directory of the descriptor
+func ResolveDiskUrl(base *url.URL, href string) (string, error) {
+	err := CheckDiskHref(href)
+	if err != nil {
+		return "", err
+	}
+	ref, _ := url.Parse(href)
+	u := base.ResolveReference(ref)
+	dir := path.Dir(base.Path)
+	if !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	if u.Scheme != base.Scheme || u.Host != base.Host || !strings.HasPrefix(u.Path, dir) {
+		return "", errors.Wrapf(ErrUnsafeDiskHref, "disk href %s resolves outside of %s", href, dir)
+	}
+	return u.String(), nil
+}
+
+// OpenDiskImage opens a disk of an ovf, only self contained sparse vmdk files are accepted
+// so that qemu-img never follows a descriptor or backing file out of the workspace
+func OpenDiskImage(diskPath string) (*qemuimg.SQemuImage, error) {
+	magic, err := readMagic(diskPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "read disk magic")
+	}
+	if magic != vmdkSparseMagic {
+		return nil, errors.Wrap(ErrUnsupportedDisk, "disk is not a sparse vmdk")
+	}
+	img, err := qemuimg.NewQemuImageWithFormat(diskPath, qemuimg.VMDK)
+	if err != nil {
+		return nil, errors.Wrap(err, "open disk")
+	}
+	err = checkDiskImage(img)
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+func readMagic(diskPath string) (string, error) {
+	fp, err := os.Open(diskPath)
+	if err != nil {
+		return "", err
+	}
+	defer fp.Close()
+	magic := make([]byte, len(vmdkSparseMagic))
+	_, err = io.ReadFull(fp, magic)
+	if err != nil {
+		return "", err
+	}
+	return string(magic), nil
+}
+
+func checkDiskImage(img *qemuimg.SQemuImage) error {
+	if img.Format != qemuimg.VMDK {
+		return errors.Wrapf(ErrUnsupportedDisk, "unsupported disk format %q", img.Format)
+	}
+	if img.IsChained() {
+		return errors.Wrapf(ErrUnsupportedDisk, "disk has backing file %s", img.BackFilePath)
+	}
+	if img.Subformat != vmdkMonolithic && img.Subformat != vmdkStreamOptimized {
+		return errors.Wrapf(ErrUnsupportedDisk, "unsupported vmdk create type %q", img.Subformat)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovfutils
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+)
+
+func TestResolveDiskUrl(t *testing.T) {
+	base, _ := url.Parse("http://example.com/images/vm/vm.ovf")
+	cases := []struct {
+		href string
+		want string
+	}{
+		{"vm-disk1.vmdk", "http://example.com/images/vm/vm-disk1.vmdk"},
+		{"disks/vm-disk1.vmdk", "http://example.com/images/vm/disks/vm-disk1.vmdk"},
+		{"../vm-disk1.vmdk", ""},
+		{"disks/../../vm-disk1.vmdk", ""},
+		{"%2e%2e/vm-disk1.vmdk", ""},
+		{"/etc/passwd", ""},
+		{"file:///etc/passwd", ""},
+		{"http://169.254.169.254/latest/meta-data", ""},
+		{"//169.254.169.254/latest", ""},
+		{"..\\vm-disk1.vmdk", ""},
+		{"", ""},
+	}
+	for _, c := range cases {
+		got, err := ResolveDiskUrl(base, c.href)
+		if len(c.want) == 0 {
+			if errors.Cause(err) != ErrUnsafeDiskHref {
+				t.Errorf("%q: want unsafe href, got %s %v", c.href, got, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", c.href, err)
+		} else if got != c.want {
+			t.Errorf("%q: want %s got %s", c.href, c.want, got)
+		}
+	}
+}
+
+func TestCheckDiskImage(t *testing.T) {
+	cases := []struct {
+		name  string
+		img   qemuimg.SQemuImage
+		valid bool
+	}{
+		{"streamOptimized", qemuimg.SQemuImage{Format: qemuimg.VMDK, Subformat: "streamOptimized"}, true},
+		{"monolithicSparse", qemuimg.SQemuImage{Format: qemuimg.VMDK, Subformat: "monolithicSparse"}, true},
+		{"monolithicFlat", qemuimg.SQemuImage{Format: qemuimg.VMDK, Subformat: "monolithicFlat"}, false},
+		{"twoGbMaxExtentSparse", qemuimg.SQemuImage{Format: qemuimg.VMDK, Subformat: "twoGbMaxExtentSparse"}, false},
+		{"backing file", qemuimg.SQemuImage{Format: qemuimg.VMDK, Subformat: "monolithicSparse", BackFilePath: "/etc/shadow"}, false},
+		{"qcow2", qemuimg.SQemuImage{Format: qemuimg.QCOW2}, false},
+	}
+	for _, c := range cases {
+		err := checkDiskImage(&c.img)
+		if c.valid && err != nil {
+			t.Errorf("%s: %s", c.name, err)
+		} else if !c.valid && errors.Cause(err) != ErrUnsupportedDisk {
+			t.Errorf("%s: want unsupported disk, got %v", c.name, err)
+		}
+	}
+}
+
+func TestOpenDiskImageDescriptor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ovfdisk")
+	if err != nil {
+		t.Fatalf("create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a text descriptor may reference any file of the host as its extent
+	path := filepath.Join(dir, "disk.vmdk")
+	desc := "# Disk DescriptorFile\nversion=1\ncreateType=\"monolithicFlat\"\nRW 2048 FLAT \"/etc/passwd\" 0\n"
+	err = ioutil.WriteFile(path, []byte(desc), 0644)
+	if err != nil {
+		t.Fatalf("write descriptor: %s", err)
+	}
+	_, err = OpenDiskImage(path)
+	if errors.Cause(err) != ErrUnsupportedDisk {
+		t.Errorf("want unsupported disk, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovfutils
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+)
+
+// ExtractOva unpacks an ova archive into dir and returns the path of the ovf descriptor,
+// directories inside the archive are flattened since ovf references files by base name
+func ExtractOva(stream io.Reader, dir string) (string, error) {
+	ovfPath := ""
+	reader := tar.NewReader(stream)
+	for {
+		hdr, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", errors.Wrap(err, "read ova")
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+		name := filepath.Base(hdr.Name)
+		if name == "." || name == ".." || name == string(filepath.Separator) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		err = extractFile(reader, path)
+		if err != nil {
+			return "", errors.Wrapf(err, "extract %s", hdr.Name)
+		}
+		if len(ovfPath) == 0 && strings.ToLower(filepath.Ext(name)) == ".ovf" {
+			ovfPath = path
+		}
+	}
+	if len(ovfPath) == 0 {
+		return "", errors.Wrap(errors.ErrNotFound, "no ovf descriptor in ova")
+	}
+	return ovfPath, nil
+}
+
+func extractFile(reader io.Reader, path string) error {
+	fp, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	_, err = io.Copy(fp, reader)
+	return err
+}
+
+// WriteOva writes an ova archive, the ovf descriptor must be the first entry
+// followed by the referenced files
+func WriteOva(w io.Writer, ovfName string, ovf []byte, files []string) error {
+	writer := tar.NewWriter(w)
+	err := writer.WriteHeader(&tar.Header{
+		Name:     ovfName,
+		Mode:     0644,
+		Size:     int64(len(ovf)),
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return errors.Wrap(err, "write ovf header")
+	}
+	_, err = writer.Write(ovf)
+	if err != nil {
+		return errors.Wrap(err, "write ovf")
+	}
+	for _, path := range files {
+		err = appendFile(writer, path)
+		if err != nil {
+			return errors.Wrapf(err, "append %s", path)
+		}
+	}
+	return writer.Close()
+}
+
+func appendFile(writer *tar.Writer, path string) error {
+	fp, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	info, err := fp.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = filepath.Base(path)
+	err = writer.WriteHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, fp)
+	return err
+}
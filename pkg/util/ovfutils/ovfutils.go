@@ -0,0 +1,403 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovfutils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"yunion.io/x/pkg/errors"
+)
+
+const (
+	FIRMWARE_BIOS = "bios"
+	FIRMWARE_UEFI = "uefi"
+
+	OS_TYPE_LINUX   = "Linux"
+	OS_TYPE_WINDOWS = "Windows"
+
+	VMDK_STREAM_OPTIMIZED = "http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"
+
+	// CIM_ResourceAllocationSettingData.ResourceType
+	resourceTypeCpu      = 3
+	resourceTypeMemory   = 4
+	resourceTypeScsi     = 6
+	resourceTypeEthernet = 10
+	resourceTypeDisk     = 17
+
+	hostResourceDiskPrefix = "ovf:/disk/"
+)
+
+type SOvfDisk struct {
+	DiskId        string
+	FileHref      string
+	FileSize      int64
+	CapacityBytes int64
+	Format        string
+}
+
+type SOvfNic struct {
+	Network string
+	Model   string
+}
+
+type SOvfDescriptor struct {
+	Name string
+	// Linux or Windows
+	OsType string
+	// vmware guest os identifier, e.g. windows9_64Guest
+	GuestOsId string
+	CpuCount  int
+	MemoryMb  int
+	Firmware  string
+	// the first disk is the system disk
+	Disks []SOvfDisk
+	Nics  []SOvfNic
+}
+
+type ovfFile struct {
+	Id   string `xml:"id,attr"`
+	Href string `xml:"href,attr"`
+	Size int64  `xml:"size,attr"`
+}
+
+type ovfDisk struct {
+	DiskId                  string `xml:"diskId,attr"`
+	FileRef                 string `xml:"fileRef,attr"`
+	Capacity                string `xml:"capacity,attr"`
+	CapacityAllocationUnits string `xml:"capacityAllocationUnits,attr"`
+	Format                  string `xml:"format,attr"`
+}
+
+type ovfItem struct {
+	InstanceID      string `xml:"InstanceID"`
+	ResourceType    int    `xml:"ResourceType"`
+	ResourceSubType string `xml:"ResourceSubType"`
+	VirtualQuantity int64  `xml:"VirtualQuantity"`
+	AllocationUnits string `xml:"AllocationUnits"`
+	HostResource    string `xml:"HostResource"`
+	Connection      string `xml:"Connection"`
+}
+
+type ovfConfig struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type ovfEnvelope struct {
+	XMLName    xml.Name  `xml:"Envelope"`
+	Files      []ovfFile `xml:"References>File"`
+	Disks      []ovfDisk `xml:"DiskSection>Disk"`
+	VirtualSys struct {
+		Id     string `xml:"id,attr"`
+		Name   string `xml:"Name"`
+		OsInfo struct {
+			Id          string `xml:"id,attr"`
+			OsType      string `xml:"osType,attr"`
+			Description string `xml:"Description"`
+		} `xml:"OperatingSystemSection"`
+		Items   []ovfItem   `xml:"VirtualHardwareSection>Item"`
+		Configs []ovfConfig `xml:"VirtualHardwareSection>Config"`
+	} `xml:"VirtualSystem"`
+}
+
+var allocationUnitsRegexp = regexp.MustCompile(`^byte\s*\*\s*2\^\s*(\d+)$`)
+
+// parseAllocationUnits returns the number of bytes of one unit, such as "byte * 2^20"
+func parseAllocationUnits(units string) (int64, error) {
+	units = strings.TrimSpace(units)
+	switch strings.ToLower(units) {
+	case "", "byte", "bytes":
+		return 1, nil
+	case "kilobytes":
+		return 1 << 10, nil
+	case "megabytes":
+		return 1 << 20, nil
+	case "gigabytes":
+		return 1 << 30, nil
+	}
+	matches := allocationUnitsRegexp.FindStringSubmatch(units)
+	if len(matches) == 0 {
+		return 0, errors.Wrapf(errors.ErrNotSupported, "allocation units %q", units)
+	}
+	exp, _ := strconv.Atoi(matches[1])
+	if exp > 62 {
+		return 0, errors.Wrapf(errors.ErrNotSupported, "allocation units %q", units)
+	}
+	return int64(1) << uint(exp), nil
+}
+
+func ParseOvf(content []byte) (*SOvfDescriptor, error) {
+	return ParseOvfStream(bytes.NewReader(content))
+}
+
+func ParseOvfStream(stream io.Reader) (*SOvfDescriptor, error) {
+	envelope := ovfEnvelope{}
+	err := xml.NewDecoder(stream).Decode(&envelope)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode ovf descriptor")
+	}
+	desc := &SOvfDescriptor{
+		Name:      envelope.VirtualSys.Name,
+		GuestOsId: envelope.VirtualSys.OsInfo.OsType,
+		Firmware:  FIRMWARE_BIOS,
+		OsType:    OS_TYPE_LINUX,
+	}
+	if len(desc.Name) == 0 {
+		desc.Name = envelope.VirtualSys.Id
+	}
+	osHint := strings.ToLower(envelope.VirtualSys.OsInfo.OsType + " " + envelope.VirtualSys.OsInfo.Description)
+	if strings.Contains(osHint, "windows") {
+		desc.OsType = OS_TYPE_WINDOWS
+	}
+	for _, conf := range envelope.VirtualSys.Configs {
+		if conf.Key == "firmware" && strings.ToLower(conf.Value) == "efi" {
+			desc.Firmware = FIRMWARE_UEFI
+		}
+	}
+
+	files := make(map[string]ovfFile)
+	for _, f := range envelope.Files {
+		files[f.Id] = f
+	}
+	disks := make(map[string]SOvfDisk)
+	for _, d := range envelope.Disks {
+		unit, err := parseAllocationUnits(d.CapacityAllocationUnits)
+		if err != nil {
+			return nil, errors.Wrapf(err, "disk %s", d.DiskId)
+		}
+		capacity, err := strconv.ParseInt(d.Capacity, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "disk %s capacity %q", d.DiskId, d.Capacity)
+		}
+		file, ok := files[d.FileRef]
+		if !ok {
+			return nil, errors.Wrapf(errors.ErrNotFound, "file %s of disk %s", d.FileRef, d.DiskId)
+		}
+		disks[d.DiskId] = SOvfDisk{
+			DiskId:        d.DiskId,
+			FileHref:      file.Href,
+			FileSize:      file.Size,
+			CapacityBytes: capacity * unit,
+			Format:        d.Format,
+		}
+	}
+
+	attached := make(map[string]bool)
+	for _, item := range envelope.VirtualSys.Items {
+		switch item.ResourceType {
+		case resourceTypeCpu:
+			desc.CpuCount = int(item.VirtualQuantity)
+		case resourceTypeMemory:
+			unit, err := parseAllocationUnits(item.AllocationUnits)
+			if err != nil {
+				return nil, errors.Wrap(err, "memory")
+			}
+			desc.MemoryMb = int(item.VirtualQuantity * unit / 1024 / 1024)
+		case resourceTypeEthernet:
+			desc.Nics = append(desc.Nics, SOvfNic{
+				Network: item.Connection,
+				Model:   item.ResourceSubType,
+			})
+		case resourceTypeDisk:
+			diskId := item.HostResource
+			if strings.HasPrefix(diskId, hostResourceDiskPrefix) {
+				diskId = diskId[len(hostResourceDiskPrefix):]
+			}
+			disk, ok := disks[diskId]
+			if !ok || attached[diskId] {
+				continue
+			}
+			attached[diskId] = true
+			desc.Disks = append(desc.Disks, disk)
+		}
+	}
+	// disks not attached to any controller are kept in the order of DiskSection
+	for _, d := range envelope.Disks {
+		if !attached[d.DiskId] {
+			desc.Disks = append(desc.Disks, disks[d.DiskId])
+		}
+	}
+	if len(desc.Disks) == 0 {
+		return nil, errors.Wrap(errors.ErrNotFound, "no disk in ovf descriptor")
+	}
+	return desc, nil
+}
+
+func (desc *SOvfDescriptor) guestOsId() string {
+	if len(desc.GuestOsId) > 0 {
+		return desc.GuestOsId
+	}
+	if desc.OsType == OS_TYPE_WINDOWS {
+		return "windows9_64Guest"
+	}
+	return "other3xLinux64Guest"
+}
+
+func nicModel(model string) string {
+	if strings.ToLower(model) == "e1000" {
+		return "E1000"
+	}
+	return "VmxNet3"
+}
+
+func xmlEscape(s string) string {
+	buf := bytes.Buffer{}
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+var ovfTemplate = template.Must(template.New("ovf").Funcs(template.FuncMap{
+	"xml": xmlEscape,
+	"add": func(a, b int) int { return a + b },
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1" xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData" xmlns:vssd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_VirtualSystemSettingData" xmlns:vmw="http://www.vmware.com/schema/ovf" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <References>
+{{- range $i, $d := .Disks}}
+    <File ovf:id="file{{add $i 1}}" ovf:href="{{xml $d.FileHref}}" ovf:size="{{$d.FileSize}}"/>
+{{- end}}
+  </References>
+  <DiskSection>
+    <Info>Virtual disk information</Info>
+{{- range $i, $d := .Disks}}
+    <Disk ovf:diskId="vmdisk{{add $i 1}}" ovf:fileRef="file{{add $i 1}}" ovf:capacity="{{$d.CapacityBytes}}" ovf:capacityAllocationUnits="byte" ovf:format="{{xml $d.Format}}"/>
+{{- end}}
+  </DiskSection>
+  <NetworkSection>
+    <Info>The list of logical networks</Info>
+{{- range .Networks}}
+    <Network ovf:name="{{xml .}}">
+      <Description>The {{xml .}} network</Description>
+    </Network>
+{{- end}}
+  </NetworkSection>
+  <VirtualSystem ovf:id="{{xml .Name}}">
+    <Info>A virtual machine</Info>
+    <Name>{{xml .Name}}</Name>
+    <OperatingSystemSection ovf:id="1" vmw:osType="{{xml .GuestOsId}}">
+      <Info>The kind of installed guest operating system</Info>
+      <Description>{{xml .OsType}}</Description>
+    </OperatingSystemSection>
+    <VirtualHardwareSection>
+      <Info>Virtual hardware requirements</Info>
+      <System>
+        <vssd:ElementName>Virtual Hardware Family</vssd:ElementName>
+        <vssd:InstanceID>0</vssd:InstanceID>
+        <vssd:VirtualSystemIdentifier>{{xml .Name}}</vssd:VirtualSystemIdentifier>
+        <vssd:VirtualSystemType>vmx-10</vssd:VirtualSystemType>
+      </System>
+      <Item>
+        <rasd:AllocationUnits>hertz * 10^6</rasd:AllocationUnits>
+        <rasd:Description>Number of Virtual CPUs</rasd:Description>
+        <rasd:ElementName>{{.CpuCount}} virtual CPU(s)</rasd:ElementName>
+        <rasd:InstanceID>1</rasd:InstanceID>
+        <rasd:ResourceType>3</rasd:ResourceType>
+        <rasd:VirtualQuantity>{{.CpuCount}}</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AllocationUnits>byte * 2^20</rasd:AllocationUnits>
+        <rasd:Description>Memory Size</rasd:Description>
+        <rasd:ElementName>{{.MemoryMb}}MB of memory</rasd:ElementName>
+        <rasd:InstanceID>2</rasd:InstanceID>
+        <rasd:ResourceType>4</rasd:ResourceType>
+        <rasd:VirtualQuantity>{{.MemoryMb}}</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:Address>0</rasd:Address>
+        <rasd:Description>SCSI Controller</rasd:Description>
+        <rasd:ElementName>SCSI Controller 0</rasd:ElementName>
+        <rasd:InstanceID>3</rasd:InstanceID>
+        <rasd:ResourceSubType>lsilogic</rasd:ResourceSubType>
+        <rasd:ResourceType>6</rasd:ResourceType>
+      </Item>
+{{- range $i, $d := .Disks}}
+      <Item>
+        <rasd:AddressOnParent>{{$i}}</rasd:AddressOnParent>
+        <rasd:ElementName>Hard Disk {{add $i 1}}</rasd:ElementName>
+        <rasd:HostResource>ovf:/disk/vmdisk{{add $i 1}}</rasd:HostResource>
+        <rasd:InstanceID>{{add $i 10}}</rasd:InstanceID>
+        <rasd:Parent>3</rasd:Parent>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+{{- end}}
+{{- range $i, $n := .Nics}}
+      <Item>
+        <rasd:AddressOnParent>{{add $i 7}}</rasd:AddressOnParent>
+        <rasd:AutomaticAllocation>true</rasd:AutomaticAllocation>
+        <rasd:Connection>{{xml $n.Network}}</rasd:Connection>
+        <rasd:ElementName>Network adapter {{add $i 1}}</rasd:ElementName>
+        <rasd:InstanceID>{{add $i 100}}</rasd:InstanceID>
+        <rasd:ResourceSubType>{{xml $n.Model}}</rasd:ResourceSubType>
+        <rasd:ResourceType>10</rasd:ResourceType>
+      </Item>
+{{- end}}
+{{- if .Uefi}}
+      <vmw:Config ovf:required="false" vmw:key="firmware" vmw:value="efi"/>
+{{- end}}
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>
+`))
+
+// Generate renders the descriptor as an OVF 1.0 envelope, disks are expected to be
+// streamOptimized vmdk files placed next to the descriptor
+func (desc *SOvfDescriptor) Generate() ([]byte, error) {
+	type sTemplateInput struct {
+		SOvfDescriptor
+		Networks []string
+		Uefi     bool
+	}
+	input := sTemplateInput{
+		SOvfDescriptor: *desc,
+		Uefi:           desc.Firmware == FIRMWARE_UEFI,
+	}
+	input.GuestOsId = desc.guestOsId()
+	if input.CpuCount <= 0 {
+		input.CpuCount = 1
+	}
+	if input.MemoryMb <= 0 {
+		input.MemoryMb = 1024
+	}
+	input.Disks = make([]SOvfDisk, len(desc.Disks))
+	for i := range desc.Disks {
+		input.Disks[i] = desc.Disks[i]
+		if len(input.Disks[i].Format) == 0 {
+			input.Disks[i].Format = VMDK_STREAM_OPTIMIZED
+		}
+	}
+	input.Nics = make([]SOvfNic, len(desc.Nics))
+	networks := make(map[string]bool)
+	for i, nic := range desc.Nics {
+		input.Nics[i] = SOvfNic{Network: nic.Network, Model: nicModel(nic.Model)}
+		if len(input.Nics[i].Network) == 0 {
+			input.Nics[i].Network = "VM Network"
+		}
+		if !networks[input.Nics[i].Network] {
+			networks[input.Nics[i].Network] = true
+			input.Networks = append(input.Networks, input.Nics[i].Network)
+		}
+	}
+	buf := bytes.Buffer{}
+	err := ovfTemplate.Execute(&buf, input)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute ovf template")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovfutils
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const vmwareOvf = `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope vmw:buildId="build-3634791" xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:cim="http://schemas.dmtf.org/wbem/wscim/1/common" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1" xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData" xmlns:vmw="http://www.vmware.com/schema/ovf" xmlns:vssd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_VirtualSystemSettingData" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <References>
+    <File ovf:href="win2016-disk1.vmdk" ovf:id="file1" ovf:size="4966432768"/>
+    <File ovf:href="win2016-disk2.vmdk" ovf:id="file2" ovf:size="68096"/>
+  </References>
+  <DiskSection>
+    <Info>Virtual disk information</Info>
+    <Disk ovf:capacity="40" ovf:capacityAllocationUnits="byte * 2^30" ovf:diskId="vmdisk1" ovf:fileRef="file1" ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized" ovf:populatedSize="10402922496"/>
+    <Disk ovf:capacity="10" ovf:capacityAllocationUnits="byte * 2^30" ovf:diskId="vmdisk2" ovf:fileRef="file2" ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized" ovf:populatedSize="0"/>
+  </DiskSection>
+  <NetworkSection>
+    <Info>The list of logical networks</Info>
+    <Network ovf:name="VM Network">
+      <Description>The VM Network network</Description>
+    </Network>
+  </NetworkSection>
+  <VirtualSystem ovf:id="win2016">
+    <Info>A virtual machine</Info>
+    <Name>win2016</Name>
+    <OperatingSystemSection ovf:id="112" vmw:osType="windows9Server64Guest">
+      <Info>The kind of installed guest operating system</Info>
+      <Description>Microsoft Windows Server 2016 (64-bit)</Description>
+    </OperatingSystemSection>
+    <VirtualHardwareSection>
+      <Info>Virtual hardware requirements</Info>
+      <Item>
+        <rasd:AllocationUnits>hertz * 10^6</rasd:AllocationUnits>
+        <rasd:InstanceID>1</rasd:InstanceID>
+        <rasd:ResourceType>3</rasd:ResourceType>
+        <rasd:VirtualQuantity>4</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AllocationUnits>byte * 2^20</rasd:AllocationUnits>
+        <rasd:InstanceID>2</rasd:InstanceID>
+        <rasd:ResourceType>4</rasd:ResourceType>
+        <rasd:VirtualQuantity>8192</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AddressOnParent>1</rasd:AddressOnParent>
+        <rasd:HostResource>ovf:/disk/vmdisk2</rasd:HostResource>
+        <rasd:InstanceID>9</rasd:InstanceID>
+        <rasd:Parent>3</rasd:Parent>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AddressOnParent>0</rasd:AddressOnParent>
+        <rasd:HostResource>ovf:/disk/vmdisk1</rasd:HostResource>
+        <rasd:InstanceID>8</rasd:InstanceID>
+        <rasd:Parent>3</rasd:Parent>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AddressOnParent>7</rasd:AddressOnParent>
+        <rasd:Connection>VM Network</rasd:Connection>
+        <rasd:InstanceID>10</rasd:InstanceID>
+        <rasd:ResourceSubType>E1000</rasd:ResourceSubType>
+        <rasd:ResourceType>10</rasd:ResourceType>
+      </Item>
+      <vmw:Config ovf:required="false" vmw:key="firmware" vmw:value="efi"/>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>`
+
+func TestParseOvf(t *testing.T) {
+	desc, err := ParseOvf([]byte(vmwareOvf))
+	if err != nil {
+		t.Fatalf("parse error %s", err)
+	}
+	want := &SOvfDescriptor{
+		Name:      "win2016",
+		OsType:    OS_TYPE_WINDOWS,
+		GuestOsId: "windows9Server64Guest",
+		CpuCount:  4,
+		MemoryMb:  8192,
+		Firmware:  FIRMWARE_UEFI,
+		Disks: []SOvfDisk{
+			{DiskId: "vmdisk2", FileHref: "win2016-disk2.vmdk", FileSize: 68096, CapacityBytes: 10 << 30, Format: VMDK_STREAM_OPTIMIZED},
+			{DiskId: "vmdisk1", FileHref: "win2016-disk1.vmdk", FileSize: 4966432768, CapacityBytes: 40 << 30, Format: VMDK_STREAM_OPTIMIZED},
+		},
+		Nics: []SOvfNic{{Network: "VM Network", Model: "E1000"}},
+	}
+	if !reflect.DeepEqual(desc, want) {
+		t.Errorf("got %#v, want %#v", desc, want)
+	}
+
+	_, err = ParseOvf([]byte(`<Envelope></Envelope>`))
+	if err == nil {
+		t.Errorf("ovf without disk should fail")
+	}
+}
+
+func TestGenerateOvf(t *testing.T) {
+	desc := &SOvfDescriptor{
+		Name:     "centos<7>",
+		OsType:   OS_TYPE_LINUX,
+		CpuCount: 2,
+		MemoryMb: 2048,
+		Firmware: FIRMWARE_BIOS,
+		Disks: []SOvfDisk{
+			{FileHref: "centos-disk1.vmdk", FileSize: 1024, CapacityBytes: 30 << 30},
+			{FileHref: "centos-disk2.vmdk", FileSize: 512, CapacityBytes: 100 << 30},
+		},
+		Nics: []SOvfNic{{Network: "vnet", Model: "virtio"}, {Network: "vnet"}},
+	}
+	content, err := desc.Generate()
+	if err != nil {
+		t.Fatalf("generate error %s", err)
+	}
+	got, err := ParseOvf(content)
+	if err != nil {
+		t.Fatalf("parse generated ovf error %s\n%s", err, content)
+	}
+	if got.Name != desc.Name || got.CpuCount != 2 || got.MemoryMb != 2048 || got.Firmware != FIRMWARE_BIOS {
+		t.Errorf("unexpected descriptor %#v", got)
+	}
+	if len(got.Disks) != 2 || got.Disks[0].FileHref != "centos-disk1.vmdk" || got.Disks[1].CapacityBytes != 100<<30 {
+		t.Errorf("unexpected disks %#v", got.Disks)
+	}
+	if len(got.Nics) != 2 || got.Nics[0].Model != "VmxNet3" {
+		t.Errorf("unexpected nics %#v", got.Nics)
+	}
+}
+
+func TestOva(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ova")
+	if err != nil {
+		t.Fatalf("tempdir %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	disk := filepath.Join(dir, "test-disk1.vmdk")
+	if err := ioutil.WriteFile(disk, []byte("vmdk content"), 0644); err != nil {
+		t.Fatalf("write disk %s", err)
+	}
+	buf := bytes.Buffer{}
+	if err := WriteOva(&buf, "test.ovf", []byte(vmwareOvf), []string{disk}); err != nil {
+		t.Fatalf("write ova %s", err)
+	}
+
+	out := filepath.Join(dir, "out")
+	os.Mkdir(out, 0755)
+	ovfPath, err := ExtractOva(&buf, out)
+	if err != nil {
+		t.Fatalf("extract ova %s", err)
+	}
+	if ovfPath != filepath.Join(out, "test.ovf") {
+		t.Errorf("unexpected ovf path %s", ovfPath)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(out, "test-disk1.vmdk"))
+	if err != nil || string(content) != "vmdk content" {
+		t.Errorf("unexpected disk content %q: %v", content, err)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsutils
+
+import (
+	"fmt"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+const (
+	DATAPATH_TYPE_NETDEV = "netdev"
+
+	IFACE_TYPE_DPDK                   = "dpdk"
+	IFACE_TYPE_DPDK_VHOST_USER_CLIENT = "dpdkvhostuserclient"
+)
+
+type SDpdkConfig struct {
+	SocketMem  string
+	LcoreMask  string
+	PmdCpuMask string
+}
+
+func vsctlGet(args ...string) string {
+	args = append([]string{"--if-exists", "get"}, args...)
+	output, err := procutils.NewCommand("ovs-vsctl", args...).Output()
+	if err != nil {
+		return ""
+	}
+	val := strings.Trim(strings.TrimSpace(string(output)), "\"")
+	if val == "[]" {
+		return ""
+	}
+	return val
+}
+
+func setupDpdkArgs(conf SDpdkConfig) []string {
+	args := []string{"--no-wait", "set", "Open_vSwitch", ".", "other_config:dpdk-init=true"}
+	if len(conf.SocketMem) > 0 {
+		args = append(args, fmt.Sprintf("other_config:dpdk-socket-mem=%q", conf.SocketMem))
+	}
+	if len(conf.LcoreMask) > 0 {
+		args = append(args, fmt.Sprintf("other_config:dpdk-lcore-mask=%s", conf.LcoreMask))
+	}
+	if len(conf.PmdCpuMask) > 0 {
+		args = append(args, fmt.Sprintf("other_config:pmd-cpu-mask=%s", conf.PmdCpuMask))
+	}
+	return args
+}
+
+// SetupDpdk write dpdk options to Open_vSwitch table,
+// ovs-vswitchd should be restarted if dpdk is not initialized yet
+func SetupDpdk(conf SDpdkConfig) error {
+	output, err := procutils.NewCommand("ovs-vsctl", setupDpdkArgs(conf)...).Output()
+	if err != nil {
+		return errors.Wrapf(err, "set dpdk config: %s", output)
+	}
+	return nil
+}
+
+func IsDpdkInitialized() bool {
+	return vsctlGet("Open_vSwitch", ".", "dpdk_initialized") == "true"
+}
+
+// GetDpdkVersion return dpdk version of ovs-vswitchd, empty if dpdk not initialized
+func GetDpdkVersion() string {
+	if !IsDpdkInitialized() {
+		return ""
+	}
+	return vsctlGet("Open_vSwitch", ".", "dpdk_version")
+}
+
+func GetInterfaceType(ifname string) string {
+	return vsctlGet("Interface", ifname, "type")
+}
+
+func addDpdkBridgeArgs(brname string) []string {
+	return []string{"--", "--may-exist", "add-br", brname,
+		"--", "set", "Bridge", brname, "datapath_type=" + DATAPATH_TYPE_NETDEV}
+}
+
+func AddDpdkBridge(brname string) error {
+	output, err := procutils.NewCommand("ovs-vsctl", addDpdkBridgeArgs(brname)...).Output()
+	if err != nil {
+		return errors.Wrapf(err, "add dpdk bridge %s: %s", brname, output)
+	}
+	return nil
+}
+
+func addDpdkPortArgs(brname, port, devargs string) []string {
+	return []string{"--", "--may-exist", "add-port", brname, port,
+		"--", "set", "Interface", port, "type=" + IFACE_TYPE_DPDK, "options:dpdk-devargs=" + devargs}
+}
+
+func AddDpdkPort(brname, port, devargs string) error {
+	output, err := procutils.NewCommand("ovs-vsctl", addDpdkPortArgs(brname, port, devargs)...).Output()
+	if err != nil {
+		return errors.Wrapf(err, "add dpdk port %s to %s: %s", port, brname, output)
+	}
+	return nil
+}
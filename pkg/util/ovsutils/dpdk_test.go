@@ -0,0 +1,78 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsutils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetupDpdkArgs(t *testing.T) {
+	cases := []struct {
+		name string
+		conf SDpdkConfig
+		want []string
+	}{
+		{
+			name: "init only",
+			conf: SDpdkConfig{},
+			want: []string{"--no-wait", "set", "Open_vSwitch", ".", "other_config:dpdk-init=true"},
+		},
+		{
+			name: "full config",
+			conf: SDpdkConfig{
+				SocketMem:  "1024,1024",
+				LcoreMask:  "0x1",
+				PmdCpuMask: "0x6",
+			},
+			want: []string{"--no-wait", "set", "Open_vSwitch", ".", "other_config:dpdk-init=true",
+				`other_config:dpdk-socket-mem="1024,1024"`,
+				"other_config:dpdk-lcore-mask=0x1",
+				"other_config:pmd-cpu-mask=0x6",
+			},
+		},
+		{
+			name: "pmd cpu mask only",
+			conf: SDpdkConfig{PmdCpuMask: "0xc"},
+			want: []string{"--no-wait", "set", "Open_vSwitch", ".", "other_config:dpdk-init=true",
+				"other_config:pmd-cpu-mask=0xc",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := setupDpdkArgs(c.conf)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("setupDpdkArgs() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestAddDpdkBridgeArgs(t *testing.T) {
+	want := []string{"--", "--may-exist", "add-br", "brdpdk",
+		"--", "set", "Bridge", "brdpdk", "datapath_type=netdev"}
+	if got := addDpdkBridgeArgs("brdpdk"); !reflect.DeepEqual(got, want) {
+		t.Errorf("addDpdkBridgeArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestAddDpdkPortArgs(t *testing.T) {
+	want := []string{"--", "--may-exist", "add-port", "brdpdk", "dpdk-eth0",
+		"--", "set", "Interface", "dpdk-eth0", "type=dpdk", "options:dpdk-devargs=0000:03:00.0"}
+	if got := addDpdkPortArgs("brdpdk", "dpdk-eth0", "0000:03:00.0"); !reflect.DeepEqual(got, want) {
+		t.Errorf("addDpdkPortArgs() = %v, want %v", got, want)
+	}
+}
@@ -83,7 +83,21 @@ func NewEncryptedQemuImageWithIOLevel(path string, password string, ioLevel TION
 	return &qemuImg, nil
 }
 
+// NewQemuImageWithFormat opens the image as the given format instead of letting qemu-img probe it
+func NewQemuImageWithFormat(path string, format TImageFormat) (*SQemuImage, error) {
+	qemuImg := SQemuImage{Path: path}
+	err := qemuImg.parseWithFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	return &qemuImg, nil
+}
+
 func (img *SQemuImage) parse() error {
+	return img.parseWithFormat("")
+}
+
+func (img *SQemuImage) parseWithFormat(format TImageFormat) error {
 	if len(img.Path) == 0 {
 		return fmt.Errorf("empty image path")
 	}
@@ -111,7 +125,12 @@ func (img *SQemuImage) parse() error {
 			img.ActualSizeBytes = fileInfo.Size()
 		}
 	}
-	cmd := procutils.NewRemoteCommandAsFarAsPossible(qemutils.GetQemuImg(), "info", img.Path)
+	args := []string{"info"}
+	if len(format) > 0 {
+		args = append(args, "-f", format.String())
+	}
+	args = append(args, img.Path)
+	cmd := procutils.NewRemoteCommandAsFarAsPossible(qemutils.GetQemuImg(), args...)
 
 	var stdin io.WriteCloser
 	var err error
@@ -0,0 +1,103 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfish
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+func (r *SBaseRedfishClient) getBios(ctx context.Context) (string, jsonutils.JSONObject, error) {
+	return r.GetResource(ctx, "Systems", "0", "Bios")
+}
+
+func (r *SBaseRedfishClient) GetBiosAttributes(ctx context.Context) (*jsonutils.JSONDict, error) {
+	_, bios, err := r.getBios(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetResource Systems 0 Bios")
+	}
+	attrs, err := bios.Get("Attributes")
+	if err != nil {
+		return nil, errors.Wrap(err, "find Attributes")
+	}
+	dict, ok := attrs.(*jsonutils.JSONDict)
+	if !ok {
+		return nil, errors.Errorf("invalid bios attributes %s", attrs)
+	}
+	return dict, nil
+}
+
+// GetBiosSettingsPath return path of pending settings object of Bios, which is applied on next reset
+func (r *SBaseRedfishClient) GetBiosSettingsPath(ctx context.Context) (string, error) {
+	biosPath, bios, err := r.getBios(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "GetResource Systems 0 Bios")
+	}
+	path, _ := bios.GetString("@Redfish.Settings", "SettingsObject", r.IRedfishDriver().LinkKey())
+	if len(path) == 0 {
+		path = httputils.JoinPath(biosPath, "Settings")
+	}
+	return path, nil
+}
+
+func (r *SBaseRedfishClient) SetBiosAttributes(ctx context.Context, attrs *jsonutils.JSONDict) error {
+	path, err := r.IRedfishDriver().GetBiosSettingsPath(ctx)
+	if err != nil {
+		return errors.Wrap(err, "GetBiosSettingsPath")
+	}
+	params := jsonutils.NewDict()
+	params.Add(attrs, "Attributes")
+	resp, err := r.Patch(ctx, path, params)
+	if err != nil {
+		return errors.Wrapf(err, "r.Patch %s", path)
+	}
+	if r.IsDebug && resp != nil {
+		log.Debugf("%s", resp.PrettyString())
+	}
+	return nil
+}
+
+func biosAttrValue(val jsonutils.JSONObject) string {
+	if str, err := val.GetString(); err == nil {
+		return str
+	}
+	return val.String()
+}
+
+// DiffBiosAttributes return attributes in desired whose values differ from current,
+// and names of attributes which are not supported by current bios
+func DiffBiosAttributes(current, desired *jsonutils.JSONDict) (*jsonutils.JSONDict, []string) {
+	diff := jsonutils.NewDict()
+	unknown := make([]string, 0)
+	for key, want := range desired.Value() {
+		cur, err := current.Get(key)
+		if err != nil {
+			unknown = append(unknown, key)
+			continue
+		}
+		if !strings.EqualFold(biosAttrValue(cur), biosAttrValue(want)) {
+			diff.Add(want, key)
+		}
+	}
+	sort.Strings(unknown)
+	return diff, unknown
+}
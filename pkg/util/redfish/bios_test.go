@@ -0,0 +1,35 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfish
+
+import (
+	"reflect"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+)
+
+func TestDiffBiosAttributes(t *testing.T) {
+	current, _ := jsonutils.ParseString(`{"ProcVirtualization":"Disabled","SriovGlobalEnable":"Enabled","BootMode":"Bios","ProcCStates":"Enabled","NumLock":1}`)
+	desired, _ := jsonutils.ParseString(`{"ProcVirtualization":"Enabled","SriovGlobalEnable":"enabled","BootMode":"Uefi","NumLock":"1","NoSuchAttr":"x"}`)
+	diff, unknown := DiffBiosAttributes(current.(*jsonutils.JSONDict), desired.(*jsonutils.JSONDict))
+	want, _ := jsonutils.ParseString(`{"ProcVirtualization":"Enabled","BootMode":"Uefi"}`)
+	if !diff.Equals(want) {
+		t.Errorf("diff want %s got %s", want, diff)
+	}
+	if !reflect.DeepEqual(unknown, []string{"NoSuchAttr"}) {
+		t.Errorf("unknown want [NoSuchAttr] got %v", unknown)
+	}
+}
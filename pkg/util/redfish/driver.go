@@ -16,6 +16,7 @@ package redfish
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"yunion.io/x/jsonutils"
@@ -72,6 +73,10 @@ type IRedfishDriver interface {
 	BmcReset(ctx context.Context) error
 
 	GetBiosInfo(ctx context.Context) (SBiosInfo, error)
+	GetBiosAttributes(ctx context.Context) (*jsonutils.JSONDict, error)
+	GetBiosSettingsPath(ctx context.Context) (string, error)
+	// SetBiosAttributes PATCH pending bios settings, which take effect after system reset
+	SetBiosAttributes(ctx context.Context, attrs *jsonutils.JSONDict) error
 
 	GetIndicatorLED(ctx context.Context) (bool, error)
 	SetIndicatorLED(ctx context.Context, on bool) error
@@ -85,6 +90,25 @@ type IRedfishDriver interface {
 	SetNTPConf(ctx context.Context, conf SNTPConf) error
 
 	GetConsoleJNLP(ctx context.Context) (string, error)
+
+	GetFirmwareInventory(ctx context.Context) ([]SFirmwareInfo, error)
+	// SimpleUpdateFirmware let BMC fetch firmware image from imageUri, return path of update task
+	SimpleUpdateFirmware(ctx context.Context, imageUri string, targets []string, applyOnReset bool) (string, error)
+	// PushFirmware upload firmware image to BMC by multipart http push, return path of update task
+	PushFirmware(ctx context.Context, filename string, image io.Reader, targets []string, applyOnReset bool) (string, error)
+	GetTask(ctx context.Context, path string) (STaskInfo, error)
+
+	GetStorageControllers(ctx context.Context) ([]SStorageController, error)
+	GetStorageDrives(ctx context.Context, ctrl SStorageController) ([]SStorageDrive, error)
+	GetStorageVolumes(ctx context.Context, ctrl SStorageController) ([]SStorageVolume, error)
+	// CreateStorageVolume build volume of raidType on drives, return path of task if creation is asynchronous
+	CreateStorageVolume(ctx context.Context, ctrl SStorageController, raidType string, drives []string) (string, error)
+	DeleteStorageVolume(ctx context.Context, path string) (string, error)
+
+	GetEventSubscriptions(ctx context.Context) ([]SEventSubscription, error)
+	// CreateEventSubscription subscribe alerts of BMC to destination, return path of the subscription
+	CreateEventSubscription(ctx context.Context, destination string, context string) (string, error)
+	DeleteEventSubscription(ctx context.Context, path string) error
 }
 
 var defaultFactory IRedfishDriverFactory
@@ -0,0 +1,122 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfish
+
+import (
+	"context"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+)
+
+func (r *SBaseRedfishClient) getSubscriptionsPath(ctx context.Context) (string, error) {
+	path, _, err := r.GetResource(ctx, "EventService", "Subscriptions")
+	if err != nil {
+		return "", errors.Wrap(err, "GetResource EventService Subscriptions")
+	}
+	return path, nil
+}
+
+func (r *SBaseRedfishClient) GetEventSubscriptions(ctx context.Context) ([]SEventSubscription, error) {
+	path, err := r.getSubscriptionsPath(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := r.getMembers(ctx, path)
+	if err != nil {
+		return nil, errors.Wrap(err, "get subscriptions")
+	}
+	ret := make([]SEventSubscription, 0)
+	for i := range subs {
+		sub := SEventSubscription{}
+		sub.Id, _ = subs[i].GetString("Id")
+		sub.Path, _ = subs[i].GetString(r.IRedfishDriver().LinkKey())
+		sub.Destination, _ = subs[i].GetString("Destination")
+		sub.Context, _ = subs[i].GetString("Context")
+		sub.Protocol, _ = subs[i].GetString("Protocol")
+		sub.EventTypes, _ = jsonutils.GetStringArray(subs[i], "EventTypes")
+		ret = append(ret, sub)
+	}
+	return ret, nil
+}
+
+func (r *SBaseRedfishClient) CreateEventSubscription(ctx context.Context, destination string, context string) (string, error) {
+	path, err := r.getSubscriptionsPath(ctx)
+	if err != nil {
+		return "", err
+	}
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(destination), "Destination")
+	params.Add(jsonutils.NewString(context), "Context")
+	params.Add(jsonutils.NewString("Redfish"), "Protocol")
+	params.Add(jsonutils.NewStringArray([]string{"Alert"}), "EventTypes")
+	hdr, resp, err := r.Post(ctx, path, params)
+	if err != nil {
+		return "", errors.Wrapf(err, "subscribe events to %s", destination)
+	}
+	var subPath string
+	if hdr != nil {
+		subPath = hdr.Get("Location")
+	}
+	if len(subPath) == 0 && resp != nil {
+		subPath, _ = resp.GetString(r.IRedfishDriver().LinkKey())
+	}
+	return subPath, nil
+}
+
+func (r *SBaseRedfishClient) DeleteEventSubscription(ctx context.Context, path string) error {
+	_, _, err := r.Delete(ctx, path)
+	if err != nil {
+		return errors.Wrapf(err, "delete subscription %s", path)
+	}
+	return nil
+}
+
+// ParseEvents normalize the Event resource pushed by EventService,
+// return Context of the subscription and the event records
+func ParseEvents(body jsonutils.JSONObject) (string, []SEvent, error) {
+	context, _ := body.GetString("Context")
+	records, err := body.GetArray("Events")
+	if err != nil {
+		return context, nil, errors.Wrap(err, "find Events")
+	}
+	events := make([]SEvent, 0)
+	for i := range records {
+		evt := SEvent{Type: EVENT_TYPE_ALERT}
+		evt.EventId, _ = records[i].GetString("EventId")
+		evt.Message, _ = records[i].GetString("Message")
+		evt.MessageId, _ = records[i].GetString("MessageId")
+		evt.Severity, _ = records[i].GetString("MessageSeverity")
+		if len(evt.Severity) == 0 {
+			// Severity is deprecated since Redfish 1.6, still sent by older BMCs
+			evt.Severity, _ = records[i].GetString("Severity")
+		}
+		evt.Origin, _ = records[i].GetString("OriginOfCondition", "@odata.id")
+		tmStr, _ := records[i].GetString("EventTimestamp")
+		if tm, err := time.Parse(time.RFC3339, tmStr); err == nil {
+			evt.Created = tm.UTC()
+		} else {
+			evt.Created = time.Now().UTC()
+		}
+		if len(context) == 0 {
+			// Context is inside event records before Redfish 1.1
+			context, _ = records[i].GetString("Context")
+		}
+		events = append(events, evt)
+	}
+	SortEvents(events)
+	return context, events, nil
+}
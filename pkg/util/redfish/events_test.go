@@ -0,0 +1,43 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfish
+
+import (
+	"testing"
+
+	"yunion.io/x/jsonutils"
+)
+
+func TestParseEvents(t *testing.T) {
+	body, _ := jsonutils.ParseString(`{"Context":"ctx1","Events":[
+		{"EventId":"2","EventTimestamp":"2020-01-02T10:00:00+08:00","MessageSeverity":"Critical","Message":"The power input for power supply 1 is lost.","MessageId":"IDRAC.2.1.PSU0003","OriginOfCondition":{"@odata.id":"/redfish/v1/Chassis/System.Embedded.1/Power"}},
+		{"EventId":"1","EventTimestamp":"2020-01-02T01:00:00Z","Severity":"Warning","Message":"Disk 0 is predicted to fail."}]}`)
+	context, events, err := ParseEvents(body)
+	if err != nil {
+		t.Fatalf("ParseEvents: %v", err)
+	}
+	if context != "ctx1" {
+		t.Errorf("context want ctx1 got %s", context)
+	}
+	if len(events) != 2 {
+		t.Fatalf("want 2 events got %d", len(events))
+	}
+	if events[0].EventId != "1" || events[0].Severity != EVENT_SEVERITY_WARNING {
+		t.Errorf("first event want 1 Warning got %s %s", events[0].EventId, events[0].Severity)
+	}
+	if events[1].Severity != EVENT_SEVERITY_CRITICAL || events[1].Origin != "/redfish/v1/Chassis/System.Embedded.1/Power" || events[1].Type != EVENT_TYPE_ALERT {
+		t.Errorf("second event parsed wrong: %#v", events[1])
+	}
+}
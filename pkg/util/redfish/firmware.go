@@ -0,0 +1,220 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfish
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/util/httputils"
+)
+
+const (
+	APPLY_TIME_ON_RESET = "OnReset"
+	APPLY_TIME_KEY      = "@Redfish.OperationApplyTime"
+)
+
+func (r *SBaseRedfishClient) GetUpdateService(ctx context.Context) (string, jsonutils.JSONObject, error) {
+	return r.GetResource(ctx, "UpdateService")
+}
+
+func (r *SBaseRedfishClient) GetFirmwareInventory(ctx context.Context) ([]SFirmwareInfo, error) {
+	_, resp, err := r.GetResource(ctx, "UpdateService", "FirmwareInventory")
+	if err != nil {
+		return nil, errors.Wrap(err, "GetResource UpdateService FirmwareInventory")
+	}
+	resp = r.IRedfishDriver().GetParent(resp)
+	members, err := resp.GetArray(r.IRedfishDriver().MemberKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "find member list")
+	}
+	ret := make([]SFirmwareInfo, 0)
+	for i := range members {
+		path, _ := members[i].GetString(r.IRedfishDriver().LinkKey())
+		if len(path) == 0 {
+			continue
+		}
+		fwResp, err := r.Get(ctx, path)
+		if err != nil {
+			log.Errorf("Get firmware %s fail %s", path, err)
+			continue
+		}
+		fw := SFirmwareInfo{}
+		err = fwResp.Unmarshal(&fw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unmarshal firmware %s", path)
+		}
+		ret = append(ret, fw)
+	}
+	return ret, nil
+}
+
+// ParseTaskPath find path of update task from Location header or task resource in response body
+func (r *SBaseRedfishClient) ParseTaskPath(hdr http.Header, resp jsonutils.JSONObject) string {
+	var path string
+	if hdr != nil {
+		path = hdr.Get("Location")
+	}
+	if len(path) == 0 && resp != nil {
+		odataType, _ := resp.GetString("@odata.type")
+		if strings.Contains(odataType, "Task") {
+			path, _ = resp.GetString(r.IRedfishDriver().LinkKey())
+		}
+	}
+	pos := strings.Index(path, r.IRedfishDriver().BasePath())
+	if pos > 0 {
+		path = path[pos:]
+	}
+	return path
+}
+
+func (r *SBaseRedfishClient) SimpleUpdateFirmware(ctx context.Context, imageUri string, targets []string, applyOnReset bool) (string, error) {
+	_, updSrv, err := r.GetUpdateService(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "GetUpdateService")
+	}
+	urlPath, _ := updSrv.GetString("Actions", "#UpdateService.SimpleUpdate", "target")
+	if len(urlPath) == 0 {
+		return "", errors.Wrap(httperrors.ErrNotSupported, "no #UpdateService.SimpleUpdate action")
+	}
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(imageUri), "ImageURI")
+	if u, err := url.Parse(imageUri); err == nil && len(u.Scheme) > 0 {
+		protocol := strings.ToUpper(u.Scheme)
+		protocols, _ := jsonutils.GetStringArray(updSrv, "Actions", "#UpdateService.SimpleUpdate", "TransferProtocol@Redfish.AllowableValues")
+		if len(protocols) > 0 && !utils.IsInStringArray(protocol, protocols) {
+			return "", errors.Wrapf(httperrors.ErrNotSupported, "transfer protocol %s not supported: %s", protocol, protocols)
+		}
+		params.Add(jsonutils.NewString(protocol), "TransferProtocol")
+	}
+	if len(targets) > 0 {
+		params.Add(jsonutils.NewStringArray(targets), "Targets")
+	}
+	if applyOnReset {
+		params.Add(jsonutils.NewString(APPLY_TIME_ON_RESET), APPLY_TIME_KEY)
+	}
+	hdr, resp, err := r.Post(ctx, urlPath, params)
+	if err != nil {
+		return "", errors.Wrap(err, "Actions/UpdateService.SimpleUpdate")
+	}
+	return r.ParseTaskPath(hdr, resp), nil
+}
+
+func (r *SBaseRedfishClient) PushFirmware(ctx context.Context, filename string, image io.Reader, targets []string, applyOnReset bool) (string, error) {
+	_, updSrv, err := r.GetUpdateService(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "GetUpdateService")
+	}
+	pushUri, _ := updSrv.GetString("MultipartHttpPushUri")
+	if len(pushUri) == 0 {
+		return "", errors.Wrap(httperrors.ErrNotSupported, "no MultipartHttpPushUri")
+	}
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewStringArray(targets), "Targets")
+	if applyOnReset {
+		params.Add(jsonutils.NewString(APPLY_TIME_ON_RESET), APPLY_TIME_KEY)
+	}
+	hdr, resp, err := r.MultipartPush(ctx, pushUri, params, filename, image)
+	if err != nil {
+		return "", errors.Wrap(err, "MultipartPush")
+	}
+	return r.ParseTaskPath(hdr, resp), nil
+}
+
+// MultipartPush stream image to BMC as multipart/form-data with UpdateParameters and UpdateFile parts
+func (r *SBaseRedfishClient) MultipartPush(ctx context.Context, path string, params jsonutils.JSONObject, filename string, image io.Reader) (http.Header, jsonutils.JSONObject, error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		err := func() error {
+			ph := textproto.MIMEHeader{}
+			ph.Set("Content-Disposition", `form-data; name="UpdateParameters"`)
+			ph.Set("Content-Type", "application/json")
+			part, err := mw.CreatePart(ph)
+			if err != nil {
+				return err
+			}
+			if _, err := part.Write([]byte(params.String())); err != nil {
+				return err
+			}
+			part, err = mw.CreateFormFile("UpdateFile", filename)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(part, image); err != nil {
+				return err
+			}
+			return mw.Close()
+		}()
+		pw.CloseWithError(err)
+	}()
+
+	header := http.Header{}
+	r.setRequestHeader(header)
+	header.Set("Content-Type", mw.FormDataContentType())
+	// upload of firmware image may take a long time
+	client := httputils.GetAdaptiveTimeoutClient()
+	urlStr := httputils.JoinPath(r.endpoint, path)
+	resp, err := httputils.Request(client, ctx, httputils.POST, urlStr, header, pr, r.IsDebug)
+	hdr, body, err := httputils.ParseJSONResponse("", resp, err, r.IsDebug)
+	if err != nil {
+		pr.Close()
+		return nil, nil, errors.Wrapf(err, "POST %s", path)
+	}
+	return hdr, body, nil
+}
+
+func (r *SBaseRedfishClient) GetTask(ctx context.Context, path string) (STaskInfo, error) {
+	task := STaskInfo{}
+	if len(path) == 0 {
+		return task, errors.Wrap(httperrors.ErrMissingParameter, "task path")
+	}
+	resp, err := r.Get(ctx, path)
+	if err != nil {
+		return task, errors.Wrapf(err, "Get %s", path)
+	}
+	if r.IsDebug {
+		log.Debugf("%s", resp.PrettyString())
+	}
+	return ParseTaskInfo(resp), nil
+}
+
+func ParseTaskInfo(resp jsonutils.JSONObject) STaskInfo {
+	task := STaskInfo{}
+	task.Id, _ = resp.GetString("Id")
+	task.TaskState, _ = resp.GetString("TaskState")
+	task.TaskStatus, _ = resp.GetString("TaskStatus")
+	pct, _ := resp.Int("PercentComplete")
+	task.PercentComplete = int(pct)
+	msgs, _ := resp.GetArray("Messages")
+	for i := range msgs {
+		msg, _ := msgs[i].GetString("Message")
+		if len(msg) > 0 {
+			task.Messages = append(task.Messages, msg)
+		}
+	}
+	return task
+}
@@ -0,0 +1,141 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/util/redfish"
+)
+
+type mockRedfishServer struct {
+	simpleUpdate jsonutils.JSONObject
+	pushParams   jsonutils.JSONObject
+	pushFile     string
+}
+
+func (m *mockRedfishServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	resources := map[string]string{
+		"/redfish/v1": `{"RedfishVersion":"1.6.0","UpdateService":{"@odata.id":"/redfish/v1/UpdateService"}}`,
+		"/redfish/v1/UpdateService": `{"MultipartHttpPushUri":"/redfish/v1/UpdateService/upload",` +
+			`"FirmwareInventory":{"@odata.id":"/redfish/v1/UpdateService/FirmwareInventory"},` +
+			`"Actions":{"#UpdateService.SimpleUpdate":{"target":"/redfish/v1/UpdateService/Actions/UpdateService.SimpleUpdate",` +
+			`"TransferProtocol@Redfish.AllowableValues":["HTTP","HTTPS"]}}}`,
+		"/redfish/v1/UpdateService/FirmwareInventory": `{"Members":[{"@odata.id":"/redfish/v1/UpdateService/FirmwareInventory/BIOS"},` +
+			`{"@odata.id":"/redfish/v1/UpdateService/FirmwareInventory/BMC"}]}`,
+		"/redfish/v1/UpdateService/FirmwareInventory/BIOS": `{"Id":"BIOS","Name":"BIOS","Version":"2.1.0","Updateable":true}`,
+		"/redfish/v1/UpdateService/FirmwareInventory/BMC":  `{"Id":"BMC","Name":"BMC","Version":"1.0.3","Updateable":false}`,
+		"/redfish/v1/TaskService/Tasks/1": `{"@odata.type":"#Task.v1_4_0.Task","Id":"1","TaskState":"Pending","TaskStatus":"OK",` +
+			`"PercentComplete":100,"Messages":[{"Message":"staged, apply on reset"}]}`,
+	}
+	switch {
+	case req.Method == "GET":
+		body, ok := resources[strings.TrimSuffix(req.URL.Path, "/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	case req.URL.Path == "/redfish/v1/UpdateService/Actions/UpdateService.SimpleUpdate":
+		data, _ := ioutil.ReadAll(req.Body)
+		m.simpleUpdate, _ = jsonutils.Parse(data)
+		w.Header().Set("Location", "https://"+req.Host+"/redfish/v1/TaskService/Tasks/1")
+		w.WriteHeader(http.StatusAccepted)
+	case req.URL.Path == "/redfish/v1/UpdateService/upload":
+		params := req.FormValue("UpdateParameters")
+		m.pushParams, _ = jsonutils.ParseString(params)
+		file, hdr, err := req.FormFile("UpdateFile")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := ioutil.ReadAll(file)
+		m.pushFile = hdr.Filename + ":" + string(data)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"@odata.type":"#Task.v1_4_0.Task","@odata.id":"/redfish/v1/TaskService/Tasks/1"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestFirmwareUpdate(t *testing.T) {
+	mock := &mockRedfishServer{}
+	srv := httptest.NewTLSServer(mock)
+	defer srv.Close()
+
+	ctx := context.Background()
+	drv := NewGenericRedfishApi(srv.URL, "root", "password", false)
+	if err := drv.Probe(ctx); err != nil {
+		t.Fatalf("Probe: %s", err)
+	}
+
+	fws, err := drv.GetFirmwareInventory(ctx)
+	if err != nil {
+		t.Fatalf("GetFirmwareInventory: %s", err)
+	}
+	if len(fws) != 2 || fws[0].Version != "2.1.0" || !fws[0].Updateable || fws[1].Updateable {
+		t.Errorf("unexpected firmware inventory %#v", fws)
+	}
+
+	taskPath, err := drv.SimpleUpdateFirmware(ctx, "http://192.168.1.1/bios.bin", []string{"/redfish/v1/UpdateService/FirmwareInventory/BIOS"}, true)
+	if err != nil {
+		t.Fatalf("SimpleUpdateFirmware: %s", err)
+	}
+	if taskPath != "/redfish/v1/TaskService/Tasks/1" {
+		t.Errorf("unexpected task path %s", taskPath)
+	}
+	if proto, _ := mock.simpleUpdate.GetString("TransferProtocol"); proto != "HTTP" {
+		t.Errorf("unexpected transfer protocol %s", proto)
+	}
+	if applyTime, _ := mock.simpleUpdate.GetString(redfish.APPLY_TIME_KEY); applyTime != redfish.APPLY_TIME_ON_RESET {
+		t.Errorf("unexpected apply time %s", applyTime)
+	}
+
+	_, err = drv.SimpleUpdateFirmware(ctx, "ftp://192.168.1.1/bios.bin", nil, false)
+	if err == nil {
+		t.Errorf("ftp transfer protocol should not be allowed")
+	}
+
+	taskPath, err = drv.PushFirmware(ctx, "bmc.bin", strings.NewReader("firmware"), []string{"/redfish/v1/UpdateService/FirmwareInventory/BMC"}, false)
+	if err != nil {
+		t.Fatalf("PushFirmware: %s", err)
+	}
+	if taskPath != "/redfish/v1/TaskService/Tasks/1" {
+		t.Errorf("unexpected task path %s", taskPath)
+	}
+	if mock.pushFile != "bmc.bin:firmware" {
+		t.Errorf("unexpected pushed file %s", mock.pushFile)
+	}
+	if targets, _ := jsonutils.GetStringArray(mock.pushParams, "Targets"); len(targets) != 1 {
+		t.Errorf("unexpected push parameters %s", mock.pushParams)
+	}
+
+	task, err := drv.GetTask(ctx, taskPath)
+	if err != nil {
+		t.Fatalf("GetTask: %s", err)
+	}
+	if !task.IsPending() || task.IsFinished() || len(task.Messages) != 1 {
+		t.Errorf("unexpected task %#v", task)
+	}
+}
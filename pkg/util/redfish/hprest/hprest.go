@@ -17,6 +17,7 @@ package hprest
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -290,3 +291,69 @@ func (r *SHpRestApi) ClearSystemLogs(ctx context.Context) error {
 func (r *SHpRestApi) ClearManagerLogs(ctx context.Context) error {
 	return r.clearLogs(ctx, "Managers")
 }
+
+func (r *SHpRestApi) GetFirmwareInventory(ctx context.Context) ([]redfish.SFirmwareInfo, error) {
+	_, resp, err := r.GetResource(ctx, "Systems", "0", "FirmwareInventory")
+	if err != nil {
+		return nil, errors.Wrap(err, "GetResource Systems 0 FirmwareInventory")
+	}
+	current, err := resp.GetMap("Current")
+	if err != nil {
+		return nil, errors.Wrap(err, "find Current")
+	}
+	ret := make([]redfish.SFirmwareInfo, 0)
+	for key, val := range current {
+		items, _ := val.GetArray()
+		for i := range items {
+			fw := redfish.SFirmwareInfo{}
+			fw.Id = key
+			if len(items) > 1 {
+				fw.Id = fmt.Sprintf("%s-%d", key, i)
+			}
+			fw.Name, _ = items[i].GetString("Name")
+			fw.Version, _ = items[i].GetString("VersionString")
+			ret = append(ret, fw)
+		}
+	}
+	return ret, nil
+}
+
+func (r *SHpRestApi) SimpleUpdateFirmware(ctx context.Context, imageUri string, targets []string, applyOnReset bool) (string, error) {
+	return "", errors.Wrap(httperrors.ErrNotSupported, "firmware update via HP REST API")
+}
+
+func (r *SHpRestApi) PushFirmware(ctx context.Context, filename string, image io.Reader, targets []string, applyOnReset bool) (string, error) {
+	return "", errors.Wrap(httperrors.ErrNotSupported, "firmware update via HP REST API")
+}
+
+func (r *SHpRestApi) GetBiosAttributes(ctx context.Context) (*jsonutils.JSONDict, error) {
+	return nil, errors.Wrap(httperrors.ErrNotSupported, "bios attributes via HP REST API")
+}
+
+func (r *SHpRestApi) SetBiosAttributes(ctx context.Context, attrs *jsonutils.JSONDict) error {
+	return errors.Wrap(httperrors.ErrNotSupported, "bios attributes via HP REST API")
+}
+
+func (r *SHpRestApi) GetStorageControllers(ctx context.Context) ([]redfish.SStorageController, error) {
+	return nil, errors.Wrap(httperrors.ErrNotSupported, "storage via HP REST API")
+}
+
+func (r *SHpRestApi) CreateStorageVolume(ctx context.Context, ctrl redfish.SStorageController, raidType string, drives []string) (string, error) {
+	return "", errors.Wrap(httperrors.ErrNotSupported, "storage via HP REST API")
+}
+
+func (r *SHpRestApi) DeleteStorageVolume(ctx context.Context, path string) (string, error) {
+	return "", errors.Wrap(httperrors.ErrNotSupported, "storage via HP REST API")
+}
+
+func (r *SHpRestApi) GetEventSubscriptions(ctx context.Context) ([]redfish.SEventSubscription, error) {
+	return nil, errors.Wrap(httperrors.ErrNotSupported, "event subscriptions via HP REST API")
+}
+
+func (r *SHpRestApi) CreateEventSubscription(ctx context.Context, destination string, context string) (string, error) {
+	return "", errors.Wrap(httperrors.ErrNotSupported, "event subscriptions via HP REST API")
+}
+
+func (r *SHpRestApi) DeleteEventSubscription(ctx context.Context, path string) error {
+	return errors.Wrap(httperrors.ErrNotSupported, "event subscriptions via HP REST API")
+}
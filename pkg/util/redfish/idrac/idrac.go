@@ -383,3 +383,99 @@ func (r *SIDracRefishApi) GetPowerPath() string {
 func (r *SIDracRefishApi) GetThermalPath() string {
 	return "/redfish/v1/Chassis/System.Embedded.1/Thermal"
 }
+
+func (r *SIDracRefishApi) GetFirmwareInventory(ctx context.Context) ([]redfish.SFirmwareInfo, error) {
+	fws, err := r.SGenericRefishApi.GetFirmwareInventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// iDRAC also lists rollback and staged images, e.g. Previous-xxx, Available-xxx
+	ret := make([]redfish.SFirmwareInfo, 0)
+	for i := range fws {
+		if strings.HasPrefix(fws[i].Id, "Previous-") || strings.HasPrefix(fws[i].Id, "Available-") {
+			continue
+		}
+		ret = append(ret, fws[i])
+	}
+	return ret, nil
+}
+
+func (r *SIDracRefishApi) GetTask(ctx context.Context, path string) (redfish.STaskInfo, error) {
+	task := redfish.STaskInfo{}
+	if len(path) == 0 {
+		return task, errors.Wrap(httperrors.ErrMissingParameter, "task path")
+	}
+	resp, err := r.Get(ctx, path)
+	if err != nil {
+		return task, errors.Wrapf(err, "Get %s", path)
+	}
+	task = redfish.ParseTaskInfo(resp)
+	// update job is reported as Running by TaskService until reboot, the real state is in Dell job
+	jobState, _ := resp.GetString("Oem", "Dell", "JobState")
+	switch jobState {
+	case "Completed":
+		task.TaskState = redfish.TASK_STATE_COMPLETED
+	case "Scheduled", "Downloaded":
+		task.TaskState = redfish.TASK_STATE_PENDING
+	case "Failed", "CompletedWithErrors":
+		task.TaskState = redfish.TASK_STATE_EXCEPTION
+		task.TaskStatus = redfish.TASK_STATUS_CRITICAL
+	}
+	if msg, _ := resp.GetString("Oem", "Dell", "Message"); len(msg) > 0 {
+		task.Messages = append(task.Messages, msg)
+	}
+	return task, nil
+}
+
+// SetBiosAttributes iDRAC only apply pending bios settings by a scheduled config job
+func (r *SIDracRefishApi) SetBiosAttributes(ctx context.Context, attrs *jsonutils.JSONDict) error {
+	err := r.SGenericRefishApi.SetBiosAttributes(ctx, attrs)
+	if err != nil {
+		return err
+	}
+	settingsPath, err := r.GetBiosSettingsPath(ctx)
+	if err != nil {
+		return errors.Wrap(err, "GetBiosSettingsPath")
+	}
+	managerPath, _, err := r.GetResource(ctx, "Managers", "0")
+	if err != nil {
+		return errors.Wrap(err, "GetResource Managers 0")
+	}
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(settingsPath), "TargetSettingsURI")
+	_, _, err = r.Post(ctx, httputils.JoinPath(managerPath, "Jobs"), params)
+	if err != nil {
+		return errors.Wrap(err, "create bios config job")
+	}
+	return nil
+}
+
+var idracVolumeTypes = map[string]string{
+	redfish.RAID_TYPE_RAID0:  "NonRedundant",
+	redfish.RAID_TYPE_RAID1:  "Mirrored",
+	redfish.RAID_TYPE_RAID5:  "StripedWithParity",
+	redfish.RAID_TYPE_RAID10: "SpannedMirrors",
+}
+
+// CreateStorageVolume iDRAC only accept deprecated VolumeType, and create volume by a real time config job
+func (r *SIDracRefishApi) CreateStorageVolume(ctx context.Context, ctrl redfish.SStorageController, raidType string, drives []string) (string, error) {
+	volumeType, ok := idracVolumeTypes[raidType]
+	if !ok {
+		return "", errors.Wrapf(httperrors.ErrNotSupported, "raid type %s", raidType)
+	}
+	links := jsonutils.NewArray()
+	for _, drive := range drives {
+		link := jsonutils.NewDict()
+		link.Add(jsonutils.NewString(drive), r.LinkKey())
+		links.Add(link)
+	}
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(volumeType), "VolumeType")
+	params.Add(links, "Drives")
+	params.Add(jsonutils.NewString("Immediate"), redfish.APPLY_TIME_KEY)
+	hdr, resp, err := r.Post(ctx, ctrl.VolumesPath, params)
+	if err != nil {
+		return "", errors.Wrapf(err, "create %s volume on %s", volumeType, ctrl.Id)
+	}
+	return r.ParseTaskPath(hdr, resp), nil
+}
@@ -307,3 +307,54 @@ func (r *SILORefishApi) GetPowerPath() string {
 func (r *SILORefishApi) GetThermalPath() string {
 	return "/redfish/v1/Chassis/1/Thermal/"
 }
+
+// SimpleUpdateFirmware iLO only accept ImageURI, flash progress is reported in Oem of UpdateService
+func (r *SILORefishApi) SimpleUpdateFirmware(ctx context.Context, imageUri string, targets []string, applyOnReset bool) (string, error) {
+	_, updSrv, err := r.GetUpdateService(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "GetUpdateService")
+	}
+	urlPath, err := updSrv.GetString("Actions", "#UpdateService.SimpleUpdate", "target")
+	if err != nil {
+		return "", errors.Wrap(err, "Actions.#UpdateService.SimpleUpdate.target")
+	}
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(imageUri), "ImageURI")
+	hdr, resp, err := r.Post(ctx, urlPath, params)
+	if err != nil {
+		return "", errors.Wrap(err, "Actions/UpdateService.SimpleUpdate")
+	}
+	return r.ParseTaskPath(hdr, resp), nil
+}
+
+func (r *SILORefishApi) GetTask(ctx context.Context, path string) (redfish.STaskInfo, error) {
+	if len(path) > 0 {
+		return r.SGenericRefishApi.GetTask(ctx, path)
+	}
+	task := redfish.STaskInfo{}
+	_, updSrv, err := r.GetUpdateService(ctx)
+	if err != nil {
+		return task, errors.Wrap(err, "GetUpdateService")
+	}
+	oemKey := "Hpe"
+	if !updSrv.Contains("Oem", oemKey) {
+		oemKey = "Hp"
+	}
+	state, _ := updSrv.GetString("Oem", oemKey, "State")
+	pct, _ := updSrv.Int("Oem", oemKey, "FlashProgressPercent")
+	task.PercentComplete = int(pct)
+	switch state {
+	case "Idle", "Complete":
+		task.TaskState = redfish.TASK_STATE_COMPLETED
+		task.TaskStatus = redfish.TASK_STATUS_OK
+	case "Error":
+		task.TaskState = redfish.TASK_STATE_EXCEPTION
+		task.TaskStatus = redfish.TASK_STATUS_CRITICAL
+		if msg, _ := updSrv.GetString("Oem", oemKey, "Result", "MessageId"); len(msg) > 0 {
+			task.Messages = append(task.Messages, msg)
+		}
+	default:
+		task.TaskState = redfish.TASK_STATE_RUNNING
+	}
+	return task, nil
+}
@@ -97,11 +97,7 @@ func (r *SBaseRedfishClient) GetEndpoint() string {
 	return r.endpoint
 }
 
-func (r *SBaseRedfishClient) request(ctx context.Context, method httputils.THttpMethod, path string, header http.Header, body jsonutils.JSONObject) (http.Header, jsonutils.JSONObject, error) {
-	urlStr := httputils.JoinPath(r.endpoint, path)
-	if header == nil {
-		header = http.Header{}
-	}
+func (r *SBaseRedfishClient) setRequestHeader(header http.Header) {
 	if len(r.SessionToken) > 0 {
 		header.Set("X-Auth-Token", r.SessionToken)
 	} else {
@@ -111,6 +107,14 @@ func (r *SBaseRedfishClient) request(ctx context.Context, method httputils.THttp
 	// !!!always close http connection for redfish API server
 	header.Set("Connection", "Close")
 	header.Set("Odata-Version", "4.0")
+}
+
+func (r *SBaseRedfishClient) request(ctx context.Context, method httputils.THttpMethod, path string, header http.Header, body jsonutils.JSONObject) (http.Header, jsonutils.JSONObject, error) {
+	urlStr := httputils.JoinPath(r.endpoint, path)
+	if header == nil {
+		header = http.Header{}
+	}
+	r.setRequestHeader(header)
 	hdr, resp, err := httputils.JSONRequest(r.client, ctx, method, urlStr, header, body, r.IsDebug)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "httputils.JSONRequest")
@@ -406,6 +410,11 @@ func (r *SBaseRedfishClient) GetSystemInfo(ctx context.Context) (string, SSystem
 	sysInfo.NodeCount = int(nodeCount)
 	sysInfo.CpuDesc = strings.TrimSpace(cpuDesc)
 
+	sysInfo.Health, _ = resp.GetString("Status", "HealthRollup")
+	if len(sysInfo.Health) == 0 {
+		sysInfo.Health, _ = resp.GetString("Status", "Health")
+	}
+
 	nextBootDev, _ := resp.GetString("Boot", "BootSourceOverrideTarget")
 	sysInfo.NextBootDev = strings.TrimSpace(nextBootDev)
 	nextBootDevSupports, _ := resp.GetArray("Boot", "BootSourceOverrideTarget@Redfish.AllowableValues")
@@ -41,6 +41,8 @@ type SSystemInfo struct {
 	CpuDesc      string   `json:"CpuDesc"`
 	PowerState   string   `json:"PowerState"`
 	NextBootDev  string   `json:"NextBootDev"`
+	// Health is HealthRollup of system status, e.g. OK, Warning, Critical
+	Health string `json:"Health"`
 
 	NextBootDevSupported []string `json:"NextBootDevSupported"`
 	ResetTypeSupported   []string `json:"ResetTypeSupported"`
@@ -49,6 +51,12 @@ type SSystemInfo struct {
 const (
 	EVENT_TYPE_SYSTEM  = "system"
 	EVENT_TYPE_MANAGER = "manager"
+	// alerts pushed by EventService subscriptions
+	EVENT_TYPE_ALERT = "alert"
+
+	EVENT_SEVERITY_OK       = "OK"
+	EVENT_SEVERITY_WARNING  = "Warning"
+	EVENT_SEVERITY_CRITICAL = "Critical"
 )
 
 type SEvent struct {
@@ -57,6 +65,20 @@ type SEvent struct {
 	Message  string    `json:"Message"`
 	Severity string    `json:"Severity"`
 	Type     string    `json:"type"`
+
+	MessageId string `json:"MessageId"`
+	// Origin is path of resource that raises the event
+	Origin string `json:"Origin"`
+}
+
+// SEventSubscription is an EventDestination of Redfish EventService
+type SEventSubscription struct {
+	Id          string
+	Path        string
+	Destination string
+	Context     string
+	Protocol    string
+	EventTypes  []string
 }
 
 type SEventList []SEvent
@@ -130,3 +152,90 @@ type SNTPConf struct {
 	ProtocolEnabled bool     `json:"ProtocolEnabled,allowfalse"`
 	TimeZone        string   `json:"TimeZone"`
 }
+
+type SFirmwareInfo struct {
+	Id         string `json:"Id"`
+	Name       string `json:"Name"`
+	Version    string `json:"Version"`
+	SoftwareId string `json:"SoftwareId"`
+	Updateable bool   `json:"Updateable"`
+}
+
+const (
+	RAID_TYPE_RAID0  = "RAID0"
+	RAID_TYPE_RAID1  = "RAID1"
+	RAID_TYPE_RAID5  = "RAID5"
+	RAID_TYPE_RAID10 = "RAID10"
+)
+
+type SStorageController struct {
+	Id                 string   `json:"Id"`
+	Name               string   `json:"Name"`
+	Model              string   `json:"Model"`
+	Path               string   `json:"Path"`
+	VolumesPath        string   `json:"VolumesPath"`
+	Drives             []string `json:"Drives"`
+	SupportedRAIDTypes []string `json:"SupportedRAIDTypes"`
+}
+
+type SStorageDrive struct {
+	Id            string `json:"Id"`
+	Name          string `json:"Name"`
+	Path          string `json:"Path"`
+	Model         string `json:"Model"`
+	SerialNumber  string `json:"SerialNumber"`
+	MediaType     string `json:"MediaType"`
+	Protocol      string `json:"Protocol"`
+	CapacityBytes int64  `json:"CapacityBytes"`
+	Health        string `json:"Health"`
+}
+
+type SStorageVolume struct {
+	Id            string   `json:"Id"`
+	Name          string   `json:"Name"`
+	Path          string   `json:"Path"`
+	RAIDType      string   `json:"RAIDType"`
+	CapacityBytes int64    `json:"CapacityBytes"`
+	Drives        []string `json:"Drives"`
+}
+
+const (
+	TASK_STATE_NEW         = "New"
+	TASK_STATE_STARTING    = "Starting"
+	TASK_STATE_RUNNING     = "Running"
+	TASK_STATE_PENDING     = "Pending"
+	TASK_STATE_COMPLETED   = "Completed"
+	TASK_STATE_EXCEPTION   = "Exception"
+	TASK_STATE_KILLED      = "Killed"
+	TASK_STATE_CANCELLED   = "Cancelled"
+	TASK_STATE_INTERRUPTED = "Interrupted"
+
+	TASK_STATUS_OK       = "OK"
+	TASK_STATUS_WARNING  = "Warning"
+	TASK_STATUS_CRITICAL = "Critical"
+)
+
+type STaskInfo struct {
+	Id              string   `json:"Id"`
+	TaskState       string   `json:"TaskState"`
+	TaskStatus      string   `json:"TaskStatus"`
+	PercentComplete int      `json:"PercentComplete"`
+	Messages        []string `json:"Messages"`
+}
+
+func (t STaskInfo) IsFinished() bool {
+	switch t.TaskState {
+	case TASK_STATE_COMPLETED, TASK_STATE_EXCEPTION, TASK_STATE_KILLED, TASK_STATE_CANCELLED, TASK_STATE_INTERRUPTED:
+		return true
+	}
+	return false
+}
+
+func (t STaskInfo) IsSucceeded() bool {
+	return t.TaskState == TASK_STATE_COMPLETED && t.TaskStatus != TASK_STATUS_CRITICAL
+}
+
+// IsPending means the image is staged and waiting for system reset to apply
+func (t STaskInfo) IsPending() bool {
+	return t.TaskState == TASK_STATE_PENDING
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfish
+
+import (
+	"context"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+)
+
+// getMembers fetch every member resource of collection at path
+func (r *SBaseRedfishClient) getMembers(ctx context.Context, path string) ([]jsonutils.JSONObject, error) {
+	resp, err := r.Get(ctx, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Get %s", path)
+	}
+	members, err := resp.GetArray(r.IRedfishDriver().MemberKey())
+	if err != nil {
+		return nil, errors.Wrapf(err, "find members of %s", path)
+	}
+	ret := make([]jsonutils.JSONObject, 0)
+	for i := range members {
+		memberPath, _ := members[i].GetString(r.IRedfishDriver().LinkKey())
+		if len(memberPath) == 0 {
+			continue
+		}
+		member, err := r.Get(ctx, memberPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Get %s", memberPath)
+		}
+		ret = append(ret, member)
+	}
+	return ret, nil
+}
+
+func (r *SBaseRedfishClient) getLinks(resp jsonutils.JSONObject, keys ...string) []string {
+	links, _ := resp.GetArray(keys...)
+	ret := make([]string, 0)
+	for i := range links {
+		path, _ := links[i].GetString(r.IRedfishDriver().LinkKey())
+		if len(path) > 0 {
+			ret = append(ret, path)
+		}
+	}
+	return ret
+}
+
+func (r *SBaseRedfishClient) GetStorageControllers(ctx context.Context) ([]SStorageController, error) {
+	path, _, err := r.GetResource(ctx, "Systems", "0", "Storage")
+	if err != nil {
+		return nil, errors.Wrap(err, "GetResource Systems 0 Storage")
+	}
+	storages, err := r.getMembers(ctx, path)
+	if err != nil {
+		return nil, errors.Wrap(err, "get storages")
+	}
+	ret := make([]SStorageController, 0)
+	for i := range storages {
+		ctrl := SStorageController{}
+		ctrl.Id, _ = storages[i].GetString("Id")
+		ctrl.Name, _ = storages[i].GetString("Name")
+		ctrl.Path, _ = storages[i].GetString(r.IRedfishDriver().LinkKey())
+		ctrl.VolumesPath, _ = storages[i].GetString("Volumes", r.IRedfishDriver().LinkKey())
+		ctrl.Drives = r.getLinks(storages[i], "Drives")
+		ctrls, _ := storages[i].GetArray("StorageControllers")
+		if len(ctrls) > 0 {
+			ctrl.Model, _ = ctrls[0].GetString("Model")
+			ctrl.SupportedRAIDTypes, _ = jsonutils.GetStringArray(ctrls[0], "SupportedRAIDTypes")
+		}
+		if len(ctrl.Drives) == 0 {
+			// controllers without attached drives, e.g. BOSS or software RAID placeholders
+			log.Debugf("skip storage %s without drives", ctrl.Id)
+			continue
+		}
+		ret = append(ret, ctrl)
+	}
+	return ret, nil
+}
+
+func (r *SBaseRedfishClient) GetStorageDrives(ctx context.Context, ctrl SStorageController) ([]SStorageDrive, error) {
+	ret := make([]SStorageDrive, 0)
+	for _, path := range ctrl.Drives {
+		resp, err := r.Get(ctx, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Get %s", path)
+		}
+		drive := SStorageDrive{Path: path}
+		drive.Id, _ = resp.GetString("Id")
+		drive.Name, _ = resp.GetString("Name")
+		drive.Model, _ = resp.GetString("Model")
+		drive.SerialNumber, _ = resp.GetString("SerialNumber")
+		drive.MediaType, _ = resp.GetString("MediaType")
+		drive.Protocol, _ = resp.GetString("Protocol")
+		drive.CapacityBytes, _ = resp.Int("CapacityBytes")
+		drive.Health, _ = resp.GetString("Status", "Health")
+		ret = append(ret, drive)
+	}
+	return ret, nil
+}
+
+func (r *SBaseRedfishClient) GetStorageVolumes(ctx context.Context, ctrl SStorageController) ([]SStorageVolume, error) {
+	if len(ctrl.VolumesPath) == 0 {
+		return nil, errors.Errorf("storage %s has no volumes collection", ctrl.Id)
+	}
+	volumes, err := r.getMembers(ctx, ctrl.VolumesPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "get volumes")
+	}
+	ret := make([]SStorageVolume, 0)
+	for i := range volumes {
+		vol := SStorageVolume{}
+		vol.Id, _ = volumes[i].GetString("Id")
+		vol.Name, _ = volumes[i].GetString("Name")
+		vol.Path, _ = volumes[i].GetString(r.IRedfishDriver().LinkKey())
+		vol.RAIDType, _ = volumes[i].GetString("RAIDType")
+		vol.CapacityBytes, _ = volumes[i].Int("CapacityBytes")
+		vol.Drives = r.getLinks(volumes[i], "Links", "Drives")
+		if len(vol.Drives) == 0 {
+			// drives of non-RAID passthrough disks are not volumes
+			continue
+		}
+		ret = append(ret, vol)
+	}
+	return ret, nil
+}
+
+func (r *SBaseRedfishClient) CreateStorageVolume(ctx context.Context, ctrl SStorageController, raidType string, drives []string) (string, error) {
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewString(raidType), "RAIDType")
+	params.Add(r.driveLinks(drives), "Links", "Drives")
+	hdr, resp, err := r.Post(ctx, ctrl.VolumesPath, params)
+	if err != nil {
+		return "", errors.Wrapf(err, "create %s volume on %s", raidType, ctrl.Id)
+	}
+	return storageTaskPath(r.ParseTaskPath(hdr, resp)), nil
+}
+
+// storageTaskPath drop Location of created volume, which is not a task
+func storageTaskPath(path string) string {
+	if strings.Contains(path, "/Task") || strings.Contains(path, "/Jobs/") {
+		return path
+	}
+	return ""
+}
+
+func (r *SBaseRedfishClient) driveLinks(drives []string) *jsonutils.JSONArray {
+	links := jsonutils.NewArray()
+	for _, drive := range drives {
+		link := jsonutils.NewDict()
+		link.Add(jsonutils.NewString(drive), r.IRedfishDriver().LinkKey())
+		links.Add(link)
+	}
+	return links
+}
+
+func (r *SBaseRedfishClient) DeleteStorageVolume(ctx context.Context, path string) (string, error) {
+	hdr, resp, err := r.Delete(ctx, path)
+	if err != nil {
+		return "", errors.Wrapf(err, "delete volume %s", path)
+	}
+	return storageTaskPath(r.ParseTaskPath(hdr, resp)), nil
+}
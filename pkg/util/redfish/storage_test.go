@@ -0,0 +1,33 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redfish
+
+import (
+	"testing"
+)
+
+func TestStorageTaskPath(t *testing.T) {
+	cases := map[string]string{
+		"":                                 "",
+		"/redfish/v1/TaskService/Tasks/12": "/redfish/v1/TaskService/Tasks/12",
+		"/redfish/v1/Managers/iDRAC.Embedded.1/Jobs/JID_1": "/redfish/v1/Managers/iDRAC.Embedded.1/Jobs/JID_1",
+		"/redfish/v1/Systems/1/Storage/RAID.1/Volumes/2":   "",
+	}
+	for path, want := range cases {
+		if got := storageTaskPath(path); got != want {
+			t.Errorf("storageTaskPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -16,6 +16,7 @@ package supermicro
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"yunion.io/x/jsonutils"
@@ -250,3 +251,39 @@ func (r *SSupermicroRefishApi) SetNTPConf(ctx context.Context, conf redfish.SNTP
 	}
 	return nil
 }
+
+// startUpdate Supermicro BMC only verify uploaded image, flash must be triggered by UpdateService.StartUpdate
+func (r *SSupermicroRefishApi) startUpdate(ctx context.Context, taskPath string) (string, error) {
+	_, updSrv, err := r.GetUpdateService(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "GetUpdateService")
+	}
+	urlPath, _ := updSrv.GetString("Actions", "#UpdateService.StartUpdate", "target")
+	if len(urlPath) == 0 {
+		return taskPath, nil
+	}
+	hdr, resp, err := r.Post(ctx, urlPath, jsonutils.NewDict())
+	if err != nil {
+		return "", errors.Wrap(err, "Actions/UpdateService.StartUpdate")
+	}
+	if path := r.ParseTaskPath(hdr, resp); len(path) > 0 {
+		return path, nil
+	}
+	return taskPath, nil
+}
+
+func (r *SSupermicroRefishApi) SimpleUpdateFirmware(ctx context.Context, imageUri string, targets []string, applyOnReset bool) (string, error) {
+	taskPath, err := r.SGenericRefishApi.SimpleUpdateFirmware(ctx, imageUri, targets, applyOnReset)
+	if err != nil {
+		return "", err
+	}
+	return r.startUpdate(ctx, taskPath)
+}
+
+func (r *SSupermicroRefishApi) PushFirmware(ctx context.Context, filename string, image io.Reader, targets []string, applyOnReset bool) (string, error) {
+	taskPath, err := r.SGenericRefishApi.PushFirmware(ctx, filename, image, targets, applyOnReset)
+	if err != nil {
+		return "", err
+	}
+	return r.startUpdate(ctx, taskPath)
+}
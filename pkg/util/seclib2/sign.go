@@ -0,0 +1,128 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package seclib2
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+// LoadOrCreateRSAKey loads the rsa private key in PEM format from path, a new
+// key is generated and saved if the file does not exist
+func LoadOrCreateRSAKey(path string) (*rsa.PrivateKey, error) {
+	cont, err := ioutil.ReadFile(path)
+	if err == nil {
+		if block, _ := pem.Decode(cont); block == nil {
+			return nil, errors.Wrapf(httperrors.ErrInvalidFormat, "no PEM data in %s", path)
+		}
+		return DecodePrivateKey(cont)
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "read %s", path)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, errors.Wrap(err, "generate rsa key")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Wrapf(err, "mkdir for %s", path)
+	}
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		return nil, errors.Wrapf(err, "write %s", path)
+	}
+	return key, nil
+}
+
+// RSAPublicKeyPEM exports public key of the rsa key in PKIX PEM format
+func RSAPublicKeyPEM(key *rsa.PrivateKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", errors.Wrap(err, "MarshalPKIXPublicKey")
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// SignSHA256 signs data with RSASSA-PKCS1-v1_5 over SHA256 and returns the base64 encoded signature
+func SignSHA256(key *rsa.PrivateKey, data []byte) (string, error) {
+	hashed := sha256.Sum256(data)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", errors.Wrap(err, "SignPKCS1v15")
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// VerifySHA256 verifies base64 encoded signature made by SignSHA256 with the PEM encoded public key
+func VerifySHA256(publicKeyPEM string, data []byte, signature string) error {
+	hashed := sha256.Sum256(data)
+	return VerifySHA256Digest(publicKeyPEM, hashed[:], signature)
+}
+
+// VerifySHA256Digest verifies base64 encoded RSASSA-PKCS1-v1_5 signature of the SHA256 digest,
+// e.g. made by `openssl dgst -sha256 -sign`, so large files need not be loaded into memory
+func VerifySHA256Digest(publicKeyPEM string, digest []byte, signature string) error {
+	rsaPub, err := decodeRSAPublicKey(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return errors.Wrap(err, "decode signature")
+	}
+	return rsa.VerifyPKCS1v15(rsaPub, crypto.SHA256, digest, sig)
+}
+
+// PublicKeyFingerprint returns hex encoded SHA256 of the DER bytes of the PEM encoded rsa public key
+func PublicKeyFingerprint(publicKeyPEM string) (string, error) {
+	rsaPub, err := decodeRSAPublicKey(publicKeyPEM)
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKIXPublicKey(rsaPub)
+	if err != nil {
+		return "", errors.Wrap(err, "MarshalPKIXPublicKey")
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(der)), nil
+}
+
+func decodeRSAPublicKey(publicKeyPEM string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, errors.Wrap(httperrors.ErrInvalidFormat, "no PEM data in public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParsePKIXPublicKey")
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.Wrap(httperrors.ErrInvalidFormat, "not a rsa public key")
+	}
+	return rsaPub, nil
+}
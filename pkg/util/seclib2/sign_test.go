@@ -0,0 +1,68 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package seclib2
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignSHA256(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seclib2")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "identity.key")
+	key, err := LoadOrCreateRSAKey(path)
+	if err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	loaded, err := LoadOrCreateRSAKey(path)
+	if err != nil {
+		t.Fatalf("load key: %v", err)
+	}
+	if loaded.N.Cmp(key.N) != 0 {
+		t.Fatalf("loaded key differs from the created one")
+	}
+
+	pub, err := RSAPublicKeyPEM(key)
+	if err != nil {
+		t.Fatalf("RSAPublicKeyPEM: %v", err)
+	}
+	data := []byte(`{"instance_id":"a2c3"}`)
+	sig, err := SignSHA256(loaded, data)
+	if err != nil {
+		t.Fatalf("SignSHA256: %v", err)
+	}
+	if err := VerifySHA256(pub, data, sig); err != nil {
+		t.Errorf("verify signature: %v", err)
+	}
+	if err := VerifySHA256(pub, []byte(`{"instance_id":"forged"}`), sig); err == nil {
+		t.Errorf("forged document verified")
+	}
+	digest := sha256.Sum256(data)
+	if err := VerifySHA256Digest(pub, digest[:], sig); err != nil {
+		t.Errorf("verify signature of digest: %v", err)
+	}
+	fp, err := PublicKeyFingerprint(pub)
+	if err != nil || len(fp) != 64 {
+		t.Errorf("PublicKeyFingerprint: %q %v", fp, err)
+	}
+}